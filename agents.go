@@ -0,0 +1,418 @@
+// agents: a persisted registry of productive tmux panes as named agents.
+//
+// every snapshot tick the registry is reconciled against the live panes:
+// each productive pane maps to an agent keyed by a session+pane
+// fingerprint, new fingerprints register a new agent, and agents whose
+// pane disappeared are marked gone. the registry accumulates what the
+// raw panes can't tell you on their own — when an agent started, how
+// long it has actually been working, and the history of its status
+// transitions — so views, stats, and the API read one table instead of
+// re-deriving everything from panes each refresh.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// agentSchema holds the registry and its status transition log. lives in
+// the snapshot db alongside annotations.
+const agentSchema = `
+CREATE TABLE IF NOT EXISTS agents (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	project TEXT NOT NULL DEFAULT '',
+	command TEXT NOT NULL DEFAULT '',
+	session_name TEXT NOT NULL,
+	window_index INTEGER NOT NULL,
+	pane_index INTEGER NOT NULL,
+	pane_pid INTEGER NOT NULL,
+	started_at TEXT NOT NULL,
+	last_seen_at TEXT NOT NULL,
+	last_activity_ms INTEGER NOT NULL DEFAULT 0,
+	active_ms INTEGER NOT NULL DEFAULT 0,
+	status TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_agents_status
+	ON agents (status);
+
+CREATE TABLE IF NOT EXISTS agent_status_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	agent_id TEXT NOT NULL REFERENCES agents(id),
+	status TEXT NOT NULL,
+	at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_status_events_agent_id
+	ON agent_status_events (agent_id, at);
+`
+
+// agent statuses. working means the pane produced output recently; idle
+// means it's alive but quiet; seen is idle after its terminal window has
+// been focused, so someone has looked at it; gone means the pane no
+// longer exists.
+const (
+	agentWorking = "working"
+	agentIdle    = "idle"
+	agentSeen    = "seen"
+	agentGone    = "gone"
+)
+
+// agentWorkingWindow is how recent pane activity must be for an agent to
+// count as working. matches the green staleness tier.
+const agentWorkingWindow = time.Minute
+
+// agentMaxActiveCredit caps how much active time one reconcile can add.
+// a long gap between ticks (sleep, serve restart) shouldn't be credited
+// as continuous work.
+const agentMaxActiveCredit = 2 * time.Minute
+
+// Agent is one registered agent identity.
+type Agent struct {
+	ID           string
+	Name         string
+	Project      string
+	Command      string
+	SessionName  string
+	WindowIndex  int
+	PaneIndex    int
+	PanePID      int
+	StartedAt    time.Time
+	LastSeenAt   time.Time
+	LastActivity time.Time
+	ActiveTime   time.Duration
+	Status       string
+}
+
+// agentStatusEvent is one status transition in an agent's history.
+type agentStatusEvent struct {
+	AgentID string
+	Status  string
+	At      time.Time
+}
+
+// agentFingerprint identifies the agent living in a pane. the pane pid is
+// part of the key so a pane that's killed and recreated at the same
+// session:window.pane address registers as a new agent.
+func agentFingerprint(p TmuxPane) string {
+	return fmt.Sprintf("%s:%d.%d@%d", p.SessionName, p.WindowIndex, p.PaneIndex, p.PanePID)
+}
+
+// defaultAgentName is the name an agent gets until someone renames it:
+// "session/command", with the window.pane suffix only when a session hosts
+// more than one agent.
+func defaultAgentName(p TmuxPane, sharedSession bool) string {
+	name := p.SessionName + "/" + p.CurrentCommand
+	if sharedSession {
+		name += fmt.Sprintf(" %d.%d", p.WindowIndex, p.PaneIndex)
+	}
+	return name
+}
+
+// agentStatusFor derives a live agent's status from its pane activity.
+func agentStatusFor(p TmuxPane, now time.Time) string {
+	if now.Sub(p.LastActivity) < agentWorkingWindow {
+		return agentWorking
+	}
+	return agentIdle
+}
+
+// reconcileAgents folds the current productive panes into the known
+// agents. focused holds the sessions showing in the focused window: a
+// quiet agent there becomes seen, and stays seen until it works again.
+// returns every agent whose row needs writing plus any status
+// transitions. pure so the bookkeeping can be tested without a db.
+func reconcileAgents(
+	known map[string]Agent,
+	panes []TmuxPane,
+	productivePanePIDs map[int]bool,
+	focused map[string]bool,
+	now time.Time,
+) ([]Agent, []agentStatusEvent) {
+	// count agents per session first so default names only carry a
+	// window.pane suffix when they need disambiguating
+	perSession := map[string]int{}
+	for _, p := range panes {
+		if productivePanePIDs[p.PanePID] {
+			perSession[p.SessionName]++
+		}
+	}
+
+	var updated []Agent
+	var events []agentStatusEvent
+	seen := map[string]bool{}
+	for _, p := range panes {
+		if !productivePanePIDs[p.PanePID] {
+			continue
+		}
+		id := agentFingerprint(p)
+		seen[id] = true
+		status := agentStatusFor(p, now)
+
+		a, ok := known[id]
+		if status == agentIdle && (focused[p.SessionName] || (ok && a.Status == agentSeen)) {
+			status = agentSeen
+		}
+		if !ok {
+			a = Agent{
+				ID:        id,
+				Name:      defaultAgentName(p, perSession[p.SessionName] > 1),
+				StartedAt: now,
+			}
+			events = append(events, agentStatusEvent{AgentID: id, Status: status, At: now})
+		} else {
+			if status == agentWorking {
+				credit := now.Sub(a.LastSeenAt)
+				if credit > agentMaxActiveCredit {
+					credit = agentMaxActiveCredit
+				}
+				if credit > 0 {
+					a.ActiveTime += credit
+				}
+			}
+			if a.Status != status {
+				events = append(events, agentStatusEvent{AgentID: id, Status: status, At: now})
+			}
+		}
+
+		a.Project = filepath.Base(p.CurrentPath)
+		a.Command = p.CurrentCommand
+		a.SessionName = p.SessionName
+		a.WindowIndex = p.WindowIndex
+		a.PaneIndex = p.PaneIndex
+		a.PanePID = p.PanePID
+		a.LastSeenAt = now
+		a.LastActivity = p.LastActivity
+		a.Status = status
+		updated = append(updated, a)
+	}
+
+	// anything known but not seen this tick has lost its pane
+	for id, a := range known {
+		if seen[id] || a.Status == agentGone {
+			continue
+		}
+		a.Status = agentGone
+		updated = append(updated, a)
+		events = append(events, agentStatusEvent{AgentID: id, Status: agentGone, At: now})
+	}
+
+	sort.Slice(updated, func(i, j int) bool { return updated[i].ID < updated[j].ID })
+	return updated, events
+}
+
+// loadAgents reads registered agents. when liveOnly is set, gone agents
+// are skipped — that's the set reconcile needs each tick.
+func loadAgents(db *sql.DB, liveOnly bool) ([]Agent, error) {
+	query := "SELECT id, name, project, command, session_name, window_index, pane_index, pane_pid, started_at, last_seen_at, last_activity_ms, active_ms, status FROM agents"
+	if liveOnly {
+		query += " WHERE status != '" + agentGone + "'"
+	}
+	query += " ORDER BY started_at"
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("querying agents: %w", err)
+	}
+	defer rows.Close()
+
+	var agents []Agent
+	for rows.Next() {
+		var a Agent
+		var startedAt, lastSeenAt string
+		var lastActivityMS, activeMS int64
+		if err := rows.Scan(&a.ID, &a.Name, &a.Project, &a.Command, &a.SessionName,
+			&a.WindowIndex, &a.PaneIndex, &a.PanePID, &startedAt, &lastSeenAt,
+			&lastActivityMS, &activeMS, &a.Status); err != nil {
+			continue
+		}
+		a.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+		a.LastSeenAt, _ = time.Parse(time.RFC3339, lastSeenAt)
+		a.LastActivity = time.UnixMilli(lastActivityMS)
+		a.ActiveTime = time.Duration(activeMS) * time.Millisecond
+		agents = append(agents, a)
+	}
+	return agents, rows.Err()
+}
+
+// syncAgentRegistry reconciles the registry against one fetch and writes
+// the changes atomically. called from the snapshot loop.
+func syncAgentRegistry(db *sql.DB, result fetchResult, now time.Time) error {
+	live, err := loadAgents(db, true)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]Agent, len(live))
+	for _, a := range live {
+		known[a.ID] = a
+	}
+	focused := focusedSessions(result.tmuxClients, result.processTree, result.windows)
+	updated, events := reconcileAgents(known, result.tmuxPanes, result.productivePanePIDs, focused, now)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning agent transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, a := range updated {
+		// name is only written on insert so renames survive reconciles
+		_, err := tx.Exec(
+			`INSERT INTO agents (id, name, project, command, session_name, window_index, pane_index, pane_pid, started_at, last_seen_at, last_activity_ms, active_ms, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET project = excluded.project, command = excluded.command,
+				last_seen_at = excluded.last_seen_at, last_activity_ms = excluded.last_activity_ms,
+				active_ms = excluded.active_ms, status = excluded.status`,
+			a.ID, a.Name, a.Project, a.Command, a.SessionName, a.WindowIndex, a.PaneIndex, a.PanePID,
+			a.StartedAt.UTC().Format(time.RFC3339), a.LastSeenAt.UTC().Format(time.RFC3339),
+			a.LastActivity.UnixMilli(), a.ActiveTime.Milliseconds(), a.Status,
+		)
+		if err != nil {
+			return fmt.Errorf("upserting agent %s: %w", a.ID, err)
+		}
+	}
+	for _, ev := range events {
+		_, err := tx.Exec(
+			"INSERT INTO agent_status_events (agent_id, status, at) VALUES (?, ?, ?)",
+			ev.AgentID, ev.Status, ev.At.UTC().Format(time.RFC3339),
+		)
+		if err != nil {
+			return fmt.Errorf("inserting agent status event: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// focusedSessions returns the tmux sessions whose client runs in the
+// focused terminal window. a terminal that owns several windows (kitty)
+// has to title the focused one after the session, as in
+// engine.MapTmuxClients.
+func focusedSessions(clients []TmuxClient, processTree map[int]int, windows []Window) map[string]bool {
+	var focus *Window
+	perPID := map[int]int{}
+	for i, w := range windows {
+		if !engine.IsTerminal(w.App) {
+			continue
+		}
+		perPID[w.PID]++
+		if w.HasFocus {
+			focus = &windows[i]
+		}
+	}
+	if focus == nil {
+		return nil
+	}
+	focused := map[string]bool{}
+	for _, c := range clients {
+		pid := c.PID
+		for depth := 0; depth < 20 && pid != focus.PID; depth++ {
+			ppid, ok := processTree[pid]
+			if !ok || ppid <= 1 {
+				break
+			}
+			pid = ppid
+		}
+		if pid == focus.PID && (perPID[pid] == 1 || focus.Title == c.SessionName) {
+			focused[c.SessionName] = true
+		}
+	}
+	return focused
+}
+
+// sortAgentsByPriority orders agents by their session's priority, highest
+// first, keeping registration order within a level.
+func sortAgentsByPriority(agents []Agent, priorities prioritySet) {
+	sort.SliceStable(agents, func(i, j int) bool {
+		return priorities.forSession(agents[i].SessionName) > priorities.forSession(agents[j].SessionName)
+	})
+}
+
+// renameAgent sets a human name on a registered agent.
+func renameAgent(db *sql.DB, id, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("agent name is required")
+	}
+	res, err := db.Exec("UPDATE agents SET name = ? WHERE id = ?", name, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("no agent with id %q", id)
+	}
+	return nil
+}
+
+// handleAgents returns the registry as JSON. gone agents are included
+// only with ?all=1 since the phone cares about what's running now.
+func handleAgents(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		agents, err := loadAgents(db, r.URL.Query().Get("all") != "1")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		priorities, _ := loadPriorities(db)
+		sortAgentsByPriority(agents, priorities)
+		var out []map[string]any
+		for _, a := range agents {
+			out = append(out, map[string]any{
+				"id":               a.ID,
+				"name":             a.Name,
+				"project":          a.Project,
+				"command":          a.Command,
+				"session_name":     a.SessionName,
+				"window_index":     a.WindowIndex,
+				"pane_index":       a.PaneIndex,
+				"started_at":       a.StartedAt.UTC().Format(time.RFC3339),
+				"last_activity_ms": a.LastActivity.UnixMilli(),
+				"active_ms":        a.ActiveTime.Milliseconds(),
+				"status":           a.Status,
+				"priority":         priorities.forSession(a.SessionName).String(),
+			})
+		}
+		writeJSON(w, out)
+	}
+}
+
+// agentsCommand is the entry point for `stop agents`. with no args it
+// lists the registry; `stop agents rename <id> <name>` renames one.
+func agentsCommand(args []string, all bool) error {
+	db, err := openSnapshotDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if len(args) > 0 {
+		if args[0] != "rename" || len(args) < 3 {
+			return fmt.Errorf("usage: stop agents [--all] | stop agents rename <id> <name>")
+		}
+		return renameAgent(db, args[1], strings.Join(args[2:], " "))
+	}
+
+	agents, err := loadAgents(db, !all)
+	if err != nil {
+		return err
+	}
+	if len(agents) == 0 {
+		fmt.Println("no agents registered.")
+		return nil
+	}
+	priorities, _ := loadPriorities(db)
+	sortAgentsByPriority(agents, priorities)
+	for _, a := range agents {
+		fmt.Printf("%-24s  %-8s  %-6s  %-12s  active %-8s  since %s  %s\n",
+			a.Name, a.Status, priorities.forSession(a.SessionName), a.Project, humanDuration(a.ActiveTime),
+			a.StartedAt.Local().Format("01-02 15:04"), a.ID)
+	}
+	return nil
+}
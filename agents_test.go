@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconcileAgents(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	panes := []TmuxPane{
+		{SessionName: "api", WindowIndex: 1, PaneIndex: 0, PanePID: 100, CurrentCommand: "claude", CurrentPath: "/src/api", LastActivity: now.Add(-10 * time.Second)},
+		{SessionName: "api", WindowIndex: 1, PaneIndex: 1, PanePID: 101, CurrentCommand: "zsh", LastActivity: now},
+	}
+	productive := map[int]bool{100: true}
+
+	updated, events := reconcileAgents(nil, panes, productive, nil, now)
+	if len(updated) != 1 || len(events) != 1 {
+		t.Fatalf("first reconcile: %d agents, %d events", len(updated), len(events))
+	}
+	a := updated[0]
+	if a.Name != "api/claude" || a.Project != "api" || a.Status != agentWorking {
+		t.Fatalf("unexpected new agent: %+v", a)
+	}
+
+	// 30s later, still working: active time credited, no transition
+	later := now.Add(30 * time.Second)
+	panes[0].LastActivity = later
+	updated, events = reconcileAgents(map[string]Agent{a.ID: a}, panes, productive, nil, later)
+	if len(events) != 0 || updated[0].ActiveTime != 30*time.Second {
+		t.Fatalf("second reconcile: events=%v active=%s", events, updated[0].ActiveTime)
+	}
+	a = updated[0]
+
+	// an hour later, quiet: credit capped, transitions to idle
+	muchLater := later.Add(time.Hour)
+	updated, events = reconcileAgents(map[string]Agent{a.ID: a}, panes, productive, nil, muchLater)
+	if len(events) != 1 || events[0].Status != agentIdle || updated[0].ActiveTime != 30*time.Second {
+		t.Fatalf("idle reconcile: events=%v active=%s", events, updated[0].ActiveTime)
+	}
+	a = updated[0]
+
+	// its window gets focused: seen, and it stays seen after focus moves on
+	updated, events = reconcileAgents(map[string]Agent{a.ID: a}, panes, productive, map[string]bool{"api": true}, muchLater)
+	if len(events) != 1 || events[0].Status != agentSeen {
+		t.Fatalf("focus reconcile: events=%v", events)
+	}
+	a = updated[0]
+	updated, events = reconcileAgents(map[string]Agent{a.ID: a}, panes, productive, nil, muchLater)
+	if len(events) != 0 || updated[0].Status != agentSeen {
+		t.Fatalf("unfocused reconcile: events=%v status=%s", events, updated[0].Status)
+	}
+	a = updated[0]
+
+	// pane gone
+	updated, events = reconcileAgents(map[string]Agent{a.ID: a}, nil, productive, nil, muchLater)
+	if len(updated) != 1 || updated[0].Status != agentGone || len(events) != 1 {
+		t.Fatalf("gone reconcile: %+v %v", updated, events)
+	}
+}
+
+func TestFocusedSessions(t *testing.T) {
+	windows := []Window{
+		{ID: 1, PID: 10, App: "Alacritty", HasFocus: true},
+		{ID: 2, PID: 20, App: "kitty", Title: "notes"},
+		{ID: 3, PID: 20, App: "kitty", Title: "api"},
+	}
+	clients := []TmuxClient{
+		{PID: 300, SessionName: "work"},
+		{PID: 400, SessionName: "api"},
+	}
+	tree := map[int]int{300: 30, 30: 10, 10: 1, 400: 20, 20: 1}
+	if got := focusedSessions(clients, tree, windows); !got["work"] || got["api"] {
+		t.Errorf("focused = %v, want only work", got)
+	}
+
+	// kitty owns two windows, so the focused one has to be titled after the session
+	windows[0].HasFocus, windows[2].HasFocus = false, true
+	if got := focusedSessions(clients, tree, windows); got["work"] || !got["api"] {
+		t.Errorf("focused = %v, want only api", got)
+	}
+}
@@ -0,0 +1,369 @@
+// agents_view: a flat list of every live agent, sliced by a filter.
+//
+// the spaces grid answers "what's on this monitor"; with dozens of agents
+// across several displays the more useful question is often "which claude
+// sessions have gone cold" or "everything in this project". a toggles the
+// agents view, / opens a builder that composes the filter from predicates
+// (fields and values are cycled from what's actually live, so there's
+// nothing to type), and v picks from filters saved by name.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// filterBuilder edits a draft filter; nothing applies until enter.
+// naming is set while the user types a name to save the draft under.
+type filterBuilder struct {
+	draft  agentFilter
+	sel    int
+	naming bool
+	name   string
+}
+
+// viewPicker is the saved-views dropdown. entry 0 is "(all agents)".
+type viewPicker struct {
+	views []savedView
+	sel   int
+}
+
+// agentViewsMsg carries saved views loaded off the update loop.
+type agentViewsMsg struct {
+	views []savedView
+	err   error
+}
+
+func loadAgentViewsCmd() tea.Msg {
+	db := sharedStateDB()
+	if db == nil {
+		return agentViewsMsg{err: fmt.Errorf("state db unavailable")}
+	}
+	views, err := loadAgentViews(db)
+	return agentViewsMsg{views: views, err: err}
+}
+
+func saveAgentViewCmd(name string, f agentFilter) tea.Cmd {
+	return func() tea.Msg {
+		status := fmt.Sprintf("save view %q", name)
+		db := sharedStateDB()
+		if db == nil {
+			return actionDoneMsg{status: status + " failed", err: fmt.Errorf("state db unavailable")}
+		}
+		if err := saveAgentView(db, name, f); err != nil {
+			return actionDoneMsg{status: status + " failed", err: err}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("saved view %q", name)}
+	}
+}
+
+// agentRows lists live agents, unfiltered.
+func (m model) agentRows() []agentRow {
+	return buildAgentRows(m.tmuxPanes, m.productivePanePIDs, m.tmuxByDisplay, m.displayGroups)
+}
+
+// visibleAgents lists live agents passing the active filter.
+func (m model) visibleAgents() []agentRow {
+	var rows []agentRow
+	for _, r := range m.agentRows() {
+		if m.agentFilter.matches(r) {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// cycle steps through values by delta, wrapping, starting from cur's
+// position (or the start when cur isn't among them).
+func cycle(values []string, cur string, delta int) string {
+	if len(values) == 0 {
+		return cur
+	}
+	i := -1
+	for j, v := range values {
+		if strings.EqualFold(v, cur) {
+			i = j
+		}
+	}
+	if i < 0 {
+		if delta < 0 {
+			return values[len(values)-1]
+		}
+		return values[0]
+	}
+	return values[(i+delta+len(values))%len(values)]
+}
+
+// handleNamingKey takes raw text for a view name. it runs ahead of every
+// other binding so q and friends can be typed.
+func (m model) handleNamingKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := *m.builder
+	switch msg.Type {
+	case tea.KeyEnter:
+		name := strings.TrimSpace(b.name)
+		if name == "" {
+			return m, nil
+		}
+		m.agentFilter = b.draft
+		m.builder, m.agentCursor = nil, 0
+		return m, saveAgentViewCmd(name, b.draft)
+	case tea.KeyEsc:
+		b.naming, b.name = false, ""
+	case tea.KeyBackspace:
+		if r := []rune(b.name); len(r) > 0 {
+			b.name = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		b.name += string(msg.Runes)
+	}
+	m.builder = &b
+	return m, nil
+}
+
+// handleAgentsKey routes keys while the agents view is showing.
+func (m model) handleAgentsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.builder != nil {
+		return m.handleBuilderKey(msg)
+	}
+	if m.picker != nil {
+		return m.handlePickerKey(msg)
+	}
+
+	switch action := activeKeymap.action(msg.String()); {
+	case action == actAgents || msg.String() == "esc":
+		m.showAgents = false
+	case action == actDown:
+		if m.agentCursor < len(m.visibleAgents())-1 {
+			m.agentCursor++
+		}
+	case action == actUp:
+		if m.agentCursor > 0 {
+			m.agentCursor--
+		}
+	case msg.String() == "/":
+		draft := agentFilter{Op: m.agentFilter.Op, Preds: append([]agentPredicate(nil), m.agentFilter.Preds...)}
+		if draft.Op == "" {
+			draft.Op = "and"
+		}
+		m.builder = &filterBuilder{draft: draft}
+	case msg.String() == "v":
+		return m, loadAgentViewsCmd
+	}
+	return m, nil
+}
+
+func (m model) handleBuilderKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := *m.builder
+	b.draft.Preds = append([]agentPredicate(nil), b.draft.Preds...)
+	live := m.agentRows()
+
+	switch msg.String() {
+	case "esc":
+		m.builder = nil
+		return m, nil
+	case "enter":
+		m.agentFilter = b.draft
+		m.builder, m.agentCursor = nil, 0
+		return m, nil
+	case "j", "down":
+		if b.sel < len(b.draft.Preds)-1 {
+			b.sel++
+		}
+	case "k", "up":
+		if b.sel > 0 {
+			b.sel--
+		}
+	case "n":
+		field := filterFields[0]
+		b.draft.Preds = append(b.draft.Preds, agentPredicate{Field: field, Value: cycle(fieldValues(live, field), "", 1)})
+		b.sel = len(b.draft.Preds) - 1
+	case "x", "d":
+		if b.sel < len(b.draft.Preds) {
+			b.draft.Preds = append(b.draft.Preds[:b.sel], b.draft.Preds[b.sel+1:]...)
+			if b.sel > 0 && b.sel >= len(b.draft.Preds) {
+				b.sel--
+			}
+		}
+	case "tab":
+		if b.sel < len(b.draft.Preds) {
+			p := &b.draft.Preds[b.sel]
+			p.Field = cycle(filterFields, p.Field, 1)
+			p.Value = cycle(fieldValues(live, p.Field), "", 1)
+		}
+	case "h", "left", "l", "right":
+		if b.sel < len(b.draft.Preds) {
+			delta := 1
+			if s := msg.String(); s == "h" || s == "left" {
+				delta = -1
+			}
+			p := &b.draft.Preds[b.sel]
+			p.Value = cycle(fieldValues(live, p.Field), p.Value, delta)
+		}
+	case "o":
+		if b.draft.Op == "or" {
+			b.draft.Op = "and"
+		} else {
+			b.draft.Op = "or"
+		}
+	case "s":
+		b.naming = true
+	}
+	m.builder = &b
+	return m, nil
+}
+
+func (m model) handlePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := *m.picker
+	switch msg.String() {
+	case "esc", "v":
+		m.picker = nil
+		return m, nil
+	case "j", "down":
+		if p.sel < len(p.views) {
+			p.sel++
+		}
+	case "k", "up":
+		if p.sel > 0 {
+			p.sel--
+		}
+	case "enter":
+		m.agentFilter = agentFilter{}
+		m.status = ""
+		if p.sel > 0 {
+			m.agentFilter = p.views[p.sel-1].Filter
+			m.status = "view: " + p.views[p.sel-1].Name
+		}
+		m.picker, m.agentCursor = nil, 0
+		return m, nil
+	}
+	m.picker = &p
+	return m, nil
+}
+
+// -- rendering --
+
+// renderAgentsView replaces the spaces grid while the agents view is up.
+func (m model) renderAgentsView(width int) string {
+	all := m.agentRows()
+	var shown []agentRow
+	for _, r := range all {
+		if m.agentFilter.matches(r) {
+			shown = append(shown, r)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(displayStyle.Render("agents"))
+	b.WriteString(dimStyle.Render(fmt.Sprintf("  %d of %d  ", len(shown), len(all))))
+	b.WriteString(annotationStyle.Render(m.agentFilter.String()))
+	b.WriteString("\n\n")
+
+	if len(shown) == 0 {
+		b.WriteString(dimStyle.Render("  no agents match"))
+		b.WriteString("\n")
+	}
+	for i, r := range shown {
+		cursor := "  "
+		if i == m.agentCursor {
+			cursor = cursorStyle.Render("> ")
+		}
+		name := fmt.Sprintf("%s:%d.%d", r.pane.SessionName, r.pane.WindowIndex, r.pane.PaneIndex)
+		line := fmt.Sprintf("%-24s %-10s %-20s %-12s ",
+			truncateStr(name, 24), truncateStr(r.pane.CurrentCommand, 10),
+			truncateStr(r.project, 20), truncateStr(r.display, 12))
+		b.WriteString(cursor)
+		b.WriteString(truncateForWidth(line, maxInt(width-12, 10)))
+		b.WriteString(stalenessStyle(r.pane.LastActivity).Render(r.tier))
+		b.WriteString(dimStyle.Render(" " + formatRelativeTime(r.pane.LastActivity)))
+		b.WriteString("\n")
+	}
+
+	if m.builder != nil {
+		b.WriteString("\n")
+		b.WriteString(m.renderFilterBuilder())
+	}
+	if m.picker != nil {
+		b.WriteString("\n")
+		b.WriteString(m.renderViewPicker())
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (m model) renderFilterBuilder() string {
+	bld := m.builder
+	var b strings.Builder
+	op := strings.ToUpper(bld.draft.Op)
+	b.WriteString(displayStyle.Render("filter") + dimStyle.Render("  match ") + keyStyle.Render(op) + "\n")
+	if len(bld.draft.Preds) == 0 {
+		b.WriteString(dimStyle.Render("  (no predicates, matches all)") + "\n")
+	}
+	for i, p := range bld.draft.Preds {
+		cursor := "  "
+		if i == bld.sel {
+			cursor = cursorStyle.Render("> ")
+		}
+		join := "    "
+		if i > 0 {
+			join = fmt.Sprintf("%-4s", bld.draft.Op)
+		}
+		b.WriteString(cursor + dimStyle.Render(join) + keyStyle.Render(p.Field) + dimStyle.Render(" = ") + p.Value + "\n")
+	}
+	if bld.naming {
+		b.WriteString(warnStyle.Render("save as: ") + bld.name + cursorStyle.Render("_") + "\n")
+	}
+	return b.String()
+}
+
+func (m model) renderViewPicker() string {
+	var b strings.Builder
+	b.WriteString(displayStyle.Render("saved views") + "\n")
+	names := []string{"(all agents)"}
+	for _, v := range m.picker.views {
+		names = append(names, v.Name)
+	}
+	for i, name := range names {
+		cursor := "  "
+		style := lipgloss.NewStyle()
+		if i == m.picker.sel {
+			cursor = cursorStyle.Render("> ")
+			style = keyStyle
+		}
+		b.WriteString(cursor + style.Render(name))
+		if i > 0 {
+			b.WriteString(dimStyle.Render("  " + m.picker.views[i-1].Filter.String()))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderAgentsHelp is the help line while the agents view is up; it
+// follows whichever sub-mode has the keys.
+func (m model) renderAgentsHelp() string {
+	var binds []struct{ key, desc string }
+	switch {
+	case m.builder != nil && m.builder.naming:
+		binds = []struct{ key, desc string }{{"enter", "save"}, {"esc", "back"}}
+	case m.builder != nil:
+		binds = []struct{ key, desc string }{
+			{"n", "add"}, {"x", "remove"}, {"tab", "field"}, {"h/l", "value"},
+			{"o", "and/or"}, {"s", "save as"}, {"enter", "apply"}, {"esc", "cancel"},
+		}
+	case m.picker != nil:
+		binds = []struct{ key, desc string }{{"j/k", "select"}, {"enter", "apply"}, {"esc", "close"}}
+	default:
+		binds = []struct{ key, desc string }{
+			{activeKeymap.label(actQuit), "quit"}, {activeKeymap.label(actDown, actUp), "navigate"},
+			{"/", "filter"}, {"v", "views"}, {activeKeymap.label(actAgents), "spaces"},
+		}
+	}
+	var parts []string
+	for _, b := range binds {
+		parts = append(parts, keyStyle.Render(b.key)+" "+helpStyle.Render(b.desc))
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,117 @@
+// agentstate: what an agent pane is doing, read off its screen.
+//
+// staleness says how long a pane has been quiet, not why. an agent that
+// stopped to ask "Do you want to proceed?" and one wedged on an API error
+// look the same from pane_activity. so every fetch captures the last
+// agentStateTailLines of each agent pane (claude, opencode, codex and the
+// rest of productiveProcesses) and runs agentStateRules (config.go) over
+// them: the first rule for the pane's tool whose pattern matches names
+// the state — awaiting input, working, or errored — and the pane gets a
+// badge next to its command. a pane no rule matches gets none.
+
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// agentStateTailLines is how much of the screen the rules see.
+const agentStateTailLines = 15
+
+// paneState is an agent pane's state as its screen shows it.
+type paneState string
+
+const (
+	paneAwaiting paneState = "awaiting input"
+	paneWorking  paneState = "working"
+	paneErrored  paneState = "errored"
+)
+
+// agentStateRule names a state when pattern matches the tail of a pane
+// running tool (any agent tool when empty).
+type agentStateRule struct {
+	state   paneState
+	tool    string
+	pattern *regexp.Regexp
+}
+
+// classifyAgentTail runs the rules over a tool's last lines, in order.
+func classifyAgentTail(tool string, lines []string, rules []agentStateRule) paneState {
+	tail := strings.Join(lines, "\n")
+	for _, r := range rules {
+		if (r.tool == "" || r.tool == tool) && r.pattern.MatchString(tail) {
+			return r.state
+		}
+	}
+	return ""
+}
+
+// agentToolOf is the agent a pane runs: its current command when that's
+// an agent, else the shallowest agent process under it.
+func agentToolOf(p TmuxPane, children map[int][]int, processComm map[int]string) string {
+	if isProductive(p.CurrentCommand) {
+		return p.CurrentCommand
+	}
+	for _, pid := range paneProcesses(p.PanePID, children) {
+		if comm := filepath.Base(processComm[pid]); isProductive(comm) {
+			return comm
+		}
+	}
+	return ""
+}
+
+// queryPaneStates classifies every agent pane, keyed by pane pid. panes
+// are captured in parallel; one that can't be captured is left out.
+func queryPaneStates(panes []TmuxPane, productive map[int]bool, processTree map[int]int, processComm map[int]string) map[int]paneState {
+	if len(agentStateRules) == 0 {
+		return nil
+	}
+	children := map[int][]int{}
+	for pid, ppid := range processTree {
+		children[ppid] = append(children[ppid], pid)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	result := map[int]paneState{}
+	for _, p := range panes {
+		if !productive[p.PanePID] && !isProductive(p.CurrentCommand) {
+			continue
+		}
+		tool := agentToolOf(p, children, processComm)
+		if tool == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(p TmuxPane, tool string) {
+			defer wg.Done()
+			lines := capturePaneTail(p, agentStateTailLines)
+			if len(lines) == 0 {
+				return
+			}
+			if state := classifyAgentTail(tool, lines, agentStateRules); state != "" {
+				mu.Lock()
+				result[p.PanePID] = state
+				mu.Unlock()
+			}
+		}(p, tool)
+	}
+	wg.Wait()
+	return result
+}
+
+// renderPaneState is a pane's state badge, or "".
+func renderPaneState(state paneState) string {
+	switch state {
+	case paneAwaiting:
+		return keyStyle.Render("◆ awaiting input")
+	case paneWorking:
+		return dimStyle.Render("● working")
+	case paneErrored:
+		return warnStyle.Render("✗ errored")
+	}
+	return ""
+}
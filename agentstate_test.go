@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestClassifyAgentTail(t *testing.T) {
+	cases := []struct {
+		tool  string
+		lines []string
+		want  paneState
+	}{
+		{"claude", []string{"⏺ Reading files…", "✻ Thinking… (12s · esc to interrupt)"}, paneWorking},
+		{"claude", []string{"Bash command", "Do you want to proceed?", "❯ 1. Yes", "  2. No"}, paneAwaiting},
+		{"claude", []string{"╭────╮", "│ >  │", "╰────╯", "  ? for shortcuts"}, paneAwaiting},
+		{"claude", []string{"API Error: 529 overloaded_error", "  ? for shortcuts"}, paneErrored},
+		{"codex", []string{"Allow command?", "  y / n"}, paneAwaiting},
+		{"opencode", []string{"working...  esc interrupt"}, paneWorking},
+		{"opencode", []string{"Do you want to"}, ""}, // claude's prompt, not opencode's
+		{"gemini", []string{"plain output"}, ""},
+	}
+	for _, c := range cases {
+		if got := classifyAgentTail(c.tool, c.lines, agentStateRules); got != c.want {
+			t.Errorf("%s %q: state = %q, want %q", c.tool, c.lines, got, c.want)
+		}
+	}
+}
+
+func TestAgentToolOf(t *testing.T) {
+	children := map[int][]int{100: {101}, 101: {102}}
+	comm := map[int]string{100: "zsh", 101: "node", 102: "/usr/local/bin/codex"}
+	if got := agentToolOf(TmuxPane{PanePID: 100, CurrentCommand: "zsh"}, children, comm); got != "codex" {
+		t.Errorf("nested tool = %q", got)
+	}
+	if got := agentToolOf(TmuxPane{PanePID: 200, CurrentCommand: "claude"}, nil, nil); got != "claude" {
+		t.Errorf("direct tool = %q", got)
+	}
+}
@@ -0,0 +1,426 @@
+// alerts: escalating notifications for agents left waiting.
+//
+// an alert rule picks which waiting agents matter and how hard to ask for
+// them: a chain of steps, each a channel that fires once the agent has
+// been waiting that long — a badge in the TUI first, then a desktop
+// notification, then a push to the phone. the chain is driven from the
+// snapshot loop in `stop serve` off the agent registry (agents.go), so it
+// keeps going with the dashboard closed. acknowledging an alert (A in the
+// TUI, POST /alerts/ack from the companion app) stops its chain; the
+// agent working again, or its window being focused, closes it. alerts
+// live in the snapshot db.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+const alertSchema = `
+CREATE TABLE IF NOT EXISTS alerts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	rule TEXT NOT NULL,
+	agent_id TEXT NOT NULL,
+	agent_name TEXT NOT NULL,
+	session_name TEXT NOT NULL,
+	waiting_since TEXT NOT NULL,
+	fired INTEGER NOT NULL DEFAULT 0,
+	acked_at TEXT NOT NULL DEFAULT '',
+	closed_at TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_closed_at
+	ON alerts (closed_at);
+`
+
+// alertChannel is where one escalation step is delivered.
+type alertChannel string
+
+const (
+	alertBadge   alertChannel = "badge"   // ⚑ on the space row in the TUI
+	alertDesktop alertChannel = "desktop" // notification center / notify-send
+	alertPush    alertChannel = "push"    // POST to alertPushURL
+)
+
+// alertStep fires its channel once an agent has waited after.
+type alertStep struct {
+	after   time.Duration
+	channel alertChannel
+}
+
+// alertRule matches waiting agents by command and session priority and
+// escalates through steps, which must be in increasing order of after.
+type alertRule struct {
+	name     string
+	command  string   // agent command to match; "" matches any
+	priority priority // lowest session priority the rule covers
+	steps    []alertStep
+}
+
+// matches reports whether an agent falls under the rule.
+func (r alertRule) matches(a Agent, priorities prioritySet) bool {
+	if r.command != "" && !strings.EqualFold(r.command, a.Command) {
+		return false
+	}
+	return priorities.forSession(a.SessionName) >= r.priority
+}
+
+// alert is one agent's wait under one rule. fired counts the steps
+// delivered so far.
+type alert struct {
+	ID           int64
+	Rule         string
+	AgentID      string
+	AgentName    string
+	SessionName  string
+	WaitingSince time.Time
+	Fired        int
+	Acked        bool
+}
+
+// alertNotice is one step to deliver.
+type alertNotice struct {
+	alert   alert
+	channel alertChannel
+}
+
+// alertPlan is what one evaluation changes: alerts to insert, alerts
+// whose fired count moved, alerts to close and steps to deliver.
+type alertPlan struct {
+	opened  []alert
+	updated []alert
+	closed  []int64
+	notices []alertNotice
+}
+
+// planAlerts advances every chain against the live registry. an agent is
+// waiting while it's idle — working again or seen (its window focused)
+// closes its alerts. pure so escalation can be tested without a db.
+func planAlerts(open []alert, agents []Agent, priorities prioritySet, rules []alertRule, now time.Time) alertPlan {
+	rulesByName := map[string]alertRule{}
+	for _, r := range rules {
+		rulesByName[r.name] = r
+	}
+	waiting := map[string]Agent{}
+	for _, a := range agents {
+		if a.Status == agentIdle {
+			waiting[a.ID] = a
+		}
+	}
+
+	// a chain that fell behind (serve was down, or the agent was already
+	// waiting when the rule was added) catches up to its loudest due step
+	// without replaying the quieter ones
+	var plan alertPlan
+	advance := func(al *alert) bool {
+		if al.Acked {
+			return false
+		}
+		steps := rulesByName[al.Rule].steps
+		was := al.Fired
+		for al.Fired < len(steps) && now.Sub(al.WaitingSince) >= steps[al.Fired].after {
+			al.Fired++
+		}
+		if al.Fired == was {
+			return false
+		}
+		plan.notices = append(plan.notices, alertNotice{*al, steps[al.Fired-1].channel})
+		return true
+	}
+
+	have := map[string]bool{} // rule + agent id of alerts still open
+	for _, al := range open {
+		rule, ok := rulesByName[al.Rule]
+		a, isWaiting := waiting[al.AgentID]
+		if !ok || !isWaiting || !rule.matches(a, priorities) {
+			plan.closed = append(plan.closed, al.ID)
+			continue
+		}
+		have[al.Rule+"\x00"+al.AgentID] = true
+		if advance(&al) {
+			plan.updated = append(plan.updated, al)
+		}
+	}
+
+	for _, r := range rules {
+		for _, a := range agents {
+			if _, ok := waiting[a.ID]; !ok || have[r.name+"\x00"+a.ID] || !r.matches(a, priorities) {
+				continue
+			}
+			al := alert{
+				Rule: r.name, AgentID: a.ID, AgentName: a.Name,
+				SessionName: a.SessionName, WaitingSince: a.LastActivity,
+			}
+			advance(&al)
+			plan.opened = append(plan.opened, al)
+		}
+	}
+	return plan
+}
+
+// loadOpenAlerts reads alerts that haven't closed, oldest first.
+func loadOpenAlerts(db *sql.DB) ([]alert, error) {
+	rows, err := db.Query(`SELECT id, rule, agent_id, agent_name, session_name, waiting_since, fired, acked_at
+		FROM alerts WHERE closed_at = '' ORDER BY waiting_since, id`)
+	if err != nil {
+		return nil, fmt.Errorf("querying alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []alert
+	for rows.Next() {
+		var al alert
+		var since, ackedAt string
+		if err := rows.Scan(&al.ID, &al.Rule, &al.AgentID, &al.AgentName, &al.SessionName, &since, &al.Fired, &ackedAt); err != nil {
+			continue
+		}
+		al.WaitingSince, _ = time.Parse(time.RFC3339, since)
+		al.Acked = ackedAt != ""
+		alerts = append(alerts, al)
+	}
+	return alerts, rows.Err()
+}
+
+// applyAlertPlan writes a plan atomically.
+func applyAlertPlan(db *sql.DB, plan alertPlan, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning alert transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stamp := now.UTC().Format(time.RFC3339)
+	for _, al := range plan.opened {
+		if _, err := tx.Exec(
+			"INSERT INTO alerts (rule, agent_id, agent_name, session_name, waiting_since, fired) VALUES (?, ?, ?, ?, ?, ?)",
+			al.Rule, al.AgentID, al.AgentName, al.SessionName, al.WaitingSince.UTC().Format(time.RFC3339), al.Fired,
+		); err != nil {
+			return fmt.Errorf("inserting alert: %w", err)
+		}
+	}
+	for _, al := range plan.updated {
+		if _, err := tx.Exec("UPDATE alerts SET fired = ? WHERE id = ?", al.Fired, al.ID); err != nil {
+			return fmt.Errorf("updating alert %d: %w", al.ID, err)
+		}
+	}
+	for _, id := range plan.closed {
+		if _, err := tx.Exec("UPDATE alerts SET closed_at = ? WHERE id = ?", stamp, id); err != nil {
+			return fmt.Errorf("closing alert %d: %w", id, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// runAlerts escalates every open chain against the registry and delivers
+// the steps that came due. a step that fails to deliver still counts as
+// fired, so a broken channel doesn't retry every tick; the failures are
+// returned together.
+func runAlerts(db *sql.DB, now time.Time) error {
+	open, err := loadOpenAlerts(db)
+	if err != nil {
+		return err
+	}
+	agents, err := loadAgents(db, true)
+	if err != nil {
+		return err
+	}
+	priorities, _ := loadPriorities(db)
+	plan := planAlerts(open, agents, priorities, alertRules, now)
+	if err := applyAlertPlan(db, plan, now); err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, n := range plan.notices {
+		if err := deliverAlert(n, now); err != nil && !errors.Is(err, engine.ErrReadOnly) {
+			failed = append(failed, fmt.Sprintf("%s %s: %v", n.channel, n.alert.AgentName, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("delivering alerts: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// alertMessage is the notification text for an alert.
+func alertMessage(al alert, now time.Time) string {
+	return fmt.Sprintf("%s has been waiting %s", al.AgentName, humanDuration(now.Sub(al.WaitingSince)))
+}
+
+// deliverAlert sends one step. badges need no delivery: the TUI reads
+// open alerts on every refresh.
+func deliverAlert(n alertNotice, now time.Time) error {
+	msg := alertMessage(n.alert, now)
+	switch n.channel {
+	case alertDesktop:
+		return notifyDesktop("stop: "+n.alert.SessionName, msg)
+	case alertPush:
+		if alertPushURL == "" {
+			return fmt.Errorf("alertPushURL isn't set")
+		}
+		if err := engine.Guard("push", alertPushURL); err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPost, alertPushURL, strings.NewReader(msg))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Title", "stop: "+n.alert.SessionName)
+		resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("push returned %s", resp.Status)
+		}
+	}
+	return nil
+}
+
+// notifyDesktop shows a desktop notification: Notification Center on
+// macOS, notify-send elsewhere — or the terminal's own, with
+// terminalNotify set and a terminal to write to (termnotify.go).
+func notifyDesktop(title, msg string) error {
+	if err := engine.Guard("notify", msg); err != nil {
+		return err
+	}
+	if terminalNotify != "" && notifyTerminal(terminalNotify, title, msg) == nil {
+		return nil
+	}
+	if runtime.GOOS == "darwin" {
+		script := fmt.Sprintf("display notification %q with title %q", msg, title)
+		return exec.Command("osascript", "-e", script).Run()
+	}
+	return exec.Command("notify-send", title, msg).Run()
+}
+
+// ackAlerts acknowledges open alerts by id, or all of a session's when id
+// is 0. returns how many were acknowledged.
+func ackAlerts(db *sql.DB, id int64, session string, now time.Time) (int, error) {
+	if id == 0 && session == "" {
+		return 0, fmt.Errorf("an alert id or session is required")
+	}
+	query := "UPDATE alerts SET acked_at = ? WHERE closed_at = '' AND acked_at = '' AND "
+	arg := any(id)
+	if id != 0 {
+		query += "id = ?"
+	} else {
+		query += "session_name = ?"
+		arg = session
+	}
+	res, err := db.Exec(query, now.UTC().Format(time.RFC3339), arg)
+	if err != nil {
+		return 0, fmt.Errorf("acknowledging alerts: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// alertSet is the unacknowledged alerts that have fired at least once,
+// by session. a nil set is valid and has none.
+type alertSet map[string][]alert
+
+// queryAlerts returns the alerts the TUI badges, or nil when the db is
+// unavailable.
+func queryAlerts() alertSet {
+	db := sharedStateDB()
+	if db == nil {
+		return nil
+	}
+	open, err := loadOpenAlerts(db)
+	if err != nil {
+		return nil
+	}
+	set := alertSet{}
+	for _, al := range open {
+		if !al.Acked && al.Fired > 0 {
+			set[al.SessionName] = append(set[al.SessionName], al)
+		}
+	}
+	return set
+}
+
+// ackAlertsCmd acknowledges every alert on the given sessions.
+func ackAlertsCmd(sessions []string) tea.Cmd {
+	return func() tea.Msg {
+		db := sharedStateDB()
+		if db == nil {
+			return actionDoneMsg{status: "ack failed", err: fmt.Errorf("state db unavailable")}
+		}
+		total := 0
+		for _, s := range sessions {
+			n, err := ackAlerts(db, 0, s, time.Now())
+			if err != nil {
+				return actionDoneMsg{status: "ack failed", err: err}
+			}
+			total += n
+		}
+		return actionDoneMsg{status: fmt.Sprintf("acknowledged %d alert(s)", total)}
+	}
+}
+
+// handleAlerts lists open alerts.
+func handleAlerts(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		open, err := loadOpenAlerts(db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		out := []map[string]any{}
+		for _, al := range open {
+			out = append(out, map[string]any{
+				"id":            al.ID,
+				"rule":          al.Rule,
+				"agent_id":      al.AgentID,
+				"agent_name":    al.AgentName,
+				"session_name":  al.SessionName,
+				"waiting_since": al.WaitingSince.UTC().Format(time.RFC3339),
+				"fired":         al.Fired,
+				"acked":         al.Acked,
+			})
+		}
+		writeJSON(w, out)
+	}
+}
+
+// alertAckRequest is the POST /alerts/ack body: one alert by id, or all
+// of a session's.
+type alertAckRequest struct {
+	ID      int64  `json:"id"`
+	Session string `json:"session"`
+}
+
+// handleAlertAck acknowledges alerts.
+func handleAlertAck(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req alertAckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		n, err := ackAlerts(db, req.ID, req.Session, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]any{"acknowledged": n})
+	}
+}
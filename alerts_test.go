@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestPlanAlertsEscalation(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	rules := []alertRule{{name: "waiting", steps: []alertStep{
+		{0, alertBadge}, {10 * time.Minute, alertDesktop}, {30 * time.Minute, alertPush},
+	}}}
+	agent := Agent{ID: "a", Name: "api/claude", SessionName: "api", Status: agentIdle, LastActivity: now.Add(-time.Minute)}
+	quiet := Agent{ID: "b", Name: "notes/claude", SessionName: "notes", Status: agentIdle, LastActivity: now}
+	priorities := prioritySet{targetSession: {"notes": priorityLow}}
+
+	// a new wait opens an alert and badges it; low priority doesn't alert
+	plan := planAlerts(nil, []Agent{agent, quiet}, priorities, rules, now)
+	if len(plan.opened) != 1 || plan.opened[0].AgentID != "a" || plan.opened[0].Fired != 1 {
+		t.Fatalf("opened = %+v", plan.opened)
+	}
+	if len(plan.notices) != 1 || plan.notices[0].channel != alertBadge {
+		t.Fatalf("notices = %+v", plan.notices)
+	}
+	open := plan.opened
+	open[0].ID = 1
+
+	// ten minutes on: desktop
+	plan = planAlerts(open, []Agent{agent}, priorities, rules, now.Add(10*time.Minute))
+	if len(plan.updated) != 1 || len(plan.notices) != 1 || plan.notices[0].channel != alertDesktop {
+		t.Fatalf("desktop step: %+v", plan)
+	}
+
+	// an hour on with nothing delivered since the badge: only the push
+	plan = planAlerts(open, []Agent{agent}, priorities, rules, now.Add(time.Hour))
+	if len(plan.notices) != 1 || plan.notices[0].channel != alertPush || plan.updated[0].Fired != 3 {
+		t.Fatalf("catch-up: %+v", plan)
+	}
+
+	// acknowledged: the chain stops but the alert stays open
+	acked := []alert{open[0]}
+	acked[0].Acked = true
+	plan = planAlerts(acked, []Agent{agent}, priorities, rules, now.Add(time.Hour))
+	if len(plan.notices) != 0 || len(plan.closed) != 0 || len(plan.opened) != 0 {
+		t.Fatalf("acked: %+v", plan)
+	}
+
+	// seen (or working again) closes it
+	seen := agent
+	seen.Status = agentSeen
+	plan = planAlerts(open, []Agent{seen}, priorities, rules, now.Add(time.Hour))
+	if len(plan.closed) != 1 || plan.closed[0] != 1 || len(plan.notices) != 0 {
+		t.Fatalf("seen: %+v", plan)
+	}
+}
+
+func TestAckAlerts(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(alertSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	plan := alertPlan{opened: []alert{
+		{Rule: "waiting", AgentID: "a", AgentName: "api/claude", SessionName: "api", WaitingSince: now, Fired: 1},
+		{Rule: "waiting", AgentID: "b", AgentName: "web/claude", SessionName: "web", WaitingSince: now, Fired: 1},
+	}}
+	if err := applyAlertPlan(db, plan, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := ackAlerts(db, 0, "api", now); err != nil || n != 1 {
+		t.Fatalf("ack by session: %d %v", n, err)
+	}
+	if n, _ := ackAlerts(db, 0, "api", now); n != 0 {
+		t.Errorf("re-ack acknowledged %d", n)
+	}
+	if _, err := ackAlerts(db, 0, "", now); err == nil {
+		t.Error("ack with no target should fail")
+	}
+
+	open, err := loadOpenAlerts(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 2 || !open[0].Acked || open[1].Acked {
+		t.Fatalf("open = %+v", open)
+	}
+	if err := applyAlertPlan(db, alertPlan{closed: []int64{open[1].ID}}, now); err != nil {
+		t.Fatal(err)
+	}
+	if open, _ = loadOpenAlerts(db); len(open) != 1 {
+		t.Errorf("closed alert still open: %+v", open)
+	}
+}
+
+func TestPushRefusedReadOnly(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer srv.Close()
+	defer func(url string) { alertPushURL = url }(alertPushURL)
+	alertPushURL = srv.URL
+	defer engine.SetReadOnly(false)
+
+	n := alertNotice{alert: alert{SessionName: "api", AgentName: "claude"}, channel: alertPush}
+	engine.SetReadOnly(true)
+	if err := deliverAlert(n, time.Now()); !errors.Is(err, engine.ErrReadOnly) || hits != 0 {
+		t.Errorf("read-only push: err %v, %d request(s)", err, hits)
+	}
+	engine.SetReadOnly(false)
+	if err := deliverAlert(n, time.Now()); err != nil || hits != 1 {
+		t.Errorf("push: err %v, %d request(s)", err, hits)
+	}
+}
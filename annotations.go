@@ -0,0 +1,216 @@
+// annotations: short status strings attached to tmux sessions or spaces.
+//
+// a lightweight channel from agents (or any script) back to whoever is
+// watching the dashboard. writers either POST /annotations to `stop serve`
+// or run `stop annotate --session foo "awaiting review"`; both land in the
+// annotations table of the snapshot db, which the TUI and /spaces read on
+// every refresh. one annotation per target — writing again replaces it,
+// writing empty text clears it.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// annotationSchema lives alongside the snapshot tables so there's one db
+// file to back up, but it's mutable state rather than history.
+const annotationSchema = `
+CREATE TABLE IF NOT EXISTS annotations (
+	target_kind TEXT NOT NULL,
+	target TEXT NOT NULL,
+	text TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (target_kind, target)
+);
+`
+
+// target kinds for per-session / per-space state (annotations, priorities).
+// a session target is a tmux session name; a space target is a yabai space
+// label or absolute index.
+const (
+	targetSession = "session"
+	targetSpace   = "space"
+)
+
+// Annotation is one status string attached to a session or space.
+type Annotation struct {
+	Kind      string    `json:"kind"`
+	Target    string    `json:"target"`
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// annotationSet indexes annotations by kind then target for render-time
+// lookups. a nil set is valid and finds nothing.
+type annotationSet map[string]map[string]Annotation
+
+// forSession returns the annotation attached to a tmux session name.
+func (s annotationSet) forSession(name string) (Annotation, bool) {
+	a, ok := s[targetSession][name]
+	return a, ok
+}
+
+// forSpace returns the annotation attached to a space, matching either its
+// label or its absolute yabai index. label wins when both are set.
+func (s annotationSet) forSpace(space Space) (Annotation, bool) {
+	if space.Label != "" {
+		if a, ok := s[targetSpace][space.Label]; ok {
+			return a, true
+		}
+	}
+	a, ok := s[targetSpace][strconv.Itoa(space.Index)]
+	return a, ok
+}
+
+// setAnnotation upserts (or, for empty text, deletes) the annotation for
+// a target.
+func setAnnotation(db *sql.DB, kind, target, text string) error {
+	if kind != targetSession && kind != targetSpace {
+		return fmt.Errorf("unknown annotation kind %q", kind)
+	}
+	if target == "" {
+		return fmt.Errorf("annotation target is required")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		_, err := db.Exec("DELETE FROM annotations WHERE target_kind = ? AND target = ?", kind, target)
+		return err
+	}
+	_, err := db.Exec(
+		"INSERT INTO annotations (target_kind, target, text, updated_at) VALUES (?, ?, ?, ?) ON CONFLICT (target_kind, target) DO UPDATE SET text = excluded.text, updated_at = excluded.updated_at",
+		kind, target, text, time.Now().UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// loadAnnotations reads every annotation into an annotationSet.
+func loadAnnotations(db *sql.DB) (annotationSet, error) {
+	rows, err := db.Query("SELECT target_kind, target, text, updated_at FROM annotations")
+	if err != nil {
+		return nil, fmt.Errorf("querying annotations: %w", err)
+	}
+	defer rows.Close()
+
+	set := annotationSet{}
+	for rows.Next() {
+		var a Annotation
+		var updatedAt string
+		if err := rows.Scan(&a.Kind, &a.Target, &a.Text, &updatedAt); err != nil {
+			continue
+		}
+		a.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+		if set[a.Kind] == nil {
+			set[a.Kind] = map[string]Annotation{}
+		}
+		set[a.Kind][a.Target] = a
+	}
+	return set, rows.Err()
+}
+
+// stateDB is the process-wide handle fetchAll uses for mutable state
+// (annotations, priorities). opened lazily so the TUI only touches the db
+// once something asks for it, and left nil (that state silently absent)
+// when the db can't be opened.
+var (
+	stateDBOnce sync.Once
+	stateDB     *sql.DB
+)
+
+// sharedStateDB returns the lazily opened state handle, or nil.
+func sharedStateDB() *sql.DB {
+	stateDBOnce.Do(func() {
+		stateDB, _ = openSnapshotDB()
+	})
+	return stateDB
+}
+
+// queryAnnotations returns the current annotations, or nil when the db is
+// unavailable. best-effort like the other non-yabai queries.
+func queryAnnotations() annotationSet {
+	db := sharedStateDB()
+	if db == nil {
+		return nil
+	}
+	set, err := loadAnnotations(db)
+	if err != nil {
+		return nil
+	}
+	return set
+}
+
+// annotationRequest is the POST /annotations body. exactly one of Session
+// or Space names the target; empty Text clears it.
+type annotationRequest struct {
+	Session string `json:"session"`
+	Space   string `json:"space"`
+	Text    string `json:"text"`
+}
+
+// handleAnnotations serves GET (list all) and POST (set/clear one).
+func handleAnnotations(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			set, err := loadAnnotations(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			var list []Annotation
+			for _, byTarget := range set {
+				for _, a := range byTarget {
+					list = append(list, a)
+				}
+			}
+			writeJSON(w, list)
+		case http.MethodPost:
+			var req annotationRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			kind, target := targetSession, req.Session
+			if req.Space != "" {
+				kind, target = targetSpace, req.Space
+			}
+			if req.Session != "" && req.Space != "" {
+				http.Error(w, "set either session or space, not both", http.StatusBadRequest)
+				return
+			}
+			if err := setAnnotation(db, kind, target, req.Text); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// annotateCommand is the entry point for `stop annotate`. writes straight
+// to the db so it works whether or not `stop serve` is running.
+func annotateCommand(session, space, text string) error {
+	if (session == "") == (space == "") {
+		return fmt.Errorf("exactly one of --session or --space is required")
+	}
+	kind, target := targetSession, session
+	if space != "" {
+		kind, target = targetSpace, space
+	}
+	db, err := openSnapshotDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return setAnnotation(db, kind, target, text)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestAnnotationsRoundTrip(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(annotationSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setAnnotation(db, targetSession, "api", "awaiting review"); err != nil {
+		t.Fatal(err)
+	}
+	if err := setAnnotation(db, targetSpace, "7", "deploying"); err != nil {
+		t.Fatal(err)
+	}
+	// second write replaces rather than duplicating
+	if err := setAnnotation(db, targetSession, "api", "  tests green  "); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := loadAnnotations(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, ok := set.forSession("api"); !ok || a.Text != "tests green" {
+		t.Fatalf("session annotation = %+v, %v", a, ok)
+	}
+	if a, ok := set.forSpace(Space{Index: 7}); !ok || a.Text != "deploying" {
+		t.Fatalf("space-by-index annotation = %+v, %v", a, ok)
+	}
+	if _, ok := set.forSpace(Space{Index: 8}); ok {
+		t.Fatal("unexpected annotation on space 8")
+	}
+
+	// empty text clears
+	if err := setAnnotation(db, targetSession, "api", ""); err != nil {
+		t.Fatal(err)
+	}
+	set, _ = loadAnnotations(db)
+	if _, ok := set.forSession("api"); ok {
+		t.Fatal("annotation should have been cleared")
+	}
+
+	if err := setAnnotation(db, "window", "x", "nope"); err == nil {
+		t.Fatal("expected error for unknown kind")
+	}
+}
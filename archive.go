@@ -0,0 +1,248 @@
+// archive: journal what was on a space or session before stop removes it.
+//
+// destroying a space (D) or killing its tmux sessions (X) from the TUI is
+// fast and easy to regret. before either runs, stop writes a journal
+// entry to the snapshot db describing what's about to disappear — the
+// windows, the sessions with their windows and panes, and the last lines
+// each pane showed — so an accidental cleanup can be reconstructed with
+// `stop journal`. if the entry can't be written the action is refused.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+const journalSchema = `
+CREATE TABLE IF NOT EXISTS journal (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	at TEXT NOT NULL,
+	action TEXT NOT NULL,
+	target TEXT NOT NULL,
+	body TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_journal_at
+	ON journal (at);
+`
+
+// journal actions
+const (
+	journalDestroySpace = "destroy-space"
+	journalKillSession  = "kill-session"
+)
+
+// archiveTailLines is how much of each pane's screen an entry keeps.
+const archiveTailLines = 20
+
+// journalEntry is one archived cleanup.
+type journalEntry struct {
+	ID     int64
+	At     time.Time
+	Action string
+	Target string
+	Body   string
+}
+
+// capturePaneTail returns the last n non-empty lines visible in a pane.
+// empty on any failure — the archive is best-effort per pane.
+func capturePaneTail(p TmuxPane, n int) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	target := fmt.Sprintf("%s:%d.%d", p.SessionName, p.WindowIndex, p.PaneIndex)
+	out, err := exec.CommandContext(ctx, "tmux", "capture-pane", "-p", "-J", "-t", target).Output()
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	var kept []string
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			kept = append(kept, strings.TrimRight(l, " "))
+		}
+	}
+	if len(kept) > n {
+		kept = kept[len(kept)-n:]
+	}
+	return kept
+}
+
+// sessionsOnSpace lists the tmux sessions shown by terminal windows on a
+// space, matched by window title the same way the view inlines them.
+func sessionsOnSpace(row spaceRow, panes []TmuxPane) []string {
+	known := map[string]bool{}
+	for _, p := range panes {
+		known[p.SessionName] = true
+	}
+	var sessions []string
+	seen := map[string]bool{}
+	for _, w := range row.windows {
+		name := strings.TrimSpace(w.Title)
+		if engine.IsTerminal(w.App) && known[name] && !seen[name] {
+			seen[name] = true
+			sessions = append(sessions, name)
+		}
+	}
+	return sessions
+}
+
+// formatSessionArchive describes a session's windows and panes, with
+// each pane's recent output as returned by tail.
+func formatSessionArchive(b *strings.Builder, session string, panes []TmuxPane, tail func(TmuxPane) []string) {
+	var own []TmuxPane
+	for _, p := range panes {
+		if p.SessionName == session {
+			own = append(own, p)
+		}
+	}
+	fmt.Fprintf(b, "session %s\n", session)
+	for _, win := range groupPanesByWindow(own) {
+		fmt.Fprintf(b, "  window %d:%s\n", win.index, win.name)
+		for _, p := range win.panes {
+			fmt.Fprintf(b, "    pane %d  %s  %s\n", p.PaneIndex, p.CurrentCommand, p.CurrentPath)
+			for _, line := range tail(p) {
+				fmt.Fprintf(b, "      | %s\n", line)
+			}
+		}
+	}
+}
+
+// formatSpaceArchive describes a space's windows and the sessions its
+// terminals were showing.
+func formatSpaceArchive(row spaceRow, panes []TmuxPane, tail func(TmuxPane) []string) string {
+	var b strings.Builder
+	label := ""
+	if row.space.Label != "" {
+		label = " [" + row.space.Label + "]"
+	}
+	fmt.Fprintf(&b, "space %d%s on display %d\n", row.space.Index, label, row.space.Display)
+	for _, w := range row.windows {
+		fmt.Fprintf(&b, "  window %s: %s\n", w.App, strings.TrimSpace(w.Title))
+	}
+	for _, s := range sessionsOnSpace(row, panes) {
+		formatSessionArchive(&b, s, panes, tail)
+	}
+	return b.String()
+}
+
+// appendJournal records one entry.
+func appendJournal(db *sql.DB, action, target, body string) error {
+	_, err := db.Exec(
+		"INSERT INTO journal (at, action, target, body) VALUES (?, ?, ?, ?)",
+		time.Now().UTC().Format(time.RFC3339), action, target, body,
+	)
+	return err
+}
+
+// loadJournal returns the most recent entries, newest first.
+func loadJournal(db *sql.DB, limit int) ([]journalEntry, error) {
+	rows, err := db.Query("SELECT id, at, action, target, body FROM journal ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying journal: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []journalEntry
+	for rows.Next() {
+		var e journalEntry
+		var at string
+		if err := rows.Scan(&e.ID, &at, &e.Action, &e.Target, &e.Body); err != nil {
+			continue
+		}
+		e.At, _ = time.Parse(time.RFC3339, at)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// archive writes the entry or explains why the action can't proceed.
+func archive(action, target, body string) error {
+	// nothing is journaled for an action read-only mode will refuse
+	if err := engine.Guard(action, target); err != nil {
+		return err
+	}
+	db := sharedStateDB()
+	if db == nil {
+		return fmt.Errorf("journal unavailable, refusing to %s", action)
+	}
+	if err := appendJournal(db, action, target, body); err != nil {
+		return fmt.Errorf("writing journal, refusing to %s: %w", action, err)
+	}
+	return nil
+}
+
+// killTmuxSession ends a tmux session and everything running in it.
+func killTmuxSession(name string) error {
+	if err := engine.Guard("tmux", "kill-session", "-t", "="+name); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return exec.CommandContext(ctx, "tmux", "kill-session", "-t", "="+name).Run()
+}
+
+// destroySpaceCmd journals a space and then destroys it.
+func destroySpaceCmd(row spaceRow, panes []TmuxPane) tea.Cmd {
+	return func() tea.Msg {
+		status := fmt.Sprintf("destroy space %d", row.space.Index)
+		body := formatSpaceArchive(row, panes, func(p TmuxPane) []string { return capturePaneTail(p, archiveTailLines) })
+		if err := archive(journalDestroySpace, fmt.Sprintf("%d", row.space.Index), body); err != nil {
+			return actionDoneMsg{status: status + " failed", err: err}
+		}
+		if err := currentWM().DestroySpace(row.space.Index); err != nil {
+			return actionDoneMsg{status: status + " failed", err: err}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("destroyed space %d (journaled)", row.space.Index)}
+	}
+}
+
+// killSessionsCmd journals each session and then kills it.
+func killSessionsCmd(sessions []string, panes []TmuxPane) tea.Cmd {
+	return func() tea.Msg {
+		tail := func(p TmuxPane) []string { return capturePaneTail(p, archiveTailLines) }
+		for _, s := range sessions {
+			var b strings.Builder
+			formatSessionArchive(&b, s, panes, tail)
+			if err := archive(journalKillSession, s, b.String()); err != nil {
+				return actionDoneMsg{status: "kill " + s + " failed", err: err}
+			}
+			if err := killTmuxSession(s); err != nil {
+				return actionDoneMsg{status: "kill " + s + " failed", err: err}
+			}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("killed %s (journaled)", strings.Join(sessions, ", "))}
+	}
+}
+
+// journalCommand is the entry point for `stop journal`: recent archived
+// cleanups, newest first.
+func journalCommand(limit int) error {
+	db, err := openSnapshotDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	entries, err := loadJournal(db, limit)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("journal is empty.")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Printf("== %s  %s %s\n", e.At.Local().Format("2006-01-02 15:04:05"), e.Action, e.Target)
+		fmt.Print(e.Body)
+		fmt.Println()
+	}
+	return nil
+}
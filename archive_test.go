@@ -0,0 +1,54 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestSpaceArchiveAndJournal(t *testing.T) {
+	row := spaceRow{
+		space: Space{Index: 4, Label: "api", Display: 2},
+		windows: []Window{
+			{App: "kitty", Title: "api"},
+			{App: "Firefox", Title: "docs"},
+		},
+	}
+	panes := []TmuxPane{
+		{SessionName: "api", WindowIndex: 1, WindowName: "main", PaneIndex: 0, CurrentCommand: "claude", CurrentPath: "/src/api"},
+		{SessionName: "other", WindowIndex: 1, WindowName: "x", CurrentCommand: "zsh"},
+	}
+	tail := func(p TmuxPane) []string { return []string{"$ go test", "ok"} }
+
+	body := formatSpaceArchive(row, panes, tail)
+	for _, want := range []string{"space 4 [api] on display 2", "window Firefox: docs", "session api", "pane 0  claude  /src/api", "| ok"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("archive missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "session other") {
+		t.Errorf("archive includes a session not on the space:\n%s", body)
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(journalSchema); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendJournal(db, journalDestroySpace, "4", body); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendJournal(db, journalKillSession, "api", "session api\n"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := loadJournal(db, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Action != journalKillSession || entries[1].Body != body {
+		t.Fatalf("unexpected journal: %+v", entries)
+	}
+}
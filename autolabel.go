@@ -0,0 +1,155 @@
+// autolabel: name spaces after the tmux sessions on them.
+//
+// stop already knows which session each terminal window shows; L hands
+// that to yabai by labeling every space with its session's name, so
+// `yabai -m space --focus api`, sketchybar and any other yabai tooling can
+// use it. with autoLabelSpaces on (config.go) the labels follow the
+// sessions on every refresh instead: a session moving to another space
+// takes its label along, and a space whose session left loses it.
+//
+// only labels stop could have set are touched — empty ones, ones naming
+// a live session, and ones it set itself this run — so hand-made labels
+// like "web" or "ml/train" stay. a space showing several sessions takes
+// the first; a session on several spaces labels the first of them, since
+// yabai labels must be unique. sessions named like a number or one of
+// yabai's selectors ("next", "recent", ...) can't be labels and are
+// skipped.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// yabaiSelectors are words a yabai space selector reads as something
+// other than a label.
+var yabaiSelectors = map[string]bool{
+	"prev": true, "next": true, "first": true, "last": true, "recent": true, "mouse": true,
+}
+
+// spaceLabel is one planned relabel.
+type spaceLabel struct {
+	index    int
+	from, to string
+}
+
+// spaceLabeler remembers the labels stop set, so it can take them back.
+// the model holds it by pointer; commands update it as they apply.
+type spaceLabeler struct {
+	ours  map[string]bool
+	tried string // the last plan auto mode ran, not rerun when it fails
+}
+
+// labelable reports whether a session name can be a yabai label.
+func labelable(session string) bool {
+	if session == "" || yabaiSelectors[session] || strings.ContainsAny(session, " \t") {
+		return false
+	}
+	_, err := strconv.Atoi(session)
+	return err != nil
+}
+
+// planSpaceLabels lists the spaces whose label should change, in column
+// order.
+func planSpaceLabels(groups []displayGroup, panes []TmuxPane, ours map[string]bool) []spaceLabel {
+	sessions := map[string]bool{}
+	for _, p := range panes {
+		sessions[p.SessionName] = true
+	}
+	owned := func(label string) bool { return label == "" || sessions[label] || ours[label] }
+
+	var plan []spaceLabel
+	claimed := map[string]bool{}
+	for _, dg := range groups {
+		for _, row := range dg.spaces {
+			if !owned(row.space.Label) {
+				continue
+			}
+			want := ""
+			for _, s := range sessionsOnSpace(row, panes) {
+				if labelable(s) && !claimed[s] {
+					want = s
+					break
+				}
+			}
+			claimed[want] = true
+			if want != row.space.Label {
+				plan = append(plan, spaceLabel{index: row.space.Index, from: row.space.Label, to: want})
+			}
+		}
+	}
+	return plan
+}
+
+// planKey identifies a plan, so auto mode doesn't retry one that failed.
+func planKey(plan []spaceLabel) string {
+	parts := make([]string, len(plan))
+	for i, l := range plan {
+		parts[i] = fmt.Sprintf("%d:%s>%s", l.index, l.from, l.to)
+	}
+	return strings.Join(parts, " ")
+}
+
+// summarizeLabels is the plan in words, for the confirm prompt.
+func summarizeLabels(plan []spaceLabel) string {
+	parts := make([]string, len(plan))
+	for i, l := range plan {
+		to := l.to
+		if to == "" {
+			to = "(none)"
+		}
+		parts[i] = fmt.Sprintf("%d → %s", l.index, to)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// labelSpacesCmd applies a plan: the old labels come off first, so a
+// label moving between spaces is never on two at once.
+func labelSpacesCmd(plan []spaceLabel, labeler *spaceLabeler) tea.Cmd {
+	for _, l := range plan {
+		if l.to != "" {
+			labeler.ours[l.to] = true
+		}
+	}
+	return func() tea.Msg {
+		wm := currentWM()
+		for _, l := range plan {
+			if l.from == "" {
+				continue
+			}
+			if err := wm.LabelSpace(l.index, ""); err != nil {
+				return actionDoneMsg{status: "labeling failed", err: fmt.Errorf("clearing space %d: %w", l.index, err)}
+			}
+		}
+		for _, l := range plan {
+			if l.to == "" {
+				continue
+			}
+			if err := wm.LabelSpace(l.index, l.to); err != nil {
+				return actionDoneMsg{status: "labeling failed", err: fmt.Errorf("labeling space %d %s: %w", l.index, l.to, err)}
+			}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("labeled %d spaces after their sessions", len(plan))}
+	}
+}
+
+// autoLabelCmd relabels after a refresh, with autoLabelSpaces on.
+func (m model) autoLabelCmd() tea.Cmd {
+	if !autoLabelSpaces || m.labeler == nil || m.wmErr != nil {
+		return nil
+	}
+	plan := planSpaceLabels(m.displayGroups, m.tmuxPanes, m.labeler.ours)
+	key := planKey(plan)
+	if key == m.labeler.tried {
+		return nil
+	}
+	m.labeler.tried = key
+	if len(plan) == 0 {
+		return nil
+	}
+	return labelSpacesCmd(plan, m.labeler)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestPlanSpaceLabels(t *testing.T) {
+	term := func(session string) Window { return Window{App: "kitty", Title: session} }
+	row := func(index int, label string, windows ...Window) spaceRow {
+		return spaceRow{space: Space{Index: index, Label: label}, windows: windows}
+	}
+	panes := []TmuxPane{{SessionName: "api"}, {SessionName: "web"}, {SessionName: "0"}, {SessionName: "docs"}}
+	groups := []displayGroup{{spaces: []spaceRow{
+		row(1, "", term("api")),               // unlabeled: takes its session
+		row(2, "web", term("web")),            // already right
+		row(3, "ml/train", term("docs")),      // hand-made label stays
+		row(4, "api", term("api")),            // api is taken by 1: cleared
+		row(5, "", term("0")),                 // tmux's default name can't be a label
+		row(6, "old", Window{App: "Safari"}),  // a label stop set, session gone
+		row(7, "", term("web"), term("docs")), // web is taken, docs is next
+		row(8, "", Window{App: "Slack"}),      // nothing to do
+	}}}
+	got := planSpaceLabels(groups, panes, map[string]bool{"old": true})
+	want := []spaceLabel{{1, "", "api"}, {4, "api", ""}, {6, "old", ""}, {7, "", "docs"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("plan = %v, want %v", got, want)
+	}
+}
+
+func TestLabelSpacesCmd(t *testing.T) {
+	fake := &engine.Fake{}
+	simulated = fake
+	defer func() { simulated = nil }()
+	wmOnce = sync.Once{}
+	defer func() { wmOnce = sync.Once{} }()
+
+	labeler := &spaceLabeler{ours: map[string]bool{}}
+	plan := []spaceLabel{{2, "api", "web"}, {5, "web", "api"}}
+	msg := labelSpacesCmd(plan, labeler)().(actionDoneMsg)
+	if msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	// both clear before either is set: a label is never on two spaces
+	want := []string{"label-space 2 ", "label-space 5 ", "label-space 2 web", "label-space 5 api"}
+	if got := fake.Calls(); !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+	if !labeler.ours["web"] || !labeler.ours["api"] {
+		t.Errorf("labels set aren't remembered: %v", labeler.ours)
+	}
+}
+
+func TestAutoLabelRetries(t *testing.T) {
+	autoLabelSpaces = true
+	defer func() { autoLabelSpaces = false }()
+	m := newModel()
+	m.tmuxPanes = []TmuxPane{{SessionName: "api"}}
+	m.displayGroups = []displayGroup{{spaces: []spaceRow{{space: Space{Index: 1}, windows: []Window{{App: "kitty", Title: "api"}}}}}}
+	if m.autoLabelCmd() == nil {
+		t.Fatal("no relabel for an unlabeled session space")
+	}
+	if m.autoLabelCmd() != nil {
+		t.Error("the same plan ran twice")
+	}
+	// once the labels match, the same plan can run again later
+	m.displayGroups[0].spaces[0].space.Label = "api"
+	m.autoLabelCmd()
+	m.displayGroups[0].spaces[0].space.Label = ""
+	if m.autoLabelCmd() == nil {
+		t.Error("a plan that came back didn't run")
+	}
+}
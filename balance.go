@@ -0,0 +1,139 @@
+// balance: suggest evening out busy spaces across displays.
+//
+// every column already counts its free spaces. when one display is
+// nearly full while another sits mostly empty, a line under the grid
+// says so and names the spaces it would move: the crowded display's
+// busy spaces that aren't on screen, last first, each onto a free space
+// of the roomiest display that isn't on screen either. B applies the
+// suggestion after a y/n. moving a space is moving its windows, planned
+// and rolled back like a swap (swap.go). the suggestion only appears
+// once the displays' free counts differ by balanceMinGap, so a desk
+// that's a space or two off doesn't nag.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// balanceMove sends a busy space's windows to a free space.
+type balanceMove struct {
+	from spaceRow // busy, on the crowded display
+	to   Space    // free, on the roomy display
+}
+
+// balancePlan is one suggestion: moves from the display with the fewest
+// free spaces to the one with the most.
+type balancePlan struct {
+	crowded, roomy displayGroup
+	moves          []balanceMove
+}
+
+// planBalance suggests moving half the free-space gap between the most
+// crowded and the roomiest display, when the gap is at least minGap.
+// spaces that are on screen aren't moved or moved onto.
+func planBalance(groups []displayGroup, minGap int) (balancePlan, bool) {
+	if len(groups) < 2 {
+		return balancePlan{}, false
+	}
+	crowded, roomy := groups[0], groups[0]
+	for _, g := range groups[1:] {
+		if g.freeCount < crowded.freeCount {
+			crowded = g
+		}
+		if g.freeCount > roomy.freeCount {
+			roomy = g
+		}
+	}
+	gap := roomy.freeCount - crowded.freeCount
+	if gap < minGap {
+		return balancePlan{}, false
+	}
+
+	var movable []spaceRow
+	for i := len(crowded.spaces) - 1; i >= 0; i-- {
+		row := crowded.spaces[i]
+		if len(row.windows) > 0 && !row.space.HasFocus && !row.space.IsVisible {
+			movable = append(movable, row)
+		}
+	}
+	var targets []Space
+	for _, row := range roomy.spaces {
+		if len(row.windows) == 0 && !row.space.IsVisible {
+			targets = append(targets, row.space)
+		}
+	}
+
+	plan := balancePlan{crowded: crowded, roomy: roomy}
+	for i := 0; i < gap/2 && i < len(movable) && i < len(targets); i++ {
+		plan.moves = append(plan.moves, balanceMove{from: movable[i], to: targets[i]})
+	}
+	return plan, len(plan.moves) > 0
+}
+
+// displayName is a group's display as the user knows it.
+func displayName(dg displayGroup) string {
+	if name := dg.display.ShortName(); name != "" {
+		return name
+	}
+	return fmt.Sprintf("display %d", dg.index)
+}
+
+// spaceSummaryName names a space by what's on it: its sessions (terminal
+// titles), else its label, else its first app.
+func spaceSummaryName(row spaceRow) string {
+	var sessions []string
+	for _, w := range row.windows {
+		if engine.IsTerminal(w.App) && strings.TrimSpace(w.Title) != "" {
+			sessions = append(sessions, strings.TrimSpace(w.Title))
+		}
+	}
+	switch {
+	case len(sessions) > 0:
+		return strings.Join(sessions, "+")
+	case row.space.Label != "":
+		return row.space.Label
+	case len(row.windows) > 0:
+		return row.windows[0].App
+	}
+	return fmt.Sprintf("space %d", row.space.Index)
+}
+
+// summary is the suggestion in words.
+func (p balancePlan) summary() string {
+	names := make([]string, len(p.moves))
+	for i, mv := range p.moves {
+		names[i] = spaceSummaryName(mv.from)
+	}
+	return fmt.Sprintf("%s has %d busy spaces, %s has %d free — move %s?",
+		displayName(p.crowded), len(p.crowded.spaces)-p.crowded.freeCount,
+		displayName(p.roomy), p.roomy.freeCount, strings.Join(names, ", "))
+}
+
+// renderBalanceLine is the suggestion under the grid, with its key.
+func renderBalanceLine(p balancePlan) string {
+	return dimStyle.Render("balance: "+p.summary()) + " " + keyStyle.Render(activeKeymap.label(actBalance))
+}
+
+// balanceCmd moves every planned space's windows in one batch, rolled
+// back as a whole if a move fails.
+func balanceCmd(p balancePlan) tea.Cmd {
+	return func() tea.Msg {
+		windows, err := currentWM().QueryWindows()
+		if err != nil {
+			return actionDoneMsg{status: "balance failed", err: fmt.Errorf("listing windows: %w", err)}
+		}
+		var moves []windowMove
+		for _, mv := range p.moves {
+			moves = append(moves, planSpaceSwap(mv.from.space.Index, mv.to.Index, windows)...)
+		}
+		if err := applyWindowMoves(moves, currentWM().MoveWindow); err != nil {
+			return actionDoneMsg{status: "balance failed", err: err}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("moved %d spaces to %s", len(p.moves), displayName(p.roomy))}
+	}
+}
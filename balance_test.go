@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlanBalance(t *testing.T) {
+	busy := func(index int, title string) spaceRow {
+		return spaceRow{space: Space{Index: index}, windows: []Window{{App: "kitty", Title: title}}}
+	}
+	free := func(index int) spaceRow { return spaceRow{space: Space{Index: index}} }
+
+	crowded := displayGroup{index: 1, spaces: []spaceRow{
+		busy(1, "notes"), busy(2, "api"), busy(3, "web"), busy(4, "ops"), free(5),
+	}, freeCount: 1}
+	crowded.spaces[0].space.IsVisible = true
+	roomy := displayGroup{index: 2, spaces: []spaceRow{
+		free(6), free(7), free(8), free(9), free(10), busy(11, "music"),
+	}, freeCount: 5}
+	roomy.spaces[0].space.IsVisible = true
+
+	plan, ok := planBalance([]displayGroup{crowded, roomy}, 4)
+	if !ok || len(plan.moves) != 2 {
+		t.Fatalf("plan = %+v", plan)
+	}
+	// the last busy spaces go to the first free ones that aren't on screen
+	if plan.moves[0].from.space.Index != 4 || plan.moves[0].to.Index != 7 || plan.moves[1].from.space.Index != 3 || plan.moves[1].to.Index != 8 {
+		t.Errorf("moves = %+v", plan.moves)
+	}
+	if s := plan.summary(); !strings.Contains(s, "display 1 has 4 busy spaces, display 2 has 5 free") || !strings.Contains(s, "move ops, web?") {
+		t.Errorf("summary = %q", s)
+	}
+
+	if _, ok := planBalance([]displayGroup{crowded, roomy}, 5); ok {
+		t.Error("suggested a move under the minimum gap")
+	}
+	if _, ok := planBalance([]displayGroup{crowded}, 1); ok {
+		t.Error("suggested a move with one display")
+	}
+}
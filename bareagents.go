@@ -0,0 +1,208 @@
+// bareagents: agents running straight in a terminal window, not in tmux.
+//
+// staleness coloring comes from tmux: a productive pane's last activity
+// colors its session's terminal window and the space it's on. claude
+// started directly in a kitty tab has no pane, so its window rendered as
+// plain as an idle shell. the process tree already reaches every
+// terminal's pid, so productive processes under a terminal with no tmux
+// pane in between are agents of that terminal's windows. with no pane
+// activity to read, their CPU time stands in for it: an agent whose CPU
+// grew by bareAgentActiveCPU since the last refresh was active just now,
+// and one seen for the first time was last active when it started.
+//
+// terminals like kitty run every window under one pid, so the window an
+// agent is in is a guess: the window whose title names it, then the one
+// whose iTerm2 session is running it (engine/iterm.go), then the
+// terminal's first window.
+
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// bareAgent is one productive process in a terminal window with no tmux
+// in between.
+type bareAgent struct {
+	PID        int
+	Command    string    // e.g. claude
+	LastActive time.Time // the last refresh its CPU grew, or its start
+}
+
+// bareSample is the CPU an agent had used at the last refresh, and when
+// it was last seen using more.
+type bareSample struct {
+	cpu    time.Duration
+	active time.Time
+}
+
+var (
+	bareMu      sync.Mutex
+	bareSamples = map[int]bareSample{}
+)
+
+// findBareAgents maps terminal window ids to the productive pids under
+// their terminal's process with no tmux pane on the way up. an agent's
+// own productive children (a subagent, a wrapped binary) are left to it.
+func findBareAgents(
+	windows []Window,
+	sessions map[int][]engine.TerminalSession,
+	panes []TmuxPane,
+	processTree map[int]int,
+	processComm map[int]string,
+) map[int][]int {
+	terminalWindows := map[int][]Window{}
+	for _, w := range windows {
+		if engine.IsTerminal(w.App) && w.PID > 0 {
+			terminalWindows[w.PID] = append(terminalWindows[w.PID], w)
+		}
+	}
+	if len(terminalWindows) == 0 {
+		return nil
+	}
+	panePIDs := make(map[int]bool, len(panes))
+	for _, p := range panes {
+		if p.PanePID > 0 {
+			panePIDs[p.PanePID] = true
+		}
+	}
+
+	pids := make([]int, 0, len(processComm))
+	for pid := range processComm {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+	found := map[int][]int{}
+	for _, pid := range pids {
+		command := filepath.Base(processComm[pid])
+		if !isProductive(command) {
+			continue
+		}
+		cur := pid
+		for depth := 0; depth < 50 && !panePIDs[cur]; depth++ {
+			ppid, ok := processTree[cur]
+			if !ok || ppid <= 1 || isProductive(filepath.Base(processComm[ppid])) {
+				break
+			}
+			if ws, ok := terminalWindows[ppid]; ok {
+				id := bareAgentWindow(ws, sessions, command)
+				found[id] = append(found[id], pid)
+				break
+			}
+			cur = ppid
+		}
+	}
+	return found
+}
+
+// bareAgentWindow picks which of a terminal's windows runs command.
+func bareAgentWindow(windows []Window, sessions map[int][]engine.TerminalSession, command string) int {
+	for _, w := range windows {
+		if strings.Contains(strings.ToLower(w.Title), command) {
+			return w.ID
+		}
+	}
+	for _, w := range windows {
+		for _, s := range sessions[w.ID] {
+			if filepath.Base(s.Job) == command {
+				return w.ID
+			}
+		}
+	}
+	return windows[0].ID
+}
+
+// queryBareAgents finds the agents running outside tmux and when each
+// was last active.
+func queryBareAgents(
+	windows []Window,
+	sessions map[int][]engine.TerminalSession,
+	panes []TmuxPane,
+	processTree map[int]int,
+	processComm map[int]string,
+	now time.Time,
+) map[int][]bareAgent {
+	found := findBareAgents(windows, sessions, panes, processTree, processComm)
+	var pids []int
+	for _, ps := range found {
+		pids = append(pids, ps...)
+	}
+	if len(pids) == 0 {
+		observeBareAgents(nil, now)
+		return nil
+	}
+	stats, err := engine.QueryProcessStats(pids, now)
+	if err != nil {
+		return nil
+	}
+	active := observeBareAgents(stats, now)
+	agents := map[int][]bareAgent{}
+	for id, ps := range found {
+		for _, pid := range ps {
+			if t, ok := active[pid]; ok {
+				agents[id] = append(agents[id], bareAgent{PID: pid, Command: filepath.Base(processComm[pid]), LastActive: t})
+			}
+		}
+	}
+	return agents
+}
+
+// observeBareAgents records this refresh's CPU times and returns each
+// pid's last activity. pids no longer running are forgotten.
+func observeBareAgents(stats map[int]engine.ProcessStat, now time.Time) map[int]time.Time {
+	bareMu.Lock()
+	defer bareMu.Unlock()
+	active := make(map[int]time.Time, len(stats))
+	next := make(map[int]bareSample, len(stats))
+	for pid, st := range stats {
+		sample, seen := bareSamples[pid]
+		switch {
+		case !seen:
+			sample = bareSample{cpu: st.CPU, active: st.Started}
+		case st.CPU-sample.cpu >= bareAgentActiveCPU:
+			sample = bareSample{cpu: st.CPU, active: now}
+		}
+		next[pid] = sample
+		active[pid] = sample.active
+	}
+	bareSamples = next
+	return active
+}
+
+// withBareAgents adds bare agents to the freshest productive activity by
+// terminal title, the way tmux sessions are keyed, keeping whichever of
+// a title's agents and sessions was active last.
+func withBareAgents(activity map[string]time.Time, windows []Window, agents map[int][]bareAgent) map[string]time.Time {
+	if len(agents) == 0 {
+		return activity
+	}
+	for _, w := range windows {
+		for _, a := range agents[w.ID] {
+			// rows look titles up as they are, window entries trimmed
+			for _, title := range []string{w.Title, strings.TrimSpace(w.Title)} {
+				if existing, ok := activity[title]; !ok || a.LastActive.After(existing) {
+					activity[title] = a.LastActive
+				}
+			}
+		}
+	}
+	return activity
+}
+
+// renderBareAgentLines lists a window's bare agents, colored by how long
+// each has been idle.
+func renderBareAgentLines(agents []bareAgent, prio priority, indent string) []string {
+	lines := make([]string, 0, len(agents))
+	for _, a := range agents {
+		line := indent + prio.stalenessStyle(a.LastActive).Render("▎ "+a.Command) +
+			dimStyle.Render("  idle "+humanDuration(time.Since(a.LastActive)))
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestFindBareAgents(t *testing.T) {
+	windows := []Window{
+		{ID: 1, PID: 100, App: "kitty", Title: "notes"},
+		{ID: 2, PID: 100, App: "kitty", Title: "✳ claude"},
+		{ID: 3, PID: 200, App: "Firefox", Title: "docs"},
+	}
+	// 300 is claude in a kitty shell, 302 its own claude child; 400 is
+	// claude in a tmux pane; 500 is codex under the browser
+	tree := map[int]int{300: 301, 301: 100, 302: 300, 400: 401, 401: 50, 500: 200}
+	comm := map[int]string{300: "/usr/local/bin/claude", 301: "zsh", 302: "claude", 400: "claude", 401: "zsh", 500: "codex"}
+	panes := []TmuxPane{{SessionName: "api", PanePID: 401}}
+	found := findBareAgents(windows, nil, panes, tree, comm)
+	if len(found) != 1 || len(found[2]) != 1 || found[2][0] != 300 {
+		t.Fatalf("found = %v", found)
+	}
+
+	// without a title naming it, the iTerm2 session running it decides
+	windows[1].Title = "work"
+	sessions := map[int][]engine.TerminalSession{2: {{TTY: "/dev/ttys004", Job: "claude"}}}
+	if found := findBareAgents(windows, sessions, panes, tree, comm); len(found[2]) != 1 {
+		t.Errorf("by session: %v", found)
+	}
+	if found := findBareAgents(windows, nil, panes, tree, comm); len(found[1]) != 1 {
+		t.Errorf("fallback: %v", found)
+	}
+}
+
+func TestBareAgentActivityFollowsCPU(t *testing.T) {
+	defer func(saved map[int]bareSample) { bareSamples = saved }(bareSamples)
+	bareSamples = map[int]bareSample{}
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-time.Hour)
+	// first sight: last active when it started
+	active := observeBareAgents(map[int]engine.ProcessStat{300: {Started: started, CPU: 5 * time.Second}}, now)
+	if !active[300].Equal(started) {
+		t.Fatalf("first sight = %v", active[300])
+	}
+	// idle ticking doesn't count
+	later := now.Add(5 * time.Second)
+	active = observeBareAgents(map[int]engine.ProcessStat{300: {Started: started, CPU: 5*time.Second + 10*time.Millisecond}}, later)
+	if !active[300].Equal(started) {
+		t.Errorf("idle = %v", active[300])
+	}
+	// real work does
+	active = observeBareAgents(map[int]engine.ProcessStat{300: {Started: started, CPU: 8 * time.Second}}, later)
+	if !active[300].Equal(later) {
+		t.Errorf("busy = %v", active[300])
+	}
+	if observeBareAgents(nil, later); len(bareSamples) != 0 {
+		t.Errorf("exited agents kept: %v", bareSamples)
+	}
+
+	// a bare agent colors its window's title like a tmux session would
+	activity := withBareAgents(map[string]time.Time{}, []Window{{ID: 2, Title: "work"}},
+		map[int][]bareAgent{2: {{PID: 300, Command: "claude", LastActive: later}}})
+	if !activity["work"].Equal(later) {
+		t.Errorf("activity = %v", activity)
+	}
+}
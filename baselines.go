@@ -0,0 +1,149 @@
+// baselines: how long each productive command usually runs, and agents
+// that have gone quiet for far longer than that.
+//
+// the staleness tiers are one clock for every command, but a claude run
+// that usually takes two minutes and a test suite that usually takes
+// twenty don't go stale at the same age. the agent registry's status log
+// (agents.go) already records every working → idle transition, so the
+// typical run of each command falls out of it: the median working stretch
+// over the last baselineWindow. a productive pane silent for
+// silenceAnomalyFactor times its command's usual run is flagged on its
+// own, apart from the tier color — often well before the tiers would call
+// a hung agent cold.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// baselineRefresh is how often the baselines are recomputed; they move
+// slowly and the query reads weeks of history.
+const baselineRefresh = 10 * time.Minute
+
+// commandBaseline is the usual working stretch of one command.
+type commandBaseline struct {
+	Median time.Duration
+	Runs   int
+}
+
+// commandBaselines maps a command name to its baseline. a nil map is
+// valid and knows nothing.
+type commandBaselines map[string]commandBaseline
+
+// commandStatusEvent is a status transition tagged with the agent's
+// command.
+type commandStatusEvent struct {
+	agentStatusEvent
+	Command string
+}
+
+// computeBaselines pairs each working transition with the agent's next
+// transition out of working and takes the median run per command.
+// commands with fewer than minRuns runs get no baseline. events must be
+// ordered by agent then time.
+func computeBaselines(events []commandStatusEvent, minRuns int) commandBaselines {
+	runs := map[string][]time.Duration{}
+	started := map[string]commandStatusEvent{} // agent id → its open working transition
+	for _, ev := range events {
+		start, working := started[ev.AgentID]
+		switch {
+		case ev.Status == agentWorking && !working:
+			started[ev.AgentID] = ev
+		case ev.Status != agentWorking && working:
+			runs[start.Command] = append(runs[start.Command], ev.At.Sub(start.At))
+			delete(started, ev.AgentID)
+		}
+	}
+
+	baselines := commandBaselines{}
+	for command, durations := range runs {
+		if len(durations) < minRuns {
+			continue
+		}
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		baselines[command] = commandBaseline{Median: durations[len(durations)/2], Runs: len(durations)}
+	}
+	return baselines
+}
+
+// silenceAnomaly reports how many usual runs a pane's command has been
+// silent for, when that's at least factor. ok is false without a baseline
+// or below the factor.
+func (b commandBaselines) silenceAnomaly(command string, lastActivity, now time.Time, factor float64) (float64, bool) {
+	base, ok := b[command]
+	if !ok || base.Median <= 0 {
+		return 0, false
+	}
+	times := float64(now.Sub(lastActivity)) / float64(base.Median)
+	return times, times >= factor
+}
+
+// loadCommandStatusEvents reads registry transitions since a time with
+// each agent's command, ordered by agent then time.
+func loadCommandStatusEvents(db *sql.DB, since time.Time) ([]commandStatusEvent, error) {
+	rows, err := db.Query(`
+		SELECT e.agent_id, a.command, e.status, e.at
+		FROM agent_status_events e JOIN agents a ON a.id = e.agent_id
+		WHERE e.at >= ?
+		ORDER BY e.agent_id, e.at, e.id`, since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("querying command status events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []commandStatusEvent
+	for rows.Next() {
+		var ev commandStatusEvent
+		var at string
+		if err := rows.Scan(&ev.AgentID, &ev.Command, &ev.Status, &at); err != nil {
+			continue
+		}
+		ev.At, _ = time.Parse(time.RFC3339, at)
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+var (
+	baselineMu    sync.Mutex
+	baselineAt    time.Time
+	baselineCache commandBaselines
+)
+
+// queryBaselines returns the current baselines, recomputing them at most
+// every baselineRefresh. nil when the db is unavailable.
+func queryBaselines() commandBaselines {
+	baselineMu.Lock()
+	defer baselineMu.Unlock()
+	if time.Since(baselineAt) < baselineRefresh {
+		return baselineCache
+	}
+	db := sharedStateDB()
+	if db == nil {
+		return nil
+	}
+	events, err := loadCommandStatusEvents(db, time.Now().Add(-baselineWindow))
+	if err != nil {
+		return baselineCache
+	}
+	baselineCache, baselineAt = computeBaselines(events, baselineMinRuns), time.Now()
+	return baselineCache
+}
+
+// renderSilenceAnomaly flags a productive pane that's been silent far
+// past its command's usual run, e.g. "silent 4× usual". empty otherwise.
+func renderSilenceAnomaly(rc renderContext, p TmuxPane) string {
+	if !rc.productivePanePIDs[p.PanePID] {
+		return ""
+	}
+	times, ok := rc.baselines.silenceAnomaly(p.CurrentCommand, p.LastActivity, time.Now(), silenceAnomalyFactor)
+	if !ok {
+		return ""
+	}
+	return warnStyle.Render(fmt.Sprintf("silent %.0f× usual", times))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestComputeBaselines(t *testing.T) {
+	base := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+	ev := func(agent, command, status string, m int) commandStatusEvent {
+		return commandStatusEvent{agentStatusEvent{AgentID: agent, Status: status, At: at(m)}, command}
+	}
+	events := []commandStatusEvent{
+		// claude runs of 2, 3 and 10 minutes across two agents; seen and
+		// gone end a run like idle does
+		ev("a", "claude", agentWorking, 0),
+		ev("a", "claude", agentIdle, 2),
+		ev("a", "claude", agentWorking, 5),
+		ev("a", "claude", agentSeen, 8),
+		ev("b", "claude", agentWorking, 0),
+		ev("b", "claude", agentGone, 10),
+		// codex ran once: not enough to judge
+		ev("c", "codex", agentWorking, 0),
+		ev("c", "codex", agentIdle, 30),
+		// still working: no run yet
+		ev("d", "claude", agentWorking, 0),
+	}
+	got := computeBaselines(events, 3)
+	if len(got) != 1 || got["claude"].Median != 3*time.Minute || got["claude"].Runs != 3 {
+		t.Fatalf("baselines = %+v", got)
+	}
+
+	now := at(100)
+	if times, ok := got.silenceAnomaly("claude", now.Add(-13*time.Minute), now, 4); !ok || times < 4.3 || times > 4.4 {
+		t.Errorf("13m silence: %.2f× ok=%v", times, ok)
+	}
+	if _, ok := got.silenceAnomaly("claude", now.Add(-5*time.Minute), now, 4); ok {
+		t.Error("5m silence flagged")
+	}
+	if _, ok := got.silenceAnomaly("codex", now.Add(-time.Hour), now, 4); ok {
+		t.Error("command without a baseline flagged")
+	}
+}
+
+func TestLoadCommandStatusEvents(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(agentSchema); err != nil {
+		t.Fatal(err)
+	}
+	db.Exec(`INSERT INTO agents (id, name, command, session_name, window_index, pane_index, pane_pid, started_at, last_seen_at, status)
+		VALUES ('a', 'api/claude', 'claude', 'api', 0, 0, 1, '2026-01-01T09:00:00Z', '2026-01-01T09:00:00Z', 'idle')`)
+	db.Exec(`INSERT INTO agent_status_events (agent_id, status, at) VALUES
+		('a', 'working', '2026-01-01T09:00:00Z'), ('a', 'idle', '2026-01-01T09:02:00Z'), ('a', 'working', '2025-12-01T09:00:00Z')`)
+
+	events, err := loadCommandStatusEvents(db, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Command != "claude" || events[0].Status != agentWorking || events[1].Status != agentIdle {
+		t.Fatalf("events = %+v", events)
+	}
+}
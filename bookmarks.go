@@ -0,0 +1,129 @@
+// bookmarks: vim's counts and marks on the grid.
+//
+// a number before a move repeats it: 3j is three rows down, 2l two
+// displays right, and 4g or 4G the fourth row of the column. a digit
+// alone still jumps to that space — it waits countTimeout (config.go)
+// for a move to follow, and any other key jumps at once before doing
+// its own thing, so typing quickly loses nothing.
+//
+// m{char} bookmarks the space under the cursor as char, and '{char}
+// brings the cursor back to it, on whichever display it's on by then:
+// bookmarks follow the space's id, not its row, so they hold across
+// refreshes, sorting and spaces moving displays. a bookmark whose space
+// was destroyed says so. these are the cursor's marks, apart from the
+// space bar's marks for bulk actions (marks.go).
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCount caps a count prefix; no column is longer.
+const maxCount = 99
+
+// countTimeoutMsg ends a count that no move followed.
+type countTimeoutMsg struct{ seq int }
+
+func countTimeoutCmd(seq int) tea.Cmd {
+	return tea.Tick(countTimeout, func(time.Time) tea.Msg { return countTimeoutMsg{seq: seq} })
+}
+
+// countable actions take a count prefix.
+var countable = map[string]bool{actDown: true, actUp: true, actLeft: true, actRight: true, actTop: true, actBottom: true}
+
+// countDigit adds a digit to the pending count and waits for a move.
+func (m model) countDigit(d int) (tea.Model, tea.Cmd) {
+	m.count = min(m.count*10+d, maxCount)
+	m.countSeq++
+	return m, countTimeoutCmd(m.countSeq)
+}
+
+// flushCount gives up on a pending count: a single digit jumps to its
+// space as it would have without waiting.
+func (m model) flushCount() (model, tea.Cmd) {
+	n := m.count
+	m.count = 0
+	if n < 1 || n > 9 {
+		return m, nil
+	}
+	next, cmd := m.quickFocus(n, false)
+	return next.(model), cmd
+}
+
+// withCount runs action with the pending count: moves repeat, top and
+// bottom go to that row, and anything else flushes the count first.
+func (m model) withCount(action string) (tea.Model, tea.Cmd) {
+	if !countable[action] {
+		m, flushed := m.flushCount()
+		next, cmd := m.handleAction(action)
+		return next, tea.Batch(flushed, cmd)
+	}
+	n := m.count
+	m.count = 0
+	if action == actTop || action == actBottom {
+		if dg := m.displayGroups[m.cursorCol]; len(dg.spaces) > 0 {
+			m.cursorRow = min(n, len(dg.spaces)) - 1
+			m.skipFolded(true)
+		}
+		return m, nil
+	}
+	for range n {
+		next, _ := m.handleAction(action)
+		m = next.(model)
+	}
+	return m, nil
+}
+
+// handleBookmarkKey names the bookmark m or ' is waiting for.
+func (m model) handleBookmarkKey(key string) (tea.Model, tea.Cmd) {
+	action := m.bookmarkPending
+	m.bookmarkPending = ""
+	if key == "esc" || len([]rune(key)) != 1 {
+		return m, nil
+	}
+	switch action {
+	case actSetMark:
+		row, ok := m.selectedSpaceRow()
+		if !ok {
+			return m, nil
+		}
+		bookmarks := make(map[string]int, len(m.bookmarks)+1)
+		for k, id := range m.bookmarks {
+			bookmarks[k] = id
+		}
+		bookmarks[key] = row.space.ID
+		m.bookmarks = bookmarks
+		m.status = fmt.Sprintf("bookmarked space %d as %s", row.space.Index, key)
+	case actJumpMark:
+		id, ok := m.bookmarks[key]
+		switch {
+		case !ok:
+			m.status = fmt.Sprintf("no bookmark %s", key)
+		case !m.restoreCursor(id):
+			m.status = fmt.Sprintf("bookmark %s's space is gone", key)
+		}
+	}
+	return m, nil
+}
+
+// renderBookmarkPrompt is the line while m or ' waits for its key.
+func renderBookmarkPrompt(action string, bookmarks map[string]int) string {
+	if action == actSetMark {
+		return dimStyle.Render("bookmark this space as …")
+	}
+	keys := make([]string, 0, len(bookmarks))
+	for k := range bookmarks {
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return dimStyle.Render("no bookmarks yet: ") + keyStyle.Render(activeKeymap.label(actSetMark)) + dimStyle.Render(" sets one")
+	}
+	sort.Strings(keys)
+	return dimStyle.Render("jump to bookmark: ") + keyStyle.Render(strings.Join(keys, " "))
+}
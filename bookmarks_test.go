@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCountPrefix(t *testing.T) {
+	m := newModel()
+	m.displayGroups = []displayGroup{{spaces: make([]spaceRow, 20)}}
+	press := func(keys string) {
+		t.Helper()
+		for _, r := range keys {
+			next, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			m = next.(model)
+		}
+	}
+
+	press("3j")
+	if m.cursorRow != 3 || m.count != 0 {
+		t.Fatalf("3j: row %d, count %d", m.cursorRow, m.count)
+	}
+	press("12")
+	if !strings.Contains(m.renderPrompt(), "12") {
+		t.Errorf("pending count not shown: %q", m.renderPrompt())
+	}
+	press("G")
+	if m.cursorRow != 11 {
+		t.Errorf("12G: row %d", m.cursorRow)
+	}
+	press("2k")
+	if m.cursorRow != 9 {
+		t.Errorf("2k: row %d", m.cursorRow)
+	}
+
+	// a digit nothing follows jumps to its space once it times out
+	press("5")
+	next, cmd := m.update(countTimeoutMsg{seq: m.countSeq})
+	m = next.(model)
+	if m.cursorRow != 4 || m.count != 0 || cmd == nil {
+		t.Errorf("5 alone: row %d, count %d", m.cursorRow, m.count)
+	}
+	// an older digit's timeout is ignored
+	press("7")
+	next, _ = m.update(countTimeoutMsg{seq: m.countSeq - 1})
+	if next.(model).count != 7 {
+		t.Error("stale timeout flushed the count")
+	}
+}
+
+func TestBookmarksFollowSpaces(t *testing.T) {
+	displays := []Display{{Index: 1}, {Index: 2}}
+	spaces := []Space{
+		{ID: 1, Index: 1, Display: 1},
+		{ID: 2, Index: 2, Display: 1},
+		{ID: 3, Index: 3, Display: 2},
+	}
+	m := newModel()
+	next, _ := m.handleData(fetchResult{spaces: spaces, displays: displays})
+	m = next.(model)
+	press := func(keys ...string) {
+		t.Helper()
+		for _, k := range keys {
+			next, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+			m = next.(model)
+		}
+	}
+
+	m.cursorCol, m.cursorRow = 0, 1
+	press("m", "a")
+	if m.bookmarks["a"] != 2 || m.bookmarkPending != "" {
+		t.Fatalf("bookmarks = %v", m.bookmarks)
+	}
+
+	// space 2 moves to the other display. a refresh hands over new
+	// slices, so the model's copy must not change under it
+	spaces = append([]Space(nil), spaces...)
+	spaces[1].Display = 2
+	next, _ = m.handleData(fetchResult{spaces: spaces, displays: displays})
+	m = next.(model)
+	m.cursorCol, m.cursorRow = 0, 0
+	press("'", "a")
+	if row, _ := m.selectedSpaceRow(); row.space.ID != 2 || m.cursorCol != 1 {
+		t.Errorf("'a landed on space %d in column %d", row.space.ID, m.cursorCol)
+	}
+
+	press("'", "b")
+	if m.status != "no bookmark b" {
+		t.Errorf("status = %q", m.status)
+	}
+	next, _ = m.handleData(fetchResult{spaces: []Space{spaces[0], spaces[2]}, displays: displays})
+	m = next.(model)
+	press("'", "a")
+	if !strings.Contains(m.status, "gone") {
+		t.Errorf("status = %q", m.status)
+	}
+}
@@ -0,0 +1,125 @@
+// budget: keep the refresh pipeline inside its poll interval.
+//
+// each tick runs fetch (every query, concurrently) → derive (grouping and
+// mapping in handleData) → render (View). on a loaded machine that can
+// take longer than the interval itself, and then ticks stack up fetches
+// faster than they finish and the dashboard falls further behind. the
+// budget times all three stages, warns in the status bar when a refresh
+// overruns, and after a few overruns in a row doubles the interval; once
+// refreshes are comfortably fast again it steps back down to the base.
+// on battery or under thermal pressure (power.go) the interval doesn't
+// drop below throttledPollInterval, whatever the timings say.
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	basePollInterval = 2 * time.Second
+	maxPollInterval  = 30 * time.Second
+
+	// consecutive overruns before stretching, and consecutive refreshes
+	// under a quarter of the interval before shrinking back
+	overloadTicks = 3
+	recoverTicks  = 10
+)
+
+// refreshBudget holds the last refresh's stage timings and the current
+// poll interval. the model keeps it by pointer so View can report its own
+// render time.
+type refreshBudget struct {
+	interval              time.Duration
+	fetch, derive, render time.Duration
+	over, under           int
+	throttle              string // why refreshes are held slow (power.go), or ""
+}
+
+func newRefreshBudget() *refreshBudget {
+	return &refreshBudget{interval: basePollInterval}
+}
+
+// pollInterval is the current interval; the base when b is nil.
+func (b *refreshBudget) pollInterval() time.Duration {
+	if b == nil {
+		return basePollInterval
+	}
+	if b.throttle != "" {
+		return max(b.interval, throttledPollInterval)
+	}
+	return b.interval
+}
+
+// setThrottle holds the interval at throttledPollInterval or more while
+// reason is set.
+func (b *refreshBudget) setThrottle(reason string) {
+	if b != nil {
+		b.throttle = reason
+	}
+}
+
+func (b *refreshBudget) total() time.Duration {
+	return b.fetch + b.derive + b.render
+}
+
+func (b *refreshBudget) overrun() bool {
+	return b != nil && b.total() > b.interval
+}
+
+// recordRender stores the latest View duration.
+func (b *refreshBudget) recordRender(d time.Duration) {
+	if b != nil {
+		b.render = d
+	}
+}
+
+// observe records a refresh's fetch and derive times (render is the most
+// recent frame's) and adapts the interval.
+func (b *refreshBudget) observe(fetch, derive time.Duration) {
+	if b == nil {
+		return
+	}
+	b.fetch, b.derive = fetch, derive
+
+	switch total := b.total(); {
+	case total > b.interval:
+		b.over++
+		b.under = 0
+	case total < b.interval/4:
+		b.under++
+		b.over = 0
+	default:
+		b.over, b.under = 0, 0
+	}
+
+	if b.over >= overloadTicks && b.interval < maxPollInterval {
+		b.interval = min(b.interval*2, maxPollInterval)
+		b.over = 0
+	}
+	if b.under >= recoverTicks && b.interval > basePollInterval {
+		b.interval = max(b.interval/2, basePollInterval)
+		b.under = 0
+	}
+}
+
+// renderBudgetLine warns about an overrun or a stretched or throttled
+// interval; empty while refreshes fit.
+func renderBudgetLine(b *refreshBudget) string {
+	if b == nil {
+		return ""
+	}
+	stages := fmt.Sprintf("fetch %s, derive %s, render %s",
+		b.fetch.Round(time.Millisecond), b.derive.Round(time.Millisecond), b.render.Round(time.Millisecond))
+	switch {
+	case b.overrun():
+		return warnStyle.Render(fmt.Sprintf("refresh took %s, over the %s interval", b.total().Round(10*time.Millisecond), b.interval)) +
+			dimStyle.Render(" ("+stages+")")
+	case b.throttle != "":
+		return dimStyle.Render(fmt.Sprintf("%s: polling every %s, agent screens paused", b.throttle, b.pollInterval()))
+	case b.interval > basePollInterval:
+		return dimStyle.Render(fmt.Sprintf("slowed polling to every %s under load (%s)", b.interval, stages))
+	}
+	return ""
+}
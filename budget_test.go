@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshBudgetAdapts(t *testing.T) {
+	b := newRefreshBudget()
+	b.recordRender(50 * time.Millisecond)
+
+	for i := 0; i < overloadTicks-1; i++ {
+		b.observe(2500*time.Millisecond, 10*time.Millisecond)
+	}
+	if !b.overrun() || b.pollInterval() != basePollInterval {
+		t.Fatalf("after %d overruns: overrun=%v interval=%s, want a warning but no stretch yet", overloadTicks-1, b.overrun(), b.pollInterval())
+	}
+	b.observe(2500*time.Millisecond, 10*time.Millisecond)
+	if b.pollInterval() != 2*basePollInterval {
+		t.Fatalf("interval = %s after sustained overload, want %s", b.pollInterval(), 2*basePollInterval)
+	}
+	if b.overrun() {
+		t.Error("the stretched interval should cover the last refresh")
+	}
+
+	// a run of fast refreshes steps back down
+	for i := 0; i < recoverTicks; i++ {
+		b.observe(100*time.Millisecond, 0)
+	}
+	if b.pollInterval() != basePollInterval {
+		t.Errorf("interval = %s after recovering, want the base %s", b.pollInterval(), basePollInterval)
+	}
+
+	var nilBudget *refreshBudget
+	nilBudget.observe(time.Second, 0)
+	if nilBudget.pollInterval() != basePollInterval || renderBudgetLine(nilBudget) != "" {
+		t.Error("nil budget should behave as the base interval")
+	}
+}
@@ -0,0 +1,198 @@
+// cleanup: clear out dead sessions and spare spaces in one go.
+//
+// c opens a checklist of what looks abandoned: tmux sessions where no
+// pane runs anything productive and nothing has printed for
+// cleanupStaleAfter, and empty spaces beyond the first
+// cleanupKeepFreeSpaces on each display (unlabeled ones only, and never
+// the focused one). everything starts checked; space toggles an item, a
+// toggles them all, and enter asks once before killing and destroying
+// the checked ones. each goes through the journal like X and D do, so the
+// batch can be looked back on with `stop journal`.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cleanupItem is a session or a space on the checklist.
+type cleanupItem struct {
+	session string   // set for a session
+	row     spaceRow // otherwise the space
+	why     string
+	checked bool
+}
+
+// cleanupPanel is the open c checklist.
+type cleanupPanel struct {
+	items []cleanupItem
+	sel   int
+}
+
+// findCleanup lists dead sessions, then spare empty spaces.
+func findCleanup(groups []displayGroup, panes []TmuxPane, productive map[int]bool, now time.Time) []cleanupItem {
+	type sessionState struct {
+		live   bool
+		latest time.Time
+	}
+	sessions := map[string]*sessionState{}
+	for _, p := range panes {
+		s := sessions[p.SessionName]
+		if s == nil {
+			s = &sessionState{}
+			sessions[p.SessionName] = s
+		}
+		s.live = s.live || productive[p.PanePID]
+		if p.LastActivity.After(s.latest) {
+			s.latest = p.LastActivity
+		}
+	}
+	var items []cleanupItem
+	for name, s := range sessions {
+		if idle := now.Sub(s.latest); !s.live && idle >= cleanupStaleAfter {
+			items = append(items, cleanupItem{session: name, why: "quiet " + humanDuration(idle), checked: true})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].session < items[j].session })
+
+	for _, dg := range groups {
+		kept := 0
+		for _, row := range dg.spaces {
+			if len(row.windows) > 0 || row.space.Label != "" || row.space.HasFocus {
+				continue
+			}
+			if kept < cleanupKeepFreeSpaces {
+				kept++
+				continue
+			}
+			items = append(items, cleanupItem{row: row, why: "empty", checked: true})
+		}
+	}
+	return items
+}
+
+// label is how an item reads in the checklist.
+func (it cleanupItem) label() string {
+	if it.session != "" {
+		return "session " + it.session
+	}
+	return fmt.Sprintf("space %d", it.row.space.Index)
+}
+
+// openCleanup opens the checklist, or says there's nothing to clean.
+func (m model) openCleanup() model {
+	items := findCleanup(m.displayGroups, m.tmuxPanes, m.productivePanePIDs, time.Now())
+	if len(items) == 0 {
+		m.status = "nothing to clean up"
+		return m
+	}
+	m.cleanup = &cleanupPanel{items: items}
+	return m
+}
+
+func (m model) handleCleanupKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := *m.cleanup
+	p.items = append([]cleanupItem(nil), p.items...)
+	switch key := msg.String(); {
+	case key == "esc" || activeKeymap.action(key) == actCleanup:
+		m.cleanup = nil
+		return m, nil
+	case activeKeymap.action(key) == actDown:
+		if p.sel < len(p.items)-1 {
+			p.sel++
+		}
+	case activeKeymap.action(key) == actUp:
+		if p.sel > 0 {
+			p.sel--
+		}
+	case key == " ":
+		p.items[p.sel].checked = !p.items[p.sel].checked
+	case key == "a":
+		all := true
+		for _, it := range p.items {
+			all = all && it.checked
+		}
+		for i := range p.items {
+			p.items[i].checked = !all
+		}
+	case key == "enter":
+		var sessions []string
+		var rows []spaceRow
+		for _, it := range p.items {
+			switch {
+			case !it.checked:
+			case it.session != "":
+				sessions = append(sessions, it.session)
+			default:
+				rows = append(rows, it.row)
+			}
+		}
+		if len(sessions)+len(rows) == 0 {
+			m.status = "nothing checked"
+			break
+		}
+		m.cleanup = nil
+		m.confirm = &pendingAction{
+			prompt: fmt.Sprintf("kill %d sessions and destroy %d spaces?", len(sessions), len(rows)),
+			run:    cleanupCmd(sessions, rows, m.tmuxPanes),
+		}
+		return m, nil
+	}
+	m.cleanup = &p
+	return m, nil
+}
+
+// cleanupCmd kills the sessions, then destroys the spaces from the
+// highest index down so the ones still to go keep their numbers.
+func cleanupCmd(sessions []string, rows []spaceRow, panes []TmuxPane) tea.Cmd {
+	rows = append([]spaceRow(nil), rows...)
+	sort.Slice(rows, func(i, j int) bool { return rows[i].space.Index > rows[j].space.Index })
+	return func() tea.Msg {
+		if len(sessions) > 0 {
+			if done := killSessionsCmd(sessions, panes)().(actionDoneMsg); done.err != nil {
+				return done
+			}
+		}
+		for _, row := range rows {
+			if done := destroySpaceCmd(row, panes)().(actionDoneMsg); done.err != nil {
+				return done
+			}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("cleaned up %d sessions and %d spaces (journaled)", len(sessions), len(rows))}
+	}
+}
+
+// renderCleanup is the checklist when open.
+func (m model) renderCleanup(width int, pad string) string {
+	if m.cleanup == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n" + pad + displayStyle.Render("cleanup") + "  " +
+		keyStyle.Render("space") + " " + helpStyle.Render("toggle") + "  " +
+		keyStyle.Render("a") + " " + helpStyle.Render("all") + "  " +
+		keyStyle.Render("enter") + " " + helpStyle.Render("clean up") + "  " +
+		keyStyle.Render("esc") + " " + helpStyle.Render("close") + "\n")
+	labelWidth := 0
+	for _, it := range m.cleanup.items {
+		labelWidth = max(labelWidth, len(it.label()))
+	}
+	for i, it := range m.cleanup.items {
+		cursor := "  "
+		if i == m.cleanup.sel {
+			cursor = cursorStyle.Render("> ")
+		}
+		box := "[ ]"
+		if it.checked {
+			box = "[x]"
+		}
+		line := fmt.Sprintf("%s%s %-*s  %s", cursor, box, labelWidth, it.label(), dimStyle.Render(it.why))
+		b.WriteString(pad + truncateForWidth(line, maxInt(width, 10)) + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFindCleanup(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-cleanupStaleAfter - time.Hour)
+	panes := []TmuxPane{
+		{SessionName: "scratch", PanePID: 1, LastActivity: old},
+		{SessionName: "scratch", PanePID: 2, LastActivity: old.Add(-time.Hour)},
+		{SessionName: "api", PanePID: 3, LastActivity: old},                    // an agent, however quiet
+		{SessionName: "logs", PanePID: 4, LastActivity: now.Add(-time.Minute)}, // still printing
+	}
+	empty := func(index int) spaceRow { return spaceRow{space: Space{Index: index}} }
+	busy := spaceRow{space: Space{Index: 1}, windows: []Window{{ID: 9}}}
+	labeled := spaceRow{space: Space{Index: 5, Label: "spare"}}
+	focused := spaceRow{space: Space{Index: 6, HasFocus: true}}
+	groups := []displayGroup{
+		{spaces: []spaceRow{busy, empty(2), empty(3), empty(4), labeled, focused, empty(7)}},
+		{spaces: []spaceRow{empty(8), empty(9)}},
+	}
+
+	items := findCleanup(groups, panes, map[int]bool{3: true}, now)
+	var got []string
+	for _, it := range items {
+		got = append(got, it.label())
+		if !it.checked {
+			t.Errorf("%s starts unchecked", it.label())
+		}
+	}
+	// two free spaces kept per display: 2 and 3, then 8 and 9
+	want := "session scratch, space 4, space 7"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("cleanup = %s, want %s", strings.Join(got, ", "), want)
+	}
+}
+
+func TestCleanupKeys(t *testing.T) {
+	m := newModel()
+	m.cleanup = &cleanupPanel{items: []cleanupItem{
+		{session: "scratch", checked: true},
+		{row: spaceRow{space: Space{Index: 4}}, checked: true},
+		{row: spaceRow{space: Space{Index: 7}}, checked: true},
+	}}
+	press := func(key string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		next, _ := m.handleCleanupKey(msg)
+		m = next.(model)
+	}
+	press("j")
+	press(" ") // uncheck space 4
+	if m.cleanup.items[1].checked || !m.cleanup.items[0].checked {
+		t.Fatalf("toggle hit the wrong item: %+v", m.cleanup.items)
+	}
+	press("a") // not all checked: check all
+	press("a") // all checked: uncheck all
+	press("enter")
+	if m.confirm != nil || m.status != "nothing checked" {
+		t.Errorf("empty batch asked to run: %v %q", m.confirm, m.status)
+	}
+	press("a")
+	press("enter")
+	if m.cleanup != nil || m.confirm == nil || m.confirm.prompt != "kill 1 sessions and destroy 2 spaces?" {
+		t.Errorf("confirm = %+v, panel open %v", m.confirm, m.cleanup != nil)
+	}
+}
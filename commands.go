@@ -0,0 +1,365 @@
+// commands: the `stop <command>` registry.
+//
+// main used to test os.Args[1] against each subcommand in turn, every
+// branch with its own flag set, its own error printing and, for the two
+// that had one, its own --debug handling. each command is now an entry
+// here: a name, a usage line and summary for help, and a setup that
+// declares its flags and returns what to run once they're parsed. the
+// dispatcher adds the flags every command shares, prints errors the
+// same way for all of them, and answers `stop help` and `stop help
+// <command>` from the same entries. a first argument that isn't a
+// command name is the dashboard's flags (main.go).
+//
+// shared flags:
+//
+//	-debug, -debug-file  write a debug log of queries, commands and mapping decisions
+//	-json                machine-readable output, for the commands that have it
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// command is one `stop <name>`.
+type command struct {
+	name    string
+	usage   string // what follows the name, e.g. "[-day YYYY-MM-DD]"
+	summary string
+	json    bool // honors -json
+	// setup declares the command's flags on fs and returns the command,
+	// which runs with the arguments left after them. g is filled in by
+	// the time it runs.
+	setup func(fs *flag.FlagSet, g *globalFlags) func(args []string) error
+}
+
+// globalFlags are the flags every command accepts.
+type globalFlags struct {
+	debug     bool
+	debugFile string
+	json      bool
+}
+
+func (g *globalFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&g.debug, "debug", false, "write a debug log of queries, commands and mapping decisions")
+	fs.StringVar(&g.debugFile, "debug-file", defaultDebugLogPath(), "where -debug writes")
+	fs.BoolVar(&g.json, "json", false, "machine-readable output, where the command has it")
+}
+
+// commands lists every subcommand, in help order.
+func commands() []command {
+	return []command{
+		{
+			name: "serve", usage: "[install | uninstall | status] [-port N] [-no-yabai] [-read-only] [-previews] [-tails]",
+			summary: "HTTP JSON server for the companion app; install runs it at login via launchd",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				port := fs.Int("port", 8385, "port to listen on")
+				fs.IntVar(port, "p", 8385, "port to listen on")
+				fs.BoolVar(&tmuxOnly, "no-yabai", false, "skip the window manager and serve tmux data only")
+				readOnly := fs.Bool("read-only", false, "observe only: never run commands with side effects")
+				fs.BoolVar(&spaceThumbnails, "previews", spaceThumbnails, "serve /preview/{space} screenshots of spaces")
+				fs.BoolVar(&paneTails, "tails", paneTails, "serve /tmux/{session}/{window}/{pane}/tail pane output")
+				return func(args []string) error {
+					engine.SetReadOnly(*readOnly)
+					serveCommand(*port)
+					return nil
+				}
+			},
+		},
+		{
+			name: "watch", usage: "[-json] [-interval D] [-once] [-events-only] [-hooks] [-read-only]", json: true,
+			summary: "stream events, or with -json NDJSON snapshots and events",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				interval := fs.Duration("interval", 2*time.Second, "refresh interval")
+				readOnly := fs.Bool("read-only", false, "observe only: never run commands with side effects")
+				hooks := fs.Bool("hooks", false, "run eventHooks on each event (leave off while the TUI runs them)")
+				once := fs.Bool("once", false, "print one JSON snapshot and exit")
+				eventsOnly := fs.Bool("events-only", false, "with -json, emit events but no snapshots")
+				return func(args []string) error {
+					engine.SetReadOnly(*readOnly)
+					return watchCommand(watchOptions{
+						interval:   *interval,
+						jsonOut:    g.json,
+						hooks:      *hooks,
+						once:       *once,
+						eventsOnly: *eventsOnly,
+					})
+				}
+			},
+		},
+		{
+			name: "doctor", usage: "[-no-yabai] [-json]", json: true,
+			summary: "pass/fail checks for why sessions show as detached",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				fs.BoolVar(&tmuxOnly, "no-yabai", false, "check without the window manager")
+				return func(args []string) error { return doctorCommand(g.json) }
+			},
+		},
+		{
+			name: "history", usage: "[-since D] [-gap D] [-collapse N] [-limit N]",
+			summary: "find restarts, crashes and sleeps in the snapshot timeline",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				since := fs.Duration("since", 7*24*time.Hour, "lookback window (e.g. 24h, 168h)")
+				gap := fs.Duration("gap", 5*time.Minute, "minimum wall-clock gap to flag as a discontinuity")
+				collapse := fs.Int("collapse", 5, "minimum drop in tmux pane count to flag (0 disables)")
+				limit := fs.Int("limit", 1, "max number of discontinuities to print (0 = no limit)")
+				return func(args []string) error {
+					return historyCommand(historyOptions{
+						since:             time.Now().Add(-*since),
+						gapThreshold:      *gap,
+						collapseThreshold: *collapse,
+						limit:             *limit,
+					})
+				}
+			},
+		},
+		{
+			name: "show", usage: "<snapshot_id>",
+			summary: "render a single snapshot by id",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				return func(args []string) error {
+					var id int64
+					if len(args) > 0 {
+						fmt.Sscanf(args[0], "%d", &id)
+					}
+					if id <= 0 {
+						return fmt.Errorf("usage: stop show <snapshot_id>")
+					}
+					db, err := openSnapshotDB()
+					if err != nil {
+						return err
+					}
+					defer db.Close()
+					return printSnapshotState(os.Stdout, db, id)
+				}
+			},
+		},
+		{
+			name: "record", usage: "-out FILE [-interval D] [-frames N] [-no-yabai]",
+			summary: "record the desk over time for --replay",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				out := fs.String("out", "", "file to write the recording to")
+				interval := fs.Duration("interval", 2*time.Second, "time between frames")
+				frames := fs.Int("frames", 0, "stop after this many frames (0: until interrupted)")
+				fs.BoolVar(&tmuxOnly, "no-yabai", false, "record without the window manager")
+				return func(args []string) error { return recordCommand(*out, *interval, *frames) }
+			},
+		},
+		{
+			name: "annotate", usage: "-session NAME | -space SPACE [text]",
+			summary: "attach a status string to a session or space; no text clears it",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				session := fs.String("session", "", "tmux session name to annotate")
+				space := fs.String("space", "", "space label or yabai index to annotate")
+				return func(args []string) error { return annotateCommand(*session, *space, strings.Join(args, " ")) }
+			},
+		},
+		{
+			name: "priority", usage: "-session NAME | -space SPACE <level>",
+			summary: "set a triage level on a session or space; normal clears it",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				session := fs.String("session", "", "tmux session name")
+				space := fs.String("space", "", "space label or yabai index")
+				return func(args []string) error {
+					level := ""
+					if len(args) > 0 {
+						level = args[0]
+					}
+					return priorityCommand(*session, *space, level)
+				}
+			},
+		},
+		{
+			name: "agents", usage: "[-all] | rename <id> <name>",
+			summary: "list the agent registry, or give an agent a human name",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				all := fs.Bool("all", false, "include agents whose pane is gone")
+				return func(args []string) error { return agentsCommand(args, *all) }
+			},
+		},
+		{
+			name: "new", usage: "[-name NAME] [-dir DIR] [template]",
+			summary: "a tmux session on a fresh space, from a template",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				name := fs.String("name", "", "session name (default: the template's name)")
+				dir := fs.String("dir", "", "working directory (default: the template's)")
+				return func(args []string) error {
+					template := ""
+					if len(args) > 0 {
+						template = args[0]
+					}
+					return newCommand(template, *name, *dir)
+				}
+			},
+		},
+		{
+			name: "layout", usage: "list | save <name> | [-dry-run] restore <name>",
+			summary: "save which space each window is on, and put them back",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				dryRun := fs.Bool("dry-run", false, "print the moves a restore would make without making them")
+				return func(args []string) error { return layoutCommand(args, *dryRun) }
+			},
+		},
+		{
+			name: "journal", usage: "[-limit N]",
+			summary: "what destroyed spaces and killed sessions held",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				limit := fs.Int("limit", 10, "number of entries to print")
+				return func(args []string) error { return journalCommand(*limit) }
+			},
+		},
+		{
+			name: "slo", usage: "[-since D] [-target D]",
+			summary: "response-time compliance report, a week back by default",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				since := fs.Duration("since", 7*24*time.Hour, "lookback window")
+				target := fs.Duration("target", responseSLOTarget, "response target")
+				return func(args []string) error { return sloCommand(*since, *target) }
+			},
+		},
+		{
+			name: "digest", usage: "[-day YYYY-MM-DD] [-send]",
+			summary: "yesterday's agent-utilization report; -send mails it",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				day := fs.String("day", "", "day to report on, YYYY-MM-DD (default yesterday)")
+				send := fs.Bool("send", false, "mail the digest to digestMailTo instead of printing it")
+				return func(args []string) error { return digestCommand(*day, *send) }
+			},
+		},
+		{
+			name: "report", usage: "[-day YYYY-MM-DD]",
+			summary: "time on each space (and app) for a day, today by default",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				day := fs.String("day", "", "day to report on, YYYY-MM-DD (default today)")
+				return func(args []string) error { return reportCommand(*day) }
+			},
+		},
+		{
+			name: "sync-titles", usage: "[-watch] [-interval D]",
+			summary: "make terminal titles match tmux session names",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				watch := fs.Bool("watch", false, "keep syncing in the background")
+				interval := fs.Duration("interval", 5*time.Second, "re-sync interval with -watch")
+				return func(args []string) error { return syncTitlesCommand(*watch, *interval) }
+			},
+		},
+		{
+			name: "update", usage: "[-check] [-force]",
+			summary: "replace this binary with the latest release (checksum-verified, not signed)",
+			setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+				check := fs.Bool("check", false, "only report whether a newer release exists")
+				force := fs.Bool("force", false, "install the latest release over a dev build or the same version")
+				return func(args []string) error { return updateCommand(*check, *force) }
+			},
+		},
+	}
+}
+
+// title is how the command is typed: "stop serve", or "stop" for the
+// dashboard.
+func (c command) title() string {
+	if c.name == "" {
+		return "stop"
+	}
+	return "stop " + c.name
+}
+
+// findCommand looks name up in cmds.
+func findCommand(cmds []command, name string) (command, bool) {
+	for _, c := range cmds {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// runCommand runs cmd with args and returns the process's exit code.
+func runCommand(cmd command, args []string) int {
+	fs := flag.NewFlagSet(cmd.title(), flag.ExitOnError)
+	var g globalFlags
+	g.register(fs)
+	run := cmd.setup(fs, &g)
+	fs.Usage = func() { printCommandUsage(fs.Output(), cmd, fs) }
+
+	// serve's install, uninstall and status come before its flags
+	var service string
+	if cmd.name == "serve" && len(args) > 0 && serviceCommands[args[0]] {
+		service, args = args[0], args[1:]
+	}
+	_ = fs.Parse(args)
+	if g.json && !cmd.json {
+		fmt.Fprintf(os.Stderr, "error: %s has no -json output\n", cmd.title())
+		return 2
+	}
+	if g.debug {
+		f, err := openDebugLog(g.debugFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		fmt.Fprintf(os.Stderr, "debug log: %s\n", g.debugFile)
+	}
+
+	var err error
+	if service != "" {
+		err = serviceCommand(service, args)
+	} else {
+		err = run(fs.Args())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printCommandUsage is `stop help <command>` and `stop <command> -h`.
+func printCommandUsage(w io.Writer, cmd command, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "usage: %s %s\n\n%s\n\nflags:\n", cmd.title(), cmd.usage, cmd.summary)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+// printHelp is `stop help`: the dashboard's usage, then every command.
+func printHelp(w io.Writer, cmds []command) {
+	fmt.Fprintf(w, "usage: stop [flags]            the dashboard (stop help dashboard for its flags)\n       stop <command> [flags]\n\ncommands:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range cmds {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nevery command takes -debug, -debug-file and -json (where it has JSON output).\nstop help <command> shows a command's flags.\n")
+}
+
+// helpCommand is `stop help [command]`.
+func helpCommand(cmds []command, args []string) int {
+	if len(args) == 0 {
+		printHelp(os.Stdout, cmds)
+		return 0
+	}
+	cmd, ok := findCommand(cmds, args[0])
+	if !ok && args[0] == "dashboard" {
+		cmd, ok = dashboardCommand(), true
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: unknown command %q\n", args[0])
+		printHelp(os.Stderr, cmds)
+		return 2
+	}
+	fs := flag.NewFlagSet(cmd.title(), flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	cmd.setup(fs, &g)
+	printCommandUsage(os.Stdout, cmd, fs)
+	return 0
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestCommandRegistry(t *testing.T) {
+	cmds := commands()
+	seen := map[string]bool{}
+	for _, c := range cmds {
+		if c.name == "" || c.summary == "" || c.setup == nil {
+			t.Errorf("incomplete command %+v", c)
+		}
+		if seen[c.name] {
+			t.Errorf("%s registered twice", c.name)
+		}
+		seen[c.name] = true
+	}
+	for _, name := range []string{"serve", "doctor", "history", "layout", "watch", "update"} {
+		if _, ok := findCommand(cmds, name); !ok {
+			t.Errorf("%s missing", name)
+		}
+	}
+	if _, ok := findCommand(cmds, "-no-yabai"); ok {
+		t.Error("a flag matched a command")
+	}
+
+	var help bytes.Buffer
+	printHelp(&help, cmds)
+	for _, c := range cmds {
+		if !strings.Contains(help.String(), "  "+c.name+" ") {
+			t.Errorf("help doesn't list %s:\n%s", c.name, help.String())
+		}
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	var got []string
+	var gotJSON bool
+	cmd := command{name: "probe", json: true, summary: "test",
+		setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+			fail := fs.Bool("fail", false, "return an error")
+			return func(args []string) error {
+				got, gotJSON = args, g.json
+				if *fail {
+					return errors.New("failed")
+				}
+				return nil
+			}
+		}}
+	if code := runCommand(cmd, []string{"-json", "a", "b"}); code != 0 || !gotJSON || strings.Join(got, " ") != "a b" {
+		t.Errorf("code %d, json %v, args %q", code, gotJSON, got)
+	}
+	if code := runCommand(cmd, []string{"-fail"}); code != 1 {
+		t.Errorf("failing command exited %d", code)
+	}
+	cmd.json = false
+	if code := runCommand(cmd, []string{"-json"}); code != 2 {
+		t.Errorf("-json on a command without it exited %d", code)
+	}
+
+	var usage bytes.Buffer
+	fs := flag.NewFlagSet(cmd.title(), flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	cmd.setup(fs, &g)
+	printCommandUsage(&usage, cmd, fs)
+	if !strings.Contains(usage.String(), "usage: stop probe") || !strings.Contains(usage.String(), "-debug") || !strings.Contains(usage.String(), "-fail") {
+		t.Errorf("usage:\n%s", usage.String())
+	}
+	if dashboardCommand().title() != "stop" {
+		t.Error("the dashboard is plain stop")
+	}
+}
@@ -0,0 +1,329 @@
+// compare: what changed since this morning, from snapshot history.
+//
+// serve records a snapshot every 30 seconds. recent history is kept in
+// full; past snapshotFullRetention it's thinned to the first snapshot of
+// each hour, and past snapshotHourlyRetention dropped (pruneSnapshots).
+// C opens a picker of those hourly points and diffs the live state
+// against the chosen one: tmux sessions started and finished since, and
+// spaces that gained windows or were freed — a rough narrative of the
+// day from data stop already has.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// snapshotChildTables hold per-snapshot rows, deleted along with their
+// snapshot when pruning.
+var snapshotChildTables = []string{
+	"snapshot_spaces", "snapshot_windows", "snapshot_tmux_panes",
+	"snapshot_nvim_buffers", "snapshot_nvim_windows", "snapshot_nvim_sessions",
+}
+
+// hourlyFirstSnapshots selects the earliest capture of each hour (id,
+// captured_at). captured_at is RFC3339 UTC, so its first 13 characters
+// are the hour; ids aren't trusted to follow capture order.
+const hourlyFirstSnapshots = `
+	SELECT id, captured_at FROM (
+		SELECT id, captured_at, ROW_NUMBER() OVER (
+			PARTITION BY substr(captured_at, 1, 13) ORDER BY captured_at, id) AS n
+		FROM snapshots)
+	WHERE n = 1`
+
+// pruneSnapshots thins history: everything newer than full is kept, the
+// first snapshot of each hour back to hourly, nothing older. returns how
+// many snapshots were deleted.
+func pruneSnapshots(db *sql.DB, now time.Time, full, hourly time.Duration) (int, error) {
+	fullCutoff := now.Add(-full).UTC().Format(time.RFC3339)
+	hourlyCutoff := now.Add(-hourly).UTC().Format(time.RFC3339)
+
+	rows, err := db.Query(`
+		SELECT id FROM snapshots
+		WHERE captured_at < ?
+		  AND (captured_at < ? OR id NOT IN (SELECT id FROM (`+hourlyFirstSnapshots+`)))`,
+		fullCutoff, hourlyCutoff)
+	if err != nil {
+		return 0, fmt.Errorf("finding snapshots to prune: %w", err)
+	}
+	var ids []any
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	// sqlite caps bound parameters, so delete in batches
+	for start := 0; start < len(ids); start += 500 {
+		batch := ids[start:min(start+500, len(ids))]
+		in := "(" + strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",") + ")"
+		for _, table := range snapshotChildTables {
+			if _, err := tx.Exec("DELETE FROM "+table+" WHERE snapshot_id IN "+in, batch...); err != nil {
+				return 0, fmt.Errorf("pruning %s: %w", table, err)
+			}
+		}
+		if _, err := tx.Exec("DELETE FROM snapshots WHERE id IN "+in, batch...); err != nil {
+			return 0, fmt.Errorf("pruning snapshots: %w", err)
+		}
+	}
+	return len(ids), tx.Commit()
+}
+
+// snapshotPoint is one hourly snapshot offered for comparison.
+type snapshotPoint struct {
+	id int64
+	at time.Time
+}
+
+// loadComparePoints lists the first snapshot of each hour since since,
+// newest first.
+func loadComparePoints(db *sql.DB, since time.Time) ([]snapshotPoint, error) {
+	rows, err := db.Query(`SELECT id, captured_at FROM (`+hourlyFirstSnapshots+`)
+		WHERE captured_at >= ?
+		ORDER BY captured_at DESC`, since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("querying snapshot hours: %w", err)
+	}
+	defer rows.Close()
+	var points []snapshotPoint
+	for rows.Next() {
+		var p snapshotPoint
+		var at string
+		if err := rows.Scan(&p.id, &at); err != nil {
+			return nil, err
+		}
+		p.at, _ = time.Parse(time.RFC3339, at)
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// workState is the part of the dashboard a comparison looks at.
+type workState struct {
+	sessions map[string]bool // tmux session names
+	occupied map[int]bool    // space indices with a visible window
+}
+
+// currentWorkState reads the live state.
+func currentWorkState(spaces []Space, windows []Window, panes []TmuxPane) workState {
+	s := workState{sessions: map[string]bool{}, occupied: map[int]bool{}}
+	for _, p := range panes {
+		s.sessions[p.SessionName] = true
+	}
+	known := map[int]bool{}
+	for _, sp := range spaces {
+		known[sp.Index] = true
+	}
+	for _, w := range windows {
+		if known[w.Space] && !w.IsHidden && !w.IsMinimized {
+			s.occupied[w.Space] = true
+		}
+	}
+	return s
+}
+
+// loadSnapshotWorkState reads the same from a snapshot.
+func loadSnapshotWorkState(db *sql.DB, snapshotID int64) (workState, error) {
+	s := workState{sessions: map[string]bool{}, occupied: map[int]bool{}}
+	rows, err := db.Query("SELECT DISTINCT session_name FROM snapshot_tmux_panes WHERE snapshot_id = ?", snapshotID)
+	if err != nil {
+		return s, fmt.Errorf("querying snapshot sessions: %w", err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return s, err
+		}
+		s.sessions[name] = true
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT DISTINCT w.space_index FROM snapshot_windows w
+		JOIN snapshot_spaces sp ON sp.snapshot_id = w.snapshot_id AND sp.space_index = w.space_index
+		WHERE w.snapshot_id = ? AND w.is_hidden = 0 AND w.is_minimized = 0`, snapshotID)
+	if err != nil {
+		return s, fmt.Errorf("querying snapshot spaces: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var idx int
+		if err := rows.Scan(&idx); err != nil {
+			return s, err
+		}
+		s.occupied[idx] = true
+	}
+	return s, rows.Err()
+}
+
+// stateDiff is what changed between two work states.
+type stateDiff struct {
+	started, finished []string // sessions
+	gained, freed     []int    // space indices
+}
+
+func diffWorkStates(then, now workState) stateDiff {
+	var d stateDiff
+	for s := range now.sessions {
+		if !then.sessions[s] {
+			d.started = append(d.started, s)
+		}
+	}
+	for s := range then.sessions {
+		if !now.sessions[s] {
+			d.finished = append(d.finished, s)
+		}
+	}
+	for i := range now.occupied {
+		if !then.occupied[i] {
+			d.gained = append(d.gained, i)
+		}
+	}
+	for i := range then.occupied {
+		if !now.occupied[i] {
+			d.freed = append(d.freed, i)
+		}
+	}
+	sort.Strings(d.started)
+	sort.Strings(d.finished)
+	sort.Ints(d.gained)
+	sort.Ints(d.freed)
+	return d
+}
+
+// -- tui --
+
+// compareView is the open C overlay: the hourly picker, then the diff
+// against the chosen point.
+type compareView struct {
+	points []snapshotPoint
+	sel    int
+	then   *workState // nil until a point is loaded
+}
+
+type comparePointsMsg struct {
+	points []snapshotPoint
+	err    error
+}
+
+type compareStateMsg struct {
+	state workState
+	err   error
+}
+
+// compareLookback is how far back the picker offers points.
+const compareLookback = 24 * time.Hour
+
+func loadComparePointsCmd() tea.Msg {
+	db := sharedStateDB()
+	if db == nil {
+		return comparePointsMsg{err: fmt.Errorf("snapshot db unavailable")}
+	}
+	points, err := loadComparePoints(db, time.Now().Add(-compareLookback))
+	return comparePointsMsg{points: points, err: err}
+}
+
+func loadCompareStateCmd(id int64) tea.Cmd {
+	return func() tea.Msg {
+		db := sharedStateDB()
+		if db == nil {
+			return compareStateMsg{err: fmt.Errorf("snapshot db unavailable")}
+		}
+		s, err := loadSnapshotWorkState(db, id)
+		return compareStateMsg{state: s, err: err}
+	}
+}
+
+func (m model) handleCompareKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := *m.compare
+	action := activeKeymap.action(msg.String())
+	switch {
+	case msg.String() == "esc" || action == actCompare:
+		// from the diff, back to the picker; from the picker, close
+		if c.then != nil {
+			c.then = nil
+		} else {
+			m.compare = nil
+			return m, nil
+		}
+	case action == actDown && c.then == nil:
+		c.sel = min(c.sel+1, len(c.points)-1)
+	case action == actUp && c.then == nil:
+		c.sel = max(c.sel-1, 0)
+	case msg.String() == "enter" && c.then == nil && c.sel < len(c.points):
+		m.compare = &c
+		return m, loadCompareStateCmd(c.points[c.sel].id)
+	}
+	m.compare = &c
+	return m, nil
+}
+
+func (m model) renderCompareView(width int) string {
+	c := m.compare
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(truncateForWidth(s, width))
+		b.WriteString("\n")
+	}
+
+	if c.then == nil {
+		line(displayStyle.Render("compare to") + dimStyle.Render("  pick an earlier snapshot"))
+		b.WriteString("\n")
+		if len(c.points) == 0 {
+			line(dimStyle.Render("  no snapshots in the last day (snapshots are recorded by stop serve)"))
+		}
+		for i, p := range c.points {
+			cursor := "  "
+			if i == c.sel {
+				cursor = cursorStyle.Render("> ")
+			}
+			line(cursor + p.at.Local().Format("Mon 15:04") + dimStyle.Render("  "+humanDuration(time.Since(p.at))+" ago"))
+		}
+		return b.String()
+	}
+
+	p := c.points[c.sel]
+	d := diffWorkStates(*c.then, currentWorkState(m.spaces, m.windows, m.tmuxPanes))
+	line(displayStyle.Render("since "+p.at.Local().Format("15:04")) + dimStyle.Render("  "+humanDuration(time.Since(p.at))+" ago"))
+	b.WriteString("\n")
+	section := func(title string, items []string, style lipgloss.Style) {
+		if len(items) == 0 {
+			line(dimStyle.Render(fmt.Sprintf("  %s: none", title)))
+			return
+		}
+		line(dimStyle.Render(fmt.Sprintf("  %s (%d): ", title, len(items))) + style.Render(strings.Join(items, ", ")))
+	}
+	section("sessions started", d.started, freeStyle)
+	section("sessions finished", d.finished, warnStyle)
+	section("spaces gained", intStrings(d.gained), keyStyle)
+	section("spaces freed", intStrings(d.freed), freeStyle)
+	return b.String()
+}
+
+func intStrings(ns []int) []string {
+	out := make([]string, len(ns))
+	for i, n := range ns {
+		out[i] = fmt.Sprintf("%d", n)
+	}
+	return out
+}
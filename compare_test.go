@@ -0,0 +1,91 @@
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPruneSnapshots(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(snapshotSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 5, 20, 12, 0, 0, 0, time.UTC)
+	insert := func(at time.Time) int64 {
+		res, err := db.Exec("INSERT INTO snapshots (captured_at) VALUES (?)", at.Format(time.RFC3339))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := res.LastInsertId()
+		if _, err := db.Exec(`INSERT INTO snapshot_tmux_panes (snapshot_id, session_name, window_index, window_name, pane_index, current_command, last_activity_ms, history_size)
+			VALUES (?, 'work', 0, 'zsh', 0, 'zsh', 0, 0)`, id); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	recent := insert(now.Add(-time.Hour))
+	recent2 := insert(now.Add(-time.Hour + 30*time.Second))
+	hourFirst := insert(now.Add(-3 * 24 * time.Hour))
+	hourSecond := insert(now.Add(-3*24*time.Hour + 30*time.Second))
+	ancient := insert(now.Add(-30 * 24 * time.Hour))
+
+	n, err := pruneSnapshots(db, now, 24*time.Hour, 7*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("pruned %d, want 2", n)
+	}
+
+	var kept []int64
+	rows, err := db.Query("SELECT id FROM snapshots ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var id int64
+		rows.Scan(&id)
+		kept = append(kept, id)
+	}
+	rows.Close()
+	if want := []int64{recent, recent2, hourFirst}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept %v, want %v (dropped %d, %d)", kept, want, hourSecond, ancient)
+	}
+	var orphans int
+	db.QueryRow("SELECT COUNT(*) FROM snapshot_tmux_panes WHERE snapshot_id NOT IN (SELECT id FROM snapshots)").Scan(&orphans)
+	if orphans != 0 {
+		t.Errorf("%d orphaned pane rows", orphans)
+	}
+
+	points, err := loadComparePoints(db, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 || points[0].id != recent || points[1].id != hourFirst {
+		t.Errorf("points = %+v", points)
+	}
+}
+
+func TestDiffWorkStates(t *testing.T) {
+	then := workState{
+		sessions: map[string]bool{"api": true, "notes": true},
+		occupied: map[int]bool{1: true, 2: true},
+	}
+	now := currentWorkState(
+		[]Space{{Index: 1}, {Index: 2}, {Index: 3}},
+		[]Window{{Space: 1}, {Space: 3}, {Space: 2, IsMinimized: true}},
+		[]TmuxPane{{SessionName: "api"}, {SessionName: "web"}},
+	)
+	got := diffWorkStates(then, now)
+	want := stateDiff{started: []string{"web"}, finished: []string{"notes"}, gained: []int{3}, freed: []int{2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diff = %+v, want %+v", got, want)
+	}
+}
@@ -6,6 +6,11 @@
 
 package main
 
+import (
+	"regexp"
+	"time"
+)
+
 // productiveProcesses are tmux pane commands that represent meaningful
 // interactive work. only these get staleness coloring (green → red).
 // everything else renders dim regardless of activity.
@@ -22,7 +27,426 @@ func isProductive(command string) bool {
 	return productiveProcesses[command]
 }
 
+// bareAgentActiveCPU is how much CPU an agent running outside tmux must
+// use between refreshes to count as active (bareagents.go). an idle
+// agent still ticks over a little.
+const bareAgentActiveCPU = 200 * time.Millisecond
+
+// throttleOnBattery and throttleOnThermal slow refreshes down to
+// throttledPollInterval, and stop reading agent screens, while the
+// machine runs on battery or is thermally throttled (power.go). turn
+// both off to refresh at full speed regardless.
+var (
+	throttleOnBattery = true
+	throttleOnThermal = true
+)
+
+// throttledPollInterval is the slowest a throttled refresh is held to;
+// an interval already stretched by load (budget.go) stays longer.
+const throttledPollInterval = 10 * time.Second
+
+// agentStateRules read an agent pane's last lines (see agentstate.go):
+// the first rule whose tool matches the pane's agent (any, when empty)
+// and whose pattern matches decides its badge. "working" rules come
+// first since a busy agent's screen can still show an old prompt or
+// error above its spinner.
+var agentStateRules = []agentStateRule{
+	{paneWorking, "", regexp.MustCompile(`(?i)esc to interrupt|esc interrupt`)},
+	{paneErrored, "", regexp.MustCompile(`(?i)API Error|overloaded_error|rate limit(ed)?|panic:|Traceback \(most recent`)},
+	{paneAwaiting, "claude", regexp.MustCompile(`Do you want to|❯ 1\. Yes|\? for shortcuts`)},
+	{paneAwaiting, "codex", regexp.MustCompile(`(?i)allow command\?|⏎ send`)},
+	{paneAwaiting, "opencode", regexp.MustCompile(`(?i)enter send|permission required`)},
+}
+
+// enricherHooks are executables that add badges and lines to spaces and
+// sessions (see hooks.go for what they read and answer), e.g.
+// {"/Users/me/bin/stop-ci-status"}. empty runs none.
+var enricherHooks = []string{}
+
+// promptNotify sends a desktop notification the moment an agent pane
+// turns "awaiting input" (see prompts.go); the status line and the ◆
+// space marker appear either way.
+var promptNotify = true
+
+// autoLabelSpaces keeps each space's yabai label set to the tmux session
+// on it, as L does once (autolabel.go). labels set by hand are left alone.
+var autoLabelSpaces = false
+
+// terminalNotify sends notifications as terminal escape sequences —
+// terminalNotifyOSC9 or terminalNotifyOSC777 — instead of through the
+// desktop (termnotify.go), so they reach the machine the terminal is on
+// when stop runs over SSH. empty uses the desktop.
+var terminalNotify = ""
+
+// alertRules escalate agents left waiting (see alerts.go): each step
+// fires its channel once the agent has been idle that long — badge in the
+// TUI, desktop notification, then a push to alertPushURL — until the
+// alert is acknowledged (A) or the agent is seen or working again. rules
+// cover sessions at or above their priority, so low-priority sessions
+// never alert here.
+var alertRules = []alertRule{
+	{name: "waiting", priority: priorityNormal, steps: []alertStep{
+		{0, alertBadge},
+		{10 * time.Minute, alertDesktop},
+		{30 * time.Minute, alertPush},
+	}},
+}
+
+// alertPushURL receives push steps as a POST with the message as the body
+// and a Title header, which is what ntfy (https://ntfy.sh/<topic>)
+// expects. empty leaves push steps undeliverable.
+var alertPushURL = ""
+
+// the daily digest (`stop digest -send`, see digest.go) goes to
+// digestMailTo. with digestSMTPAddr empty it's handed to the local `mail`
+// command; otherwise it's sent over SMTP with STARTTLS (e.g.
+// "smtp.fastmail.com:587") as digestSMTPUser, with the password read
+// from $STOP_SMTP_PASSWORD. digestMailFrom defaults to digestSMTPUser.
+var (
+	digestMailTo   = ""
+	digestMailFrom = ""
+	digestSMTPAddr = ""
+	digestSMTPUser = ""
+)
+
+// silenceAnomalyFactor flags a productive pane silent for this many of
+// its command's usual runs (see baselines.go), learned from the last
+// baselineWindow of agent history. commands with fewer than
+// baselineMinRuns runs in that window aren't judged.
+const (
+	silenceAnomalyFactor = 4.0
+	baselineWindow       = 14 * 24 * time.Hour
+	baselineMinRuns      = 5
+)
+
+// responseSLOTarget is the objective for answering a waiting agent: the
+// time from an agent going idle to it working again. see slo.go.
+const responseSLOTarget = 10 * time.Minute
+
 // snapshotDBPath is the absolute path where snapshot history is persisted.
 // hardcoded so the binary finds the same db whether it's run from the repo
 // or installed via `go install` (executable-relative paths break that case).
 const snapshotDBPath = "/Users/regular/knowledge/personal/repositories/stop/snapshots.db"
+
+// serviceLabel names the launchd job `stop serve install` sets up
+// (launchd.go), and its plist in ~/Library/LaunchAgents.
+const serviceLabel = "com.fadedlamp42.stop.serve"
+
+// updateCheck looks for a newer release when the dashboard starts, at
+// most once a day, and mentions it in the status line (update.go). set
+// false to opt out; `stop update` works either way.
+var updateCheck = true
+
+// snapshot retention (see compare.go): every snapshot is kept for
+// snapshotFullRetention, the first of each hour until
+// snapshotHourlyRetention, nothing after. `stop history` looks for gaps
+// between snapshots, so keep the full window at least as long as the
+// range you run it over (its --since defaults to a week).
+const (
+	snapshotFullRetention   = 7 * 24 * time.Hour
+	snapshotHourlyRetention = 90 * 24 * time.Hour
+)
+
+// sessionNamePattern is the naming convention for tmux sessions. stop
+// finds a terminal's session by matching the window title against session
+// names, so names that are easy to mistype or that the terminal mangles
+// (spaces, colons, dots) quietly break that correlation. sessions that
+// don't match are flagged and offered a rename (R). nil disables the check.
+var sessionNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// terminalTitlePattern pulls the session name out of a terminal window
+// title, for terminals with several windows under one process (kitty)
+// whose titles aren't just the session name: a regexp with a "session"
+// group (or just one group), e.g. `^\[(?P<session>[^\]]+)\]` for a
+// set-titles-string of "[#S] #W". exact names, and titles that start with
+// the session name followed by a space or colon, match without it. nil
+// turns it off.
+var terminalTitlePattern *regexp.Regexp
+
+// queryTimeouts overrides how long each query may run, by source:
+// engine.SourceYabai (3s by default), SourceTmux, SourcePs and
+// SourceOsascript (2s each). raise them on a machine so loaded that
+// refreshes time out (engine/timeouts.go).
+var queryTimeouts = map[string]time.Duration{}
+
+// queryRetries is how many more times a query that timed out is tried
+// before the refresh gives up on it, waiting queryRetryBackoff before the
+// first retry and twice as long before each after that.
+const (
+	queryRetries      = 1
+	queryRetryBackoff = 250 * time.Millisecond
+)
+
+// ignoreApps leaves out the windows of matching apps (ignore.go), e.g.
+// regexp.MustCompile(`^(Finder|Stickies)$`); a space holding only those
+// counts as free. nil shows every app.
+var ignoreApps *regexp.Regexp
+
+// ignoreSpaceLabels leaves out spaces whose label matches, e.g.
+// regexp.MustCompile(`^scratch$`). nil shows every space.
+var ignoreSpaceLabels *regexp.Regexp
+
+// balanceMinGap is how far apart two displays' free-space counts must be
+// before stop suggests moving spaces between them (see balance.go).
+const balanceMinGap = 4
+
+// frameCadence is how often the page is redrawn when nothing on it has
+// changed but the clock, moving relative times along (see frame.go).
+const frameCadence = time.Second
+
+// focusSessionLength is how long a focus session (t) runs before it
+// counts as done.
+const focusSessionLength = 25 * time.Minute
+
+// spaceGroupSeparator splits a space label into group and name: with
+// "/", spaces labeled "work/api" and "work/frontend" share a "work"
+// header in their display column (see spacegroups.go).
+var spaceGroupSeparator = "/"
+
+// liveWindowRules flag spaces with a call or media on them (see live.go):
+// the first rule whose app (any, when empty) and title pattern match a
+// window decides what it is.
+var liveWindowRules = []liveWindowRule{
+	{liveMeeting, "zoom.us", regexp.MustCompile(`^Zoom (Meeting|Webinar)`)},
+	{liveMeeting, "", regexp.MustCompile(`^Meet - [a-z]{3}-[a-z]{4}-[a-z]{3}`)},
+	{liveMeeting, "Microsoft Teams", regexp.MustCompile(`(?i)\b(meeting|call)\b`)},
+	{liveMeeting, "Slack", regexp.MustCompile(`(?i)\bhuddle\b`)},
+	{liveMeeting, "FaceTime", regexp.MustCompile(`.`)},
+	{liveMedia, "", regexp.MustCompile(` - YouTube( |$)`)},
+	{liveMedia, "", regexp.MustCompile(` - Twitch( |$)`)},
+	{liveMedia, "Spotify", regexp.MustCompile(`^.+ [-–] .+$`)},
+}
+
+// paneEnvBadges are the environment variables shown as badges next to
+// each tmux pane (see env.go), with the label each badge uses. empty
+// turns environment inspection off entirely.
+var paneEnvBadges = []envBadge{
+	{"AWS_PROFILE", "aws"},
+	{"KUBECONFIG", "kube"},
+	{"NODE_ENV", "node"},
+}
+
+// kubeContextColors colors the ⎈ badge of panes running kubectl, k9s or
+// helm (see kube.go): the first entry whose match is a substring of the
+// context name wins. colors take the same forms as theme colors.
+var kubeContextColors = []kubeContextColor{
+	{"prod", "bright-red"},
+	{"staging", "yellow"},
+}
+
+// stalenessTiers are the activity bands panes and sessions are colored
+// by, freshest first: a pane lands in the first tier whose threshold its
+// last output is younger than, and the last tier (under 0) takes
+// everything older. add, drop or rename tiers freely — an agent that legitimately
+// thinks for 20 minutes wants a wider band before it reads as stale.
+// role is the theme color a tier takes (active, recent, cooling, stale or
+// cold; the tier's name when omitted). names are what agent filters match
+// and what `stop serve` reports as a pane's "tier".
+var stalenessTiers = []stalenessBand{
+	{name: "active", under: time.Minute},
+	{name: "recent", under: 5 * time.Minute},
+	{name: "cooling", under: 15 * time.Minute},
+	{name: "stale", under: time.Hour},
+	{name: "cold"},
+}
+
+// focusMode is what enter (and double-click) does to keyboard focus.
+// "switch" follows it to the chosen space. "restore" switches the space
+// and hands focus back to the window that had it, so a space on another
+// display can be brought up without leaving the input field you're typing
+// in. yabai can't change spaces without focusing a window on the new one,
+// so restore re-focuses afterwards rather than never taking focus; on the
+// same display the old window is hidden and focus stays on the new space.
+var focusMode = focusModeSwitch
+
+// focusCommand switches spaces in place of the window manager, with
+// {index}, {desktop}, {display} and {label} filled in from the space
+// (focuscmd.go), e.g. {"skhd", "-k", "alt - {desktop}"}. nil asks the
+// window manager.
+var focusCommand []string
+
+// focusFallback is how spaces are focused when yabai's scripting addition
+// isn't loaded: "osascript" or "skhd" press Mission Control's ctrl+N
+// "Switch to Desktop" shortcut through that tool (turn the shortcuts on
+// in System Settings › Keyboard › Keyboard Shortcuts), "" leaves focusing
+// broken until the addition is loaded (engine/yabaisa.go).
+var focusFallback = "osascript"
+
+// keyBindings remaps dashboard keys: action → keys, replacing that
+// action's defaults (see defaultKeyBindings in keys.go for the actions).
+// a binding may be a space-separated sequence, e.g.
+//
+//	keyBindings = map[string][]string{
+//		"down": {"n", "down"}, "up": {"e", "up"}, "top": {"g g"},
+//	}
+var keyBindings = map[string][]string{}
+
+// countTimeout is how long a digit waits for a move to count (3j) before
+// jumping to its space as it does alone (bookmarks.go).
+const countTimeout = 350 * time.Millisecond
+
+// themeName is the built-in theme used when --theme isn't given: default,
+// solarized, gruvbox, high-contrast, or no-color (see theme.go).
+var themeName = "default"
+
+// themeBackground picks the light or dark variant of the theme: "auto"
+// asks the terminal, "dark" or "light" skip the query.
+var themeBackground = "auto"
+
+// themeOverrides recolors individual roles on top of the theme, e.g.
+// {"cursor": "#ff8800", "cold": "bright-red"}. roles are listed in
+// themeRoles; colors are ANSI numbers, #hex, or names.
+var themeOverrides = map[string]string{}
+
+// appIcons are the nerd font glyphs drawn before app names (icons.go),
+// matched exactly and then case-insensitively; terminals without an
+// entry get a generic terminal glyph. --no-icons turns them off.
+var appIcons = map[string]string{
+	"kitty":              "\uf489",     // nf-oct-terminal
+	"iTerm2":             "\uf489",     // nf-oct-terminal
+	"Firefox":            "\uf269",     // nf-fa-firefox
+	"Google Chrome":      "\uf268",     // nf-fa-chrome
+	"Safari":             "\uf267",     // nf-fa-safari
+	"Slack":              "\U000f04b1", // nf-md-slack
+	"Discord":            "\U000f066f", // nf-md-discord
+	"Spotify":            "\uf1bc",     // nf-fa-spotify
+	"Music":              "\U000f075a", // nf-md-music
+	"Code":               "\U000f0a1e", // nf-md-microsoft_visual_studio_code
+	"Visual Studio Code": "\U000f0a1e", // nf-md-microsoft_visual_studio_code
+	"Finder":             "\U000f024b", // nf-md-folder
+	"Mail":               "\U000f01ee", // nf-md-email
+}
+
+// browserTabsEnabled asks browsers for their tabs so browser windows show
+// a tab count (tabs.go). off by default: each ask takes a few hundred
+// milliseconds per browser, and macOS prompts for automation access the
+// first time.
+var browserTabsEnabled = false
+
+// browserTabTitles lists each browser window's tab titles in the detail
+// panel, with browserTabsEnabled on.
+var browserTabTitles = false
+
+// summaryLine shows the whole desk's counts on the page's top line —
+// spaces, free spaces, productive panes per staleness tier — and when the
+// last refresh landed (summary.go).
+var summaryLine = true
+
+// skhdConfigPath is skhd's config, read for the hotkeys that focus each
+// space so their rows can show them (skhd.go). empty turns it off.
+var skhdConfigPath = "~/.config/skhd/skhdrc"
+
+// statusFresh is how long an action's result stays bright on the status
+// line, and statusLinger how long before it's cleared (statusbar.go).
+const (
+	statusFresh  = 2 * time.Second
+	statusLinger = 6 * time.Second
+)
+
+// titleHistoryLength is how many recent window titles each space keeps
+// for the detail panel's "earlier" list (titlehistory.go).
+const titleHistoryLength = 8
+
+// browserCDPURL is a Chromium DevTools endpoint to list tabs from, e.g.
+// "http://localhost:9222" for a browser started with
+// --remote-debugging-port=9222. empty skips it.
+var browserCDPURL = ""
+
+// eventHooks maps a watch event (space_freed, agent_finished,
+// agent_stale, focus_changed, ...; "*" for all) to shell commands run
+// when it fires (eventhooks.go).
+var eventHooks = map[string][]string{}
+
+// spaceThumbnails lets `stop serve` answer /preview/{space} with a
+// screenshot of the space (thumbnail.go), as `stop serve --previews`
+// does. off by default: macOS asks for screen recording access the first
+// time, and a screenshot shows whatever is on screen to whoever can
+// reach the port.
+var spaceThumbnails = false
+
+// paneTails lets `stop serve` answer /tmux/{session}/{window}/{pane}/tail
+// with a pane's recent output (scrollback.go), as `stop serve --tails`
+// does. off by default: scrollback can hold tokens and secrets, and
+// serve has no auth, so turn it on only where the port is private.
+var paneTails = false
+
+// spaceThumbnailSize is the longest edge of a thumbnail, in pixels.
+const spaceThumbnailSize = 480
+
+// spaceThumbnailInterval is how often a space's thumbnail may be retaken;
+// requests in between get the cached one.
+const spaceThumbnailInterval = 10 * time.Second
+
+// mqttBroker is an MQTT broker's host:port, e.g.
+// "homeassistant.local:1883", that `stop serve` publishes the desk's
+// summary to (mqtt.go). empty publishes nothing.
+var mqttBroker = ""
+
+// mqttUsername and mqttPassword log in to mqttBroker; empty connects
+// anonymously.
+var (
+	mqttUsername = ""
+	mqttPassword = ""
+)
+
+// mqttClientID is how stop introduces itself to the broker.
+const mqttClientID = "stop"
+
+// mqttInterval is how often the summary is published.
+const mqttInterval = 15 * time.Second
+
+// mqttTopics maps each summary value to the topic it's published on; a
+// value without a topic isn't published.
+var mqttTopics = map[string]string{
+	mqttFocusedSpace:        "stop/focused_space",
+	mqttStalestAgentMinutes: "stop/stalest_agent_minutes",
+	mqttFreeSpaces:          "stop/free_spaces",
+}
+
+// cleanupStaleAfter is how long a session without a productive pane has
+// to sit quiet before c offers to kill it (cleanup.go).
+const cleanupStaleAfter = 12 * time.Hour
+
+// cleanupKeepFreeSpaces is how many empty spaces each display keeps when
+// c offers to destroy the rest.
+const cleanupKeepFreeSpaces = 2
+
+// workspaceTemplates are what `stop new` and N build workspaces from
+// (workspace.go): a tmux session in a directory with windows of panes,
+// each pane starting a command, on a space of its own.
+var workspaceTemplates = map[string]workspaceTemplate{
+	"agent": {dir: "~", windows: []templateWindow{
+		{name: "agent", panes: []string{"claude"}},
+		{name: "shell", panes: []string{"", ""}, layout: "even-horizontal"},
+	}},
+}
+
+// defaultWorkspaceTemplate is the template N builds; empty (or unknown)
+// takes the first by name.
+var defaultWorkspaceTemplate = "agent"
+
+// workspaceTerminal opens a terminal attached to a new workspace's
+// session, with {session} and {dir} filled in. it opens on the focused
+// space, which is the workspace's by then. empty opens none.
+var workspaceTerminal = []string{
+	"kitty", "--single-instance", "--directory", "{dir}",
+	"tmux", "attach-session", "-t", "={session}",
+}
+
+// agentUsageEnabled reads claude's and codex's own logs for the tokens
+// each session's agents used today and what they cost, shown in the
+// detail panel and kept per day by `stop serve` for `stop report`
+// (usage.go).
+var agentUsageEnabled = false
+
+// modelPrices is dollars per million tokens, by a part of the model's
+// name; the longest part that matches wins. models matching none are
+// counted but cost nothing.
+var modelPrices = map[string]modelPrice{
+	"opus":       {input: 15, output: 75, cacheRead: 1.5, cacheWrite: 18.75},
+	"opus-4-5":   {input: 5, output: 25, cacheRead: 0.5, cacheWrite: 6.25},
+	"sonnet":     {input: 3, output: 15, cacheRead: 0.3, cacheWrite: 3.75},
+	"haiku":      {input: 1, output: 5, cacheRead: 0.1, cacheWrite: 1.25},
+	"gpt-5":      {input: 1.25, output: 10, cacheRead: 0.125},
+	"gpt-5-mini": {input: 0.25, output: 2, cacheRead: 0.025},
+}
@@ -1,16 +1,15 @@
-// data layer: yabai and tmux subprocess queries.
+// data layer: everything a refresh reads.
 //
-// all external data comes through here. queries run with context timeouts
-// to avoid hanging if yabai or tmux are unresponsive. the fetchAll function
-// runs all three queries (spaces, windows, tmux) concurrently via goroutines
-// so total latency is max(query times) instead of sum.
+// the window manager, tmux and ps come from the engine package (see
+// engine.Fetch); the rest — the now-playing script, annotations,
+// priorities, nvim, pane environments — is stop's own. fetchAll runs
+// them all concurrently so total latency is max(query times) instead of
+// sum, with context timeouts so an unresponsive source can't hang it.
 
 package main
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -18,6 +17,18 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// the engine's types under their historical names
+type (
+	Space      = engine.Space
+	Frame      = engine.Frame
+	Display    = engine.Display
+	Window     = engine.Window
+	TmuxPane   = engine.TmuxPane
+	TmuxClient = engine.TmuxClient
 )
 
 // (queryNowPlaying removed: PlayingMeta carries artist/title now, so the
@@ -152,22 +163,6 @@ func runPlaying(args ...string) ([]byte, bool) {
 	return out, true
 }
 
-// terminal emulator app names as reported by macOS / yabai
-var terminalApps = map[string]bool{
-	"kitty":     true,
-	"iTerm2":    true,
-	"Terminal":  true,
-	"Alacritty": true,
-	"WezTerm":   true,
-	"Hyper":     true,
-	"Rio":       true,
-	"Tabby":     true,
-}
-
-func isTerminal(app string) bool {
-	return terminalApps[app]
-}
-
 // browser apps whose window titles contain useful page info
 var browserApps = map[string]bool{
 	"Firefox":        true,
@@ -196,177 +191,54 @@ func cleanBrowserTitle(title string) string {
 	return title
 }
 
-// -- yabai types --
-// fields match yabai's JSON output (hyphenated keys)
-
-// Space represents a macOS space/desktop as reported by yabai
-type Space struct {
-	ID        int    `json:"id"`
-	Index     int    `json:"index"`
-	Label     string `json:"label"`
-	Display   int    `json:"display"`
-	Windows   []int  `json:"windows"`
-	HasFocus  bool   `json:"has-focus"`
-	IsVisible bool   `json:"is-visible"`
-}
-
-// Window represents an application window as reported by yabai
-type Window struct {
-	ID          int    `json:"id"`
-	PID         int    `json:"pid"`
-	App         string `json:"app"`
-	Title       string `json:"title"`
-	Space       int    `json:"space"`
-	IsVisible   bool   `json:"is-visible"`
-	IsMinimized bool   `json:"is-minimized"`
-	IsHidden    bool   `json:"is-hidden"`
-}
-
-// TmuxPane holds per-pane data from tmux including staleness and buffer info
-type TmuxPane struct {
-	SessionName    string
-	WindowIndex    int
-	WindowName     string
-	PaneIndex      int
-	CurrentCommand string
-	CurrentPath    string // working directory of the pane's active process
-	PanePID        int    // PID of the pane's active process
-	LastActivity   time.Time
-	HistorySize    int // lines in scroll buffer
-}
-
-// -- queries --
-
-func queryYabai(domain string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-	return exec.CommandContext(ctx, "yabai", "-m", "query", "--"+domain).Output()
-}
-
-func querySpaces() ([]Space, error) {
-	data, err := queryYabai("spaces")
-	if err != nil {
-		return nil, err
+// focusSpace tells the window manager to switch focus to a specific space
+// index. with focusMode "restore" (config.go) the window that had keyboard
+// focus gets it back afterwards, as long as it's still on screen.
+func focusSpace(index int) error {
+	wm := currentWM()
+	if focusMode != focusModeRestore {
+		return switchSpace(wm, index)
 	}
-	var spaces []Space
-	return spaces, json.Unmarshal(data, &spaces)
-}
-
-func queryWindows() ([]Window, error) {
-	data, err := queryYabai("windows")
-	if err != nil {
-		return nil, err
+	before, _ := wm.QueryWindows()
+	if err := switchSpace(wm, index); err != nil {
+		return err
 	}
-	var windows []Window
-	return windows, json.Unmarshal(data, &windows)
-}
-
-// queryTmuxPanes fetches per-pane data from all tmux sessions.
-// returns nil if tmux is not running or has no sessions.
-func queryTmuxPanes() []TmuxPane {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-	out, err := exec.CommandContext(ctx, "tmux", "list-panes", "-a", "-F",
-		"#{session_name}\t#{window_index}\t#{window_name}\t#{pane_index}\t#{pane_current_command}\t#{window_activity}\t#{history_size}\t#{pane_current_path}\t#{pane_pid}").Output()
+	after, err := wm.QueryWindows()
 	if err != nil {
-		return nil
+		return nil // focused, just not restored
 	}
-	var panes []TmuxPane
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		if line == "" {
-			continue
-		}
-		parts := strings.Split(line, "\t")
-		if len(parts) < 9 {
-			continue
-		}
-		var windowIndex, paneIndex, historySize, panePID int
-		var activityEpoch int64
-		fmt.Sscanf(parts[1], "%d", &windowIndex)
-		fmt.Sscanf(parts[3], "%d", &paneIndex)
-		fmt.Sscanf(parts[5], "%d", &activityEpoch)
-		fmt.Sscanf(parts[6], "%d", &historySize)
-		fmt.Sscanf(parts[8], "%d", &panePID)
-		panes = append(panes, TmuxPane{
-			SessionName:    parts[0],
-			WindowIndex:    windowIndex,
-			WindowName:     parts[2],
-			PaneIndex:      paneIndex,
-			CurrentCommand: parts[4],
-			CurrentPath:    parts[7],
-			PanePID:        panePID,
-			LastActivity:   time.Unix(activityEpoch, 0),
-			HistorySize:    historySize,
-		})
-	}
-	return panes
+	if id := focusToRestore(before, after); id != 0 {
+		wm.FocusWindow(id)
+	}
+	return nil
 }
 
-// TmuxClient maps a tmux client process to its session.
-// used to correlate tmux sessions with terminal windows via process tree.
-type TmuxClient struct {
-	PID         int
-	SessionName string
-}
+// focus modes for enter / space switching
+const (
+	focusModeSwitch  = "switch"  // follow focus to the space
+	focusModeRestore = "restore" // switch the space, keep the input focus
+)
 
-// queryTmuxClients fetches the PID and session name for each attached tmux client.
-// returns nil if tmux is not running or has no attached clients.
-func queryTmuxClients() []TmuxClient {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-	out, err := exec.CommandContext(ctx, "tmux", "list-clients", "-F",
-		"#{client_pid}\t#{session_name}").Output()
-	if err != nil {
-		return nil
-	}
-	var clients []TmuxClient
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		if line == "" {
-			continue
-		}
-		parts := strings.Split(line, "\t")
-		if len(parts) < 2 {
-			continue
+// focusToRestore picks the window to hand focus back to after a switch:
+// the one focused before, if it's still visible (typically it sits on
+// another display) and lost focus. 0 when there's nothing to restore —
+// switching the space on its own display necessarily hides it.
+func focusToRestore(before, after []Window) int {
+	prev := 0
+	for _, w := range before {
+		if w.HasFocus {
+			prev = w.ID
 		}
-		var pid int
-		fmt.Sscanf(parts[0], "%d", &pid)
-		clients = append(clients, TmuxClient{PID: pid, SessionName: parts[1]})
 	}
-	return clients
-}
-
-// queryProcessTree returns a pid → ppid map and pid → comm map for all
-// running processes. used to walk from tmux client PIDs up to terminal
-// emulator PIDs, and to detect productive process descendants.
-func queryProcessTree() (map[int]int, map[int]string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-	out, err := exec.CommandContext(ctx, "ps", "-eo", "pid,ppid,comm").Output()
-	if err != nil {
-		return nil, nil
-	}
-	tree := make(map[int]int)
-	comm := make(map[int]string)
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "PID") {
-			continue
-		}
-		var pid, ppid int
-		var cmd string
-		if _, err := fmt.Sscanf(line, "%d %d %s", &pid, &ppid, &cmd); err == nil {
-			tree[pid] = ppid
-			comm[pid] = cmd
+	if prev == 0 {
+		return 0
+	}
+	for _, w := range after {
+		if w.ID == prev && w.IsVisible && !w.HasFocus {
+			return prev
 		}
 	}
-	return tree, comm
-}
-
-// focusSpace tells yabai to switch focus to a specific space index
-func focusSpace(index int) {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-	exec.CommandContext(ctx, "yabai", "-m", "space", "--focus", fmt.Sprintf("%d", index)).Run()
+	return 0
 }
 
 // resolveProductivePanePIDs walks up from every productive process in the
@@ -419,6 +291,7 @@ func resolveProductivePanePIDs(
 // fetchResult holds the combined result of all concurrent queries
 type fetchResult struct {
 	spaces               []Space
+	displays             []Display
 	windows              []Window
 	tmuxPanes            []TmuxPane
 	tmuxClients          []TmuxClient
@@ -429,84 +302,152 @@ type fetchResult struct {
 	nvimWindows          []NvimWindow         // flat list, each tagged with PanePID/NvimPID
 	nvimSessions         []NvimSession        // one per reachable nvim instance
 	playingMeta          PlayingMeta          // single sample of player state used for both UI + interpolation
+	annotations          annotationSet        // external status strings keyed by session / space
+	priorities           prioritySet          // triage levels keyed by session / space
+	slo                  *sloReport           // running response-time compliance; nil when unavailable
+	alerts               alertSet             // fired, unacknowledged alerts by session (alerts.go)
+	baselines            commandBaselines     // usual run per command (baselines.go)
+	paneEnv              map[int]map[string]string // pane_pid → badge env keys (env.go)
+	paneKube             map[int]string       // pane_pid → kube context (kube.go)
+	paneStates           map[int]paneState    // pane_pid → agent state from its screen (agentstate.go)
+	browserTabs          map[int][]string     // window id → tab titles (tabs.go)
+	terminalSessions     map[int][]engine.TerminalSession // window id → its tabs and split panes (shells.go)
+	bareAgents           map[int][]bareAgent  // window id → agents running outside tmux (bareagents.go)
+	throttle             string               // why refreshes are slowed, "" when they aren't (power.go)
+	idle                 time.Duration        // time since the last keyboard / mouse input (idle.go)
+	idleKnown            bool                 // false when idle couldn't be read
+	focusSession         *focusSession        // the running focus timer, nil when none (focussession.go)
+	spaceTime            map[string]time.Duration // spaceTitle → focus time today (spacetime.go)
+	agentUsage           map[string]agentUsage    // session → agents' usage today (usage.go)
+	spaceHotkeys         map[int]string           // space index → the skhd hotkey focusing it (skhd.go)
+	enrichments          enrichSet            // enricher hooks' last answers (hooks.go)
+	fetchTook            time.Duration        // wall-clock time of the whole fetch
+	wmErr                error                // window manager unreachable: tmux-only data
+	sourceErrs           []engine.SourceError // best-effort queries that failed (sources.go)
 	err                  error
 }
 
-// fetchAll queries yabai (spaces + windows) and tmux concurrently.
-// spaces query is required; windows and tmux are best-effort.
+// fetchAll is fetchFrom this process's window manager, tmux and ps
+// (wm.go).
 func fetchAll() fetchResult {
+	return fetchFrom(currentWM(), currentQuerier())
+}
+
+// fetchFrom runs the engine's fetch (window manager, tmux, ps) alongside
+// stop's own sources concurrently. windows and tmux are best-effort.
+// spaces are required for the grid, but when the window manager is
+// missing or disabled the tmux half still stands on its own: the result
+// degrades to tmux only, with wmErr set.
+func fetchFrom(wm engine.WindowManager, q engine.Querier) fetchResult {
+	start := time.Now()
 	var (
-		spaces              []Space
-		windows             []Window
-		tmuxPanes           []TmuxPane
-		tmuxClients         []TmuxClient
-		processTree         map[int]int
-		processComm         map[int]string
-		productivePanePIDs  map[int]bool
-		spaceErr            error
-		mu                  sync.Mutex
-		wg                  sync.WaitGroup
+		core               engine.State
+		productivePanePIDs map[int]bool
+		mu                 sync.Mutex
+		wg                 sync.WaitGroup
 	)
 
 	var playingMeta PlayingMeta
+	var annotations annotationSet
+	var priorities prioritySet
+	var slo *sloReport
+	var alerts alertSet
+	var baselines commandBaselines
+	var idle time.Duration
+	var idleKnown bool
+	var focus *focusSession
+	var spaceTime map[string]time.Duration
+
+	wg.Add(10)
 
-	wg.Add(6)
+	go func() {
+		defer wg.Done()
+		r := engine.FetchFrom(wm, q)
+		mu.Lock()
+		core = r
+		mu.Unlock()
+	}()
 
 	go func() {
 		defer wg.Done()
-		m := queryPlayingMeta()
+		r := querySLO()
 		mu.Lock()
-		playingMeta = m
+		slo = r
+		mu.Unlock()
+	}()
+
+	go func() {
+		defer wg.Done()
+		b := queryBaselines()
+		mu.Lock()
+		baselines = b
+		mu.Unlock()
+	}()
+
+	go func() {
+		defer wg.Done()
+		a := queryAlerts()
+		mu.Lock()
+		alerts = a
 		mu.Unlock()
 	}()
 
 	go func() {
 		defer wg.Done()
-		s, err := querySpaces()
+		p := queryPriorities()
 		mu.Lock()
-		spaces, spaceErr = s, err
+		priorities = p
 		mu.Unlock()
 	}()
 
 	go func() {
 		defer wg.Done()
-		w, _ := queryWindows()
+		a := queryAnnotations()
 		mu.Lock()
-		windows = w
+		annotations = a
 		mu.Unlock()
 	}()
 
 	go func() {
 		defer wg.Done()
-		t := queryTmuxPanes()
+		m := queryPlayingMeta()
 		mu.Lock()
-		tmuxPanes = t
+		playingMeta = m
 		mu.Unlock()
 	}()
 
 	go func() {
 		defer wg.Done()
-		c := queryTmuxClients()
+		t := querySpaceTimeToday()
 		mu.Lock()
-		tmuxClients = c
+		spaceTime = t
 		mu.Unlock()
 	}()
 
 	go func() {
 		defer wg.Done()
-		t, c := queryProcessTree()
+		s := queryFocusSession()
 		mu.Lock()
-		processTree = t
-		processComm = c
+		focus = s
+		mu.Unlock()
+	}()
+
+	go func() {
+		defer wg.Done()
+		d, ok := queryIdle()
+		mu.Lock()
+		idle, idleKnown = d, ok
 		mu.Unlock()
 	}()
 
 	wg.Wait()
 
-	// spaces are required — can't render anything without them
-	if spaceErr != nil {
-		return fetchResult{err: spaceErr}
+	// without spaces there's no grid; fail only when there's no tmux to
+	// fall back to either (and the user didn't ask for tmux only)
+	if core.WMErr != nil && !tmuxOnly && len(core.TmuxPanes) == 0 {
+		return fetchResult{err: core.WMErr}
 	}
+	tmuxPanes, processTree, processComm := core.TmuxPanes, core.ProcessTree, core.ProcessComm
 
 	// nvim introspection runs after the first phase since it needs both
 	// tmuxPanes (to filter) and processTree (to map nvim → pane). queries
@@ -514,6 +455,13 @@ func fetchAll() fetchResult {
 	// and one round-trip pulls buffers + windows + session state.
 	capture := collectNvimState(tmuxPanes, processTree)
 
+	// environment badges also walk each pane's process tree
+	paneEnv := queryPaneEnv(tmuxPanes, processTree)
+	paneKube := queryPaneKube(tmuxPanes, processTree, processComm)
+
+	// browser tabs come from a background cache, so this never blocks
+	browserTabs := queryBrowserTabs(core.Windows)
+
 	// compute which pane PIDs have a productive process somewhere in
 	// their descendant tree. this handles wrapper scripts and any
 	// nesting depth — the fast check on pane_current_command alone
@@ -522,17 +470,62 @@ func fetchAll() fetchResult {
 		productivePanePIDs = resolveProductivePanePIDs(tmuxPanes, processTree, processComm)
 	}
 
+	// agent panes are read off their screens once we know which they are,
+	// unless the machine is on battery or running hot
+	throttle := throttleReason(time.Now())
+	var paneStates map[int]paneState
+	if throttle == "" {
+		paneStates = queryPaneStates(tmuxPanes, productivePanePIDs, processTree, processComm)
+	}
+
+	result := coreResult(core)
+	result.productivePanePIDs = productivePanePIDs
+	result.nvimBuffers = capture.PerPaneBuffers
+	result.nvimWindows = capture.Windows
+	result.nvimSessions = capture.Sessions
+	result.playingMeta = playingMeta
+	result.annotations = annotations
+	result.priorities = priorities
+	result.slo = slo
+	result.alerts = alerts
+	result.baselines = baselines
+	result.paneEnv = paneEnv
+	result.paneKube = paneKube
+	result.paneStates = paneStates
+	result.throttle = throttle
+	result.browserTabs = browserTabs
+	result.idle, result.idleKnown = idle, idleKnown
+	result.focusSession = focus
+	result.spaceTime = spaceTime
+	if processTree != nil && processComm != nil {
+		result.bareAgents = queryBareAgents(core.Windows, core.Sessions, tmuxPanes, processTree, processComm, time.Now())
+	}
+	result.agentUsage = queryAgentUsage(tmuxPanes, productivePanePIDs, time.Now())
+	result.spaceHotkeys = spaceHotkeys(loadSkhdBindings(), core.Spaces)
+	result.fetchTook = time.Since(start)
+
+	// hooks see everything else, so they go last
+	var hookErrs []engine.SourceError
+	result.enrichments, hookErrs = queryEnrichments(result)
+	result.sourceErrs = append(result.sourceErrs, hookErrs...)
+	return result
+}
+
+// coreResult is the engine's half of a fetch on its own: the window
+// manager, tmux and ps, none of stop's own sources. tests build results
+// from a fixture (engine.Fake) with it.
+func coreResult(core engine.State) fetchResult {
 	return fetchResult{
-		spaces:             spaces,
-		windows:            windows,
-		tmuxPanes:          tmuxPanes,
-		tmuxClients:        tmuxClients,
-		processTree:        processTree,
-		processComm:        processComm,
-		productivePanePIDs: productivePanePIDs,
-		nvimBuffers:        capture.PerPaneBuffers,
-		nvimWindows:        capture.Windows,
-		nvimSessions:       capture.Sessions,
-		playingMeta:        playingMeta,
+		spaces:      core.Spaces,
+		displays:    core.Displays,
+		windows:     core.Windows,
+		tmuxPanes:   core.TmuxPanes,
+		tmuxClients: core.TmuxClients,
+		processTree: core.ProcessTree,
+		processComm: core.ProcessComm,
+		terminalSessions: core.Sessions,
+		fetchTook:   core.Took,
+		wmErr:       core.WMErr,
+		sourceErrs:  core.Errors,
 	}
 }
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestFocusToRestore(t *testing.T) {
+	before := []Window{{ID: 1, IsVisible: true, HasFocus: true}, {ID: 2, IsVisible: true}}
+
+	// the old window is still up on its display: hand focus back
+	after := []Window{{ID: 1, IsVisible: true}, {ID: 2, IsVisible: true}, {ID: 3, IsVisible: true, HasFocus: true}}
+	if got := focusToRestore(before, after); got != 1 {
+		t.Errorf("focusToRestore = %d, want 1", got)
+	}
+
+	// the switch replaced its space: nothing to restore
+	after = []Window{{ID: 1}, {ID: 3, IsVisible: true, HasFocus: true}}
+	if got := focusToRestore(before, after); got != 0 {
+		t.Errorf("hidden window restored: %d", got)
+	}
+
+	// still focused, or nothing was focused to begin with
+	if got := focusToRestore(before, before); got != 0 {
+		t.Errorf("already focused window restored: %d", got)
+	}
+	if got := focusToRestore([]Window{{ID: 2}}, after); got != 0 {
+		t.Errorf("restored without a focused window: %d", got)
+	}
+}
@@ -0,0 +1,42 @@
+// debuglog: a structured trace for diagnosing yabai / tmux integration.
+//
+// with --debug stop writes a log/slog text log to a file (the TUI owns
+// the terminal, so never to stderr): how long each refresh query took,
+// every subprocess with its exit code, and the mapping decisions that
+// are otherwise invisible — chiefly why a tmux session ended up under
+// "detached" instead of on a display. without --debug the logger
+// discards everything.
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// debugLog is the process logger; a no-op until openDebugLog, which
+// also hands it to the engine.
+var debugLog = slog.New(slog.DiscardHandler)
+
+// defaultDebugLogPath is where --debug writes without --debug-file.
+func defaultDebugLogPath() string {
+	return filepath.Join(os.TempDir(), "stop-debug.log")
+}
+
+// openDebugLog points debugLog at path (appending) and returns the file
+// for the caller to close.
+func openDebugLog(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening debug log: %w", err)
+	}
+	debugLog = slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	engine.SetLogger(debugLog)
+	debugLog.Info("debug log started", "pid", os.Getpid(), "args", strings.Join(os.Args[1:], " "))
+	return f, nil
+}
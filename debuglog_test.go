@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestDebugLog(t *testing.T) {
+	saved := debugLog
+	defer func() {
+		debugLog = saved
+		engine.SetLogger(saved)
+	}()
+
+	path := filepath.Join(t.TempDir(), "debug.log")
+	f, err := openDebugLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	err = exec.Command("sh", "-c", "exit 3").Run()
+	engine.LogCommand(start, "sh", []string{"-c", "exit 3"}, err)
+	partitionTmuxByDisplay([]TmuxPane{{SessionName: "api"}}, nil, nil, nil, nil)
+	f.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	for _, want := range []string{`cmd="sh -c exit 3"`, "exit=3", `session=api why="no attached tmux client"`} {
+		if !strings.Contains(log, want) {
+			t.Errorf("debug log missing %s:\n%s", want, log)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// demo: a made-up desk to try the dashboard on.
+//
+// `stop --demo` runs against demoDesk instead of the machine: three
+// displays (a laptop under two monitors), a dozen spaces with labels
+// and label groups, kitty windows showing tmux sessions, the usual
+// browser and chat windows, a minimized one, and coding agents at every
+// staleness. the desk moves on its own — every fetch some agents do a
+// little work and others go quiet — and answers the focus, move and
+// restore actions, so the whole UI can be tried, screencast or worked on
+// with neither yabai nor tmux installed. nothing else is touched: the
+// demo runs read-only for everything but its own desk. the same seed
+// gives the same desk, which keeps screencasts repeatable.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// demoSeed makes the demo desk the same every run.
+const demoSeed = 42
+
+// demoSession is one generated tmux session and where its kitty window
+// sits.
+type demoSession struct {
+	name    string
+	space   int
+	command string        // the pane's foreground command
+	idle    time.Duration // how long ago it last did something, at start
+}
+
+var demoSessions = []demoSession{
+	{"api", 1, "claude", 20 * time.Second},
+	{"web", 2, "codex", 4 * time.Minute},
+	{"infra", 4, "claude", 12 * time.Minute},
+	{"train", 7, "claude", 45 * time.Minute},
+	{"eval", 8, "opencode", 3 * time.Hour},
+	{"docs", 10, "nvim", 2 * time.Minute},
+	{"scratch", 0, "zsh", time.Hour}, // no window: detached
+}
+
+// demoDesk is a generated window manager, tmux server and process table.
+type demoDesk struct {
+	mu       sync.Mutex
+	rng      *rand.Rand
+	displays []Display
+	spaces   []Space
+	windows  []Window
+	panes    []TmuxPane
+	clients  []TmuxClient
+	tree     map[int]int
+	comm     map[int]string
+	ttys     map[int]string // window id → tty
+	focused  int            // space index
+}
+
+func newDemoDesk(now time.Time, seed int64) *demoDesk {
+	d := &demoDesk{
+		rng: rand.New(rand.NewSource(seed)),
+		displays: []Display{
+			{ID: 1, UUID: "demo-left", Index: 1, Name: "DELL U2720Q", Frame: Frame{X: 0, Y: 0, W: 2560, H: 1440}, PixelWidth: 3840, PixelHeight: 2160},
+			{ID: 2, UUID: "demo-right", Index: 2, Name: "LG HDR 4K", Frame: Frame{X: 2560, Y: 0, W: 2560, H: 1440}, PixelWidth: 3840, PixelHeight: 2160},
+			{ID: 3, UUID: "demo-built-in", Index: 3, Name: "Built-in Retina Display", Frame: Frame{X: 1800, Y: 1440, W: 1512, H: 982}, PixelWidth: 3024, PixelHeight: 1964},
+		},
+		tree:    map[int]int{500: 1, 800: 1},
+		comm:    map[int]string{500: "kitty", 800: "tmux"},
+		ttys:    map[int]string{},
+		focused: 1,
+	}
+	labels := []string{"code", "web", "", "infra", "chat", "mail", "ml/train", "ml/eval", "", "docs", "music", ""}
+	for i, label := range labels {
+		display := 1 + i/4
+		d.spaces = append(d.spaces, Space{ID: 100 + i, Index: i + 1, Label: label, Display: display})
+	}
+
+	id := 1000
+	window := func(app, title string, space, pid int) *Window {
+		id++
+		d.windows = append(d.windows, Window{ID: id, PID: pid, App: app, Title: title, Space: space, IsVisible: true})
+		return &d.windows[len(d.windows)-1]
+	}
+	for i, s := range demoSessions {
+		paneShell, panePID := 900+10*i, 901+10*i
+		d.tree[paneShell], d.tree[panePID] = 800, 800
+		d.comm[paneShell], d.comm[panePID] = "zsh", s.command
+		d.panes = append(d.panes,
+			TmuxPane{SessionName: s.name, WindowIndex: 0, WindowName: s.command, CurrentCommand: s.command,
+				CurrentPath: "/Users/demo/" + s.name, PanePID: panePID, LastActivity: now.Add(-s.idle), HistorySize: 1500 + 100*i, Progress: -1},
+			TmuxPane{SessionName: s.name, WindowIndex: 1, WindowName: "zsh", CurrentCommand: "zsh",
+				CurrentPath: "/Users/demo/" + s.name, PanePID: paneShell, LastActivity: now.Add(-s.idle - time.Hour), HistorySize: 200, Progress: -1},
+		)
+		if s.space == 0 {
+			continue
+		}
+		// kitty is one process; each window's shell runs a tmux client
+		shell, client, tty := 600+i, 700+i, fmt.Sprintf("/dev/ttys%03d", i+1)
+		d.tree[shell], d.tree[client] = 500, shell
+		d.comm[shell], d.comm[client] = "zsh", "tmux"
+		w := window("kitty", s.name, s.space, 500)
+		d.ttys[w.ID] = tty
+		d.clients = append(d.clients, TmuxClient{PID: client, SessionName: s.name, TTY: tty, TermName: "xterm-kitty"})
+	}
+	window("Safari", "localhost:3000", 2, 510)
+	window("Safari", "Pull Request #812", 2, 510)
+	window("Slack", "#deploys", 5, 520)
+	window("Mail", "Inbox (12)", 6, 530)
+	window("Spotify", "Spotify Premium", 11, 540)
+	window("Figma", "dashboard v3", 3, 550)
+	notes := window("Notes", "standup", 10, 560)
+	notes.IsMinimized, notes.IsVisible = true, false
+	return d
+}
+
+// tick moves the desk along: agents work or go quiet, and the focused
+// space follows. called on each spaces query, the first of a fetch.
+func (d *demoDesk) tick(now time.Time) {
+	for i := range d.panes {
+		p := &d.panes[i]
+		if !isProductive(p.CurrentCommand) || d.rng.Intn(4) != 0 {
+			continue
+		}
+		// a busy agent keeps busy; a stale one rarely wakes
+		if now.Sub(p.LastActivity) < 10*time.Minute || d.rng.Intn(10) == 0 {
+			p.LastActivity = now
+			p.HistorySize += 10 + d.rng.Intn(90)
+		}
+	}
+	for i := range d.spaces {
+		d.spaces[i].HasFocus = d.spaces[i].Index == d.focused
+		d.spaces[i].IsVisible = d.spaces[i].HasFocus
+		d.spaces[i].Windows = d.spaces[i].Windows[:0]
+		for _, w := range d.windows {
+			if w.Space == d.spaces[i].Index {
+				d.spaces[i].Windows = append(d.spaces[i].Windows, w.ID)
+			}
+		}
+	}
+}
+
+func (d *demoDesk) Name() string { return "demo" }
+
+func (d *demoDesk) QuerySpaces() ([]Space, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tick(time.Now())
+	spaces := slices.Clone(d.spaces)
+	for i := range spaces {
+		spaces[i].Windows = slices.Clone(spaces[i].Windows)
+	}
+	return spaces, nil
+}
+
+func (d *demoDesk) QueryDisplays() ([]Display, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.displays), nil
+}
+
+func (d *demoDesk) QueryWindows() ([]Window, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.windows), nil
+}
+
+func (d *demoDesk) QueryTmuxPanes() ([]TmuxPane, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.panes), nil
+}
+
+func (d *demoDesk) QueryTmuxClients() ([]TmuxClient, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.clients), nil
+}
+
+func (d *demoDesk) QueryProcessTree([]TmuxClient, time.Time) (map[int]int, map[int]string, error) {
+	return d.tree, d.comm, nil
+}
+
+func (d *demoDesk) AttachWindowTTYs(windows []Window) (map[int][]engine.TerminalSession, error) {
+	for i := range windows {
+		if tty, ok := d.ttys[windows[i].ID]; ok {
+			windows[i].TTY = tty
+		}
+	}
+	return nil, nil
+}
+
+func (d *demoDesk) FocusSpace(index int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !slices.ContainsFunc(d.spaces, func(s Space) bool { return s.Index == index }) {
+		return fmt.Errorf("no demo space %d", index)
+	}
+	d.focused = index
+	return nil
+}
+
+// window runs f on a window; callers hold mu.
+func (d *demoDesk) window(id int, f func(w *Window)) error {
+	for i := range d.windows {
+		if d.windows[i].ID == id {
+			f(&d.windows[i])
+			return nil
+		}
+	}
+	return fmt.Errorf("no demo window %d", id)
+}
+
+func (d *demoDesk) FocusWindow(id int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.window(id, func(w *Window) { d.focused = w.Space })
+}
+
+func (d *demoDesk) RestoreWindow(id int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.window(id, func(w *Window) { w.IsMinimized, w.IsHidden, w.IsVisible = false, false, true })
+}
+
+func (d *demoDesk) MoveWindow(id, space int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.window(id, func(w *Window) { w.Space = space })
+}
+
+func (d *demoDesk) LabelSpace(index int, label string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range d.spaces {
+		if d.spaces[i].Index == index {
+			d.spaces[i].Label = label
+			return nil
+		}
+	}
+	return fmt.Errorf("no demo space %d", index)
+}
+
+// CreateSpace renumbers like yabai: the new space goes after the
+// display's last one, and the spaces behind it shift up.
+func (d *demoDesk) CreateSpace(display int) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	at, id := 0, 0
+	for _, s := range d.spaces {
+		if s.Display <= display {
+			at = max(at, s.Index)
+		}
+		id = max(id, s.ID)
+	}
+	index := at + 1
+	for i := range d.spaces {
+		if d.spaces[i].Index >= index {
+			d.spaces[i].Index++
+		}
+	}
+	for i := range d.windows {
+		if d.windows[i].Space >= index {
+			d.windows[i].Space++
+		}
+	}
+	if d.focused >= index {
+		d.focused++
+	}
+	d.spaces = append(d.spaces, Space{ID: id + 1, Index: index, Display: display})
+	slices.SortFunc(d.spaces, func(a, b Space) int { return a.Index - b.Index })
+	return index, nil
+}
+
+func (d *demoDesk) ToggleScratchpad(string) error { return engine.ErrReadOnly }
+func (d *demoDesk) FlashWindows([]int) error      { return nil }
+func (d *demoDesk) DestroySpace(int) error        { return engine.ErrReadOnly }
+
+// startDemo swaps the machine for a demo desk; call before currentWM.
+func startDemo() {
+	simulated = newDemoDesk(time.Now(), demoSeed)
+	engine.SetReadOnly(true)
+}
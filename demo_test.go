@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestDemoDesk(t *testing.T) {
+	now := time.Now()
+	d := newDemoDesk(now, demoSeed)
+
+	// every staleness tier starts with an agent in it
+	productive := resolveProductivePanePIDs(d.panes, d.tree, d.comm)
+	tiers := map[int]bool{}
+	for _, p := range d.panes {
+		if productive[p.PanePID] {
+			tiers[stalenessTierFor(now.Sub(p.LastActivity))] = true
+		}
+	}
+	if len(tiers) < len(stalenessTiers) {
+		t.Errorf("agents cover tiers %v of %d", tiers, len(stalenessTiers))
+	}
+
+	s := engine.FetchFrom(d, d)
+	if len(s.Errors) != 0 {
+		t.Fatalf("demo fetch failed: %v", s.Errors)
+	}
+
+	groups := buildDisplayGroups(s.Spaces, s.Windows, s.Displays)
+	if len(groups) != 3 || groups[2].layoutRow != 1 || groups[2].display.ShortName() != "built-in" {
+		t.Fatalf("groups = %+v", groups)
+	}
+	byDisplay, detached := partitionTmuxByDisplay(s.TmuxPanes, s.TmuxClients, s.ProcessTree, s.Windows, groups)
+	if len(detached) != 2 || detached[0].SessionName != "scratch" {
+		t.Errorf("detached = %+v, want only scratch's panes", detached)
+	}
+	if len(byDisplay[1]) != 6 || len(byDisplay[2]) != 4 || len(byDisplay[3]) != 2 {
+		t.Errorf("panes per display = %d, %d, %d", len(byDisplay[1]), len(byDisplay[2]), len(byDisplay[3]))
+	}
+}
+
+func TestDemoDeskActions(t *testing.T) {
+	d := newDemoDesk(time.Now(), demoSeed)
+	if err := d.FocusSpace(7); err != nil {
+		t.Fatal(err)
+	}
+	spaces, _ := d.QuerySpaces()
+	if !spaces[6].HasFocus || spaces[0].HasFocus {
+		t.Errorf("focus didn't move to space 7")
+	}
+
+	windows, _ := d.QueryWindows()
+	if err := d.MoveWindow(windows[0].ID, 9); err != nil {
+		t.Fatal(err)
+	}
+	spaces, _ = d.QuerySpaces()
+	if len(spaces[8].Windows) != 1 || spaces[8].Windows[0] != windows[0].ID {
+		t.Errorf("space 9 windows = %v", spaces[8].Windows)
+	}
+	if err := d.FocusSpace(99); err == nil {
+		t.Error("focused a space that doesn't exist")
+	}
+}
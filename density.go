@@ -0,0 +1,140 @@
+// density: how much each space row shows.
+//
+// z cycles normal → compact → expanded. compact is one line per space
+// with counts instead of window titles, for fitting a lot of spaces on a
+// small terminal; expanded adds a line per tmux pane with its working
+// directory and scrollback size. the choice persists in the ui_state
+// table of the snapshot db, so the dashboard comes back the way it was
+// left.
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+const uiStateSchema = `
+CREATE TABLE IF NOT EXISTS ui_state (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+`
+
+// ui_state keys
+const uiStateDensity = "density"
+
+type density int
+
+const (
+	densityNormal density = iota
+	densityCompact
+	densityExpanded
+)
+
+var densityNames = []string{"normal", "compact", "expanded"}
+
+func (d density) String() string {
+	if d < 0 || int(d) >= len(densityNames) {
+		return densityNames[0]
+	}
+	return densityNames[d]
+}
+
+func (d density) next() density {
+	return (d + 1) % density(len(densityNames))
+}
+
+// parseDensity maps a stored name back; unknown names read as normal.
+func parseDensity(name string) density {
+	for i, n := range densityNames {
+		if n == name {
+			return density(i)
+		}
+	}
+	return densityNormal
+}
+
+// getUIState reads one ui_state value; "" when unset.
+func getUIState(db *sql.DB, key string) (string, error) {
+	var value string
+	err := db.QueryRow("SELECT value FROM ui_state WHERE key = ?", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return value, err
+}
+
+// setUIState upserts one ui_state value.
+func setUIState(db *sql.DB, key, value string) error {
+	_, err := db.Exec(
+		"INSERT INTO ui_state (key, value, updated_at) VALUES (?, ?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at",
+		key, value, time.Now().UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// densityLoadedMsg carries the persisted density at startup.
+type densityLoadedMsg density
+
+// stateSavedMsg reports a failed ui_state write; success needs no
+// follow-up.
+type stateSavedMsg struct{ err error }
+
+func loadDensityCmd() tea.Msg {
+	db := sharedStateDB()
+	if db == nil {
+		return densityLoadedMsg(densityNormal)
+	}
+	name, _ := getUIState(db, uiStateDensity)
+	return densityLoadedMsg(parseDensity(name))
+}
+
+func saveDensityCmd(d density) tea.Cmd {
+	return func() tea.Msg {
+		db := sharedStateDB()
+		if db == nil {
+			return stateSavedMsg{err: fmt.Errorf("state db unavailable")}
+		}
+		return stateSavedMsg{err: setUIState(db, uiStateDensity, d.String())}
+	}
+}
+
+// renderSpaceCounts is compact mode's stand-in for the window list:
+// windows, tmux sessions, and agents on the space.
+func renderSpaceCounts(row spaceRow, tmuxBySession map[string][]TmuxPane, rc renderContext) string {
+	sessions, agents := 0, 0
+	for _, w := range row.windows {
+		panes, ok := tmuxBySession[strings.TrimSpace(w.Title)]
+		if !engine.IsTerminal(w.App) || !ok {
+			continue
+		}
+		sessions++
+		for _, p := range panes {
+			if rc.productivePanePIDs[p.PanePID] {
+				agents++
+			}
+		}
+	}
+	if len(row.windows) == 0 {
+		return dimStyle.Render("--")
+	}
+	text := fmt.Sprintf("%d win", len(row.windows))
+	if sessions > 0 {
+		text += fmt.Sprintf("  %d tmux", sessions)
+	}
+	if agents > 0 {
+		text += fmt.Sprintf("  %d agent", agents)
+		if agents > 1 {
+			text += "s"
+		}
+	}
+	return dimStyle.Render(text)
+}
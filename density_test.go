@@ -0,0 +1,66 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestDensityPersistence(t *testing.T) {
+	if got := densityExpanded.next(); got != densityNormal {
+		t.Errorf("expanded.next() = %v, want normal", got)
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(uiStateSchema); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := getUIState(db, uiStateDensity); err != nil || v != "" {
+		t.Fatalf("unset state = %q, %v", v, err)
+	}
+	for _, d := range []density{densityCompact, densityExpanded} {
+		if err := setUIState(db, uiStateDensity, d.String()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v, _ := getUIState(db, uiStateDensity)
+	if parseDensity(v) != densityExpanded {
+		t.Errorf("stored density = %q, want expanded", v)
+	}
+}
+
+func TestSpaceRowDensity(t *testing.T) {
+	row := spaceRow{
+		space:   Space{Index: 1},
+		windows: []Window{{App: "kitty", Title: "api"}, {App: "Firefox", Title: "docs"}},
+	}
+	tmux := map[string][]TmuxPane{
+		"api": {
+			{SessionName: "api", WindowIndex: 1, WindowName: "main", PaneIndex: 0, PanePID: 7, CurrentCommand: "claude", CurrentPath: "/src/api", HistorySize: 2500, Progress: -1},
+			{SessionName: "api", WindowIndex: 1, WindowName: "main", PaneIndex: 1, PanePID: 8, CurrentCommand: "zsh", Progress: -1},
+		},
+	}
+	rc := renderContext{productivePanePIDs: map[int]bool{7: true}}
+
+	rc.density = densityCompact
+	text, targets := renderSpaceRow(row, 1, 1, false, 40, tmux, rc)
+	if strings.Contains(text, "\n") || len(targets) != 1 {
+		t.Errorf("compact row spans lines:\n%s", text)
+	}
+	if !strings.Contains(text, "2 win  1 tmux  1 agent") {
+		t.Errorf("compact row missing counts: %s", text)
+	}
+
+	rc.density = densityExpanded
+	text, targets = renderSpaceRow(row, 1, 1, false, 40, tmux, rc)
+	if n := strings.Count(text, "\n") + 1; n != 4 || len(targets) != 4 {
+		t.Errorf("expanded row has %d lines / %d targets, want 4 (space, window, 2 panes):\n%s", n, len(targets), text)
+	}
+	if !strings.Contains(text, "2.5k lines") {
+		t.Errorf("expanded row missing history size:\n%s", text)
+	}
+}
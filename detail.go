@@ -0,0 +1,125 @@
+// detail: a right-hand panel describing the selected space in full.
+//
+// the grid squeezes each space onto a line or three; the detail panel (i,
+// or enter on the space that already has focus) lists everything instead:
+// every window with its pid, title and floating / zoom state, and every
+// tmux pane its terminals show with path, scrollback and activity. the
+// panel follows the cursor, and while it's open the grid drops to compact
+// rows so the two fit side by side.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// detailPanelMinWidth keeps the panel readable on narrow terminals.
+const detailPanelMinWidth = 40
+
+// renderDetailPanel describes one space within width cells.
+func renderDetailPanel(row spaceRow, panes []TmuxPane, width int, rc renderContext) string {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(truncateForWidth(s, width))
+		b.WriteString("\n")
+	}
+
+	title := fmt.Sprintf("space %d", row.space.Index)
+	if row.space.Label != "" {
+		title += " [" + row.space.Label + "]"
+	}
+	line(displayStyle.Render(title) + dimStyle.Render(fmt.Sprintf("  display %d", row.space.Display)))
+	if d := rc.spaceTime[spaceTitle(row.space)]; d > 0 {
+		line(dimStyle.Render("focused " + humanDuration(d) + " today"))
+	}
+	b.WriteString("\n")
+
+	line(dimStyle.Render(fmt.Sprintf("windows (%d)", len(row.windows))))
+	if len(row.windows) == 0 {
+		line(dimStyle.Render("  --"))
+	}
+	for _, w := range row.windows {
+		var flags []string
+		if w.IsFloating {
+			flags = append(flags, "floating")
+		}
+		if w.IsSticky {
+			flags = append(flags, "sticky")
+		}
+		if w.IsNativeFullscreen {
+			flags = append(flags, "fullscreen")
+		} else if w.HasFullscreenZoom {
+			flags = append(flags, "zoomed")
+		} else if w.HasParentZoom {
+			flags = append(flags, "parent zoom")
+		}
+		head := "  " + keyStyle.Render(withIcon(w.App)) + dimStyle.Render(fmt.Sprintf("  pid %d", w.PID))
+		if len(flags) > 0 {
+			head += "  " + warnStyle.Render(strings.Join(flags, ", "))
+		}
+		line(head)
+		if t := strings.TrimSpace(w.Title); t != "" {
+			line("    " + t)
+		}
+		if tabs := rc.browserTabs[w.ID]; browserTabTitles && len(tabs) > 0 {
+			line(dimStyle.Render(fmt.Sprintf("    tabs (%d)", len(tabs))))
+			for _, t := range tabs {
+				line(dimStyle.Render("      " + t))
+			}
+		}
+	}
+
+	if earlier := renderEarlierTitles(row, rc.titleHistory); len(earlier) > 0 {
+		b.WriteString("\n")
+		for _, l := range earlier {
+			line(l)
+		}
+	}
+
+	sessions := sessionsOnSpace(row, panes)
+	if len(sessions) == 0 {
+		return strings.TrimRight(b.String(), "\n")
+	}
+	b.WriteString("\n")
+	line(dimStyle.Render(fmt.Sprintf("tmux (%d)", len(sessions))))
+	for _, s := range sessions {
+		var own []TmuxPane
+		for _, p := range panes {
+			if p.SessionName == s {
+				own = append(own, p)
+			}
+		}
+		prio := rc.priorities.forSession(s)
+		head := "  " + keyStyle.Render(s)
+		if u, ok := rc.agentUsage[s]; ok {
+			head += dimStyle.Render("  today " + u.String())
+		}
+		line(head)
+		for _, win := range groupPanesByWindow(own) {
+			line(dimStyle.Render(fmt.Sprintf("    %d:%s", win.index, win.name)))
+			for _, p := range win.panes {
+				style := dimStyle
+				if rc.productivePanePIDs[p.PanePID] {
+					style = prio.stalenessStyle(p.LastActivity)
+				}
+				line(fmt.Sprintf("      %s %s  %s  %s",
+					style.Render(fmt.Sprintf("%d ▎ %s", p.PaneIndex, p.CurrentCommand)),
+					dimStyle.Render(formatRelativeTime(p.LastActivity)),
+					dimStyle.Render(formatHistorySize(p.HistorySize)+" lines"),
+					dimStyle.Render(fmt.Sprintf("pid %d", p.PanePID)),
+				))
+				if p.CurrentPath != "" {
+					line("        " + shortPath(p.CurrentPath))
+				}
+				if badges := renderPaneBadges(rc, p.PanePID); badges != "" {
+					line("        " + badges)
+				}
+				if anomaly := renderSilenceAnomaly(rc, p); anomaly != "" {
+					line("        " + anomaly)
+				}
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
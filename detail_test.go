@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderDetailPanel(t *testing.T) {
+	row := spaceRow{
+		space: Space{Index: 3, Label: "api", Display: 1},
+		windows: []Window{
+			{App: "kitty", PID: 500, Title: "api"},
+			{App: "Preview", PID: 612, Title: "diagram.png", IsFloating: true, HasFullscreenZoom: true},
+		},
+	}
+	panes := []TmuxPane{
+		{SessionName: "api", WindowIndex: 1, WindowName: "main", PaneIndex: 0, PanePID: 900, CurrentCommand: "claude", CurrentPath: "/src/api", HistorySize: 12000},
+		{SessionName: "web", WindowIndex: 1, WindowName: "main", PanePID: 901, CurrentCommand: "zsh"},
+	}
+
+	panel := renderDetailPanel(row, panes, 80, renderContext{})
+	for _, want := range []string{"space 3 [api]", "pid 612", "floating, zoomed", "diagram.png", "12k lines", "pid 900", "/src/api"} {
+		if !strings.Contains(panel, want) {
+			t.Errorf("panel missing %q:\n%s", want, panel)
+		}
+	}
+	if strings.Contains(panel, "pid 901") {
+		t.Errorf("panel lists a session that isn't on the space:\n%s", panel)
+	}
+}
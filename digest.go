@@ -0,0 +1,251 @@
+// digest: the daily agent-utilization report, printed or mailed.
+//
+// `stop digest` sums up one day from the agent registry's status log:
+// how long each agent worked, how long it sat waiting on someone, and
+// how the day did against the response slo. with -send the report goes
+// out by mail instead of to stdout — over SMTP when digestSMTPAddr is
+// set, through the local `mail` command otherwise — so the record ends
+// up in an inbox or archive. run it from cron shortly after midnight and
+// it reports on the day before.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// digestLookback is how far before the day the status log is read, so an
+// agent already working (or waiting) at midnight has a status to carry in.
+const digestLookback = 7 * 24 * time.Hour
+
+// agentDay is one agent's time within the day.
+type agentDay struct {
+	AgentID string
+	Name    string
+	Working time.Duration
+	Waiting time.Duration // idle or seen
+	Runs    int           // working stretches started within the day
+}
+
+// dailyDigest is the report for one day.
+type dailyDigest struct {
+	From, To time.Time
+	Agents   []agentDay // most worked first
+	SLO      sloReport
+}
+
+// agentDays clips each agent's status intervals to [from, to): a status
+// lasts until the agent's next transition, or until to after its last.
+// events must be ordered by agent then time; ones at or after to are
+// ignored. agents with no working or waiting time in the day are left
+// out.
+func agentDays(events []agentStatusEvent, from, to time.Time) []agentDay {
+	var days []agentDay
+	for i := 0; i < len(events); {
+		j := i
+		for j < len(events) && events[j].AgentID == events[i].AgentID {
+			j++
+		}
+		day := agentDay{AgentID: events[i].AgentID}
+		for k := i; k < j && events[k].At.Before(to); k++ {
+			ev := events[k]
+			end := to
+			if k+1 < j && events[k+1].At.Before(to) {
+				end = events[k+1].At
+			}
+			start := ev.At
+			if start.Before(from) {
+				start = from
+			}
+			span := end.Sub(start)
+			if span <= 0 {
+				continue
+			}
+			switch ev.Status {
+			case agentWorking:
+				day.Working += span
+				if !ev.At.Before(from) && (k == i || events[k-1].Status != agentWorking) {
+					day.Runs++
+				}
+			case agentIdle, agentSeen:
+				day.Waiting += span
+			}
+		}
+		if day.Working > 0 || day.Waiting > 0 {
+			days = append(days, day)
+		}
+		i = j
+	}
+	sort.SliceStable(days, func(a, b int) bool { return days[a].Working > days[b].Working })
+	return days
+}
+
+// loadDailyDigest builds the report for [from, to), with to capped at now
+// for a day still in progress.
+func loadDailyDigest(db *sql.DB, from, to, now time.Time) (dailyDigest, error) {
+	if to.After(now) {
+		to = now
+	}
+	events, err := loadAgentStatusEvents(db, from.Add(-digestLookback))
+	if err != nil {
+		return dailyDigest{}, err
+	}
+	agents, err := loadAgents(db, false)
+	if err != nil {
+		return dailyDigest{}, err
+	}
+	names := map[string]string{}
+	for _, a := range agents {
+		names[a.ID] = a.Name
+	}
+
+	d := dailyDigest{From: from, To: to, Agents: agentDays(events, from, to)}
+	for i := range d.Agents {
+		if name := names[d.Agents[i].AgentID]; name != "" {
+			d.Agents[i].Name = name
+		} else {
+			d.Agents[i].Name = d.Agents[i].AgentID
+		}
+	}
+	var episodes []sloEpisode
+	for _, ep := range sloEpisodes(events) {
+		if !ep.idleAt.Before(from) && ep.idleAt.Before(to) {
+			episodes = append(episodes, ep)
+		}
+	}
+	d.SLO = computeSLO(episodes, responseSLOTarget, to)
+	return d, nil
+}
+
+// subject is the mail subject line.
+func (d dailyDigest) subject() string {
+	return "stop digest " + d.From.Format("2006-01-02")
+}
+
+// text renders the report as plain text, the same on stdout and in mail.
+func (d dailyDigest) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "stop digest for %s\n\n", d.From.Format("Mon 2006-01-02"))
+
+	var working, waiting time.Duration
+	for _, a := range d.Agents {
+		working += a.Working
+		waiting += a.Waiting
+	}
+	fmt.Fprintf(&b, "agents    %d active, %s working, %s waiting\n", len(d.Agents), humanDuration(working), humanDuration(waiting))
+	if d.SLO.total() == 0 {
+		fmt.Fprintf(&b, "response  no decided waits (%d pending)\n", d.SLO.Pending)
+	} else {
+		fmt.Fprintf(&b, "response  %.0f%% within %s (%d met, %d breached), worst %s\n",
+			d.SLO.compliance()*100, humanDuration(d.SLO.Target), d.SLO.Met, d.SLO.Breached, humanDuration(d.SLO.Worst))
+	}
+	if len(d.Agents) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n")
+	nameWidth := 0
+	for _, a := range d.Agents {
+		nameWidth = max(nameWidth, len(a.Name))
+	}
+	for _, a := range d.Agents {
+		fmt.Fprintf(&b, "  %-*s  %8s working  %8s waiting  %d runs\n",
+			nameWidth, a.Name, humanDuration(a.Working), humanDuration(a.Waiting), a.Runs)
+	}
+	return b.String()
+}
+
+// digestMessage is an RFC 5322 plain-text message with CRLF line endings.
+func digestMessage(from, to, subject, body string, now time.Time) []byte {
+	headers := []string{
+		"From: " + from,
+		"To: " + to,
+		"Subject: " + subject,
+		"Date: " + now.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	}
+	msg := strings.Join(headers, "\r\n") + "\r\n\r\n" + body
+	return []byte(strings.ReplaceAll(strings.ReplaceAll(msg, "\r\n", "\n"), "\n", "\r\n"))
+}
+
+// sendDigest mails the report to digestMailTo.
+func sendDigest(d dailyDigest, now time.Time) error {
+	if digestMailTo == "" {
+		return fmt.Errorf("digestMailTo isn't set")
+	}
+	if err := engine.Guard("mail", digestMailTo); err != nil {
+		return err
+	}
+	if digestSMTPAddr == "" {
+		cmd := exec.Command("mail", "-s", d.subject(), digestMailTo)
+		cmd.Stdin = strings.NewReader(d.text())
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("running mail: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(digestSMTPAddr)
+	if err != nil {
+		return fmt.Errorf("digestSMTPAddr: %w", err)
+	}
+	var auth smtp.Auth
+	if digestSMTPUser != "" {
+		auth = smtp.PlainAuth("", digestSMTPUser, os.Getenv("STOP_SMTP_PASSWORD"), host)
+	}
+	from := digestMailFrom
+	if from == "" {
+		from = digestSMTPUser
+	}
+	msg := digestMessage(from, digestMailTo, d.subject(), d.text(), now)
+	if err := smtp.SendMail(digestSMTPAddr, auth, from, []string{digestMailTo}, msg); err != nil {
+		return fmt.Errorf("sending digest: %w", err)
+	}
+	return nil
+}
+
+// digestCommand is the entry point for `stop digest` — day is
+// YYYY-MM-DD in local time, yesterday when empty.
+func digestCommand(day string, send bool) error {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.Local)
+	if day != "" {
+		t, err := time.ParseInLocation("2006-01-02", day, time.Local)
+		if err != nil {
+			return fmt.Errorf("parsing day: %w", err)
+		}
+		from = t
+	}
+	to := from.AddDate(0, 0, 1)
+
+	db, err := openSnapshotDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	d, err := loadDailyDigest(db, from, to, now)
+	if err != nil {
+		return err
+	}
+	if !send {
+		fmt.Print(d.text())
+		return nil
+	}
+	if err := sendDigest(d, now); err != nil {
+		return err
+	}
+	fmt.Printf("sent %s to %s\n", d.subject(), digestMailTo)
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAgentDays(t *testing.T) {
+	from := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	at := func(h, m int) time.Time { return from.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+	events := []agentStatusEvent{
+		// working since the evening before: only the part after midnight
+		// counts, and it isn't a run started today
+		{AgentID: "a", Status: agentWorking, At: at(-2, 0)},
+		{AgentID: "a", Status: agentIdle, At: at(1, 0)},
+		{AgentID: "a", Status: agentWorking, At: at(2, 0)},
+		{AgentID: "a", Status: agentSeen, At: at(2, 30)},
+		{AgentID: "a", Status: agentGone, At: at(3, 0)},
+		// still working at the end of the day; tomorrow's event is ignored
+		{AgentID: "b", Status: agentWorking, At: at(22, 0)},
+		{AgentID: "b", Status: agentIdle, At: at(25, 0)},
+		// gone before the day started
+		{AgentID: "c", Status: agentGone, At: at(-1, 0)},
+	}
+	days := agentDays(events, from, to)
+	if len(days) != 2 {
+		t.Fatalf("days = %+v", days)
+	}
+	// most worked first
+	b, a := days[0], days[1]
+	if a.AgentID != "a" || a.Working != 90*time.Minute || a.Waiting != 90*time.Minute || a.Runs != 1 {
+		t.Errorf("a = %+v", a)
+	}
+	if b.AgentID != "b" || b.Working != 2*time.Hour || b.Waiting != 0 || b.Runs != 1 {
+		t.Errorf("b = %+v", b)
+	}
+}
+
+func TestLoadDailyDigest(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(agentSchema); err != nil {
+		t.Fatal(err)
+	}
+	db.Exec(`INSERT INTO agents (id, name, command, session_name, window_index, pane_index, pane_pid, started_at, last_seen_at, status)
+		VALUES ('a', 'api/claude', 'claude', 'api', 0, 0, 1, '2026-01-02T09:00:00Z', '2026-01-02T09:00:00Z', 'seen')`)
+	db.Exec(`INSERT INTO agent_status_events (agent_id, status, at) VALUES
+		('a', 'working', '2026-01-02T09:00:00Z'), ('a', 'idle', '2026-01-02T10:00:00Z'), ('a', 'seen', '2026-01-02T10:05:00Z')`)
+
+	from := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 1, 2, 11, 0, 0, 0, time.UTC) // day still in progress
+	d, err := loadDailyDigest(db, from, from.Add(24*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Agents) != 1 || d.Agents[0].Name != "api/claude" || d.Agents[0].Waiting != time.Hour {
+		t.Fatalf("agents = %+v", d.Agents)
+	}
+	if d.SLO.Met != 1 {
+		t.Errorf("slo = %+v", d.SLO)
+	}
+	text := d.text()
+	if !strings.Contains(text, "1 active, 1h0m working") || !strings.Contains(text, "api/claude") {
+		t.Errorf("text = %q", text)
+	}
+
+	msg := string(digestMessage("me@example.com", "you@example.com", d.subject(), text, now))
+	if !strings.Contains(msg, "Subject: stop digest 2026-01-02\r\n") || strings.Contains(strings.ReplaceAll(msg, "\r\n", ""), "\n") {
+		t.Errorf("message = %q", msg)
+	}
+}
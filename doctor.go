@@ -0,0 +1,286 @@
+// doctor: `stop doctor`, a pass/fail checklist for a broken dashboard.
+//
+// most reports of "everything shows as detached" come down to one of a
+// handful of causes: yabai missing or without its scripting addition,
+// tmux not on PATH, ps unable to see other processes, a slow query
+// timing out, or a tmux client whose process-tree walk never reaches a
+// terminal window. doctor runs the same queries a refresh does, times
+// them, and walks every attached client the way engine.PartitionTmux
+// does, printing one line per check with the reason for each failure.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// yabaiScriptingAddition is where `yabai --install-sa` puts the payload
+// that space focus and destroy depend on.
+const yabaiScriptingAddition = "/Library/ScriptingAdditions/yabai.osax"
+
+// doctorSlowQuery flags a query as too slow for a smooth refresh.
+const doctorSlowQuery = 500 * time.Millisecond
+
+// doctorCheck is one line of the report.
+type doctorCheck struct {
+	name   string
+	ok     bool
+	detail string
+}
+
+// timedCheck runs one query and reports its latency, failing it on an
+// error or when it's slower than doctorSlowQuery. query returns a short
+// summary of what it found ("9 spaces").
+func timedCheck(name string, query func() (string, error)) doctorCheck {
+	start := time.Now()
+	summary, err := query()
+	took := time.Since(start).Round(time.Millisecond)
+	switch {
+	case err != nil:
+		return doctorCheck{name, false, fmt.Sprintf("%v (after %s)", err, took)}
+	case took > doctorSlowQuery:
+		return doctorCheck{name, false, fmt.Sprintf("%s, took %s (over %s, refreshes will lag)", summary, took, doctorSlowQuery)}
+	}
+	return doctorCheck{name, true, fmt.Sprintf("%s in %s", summary, took)}
+}
+
+// runDoctor runs every check in order.
+func runDoctor() []doctorCheck {
+	var checks []doctorCheck
+	add := func(c doctorCheck) { checks = append(checks, c) }
+
+	// window manager
+	wm := currentWM()
+	var spaces []Space
+	var displays []Display
+	var windows []Window
+	switch wm.(type) {
+	case engine.NoWindowManager:
+		add(doctorCheck{"window manager", true, "disabled (--no-yabai); tmux sessions all show as detached"})
+	case engine.Yabai:
+		if path, err := exec.LookPath("yabai"); err != nil {
+			add(doctorCheck{"yabai", false, "not found in PATH"})
+		} else {
+			add(doctorCheck{"yabai", true, path})
+		}
+		if _, err := os.Stat(yabaiScriptingAddition); err != nil {
+			why := "not installed: focusing and destroying spaces will fail (sudo yabai --install-sa)"
+			if fallback := engine.FocusFallback(); fallback != "" {
+				why = "not installed: destroying spaces will fail and focus falls back to desktop shortcuts via " + fallback + " (sudo yabai --install-sa)"
+			}
+			add(doctorCheck{"yabai scripting addition", false, why})
+		} else {
+			add(doctorCheck{"yabai scripting addition", true, "installed (load it with sudo yabai --load-sa)"})
+		}
+	default:
+		add(doctorCheck{"window manager", true, wm.Name() + " via IPC socket"})
+	}
+	if _, disabled := wm.(engine.NoWindowManager); !disabled {
+		add(timedCheck(wm.Name()+" spaces", func() (string, error) {
+			var err error
+			spaces, err = wm.QuerySpaces()
+			return fmt.Sprintf("%d spaces", len(spaces)), err
+		}))
+		add(timedCheck(wm.Name()+" displays", func() (string, error) {
+			var err error
+			displays, err = wm.QueryDisplays()
+			return fmt.Sprintf("%d displays", len(displays)), err
+		}))
+		add(timedCheck(wm.Name()+" windows", func() (string, error) {
+			var err error
+			windows, err = wm.QueryWindows()
+			return fmt.Sprintf("%d windows", len(windows)), err
+		}))
+		if _, err := engine.AttachWindowTTYs(windows); err != nil {
+			add(doctorCheck{"terminal ttys", false, err.Error() + " (allow automation in System Settings › Privacy & Security)"})
+		}
+	}
+
+	// tmux
+	var panes []TmuxPane
+	var clients []TmuxClient
+	if path, err := exec.LookPath("tmux"); err != nil {
+		add(doctorCheck{"tmux", false, "not found in PATH"})
+	} else {
+		add(doctorCheck{"tmux", true, path})
+		add(timedCheck("tmux panes", func() (string, error) {
+			var err error
+			panes, err = engine.QueryTmuxPanes()
+			if err == nil && len(panes) == 0 {
+				return "no server running or no sessions", nil
+			}
+			return fmt.Sprintf("%d panes", len(panes)), err
+		}))
+		add(timedCheck("tmux clients", func() (string, error) {
+			var err error
+			clients, err = engine.QueryTmuxClients()
+			return fmt.Sprintf("%d attached clients", len(clients)), err
+		}))
+	}
+
+	// ps: the tree has to include other processes, not just our own
+	var processTree map[int]int
+	add(timedCheck("ps", func() (string, error) {
+		var err error
+		processTree, _, err = engine.QueryProcessTree()
+		return fmt.Sprintf("%d processes", len(processTree)), err
+	}))
+	if processTree != nil {
+		add(psVisibilityCheck(processTree, clients))
+	}
+
+	// the client → terminal → display walk
+	if processTree != nil && len(clients) > 0 && len(windows) > 0 {
+		checks = append(checks, clientWalkChecks(clients, processTree, windows, spaces)...)
+	}
+	if len(panes) > 0 {
+		checks = append(checks, clientlessSessionChecks(panes, clients)...)
+	}
+	return append(checks, timeoutCheck(engine.TimeoutStats()))
+}
+
+// timeoutCheck reports the queries above that timed out. one that
+// answered on a retry passes, but is worth knowing about; one that never
+// answered fails.
+func timeoutCheck(stats []engine.TimeoutStat) doctorCheck {
+	if len(stats) == 0 {
+		return doctorCheck{"query timeouts", true, "none"}
+	}
+	ok := true
+	parts := make([]string, len(stats))
+	for i, s := range stats {
+		parts[i] = fmt.Sprintf("%s %d", s.Source, s.Timeouts)
+		if s.GaveUp > 0 {
+			parts[i] += fmt.Sprintf(" (%d never answered)", s.GaveUp)
+			ok = false
+		}
+	}
+	return doctorCheck{"query timeouts", ok, strings.Join(parts, ", ") + "; raise queryTimeouts in config.go on a loaded machine"}
+}
+
+// psVisibilityCheck fails when ps can't see our own parent or a tmux
+// client, which is what a sandbox or restricted process listing looks like.
+func psVisibilityCheck(processTree map[int]int, clients []TmuxClient) doctorCheck {
+	if ppid, ok := processTree[os.Getpid()]; !ok || ppid != os.Getppid() {
+		return doctorCheck{"ps permissions", false, "ps doesn't list this process's parent; process-tree walks can't work"}
+	}
+	var missing []string
+	for _, c := range clients {
+		if _, ok := processTree[c.PID]; !ok {
+			missing = append(missing, fmt.Sprintf("%d (%s)", c.PID, c.SessionName))
+		}
+	}
+	if len(missing) > 0 {
+		return doctorCheck{"ps permissions", false, "can't see tmux client pids " + strings.Join(missing, ", ")}
+	}
+	return doctorCheck{"ps permissions", true, "other users' processes visible"}
+}
+
+// clientWalkChecks reports, per attached client, the display it maps to
+// or why it didn't.
+func clientWalkChecks(clients []TmuxClient, processTree map[int]int, windows []Window, spaces []Space) []doctorCheck {
+	sessionToDisplay, detachedWhy := engine.MapTmuxClients(clients, processTree, windows, spaces)
+	var checks []doctorCheck
+	for _, c := range clients {
+		name := fmt.Sprintf("client %s (pid %d)", c.SessionName, c.PID)
+		if display, ok := sessionToDisplay[c.SessionName]; ok {
+			checks = append(checks, doctorCheck{name, true, fmt.Sprintf("display %d", display)})
+			continue
+		}
+		why := detachedWhy[c.SessionName]
+		if strings.Contains(why, "none titled") {
+			why += "; try stop sync-titles"
+		}
+		checks = append(checks, doctorCheck{name, false, why})
+	}
+	return checks
+}
+
+// clientlessSessionChecks lists sessions with no attached client. that's
+// not a failure — nothing is showing them — but it explains why they're
+// under detached.
+func clientlessSessionChecks(panes []TmuxPane, clients []TmuxClient) []doctorCheck {
+	attached := map[string]bool{}
+	for _, c := range clients {
+		attached[c.SessionName] = true
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, p := range panes {
+		if !attached[p.SessionName] && !seen[p.SessionName] {
+			seen[p.SessionName] = true
+			names = append(names, p.SessionName)
+		}
+	}
+	sort.Strings(names)
+	var checks []doctorCheck
+	for _, n := range names {
+		checks = append(checks, doctorCheck{"session " + n, true, "no attached client, so shown as detached"})
+	}
+	return checks
+}
+
+// printDoctorReport writes the checklist and returns how many failed.
+func printDoctorReport(w io.Writer, checks []doctorCheck) int {
+	width := 0
+	for _, c := range checks {
+		width = max(width, len(c.name))
+	}
+	failed := 0
+	for _, c := range checks {
+		mark := "ok  "
+		if !c.ok {
+			mark = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(w, "%s  %-*s  %s\n", mark, width, c.name, c.detail)
+	}
+	return failed
+}
+
+// printDoctorJSON writes the checks as a JSON array for -json, failing
+// like the checklist does when any check failed.
+func printDoctorJSON(w io.Writer, checks []doctorCheck) error {
+	type check struct {
+		Name   string `json:"name"`
+		OK     bool   `json:"ok"`
+		Detail string `json:"detail"`
+	}
+	out := make([]check, len(checks))
+	failed := 0
+	for i, c := range checks {
+		out[i] = check{Name: c.name, OK: c.ok, Detail: c.detail}
+		if !c.ok {
+			failed++
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+func doctorCommand(jsonOut bool) error {
+	checks := runDoctor()
+	if jsonOut {
+		return printDoctorJSON(os.Stdout, checks)
+	}
+	if failed := printDoctorReport(os.Stdout, checks); failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
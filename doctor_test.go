@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestDoctorClientWalk(t *testing.T) {
+	windows := []Window{
+		{ID: 1, PID: 100, App: "kitty", Title: "api", Space: 1},
+		{ID: 2, PID: 100, App: "kitty", Title: "notes", Space: 2},
+	}
+	spaces := []Space{{Index: 1, Display: 1}, {Index: 2, Display: 2}}
+	clients := []TmuxClient{
+		{PID: 300, SessionName: "api"},    // 300 → 200 → kitty
+		{PID: 301, SessionName: "web"},    // kitty, but no window titled web
+		{PID: 302, SessionName: "ssh-in"}, // sshd, never reaches a terminal
+	}
+	tree := map[int]int{300: 200, 200: 100, 301: 100, 302: 50, 100: 1, 50: 1}
+
+	checks := clientWalkChecks(clients, tree, windows, spaces)
+	if len(checks) != 3 {
+		t.Fatalf("got %d checks", len(checks))
+	}
+	if !checks[0].ok || checks[0].detail != "display 1" {
+		t.Errorf("api: %+v", checks[0])
+	}
+	if checks[1].ok || !strings.Contains(checks[1].detail, "sync-titles") {
+		t.Errorf("web: %+v", checks[1])
+	}
+	if checks[2].ok || !strings.Contains(checks[2].detail, "no terminal window") {
+		t.Errorf("ssh-in: %+v", checks[2])
+	}
+
+	clientless := clientlessSessionChecks(
+		[]TmuxPane{{SessionName: "api"}, {SessionName: "scratch"}, {SessionName: "scratch"}},
+		clients,
+	)
+	if len(clientless) != 1 || clientless[0].name != "session scratch" || !clientless[0].ok {
+		t.Errorf("clientless = %+v", clientless)
+	}
+}
+
+func TestDoctorPSVisibility(t *testing.T) {
+	tree := map[int]int{os.Getpid(): os.Getppid(), 300: 1}
+	if c := psVisibilityCheck(tree, []TmuxClient{{PID: 300, SessionName: "api"}}); !c.ok {
+		t.Errorf("visible tree failed: %+v", c)
+	}
+	if c := psVisibilityCheck(tree, []TmuxClient{{PID: 999, SessionName: "api"}}); c.ok || !strings.Contains(c.detail, "999") {
+		t.Errorf("missing client passed: %+v", c)
+	}
+	if c := psVisibilityCheck(map[int]int{}, nil); c.ok {
+		t.Errorf("empty tree passed: %+v", c)
+	}
+}
+
+func TestPrintDoctorReport(t *testing.T) {
+	var b strings.Builder
+	failed := printDoctorReport(&b, []doctorCheck{
+		{"tmux", true, "/usr/bin/tmux"},
+		{"yabai windows", false, "timeout"},
+	})
+	if failed != 1 {
+		t.Errorf("failed = %d", failed)
+	}
+	want := "ok    tmux           /usr/bin/tmux\nFAIL  yabai windows  timeout\n"
+	if b.String() != want {
+		t.Errorf("report =\n%q\nwant\n%q", b.String(), want)
+	}
+}
+
+func TestTimeoutCheck(t *testing.T) {
+	if c := timeoutCheck(nil); !c.ok || c.detail != "none" {
+		t.Errorf("no timeouts = %+v", c)
+	}
+	c := timeoutCheck([]engine.TimeoutStat{{Source: "ps", Timeouts: 1}, {Source: "yabai", Timeouts: 3, GaveUp: 1}})
+	if c.ok || !strings.HasPrefix(c.detail, "ps 1, yabai 3 (1 never answered)") {
+		t.Errorf("timeouts = %+v", c)
+	}
+	if c := timeoutCheck([]engine.TimeoutStat{{Source: "tmux", Timeouts: 2}}); !c.ok {
+		t.Errorf("timeouts that were retried failed the check: %+v", c)
+	}
+}
@@ -0,0 +1,196 @@
+// Package engine is stop's state engine: the queries and derivations
+// behind the dashboard, importable by other Go tools — status bars, bots,
+// scripts — that want the same picture without scraping `stop serve`.
+//
+// the pipeline is Fetch (window manager spaces, displays and windows;
+// tmux panes and clients; the process tree), then GroupDisplays to lay
+// spaces out like the desk, PartitionTmux to place tmux sessions on the
+// display showing them, and ClassifyStaleness to band pane activity.
+// everything else stop shows — nvim buffers, agents, annotations — is
+// layered on top in package main and isn't part of this API.
+//
+// exported names here are kept stable; stop itself only reaches the
+// window manager, tmux and ps through this package.
+package engine
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SourceError is one failed query of a fetch.
+type SourceError struct {
+	Source string // "tmux", "ps", "yabai windows", ...
+	Err    error
+}
+
+func (e SourceError) String() string {
+	return e.Source + ": " + e.Err.Error()
+}
+
+// State is one fetch: everything read from the window manager, tmux and
+// ps at a moment.
+type State struct {
+	Spaces      []Space
+	Displays    []Display
+	Windows     []Window
+	TmuxPanes   []TmuxPane
+	TmuxClients []TmuxClient
+	ProcessTree map[int]int    // pid → ppid
+	ProcessComm map[int]string // pid → command name
+	// Sessions are the tabs and split panes of terminal windows that
+	// list them (iterm.go), by window id
+	Sessions map[int][]TerminalSession
+
+	// WMErr is set when the window manager couldn't list spaces: there's
+	// no grid, though the tmux half may still be complete.
+	WMErr error
+	// Errors lists the queries that failed, sorted by source. a window
+	// manager that's down is reported once, under its name.
+	Errors []SourceError
+	Took   time.Duration
+}
+
+// Querier is what Fetch reads besides the window manager: tmux, the
+// process table and the terminals' ttys. Local is this machine's; Fake
+// (fake.go) answers from a recorded fixture.
+type Querier interface {
+	QueryTmuxPanes() ([]TmuxPane, error)
+	QueryTmuxClients() ([]TmuxClient, error)
+	QueryProcessTree(clients []TmuxClient, now time.Time) (tree map[int]int, comm map[int]string, err error)
+	AttachWindowTTYs(windows []Window) (map[int][]TerminalSession, error)
+}
+
+// Local queries this machine's tmux server, process table and terminals.
+type Local struct{}
+
+func (Local) QueryTmuxPanes() ([]TmuxPane, error)     { return QueryTmuxPanes() }
+func (Local) QueryTmuxClients() ([]TmuxClient, error) { return QueryTmuxClients() }
+func (Local) AttachWindowTTYs(windows []Window) (map[int][]TerminalSession, error) {
+	return AttachWindowTTYs(windows)
+}
+
+// QueryProcessTree is cached per set of clients (proctree.go).
+func (Local) QueryProcessTree(clients []TmuxClient, now time.Time) (map[int]int, map[int]string, error) {
+	return CachedProcessTree(clients, now)
+}
+
+// Fetch is FetchFrom this machine.
+func Fetch(wm WindowManager) State {
+	return FetchFrom(wm, Local{})
+}
+
+// FetchFrom runs every query concurrently, so a fetch takes as long as
+// its slowest query. each is best-effort: a failure is recorded in Errors
+// and the rest of the state still fills in.
+func FetchFrom(wm WindowManager, q Querier) State {
+	start := time.Now()
+	var (
+		s  State
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	// fail records a failed source; callers hold mu
+	fail := func(source string, err error) {
+		if err != nil {
+			s.Errors = append(s.Errors, SourceError{source, err})
+		}
+	}
+
+	wg.Add(5)
+
+	go func() {
+		defer wg.Done()
+		t0 := time.Now()
+		d, err := wm.QueryDisplays()
+		logQuery("displays", t0, err)
+		mu.Lock()
+		s.Displays = d
+		fail(wm.Name()+" displays", err)
+		mu.Unlock()
+	}()
+
+	go func() {
+		defer wg.Done()
+		t0 := time.Now()
+		sp, err := wm.QuerySpaces()
+		logQuery("spaces", t0, err)
+		mu.Lock()
+		s.Spaces, s.WMErr = sp, err
+		if !errors.Is(err, ErrNoWindowManager) {
+			fail(wm.Name(), err)
+		}
+		mu.Unlock()
+	}()
+
+	go func() {
+		defer wg.Done()
+		t0 := time.Now()
+		w, err := wm.QueryWindows()
+		logQuery("windows", t0, err)
+		mu.Lock()
+		s.Windows = w
+		fail(wm.Name()+" windows", err)
+		mu.Unlock()
+	}()
+
+	go func() {
+		defer wg.Done()
+		t, err := q.QueryTmuxPanes()
+		mu.Lock()
+		s.TmuxPanes = t
+		fail("tmux", err)
+		mu.Unlock()
+	}()
+
+	// the process tree is cached per set of clients (proctree.go), so it
+	// follows them
+	go func() {
+		defer wg.Done()
+		c, err := q.QueryTmuxClients()
+		mu.Lock()
+		s.TmuxClients = c
+		fail("tmux clients", err)
+		mu.Unlock()
+
+		t, comm, err := q.QueryProcessTree(c, time.Now())
+		mu.Lock()
+		s.ProcessTree, s.ProcessComm = t, comm
+		fail("ps", err)
+		mu.Unlock()
+	}()
+
+	wg.Wait()
+
+	// multi-window terminals that can name each window's tty make the
+	// tmux mapping exact (ttys.go)
+	sessions, err := q.AttachWindowTTYs(s.Windows)
+	s.Sessions = sessions
+	fail("terminal ttys", err)
+
+	// a window manager that's down fails every query the same way; its
+	// one spaces error says it all
+	if s.WMErr != nil {
+		kept := s.Errors[:0]
+		for _, e := range s.Errors {
+			if !strings.HasPrefix(e.Source, wm.Name()+" ") {
+				kept = append(kept, e)
+			}
+		}
+		s.Errors = kept
+	}
+	sortSourceErrors(s.Errors)
+	s.Took = time.Since(start)
+	logger.Debug("fetch", "took", s.Took, "spaces", len(s.Spaces), "windows", len(s.Windows),
+		"panes", len(s.TmuxPanes), "clients", len(s.TmuxClients), "failed", len(s.Errors))
+	return s
+}
+
+// sortSourceErrors orders errors by source for a stable report.
+func sortSourceErrors(errs []SourceError) {
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Source < errs[j].Source })
+}
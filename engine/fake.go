@@ -0,0 +1,180 @@
+// fake backends: a recorded State played back.
+//
+// Fake is both a WindowManager and a Querier, answering every query from
+// a fixture — one frame of a `stop record` recording (replay.go) or a
+// hand-written one — so the grouping, the tmux placement and
+// everything built on them can be tested without a Mac, yabai or a tmux
+// server. a fixture can also record a query failing, by source. actions
+// succeed without touching anything and are kept in Calls.
+
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fake answers queries from a fixture.
+type Fake struct {
+	At          time.Duration     `json:"at,omitempty"` // into the recording, for a recorded frame
+	WM          string            `json:"wm"`           // the Name it reports, "fake" when empty
+	Spaces      []Space           `json:"spaces"`
+	Displays    []Display         `json:"displays"`
+	Windows     []Window          `json:"windows"`
+	TmuxPanes   []TmuxPane        `json:"tmux_panes"`
+	TmuxClients []TmuxClient      `json:"tmux_clients"`
+	ProcessTree map[int]int       `json:"process_tree"` // pid → ppid
+	ProcessComm map[int]string    `json:"process_comm"` // pid → command name
+	TTYs        map[int]string    `json:"ttys"`         // window id → tty
+	Errors      map[string]string `json:"errors"`       // "spaces", "displays", "windows", "tmux", "tmux clients" or "ps" → message
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// LoadFake reads a fixture file.
+func LoadFake(path string) (*Fake, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f Fake
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing fixture %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// RecordFake turns a fetch into a fixture that plays it back. window
+// ttys, which a Window doesn't carry in JSON, are kept on the side.
+func RecordFake(wmName string, s State) *Fake {
+	f := &Fake{
+		WM:          wmName,
+		Spaces:      s.Spaces,
+		Displays:    s.Displays,
+		Windows:     s.Windows,
+		TmuxPanes:   s.TmuxPanes,
+		TmuxClients: s.TmuxClients,
+		ProcessTree: s.ProcessTree,
+		ProcessComm: s.ProcessComm,
+		TTYs:        map[int]string{},
+		Errors:      map[string]string{},
+	}
+	for _, w := range s.Windows {
+		if w.TTY != "" {
+			f.TTYs[w.ID] = w.TTY
+		}
+	}
+	if s.WMErr != nil {
+		f.Errors["spaces"] = s.WMErr.Error()
+	}
+	for _, e := range s.Errors {
+		// sources are reported under the window manager's name
+		source := strings.TrimSpace(strings.TrimPrefix(e.Source, wmName))
+		if source != "" && e.Err != nil {
+			f.Errors[source] = e.Err.Error()
+		}
+	}
+	return f
+}
+
+// fail is the recorded failure of a source, if any.
+func (f *Fake) fail(source string) error {
+	msg, ok := f.Errors[source]
+	switch {
+	case !ok:
+		return nil
+	case msg == ErrNoWindowManager.Error():
+		return ErrNoWindowManager
+	}
+	return errors.New(msg)
+}
+
+// Calls lists the actions taken so far, like "focus-space 3".
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+func (f *Fake) call(action string, args ...any) error {
+	line := action
+	for _, a := range args {
+		line += " " + fmt.Sprint(a)
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, line)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *Fake) Name() string {
+	if f.WM == "" {
+		return "fake"
+	}
+	return f.WM
+}
+
+// the queries hand back copies, as a live backend's answers are fresh
+
+func (f *Fake) QuerySpaces() ([]Space, error) {
+	return slices.Clone(f.Spaces), f.fail("spaces")
+}
+
+func (f *Fake) QueryDisplays() ([]Display, error) {
+	return slices.Clone(f.Displays), f.fail("displays")
+}
+
+func (f *Fake) QueryWindows() ([]Window, error) {
+	return slices.Clone(f.Windows), f.fail("windows")
+}
+
+func (f *Fake) QueryTmuxPanes() ([]TmuxPane, error) {
+	return slices.Clone(f.TmuxPanes), f.fail("tmux")
+}
+
+func (f *Fake) QueryTmuxClients() ([]TmuxClient, error) {
+	return slices.Clone(f.TmuxClients), f.fail("tmux clients")
+}
+
+func (f *Fake) QueryProcessTree([]TmuxClient, time.Time) (map[int]int, map[int]string, error) {
+	if err := f.fail("ps"); err != nil {
+		return nil, nil, err
+	}
+	return f.ProcessTree, f.ProcessComm, nil
+}
+
+func (f *Fake) AttachWindowTTYs(windows []Window) (map[int][]TerminalSession, error) {
+	for i := range windows {
+		if tty, ok := f.TTYs[windows[i].ID]; ok {
+			windows[i].TTY = tty
+		}
+	}
+	return nil, f.fail("terminal ttys")
+}
+
+func (f *Fake) FocusSpace(index int) error           { return f.call("focus-space", index) }
+func (f *Fake) FocusWindow(id int) error             { return f.call("focus-window", id) }
+func (f *Fake) RestoreWindow(id int) error           { return f.call("restore-window", id) }
+func (f *Fake) ToggleScratchpad(label string) error  { return f.call("toggle-scratchpad", label) }
+func (f *Fake) MoveWindow(windowID, space int) error { return f.call("move-window", windowID, space) }
+func (f *Fake) FlashWindows(ids []int) error         { return f.call("flash-windows", ids) }
+func (f *Fake) DestroySpace(index int) error         { return f.call("destroy-space", index) }
+func (f *Fake) LabelSpace(index int, label string) error {
+	return f.call("label-space", index, label)
+}
+
+// CreateSpace answers with the index after the fixture's last space.
+func (f *Fake) CreateSpace(display int) (int, error) {
+	index := 0
+	for _, s := range f.Spaces {
+		index = max(index, s.Index)
+	}
+	return index + 1, f.call("create-space", display)
+}
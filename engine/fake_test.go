@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func loadDesk(t *testing.T) *Fake {
+	t.Helper()
+	f, err := LoadFake("../testdata/desk.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestFetchFromFake(t *testing.T) {
+	f := loadDesk(t)
+	s := FetchFrom(f, f)
+	if s.WMErr != nil || len(s.Errors) != 0 {
+		t.Fatalf("fetch failed: %v %v", s.WMErr, s.Errors)
+	}
+	if len(s.Spaces) != 5 || len(s.Windows) != 5 || len(s.TmuxPanes) != 4 {
+		t.Fatalf("fetched %d spaces, %d windows, %d panes", len(s.Spaces), len(s.Windows), len(s.TmuxPanes))
+	}
+	if s.Windows[0].TTY != "/dev/ttys001" {
+		t.Errorf("window 101 tty = %q", s.Windows[0].TTY)
+	}
+
+	groups := GroupDisplays(s.Spaces, s.Windows, s.Displays)
+	if len(groups) != 2 || groups[0].FreeCount != 1 || groups[1].FreeCount != 1 || len(groups[0].Hidden) != 1 {
+		t.Errorf("groups = %+v", groups)
+	}
+	byDisplay, detached := PartitionTmux(s.TmuxPanes, s.TmuxClients, s.ProcessTree, s.Windows, s.Spaces)
+	if len(byDisplay[1]) != 2 || len(byDisplay[2]) != 1 || len(detached) != 1 || detached[0].SessionName != "notes" {
+		t.Errorf("byDisplay = %+v, detached = %+v", byDisplay, detached)
+	}
+}
+
+func TestFakeErrors(t *testing.T) {
+	f := loadDesk(t)
+	f.Errors = map[string]string{"windows": "yabai timed out", "ps": "ps: not found"}
+	s := FetchFrom(f, f)
+	var sources []string
+	for _, e := range s.Errors {
+		sources = append(sources, e.Source)
+	}
+	if !reflect.DeepEqual(sources, []string{"ps", "yabai windows"}) {
+		t.Errorf("failed sources = %v", sources)
+	}
+
+	f.Errors = map[string]string{"spaces": ErrNoWindowManager.Error()}
+	if s := FetchFrom(f, f); !errors.Is(s.WMErr, ErrNoWindowManager) || len(s.Errors) != 0 {
+		t.Errorf("disabled window manager: %v, %v", s.WMErr, s.Errors)
+	}
+}
+
+func TestRecordFakeRoundTrip(t *testing.T) {
+	f := loadDesk(t)
+	f.Errors = map[string]string{"displays": "boom"}
+	before := FetchFrom(f, f)
+
+	data, err := json.Marshal(RecordFake("yabai", before))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replay Fake
+	if err := json.Unmarshal(data, &replay); err != nil {
+		t.Fatal(err)
+	}
+	after := FetchFrom(&replay, &replay)
+	before.Took, after.Took = 0, 0
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("replay differs:\n%+v\n%+v", before, after)
+	}
+}
+
+func TestFakeCalls(t *testing.T) {
+	f := &Fake{}
+	f.FocusSpace(3)
+	f.MoveWindow(101, 2)
+	f.ToggleScratchpad("notes")
+	want := []string{"focus-space 3", "move-window 101 2", "toggle-scratchpad notes"}
+	if got := f.Calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %q", got)
+	}
+}
@@ -0,0 +1,336 @@
+// grouping and mapping: from raw queries to what's on which display.
+//
+// spaces group under their display, ordered like the physical desk, and
+// tmux sessions are placed on the display whose terminal window is
+// showing them — found by walking each tmux client's process up to a
+// terminal window's pid. sessions that can't be placed are detached.
+
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DisplayGroup is one display and its spaces.
+type DisplayGroup struct {
+	Index     int
+	Display   Display // zero when the backend couldn't describe this display
+	LayoutRow int     // 0 for the top row of monitors, 1 for ones stacked below, ...
+	Spaces    []SpaceRow
+	FreeCount int      // spaces with no visible window
+	TermCount int      // spaces with a terminal window
+	Hidden    []Window // minimized and hidden windows, by app then title
+}
+
+// SpaceRow is a space and its visible windows.
+type SpaceRow struct {
+	Space   Space
+	Windows []Window
+}
+
+// GroupDisplays organizes spaces by display and attaches their visible
+// (non-hidden, non-minimized) windows. each group gets its own
+// free/terminal counts and its spaces' hidden and minimized windows;
+// ones on no known space (i3's scratchpad) go to the first group. groups
+// are ordered like the desk: row by row (monitors stacked vertically
+// land in later rows), left-to-right within a row, falling back to
+// display index when frames are unknown.
+func GroupDisplays(spaces []Space, windows []Window, displays []Display) []DisplayGroup {
+	// index windows by space, setting hidden and minimized aside
+	windowsBySpace := make(map[int][]Window)
+	hiddenBySpace := make(map[int][]Window)
+	for _, w := range windows {
+		switch {
+		case w.Scratchpad != "" && (w.IsHidden || w.IsMinimized):
+			// toggled away: not on a space, listed with its scratchpad
+		case w.IsHidden || w.IsMinimized:
+			hiddenBySpace[w.Space] = append(hiddenBySpace[w.Space], w)
+		case w.Space > 0:
+			windowsBySpace[w.Space] = append(windowsBySpace[w.Space], w)
+		}
+	}
+
+	// group spaces by display
+	displayMap := make(map[int][]SpaceRow)
+	for _, s := range spaces {
+		displayMap[s.Display] = append(displayMap[s.Display], SpaceRow{
+			Space:   s,
+			Windows: windowsBySpace[s.Index],
+		})
+	}
+
+	displayByIndex := make(map[int]Display, len(displays))
+	for _, d := range displays {
+		displayByIndex[d.Index] = d
+	}
+
+	// sort displays by physical position (index breaks ties and covers
+	// displays the backend didn't describe), then spaces within each display
+	var displayIndices []int
+	for d := range displayMap {
+		displayIndices = append(displayIndices, d)
+	}
+	rowOf := layoutRows(displayIndices, displayByIndex)
+	sort.Slice(displayIndices, func(i, j int) bool {
+		ri, rj := rowOf[displayIndices[i]], rowOf[displayIndices[j]]
+		if ri != rj {
+			return ri < rj
+		}
+		fi, fj := displayByIndex[displayIndices[i]].Frame, displayByIndex[displayIndices[j]].Frame
+		if fi.X != fj.X {
+			return fi.X < fj.X
+		}
+		if fi.Y != fj.Y {
+			return fi.Y < fj.Y
+		}
+		return displayIndices[i] < displayIndices[j]
+	})
+
+	var groups []DisplayGroup
+	for _, d := range displayIndices {
+		rows := displayMap[d]
+		sort.Slice(rows, func(i, j int) bool {
+			return rows[i].Space.Index < rows[j].Space.Index
+		})
+
+		freeCount := 0
+		termCount := 0
+		for _, row := range rows {
+			if len(row.Windows) == 0 {
+				freeCount++
+			}
+			for _, w := range row.Windows {
+				if IsTerminal(w.App) {
+					termCount++
+					break
+				}
+			}
+		}
+
+		var hidden []Window
+		for _, row := range rows {
+			hidden = append(hidden, hiddenBySpace[row.Space.Index]...)
+			delete(hiddenBySpace, row.Space.Index)
+		}
+
+		groups = append(groups, DisplayGroup{
+			Index:     d,
+			Display:   displayByIndex[d],
+			LayoutRow: rowOf[d],
+			Spaces:    rows,
+			FreeCount: freeCount,
+			TermCount: termCount,
+			Hidden:    hidden,
+		})
+	}
+
+	// whatever is left sits on no space a display owns
+	if len(groups) > 0 {
+		for _, ws := range hiddenBySpace {
+			groups[0].Hidden = append(groups[0].Hidden, ws...)
+		}
+	}
+	for i := range groups {
+		h := groups[i].Hidden
+		sort.Slice(h, func(a, b int) bool {
+			if h[a].App != h[b].App {
+				return h[a].App < h[b].App
+			}
+			if h[a].Title != h[b].Title {
+				return h[a].Title < h[b].Title
+			}
+			return h[a].ID < h[b].ID
+		})
+	}
+
+	return groups
+}
+
+// layoutRows assigns each display a row in the physical arrangement. a
+// display sits one row below the lowest display that's above it and
+// overlaps it horizontally, so side-by-side monitors share row 0 and a
+// laptop tucked under an external screen gets row 1. displays with an
+// unknown (zero) frame overlap nothing and stay in row 0.
+func layoutRows(indices []int, displays map[int]Display) map[int]int {
+	order := append([]int(nil), indices...)
+	sort.Slice(order, func(i, j int) bool {
+		return displays[order[i]].Frame.Y < displays[order[j]].Frame.Y
+	})
+
+	rows := make(map[int]int, len(order))
+	for i, d := range order {
+		f := displays[d].Frame
+		rows[d] = 0
+		for _, above := range order[:i] {
+			a := displays[above].Frame
+			if a.W <= 0 || f.W <= 0 || a.Y+a.H > f.Y {
+				continue
+			}
+			if a.X < f.X+f.W && f.X < a.X+a.W && rows[above]+1 > rows[d] {
+				rows[d] = rows[above] + 1
+			}
+		}
+	}
+	return rows
+}
+
+// -- tmux-to-display mapping --
+
+// PartitionTmux correlates tmux sessions to displays.
+// walks from each tmux client PID up the process tree to find the terminal
+// emulator's PID, which matches a window PID → space → display.
+// when multiple windows share a PID (e.g. kitty is single-process),
+// disambiguates by matching window titles against the client (titles.go).
+// sessions without an attached client (or unmappable) go into detached.
+func PartitionTmux(
+	panes []TmuxPane,
+	clients []TmuxClient,
+	processTree map[int]int,
+	windows []Window,
+	spaces []Space,
+) (byDisplay map[int][]TmuxPane, detached []TmuxPane) {
+	byDisplay = make(map[int][]TmuxPane)
+
+	if len(panes) == 0 {
+		return byDisplay, nil
+	}
+
+	sessionToDisplay, detachedWhy := MapTmuxClients(clients, processTree, windows, spaces)
+	return BucketTmux(panes, sessionToDisplay, detachedWhy)
+}
+
+// BucketTmux is PartitionTmux's second half: panes into their session's
+// display, or detached. callers that kept a MapTmuxClients answer can
+// re-bucket changed panes without walking the process tree again.
+func BucketTmux(
+	panes []TmuxPane,
+	sessionToDisplay map[string]int,
+	detachedWhy map[string]string,
+) (byDisplay map[int][]TmuxPane, detached []TmuxPane) {
+	byDisplay = make(map[int][]TmuxPane)
+	logged := make(map[string]bool)
+	for _, p := range panes {
+		if display, ok := sessionToDisplay[p.SessionName]; ok {
+			byDisplay[display] = append(byDisplay[display], p)
+			continue
+		}
+		detached = append(detached, p)
+		if !logged[p.SessionName] {
+			logged[p.SessionName] = true
+			why, ok := detachedWhy[p.SessionName]
+			if !ok {
+				why = "no attached tmux client"
+			}
+			logger.Debug("session detached", "session", p.SessionName, "why", why)
+		}
+	}
+
+	return byDisplay, detached
+}
+
+// MapTmuxClients resolves each tmux client to the display its terminal
+// window is on. detachedWhy keeps the reason a client didn't resolve.
+func MapTmuxClients(
+	clients []TmuxClient,
+	processTree map[int]int,
+	windows []Window,
+	spaces []Space,
+) (sessionToDisplay map[string]int, detachedWhy map[string]string) {
+	// build space → display lookup
+	spaceToDisplay := make(map[int]int)
+	for _, s := range spaces {
+		spaceToDisplay[s.Index] = s.Display
+	}
+
+	// group terminal windows by PID with their display info.
+	// kitty is single-process so all its OS windows share one PID.
+	type windowInfo struct {
+		title   string
+		ttys    []string // its current tab's first, then any other sessions'
+		display int
+	}
+	windowsByPID := make(map[int][]windowInfo)
+	windowsByApp := make(map[string][]windowInfo)
+	for _, w := range windows {
+		if !IsTerminal(w.App) {
+			continue
+		}
+		if display, ok := spaceToDisplay[w.Space]; ok {
+			wi := windowInfo{title: w.Title, display: display}
+			if w.TTY != "" {
+				wi.ttys = append(wi.ttys, w.TTY)
+			}
+			wi.ttys = append(wi.ttys, strings.Fields(w.OtherTTYs)...)
+			windowsByPID[w.PID] = append(windowsByPID[w.PID], wi)
+			windowsByApp[w.App] = append(windowsByApp[w.App], wi)
+		}
+	}
+	titled := func(wins []windowInfo, client TmuxClient) (windowInfo, bool) {
+		// a window on the client's own tty is certain
+		if client.TTY != "" {
+			for _, wi := range wins {
+				if slices.Contains(wi.ttys, client.TTY) {
+					return wi, true
+				}
+			}
+		}
+		titles := make([]string, len(wins))
+		for i, wi := range wins {
+			titles[i] = wi.title
+		}
+		if i := bestTitleMatch(titles, client); i >= 0 {
+			return wins[i], true
+		}
+		return windowInfo{}, false
+	}
+
+	// for each tmux client, walk up process tree to find terminal PID,
+	// then resolve to a specific window/display
+	sessionToDisplay = make(map[string]int)
+	detachedWhy = make(map[string]string)
+	for _, client := range clients {
+		termPID := -1
+		pid := client.PID
+		for depth := 0; depth < 20; depth++ {
+			if _, ok := windowsByPID[pid]; ok {
+				termPID = pid
+				break
+			}
+			ppid, ok := processTree[pid]
+			if !ok || ppid <= 1 {
+				break
+			}
+			pid = ppid
+		}
+
+		if termPID < 0 {
+			// the terminal may still say who it is through TERM
+			var wins []windowInfo
+			for _, app := range termNameApps[client.TermName] {
+				wins = append(wins, windowsByApp[app]...)
+			}
+			if wi, ok := titled(wins, client); ok {
+				sessionToDisplay[client.SessionName] = wi.display
+				continue
+			}
+			detachedWhy[client.SessionName] = fmt.Sprintf("client pid %d has no terminal window among its ancestors", client.PID)
+			continue
+		}
+
+		wins := windowsByPID[termPID]
+		if len(wins) == 1 {
+			// single window for this PID — unambiguous
+			sessionToDisplay[client.SessionName] = wins[0].display
+		} else if wi, ok := titled(wins, client); ok {
+			// multiple windows share this PID (e.g. kitty): the tty or
+			// the title decides
+			sessionToDisplay[client.SessionName] = wi.display
+		} else {
+			detachedWhy[client.SessionName] = fmt.Sprintf("terminal pid %d has %d windows, none titled %q", termPID, len(wins), client.SessionName)
+		}
+	}
+	return sessionToDisplay, detachedWhy
+}
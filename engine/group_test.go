@@ -0,0 +1,55 @@
+package engine
+
+import "testing"
+
+func TestLayoutRows(t *testing.T) {
+	displays := map[int]Display{
+		// two externals side by side, laptop centered under the left one
+		1: {Index: 1, Frame: Frame{X: 0, Y: 0, W: 1512, H: 982}},
+		2: {Index: 2, Frame: Frame{X: -200, Y: -1080, W: 1920, H: 1080}},
+		3: {Index: 3, Frame: Frame{X: 1720, Y: -1080, W: 1920, H: 1080}},
+		// backend couldn't describe this one
+		4: {Index: 4},
+	}
+	rows := layoutRows([]int{1, 2, 3, 4}, displays)
+	want := map[int]int{1: 1, 2: 0, 3: 0, 4: 0}
+	for d, r := range want {
+		if rows[d] != r {
+			t.Errorf("display %d: row %d, want %d", d, rows[d], r)
+		}
+	}
+}
+
+func TestGroupAndPartition(t *testing.T) {
+	spaces := []Space{{Index: 1, Display: 1}, {Index: 2, Display: 1}, {Index: 3, Display: 2}}
+	windows := []Window{
+		{ID: 10, PID: 100, App: "kitty", Title: "api", Space: 1},
+		{ID: 11, PID: 100, App: "kitty", Title: "web", Space: 3},
+		{ID: 12, PID: 200, App: "Safari", Space: 3},
+		{ID: 13, PID: 300, App: "Notes", Space: 2, IsMinimized: true},
+		{ID: 14, PID: 400, App: "Mail", Space: 3, IsHidden: true},
+		{ID: 15, PID: 500, App: "Keys", IsHidden: true}, // scratchpad: no space
+		{ID: 16, PID: 600, App: "kitty", Space: 1, IsMinimized: true, Scratchpad: "term"},
+	}
+	groups := GroupDisplays(spaces, windows, nil)
+	if len(groups) != 2 || groups[0].FreeCount != 1 || groups[0].TermCount != 1 || len(groups[1].Spaces[0].Windows) != 2 {
+		t.Fatalf("groups = %+v", groups)
+	}
+	if h := groups[0].Hidden; len(h) != 2 || h[0].ID != 15 || h[1].ID != 13 {
+		t.Errorf("display 1 hidden = %+v", h)
+	}
+	if h := groups[1].Hidden; len(h) != 1 || h[0].ID != 14 {
+		t.Errorf("display 2 hidden = %+v", h)
+	}
+
+	panes := []TmuxPane{{SessionName: "api"}, {SessionName: "web"}, {SessionName: "bg"}}
+	clients := []TmuxClient{{PID: 501, SessionName: "api"}, {PID: 502, SessionName: "web"}}
+	tree := map[int]int{501: 100, 502: 100}
+	byDisplay, detached := PartitionTmux(panes, clients, tree, windows, spaces)
+	if len(byDisplay[1]) != 1 || byDisplay[1][0].SessionName != "api" || len(byDisplay[2]) != 1 {
+		t.Errorf("byDisplay = %+v", byDisplay)
+	}
+	if len(detached) != 1 || detached[0].SessionName != "bg" {
+		t.Errorf("detached = %+v", detached)
+	}
+}
@@ -0,0 +1,123 @@
+// iterm: every iTerm2 window's tabs and split panes, with their ttys.
+//
+// iTerm2 runs all its windows under one pid, and AppleScript only knows
+// its windows by iTerm2's own ids, so ttys.go used to ask for each
+// window's current tab alone, keyed by title. a tmux client in a
+// background tab or a split pane then had no tty to match, and its
+// session fell back to title guessing or landed on no display. the
+// script here lists every session of every window — tty, title,
+// foreground job and working directory — so each window carries the
+// ttys of all of them (Window.OtherTTYs), a client on any of them maps
+// to that window, and the sessions themselves come back in
+// State.Sessions. sessions that aren't tmux clients are plain shells, which the
+// dashboard lists under the window with what they're running.
+//
+// iTerm2's windows are still matched to the window manager's by title,
+// and a title two windows share is dropped as ambiguous; with only one
+// window on each side there's nothing to tell apart and it matches
+// whatever the titles say.
+
+package engine
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TerminalSession is one shell inside a terminal window: an iTerm2 tab
+// or split pane.
+type TerminalSession struct {
+	TTY     string // e.g. /dev/ttys004
+	Name    string // the session's title
+	Job     string // its foreground command, e.g. zsh or npm
+	Path    string // its working directory; "" when iTerm2 doesn't know
+	Tab     int    // the tab it's in, from 1
+	Current bool   // the window's current tab's active session
+}
+
+// itermScript lists each session as "window<tab>tab<tab>current<tab>tty
+// <tab>name<tab>job<tab>path", window being the window's name. jobName
+// and path are iTerm2 session variables, blank when shell integration
+// or an old iTerm2 doesn't provide them.
+const itermScript = `tell application "iTerm2"
+	set out to ""
+	repeat with w in windows
+		set wname to name of w
+		set cur to current session of current tab of w
+		set tabIndex to 0
+		repeat with t in tabs of w
+			set tabIndex to tabIndex + 1
+			repeat with s in sessions of t
+				set job to ""
+				set cwd to ""
+				try
+					tell s to set job to (variable named "jobName")
+					tell s to set cwd to (variable named "path")
+				end try
+				set isCur to "0"
+				if (id of s) is (id of cur) then set isCur to "1"
+				set out to out & wname & tab & tabIndex & tab & isCur & tab & (tty of s) & tab & (name of s) & tab & job & tab & cwd & linefeed
+			end repeat
+		end repeat
+	end repeat
+	return out
+end tell`
+
+// parseITermSessions groups the script's lines by window name, in the
+// order iTerm2 listed them. lines without a tty are skipped.
+func parseITermSessions(out string) (names []string, byName map[string][]TerminalSession) {
+	byName = map[string][]TerminalSession{}
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(f) < 7 || f[3] == "" {
+			continue
+		}
+		tab, _ := strconv.Atoi(f[1])
+		s := TerminalSession{TTY: f[3], Name: f[4], Job: f[5], Path: f[6], Tab: tab, Current: f[2] == "1"}
+		if _, ok := byName[f[0]]; !ok {
+			names = append(names, f[0])
+		}
+		byName[f[0]] = append(byName[f[0]], s)
+	}
+	return names, byName
+}
+
+// attachITermSessions fills in TTY and OtherTTYs on iTerm2's windows and
+// returns their sessions by window id. windows match by title, or
+// regardless of it when each side has one. two iTerm2 windows with one
+// name group together under it, each with its own current session, and
+// match nothing.
+func attachITermSessions(windows []Window, out string) map[int][]TerminalSession {
+	names, byName := parseITermSessions(out)
+	var mine []int
+	titles := map[string]int{}
+	for i, w := range windows {
+		if w.App == "iTerm2" {
+			mine = append(mine, i)
+			titles[w.Title]++
+		}
+	}
+	sessions := map[int][]TerminalSession{}
+	for _, i := range mine {
+		key := windows[i].Title
+		if len(mine) == 1 && len(names) == 1 {
+			key = names[0]
+		} else if titles[key] > 1 {
+			continue
+		}
+		var current, others []string
+		for _, s := range byName[key] {
+			if s.Current {
+				current = append(current, s.TTY)
+			} else {
+				others = append(others, s.TTY)
+			}
+		}
+		if len(current) != 1 {
+			continue
+		}
+		windows[i].TTY, windows[i].OtherTTYs = current[0], strings.Join(others, " ")
+		sessions[windows[i].ID] = byName[key]
+	}
+	return sessions
+}
@@ -0,0 +1,49 @@
+package engine
+
+import "testing"
+
+func TestITermSessionsMapBackgroundTabs(t *testing.T) {
+	out := "api\t1\t0\t/dev/ttys003\tzsh\tzsh\t/Users/me/api\n" +
+		"api\t2\t1\t/dev/ttys004\tserver\tnpm\t/Users/me/api\r\n" +
+		"web\t1\t1\t/dev/ttys007\ttmux\ttmux\t\n" +
+		"broken\n"
+	windows := []Window{
+		{ID: 1, PID: 100, App: "iTerm2", Title: "api", Space: 1},
+		{ID: 2, PID: 100, App: "iTerm2", Title: "web", Space: 2},
+		{ID: 3, PID: 200, App: "kitty", Title: "api", Space: 2},
+	}
+	sessions := attachITermSessions(windows, out)
+	if windows[0].TTY != "/dev/ttys004" || windows[0].OtherTTYs != "/dev/ttys003" || len(sessions[1]) != 2 || sessions[1][1].Job != "npm" {
+		t.Fatalf("api window = %+v, sessions %+v", windows[0], sessions)
+	}
+	if windows[1].TTY != "/dev/ttys007" || windows[2].TTY != "" {
+		t.Errorf("windows = %+v", windows)
+	}
+
+	// a client in api's background tab is on api's display
+	spaces := []Space{{Index: 1, Display: 1}, {Index: 2, Display: 2}}
+	clients := []TmuxClient{{PID: 501, SessionName: "deploy", TTY: "/dev/ttys003"}}
+	got, why := MapTmuxClients(clients, map[int]int{501: 100}, windows, spaces)
+	if got["deploy"] != 1 {
+		t.Errorf("sessionToDisplay = %v (%v)", got, why)
+	}
+}
+
+func TestITermSessionsAmbiguousTitles(t *testing.T) {
+	// two iTerm2 windows named zsh: their sessions can't be told apart
+	out := "zsh\t1\t1\t/dev/ttys001\tzsh\tzsh\t\nzsh\t1\t1\t/dev/ttys002\tzsh\tzsh\t\n"
+	windows := []Window{
+		{ID: 1, App: "iTerm2", Title: "zsh"},
+		{ID: 2, App: "iTerm2", Title: "zsh"},
+	}
+	if sessions := attachITermSessions(windows, out); windows[0].TTY != "" || len(sessions) != 0 {
+		t.Errorf("windows = %+v", windows)
+	}
+
+	// one window each side matches whatever the titles say
+	windows = []Window{{ID: 1, App: "iTerm2", Title: "~/src (zsh)"}}
+	attachITermSessions(windows, "zsh\t1\t1\t/dev/ttys001\tzsh\tzsh\t\n")
+	if windows[0].TTY != "/dev/ttys001" {
+		t.Errorf("lone window = %+v", windows[0])
+	}
+}
@@ -0,0 +1,56 @@
+// log: the engine's debug trace.
+//
+// queries, subprocesses and mapping decisions are logged at debug level
+// to a log/slog logger that discards everything until SetLogger. stop
+// points it at its --debug file; an embedder can point it anywhere.
+
+package engine
+
+import (
+	"errors"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var logger = slog.New(slog.DiscardHandler)
+
+// SetLogger routes the engine's trace to l.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// exitCode reads a subprocess error: 0 on success, the exit status when
+// it ran, -1 when it didn't start or was killed.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// LogCommand records one subprocess run, for callers running their own
+// commands alongside the engine's.
+func LogCommand(start time.Time, name string, args []string, err error) {
+	attrs := []any{"cmd", name + " " + strings.Join(args, " "), "took", time.Since(start), "exit", exitCode(err)}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+		logger.Warn("command failed", attrs...)
+		return
+	}
+	logger.Debug("command", attrs...)
+}
+
+// logQuery records one fetch source's timing.
+func logQuery(source string, start time.Time, err error) {
+	if err != nil {
+		logger.Warn("query failed", "source", source, "took", time.Since(start), "err", err)
+		return
+	}
+	logger.Debug("query", "source", source, "took", time.Since(start))
+}
@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	saved := logger
+	defer func() { logger = saved }()
+
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	start := time.Now()
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	LogCommand(start, "sh", []string{"-c", "exit 3"}, err)
+	logQuery("tmux", start, nil)
+	PartitionTmux([]TmuxPane{{SessionName: "api"}}, nil, nil, nil, nil)
+
+	log := buf.String()
+	for _, want := range []string{`cmd="sh -c exit 3"`, "exit=3", "source=tmux", `session=api why="no attached tmux client"`} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log missing %s:\n%s", want, log)
+		}
+	}
+	if exitCode(nil) != 0 || exitCode(os.ErrNotExist) != -1 {
+		t.Error("exitCode mapping wrong")
+	}
+}
+
+func TestSortSourceErrors(t *testing.T) {
+	errs := []SourceError{{"tmux", os.ErrDeadlineExceeded}, {"ps", exec.ErrNotFound}}
+	sortSourceErrors(errs)
+	if errs[0].Source != "ps" || errs[1].String() != "tmux: i/o timeout" {
+		t.Errorf("sorted = %v", errs)
+	}
+}
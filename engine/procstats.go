@@ -0,0 +1,122 @@
+// procstats: how long a process has run and how much CPU it has used.
+//
+// an agent in a tmux pane tells stop it's busy through the pane's
+// activity timestamp. an agent started straight in a terminal tab has no
+// such thing, so the dashboard watches its CPU time instead (see
+// bareagents.go): ps reports each process's elapsed time and cumulative
+// CPU, and a process whose CPU has grown since the last look did
+// something.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProcessStat is a process's start time and the CPU it has used so far.
+type ProcessStat struct {
+	Started time.Time
+	CPU     time.Duration
+}
+
+// QueryProcessStats reads the start time and CPU time of pids. pids that
+// have exited are missing from the result; none of them running isn't
+// an error.
+func QueryProcessStats(pids []int, now time.Time) (map[int]ProcessStat, error) {
+	if len(pids) == 0 {
+		return nil, nil
+	}
+	list := make([]string, len(pids))
+	for i, pid := range pids {
+		list[i] = strconv.Itoa(pid)
+	}
+	args := []string{"-o", "pid=,etime=,time=", "-p", strings.Join(list, ",")}
+	var out []byte
+	err := withRetry(SourcePs, 2*time.Second, func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		start := time.Now()
+		var err error
+		out, err = exec.CommandContext(ctx, "ps", args...).Output()
+		LogCommand(start, "ps", args, err)
+		// ps exits 1 when none of the pids are left
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(strings.TrimSpace(string(out))) == 0 && ctx.Err() == nil {
+			out = nil
+			return nil
+		}
+		if err != nil {
+			return commandError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseProcessStats(string(out), now), nil
+}
+
+// parseProcessStats reads ps's "pid etime time" lines, skipping any it
+// can't make sense of.
+func parseProcessStats(out string, now time.Time) map[int]ProcessStat {
+	stats := map[int]ProcessStat{}
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Fields(line)
+		if len(f) != 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(f[0])
+		if err != nil {
+			continue
+		}
+		elapsed, ok := parsePsDuration(f[1])
+		if !ok {
+			continue
+		}
+		cpu, ok := parsePsDuration(f[2])
+		if !ok {
+			continue
+		}
+		stats[pid] = ProcessStat{Started: now.Add(-elapsed), CPU: cpu}
+	}
+	return stats
+}
+
+// parsePsDuration reads ps's [[dd-]hh:]mm:ss[.cc] durations, which is
+// how both etime and time are printed.
+func parsePsDuration(s string) (time.Duration, bool) {
+	var days int
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, false
+		}
+		days, s = n, rest
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	secs, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, false
+	}
+	d := time.Duration(secs*float64(time.Second)) + time.Duration(days)*24*time.Hour
+	for i, unit := range []time.Duration{time.Minute, time.Hour} {
+		idx := len(parts) - 2 - i
+		if idx < 0 {
+			break
+		}
+		n, err := strconv.Atoi(parts[idx])
+		if err != nil {
+			return 0, false
+		}
+		d += time.Duration(n) * unit
+	}
+	return d, true
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePsDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"00:07":      7 * time.Second,
+		"12:34":      12*time.Minute + 34*time.Second,
+		"01:02:03":   time.Hour + 2*time.Minute + 3*time.Second,
+		"2-03:00:00": 51 * time.Hour,
+		"0:01.50":    1500 * time.Millisecond,
+	}
+	for in, want := range cases {
+		if got, ok := parsePsDuration(in); !ok || got != want {
+			t.Errorf("parsePsDuration(%q) = %v, %v; want %v", in, got, ok, want)
+		}
+	}
+	for _, bad := range []string{"", "7", "x:00", "1-2-03:00"} {
+		if _, ok := parsePsDuration(bad); ok {
+			t.Errorf("parsePsDuration(%q) should fail", bad)
+		}
+	}
+}
+
+func TestParseProcessStats(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	out := "  412    01:00:00     0:12.30\n  977       00:30   0:00.01\nbroken\n"
+	stats := parseProcessStats(out, now)
+	if len(stats) != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if s := stats[412]; !s.Started.Equal(now.Add(-time.Hour)) || s.CPU != 12300*time.Millisecond {
+		t.Errorf("412 = %+v", s)
+	}
+	if s := stats[977]; !s.Started.Equal(now.Add(-30 * time.Second)) {
+		t.Errorf("977 = %+v", s)
+	}
+}
@@ -0,0 +1,78 @@
+// process table reads, and the cache in front of them.
+//
+// the process tree ties tmux clients to terminal windows and panes to the
+// agents running in them. it used to come from ps, which forks, walks and
+// formats every process on each refresh — on a busy machine the slowest
+// query of a fetch. the table is now read directly (sysctl KERN_PROC on
+// macOS, /proc on linux; ps remains for everything else and as the
+// fallback) and kept for processTreeTTL. a change in the set of tmux
+// client pids re-reads it straight away, since tracing a newly attached
+// client to its window is the one thing that can't wait.
+
+package engine
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// processTreeTTL is how long a read process table is reused while the
+// tmux clients stay the same.
+const processTreeTTL = 5 * time.Second
+
+var (
+	procMu   sync.Mutex
+	procAt   time.Time
+	procKey  string
+	procTree map[int]int
+	procComm map[int]string
+)
+
+// clientKey identifies a set of tmux clients by pid.
+func clientKey(clients []TmuxClient) string {
+	pids := make([]int, len(clients))
+	for i, c := range clients {
+		pids[i] = c.PID
+	}
+	slices.Sort(pids)
+	parts := make([]string, len(pids))
+	for i, pid := range pids {
+		parts[i] = strconv.Itoa(pid)
+	}
+	return strings.Join(parts, ",")
+}
+
+// CachedProcessTree is QueryProcessTree behind a cache keyed by the tmux
+// client pids: the last table is returned while clients is the same set
+// and it's younger than processTreeTTL. the maps are shared between
+// callers and must not be modified.
+func CachedProcessTree(clients []TmuxClient, now time.Time) (map[int]int, map[int]string, error) {
+	key := clientKey(clients)
+	procMu.Lock()
+	defer procMu.Unlock()
+	if procTree != nil && key == procKey && now.Sub(procAt) < processTreeTTL {
+		return procTree, procComm, nil
+	}
+	tree, comm, err := QueryProcessTree()
+	if err != nil {
+		return nil, nil, err
+	}
+	procTree, procComm, procKey, procAt = tree, comm, key, now
+	return tree, comm, nil
+}
+
+// QueryProcessTree returns a pid → ppid map and pid → comm map for all
+// processes, read from the kernel where the platform allows and from ps
+// otherwise.
+func QueryProcessTree() (map[int]int, map[int]string, error) {
+	start := time.Now()
+	tree, comm, err := readProcessTable()
+	logQuery("process table", start, err)
+	if err != nil {
+		return psProcessTree()
+	}
+	return tree, comm, nil
+}
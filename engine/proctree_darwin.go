@@ -0,0 +1,21 @@
+//go:build darwin
+
+package engine
+
+import "golang.org/x/sys/unix"
+
+// readProcessTable lists every process with one sysctl(KERN_PROC_ALL).
+func readProcessTable() (map[int]int, map[int]string, error) {
+	procs, err := unix.SysctlKinfoProcSlice("kern.proc.all")
+	if err != nil {
+		return nil, nil, err
+	}
+	tree := make(map[int]int, len(procs))
+	comm := make(map[int]string, len(procs))
+	for _, p := range procs {
+		pid := int(p.Proc.P_pid)
+		tree[pid] = int(p.Eproc.Ppid)
+		comm[pid] = unix.ByteSliceToString(p.Proc.P_comm[:])
+	}
+	return tree, comm, nil
+}
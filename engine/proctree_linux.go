@@ -0,0 +1,60 @@
+//go:build linux
+
+package engine
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readProcessTable lists every process from /proc/<pid>/stat. processes
+// that exit mid-walk are skipped.
+func readProcessTable() (map[int]int, map[int]string, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, nil, err
+	}
+	tree := make(map[int]int, len(entries))
+	comm := make(map[int]string, len(entries))
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile("/proc/" + e.Name() + "/stat")
+		if err != nil {
+			continue
+		}
+		name, ppid, err := parseProcStat(string(data))
+		if err != nil {
+			continue
+		}
+		tree[pid], comm[pid] = ppid, name
+	}
+	if len(tree) == 0 {
+		return nil, nil, errors.New("no processes in /proc")
+	}
+	return tree, comm, nil
+}
+
+// parseProcStat pulls comm and ppid out of a /proc/<pid>/stat line:
+// "pid (comm) state ppid ...". comm can hold spaces and parentheses, so
+// it runs to the last ')'.
+func parseProcStat(stat string) (string, int, error) {
+	open := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return "", 0, errors.New("malformed stat")
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return "", 0, errors.New("malformed stat")
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, err
+	}
+	return stat[open+1 : end], ppid, nil
+}
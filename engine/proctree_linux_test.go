@@ -0,0 +1,23 @@
+package engine
+
+import "testing"
+
+func TestParseProcStat(t *testing.T) {
+	cases := []struct {
+		stat string
+		comm string
+		ppid int
+		ok   bool
+	}{
+		{"4242 (zsh) S 4100 4242 4242 34817", "zsh", 4100, true},
+		{"77 (tmux: server) S 1 77 77 0", "tmux: server", 1, true},
+		{"88 (a) b) (c) R 12 88", "a) b) (c", 12, true},
+		{"garbage", "", 0, false},
+	}
+	for _, c := range cases {
+		comm, ppid, err := parseProcStat(c.stat)
+		if (err == nil) != c.ok || comm != c.comm || ppid != c.ppid {
+			t.Errorf("parseProcStat(%q) = %q, %d, %v", c.stat, comm, ppid, err)
+		}
+	}
+}
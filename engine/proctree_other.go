@@ -0,0 +1,8 @@
+//go:build !darwin && !linux
+
+package engine
+
+// readProcessTable has no native source here; ps it is.
+func readProcessTable() (map[int]int, map[int]string, error) {
+	return psProcessTree()
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadProcessTable(t *testing.T) {
+	tree, comm, err := readProcessTable()
+	if err != nil {
+		t.Skipf("no process table here: %v", err)
+	}
+	if ppid, ok := tree[os.Getpid()]; !ok || ppid != os.Getppid() {
+		t.Errorf("own pid → %d, %v; want %d", ppid, ok, os.Getppid())
+	}
+	if comm[os.Getpid()] == "" {
+		t.Error("own comm missing")
+	}
+}
+
+func TestCachedProcessTree(t *testing.T) {
+	defer func() { procTree, procComm, procKey, procAt = nil, nil, "", time.Time{} }()
+	now := time.Now()
+	clients := []TmuxClient{{PID: 20}, {PID: 10}}
+	first, _, err := CachedProcessTree(clients, now)
+	if err != nil {
+		t.Skipf("no process tree here: %v", err)
+	}
+	same := func(a, b map[int]int) bool { return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer() }
+
+	// same clients in another order, inside the TTL: the cached table
+	again, _, _ := CachedProcessTree([]TmuxClient{{PID: 10}, {PID: 20}}, now.Add(time.Second))
+	if !same(first, again) {
+		t.Error("same clients re-read the table")
+	}
+	// a new client re-reads at once
+	attached, _, _ := CachedProcessTree(append(clients, TmuxClient{PID: 30}), now.Add(2*time.Second))
+	if same(first, attached) {
+		t.Error("new client served from cache")
+	}
+	// and so does age
+	expired, _, _ := CachedProcessTree(append(clients, TmuxClient{PID: 30}), now.Add(2*time.Second+processTreeTTL))
+	if same(attached, expired) {
+		t.Error("expired table served from cache")
+	}
+}
+
+func BenchmarkProcessTreePS(b *testing.B) {
+	if _, err := exec.LookPath("ps"); err != nil {
+		b.Skip("ps not installed")
+	}
+	for b.Loop() {
+		if _, _, err := psProcessTree(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessTreeNative(b *testing.B) {
+	for b.Loop() {
+		if _, _, err := readProcessTable(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessTreeCached(b *testing.B) {
+	defer func() { procTree, procComm, procKey, procAt = nil, nil, "", time.Time{} }()
+	clients := []TmuxClient{{PID: 1}, {PID: 2}}
+	now := time.Now()
+	for b.Loop() {
+		if _, _, err := CachedProcessTree(clients, now); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
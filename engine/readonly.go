@@ -0,0 +1,41 @@
+// readonly: a switch that keeps the engine, and stop, from changing anything.
+//
+// with read-only on, every command that would change the machine (focus,
+// window moves, tmux kills and renames, notifications) asks Guard first
+// and gets ErrReadOnly instead of running. queries still run. the window
+// manager backends guard their own mutating calls; callers running their
+// own side-effecting commands guard them the same way, so the policy
+// lives here and nowhere else.
+
+package engine
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrReadOnly is what a refused mutation returns.
+var ErrReadOnly = errors.New("read-only: not changing anything")
+
+var readOnly atomic.Bool
+
+// SetReadOnly turns read-only on or off for the whole process.
+func SetReadOnly(on bool) {
+	readOnly.Store(on)
+}
+
+// ReadOnly reports whether mutations are refused.
+func ReadOnly() bool {
+	return readOnly.Load()
+}
+
+// Guard is called before a command with side effects: nil lets it run,
+// ErrReadOnly (logged with the command) means it mustn't.
+func Guard(name string, args ...string) error {
+	if !readOnly.Load() {
+		return nil
+	}
+	logger.Info("refused in read-only mode", "cmd", name+" "+strings.Join(args, " "))
+	return ErrReadOnly
+}
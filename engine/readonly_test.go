@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	defer SetReadOnly(false)
+
+	commands := make(chan string, 1)
+	sock := fakeI3(t, map[uint32]string{
+		i3RunCommand: `[{"success":true}]`,
+		i3GetOutputs: `[]`,
+	}, commands)
+	b := NewI3("i3", sock)
+
+	SetReadOnly(true)
+	if err := Guard("tmux", "kill-session"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Guard = %v", err)
+	}
+	if err := b.FocusWindow(100); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("FocusWindow = %v", err)
+	}
+	if err := runYabai("space", "--focus", "1"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("runYabai = %v", err)
+	}
+	select {
+	case cmd := <-commands:
+		t.Errorf("read-only sent %q", cmd)
+	default:
+	}
+	if _, err := b.request(i3GetOutputs, nil); err != nil {
+		t.Errorf("queries should still run: %v", err)
+	}
+
+	SetReadOnly(false)
+	if err := b.FocusWindow(100); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-commands; got != "[con_id=100] focus" {
+		t.Errorf("focus = %q", got)
+	}
+}
@@ -0,0 +1,101 @@
+// replay: a recording played back in time.
+//
+// a Recording is Fake frames, each stamped with how far into the
+// recording it was fetched. Replay is a WindowManager and a Querier that
+// answers from whichever frame is current — the last one at or before
+// the time since the replay started — so whatever fetches from it sees
+// the recorded desk change as it did, and stays on the last frame once
+// the recording runs out. actions don't touch anything; they fail with
+// ErrReplaying.
+
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// ErrReplaying is what a Replay answers every action with.
+var ErrReplaying = errors.New("replaying a recording")
+
+// Recording is fetches over time.
+type Recording struct {
+	Started  time.Time     `json:"started"`
+	Interval time.Duration `json:"interval"`
+	Frames   []*Fake       `json:"frames"`
+}
+
+// LoadRecording reads a recording. a single fixture (engine.Fake's JSON)
+// reads as a one-frame recording.
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("parsing recording %s: %w", path, err)
+	}
+	if len(rec.Frames) == 0 {
+		var f Fake
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("parsing recording %s: %w", path, err)
+		}
+		rec.Frames = []*Fake{&f}
+	}
+	sort.SliceStable(rec.Frames, func(i, j int) bool { return rec.Frames[i].At < rec.Frames[j].At })
+	return &rec, nil
+}
+
+// Replay plays a recording back from the moment it was made.
+type Replay struct {
+	rec   *Recording
+	start time.Time
+	now   func() time.Time
+}
+
+// NewReplay starts playing rec at start.
+func NewReplay(rec *Recording, start time.Time) *Replay {
+	return &Replay{rec: rec, start: start, now: time.Now}
+}
+
+// Position is the current frame's number (from 0) and how many there are.
+func (r *Replay) Position() (frame, frames int) {
+	elapsed := r.now().Sub(r.start)
+	frame = sort.Search(len(r.rec.Frames), func(i int) bool { return r.rec.Frames[i].At > elapsed }) - 1
+	return max(frame, 0), len(r.rec.Frames)
+}
+
+func (r *Replay) frame() *Fake {
+	i, _ := r.Position()
+	return r.rec.Frames[i]
+}
+
+func (r *Replay) Name() string                      { return r.frame().Name() }
+func (r *Replay) QuerySpaces() ([]Space, error)     { return r.frame().QuerySpaces() }
+func (r *Replay) QueryDisplays() ([]Display, error) { return r.frame().QueryDisplays() }
+func (r *Replay) QueryWindows() ([]Window, error)   { return r.frame().QueryWindows() }
+
+func (r *Replay) QueryTmuxPanes() ([]TmuxPane, error)     { return r.frame().QueryTmuxPanes() }
+func (r *Replay) QueryTmuxClients() ([]TmuxClient, error) { return r.frame().QueryTmuxClients() }
+func (r *Replay) AttachWindowTTYs(windows []Window) (map[int][]TerminalSession, error) {
+	return r.frame().AttachWindowTTYs(windows)
+}
+
+func (r *Replay) QueryProcessTree(clients []TmuxClient, now time.Time) (map[int]int, map[int]string, error) {
+	return r.frame().QueryProcessTree(clients, now)
+}
+
+func (r *Replay) FocusSpace(int) error          { return ErrReplaying }
+func (r *Replay) FocusWindow(int) error         { return ErrReplaying }
+func (r *Replay) RestoreWindow(int) error       { return ErrReplaying }
+func (r *Replay) ToggleScratchpad(string) error { return ErrReplaying }
+func (r *Replay) MoveWindow(int, int) error     { return ErrReplaying }
+func (r *Replay) FlashWindows([]int) error      { return ErrReplaying }
+func (r *Replay) DestroySpace(int) error        { return ErrReplaying }
+func (r *Replay) LabelSpace(int, string) error  { return ErrReplaying }
+func (r *Replay) CreateSpace(int) (int, error)  { return 0, ErrReplaying }
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReplayFollowsTheClock(t *testing.T) {
+	rec := Recording{Frames: []*Fake{
+		{At: 0, Spaces: []Space{{Index: 1}}},
+		{At: 2 * time.Second, Spaces: []Space{{Index: 1}, {Index: 2}}},
+		{At: 4 * time.Second, Spaces: []Space{{Index: 1}, {Index: 2}, {Index: 3}}},
+	}}
+	path := filepath.Join(t.TempDir(), "session.json")
+	data, _ := json.Marshal(rec)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadRecording(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewReplay(loaded, start)
+	for _, c := range []struct {
+		after  time.Duration
+		spaces int
+	}{{0, 1}, {1999 * time.Millisecond, 1}, {2 * time.Second, 2}, {3 * time.Second, 2}, {time.Minute, 3}} {
+		r.now = func() time.Time { return start.Add(c.after) }
+		spaces, _ := r.QuerySpaces()
+		if len(spaces) != c.spaces {
+			t.Errorf("at %s: %d spaces, want %d", c.after, len(spaces), c.spaces)
+		}
+	}
+	if frame, frames := r.Position(); frame != 2 || frames != 3 {
+		t.Errorf("position = %d of %d", frame, frames)
+	}
+	if err := r.FocusSpace(1); !errors.Is(err, ErrReplaying) {
+		t.Errorf("FocusSpace = %v, want ErrReplaying", err)
+	}
+}
+
+func TestLoadRecordingFixture(t *testing.T) {
+	rec, err := LoadRecording("../testdata/desk.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Frames) != 1 || len(rec.Frames[0].Spaces) != 5 {
+		t.Fatalf("fixture read as %d frames", len(rec.Frames))
+	}
+	r := NewReplay(rec, time.Now())
+	if s := FetchFrom(r, r); len(s.TmuxPanes) != 4 || s.Windows[1].TTY != "/dev/ttys002" {
+		t.Errorf("replayed fetch = %+v", s)
+	}
+}
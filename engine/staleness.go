@@ -0,0 +1,66 @@
+// staleness: how long ago a pane last did something, in named bands.
+//
+// panes and sessions are classified by the age of their last output into
+// an ordered list of tiers, freshest first. the list is the caller's —
+// stop's comes from its config — but it has to be well-formed for every
+// age to land in exactly one tier, which CheckStalenessTiers enforces.
+
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// StalenessTier is one activity band. a pane lands in the first tier
+// whose Under its last output is younger than; the last tier has Under
+// 0 and takes everything older.
+type StalenessTier struct {
+	Name  string
+	Under time.Duration
+}
+
+// DefaultStalenessTiers are stop's default bands.
+var DefaultStalenessTiers = []StalenessTier{
+	{Name: "active", Under: time.Minute},
+	{Name: "recent", Under: 5 * time.Minute},
+	{Name: "cooling", Under: 15 * time.Minute},
+	{Name: "stale", Under: time.Hour},
+	{Name: "cold"},
+}
+
+// CheckStalenessTiers validates a tier list: at least two tiers, unique
+// names, strictly increasing bounds and an unbounded last tier.
+func CheckStalenessTiers(tiers []StalenessTier) error {
+	if len(tiers) < 2 {
+		return fmt.Errorf("staleness tiers: need at least 2, have %d", len(tiers))
+	}
+	seen := map[string]bool{}
+	var prev time.Duration
+	for i, t := range tiers {
+		switch {
+		case t.Name == "":
+			return fmt.Errorf("staleness tier %d: empty name", i)
+		case seen[t.Name]:
+			return fmt.Errorf("staleness tier %q: duplicate name", t.Name)
+		case i == len(tiers)-1 && t.Under != 0:
+			return fmt.Errorf("staleness tier %q: the last tier must be unbounded (under 0)", t.Name)
+		case i < len(tiers)-1 && t.Under <= prev:
+			return fmt.Errorf("staleness tier %q: bound %s must be above the previous tier's %s", t.Name, t.Under, prev)
+		}
+		seen[t.Name] = true
+		prev = t.Under
+	}
+	return nil
+}
+
+// ClassifyStaleness returns the index of the tier an activity age falls
+// in. tiers must pass CheckStalenessTiers.
+func ClassifyStaleness(tiers []StalenessTier, age time.Duration) int {
+	for i, t := range tiers {
+		if t.Under > 0 && age < t.Under {
+			return i
+		}
+	}
+	return len(tiers) - 1
+}
@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassifyStaleness(t *testing.T) {
+	if err := CheckStalenessTiers(DefaultStalenessTiers); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[time.Duration]string{
+		0:                "active",
+		90 * time.Second: "recent",
+		10 * time.Minute: "cooling",
+		time.Hour:        "cold",
+	}
+	for age, want := range cases {
+		if got := DefaultStalenessTiers[ClassifyStaleness(DefaultStalenessTiers, age)].Name; got != want {
+			t.Errorf("%s → %s, want %s", age, got, want)
+		}
+	}
+
+	bad := [][]StalenessTier{
+		{{Name: "only"}},
+		{{Name: "a", Under: time.Minute}, {Name: "a"}},
+		{{Name: "a", Under: 5 * time.Minute}, {Name: "b", Under: time.Minute}, {Name: "c"}},
+		{{Name: "a", Under: time.Minute}, {Name: "b", Under: time.Hour}},
+	}
+	for _, tiers := range bad {
+		if CheckStalenessTiers(tiers) == nil {
+			t.Errorf("CheckStalenessTiers(%v) accepted", tiers)
+		}
+	}
+}
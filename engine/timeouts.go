@@ -0,0 +1,118 @@
+// timeouts: how long each query gets, and another try when it runs out.
+//
+// on a loaded machine yabai or ps can take longer than the defaults (3s
+// for yabai, 2s for the rest), and one slow answer used to cost the
+// whole refresh. SetQueryLimits sets each source's timeout and how many
+// times a timed-out query is tried again, with a backoff that doubles
+// between tries. only timeouts are retried: any other failure is an
+// answer, and asking again wouldn't change it. commands that change
+// something (focus, move, destroy) aren't queries and never retry.
+//
+// every timeout is counted per source, and logged, so `stop doctor` and
+// the debug log can show which source is struggling.
+
+package engine
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is a query that ran past its source's timeout.
+var ErrTimeout = errors.New("timeout")
+
+// query sources, as keys of QueryLimits.Timeouts
+const (
+	SourceYabai     = "yabai"
+	SourceTmux      = "tmux"
+	SourcePs        = "ps"
+	SourceOsascript = "osascript"
+)
+
+// QueryLimits bounds the engine's queries.
+type QueryLimits struct {
+	Timeouts map[string]time.Duration // by source; a source left out keeps its default
+	Retries  int                      // tries after the first that times out
+	Backoff  time.Duration            // wait before the first retry, doubled after each
+}
+
+// TimeoutStat is one source's timeouts since the process started.
+type TimeoutStat struct {
+	Source   string
+	Timeouts int // every attempt that timed out
+	GaveUp   int // queries that timed out on every try
+}
+
+var (
+	limitsMu     sync.Mutex
+	limits       = QueryLimits{Retries: 1, Backoff: 250 * time.Millisecond}
+	timeoutStats = map[string]*TimeoutStat{}
+)
+
+// SetQueryLimits replaces the query limits.
+func SetQueryLimits(l QueryLimits) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	limits = l
+}
+
+// queryTimeout is source's timeout, or def when none is set.
+func queryTimeout(source string, def time.Duration) time.Duration {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	if d := limits.Timeouts[source]; d > 0 {
+		return d
+	}
+	return def
+}
+
+// withRetry runs query with source's timeout, and again after a backoff
+// each time it times out, up to the configured retries.
+func withRetry(source string, def time.Duration, query func(timeout time.Duration) error) error {
+	timeout := queryTimeout(source, def)
+	limitsMu.Lock()
+	retries, backoff := limits.Retries, limits.Backoff
+	limitsMu.Unlock()
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = query(timeout); !errors.Is(err, ErrTimeout) {
+			return err
+		}
+		gaveUp := attempt >= retries
+		countTimeout(source, gaveUp)
+		logger.Warn("query timed out", "source", source, "timeout", timeout, "attempt", attempt+1, "gave_up", gaveUp)
+		if gaveUp {
+			return err
+		}
+		time.Sleep(backoff << attempt)
+	}
+}
+
+func countTimeout(source string, gaveUp bool) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	s := timeoutStats[source]
+	if s == nil {
+		s = &TimeoutStat{Source: source}
+		timeoutStats[source] = s
+	}
+	s.Timeouts++
+	if gaveUp {
+		s.GaveUp++
+	}
+}
+
+// TimeoutStats lists each source that has timed out, by name.
+func TimeoutStats() []TimeoutStat {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	stats := make([]TimeoutStat, 0, len(timeoutStats))
+	for _, s := range timeoutStats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Source < stats[j].Source })
+	return stats
+}
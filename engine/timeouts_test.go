@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithRetry(t *testing.T) {
+	defer SetQueryLimits(limits)
+	defer func() { timeoutStats = map[string]*TimeoutStat{} }()
+	SetQueryLimits(QueryLimits{Timeouts: map[string]time.Duration{"slow": 7 * time.Second}, Retries: 2, Backoff: time.Millisecond})
+
+	// times out once, then answers
+	var timeouts []time.Duration
+	err := withRetry("slow", time.Second, func(timeout time.Duration) error {
+		timeouts = append(timeouts, timeout)
+		if len(timeouts) == 1 {
+			return ErrTimeout
+		}
+		return nil
+	})
+	if err != nil || len(timeouts) != 2 || timeouts[0] != 7*time.Second {
+		t.Fatalf("err = %v after %v", err, timeouts)
+	}
+
+	// never answers: three tries, then the timeout
+	tries := 0
+	err = withRetry("hung", time.Second, func(timeout time.Duration) error {
+		tries++
+		if timeout != time.Second {
+			t.Errorf("timeout = %v, want the default", timeout)
+		}
+		return ErrTimeout
+	})
+	if !errors.Is(err, ErrTimeout) || tries != 3 {
+		t.Errorf("err = %v after %d tries", err, tries)
+	}
+
+	// any other failure is final
+	tries = 0
+	boom := errors.New("boom")
+	if err := withRetry("slow", time.Second, func(time.Duration) error { tries++; return boom }); err != boom || tries != 1 {
+		t.Errorf("err = %v after %d tries", err, tries)
+	}
+
+	stats := TimeoutStats()
+	want := []TimeoutStat{{Source: "hung", Timeouts: 3, GaveUp: 1}, {Source: "slow", Timeouts: 1}}
+	if len(stats) != 2 || stats[0] != want[0] || stats[1] != want[1] {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
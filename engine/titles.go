@@ -0,0 +1,111 @@
+// titles: which of a terminal's windows is showing a tmux client.
+//
+// single-process terminals (kitty) put every OS window under one pid, so
+// the process-tree walk only narrows a client down to the terminal; the
+// window itself is picked by title. the title is rarely just the session
+// name — tmux's set-titles-string, a shell or vim all decorate it — so a
+// window matches a client in decreasing order of confidence: the title is
+// exactly the session name, a configured title template extracts the
+// session name, the title starts with the session name as a word, or the
+// title mentions the client's tty.
+
+package engine
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var titlePattern *regexp.Regexp
+
+// SetTitlePattern sets the title template: a regexp whose "session"
+// group (or first group, when unnamed) captures the session name from a
+// window title, e.g. `^\[(?P<session>[^\]]+)\]`. nil turns it off.
+func SetTitlePattern(re *regexp.Regexp) {
+	titlePattern = re
+}
+
+// title match ranks, best last. titleNoMatch means the title says nothing
+// about the client.
+const (
+	titleNoMatch = iota
+	titleTTY
+	titlePrefix
+	titleTemplate
+	titleExact
+)
+
+// titleMatch ranks how surely a window title names a client.
+func titleMatch(title string, c TmuxClient) int {
+	session := c.SessionName
+	switch {
+	case title == "" || session == "":
+		return titleNoMatch
+	case title == session:
+		return titleExact
+	case templateSession(title) == session:
+		return titleTemplate
+	case wordPrefix(title, session):
+		return titlePrefix
+	case c.TTY != "" && strings.Contains(title, strings.TrimPrefix(c.TTY, "/dev/")):
+		return titleTTY
+	}
+	return titleNoMatch
+}
+
+// templateSession extracts the session name from a title through the
+// title template, or "" without one or when it doesn't match.
+func templateSession(title string) string {
+	if titlePattern == nil {
+		return ""
+	}
+	m := titlePattern.FindStringSubmatch(title)
+	if m == nil {
+		return ""
+	}
+	if i := titlePattern.SubexpIndex("session"); i > 0 {
+		return m[i]
+	}
+	if len(m) > 1 {
+		return m[1]
+	}
+	return ""
+}
+
+// wordPrefix reports whether title starts with session followed by a
+// space or colon ("api — vim main.go", "api: zsh"). hyphens don't count
+// as a boundary, so session "api" doesn't claim "api-v2 — vim".
+func wordPrefix(title, session string) bool {
+	if !strings.HasPrefix(title, session) {
+		return false
+	}
+	next, _ := utf8.DecodeRuneInString(title[len(session):])
+	return unicode.IsSpace(next) || next == ':'
+}
+
+// bestTitleMatch picks the index of the window title that best names the
+// client, or -1 when none does. ties go to the first.
+func bestTitleMatch(titles []string, c TmuxClient) int {
+	best, bestRank := -1, titleNoMatch
+	for i, t := range titles {
+		if rank := titleMatch(t, c); rank > bestRank {
+			best, bestRank = i, rank
+		}
+	}
+	return best
+}
+
+// termNameApps maps the TERM a terminal advertises to its app names, for
+// the terminals whose TERM says who they are; the rest report a generic
+// xterm-256color. it lets a client whose process-tree walk never reaches
+// a window (ps can't see it, or it sits behind a setsid) still be matched
+// by title among that terminal's windows.
+var termNameApps = map[string][]string{
+	"xterm-kitty":   {"kitty"},
+	"alacritty":     {"Alacritty"},
+	"wezterm":       {"WezTerm", "org.wezfurlong.wezterm"},
+	"xterm-ghostty": {"com.mitchellh.ghostty"},
+	"foot":          {"foot", "footclient"},
+}
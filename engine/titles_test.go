@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTitleMatch(t *testing.T) {
+	SetTitlePattern(regexp.MustCompile(`^\[(?P<session>[^\]]+)\]`))
+	defer SetTitlePattern(nil)
+
+	c := TmuxClient{SessionName: "api", TTY: "/dev/ttys003"}
+	cases := []struct {
+		title string
+		want  int
+	}{
+		{"api", titleExact},
+		{"[api] vim", titleTemplate},
+		{"api — vim foo.go", titlePrefix},
+		{"api: zsh", titlePrefix},
+		{"api-v2 — vim", titleNoMatch},
+		{"zsh on ttys003", titleTTY},
+		{"web", titleNoMatch},
+		{"", titleNoMatch},
+	}
+	for _, tc := range cases {
+		if got := titleMatch(tc.title, c); got != tc.want {
+			t.Errorf("titleMatch(%q) = %d, want %d", tc.title, got, tc.want)
+		}
+	}
+
+	// an exact title wins over an earlier prefix one
+	if got := bestTitleMatch([]string{"api — vim", "web", "api"}, c); got != 2 {
+		t.Errorf("bestTitleMatch = %d, want 2", got)
+	}
+	if got := bestTitleMatch([]string{"web", "notes"}, c); got != -1 {
+		t.Errorf("bestTitleMatch = %d, want -1", got)
+	}
+}
+
+func TestMapTmuxClientsByTitle(t *testing.T) {
+	spaces := []Space{{Index: 1, Display: 1}, {Index: 2, Display: 2}}
+	windows := []Window{
+		{PID: 100, App: "kitty", Title: "api — vim main.go", Space: 1},
+		{PID: 100, App: "kitty", Title: "web: zsh", Space: 2},
+	}
+	clients := []TmuxClient{
+		{PID: 501, SessionName: "api"},
+		{PID: 502, SessionName: "web"},
+		// not under kitty's pid, but its TERM says kitty
+		{PID: 503, SessionName: "web", TermName: "xterm-kitty"},
+		{PID: 504, SessionName: "bg"},
+	}
+	tree := map[int]int{501: 100, 502: 100}
+	got, why := MapTmuxClients(clients, tree, windows, spaces)
+	if got["api"] != 1 || got["web"] != 2 {
+		t.Errorf("sessionToDisplay = %v", got)
+	}
+	if _, ok := got["bg"]; ok || why["bg"] == "" {
+		t.Errorf("bg should be detached with a reason: %v %v", got, why)
+	}
+
+	clients = []TmuxClient{{PID: 503, SessionName: "web", TermName: "xterm-kitty"}}
+	if got, _ := MapTmuxClients(clients, tree, windows, spaces); got["web"] != 2 {
+		t.Errorf("TERM fallback: %v", got)
+	}
+}
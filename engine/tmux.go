@@ -0,0 +1,221 @@
+// tmux and ps queries.
+//
+// tmux is the other half of the picture: every pane, and every attached
+// client so sessions can be traced to the terminal window showing them.
+// the process tree that trace walks is read in proctree.go. a tmux with no server
+// running isn't an error, just no sessions.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryTmuxPanes fetches per-pane data from all tmux sessions.
+// returns nil, and no error, if tmux is not running or has no sessions.
+func QueryTmuxPanes() ([]TmuxPane, error) {
+	var out []byte
+	err := withRetry(SourceTmux, 2*time.Second, func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		start := time.Now()
+		var err error
+		out, err = exec.CommandContext(ctx, "tmux", "list-panes", "-a", "-F",
+			"#{session_name}\t#{window_index}\t#{window_name}\t#{pane_index}\t#{pane_current_command}\t#{window_activity}\t#{history_size}\t#{pane_current_path}\t#{pane_pid}\t#{@stop_progress}\t#{pane_title}").Output()
+		LogCommand(start, "tmux", []string{"list-panes", "-a"}, err)
+		if err != nil && !tmuxNotRunning(err) {
+			return commandError(ctx, err)
+		}
+		return err
+	})
+	if err != nil {
+		if tmuxNotRunning(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var panes []TmuxPane
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		// pane_title is last and free-form, so cap the split to keep any
+		// tabs inside it from shifting the fixed columns
+		parts := strings.SplitN(line, "\t", 11)
+		if len(parts) < 9 {
+			continue
+		}
+		var progressOption, paneTitle string
+		if len(parts) >= 11 {
+			progressOption, paneTitle = parts[9], parts[10]
+		}
+		var windowIndex, paneIndex, historySize, panePID int
+		var activityEpoch int64
+		fmt.Sscanf(parts[1], "%d", &windowIndex)
+		fmt.Sscanf(parts[3], "%d", &paneIndex)
+		fmt.Sscanf(parts[5], "%d", &activityEpoch)
+		fmt.Sscanf(parts[6], "%d", &historySize)
+		fmt.Sscanf(parts[8], "%d", &panePID)
+		panes = append(panes, TmuxPane{
+			SessionName:    parts[0],
+			WindowIndex:    windowIndex,
+			WindowName:     parts[2],
+			PaneIndex:      paneIndex,
+			CurrentCommand: parts[4],
+			CurrentPath:    parts[7],
+			PanePID:        panePID,
+			LastActivity:   time.Unix(activityEpoch, 0),
+			HistorySize:    historySize,
+			Progress:       parsePaneProgress(progressOption, paneTitle),
+		})
+	}
+	return panes, nil
+}
+
+// paneTitleProgressPattern matches the escape-sequence convention for
+// publishing progress without tmux access: a process sets its pane title
+// via OSC 2 (printf '\e]2;stop:progress=42\e\\') and we pick it up from
+// #{pane_title}.
+var paneTitleProgressPattern = regexp.MustCompile(`stop:progress=(\d{1,3})`)
+
+// parsePaneProgress resolves a pane's published progress. the tmux user
+// option (`tmux set -p @stop_progress 42`) wins over the pane title
+// convention since it's explicit. returns -1 when neither is set or the
+// value isn't a usable percentage; values above 100 clamp to 100.
+func parsePaneProgress(option, title string) int {
+	raw := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(option), "%"))
+	if raw == "" {
+		if m := paneTitleProgressPattern.FindStringSubmatch(title); m != nil {
+			raw = m[1]
+		}
+	}
+	if raw == "" {
+		return -1
+	}
+	pct, err := strconv.Atoi(raw)
+	if err != nil || pct < 0 {
+		return -1
+	}
+	if pct > 100 {
+		pct = 100
+	}
+	return pct
+}
+
+// QueryTmuxClients fetches the PID, session name, tty and TERM of each
+// attached tmux client. returns nil, and no error, if tmux is not running
+// or has no attached clients.
+func QueryTmuxClients() ([]TmuxClient, error) {
+	var out []byte
+	err := withRetry(SourceTmux, 2*time.Second, func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		start := time.Now()
+		var err error
+		out, err = exec.CommandContext(ctx, "tmux", "list-clients", "-F",
+			"#{client_pid}\t#{session_name}\t#{client_tty}\t#{client_termname}").Output()
+		LogCommand(start, "tmux", []string{"list-clients"}, err)
+		if err != nil && !tmuxNotRunning(err) {
+			return commandError(ctx, err)
+		}
+		return err
+	})
+	if err != nil {
+		if tmuxNotRunning(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var clients []TmuxClient
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 {
+			continue
+		}
+		var pid int
+		fmt.Sscanf(parts[0], "%d", &pid)
+		c := TmuxClient{PID: pid, SessionName: parts[1]}
+		if len(parts) >= 4 {
+			c.TTY, c.TermName = parts[2], parts[3]
+		}
+		clients = append(clients, c)
+	}
+	return clients, nil
+}
+
+// psProcessTree returns a pid → ppid map and pid → comm map for all
+// running processes. used to walk from tmux client PIDs up to terminal
+// emulator PIDs, and to detect productive process descendants.
+func psProcessTree() (map[int]int, map[int]string, error) {
+	var out []byte
+	err := withRetry(SourcePs, 2*time.Second, func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		start := time.Now()
+		var err error
+		out, err = exec.CommandContext(ctx, "ps", "-eo", "pid,ppid,comm").Output()
+		LogCommand(start, "ps", []string{"-eo", "pid,ppid,comm"}, err)
+		if err != nil {
+			return commandError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	tree := make(map[int]int)
+	comm := make(map[int]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "PID") {
+			continue
+		}
+		var pid, ppid int
+		var cmd string
+		if _, err := fmt.Sscanf(line, "%d %d %s", &pid, &ppid, &cmd); err == nil {
+			tree[pid] = ppid
+			comm[pid] = cmd
+		}
+	}
+	return tree, comm, nil
+}
+
+// commandError shortens a failed query command to its reason: a timeout,
+// a missing binary, or the first line the command printed to stderr.
+func commandError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, exec.ErrNotFound):
+		return errors.New("not installed")
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		line, _, _ := strings.Cut(strings.TrimSpace(string(exitErr.Stderr)), "\n")
+		if line != "" {
+			return errors.New(line)
+		}
+	}
+	return err
+}
+
+// tmuxNotRunning reports whether a tmux command failed only because no
+// server is up, which just means there are no sessions.
+func tmuxNotRunning(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	stderr := string(exitErr.Stderr)
+	return strings.Contains(stderr, "no server running") || strings.Contains(stderr, "error connecting to")
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestParsePaneProgress(t *testing.T) {
+	cases := []struct {
+		option, title string
+		want          int
+	}{
+		{"", "", -1},
+		{"42", "", 42},
+		{" 42% ", "", 42},
+		{"250", "", 100},
+		{"-3", "", -1},
+		{"abc", "", -1},
+		{"", "build stop:progress=7", 7},
+		{"60", "stop:progress=7", 60}, // option wins over title
+		{"", "progress 50%", -1},      // free-form titles are ignored
+	}
+	for _, c := range cases {
+		if got := parsePaneProgress(c.option, c.title); got != c.want {
+			t.Errorf("parsePaneProgress(%q, %q) = %d, want %d", c.option, c.title, got, c.want)
+		}
+	}
+}
+
+func TestCommandError(t *testing.T) {
+	ctx := context.Background()
+	_, err := exec.CommandContext(ctx, "sh", "-c", "echo 'ps: permission denied' >&2; echo more >&2; exit 1").Output()
+	if got := commandError(ctx, err); got.Error() != "ps: permission denied" {
+		t.Errorf("stderr reason = %q", got)
+	}
+
+	_, err = exec.CommandContext(ctx, "stop-test-no-such-binary").Output()
+	if got := commandError(ctx, err); got.Error() != "not installed" {
+		t.Errorf("missing binary reason = %q", got)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = exec.CommandContext(short, "sleep", "1").Output()
+	if got := commandError(short, err); got.Error() != "timeout" {
+		t.Errorf("timeout reason = %q", got)
+	}
+
+	_, err = exec.CommandContext(ctx, "sh", "-c", "echo 'no server running on /tmp/tmux-501/default' >&2; exit 1").Output()
+	if !tmuxNotRunning(err) {
+		t.Error("no tmux server should read as no sessions, not a failure")
+	}
+}
@@ -0,0 +1,126 @@
+// ttys: which tty each terminal window is showing.
+//
+// iTerm2 and Terminal.app run every window under one pid, like kitty, but
+// their titles are whatever the shell last set, so title matching is
+// guesswork. both will say over AppleScript which tty each window's
+// current tab is on, and tmux reports the tty each client runs on, so a
+// client and a window on the same tty are certainly the same thing.
+// AttachWindowTTYs asks them and fills in Window.TTY; iTerm2 is asked
+// for every tab and split pane besides (iterm.go).
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ttyScripts list each window of an app with its current tab's tty, one
+// "id<tab>tty" line per window. Terminal's AppleScript window id is the
+// window server id the window managers report.
+var ttyScripts = map[string]string{
+	"Terminal": `tell application "Terminal"
+	set out to ""
+	repeat with w in windows
+		set out to out & (id of w) & tab & (tty of selected tab of w) & linefeed
+	end repeat
+	return out
+end tell`,
+}
+
+// AttachWindowTTYs sets TTY on the windows of terminals that share one
+// pid across several windows and can report their ttys, and on iTerm2's
+// windows however many it has, returning their sessions by window id.
+// apps without such windows aren't asked — telling an app that isn't
+// running to list its windows would launch it.
+func AttachWindowTTYs(windows []Window) (map[int][]TerminalSession, error) {
+	perPID := map[int]int{}
+	for _, w := range windows {
+		perPID[w.PID]++
+	}
+	var apps []string
+	for _, w := range windows {
+		_, ok := ttyScripts[w.App]
+		ok = (ok && perPID[w.PID] > 1) || w.App == "iTerm2"
+		if ok && !slices.Contains(apps, w.App) {
+			apps = append(apps, w.App)
+		}
+	}
+	sort.Strings(apps)
+
+	var sessions map[int][]TerminalSession
+	var errs []string
+	for _, app := range apps {
+		script := ttyScripts[app]
+		if app == "iTerm2" {
+			script = itermScript
+		}
+		out, err := runOsascript(script)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", app, err))
+			continue
+		}
+		if app == "iTerm2" {
+			sessions = attachITermSessions(windows, out)
+			continue
+		}
+		ttys := parseWindowTTYs(out)
+		for i, w := range windows {
+			if w.App != app {
+				continue
+			}
+			if tty, ok := ttys[strconv.Itoa(w.ID)]; ok {
+				windows[i].TTY = tty
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return sessions, fmt.Errorf("querying terminal ttys: %s", strings.Join(errs, "; "))
+	}
+	return sessions, nil
+}
+
+// parseWindowTTYs reads "key<tab>tty" lines. a key listed twice is
+// ambiguous and dropped.
+func parseWindowTTYs(out string) map[string]string {
+	ttys := map[string]string{}
+	dup := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		key, tty, ok := strings.Cut(strings.TrimRight(line, "\r"), "\t")
+		if !ok || tty == "" {
+			continue
+		}
+		if _, seen := ttys[key]; seen {
+			dup[key] = true
+		}
+		ttys[key] = tty
+	}
+	for key := range dup {
+		delete(ttys, key)
+	}
+	return ttys
+}
+
+// runOsascript runs an AppleScript and returns its output.
+func runOsascript(script string) (string, error) {
+	var out []byte
+	err := withRetry(SourceOsascript, 2*time.Second, func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		start := time.Now()
+		var err error
+		out, err = exec.CommandContext(ctx, "osascript", "-e", script).Output()
+		LogCommand(start, "osascript", []string{"-e", "…"}, err)
+		if err != nil {
+			return commandError(ctx, err)
+		}
+		return nil
+	})
+	return string(out), err
+}
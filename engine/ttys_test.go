@@ -0,0 +1,29 @@
+package engine
+
+import "testing"
+
+func TestParseWindowTTYs(t *testing.T) {
+	out := "4117\t/dev/ttys003\n4120\t/dev/ttys007\r\nzsh\t/dev/ttys001\nzsh\t/dev/ttys002\nbroken line\n5000\t\n"
+	got := parseWindowTTYs(out)
+	if len(got) != 2 || got["4117"] != "/dev/ttys003" || got["4120"] != "/dev/ttys007" {
+		t.Errorf("parseWindowTTYs = %v", got)
+	}
+}
+
+func TestMapTmuxClientsByTTY(t *testing.T) {
+	spaces := []Space{{Index: 1, Display: 1}, {Index: 2, Display: 2}}
+	// titles say nothing useful; the ttys do
+	windows := []Window{
+		{ID: 1, PID: 100, App: "Terminal", Title: "zsh", Space: 1, TTY: "/dev/ttys003"},
+		{ID: 2, PID: 100, App: "Terminal", Title: "zsh", Space: 2, TTY: "/dev/ttys007"},
+	}
+	clients := []TmuxClient{
+		{PID: 501, SessionName: "api", TTY: "/dev/ttys007"},
+		{PID: 502, SessionName: "web", TTY: "/dev/ttys003"},
+	}
+	tree := map[int]int{501: 100, 502: 100}
+	got, why := MapTmuxClients(clients, tree, windows, spaces)
+	if got["api"] != 2 || got["web"] != 1 {
+		t.Errorf("sessionToDisplay = %v (%v)", got, why)
+	}
+}
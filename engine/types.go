@@ -0,0 +1,143 @@
+// types: what stop reads from the window manager and tmux.
+//
+// the window manager types keep yabai's vocabulary and JSON shape
+// (hyphenated keys) whatever backend produced them, so a consumer can
+// decode `yabai -m query` output or stop's own snapshots with the same
+// structs.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// -- yabai types --
+// fields match yabai's JSON output (hyphenated keys)
+
+// Space represents a macOS space/desktop as reported by yabai
+type Space struct {
+	ID        int    `json:"id"`
+	Index     int    `json:"index"`
+	Label     string `json:"label"`
+	Display   int    `json:"display"`
+	Windows   []int  `json:"windows"`
+	HasFocus  bool   `json:"has-focus"`
+	IsVisible bool   `json:"is-visible"`
+}
+
+// Frame is a rectangle in the window manager's global coordinate space
+// (points on macOS, pixels on sway/i3). y grows downward in both.
+type Frame struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+}
+
+// Display represents a physical monitor as reported by yabai. Name and
+// the pixel resolution don't come from yabai itself; backends fill them
+// in when they can, and they stay empty/zero otherwise.
+type Display struct {
+	ID          int    `json:"id"`
+	UUID        string `json:"uuid"` // stable across hot-plugs, unlike the index
+	Index       int    `json:"index"`
+	Frame       Frame  `json:"frame"`
+	Name        string `json:"-"`
+	PixelWidth  int    `json:"-"`
+	PixelHeight int    `json:"-"`
+}
+
+// ShortName is the display's name trimmed for a column header: the
+// built-in panel's long marketing name collapses to "built-in".
+func (d Display) ShortName() string {
+	if strings.HasPrefix(d.Name, "Built-in") {
+		return "built-in"
+	}
+	return d.Name
+}
+
+// Resolution renders the pixel size as "3840x2160", or "" when unknown.
+func (d Display) Resolution() string {
+	if d.PixelWidth <= 0 || d.PixelHeight <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", d.PixelWidth, d.PixelHeight)
+}
+
+// Window represents an application window as reported by yabai
+type Window struct {
+	ID          int    `json:"id"`
+	PID         int    `json:"pid"`
+	App         string `json:"app"`
+	Title       string `json:"title"`
+	Space       int    `json:"space"`
+	IsVisible   bool   `json:"is-visible"`
+	IsMinimized bool   `json:"is-minimized"`
+	IsHidden    bool   `json:"is-hidden"`
+	IsFloating  bool   `json:"is-floating"`
+	IsSticky    bool   `json:"is-sticky"` // shown on every space
+	HasFocus    bool   `json:"has-focus"`
+	// zoomed to fill the space, or to fill its parent split
+	HasFullscreenZoom bool `json:"has-fullscreen-zoom"`
+	HasParentZoom     bool `json:"has-parent-zoom"`
+	// in a macOS fullscreen space of its own
+	IsNativeFullscreen bool `json:"is-native-fullscreen"`
+	// scratchpad label (yabai 6+, i3 marks): the window is toggled in and
+	// out of view by label rather than living on a space
+	Scratchpad string `json:"scratchpad"`
+	// tty of the window's current tab, for terminals that report it
+	// (ttys.go); not from the window manager
+	TTY string `json:"-"`
+	// ttys of the window's other tabs and split panes, space-separated,
+	// for terminals that list them (iterm.go)
+	OtherTTYs string `json:"-"`
+}
+
+// TmuxPane holds per-pane data from tmux including staleness and buffer info
+type TmuxPane struct {
+	SessionName    string
+	WindowIndex    int
+	WindowName     string
+	PaneIndex      int
+	CurrentCommand string
+	CurrentPath    string // working directory of the pane's active process
+	PanePID        int    // PID of the pane's active process
+	LastActivity   time.Time
+	HistorySize    int // lines in scroll buffer
+	Progress       int // 0-100 published by the pane itself; -1 when unset
+}
+
+// TmuxClient maps a tmux client process to its session.
+// used to correlate tmux sessions with terminal windows via process tree.
+type TmuxClient struct {
+	PID         int
+	SessionName string
+	TTY         string // e.g. /dev/ttys003
+	TermName    string // the TERM the terminal advertised, e.g. xterm-kitty
+}
+
+// TerminalApps are terminal emulator app names as reported by the window
+// manager backends. tmux sessions are only mapped through these windows.
+var TerminalApps = map[string]bool{
+	"kitty":     true,
+	"iTerm2":    true,
+	"Terminal":  true,
+	"Alacritty": true,
+	"WezTerm":   true,
+	"Hyper":     true,
+	"Rio":       true,
+	"Tabby":     true,
+
+	// linux app_id / WM_CLASS values as reported by sway / i3
+	"foot":                   true,
+	"footclient":             true,
+	"org.wezfurlong.wezterm": true,
+	"com.mitchellh.ghostty":  true,
+}
+
+// IsTerminal reports whether app is a terminal emulator.
+func IsTerminal(app string) bool {
+	return TerminalApps[app]
+}
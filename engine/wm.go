@@ -0,0 +1,323 @@
+// window manager backends: where spaces and windows come from.
+//
+// yabai (macOS) was the only source originally. the WindowManager
+// interface lets everything downstream stay in yabai's vocabulary —
+// displays, spaces, windows — while a backend translates whatever its
+// window manager reports into those types. DetectWindowManager picks
+// sway/i3 when their IPC socket is advertised in the environment and
+// yabai otherwise; NoWindowManager stands in when there's none at all,
+// leaving just the tmux half.
+
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// WindowManager is a source of spaces and windows plus the few mutating
+// actions stop needs (switching, destroying, moving, flashing,
+// restoring, toggling scratchpads, labeling, creating). space
+// indices are the backend's absolute indices — Window.Space refers to
+// them and the mutating methods take one.
+type WindowManager interface {
+	Name() string
+	QuerySpaces() ([]Space, error)
+	QueryDisplays() ([]Display, error)
+	QueryWindows() ([]Window, error)
+	FocusSpace(index int) error
+	FocusWindow(id int) error
+	RestoreWindow(id int) error
+	ToggleScratchpad(label string) error
+	MoveWindow(windowID, space int) error
+	FlashWindows(ids []int) error
+	DestroySpace(index int) error
+	LabelSpace(index int, label string) error
+	CreateSpace(display int) (int, error)
+}
+
+// DetectWindowManager prefers an i3-protocol socket when the session
+// advertises one (sway sets SWAYSOCK, i3 sets I3SOCK) and falls back to
+// yabai, which also covers the "nothing detected" case so error messages
+// keep pointing at yabai on macOS.
+func DetectWindowManager() WindowManager {
+	if sock := os.Getenv("SWAYSOCK"); sock != "" {
+		return NewI3("sway", sock)
+	}
+	if sock := os.Getenv("I3SOCK"); sock != "" {
+		return NewI3("i3", sock)
+	}
+	return Yabai{}
+}
+
+// -- none --
+
+// ErrNoWindowManager is what NoWindowManager answers everything with.
+var ErrNoWindowManager = errors.New("window manager disabled")
+
+// NoWindowManager has no spaces and refuses every action.
+type NoWindowManager struct{}
+
+func (NoWindowManager) Name() string                      { return "none" }
+func (NoWindowManager) QuerySpaces() ([]Space, error)     { return nil, ErrNoWindowManager }
+func (NoWindowManager) QueryDisplays() ([]Display, error) { return nil, ErrNoWindowManager }
+func (NoWindowManager) QueryWindows() ([]Window, error)   { return nil, ErrNoWindowManager }
+func (NoWindowManager) FocusSpace(int) error              { return ErrNoWindowManager }
+func (NoWindowManager) FocusWindow(int) error             { return ErrNoWindowManager }
+func (NoWindowManager) RestoreWindow(int) error           { return ErrNoWindowManager }
+func (NoWindowManager) ToggleScratchpad(string) error     { return ErrNoWindowManager }
+func (NoWindowManager) MoveWindow(int, int) error         { return ErrNoWindowManager }
+func (NoWindowManager) FlashWindows([]int) error          { return ErrNoWindowManager }
+func (NoWindowManager) DestroySpace(int) error            { return ErrNoWindowManager }
+func (NoWindowManager) LabelSpace(int, string) error      { return ErrNoWindowManager }
+func (NoWindowManager) CreateSpace(int) (int, error)      { return 0, ErrNoWindowManager }
+
+// flashDuration is how long a flashed window stays dimmed.
+const flashDuration = 300 * time.Millisecond
+
+// -- yabai --
+
+// Yabai sends each request over yabai's socket, or shells out to
+// `yabai -m` when the socket can't be reached.
+type Yabai struct{}
+
+func (Yabai) Name() string { return "yabai" }
+
+// queryYabai asks over yabai's socket (yabaisock.go), exec'ing the CLI
+// when there's no socket, and asks again when it times out (timeouts.go).
+func queryYabai(domain string) ([]byte, error) {
+	var out []byte
+	err := withRetry(SourceYabai, 3*time.Second, func(timeout time.Duration) error {
+		var err error
+		out, err = queryYabaiOnce(domain, timeout)
+		return err
+	})
+	return out, err
+}
+
+func queryYabaiOnce(domain string, timeout time.Duration) ([]byte, error) {
+	start := time.Now()
+	out, err := yabaiMessage(timeout, "query", "--"+domain)
+	if !errors.Is(err, errNoYabaiSocket) {
+		LogCommand(start, "yabai.socket", []string{"query", "--" + domain}, err)
+		return out, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start = time.Now()
+	out, err = exec.CommandContext(ctx, "yabai", "-m", "query", "--"+domain).Output()
+	LogCommand(start, "yabai", []string{"-m", "query", "--" + domain}, err)
+	if err != nil {
+		return nil, commandError(ctx, err)
+	}
+	return out, nil
+}
+
+func (Yabai) QuerySpaces() ([]Space, error) {
+	data, err := queryYabai("spaces")
+	if err != nil {
+		return nil, err
+	}
+	var spaces []Space
+	return spaces, json.Unmarshal(data, &spaces)
+}
+
+// QueryDisplays reads yabai's displays and names them from NSScreen, which
+// knows the marketing name ("LG HDR 4K") and backing scale yabai lacks.
+// screens are matched to displays by frame since both use the same point
+// coordinates; unmatched displays keep an empty name.
+func (Yabai) QueryDisplays() ([]Display, error) {
+	data, err := queryYabai("displays")
+	if err != nil {
+		return nil, err
+	}
+	var displays []Display
+	if err := json.Unmarshal(data, &displays); err != nil {
+		return nil, err
+	}
+	screens := queryScreens()
+	for i, d := range displays {
+		for _, s := range screens {
+			if s.X == d.Frame.X && s.W == d.Frame.W && s.H == d.Frame.H {
+				displays[i].Name = s.Name
+				displays[i].PixelWidth = int(s.W * s.Scale)
+				displays[i].PixelHeight = int(s.H * s.Scale)
+				break
+			}
+		}
+	}
+	return displays, nil
+}
+
+// screenInfo is one NSScreen as reported by screenInfoScript.
+type screenInfo struct {
+	Name  string  `json:"name"`
+	X     float64 `json:"x"`
+	W     float64 `json:"w"`
+	H     float64 `json:"h"`
+	Scale float64 `json:"scale"`
+}
+
+// screenInfoScript is JXA that dumps every NSScreen's name, frame, and
+// backing scale as JSON.
+const screenInfoScript = `ObjC.import('AppKit');
+var s = $.NSScreen.screens, out = [];
+for (var i = 0; i < s.count; i++) {
+	var sc = s.objectAtIndex(i), f = sc.frame;
+	out.push({name: ObjC.unwrap(sc.localizedName), x: f.origin.x, w: f.size.width, h: f.size.height, scale: sc.backingScaleFactor});
+}
+JSON.stringify(out);`
+
+// screenCacheTTL bounds how often the osascript round-trip runs. monitors
+// rarely change, and the script costs far more than a yabai query.
+const screenCacheTTL = time.Minute
+
+var (
+	screenCacheMu sync.Mutex
+	screenCache   []screenInfo
+	screenCacheAt time.Time
+)
+
+// queryScreens runs screenInfoScript, cached for screenCacheTTL. nil on
+// any failure — display names are decoration, never worth an error.
+func queryScreens() []screenInfo {
+	screenCacheMu.Lock()
+	defer screenCacheMu.Unlock()
+	if screenCache != nil && time.Since(screenCacheAt) < screenCacheTTL {
+		return screenCache
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "osascript", "-l", "JavaScript", "-e", screenInfoScript).Output()
+	if err != nil {
+		return nil
+	}
+	var screens []screenInfo
+	if json.Unmarshal(out, &screens) != nil {
+		return nil
+	}
+	screenCache, screenCacheAt = screens, time.Now()
+	return screens
+}
+
+func (Yabai) QueryWindows() ([]Window, error) {
+	data, err := queryYabai("windows")
+	if err != nil {
+		return nil, err
+	}
+	var windows []Window
+	return windows, json.Unmarshal(data, &windows)
+}
+
+// runYabai runs one mutating `yabai -m` command
+func runYabai(args ...string) error {
+	if err := Guard("yabai", append([]string{"-m"}, args...)...); err != nil {
+		return err
+	}
+	start := time.Now()
+	_, err := yabaiMessage(2*time.Second, args...)
+	if !errors.Is(err, errNoYabaiSocket) {
+		LogCommand(start, "yabai.socket", args, err)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start = time.Now()
+	_, err = exec.CommandContext(ctx, "yabai", append([]string{"-m"}, args...)...).Output()
+	LogCommand(start, "yabai", append([]string{"-m"}, args...), err)
+	if err != nil {
+		return commandError(ctx, err) // yabai's complaint, from stderr
+	}
+	return nil
+}
+
+// FocusSpace tells yabai to switch focus to a specific space index,
+// falling back to Mission Control's shortcuts without the scripting
+// addition (yabaisa.go)
+func (y Yabai) FocusSpace(index int) error {
+	return focusSpace(index, func(index int) error {
+		return runYabai("space", "--focus", fmt.Sprintf("%d", index))
+	}, y.QuerySpaces)
+}
+
+// FocusWindow gives a window keyboard focus
+func (Yabai) FocusWindow(id int) error {
+	return runYabai("window", "--focus", fmt.Sprintf("%d", id))
+}
+
+// RestoreWindow brings back a minimized or hidden window and focuses it.
+// deminimizing fails on a window that isn't minimized, which is fine:
+// focusing alone unhides a hidden app's window.
+func (y Yabai) RestoreWindow(id int) error {
+	runYabai("window", fmt.Sprintf("%d", id), "--deminimize")
+	return y.FocusWindow(id)
+}
+
+// ToggleScratchpad shows the window with a scratchpad label on the
+// current space, or hides it again when it's showing.
+func (Yabai) ToggleScratchpad(label string) error {
+	return runYabai("window", "--toggle", label)
+}
+
+// DestroySpace removes a space; yabai moves its windows to a neighbor
+func (Yabai) DestroySpace(index int) error {
+	return runYabai("space", fmt.Sprintf("%d", index), "--destroy")
+}
+
+// LabelSpace sets a space's label; an empty label clears it
+func (Yabai) LabelSpace(index int, label string) error {
+	return runYabai("space", fmt.Sprintf("%d", index), "--label", label)
+}
+
+// CreateSpace adds a space at the end of a display and returns its
+// index, found as the display's last space once yabai has made it
+func (y Yabai) CreateSpace(display int) (int, error) {
+	if err := runYabai("space", "--create", fmt.Sprintf("%d", display)); err != nil {
+		return 0, err
+	}
+	spaces, err := y.QuerySpaces()
+	if err != nil {
+		return 0, err
+	}
+	index := 0
+	for _, s := range spaces {
+		if s.Display == display {
+			index = max(index, s.Index)
+		}
+	}
+	if index == 0 {
+		return 0, fmt.Errorf("no spaces on display %d after creating one", display)
+	}
+	return index, nil
+}
+
+// MoveWindow sends a window to another space without following it
+func (Yabai) MoveWindow(windowID, space int) error {
+	return runYabai("window", fmt.Sprintf("%d", windowID), "--space", fmt.Sprintf("%d", space))
+}
+
+// FlashWindows dims each window, waits, then resets opacity to yabai's
+// default (0.0 means "use the configured opacity").
+func (Yabai) FlashWindows(ids []int) error {
+	set := func(opacity string) error {
+		for _, id := range ids {
+			if err := runYabai("window", fmt.Sprintf("%d", id), "--opacity", opacity); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := set("0.4"); err != nil {
+		set("0.0")
+		return err
+	}
+	time.Sleep(flashDuration)
+	return set("0.0")
+}
@@ -0,0 +1,467 @@
+// sway / i3 backend: spaces and windows over the i3 IPC socket.
+//
+// the i3 IPC protocol is a framed request/response over a unix socket:
+// "i3-ipc" magic, little-endian uint32 payload length, uint32 message
+// type, then a JSON payload. sway speaks the same protocol. mapping into
+// yabai's vocabulary: outputs become displays (numbered left-to-right,
+// top-to-bottom like a physical arrangement), workspaces become spaces,
+// and leaf containers with a pid become windows.
+
+package engine
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// i3 IPC message types used here
+const (
+	i3RunCommand    = 0
+	i3GetWorkspaces = 1
+	i3GetOutputs    = 3
+	i3GetTree       = 4
+)
+
+// i3IPCMagic prefixes every request and reply frame
+const i3IPCMagic = "i3-ipc"
+
+// i3ScratchpadName is the internal workspace holding scratchpad windows.
+// windows there are treated as hidden, like yabai's hidden windows.
+const i3ScratchpadName = "__i3_scratch"
+
+// I3 is the sway / i3 backend.
+type I3 struct {
+	wmName string
+	socket string
+
+	// workspace names by the space index we assigned on the last query,
+	// so FocusSpace can translate back to a `workspace` command
+	mu           sync.Mutex
+	namesByIndex map[int]string
+}
+
+// NewI3 talks to the window manager called name ("sway" or "i3") over
+// its IPC socket.
+func NewI3(name, socket string) *I3 {
+	return &I3{wmName: name, socket: socket}
+}
+
+func (b *I3) Name() string { return b.wmName }
+
+// request sends one IPC message and returns the reply payload. each call
+// dials a fresh connection — the socket is local and cheap, and it keeps
+// concurrent queries from interleaving frames. commands are refused in
+// read-only mode (readonly.go).
+func (b *I3) request(msgType uint32, payload []byte) ([]byte, error) {
+	if msgType == i3RunCommand {
+		if err := Guard(b.wmName+"-msg", string(payload)); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := net.DialTimeout("unix", b.socket, 3*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+	header := make([]byte, len(i3IPCMagic)+8)
+	copy(header, i3IPCMagic)
+	binary.LittleEndian.PutUint32(header[len(i3IPCMagic):], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(header[len(i3IPCMagic)+4:], msgType)
+	if _, err := conn.Write(append(header, payload...)); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, fmt.Errorf("reading %s reply header: %w", b.wmName, err)
+	}
+	if string(header[:len(i3IPCMagic)]) != i3IPCMagic {
+		return nil, fmt.Errorf("bad %s reply magic", b.wmName)
+	}
+	size := binary.LittleEndian.Uint32(header[len(i3IPCMagic):])
+	body := make([]byte, size)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil, fmt.Errorf("reading %s reply: %w", b.wmName, err)
+	}
+	return body, nil
+}
+
+type i3Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type i3Output struct {
+	Name        string `json:"name"`
+	Make        string `json:"make"`
+	Model       string `json:"model"`
+	Active      bool   `json:"active"`
+	Rect        i3Rect `json:"rect"`
+	CurrentMode struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"current_mode"`
+}
+
+type i3Workspace struct {
+	ID      int    `json:"id"`
+	Num     int    `json:"num"`
+	Name    string `json:"name"`
+	Visible bool   `json:"visible"`
+	Focused bool   `json:"focused"`
+	Output  string `json:"output"`
+}
+
+// i3Node is the subset of a GET_TREE node we read. sway reports app_id
+// for wayland clients; xwayland and i3 clients carry window_properties.
+type i3Node struct {
+	ID               int      `json:"id"`
+	Name             string   `json:"name"`
+	Type             string   `json:"type"`
+	PID              int      `json:"pid"`
+	AppID            string   `json:"app_id"`
+	Visible          *bool    `json:"visible"`
+	Focused          bool     `json:"focused"`
+	Sticky           bool     `json:"sticky"`
+	FullscreenMode   int      `json:"fullscreen_mode"`  // 0 none, 1 workspace, 2 global
+	ScratchpadState  string   `json:"scratchpad_state"` // "none" unless it was ever moved to the scratchpad
+	Marks            []string `json:"marks"`
+	Nodes            []i3Node `json:"nodes"`
+	FloatingNodes    []i3Node `json:"floating_nodes"`
+	WindowProperties struct {
+		Class string `json:"class"`
+	} `json:"window_properties"`
+}
+
+// activeOutputs returns active outputs sorted by physical position, which
+// is also their display numbering (1..N).
+func (b *I3) activeOutputs() ([]i3Output, error) {
+	data, err := b.request(i3GetOutputs, nil)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []i3Output
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		return nil, err
+	}
+	var active []i3Output
+	for _, o := range outputs {
+		if o.Active {
+			active = append(active, o)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].Rect.X != active[j].Rect.X {
+			return active[i].Rect.X < active[j].Rect.X
+		}
+		return active[i].Rect.Y < active[j].Rect.Y
+	})
+	return active, nil
+}
+
+// displayIndices numbers active outputs 1..N by physical position.
+func (b *I3) displayIndices() (map[string]int, error) {
+	active, err := b.activeOutputs()
+	if err != nil {
+		return nil, err
+	}
+	indices := make(map[string]int, len(active))
+	for i, o := range active {
+		indices[o.Name] = i + 1
+	}
+	return indices, nil
+}
+
+// workspaceSpaces returns spaces in display order with contiguous absolute
+// indices, plus a workspace-name → index map for window assignment.
+func (b *I3) workspaceSpaces() ([]Space, map[string]int, error) {
+	displays, err := b.displayIndices()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := b.request(i3GetWorkspaces, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var workspaces []i3Workspace
+	if err := json.Unmarshal(data, &workspaces); err != nil {
+		return nil, nil, err
+	}
+
+	// named workspaces report num -1; sort them after numbered ones
+	sort.SliceStable(workspaces, func(i, j int) bool {
+		di, dj := displays[workspaces[i].Output], displays[workspaces[j].Output]
+		if di != dj {
+			return di < dj
+		}
+		ni, nj := workspaces[i].Num, workspaces[j].Num
+		if (ni < 0) != (nj < 0) {
+			return nj < 0
+		}
+		return ni < nj
+	})
+
+	spaces := make([]Space, 0, len(workspaces))
+	indexByName := make(map[string]int, len(workspaces))
+	names := make(map[int]string, len(workspaces))
+	for i, ws := range workspaces {
+		idx := i + 1
+		label := ""
+		if ws.Name != strconv.Itoa(ws.Num) {
+			label = ws.Name
+		}
+		spaces = append(spaces, Space{
+			ID:        ws.ID,
+			Index:     idx,
+			Label:     label,
+			Display:   displays[ws.Output],
+			HasFocus:  ws.Focused,
+			IsVisible: ws.Visible,
+		})
+		indexByName[ws.Name] = idx
+		names[idx] = ws.Name
+	}
+
+	b.mu.Lock()
+	b.namesByIndex = names
+	b.mu.Unlock()
+	return spaces, indexByName, nil
+}
+
+// QueryDisplays maps active outputs to displays. the name prefers the
+// monitor's make/model over the connector name (DP-2) when sway reports it;
+// the connector name is the display's UUID, which outlives index shifts.
+func (b *I3) QueryDisplays() ([]Display, error) {
+	active, err := b.activeOutputs()
+	if err != nil {
+		return nil, err
+	}
+	displays := make([]Display, 0, len(active))
+	for i, o := range active {
+		name := strings.TrimSpace(o.Make + " " + o.Model)
+		if name == "" || name == "Unknown Unknown" {
+			name = o.Name
+		}
+		displays = append(displays, Display{
+			UUID:  o.Name,
+			Index: i + 1,
+			Frame: Frame{
+				X: float64(o.Rect.X), Y: float64(o.Rect.Y),
+				W: float64(o.Rect.Width), H: float64(o.Rect.Height),
+			},
+			Name:        name,
+			PixelWidth:  o.CurrentMode.Width,
+			PixelHeight: o.CurrentMode.Height,
+		})
+	}
+	return displays, nil
+}
+
+func (b *I3) QuerySpaces() ([]Space, error) {
+	spaces, _, err := b.workspaceSpaces()
+	if err != nil {
+		return nil, err
+	}
+	// yabai fills Space.Windows; do the same from the tree so consumers
+	// relying on it (snapshots) see consistent data
+	windows, err := b.QueryWindows()
+	if err == nil {
+		bySpace := map[int][]int{}
+		for _, w := range windows {
+			bySpace[w.Space] = append(bySpace[w.Space], w.ID)
+		}
+		for i := range spaces {
+			spaces[i].Windows = bySpace[spaces[i].Index]
+		}
+	}
+	return spaces, nil
+}
+
+func (b *I3) QueryWindows() ([]Window, error) {
+	_, indexByName, err := b.workspaceSpaces()
+	if err != nil {
+		return nil, err
+	}
+	data, err := b.request(i3GetTree, nil)
+	if err != nil {
+		return nil, err
+	}
+	var root i3Node
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	// a scratchpad window keeps its scratchpad_state on the floating
+	// container around it, wherever it's shown; its first mark is the
+	// label it's toggled by
+	var windows []Window
+	var walk func(n i3Node, space int, scratch, floating, pad bool)
+	walk = func(n i3Node, space int, scratch, floating, pad bool) {
+		if n.Type == "workspace" {
+			space = indexByName[n.Name]
+			scratch = n.Name == i3ScratchpadName
+		}
+		if n.ScratchpadState != "" && n.ScratchpadState != "none" {
+			pad = true
+		}
+		if len(n.Nodes) == 0 && len(n.FloatingNodes) == 0 && n.PID > 0 && (n.Type == "con" || n.Type == "floating_con") {
+			app := n.AppID
+			if app == "" {
+				app = n.WindowProperties.Class
+			}
+			visible := true
+			if n.Visible != nil {
+				visible = *n.Visible
+			}
+			label := ""
+			if pad && len(n.Marks) > 0 {
+				label = n.Marks[0]
+			}
+			windows = append(windows, Window{
+				ID:                n.ID,
+				PID:               n.PID,
+				App:               app,
+				Title:             n.Name,
+				Space:             space,
+				IsVisible:         visible && !scratch,
+				IsHidden:          scratch,
+				IsFloating:        floating || n.Type == "floating_con",
+				IsSticky:          n.Sticky,
+				HasFocus:          n.Focused,
+				HasFullscreenZoom: n.FullscreenMode != 0,
+				Scratchpad:        label,
+			})
+			return
+		}
+		for _, c := range n.Nodes {
+			walk(c, space, scratch, floating, pad)
+		}
+		for _, c := range n.FloatingNodes {
+			walk(c, space, scratch, true, pad)
+		}
+	}
+	walk(root, 0, false, false, false)
+	return windows, nil
+}
+
+func (b *I3) FocusSpace(index int) error {
+	b.mu.Lock()
+	name, ok := b.namesByIndex[index]
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no %s workspace at index %d", b.wmName, index)
+	}
+	cmd := "workspace " + strconv.Quote(name)
+	_, err := b.request(i3RunCommand, []byte(cmd))
+	return err
+}
+
+func (b *I3) FocusWindow(id int) error {
+	_, err := b.request(i3RunCommand, []byte(fmt.Sprintf("[con_id=%d] focus", id)))
+	return err
+}
+
+// RestoreWindow shows a window from the scratchpad, the only place i3
+// hides windows; showing it also focuses it.
+func (b *I3) RestoreWindow(id int) error {
+	return b.runCommand(fmt.Sprintf("[con_id=%d] scratchpad show", id))
+}
+
+// ToggleScratchpad shows the scratchpad window marked with label, or
+// sends it back when it's already showing.
+func (b *I3) ToggleScratchpad(label string) error {
+	return b.runCommand(fmt.Sprintf("[con_mark=%s] scratchpad show", strconv.Quote("^"+regexp.QuoteMeta(label)+"$")))
+}
+
+// MoveWindow moves a container by id to the workspace behind a space
+// index. i3 reports command failures in the reply body, not the transport.
+func (b *I3) MoveWindow(windowID, space int) error {
+	b.mu.Lock()
+	name, ok := b.namesByIndex[space]
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no %s workspace at index %d", b.wmName, space)
+	}
+	cmd := fmt.Sprintf("[con_id=%d] move container to workspace %s", windowID, strconv.Quote(name))
+	return b.runCommand(cmd)
+}
+
+// runCommand sends a RUN_COMMAND and fails on the first command the
+// window manager rejected.
+func (b *I3) runCommand(cmd string) error {
+	reply, err := b.request(i3RunCommand, []byte(cmd))
+	if err != nil {
+		return err
+	}
+	var results []struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(reply, &results) == nil {
+		for _, r := range results {
+			if !r.Success {
+				return fmt.Errorf("%s: %s", b.wmName, r.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// DestroySpace can't be expressed in i3: a workspace exists exactly as
+// long as it has windows (or focus), so there's nothing to destroy.
+func (b *I3) DestroySpace(index int) error {
+	return fmt.Errorf("%s removes workspaces on its own once they're empty", b.wmName)
+}
+
+// LabelSpace isn't offered on i3: a workspace's label is its name, which
+// also carries its number, and renaming it from under the user's
+// bindings ("workspace number 3") would be a surprise.
+func (b *I3) LabelSpace(index int, label string) error {
+	return fmt.Errorf("%s labels workspaces by name; rename the workspace instead", b.wmName)
+}
+
+// CreateSpace isn't offered on i3 either: workspaces come into being by
+// switching to them, and go again once they're empty.
+func (b *I3) CreateSpace(display int) (int, error) {
+	return 0, fmt.Errorf("%s creates workspaces when you switch to them", b.wmName)
+}
+
+// i3FlashBorder is the border width FlashWindows swaps in on i3.
+const i3FlashBorder = 8
+
+// FlashWindows dims windows on sway. i3 has no compositor-backed
+// opacity (and no urgent command), so there the windows get a thick
+// pixel border for the flash and then go back to the normal border.
+func (b *I3) FlashWindows(ids []int) error {
+	on, off := "opacity 0.4", "opacity 1"
+	if b.wmName == "i3" {
+		on, off = fmt.Sprintf("border pixel %d", i3FlashBorder), "border normal"
+	}
+	run := func(action string) error {
+		cmds := make([]string, 0, len(ids))
+		for _, id := range ids {
+			cmds = append(cmds, fmt.Sprintf("[con_id=%d] %s", id, action))
+		}
+		return b.runCommand(strings.Join(cmds, "; "))
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := run(on); err != nil {
+		return err
+	}
+	time.Sleep(flashDuration)
+	return run(off)
+}
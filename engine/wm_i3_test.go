@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeI3 serves canned replies keyed by IPC message type and records any
+// RUN_COMMAND payloads.
+func fakeI3(t *testing.T, replies map[uint32]string, commands chan<- string) string {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "ipc.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			header := make([]byte, len(i3IPCMagic)+8)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				conn.Close()
+				continue
+			}
+			size := binary.LittleEndian.Uint32(header[len(i3IPCMagic):])
+			msgType := binary.LittleEndian.Uint32(header[len(i3IPCMagic)+4:])
+			payload := make([]byte, size)
+			io.ReadFull(conn, payload)
+			if msgType == i3RunCommand && commands != nil {
+				commands <- string(payload)
+			}
+			reply := replies[msgType]
+			binary.LittleEndian.PutUint32(header[len(i3IPCMagic):], uint32(len(reply)))
+			conn.Write(append(header, reply...))
+			conn.Close()
+		}
+	}()
+	return sock
+}
+
+func TestI3BackendMapping(t *testing.T) {
+	commands := make(chan string, 1)
+	sock := fakeI3(t, map[uint32]string{
+		i3GetOutputs: `[{"name":"DP-2","active":true,"rect":{"x":1920,"y":0}},
+			{"name":"eDP-1","active":true,"rect":{"x":0,"y":0}},
+			{"name":"HDMI-1","active":false,"rect":{"x":0,"y":0}}]`,
+		i3GetWorkspaces: `[{"id":11,"num":1,"name":"1","visible":true,"focused":false,"output":"eDP-1"},
+			{"id":12,"num":2,"name":"2:web","visible":true,"focused":true,"output":"DP-2"},
+			{"id":13,"num":-1,"name":"notes","visible":false,"focused":false,"output":"eDP-1"}]`,
+		i3GetTree: `{"id":1,"type":"root","nodes":[
+			{"id":2,"type":"output","nodes":[
+				{"id":11,"type":"workspace","name":"1","nodes":[
+					{"id":100,"type":"con","name":"api","pid":500,"app_id":"foot","visible":true,"focused":true}]},
+				{"id":13,"type":"workspace","name":"notes","nodes":[],"floating_nodes":[
+					{"id":101,"type":"floating_con","name":"scratch","pid":501,"window_properties":{"class":"Obsidian"}}]}]},
+			{"id":3,"type":"output","nodes":[
+				{"id":14,"type":"workspace","name":"__i3_scratch","nodes":[
+					{"id":102,"type":"con","name":"hidden","pid":502,"app_id":"foot","scratchpad_state":"fresh","marks":["term"]}]}]}]}`,
+		i3RunCommand: `[{"success":true}]`,
+	}, commands)
+
+	b := NewI3("sway", sock)
+	spaces, err := b.QuerySpaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spaces) != 3 {
+		t.Fatalf("expected 3 spaces, got %d", len(spaces))
+	}
+	// eDP-1 is leftmost so it's display 1; its numbered workspace sorts
+	// before the named one, then DP-2's workspace follows
+	want := []struct {
+		label   string
+		display int
+	}{{"", 1}, {"notes", 1}, {"2:web", 2}}
+	for i, w := range want {
+		if spaces[i].Index != i+1 || spaces[i].Label != w.label || spaces[i].Display != w.display {
+			t.Errorf("space %d = %+v, want label %q display %d", i, spaces[i], w.label, w.display)
+		}
+	}
+	if !spaces[2].HasFocus || len(spaces[0].Windows) != 1 {
+		t.Errorf("focus/windows wrong: %+v", spaces)
+	}
+
+	windows, err := b.QueryWindows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 3 {
+		t.Fatalf("expected 3 windows, got %d", len(windows))
+	}
+	if windows[0].App != "foot" || windows[0].Space != 1 || windows[0].PID != 500 {
+		t.Errorf("tiled window wrong: %+v", windows[0])
+	}
+	if !windows[0].HasFocus || windows[1].HasFocus {
+		t.Errorf("focus not mapped: %+v", windows[:2])
+	}
+	if windows[1].App != "Obsidian" || windows[1].Space != 2 {
+		t.Errorf("floating window wrong: %+v", windows[1])
+	}
+	if !windows[2].IsHidden || windows[2].IsVisible || windows[2].Scratchpad != "term" {
+		t.Errorf("scratchpad window should be hidden and labeled: %+v", windows[2])
+	}
+	if windows[0].Scratchpad != "" {
+		t.Errorf("tiled window labeled: %+v", windows[0])
+	}
+
+	displays, err := b.QueryDisplays()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(displays) != 2 || displays[0].Name != "eDP-1" || displays[1].Frame.X != 1920 {
+		t.Errorf("displays wrong: %+v", displays)
+	}
+
+	if err := b.FocusSpace(2); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-commands; got != `workspace "notes"` {
+		t.Errorf("focus command = %q", got)
+	}
+
+	if err := b.FocusWindow(100); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-commands; got != `[con_id=100] focus` {
+		t.Errorf("focus window command = %q", got)
+	}
+
+	if err := b.RestoreWindow(101); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-commands; got != `[con_id=101] scratchpad show` {
+		t.Errorf("restore window command = %q", got)
+	}
+
+	if err := b.ToggleScratchpad("term.1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-commands; got != `[con_mark="^term\\.1$"] scratchpad show` {
+		t.Errorf("toggle scratchpad command = %q", got)
+	}
+}
+
+func TestI3FlashWindows(t *testing.T) {
+	commands := make(chan string, 2)
+	sock := fakeI3(t, map[uint32]string{
+		i3RunCommand: `[{"success":true},{"success":true}]`,
+	}, commands)
+	if err := NewI3("i3", sock).FlashWindows([]int{100, 101}); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-commands; got != "[con_id=100] border pixel 8; [con_id=101] border pixel 8" {
+		t.Errorf("flash on = %q", got)
+	}
+	if got := <-commands; got != "[con_id=100] border normal; [con_id=101] border normal" {
+		t.Errorf("flash off = %q", got)
+	}
+
+	rejected := fakeI3(t, map[uint32]string{
+		i3RunCommand: `[{"success":false,"error":"Expected one of these tokens"}]`,
+	}, nil)
+	err := NewI3("i3", rejected).FlashWindows([]int{100})
+	if err == nil || !strings.Contains(err.Error(), "Expected one of these tokens") {
+		t.Errorf("rejected command error = %v", err)
+	}
+}
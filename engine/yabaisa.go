@@ -0,0 +1,171 @@
+// yabaisa: focusing spaces when yabai's scripting addition isn't loaded.
+//
+// yabai switches spaces through a scripting addition injected into the
+// Dock, which SIP has to allow and which has to be loaded again after
+// every Dock restart. without it `space --focus` fails with a complaint
+// about the scripting-addition, and the dashboard's main action stops
+// working. FocusSpace notices that answer and, with a fallback set
+// (SetFocusFallback), switches the way a person would: it focuses the
+// space's display (which needs no scripting addition) and presses
+// Mission Control's "Switch to Desktop N" shortcut, ctrl+N, for the
+// space's place on that display — sent through System Events
+// ("osascript") or skhd ("skhd"). those shortcuts have to be turned on
+// in System Settings › Keyboard › Keyboard Shortcuts › Mission Control,
+// and only reach the first nine spaces of a display.
+//
+// yabai is still asked first every time, so loading the scripting
+// addition puts things right without a restart.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// focus fallbacks, for SetFocusFallback
+const (
+	FocusFallbackOsascript = "osascript"
+	FocusFallbackSkhd      = "skhd"
+)
+
+var (
+	saMissing     atomic.Bool
+	focusFallback atomic.Value // string
+)
+
+// SetFocusFallback picks how spaces are focused without the scripting
+// addition: FocusFallbackOsascript, FocusFallbackSkhd, or "" for not at
+// all.
+func SetFocusFallback(name string) error {
+	switch name {
+	case "", FocusFallbackOsascript, FocusFallbackSkhd:
+		focusFallback.Store(name)
+		return nil
+	}
+	return fmt.Errorf("unknown focus fallback %q (want %s, %s or empty)", name, FocusFallbackOsascript, FocusFallbackSkhd)
+}
+
+// ScriptingAdditionMissing reports whether yabai's last space focus
+// failed for want of the scripting addition.
+func ScriptingAdditionMissing() bool {
+	return saMissing.Load()
+}
+
+// FocusFallback is the configured fallback, "" for none.
+func FocusFallback() string {
+	name, _ := focusFallback.Load().(string)
+	return name
+}
+
+// isScriptingAdditionError reports whether yabai refused a command for
+// want of the scripting addition.
+func isScriptingAdditionError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "scripting-addition")
+}
+
+// desktopKeyCodes are the macOS key codes of the digits 1–9.
+var desktopKeyCodes = [...]int{18, 19, 20, 21, 23, 22, 26, 28, 25}
+
+// DesktopNumber is a space's display and its place on it, counted from
+// 1 the way Mission Control numbers desktops.
+func DesktopNumber(spaces []Space, index int) (display, n int, err error) {
+	var target *Space
+	for i := range spaces {
+		if spaces[i].Index == index {
+			target = &spaces[i]
+		}
+	}
+	if target == nil {
+		return 0, 0, fmt.Errorf("no space %d", index)
+	}
+	var onDisplay []int
+	for _, s := range spaces {
+		if s.Display == target.Display {
+			onDisplay = append(onDisplay, s.Index)
+		}
+	}
+	sort.Ints(onDisplay)
+	for i, idx := range onDisplay {
+		if idx == index {
+			n = i + 1
+		}
+	}
+	return target.Display, n, nil
+}
+
+// focusByShortcut focuses a space through its display and Mission
+// Control's desktop shortcut.
+func focusByShortcut(spaces []Space, index int) error {
+	display, n, err := DesktopNumber(spaces, index)
+	if err != nil {
+		return err
+	}
+	if n > len(desktopKeyCodes) {
+		return fmt.Errorf("space %d is desktop %d of its display; Mission Control shortcuts stop at %d", index, n, len(desktopKeyCodes))
+	}
+	for _, s := range spaces {
+		if s.HasFocus && s.Display != display {
+			if err := runYabai("display", "--focus", fmt.Sprintf("%d", display)); err != nil {
+				return fmt.Errorf("focusing display %d: %w", display, err)
+			}
+		}
+	}
+
+	var name string
+	var args []string
+	switch FocusFallback() {
+	case FocusFallbackSkhd:
+		name, args = "skhd", []string{"-k", fmt.Sprintf("ctrl - %d", n)}
+	default:
+		name, args = "osascript", []string{"-e", fmt.Sprintf(`tell application "System Events" to key code %d using control down`, desktopKeyCodes[n-1])}
+	}
+	if err := Guard(name, args...); err != nil {
+		return err
+	}
+	start := time.Now()
+	err = runShortcut(name, args)
+	LogCommand(start, name, args, err)
+	return err
+}
+
+// runShortcut sends the keystroke; a var so tests can watch it.
+var runShortcut = func(name string, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := exec.CommandContext(ctx, name, args...).Output(); err != nil {
+		return commandError(ctx, err)
+	}
+	return nil
+}
+
+// focusSpace is FocusSpace: focus (yabai), then the fallback when yabai
+// says the scripting addition is missing.
+func focusSpace(index int, focus func(index int) error, query func() ([]Space, error)) error {
+	err := focus(index)
+	if !isScriptingAdditionError(err) {
+		if err == nil {
+			saMissing.Store(false)
+		}
+		return err
+	}
+	saMissing.Store(true)
+	if FocusFallback() == "" {
+		return err
+	}
+	logger.Warn("no scripting addition, focusing by shortcut", "space", index, "via", FocusFallback())
+	spaces, qerr := query()
+	if qerr == nil {
+		qerr = focusByShortcut(spaces, index)
+	}
+	if qerr != nil {
+		return errors.Join(err, fmt.Errorf("%s fallback: %w", FocusFallback(), qerr))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFocusSpaceFallback(t *testing.T) {
+	defer SetFocusFallback(FocusFallback())
+	defer func(run func(string, []string) error) { runShortcut = run }(runShortcut)
+	defer saMissing.Store(false)
+	var sent []string
+	runShortcut = func(name string, args []string) error {
+		sent = append(sent, name+" "+strings.Join(args, " "))
+		return nil
+	}
+	spaces := []Space{{Index: 1, Display: 1, HasFocus: true}, {Index: 2, Display: 2}, {Index: 3, Display: 1}, {Index: 4, Display: 1}}
+	query := func() ([]Space, error) { return spaces, nil }
+	saErr := errors.New("cannot focus space due to an error with the scripting-addition.")
+	noSA := func(int) error { return saErr }
+
+	// no fallback: yabai's answer stands, and the dashboard knows why
+	SetFocusFallback("")
+	if err := focusSpace(4, noSA, query); err != saErr || !ScriptingAdditionMissing() {
+		t.Fatalf("err = %v, missing = %v", err, ScriptingAdditionMissing())
+	}
+
+	// space 4 is the third desktop of display 1
+	SetFocusFallback(FocusFallbackSkhd)
+	if err := focusSpace(4, noSA, query); err != nil {
+		t.Fatal(err)
+	}
+	SetFocusFallback(FocusFallbackOsascript)
+	if err := focusSpace(3, noSA, query); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"skhd -k ctrl - 3", `osascript -e tell application "System Events" to key code 19 using control down`}
+	if strings.Join(sent, "\n") != strings.Join(want, "\n") {
+		t.Errorf("sent %q, want %q", sent, want)
+	}
+
+	// other failures aren't the scripting addition's, and a working
+	// yabai clears the flag
+	other := errors.New("could not locate space")
+	if err := focusSpace(9, func(int) error { return other }, query); err != other || len(sent) != 2 {
+		t.Errorf("err = %v, sent %q", err, sent)
+	}
+	if err := focusSpace(1, func(int) error { return nil }, query); err != nil || ScriptingAdditionMissing() {
+		t.Errorf("err = %v, still missing = %v", err, ScriptingAdditionMissing())
+	}
+
+	if err := SetFocusFallback("applescript"); err == nil {
+		t.Error("an unknown fallback was accepted")
+	}
+}
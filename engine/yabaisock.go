@@ -0,0 +1,85 @@
+// yabai's message socket, spoken directly.
+//
+// `yabai -m ...` is itself only a client: it writes the arguments to the
+// running yabai's unix socket (/tmp/yabai_$USER.socket) and prints the
+// answer. stop sends three queries every refresh, so it talks to the
+// socket itself and skips three fork/execs. the message is a native-endian
+// int32 length, then each argument NUL-terminated, then a final NUL; the
+// answer is read to EOF and starts with yabaiFailure when the command
+// failed. with no socket to dial, callers fall back to exec'ing yabai.
+
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// yabaiFailure leads an answer to a command yabai rejected.
+const yabaiFailure = 0x07
+
+// errNoYabaiSocket means the socket couldn't be dialed, so exec instead.
+var errNoYabaiSocket = errors.New("yabai socket unavailable")
+
+// yabaiSocketPath is where yabai listens for the current user.
+var yabaiSocketPath = "/tmp/yabai_" + currentUser() + ".socket"
+
+func currentUser() string {
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// encodeYabaiMessage frames args the way the yabai CLI does.
+func encodeYabaiMessage(args []string) []byte {
+	var payload bytes.Buffer
+	for _, a := range args {
+		payload.WriteString(a)
+		payload.WriteByte(0)
+	}
+	payload.WriteByte(0)
+	msg := binary.NativeEndian.AppendUint32(nil, uint32(payload.Len()))
+	return append(msg, payload.Bytes()...)
+}
+
+// yabaiMessage sends one message and returns the answer. a rejected
+// command's error is yabai's own message.
+func yabaiMessage(timeout time.Duration, args ...string) ([]byte, error) {
+	conn, err := net.DialTimeout("unix", yabaiSocketPath, timeout)
+	if err != nil {
+		return nil, errNoYabaiSocket
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(encodeYabaiMessage(args)); err != nil {
+		return nil, err
+	}
+	if uc, ok := conn.(*net.UnixConn); ok {
+		_ = uc.CloseWrite()
+	}
+	out, err := io.ReadAll(conn)
+	if err != nil {
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() {
+			return nil, ErrTimeout
+		}
+		return nil, err
+	}
+	if len(out) > 0 && out[0] == yabaiFailure {
+		return nil, errors.New(strings.TrimSpace(string(out[1:])))
+	}
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeYabai answers each message on a socket with reply(args).
+func fakeYabai(t *testing.T, reply func(args []string) []byte) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "yb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "s")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := yabaiSocketPath
+	yabaiSocketPath = path
+	t.Cleanup(func() {
+		yabaiSocketPath = old
+		ln.Close()
+		os.RemoveAll(dir)
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var n uint32
+			if err := binary.Read(conn, binary.NativeEndian, &n); err != nil {
+				conn.Close()
+				continue
+			}
+			payload := make([]byte, n)
+			if _, err := io.ReadFull(conn, payload); err != nil {
+				conn.Close()
+				continue
+			}
+			args := strings.Split(string(bytes.TrimSuffix(payload, []byte{0, 0})), "\x00")
+			conn.Write(reply(args))
+			conn.Close()
+		}
+	}()
+}
+
+func TestEncodeYabaiMessage(t *testing.T) {
+	msg := encodeYabaiMessage([]string{"query", "--spaces"})
+	want := "query\x00--spaces\x00\x00"
+	if binary.NativeEndian.Uint32(msg) != uint32(len(want)) || string(msg[4:]) != want {
+		t.Errorf("message = %q", msg)
+	}
+}
+
+func TestQueryYabaiSocket(t *testing.T) {
+	var got [][]string
+	fakeYabai(t, func(args []string) []byte {
+		got = append(got, args)
+		if args[0] == "space" {
+			return []byte("\x07could not locate space with mission-control index '99'.\n")
+		}
+		return []byte(`[{"id":1,"index":1,"label":"code","display":1}]`)
+	})
+
+	spaces, err := Yabai{}.QuerySpaces()
+	if err != nil || len(spaces) != 1 || spaces[0].Label != "code" {
+		t.Fatalf("spaces = %+v, %v", spaces, err)
+	}
+	err = Yabai{}.FocusSpace(99)
+	if err == nil || !strings.Contains(err.Error(), "could not locate space") {
+		t.Errorf("focus error = %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	want := [][]string{{"query", "--spaces"}, {"space", "--focus", "99"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %q", got)
+	}
+}
+
+func TestYabaiSocketMissing(t *testing.T) {
+	old := yabaiSocketPath
+	yabaiSocketPath = filepath.Join(t.TempDir(), "none.socket")
+	defer func() { yabaiSocketPath = old }()
+	if _, err := yabaiMessage(time.Second, "query", "--spaces"); !errors.Is(err, errNoYabaiSocket) {
+		t.Errorf("err = %v, want errNoYabaiSocket", err)
+	}
+}
@@ -0,0 +1,197 @@
+// env: badges for the environment a pane is pointed at.
+//
+// "which cluster is this shell talking to" is a classic way to run the
+// right command in the wrong place. for the keys listed in paneEnvBadges
+// (config.go) stop reads each pane's environment and renders a small
+// badge per key next to the pane, e.g. aws:prod kube:staging.
+//
+// a pane's environment is layered: the tmux session environment, then
+// the pane's shell, then its descendants — a variable exported after the
+// shell started only shows up in processes launched since, so the deepest
+// process that has a key wins. process environments come from
+// /proc/<pid>/environ where there is one and `ps eww` otherwise (macOS).
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envBadge maps an environment key to the short label its badge shows.
+type envBadge struct {
+	key   string
+	label string
+}
+
+// envKeys lists the configured keys.
+func envKeys() []string {
+	keys := make([]string, len(paneEnvBadges))
+	for i, b := range paneEnvBadges {
+		keys[i] = b.key
+	}
+	return keys
+}
+
+// pickEnv keeps only the wanted keys from KEY=VALUE entries.
+func pickEnv(entries []string, keys []string) map[string]string {
+	var env map[string]string
+	for _, e := range entries {
+		k, v, ok := strings.Cut(e, "=")
+		if !ok {
+			continue
+		}
+		for _, want := range keys {
+			if k == want {
+				if env == nil {
+					env = map[string]string{}
+				}
+				env[k] = v
+			}
+		}
+	}
+	return env
+}
+
+// parsePSEnv reads `ps eww -o pid=,command=` output, where each process's
+// environment trails its command line as space-separated KEY=VALUE words.
+// values containing spaces come out truncated; the keys worth a badge
+// rarely have them.
+func parsePSEnv(out string, keys []string) map[int]map[string]string {
+	result := map[int]map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		if env := pickEnv(fields[1:], keys); env != nil {
+			result[pid] = env
+		}
+	}
+	return result
+}
+
+// readProcessEnv returns the wanted keys for each pid that has any.
+func readProcessEnv(pids []int, keys []string) map[int]map[string]string {
+	if _, err := os.Stat("/proc/self/environ"); err == nil {
+		result := map[int]map[string]string{}
+		for _, pid := range pids {
+			data, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+			if err != nil {
+				continue
+			}
+			if env := pickEnv(strings.Split(string(bytes.TrimRight(data, "\x00")), "\x00"), keys); env != nil {
+				result[pid] = env
+			}
+		}
+		return result
+	}
+
+	list := make([]string, len(pids))
+	for i, pid := range pids {
+		list[i] = strconv.Itoa(pid)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ps", "eww", "-o", "pid=,command=", "-p", strings.Join(list, ",")).Output()
+	if err != nil && len(out) == 0 {
+		return nil // ps exits non-zero when some pids are gone; keep what it printed
+	}
+	return parsePSEnv(string(out), keys)
+}
+
+// readSessionEnv reads a tmux session's environment. "-KEY" lines mark
+// variables removed from it and are skipped.
+func readSessionEnv(session string, keys []string) map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "tmux", "show-environment", "-t", "="+session).Output()
+	if err != nil {
+		return nil
+	}
+	return pickEnv(strings.Split(string(out), "\n"), keys)
+}
+
+// paneProcesses lists a pane's pid and its descendants, shallowest
+// first (breadth-first over children, a ppid → pids index).
+func paneProcesses(panePID int, children map[int][]int) []int {
+	pids := []int{panePID}
+	for i := 0; i < len(pids) && len(pids) < 256; i++ {
+		pids = append(pids, children[pids[i]]...)
+	}
+	return pids
+}
+
+// queryPaneEnv resolves the badge keys for every pane, keyed by pane pid.
+// nil when no keys are configured.
+func queryPaneEnv(panes []TmuxPane, processTree map[int]int) map[int]map[string]string {
+	keys := envKeys()
+	if len(keys) == 0 || len(panes) == 0 {
+		return nil
+	}
+
+	children := map[int][]int{}
+	for pid, ppid := range processTree {
+		children[ppid] = append(children[ppid], pid)
+	}
+	for _, c := range children {
+		sort.Ints(c)
+	}
+
+	sessionEnv := map[string]map[string]string{}
+	chains := map[int][]int{}
+	var pids []int
+	for _, p := range panes {
+		if _, ok := sessionEnv[p.SessionName]; !ok {
+			sessionEnv[p.SessionName] = readSessionEnv(p.SessionName, keys)
+		}
+		chains[p.PanePID] = paneProcesses(p.PanePID, children)
+		pids = append(pids, chains[p.PanePID]...)
+	}
+	procEnv := readProcessEnv(pids, keys)
+
+	result := map[int]map[string]string{}
+	for _, p := range panes {
+		env := map[string]string{}
+		for k, v := range sessionEnv[p.SessionName] {
+			env[k] = v
+		}
+		for _, pid := range chains[p.PanePID] {
+			for k, v := range procEnv[pid] {
+				env[k] = v
+			}
+		}
+		if len(env) > 0 {
+			result[p.PanePID] = env
+		}
+	}
+	return result
+}
+
+// renderEnvBadges renders a pane's badges in configured order; "" when
+// it has none. path values (KUBECONFIG) show only their file name.
+func renderEnvBadges(env map[string]string) string {
+	var parts []string
+	for _, b := range paneEnvBadges {
+		v, ok := env[b.key]
+		if !ok || v == "" {
+			continue
+		}
+		if strings.Contains(v, "/") {
+			v = filepathBase(strings.Split(v, ":")[0])
+		}
+		parts = append(parts, envBadgeStyle.Render(b.label+":"+v))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePSEnv(t *testing.T) {
+	out := "  412 -zsh TERM=xterm AWS_PROFILE=dev HOME=/Users/me\n" +
+		"  980 kubectl get pods KUBECONFIG=/Users/me/.kube/prod.yaml AWS_PROFILE=prod\n" +
+		"  981 sleep 10 PATH=/bin\n"
+	env := parsePSEnv(out, []string{"AWS_PROFILE", "KUBECONFIG"})
+
+	if env[412]["AWS_PROFILE"] != "dev" {
+		t.Errorf("412 AWS_PROFILE = %q, want dev", env[412]["AWS_PROFILE"])
+	}
+	if env[980]["KUBECONFIG"] != "/Users/me/.kube/prod.yaml" || env[980]["AWS_PROFILE"] != "prod" {
+		t.Errorf("980 env = %v", env[980])
+	}
+	if _, ok := env[981]; ok {
+		t.Errorf("981 has none of the keys but got %v", env[981])
+	}
+}
+
+func TestPaneProcessesAndBadges(t *testing.T) {
+	children := map[int][]int{100: {200, 201}, 200: {300}}
+	got := paneProcesses(100, children)
+	want := []int{100, 200, 201, 300}
+	if len(got) != len(want) {
+		t.Fatalf("paneProcesses = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("paneProcesses = %v, want %v (shallowest first)", got, want)
+		}
+	}
+
+	badges := renderEnvBadges(map[string]string{"KUBECONFIG": "/home/me/.kube/staging.yaml", "AWS_PROFILE": "prod"})
+	if !strings.Contains(badges, "aws:prod") || !strings.Contains(badges, "kube:staging.yaml") {
+		t.Errorf("badges = %q", badges)
+	}
+	if strings.Index(badges, "aws:") > strings.Index(badges, "kube:") {
+		t.Errorf("badges out of configured order: %q", badges)
+	}
+}
@@ -0,0 +1,116 @@
+// eventhooks: run commands when something happens.
+//
+// the dashboard already notices the moments worth acting on — a space
+// emptying, an agent finishing or going stale (the events of watch.go).
+// eventHooks (config.go) maps an event name to shell commands, run with
+// sh -c when that event fires ("*" runs on every event):
+//
+//	var eventHooks = map[string][]string{
+//		"agent_finished": {`say "$STOP_COMMAND in $STOP_SESSION is done"`},
+//		"space_freed":    {"yabai -m space $STOP_SPACE --label ''"},
+//	}
+//
+// each command gets the event in STOP_* environment variables, and the
+// whole event as JSON in STOP_EVENT_JSON. the TUI runs them; `stop watch
+// -hooks` runs them headless instead. like every other side effect they
+// are skipped in read-only mode. a command that fails or outlives
+// eventHookTimeout shows on the status line.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+const eventHookTimeout = 10 * time.Second
+
+// eventHookCommands lists the commands for one event, its own before
+// the "*" ones.
+func eventHookCommands(event string) []string {
+	var cmds []string
+	cmds = append(cmds, eventHooks[event]...)
+	return append(cmds, eventHooks["*"]...)
+}
+
+// eventEnv is the environment describing an event to a hook.
+func eventEnv(ev watchEvent) []string {
+	space := ""
+	if ev.Space != 0 {
+		space = strconv.Itoa(ev.Space)
+	}
+	data, _ := json.Marshal(ev)
+	return []string{
+		"STOP_EVENT=" + ev.Event,
+		"STOP_AT=" + strconv.FormatInt(ev.At, 10),
+		"STOP_SPACE=" + space,
+		"STOP_SESSION=" + ev.Session,
+		"STOP_AGENT=" + ev.Agent,
+		"STOP_COMMAND=" + ev.Command,
+		"STOP_STATUS=" + ev.Status,
+		"STOP_PREVIOUS=" + ev.Previous,
+		"STOP_EVENT_JSON=" + string(data),
+	}
+}
+
+// runEventHook runs one hook command for an event.
+func runEventHook(command string, ev watchEvent) error {
+	if err := engine.Guard("sh", "-c", command); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), eventHookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), eventEnv(ev)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	start := time.Now()
+	err := cmd.Run()
+	engine.LogCommand(start, "sh", []string{"-c", command}, err)
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s hook: %w: %s", ev.Event, err, msg)
+		}
+		return fmt.Errorf("%s hook: %w", ev.Event, err)
+	}
+	return nil
+}
+
+// runEventHooks runs every event's hooks in order. read-only skips aren't
+// failures.
+func runEventHooks(events []watchEvent) []error {
+	var failed []error
+	for _, ev := range events {
+		for _, command := range eventHookCommands(ev.Event) {
+			if err := runEventHook(command, ev); err != nil && !errors.Is(err, engine.ErrReadOnly) {
+				failed = append(failed, err)
+			}
+		}
+	}
+	return failed
+}
+
+// eventHooksCmd runs a refresh's hooks off the UI loop. only a failure
+// comes back as a message.
+func eventHooksCmd(events []watchEvent) tea.Cmd {
+	if len(eventHooks) == 0 || len(events) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		if errs := runEventHooks(events); len(errs) > 0 {
+			return actionDoneMsg{status: "event hook failed", err: errors.Join(errs...)}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunEventHooks(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	defer func(hooks map[string][]string) { eventHooks = hooks }(eventHooks)
+	eventHooks = map[string][]string{
+		watchAgentFinished: {`echo "$STOP_EVENT $STOP_SESSION $STOP_COMMAND $STOP_PREVIOUS>$STOP_STATUS" >> ` + out},
+		watchSpaceFreed:    {`echo "freed $STOP_SPACE" >> ` + out, `echo boom >&2; exit 3`},
+		"*":                {`echo "any $STOP_EVENT" >> ` + out},
+	}
+
+	errs := runEventHooks([]watchEvent{
+		{Type: "event", Event: watchAgentFinished, Session: "api", Command: "claude", Previous: agentWorking, Status: agentIdle},
+		{Type: "event", Event: watchSpaceFreed, Space: 3},
+	})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "space_freed hook") || !strings.Contains(errs[0].Error(), "boom") {
+		t.Errorf("errors = %v", errs)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "agent_finished api claude working>idle\nany agent_finished\nfreed 3\nany space_freed\n"
+	if string(data) != want {
+		t.Errorf("hook output = %q, want %q", data, want)
+	}
+}
+
+func TestEventEnv(t *testing.T) {
+	env := eventEnv(watchEvent{Type: "event", Event: watchFocusChanged, Space: 2, Previous: "1"})
+	joined := strings.Join(env, "\n")
+	for _, want := range []string{"STOP_EVENT=focus_changed", "STOP_SPACE=2", "STOP_PREVIOUS=1", `STOP_EVENT_JSON={"type":"event","event":"focus_changed"`} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("env missing %q:\n%s", want, joined)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// events: the stream of changes between refreshes, for the TUI and serve.
+//
+// diffWatchState (watch.go) turns two consecutive fetches into typed
+// events. the TUI diffs every refresh: the events run eventHooks and
+// flash the spaces they touched — a space that appeared, or whose window
+// set changed as a window opened, closed or moved in or out, shows its
+// number highlighted for flashDuration, so a change elsewhere on the
+// desktop catches the eye. serve
+// streams the same events as server-sent events on /events, polling only
+// while someone is listening:
+//
+//	curl -N localhost:8080/events
+//	event: window_moved
+//	data: {"type":"event","event":"window_moved","window":4211,...}
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// flashDuration is how long a space stays highlighted after an event.
+const flashDuration = 3 * time.Second
+
+// eventBacklog is how many events a slow /events client may fall behind
+// before newer ones are dropped for it.
+const eventBacklog = 64
+
+var flashStyle = lipgloss.NewStyle().Reverse(true)
+
+// flashSpaces marks the spaces events touched, by absolute index, until
+// now+flashDuration.
+func flashSpaces(flashes map[int]time.Time, events []watchEvent, now time.Time) map[int]time.Time {
+	next := map[int]time.Time{}
+	for idx, until := range flashes {
+		if now.Before(until) {
+			next[idx] = until
+		}
+	}
+	for _, ev := range events {
+		switch ev.Event {
+		case watchSpaceCreated, watchWindowOpened, watchWindowClosed, watchSpaceFreed, watchSpaceMigrated:
+			next[ev.Space] = now.Add(flashDuration)
+		case watchWindowMoved:
+			next[ev.Space] = now.Add(flashDuration)
+			if from, err := strconv.Atoi(ev.Previous); err == nil {
+				next[from] = now.Add(flashDuration)
+			}
+		}
+	}
+	return next
+}
+
+// flashing reports whether a space is highlighted at now.
+func flashing(flashes map[int]time.Time, space Space, now time.Time) bool {
+	until, ok := flashes[space.Index]
+	return ok && now.Before(until)
+}
+
+// trackEvents diffs a refresh against the previous one, updates the
+// flashes and returns the hooks to run. the first refresh only sets the
+// baseline.
+func (m *model) trackEvents(result fetchResult, now time.Time) tea.Cmd {
+	cur := newWatchState(result, now)
+	prev := m.lastWatch
+	m.lastWatch = &cur
+	if prev == nil {
+		return nil
+	}
+	events := diffWatchState(*prev, cur, now)
+	m.flashes = flashSpaces(m.flashes, events, now)
+	m.migrations = flagMigrations(m.migrations, events, now)
+	return eventHooksCmd(events)
+}
+
+// eventHub fans events out to /events subscribers.
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan watchEvent]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: map[chan watchEvent]struct{}{}}
+}
+
+// subscribe returns a channel of events and the func that closes it.
+func (h *eventHub) subscribe() (<-chan watchEvent, func()) {
+	ch := make(chan watchEvent, eventBacklog)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+// listening reports whether anyone is subscribed.
+func (h *eventHub) listening() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs) > 0
+}
+
+// publish sends events to every subscriber without waiting on any.
+func (h *eventHub) publish(events []watchEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		for _, ev := range events {
+			select {
+			case ch <- ev:
+			default: // full: this client is behind, drop rather than block
+			}
+		}
+	}
+}
+
+// run refreshes every interval while there are subscribers and publishes
+// what changed. with nobody listening the baseline is dropped, so a new
+// client never gets a diff against long-gone state.
+func (h *eventHub) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var prev *watchState
+	for {
+		if !h.listening() {
+			prev = nil
+		} else if result := fetchAll(); result.err == nil {
+			now := time.Now()
+			cur := newWatchState(result, now)
+			if prev != nil {
+				h.publish(diffWatchState(*prev, cur, now))
+			}
+			prev = &cur
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// handleEvents streams events as server-sent events until the client
+// goes away.
+func handleEvents(hub *eventHub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		events, cancel := hub.subscribe()
+		defer cancel()
+		flusher.Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case ev := <-events:
+				data, err := json.Marshal(ev)
+				if err != nil {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Event, data); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
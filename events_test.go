@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlashSpaces(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	old := map[int]time.Time{1: now.Add(-time.Second), 2: now.Add(time.Second)}
+	flashes := flashSpaces(old, []watchEvent{
+		{Event: watchSpaceCreated, Space: 5},
+		{Event: watchWindowMoved, Space: 3, Previous: "6"},
+		{Event: watchWindowClosed, Space: 7},
+		{Event: watchFocusChanged, Space: 4},
+	}, now)
+	if _, ok := flashes[1]; ok {
+		t.Error("expired flash kept")
+	}
+	for _, idx := range []int{2, 3, 5, 6, 7} {
+		if !flashing(flashes, Space{Index: idx}, now) {
+			t.Errorf("space %d not flashing", idx)
+		}
+	}
+	if flashing(flashes, Space{Index: 4}, now) {
+		t.Error("focus change flashed")
+	}
+	if flashing(flashes, Space{Index: 5}, now.Add(flashDuration)) {
+		t.Error("flash outlived flashDuration")
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	hub := newEventHub()
+	srv := httptest.NewServer(handleEvents(hub))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type = %q", ct)
+	}
+	for !hub.listening() {
+		time.Sleep(time.Millisecond)
+	}
+	hub.publish([]watchEvent{{Type: "event", Event: watchWindowMoved, Window: 7, Space: 2, Previous: "1"}})
+
+	r := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if lines[0] != "event: window_moved" || !strings.HasPrefix(lines[1], `data: {"type":"event","event":"window_moved"`) {
+		t.Errorf("stream = %q", lines)
+	}
+}
@@ -0,0 +1,201 @@
+// filter: predicate filters for the agents view, plus saved named views.
+//
+// a filter is a flat list of field=value predicates joined by one
+// operator, AND or OR. fields are the agent attributes worth slicing a
+// fleet by: the command running (claude, codex, ...), the project (cwd
+// basename), the staleness tier, and the display the agent's terminal
+// sits on. flat keeps the builder UI simple while still covering the
+// useful cases ("claude on the left monitor", "cold or stale"). named
+// views persist in the snapshot db so they survive restarts.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const agentViewSchema = `
+CREATE TABLE IF NOT EXISTS agent_views (
+	name TEXT PRIMARY KEY,
+	filter TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+`
+
+// filterable agent fields, in the order the builder cycles through them
+var filterFields = []string{"command", "project", "tier", "display"}
+
+// agentPredicate matches one field against one value, case-insensitively.
+type agentPredicate struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// agentFilter joins predicates with Op ("and" / "or"). no predicates
+// matches everything.
+type agentFilter struct {
+	Op    string           `json:"op"`
+	Preds []agentPredicate `json:"preds"`
+}
+
+// agentRow is one live agent as the agents view lists it.
+type agentRow struct {
+	pane    TmuxPane
+	project string
+	tier    string
+	display string
+}
+
+// field returns a row's value for a filter field.
+func (r agentRow) field(name string) string {
+	switch name {
+	case "command":
+		return r.pane.CurrentCommand
+	case "project":
+		return r.project
+	case "tier":
+		return r.tier
+	case "display":
+		return r.display
+	}
+	return ""
+}
+
+// matches reports whether a row passes the filter.
+func (f agentFilter) matches(r agentRow) bool {
+	if len(f.Preds) == 0 {
+		return true
+	}
+	for _, p := range f.Preds {
+		hit := strings.EqualFold(r.field(p.Field), p.Value)
+		if f.Op == "or" && hit {
+			return true
+		}
+		if f.Op != "or" && !hit {
+			return false
+		}
+	}
+	return f.Op != "or"
+}
+
+// String renders the filter as "command=claude and tier=cold".
+func (f agentFilter) String() string {
+	if len(f.Preds) == 0 {
+		return "all agents"
+	}
+	parts := make([]string, len(f.Preds))
+	for i, p := range f.Preds {
+		parts[i] = p.Field + "=" + p.Value
+	}
+	op := f.Op
+	if op == "" {
+		op = "and"
+	}
+	return strings.Join(parts, " "+op+" ")
+}
+
+// fieldValues lists the distinct values a field takes across rows, so the
+// builder can offer real choices instead of free text.
+func fieldValues(rows []agentRow, field string) []string {
+	if field == "tier" {
+		names := make([]string, len(stalenessTiers))
+		for i, t := range stalenessTiers {
+			names[i] = t.name
+		}
+		return names
+	}
+	seen := map[string]bool{}
+	var values []string
+	for _, r := range rows {
+		if v := r.field(field); v != "" && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// buildAgentRows lists productive panes as agents, annotated with the
+// display their session's terminal is on.
+func buildAgentRows(panes []TmuxPane, productivePanePIDs map[int]bool, byDisplay map[int][]TmuxPane, groups []displayGroup) []agentRow {
+	displayOf := map[string]string{}
+	for _, g := range groups {
+		label := g.display.ShortName()
+		if label == "" {
+			label = fmt.Sprintf("display %d", g.index)
+		}
+		for _, p := range byDisplay[g.index] {
+			displayOf[p.SessionName] = label
+		}
+	}
+
+	var rows []agentRow
+	for _, p := range panes {
+		if !productivePanePIDs[p.PanePID] {
+			continue
+		}
+		display := displayOf[p.SessionName]
+		if display == "" {
+			display = "detached"
+		}
+		rows = append(rows, agentRow{
+			pane:    p,
+			project: filepathBase(p.CurrentPath),
+			tier:    stalenessTiers[stalenessTier(p.LastActivity)].name,
+			display: display,
+		})
+	}
+	return rows
+}
+
+// savedView is a named filter.
+type savedView struct {
+	Name   string
+	Filter agentFilter
+}
+
+// saveAgentView upserts a named view.
+func saveAgentView(db *sql.DB, name string, f agentFilter) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("view name is required")
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		"INSERT INTO agent_views (name, filter, updated_at) VALUES (?, ?, ?) ON CONFLICT (name) DO UPDATE SET filter = excluded.filter, updated_at = excluded.updated_at",
+		name, string(data), time.Now().UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// loadAgentViews returns saved views sorted by name.
+func loadAgentViews(db *sql.DB) ([]savedView, error) {
+	rows, err := db.Query("SELECT name, filter FROM agent_views ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("querying agent views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []savedView
+	for rows.Next() {
+		var v savedView
+		var data string
+		if err := rows.Scan(&v.Name, &data); err != nil {
+			continue
+		}
+		if json.Unmarshal([]byte(data), &v.Filter) != nil {
+			continue
+		}
+		views = append(views, v)
+	}
+	return views, rows.Err()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestAgentFilterMatches(t *testing.T) {
+	claudeCold := agentRow{pane: TmuxPane{CurrentCommand: "claude"}, project: "api", tier: "cold", display: "left"}
+	codexActive := agentRow{pane: TmuxPane{CurrentCommand: "codex"}, project: "web", tier: "active", display: "right"}
+
+	and := agentFilter{Op: "and", Preds: []agentPredicate{{"command", "Claude"}, {"tier", "cold"}}}
+	or := agentFilter{Op: "or", Preds: []agentPredicate{{"project", "web"}, {"tier", "cold"}}}
+
+	cases := []struct {
+		name string
+		f    agentFilter
+		row  agentRow
+		want bool
+	}{
+		{"empty matches all", agentFilter{}, codexActive, true},
+		{"and all hit", and, claudeCold, true},
+		{"and one miss", and, codexActive, false},
+		{"or first hit", or, codexActive, true},
+		{"or second hit", or, claudeCold, true},
+		{"or none hit", agentFilter{Op: "or", Preds: []agentPredicate{{"display", "center"}}}, claudeCold, false},
+	}
+	for _, c := range cases {
+		if got := c.f.matches(c.row); got != c.want {
+			t.Errorf("%s: matches = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	if got, want := and.String(), "command=Claude and tier=cold"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestAgentViewsRoundTrip(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(agentViewSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	f := agentFilter{Op: "or", Preds: []agentPredicate{{"tier", "stale"}, {"tier", "cold"}}}
+	if err := saveAgentView(db, "idle", f); err != nil {
+		t.Fatal(err)
+	}
+	f.Preds = f.Preds[:1]
+	if err := saveAgentView(db, "idle", f); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveAgentView(db, "  ", f); err == nil {
+		t.Error("saved a view with a blank name")
+	}
+
+	views, err := loadAgentViews(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 1 || views[0].Name != "idle" {
+		t.Fatalf("views = %+v, want one named idle", views)
+	}
+	if got := views[0].Filter.String(); got != "tier=stale" {
+		t.Errorf("overwritten filter = %q, want tier=stale", got)
+	}
+}
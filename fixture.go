@@ -0,0 +1,101 @@
+// fixture: record this machine's state, and play recordings back.
+//
+// `stop record -out session.json` fetches the window manager, tmux and
+// ps every -interval until interrupted (or -frames are taken) and writes
+// what came back as an engine.Recording. `stop --replay session.json`
+// runs the dashboard from it: the window manager, tmux and ps answer
+// from the frame recorded that long into the session, and read-only is
+// forced so nothing acts on this machine — a mapping bug that needs
+// someone else's yabai and tmux topology can be reproduced from the file
+// alone. a one-frame recording, or a single engine.Fake fixture, is what
+// tests load from testdata/. stop's own sources (nvim, agent screens,
+// the state db) aren't recorded and still read this machine, though
+// focus time isn't tracked during a replay. window titles and paths are
+// kept verbatim; look the file over before sharing it.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// replaying is the recording --replay plays back and replayPath its
+// file, for the status line.
+var (
+	replaying  *engine.Replay
+	replayPath string
+)
+
+// recordCommand is the entry point for `stop record`.
+func recordCommand(out string, interval time.Duration, frames int) error {
+	if out == "" {
+		return fmt.Errorf("usage: stop record -out <file> [-interval 2s] [-frames n]")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	wm := currentWM()
+	rec := engine.Recording{Started: time.Now(), Interval: interval}
+	fmt.Fprintln(os.Stderr, "recording; ctrl-c to stop")
+record:
+	for {
+		at := time.Since(rec.Started)
+		frame := engine.RecordFake(wm.Name(), engine.Fetch(wm))
+		frame.At = at
+		rec.Frames = append(rec.Frames, frame)
+		fmt.Fprintf(os.Stderr, "\r%d frames", len(rec.Frames))
+		if frames > 0 && len(rec.Frames) >= frames {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break record
+		case <-time.After(interval):
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding recording: %w", err)
+	}
+	if err := os.WriteFile(out, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("recorded %d frames over %s to %s\n", len(rec.Frames), time.Since(rec.Started).Round(time.Second), out)
+	return nil
+}
+
+// startReplay loads a recording for --replay; call before currentWM.
+func startReplay(path string) error {
+	rec, err := engine.LoadRecording(path)
+	if err != nil {
+		return err
+	}
+	replaying = engine.NewReplay(rec, time.Now())
+	simulated, replayPath = replaying, path
+	engine.SetReadOnly(true)
+	return nil
+}
+
+// renderSimulatedLine says the desk isn't this machine's: which
+// recording is playing and where, or that it's the demo. empty when live.
+func renderSimulatedLine() string {
+	if _, ok := simulated.(*demoDesk); ok {
+		return warnStyle.Render("demo") + dimStyle.Render(" — a made-up desk; focus and moves act on it, nothing else runs")
+	}
+	if replaying == nil {
+		return ""
+	}
+	frame, frames := replaying.Position()
+	return warnStyle.Render("replaying") + dimStyle.Render(fmt.Sprintf(" %s, frame %d of %d — actions are off", filepath.Base(replayPath), frame+1, frames))
+}
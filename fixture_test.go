@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// fixtureResult fetches a testdata fixture the way fetchAll fetches the
+// machine, without stop's own sources.
+func fixtureResult(t *testing.T, name string) fetchResult {
+	t.Helper()
+	f, err := engine.LoadFake("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return coreResult(engine.FetchFrom(f, f))
+}
+
+func TestFixtureDisplayGroups(t *testing.T) {
+	result := fixtureResult(t, "desk.json")
+	groups := buildDisplayGroups(result.spaces, result.windows, result.displays)
+	if len(groups) != 2 {
+		t.Fatalf("%d groups, want 2", len(groups))
+	}
+	builtIn, lg := groups[0], groups[1]
+	if builtIn.index != 1 || len(builtIn.spaces) != 2 || builtIn.freeCount != 1 || builtIn.termCount != 1 {
+		t.Errorf("built-in = %+v", builtIn)
+	}
+	if len(builtIn.hidden) != 1 || builtIn.hidden[0].App != "Notes" {
+		t.Errorf("built-in hidden = %+v", builtIn.hidden)
+	}
+	if lg.index != 2 || len(lg.spaces) != 3 || len(lg.spaces[0].windows) != 2 || lg.freeCount != 1 {
+		t.Errorf("lg = %+v", lg)
+	}
+}
+
+func TestFixtureTmuxPlacement(t *testing.T) {
+	result := fixtureResult(t, "desk.json")
+	groups := buildDisplayGroups(result.spaces, result.windows, result.displays)
+	byDisplay, detached := partitionTmuxByDisplay(result.tmuxPanes, result.tmuxClients, result.processTree, result.windows, groups)
+
+	// kitty is one process for both windows; the clients' ttys tell them apart
+	if len(byDisplay[1]) != 2 || byDisplay[1][0].SessionName != "api" {
+		t.Errorf("built-in panes = %+v", byDisplay[1])
+	}
+	if len(byDisplay[2]) != 1 || byDisplay[2][0].SessionName != "web" {
+		t.Errorf("lg panes = %+v", byDisplay[2])
+	}
+	if len(detached) != 1 || detached[0].SessionName != "notes" {
+		t.Errorf("detached = %+v", detached)
+	}
+}
+
+func TestFixtureView(t *testing.T) {
+	next, _ := newModel().handleData(fixtureResult(t, "desk.json"))
+	m := next.(model)
+	m.width, m.height = 160, 50
+	view := m.View()
+	for _, want := range []string{"code", "web", "chat", "api", "notes", "detached"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view is missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestReplayLine(t *testing.T) {
+	if err := startReplay("testdata/desk.json"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		replaying, simulated, replayPath = nil, nil, ""
+		engine.SetReadOnly(false)
+	}()
+	if !engine.ReadOnly() {
+		t.Error("replay left actions on")
+	}
+	if line := renderSimulatedLine(); !strings.Contains(line, "desk.json, frame 1 of 1") {
+		t.Errorf("replay line = %q", line)
+	}
+}
@@ -0,0 +1,82 @@
+// focuscmd: switch spaces with a command of your own.
+//
+// some desks don't switch spaces through yabai at all: without the
+// scripting addition, skhd synthesizing the key a person would press is
+// what works. with focusCommand set (config.go), enter, the jump keys,
+// and `stop new` run it instead of asking the window manager, e.g.
+// {"skhd", "-k", "alt - {desktop}"}. the placeholders are filled from the
+// space being focused:
+//
+//	{index}    its index across every display
+//	{desktop}  its place on its display, as Mission Control counts desktops
+//	{display}  its display
+//	{label}    its label, or the index when it has none
+//
+// the command's window manager still answers everything else; focusMode
+// "restore" works the same either way.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// focusCommandArgs fills the placeholders of focusCommand for a space.
+func focusCommandArgs(template []string, spaces []Space, index int) ([]string, error) {
+	display, desktop, err := engine.DesktopNumber(spaces, index)
+	if err != nil {
+		return nil, err
+	}
+	label := strconv.Itoa(index)
+	for _, s := range spaces {
+		if s.Index == index && s.Label != "" {
+			label = s.Label
+		}
+	}
+	r := strings.NewReplacer(
+		"{index}", strconv.Itoa(index),
+		"{desktop}", strconv.Itoa(desktop),
+		"{display}", strconv.Itoa(display),
+		"{label}", label,
+	)
+	args := make([]string, len(template))
+	for i, a := range template {
+		args[i] = r.Replace(a)
+	}
+	return args, nil
+}
+
+// switchSpace focuses a space: through focusCommand when it's set, the
+// window manager otherwise.
+func switchSpace(wm engine.WindowManager, index int) error {
+	if len(focusCommand) == 0 {
+		return wm.FocusSpace(index)
+	}
+	spaces, err := wm.QuerySpaces()
+	if err != nil {
+		return err
+	}
+	args, err := focusCommandArgs(focusCommand, spaces, index)
+	if err != nil {
+		return err
+	}
+	if err := engine.Guard(args[0], args[1:]...); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	engine.LogCommand(start, args[0], args[1:], err)
+	if err != nil {
+		return fmt.Errorf("focus command %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
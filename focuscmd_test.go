@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestFocusCommandArgs(t *testing.T) {
+	spaces := []Space{{Index: 1, Display: 1}, {Index: 2, Display: 2}, {Index: 3, Display: 2, Label: "web"}}
+	args, err := focusCommandArgs([]string{"skhd", "-k", "alt - {desktop}", "{index}:{display}:{label}"}, spaces, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"skhd", "-k", "alt - 2", "3:2:web"}; !slices.Equal(args, want) {
+		t.Errorf("args = %q, want %q", args, want)
+	}
+	if args, _ := focusCommandArgs([]string{"{label}"}, spaces, 1); args[0] != "1" {
+		t.Errorf("unlabeled = %q, want the index", args)
+	}
+	if _, err := focusCommandArgs([]string{"{index}"}, spaces, 9); err == nil {
+		t.Error("a missing space was filled in")
+	}
+}
+
+func TestSwitchSpace(t *testing.T) {
+	fake := &engine.Fake{Spaces: []Space{{Index: 1, Display: 1}, {Index: 2, Display: 1, Label: "api"}}}
+	defer func(old []string) { focusCommand = old }(focusCommand)
+
+	focusCommand = nil
+	if err := switchSpace(fake, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Calls(); !slices.Equal(got, []string{"focus-space 2"}) {
+		t.Errorf("calls = %q", got)
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	focusCommand = []string{"sh", "-c", "echo {desktop} {label} > " + out}
+	if err := switchSpace(fake, 2); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(out); strings.TrimSpace(string(data)) != "2 api" {
+		t.Errorf("command ran with %q", data)
+	}
+	if got := fake.Calls(); len(got) != 1 {
+		t.Errorf("the window manager was asked too: %q", got)
+	}
+}
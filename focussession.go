@@ -0,0 +1,181 @@
+// focussession: a pomodoro-style timer bound to one space.
+//
+// t on a space starts a focus session on it, focusSessionLength long
+// (config.go); t again ends it early. while one runs the page's top line
+// shows elapsed and remaining time and the space carries a ⏱, and the
+// line turns to a warning when some other space has focus — the nudge to
+// go back. sessions live in the snapshot db, so `stop serve` reports the
+// running one and every session, finished or abandoned, stays on record
+// in focus_sessions.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const focusSessionSchema = `
+CREATE TABLE IF NOT EXISTS focus_sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	space_id INTEGER NOT NULL,
+	space_index INTEGER NOT NULL,
+	space_label TEXT NOT NULL DEFAULT '',
+	started_at TEXT NOT NULL,
+	length_ms INTEGER NOT NULL,
+	ended_at TEXT NOT NULL DEFAULT '',
+	completed INTEGER NOT NULL DEFAULT 0
+);
+`
+
+// focusSession is the running timer.
+type focusSession struct {
+	ID         int64
+	SpaceID    int
+	SpaceIndex int
+	SpaceLabel string
+	StartedAt  time.Time
+	Length     time.Duration
+}
+
+// name is the session's space the way the header names it.
+func (s focusSession) name() string {
+	return spaceTitle(Space{Index: s.SpaceIndex, Label: s.SpaceLabel})
+}
+
+// remaining is how long is left at now, never negative.
+func (s focusSession) remaining(now time.Time) time.Duration {
+	return max(s.Length-now.Sub(s.StartedAt), 0)
+}
+
+// startFocusSession ends whatever session is running (abandoned) and
+// starts one on space.
+func startFocusSession(db *sql.DB, space Space, length time.Duration, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stamp := now.UTC().Format(time.RFC3339)
+	if _, err := tx.Exec("UPDATE focus_sessions SET ended_at = ? WHERE ended_at = ''", stamp); err != nil {
+		return fmt.Errorf("ending focus session: %w", err)
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO focus_sessions (space_id, space_index, space_label, started_at, length_ms) VALUES (?, ?, ?, ?, ?)",
+		space.ID, space.Index, space.Label, stamp, length.Milliseconds(),
+	); err != nil {
+		return fmt.Errorf("starting focus session: %w", err)
+	}
+	return tx.Commit()
+}
+
+// endFocusSession closes a session at end, completed or not.
+func endFocusSession(db *sql.DB, id int64, end time.Time, completed bool) error {
+	_, err := db.Exec("UPDATE focus_sessions SET ended_at = ?, completed = ? WHERE id = ? AND ended_at = ''",
+		end.UTC().Format(time.RFC3339), completed, id)
+	if err != nil {
+		return fmt.Errorf("ending focus session: %w", err)
+	}
+	return nil
+}
+
+// loadFocusSession returns the running session, completing it first if
+// its time ran out (then nil comes back). ok is false with none running.
+func loadFocusSession(db *sql.DB, now time.Time) (focusSession, bool, error) {
+	var s focusSession
+	var started string
+	var lengthMS int64
+	err := db.QueryRow(
+		"SELECT id, space_id, space_index, space_label, started_at, length_ms FROM focus_sessions WHERE ended_at = '' ORDER BY id DESC LIMIT 1",
+	).Scan(&s.ID, &s.SpaceID, &s.SpaceIndex, &s.SpaceLabel, &started, &lengthMS)
+	if err == sql.ErrNoRows {
+		return focusSession{}, false, nil
+	}
+	if err != nil {
+		return focusSession{}, false, fmt.Errorf("querying focus session: %w", err)
+	}
+	s.StartedAt, _ = time.Parse(time.RFC3339, started)
+	s.Length = time.Duration(lengthMS) * time.Millisecond
+	if s.remaining(now) == 0 {
+		return focusSession{}, false, endFocusSession(db, s.ID, s.StartedAt.Add(s.Length), true)
+	}
+	return s, true, nil
+}
+
+// queryFocusSession is the fetch-side read: the running session or nil.
+func queryFocusSession() *focusSession {
+	db := sharedStateDB()
+	if db == nil {
+		return nil
+	}
+	s, ok, err := loadFocusSession(db, time.Now())
+	if err != nil || !ok {
+		return nil
+	}
+	return &s
+}
+
+// toggleFocusSessionCmd starts a session on space, or ends the running
+// one early when it's already on space.
+func toggleFocusSessionCmd(running *focusSession, space Space) tea.Cmd {
+	return func() tea.Msg {
+		db := sharedStateDB()
+		if db == nil {
+			return actionDoneMsg{status: "focus session failed", err: fmt.Errorf("state db unavailable")}
+		}
+		if running != nil && running.SpaceID == space.ID {
+			if err := endFocusSession(db, running.ID, time.Now(), false); err != nil {
+				return actionDoneMsg{status: "focus session failed", err: err}
+			}
+			return actionDoneMsg{status: fmt.Sprintf("focus session on %s ended after %s", running.name(), formatRelativeTime(running.StartedAt))}
+		}
+		if err := startFocusSession(db, space, focusSessionLength, time.Now()); err != nil {
+			return actionDoneMsg{status: "focus session failed", err: err}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("focusing on %s for %s", spaceTitle(space), humanDuration(focusSessionLength))}
+	}
+}
+
+// focusSessionDone is the status when a session vanished between fetches
+// because its time ran out.
+func focusSessionDone(prev, next *focusSession, now time.Time) (string, bool) {
+	if prev == nil || next != nil && next.ID == prev.ID || prev.remaining(now) > 0 {
+		return "", false
+	}
+	return fmt.Sprintf("focus session on %s done (%s)", prev.name(), humanDuration(prev.Length)), true
+}
+
+// renderFocusSessionLine is the page's top line while a session runs:
+// dim while its space has focus, a warning while another one does.
+func renderFocusSessionLine(s *focusSession, focused Space, now time.Time) string {
+	if s == nil {
+		return ""
+	}
+	elapsed := now.Sub(s.StartedAt).Truncate(time.Second)
+	text := fmt.Sprintf("⏱ %s  %s in, %s left", s.name(), humanDuration(elapsed), humanDuration(s.remaining(now).Truncate(time.Second)))
+	if focused.ID != 0 && focused.ID != s.SpaceID {
+		return warnStyle.Render(text + " — you're on " + spaceTitle(focused))
+	}
+	return dimStyle.Render(text)
+}
+
+// focusedSpace is the space with keyboard focus, zero when unknown.
+func (m model) focusedSpace() Space {
+	for _, s := range m.spaces {
+		if s.HasFocus {
+			return s
+		}
+	}
+	return Space{}
+}
+
+// spaceTitle names a space by label, else index.
+func spaceTitle(s Space) string {
+	if s.Label != "" {
+		return s.Label
+	}
+	return fmt.Sprintf("space %d", s.Index)
+}
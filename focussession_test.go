@@ -0,0 +1,59 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFocusSessionLifecycle(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(focusSessionSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	api := Space{ID: 7, Index: 3, Label: "api"}
+	if err := startFocusSession(db, Space{ID: 4, Index: 1}, 25*time.Minute, start); err != nil {
+		t.Fatal(err)
+	}
+	// starting another abandons the first
+	if err := startFocusSession(db, api, 25*time.Minute, start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	s, ok, err := loadFocusSession(db, start.Add(11*time.Minute))
+	if err != nil || !ok || s.SpaceID != 7 || s.remaining(start.Add(11*time.Minute)) != 15*time.Minute {
+		t.Fatalf("running = %+v, %v, %v", s, ok, err)
+	}
+
+	line := renderFocusSessionLine(&s, api, start.Add(11*time.Minute))
+	if !strings.Contains(line, "api  10m0s in, 15m0s left") || strings.Contains(line, "you're on") {
+		t.Errorf("on its space: %q", line)
+	}
+	if line := renderFocusSessionLine(&s, Space{ID: 9, Index: 5}, start); !strings.Contains(line, "you're on space 5") {
+		t.Errorf("elsewhere: %q", line)
+	}
+
+	// time runs out: the session completes and a done status appears once
+	if _, ok, err := loadFocusSession(db, start.Add(30*time.Minute)); err != nil || ok {
+		t.Fatalf("expired session still running: %v, %v", ok, err)
+	}
+	if _, ok := focusSessionDone(&s, nil, start.Add(30*time.Minute)); !ok {
+		t.Error("no done status")
+	}
+	if _, ok := focusSessionDone(&s, nil, start.Add(12*time.Minute)); ok {
+		t.Error("an ended-early session reported done")
+	}
+
+	var completed, total int
+	db.QueryRow("SELECT SUM(completed), COUNT(*) FROM focus_sessions WHERE ended_at != ''").Scan(&completed, &total)
+	if completed != 1 || total != 2 {
+		t.Errorf("history: %d completed of %d", completed, total)
+	}
+}
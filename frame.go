@@ -0,0 +1,100 @@
+// frame: skip drawing a page that would come out the same.
+//
+// the render tick asks for a frame every 100ms so the lyrics play head
+// moves smoothly, and every one of them used to build the whole page.
+// View now keeps its last frame under a key and hands it back while the
+// key holds. the key is a hash of what the last refresh gave the view
+// (taken once per refresh in handleData, not per frame), a generation
+// bumped by every other message that can change the model — keys, the
+// mouse, resizes, action results — and the wall clock rounded down to
+// frameCadence (config.go), so relative times ("3m", staleness colors,
+// flash and focus countdowns) still advance, just on that slower beat.
+// while a song plays the clock isn't rounded: the play head moves every
+// tick.
+
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// frameCache is View's last page. the model holds it by pointer so the
+// value-receiver View can fill it.
+type frameCache struct {
+	gen  uint64 // bumped by each message that can change the model
+	data uint64 // viewStateHash of the last refresh
+	key  uint64
+	view string
+}
+
+// observe bumps the generation for msg, unless it's one that leaves the
+// model alone (the tick messages) or stamps data itself (dataMsg).
+func (f *frameCache) observe(msg tea.Msg) {
+	if f == nil {
+		return
+	}
+	switch msg.(type) {
+	case renderTickMsg, tickMsg, metaMsg, dataMsg:
+		return
+	}
+	f.gen++
+}
+
+// stamp records the view state a refresh left behind.
+func (f *frameCache) stamp(m model) {
+	if f != nil {
+		f.data = viewStateHash(m)
+	}
+}
+
+// viewStateHash hashes everything a refresh sets that the page shows.
+// pointers hash by address, so a replaced value counts as changed.
+func viewStateHash(m model) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00",
+		m.displayGroups, m.tmuxByDisplay, m.detachedTmux, m.productivePanePIDs,
+		m.nvimBuffers, m.annotations, m.priorities, m.alerts)
+	fmt.Fprintf(h, "%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00",
+		m.baselines, m.paneEnv, m.paneKube, m.paneStates,
+		m.browserTabs, m.terminalSessions, m.bareAgents, m.focusSession, m.spaceTime, m.agentUsage, m.titleHistory, m.spaceHotkeys, m.enrichments)
+	fmt.Fprintf(h, "%v\x00%v\x00%v\x00%v\x00%v\x00%d,%d\x00%s\x00%v\x00%v",
+		m.slo, m.flashes, m.migrations, m.wmErr, m.sourceErrs,
+		m.cursorCol, m.cursorRow, m.status, m.err, m.ready)
+	return h.Sum64()
+}
+
+// frameKey is what the page drawn at now depends on.
+func (m model) frameKey(now time.Time) uint64 {
+	clock := now.Truncate(frameCadence)
+	if m.playingMeta.State == "playing" {
+		clock = now
+	}
+	meta := m.playingMeta
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d\x00%d\x00%d\x00%s\x00%s\x00%s\x00%v\x00%v",
+		m.frame.gen, m.frame.data, clock.UnixNano(), meta.State, meta.Artist, meta.Title, meta.Position, meta.Duration)
+	// the budget line only shows once refreshes run long or are throttled (budget.go)
+	if b := m.budget; b != nil && (b.overrun() || b.interval > basePollInterval || b.throttle != "") {
+		fmt.Fprintf(h, "\x00%v,%v,%v,%s", b.interval, b.fetch, b.derive, b.throttle)
+	}
+	return h.Sum64()
+}
+
+// View draws the page, or hands back the last one when nothing it
+// shows has changed.
+func (m model) View() string {
+	if m.frame == nil {
+		return m.renderView()
+	}
+	key := m.frameKey(time.Now())
+	if m.frame.view != "" && m.frame.key == key {
+		return m.frame.view
+	}
+	view := m.renderView()
+	m.frame.key, m.frame.view = key, view
+	return view
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFrameKey(t *testing.T) {
+	spaces := []Space{{ID: 1, Index: 1, Display: 1}}
+	next, _ := newModel().handleData(fetchResult{spaces: spaces, displays: []Display{{Index: 1}}})
+	m := next.(model)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := m.frameKey(now)
+
+	if m.frameKey(now.Add(100*time.Millisecond)) != key {
+		t.Error("a render tick inside the cadence changed the key")
+	}
+	if m.frameKey(now.Add(frameCadence)) == key {
+		t.Error("the key outlived frameCadence")
+	}
+
+	// ticks and identical refreshes leave it alone
+	for _, msg := range []tea.Msg{renderTickMsg{}, tickMsg(now)} {
+		next, _ = m.Update(msg)
+		m = next.(model)
+	}
+	next, _ = m.handleData(fetchResult{spaces: spaces, displays: []Display{{Index: 1}}})
+	m = next.(model)
+	if m.frameKey(now) != key {
+		t.Error("an unchanged refresh changed the key")
+	}
+
+	// a refresh with something new, or a key press, changes it
+	next, _ = m.handleData(fetchResult{spaces: spaces, displays: []Display{{Index: 1}}, windows: []Window{{ID: 9, App: "kitty", Space: 1}}})
+	m = next.(model)
+	refreshed := m.frameKey(now)
+	if refreshed == key {
+		t.Error("a new window left the key alone")
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	m = next.(model)
+	if m.frameKey(now) == refreshed {
+		t.Error("a key press left the key alone")
+	}
+
+	// a playing song redraws every tick
+	m.playingMeta = PlayingMeta{State: "playing", Duration: 200, SampledAt: now}
+	if m.frameKey(now) == m.frameKey(now.Add(100*time.Millisecond)) {
+		t.Error("the play head didn't move the key")
+	}
+}
+
+func TestViewReusesFrame(t *testing.T) {
+	next, _ := newModel().handleData(fetchResult{spaces: []Space{{ID: 1, Index: 1, Display: 1}}})
+	m := next.(model)
+	view := m.View()
+	if m.frame.view != view {
+		t.Fatal("View didn't keep its frame")
+	}
+	// the same key hands back the kept frame without drawing
+	m.frame.key, m.frame.view = m.frameKey(time.Now()), "kept"
+	if got := m.View(); got != "kept" && m.frame.key == m.frameKey(time.Now()) {
+		t.Errorf("View = %q, want the kept frame", got)
+	}
+}
@@ -0,0 +1,75 @@
+// hidden: the minimized and hidden windows each display is holding.
+//
+// the grid only shows what's on screen, so a window minimized to the dock
+// (or hidden with its app, or parked in i3's scratchpad) drops out of
+// sight entirely. H opens a section under each display's spaces listing
+// them by app and title, with the space each one came from. U brings
+// back the selected space's hidden windows, and clicking one in the
+// section brings back just that window.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hiddenOnSpace lists a display's hidden windows that came from a space.
+func hiddenOnSpace(dg displayGroup, space int) []Window {
+	var ws []Window
+	for _, w := range dg.hidden {
+		if w.Space == space {
+			ws = append(ws, w)
+		}
+	}
+	return ws
+}
+
+// restoreWindowsCmd un-minimizes / unhides windows and focuses each in
+// turn, so the last one ends up in front.
+func restoreWindowsCmd(ws []Window) tea.Cmd {
+	return func() tea.Msg {
+		for _, w := range ws {
+			if err := currentWM().RestoreWindow(w.ID); err != nil {
+				return actionDoneMsg{status: "restoring " + w.App + " failed", err: err}
+			}
+		}
+		if len(ws) == 1 {
+			return actionDoneMsg{status: "restored " + ws[0].App}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("restored %d windows", len(ws))}
+	}
+}
+
+// renderHiddenSection is the section under a display's spaces: a heading
+// and one "app: title" line per hidden window, tagged min (minimized) or
+// hid (hidden) and with the relative index of the space it came from.
+// each window line is a click target that restores it.
+func renderHiddenSection(dg displayGroup, maxTitleLen int) (string, []hitTarget) {
+	relIdx := map[int]int{}
+	for i, row := range dg.spaces {
+		relIdx[row.space.Index] = i + 1
+	}
+
+	lines := []string{dimStyle.Render(fmt.Sprintf("  hidden (%d)", len(dg.hidden)))}
+	targets := []hitTarget{{}}
+	for _, w := range dg.hidden {
+		state := "hid"
+		if w.IsMinimized {
+			state = "min"
+		}
+		where := "  "
+		if n, ok := relIdx[w.Space]; ok {
+			where = fmt.Sprintf("%2d", n)
+		}
+		text := withIcon(w.App)
+		if w.Title != "" {
+			text += ": " + truncateStr(w.Title, maxTitleLen)
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s  %s", dimStyle.Render(where), dimStyle.Render(state), text))
+		targets = append(targets, hitTarget{kind: hitHiddenWindow, windowID: w.ID})
+	}
+	return strings.Join(lines, "\n"), targets
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHiddenSection(t *testing.T) {
+	dg := displayGroup{
+		spaces: []spaceRow{{space: Space{Index: 4}}, {space: Space{Index: 5}}},
+		hidden: []Window{
+			{ID: 7, App: "Mail", Title: "Inbox", Space: 5, IsMinimized: true},
+			{ID: 8, App: "Keys", IsHidden: true}, // scratchpad
+		},
+	}
+	text, targets := renderHiddenSection(dg, 40)
+	lines := strings.Split(text, "\n")
+	if len(lines) != 3 || len(targets) != 3 {
+		t.Fatalf("lines = %q, targets = %+v", lines, targets)
+	}
+	if !strings.Contains(lines[1], " 2") || !strings.Contains(lines[1], "min") || !strings.Contains(lines[1], "Mail: Inbox") {
+		t.Errorf("minimized line = %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "hid") || !strings.HasSuffix(lines[2], "Keys") {
+		t.Errorf("hidden line = %q", lines[2])
+	}
+	if targets[0].kind != hitNone || targets[1].kind != hitHiddenWindow || targets[2].windowID != 8 {
+		t.Errorf("targets = %+v", targets)
+	}
+
+	if ws := hiddenOnSpace(dg, 5); len(ws) != 1 || ws[0].ID != 7 {
+		t.Errorf("hidden on space 5 = %+v", ws)
+	}
+	if ws := hiddenOnSpace(dg, 4); len(ws) != 0 {
+		t.Errorf("hidden on space 4 = %+v", ws)
+	}
+}
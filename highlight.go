@@ -0,0 +1,58 @@
+// highlight: mirror the TUI cursor onto the real screen.
+//
+// on a big multi-monitor desk it isn't always obvious which physical
+// space a dashboard row means. with mirroring on (v), every time the
+// cursor settles on a space its windows flash briefly — dimmed via
+// opacity on yabai and sway, marked urgent on i3 — so the eye finds the
+// space on the display itself. focus-follows-cursor (f) rides the same
+// debounce but switches to the space outright.
+
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// debounces for cursor-driven side effects: holding j shouldn't flash or
+// focus every space the cursor passes through. focusing is the heavier
+// action (the whole display switches), so it waits longer.
+const (
+	cursorSettleDelay = 150 * time.Millisecond
+	focusFollowDelay  = 300 * time.Millisecond
+)
+
+// cursorSettledMsg fires after a cursor move has sat still for its delay.
+// seq lets the model drop ticks superseded by a later move; focus tells
+// the focus-follows-cursor tick apart from the highlight one.
+type cursorSettledMsg struct {
+	seq   int
+	focus bool
+}
+
+// flashDoneMsg reports a failed flash; success needs no follow-up.
+type flashDoneMsg struct{ err error }
+
+func cursorSettleCmd(seq int, delay time.Duration, focus bool) tea.Cmd {
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return cursorSettledMsg{seq: seq, focus: focus}
+	})
+}
+
+// flashWindowsCmd highlights windows off the update loop.
+func flashWindowsCmd(ids []int) tea.Cmd {
+	return func() tea.Msg {
+		return flashDoneMsg{err: currentWM().FlashWindows(ids)}
+	}
+}
+
+// selectedWindowIDs lists the visible windows on the cursor's space.
+func (m model) selectedWindowIDs() []int {
+	row, _ := m.selectedSpaceRow()
+	var ids []int
+	for _, w := range row.windows {
+		ids = append(ids, w.ID)
+	}
+	return ids
+}
@@ -0,0 +1,201 @@
+// hooks: external enrichers that add badges and lines to the dashboard.
+//
+// stop can't know about CI, calendars or an agent's own telemetry, but a
+// script can. each executable in enricherHooks (config.go) is run with
+// the /spaces JSON on stdin and answers on stdout with what to add:
+//
+//	{"spaces":   {"api": {"badge": "CI ✓", "color": "green"}},
+//	 "sessions": {"web": {"badge": "deploying", "lines": ["eta 14:05"]}}}
+//
+// spaces are keyed by label or absolute index, sessions by tmux session
+// name, like annotations. badges (colored with any theme color) follow
+// the space's or session's line and lines go under it; serve returns the
+// same under "enrichments". hooks run in the background every
+// enricherRefresh, each with enricherTimeout, so a slow one never holds
+// up a refresh — the dashboard shows the last answers. a hook that fails
+// or prints something unreadable shows on the source errors line.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+const (
+	enricherRefresh = 15 * time.Second
+	enricherTimeout = 5 * time.Second
+)
+
+// hookBadge is one short colored tag.
+type hookBadge struct {
+	Text  string `json:"text"`
+	Color string `json:"color,omitempty"`
+}
+
+// enrichment is everything hooks added to one space or session.
+type enrichment struct {
+	Badges []hookBadge `json:"badges,omitempty"`
+	Lines  []string    `json:"lines,omitempty"`
+}
+
+// hookTarget is one entry of a hook's answer.
+type hookTarget struct {
+	Badge string   `json:"badge"`
+	Color string   `json:"color"`
+	Lines []string `json:"lines"`
+}
+
+// hookOutput is a hook's whole answer.
+type hookOutput struct {
+	Spaces   map[string]hookTarget `json:"spaces"`
+	Sessions map[string]hookTarget `json:"sessions"`
+}
+
+// enrichSet indexes enrichments by target kind then target, like
+// annotationSet. a nil set is valid and finds nothing.
+type enrichSet map[string]map[string]enrichment
+
+// merge adds one hook's answer; hooks listed later append after earlier
+// ones.
+func (s enrichSet) merge(out hookOutput) {
+	add := func(kind string, targets map[string]hookTarget) {
+		for target, t := range targets {
+			if s[kind] == nil {
+				s[kind] = map[string]enrichment{}
+			}
+			e := s[kind][target]
+			if t.Badge != "" {
+				e.Badges = append(e.Badges, hookBadge{t.Badge, t.Color})
+			}
+			e.Lines = append(e.Lines, t.Lines...)
+			s[kind][target] = e
+		}
+	}
+	add(targetSpace, out.Spaces)
+	add(targetSession, out.Sessions)
+}
+
+// forSpace returns a space's enrichment by label, then absolute index.
+func (s enrichSet) forSpace(space Space) enrichment {
+	if e, ok := s[targetSpace][space.Label]; ok && space.Label != "" {
+		return e
+	}
+	return s[targetSpace][strconv.Itoa(space.Index)]
+}
+
+// forSession returns a tmux session's enrichment.
+func (s enrichSet) forSession(name string) enrichment {
+	return s[targetSession][name]
+}
+
+// runHook runs one hook with state on stdin and parses its answer.
+func runHook(path string, state []byte) (hookOutput, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), enricherTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(state)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	start := time.Now()
+	stdout, err := cmd.Output()
+	engine.LogCommand(start, path, nil, err)
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return hookOutput{}, fmt.Errorf("%w: %s", err, msg)
+		}
+		return hookOutput{}, err
+	}
+	var out hookOutput
+	if err := json.Unmarshal(stdout, &out); err != nil {
+		return hookOutput{}, fmt.Errorf("reading answer: %w", err)
+	}
+	return out, nil
+}
+
+var (
+	hooksMu      sync.Mutex
+	hooksAt      time.Time
+	hooksRunning bool
+	hooksSet     enrichSet
+	hooksErrs    []engine.SourceError
+)
+
+// refreshHooks runs every hook in parallel on one state and swaps in the
+// merged answers, keeping the configured order.
+func refreshHooks(state []byte) {
+	outs := make([]hookOutput, len(enricherHooks))
+	errs := make([]error, len(enricherHooks))
+	var wg sync.WaitGroup
+	for i, path := range enricherHooks {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			outs[i], errs[i] = runHook(path, state)
+		}(i, path)
+	}
+	wg.Wait()
+
+	set := enrichSet{}
+	var failed []engine.SourceError
+	for i, out := range outs {
+		if errs[i] != nil {
+			failed = append(failed, engine.SourceError{Source: "hook " + filepath.Base(enricherHooks[i]), Err: errs[i]})
+			continue
+		}
+		set.merge(out)
+	}
+
+	hooksMu.Lock()
+	hooksSet, hooksErrs, hooksAt, hooksRunning = set, failed, time.Now(), false
+	hooksMu.Unlock()
+}
+
+// queryEnrichments returns the hooks' last answers and failures,
+// starting a background run on this fetch's state when they're stale.
+func queryEnrichments(result fetchResult) (enrichSet, []engine.SourceError) {
+	if len(enricherHooks) == 0 {
+		return nil, nil
+	}
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	if !hooksRunning && time.Since(hooksAt) >= enricherRefresh {
+		if state, err := json.Marshal(buildSpacesResponse(result)); err == nil {
+			hooksRunning = true
+			go refreshHooks(state)
+		}
+	}
+	return hooksSet, hooksErrs
+}
+
+// renderHookBadges renders an enrichment's badges, "" without any.
+func renderHookBadges(e enrichment) string {
+	parts := make([]string, 0, len(e.Badges))
+	for _, b := range e.Badges {
+		style := envBadgeStyle
+		if color, err := resolveColor(b.Color); err == nil && color != "" {
+			style = lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+		}
+		parts = append(parts, style.Render(b.Text))
+	}
+	return strings.Join(parts, " ")
+}
+
+// enrichmentJSON is the serve shape, nil when there's nothing.
+func enrichmentJSON(e enrichment) map[string]any {
+	if len(e.Badges) == 0 && len(e.Lines) == 0 {
+		return nil
+	}
+	return map[string]any{"badges": e.Badges, "lines": e.Lines}
+}
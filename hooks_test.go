@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, script string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	ci := write("ci", `grep -q '"timestamp"' && echo '{"spaces": {"api": {"badge": "CI ✓", "color": "green"}, "4": {"lines": ["standup 10:00"]}}, "sessions": {"web": {"badge": "deploying", "lines": ["eta 14:05"]}}}'`)
+	cal := write("cal", `cat >/dev/null; echo '{"spaces": {"api": {"badge": "busy", "lines": ["1:1 at 15:00"]}}}'`)
+	broken := write("broken", `echo 'oops' >&2; exit 2`)
+	garbled := write("garbled", `cat >/dev/null; echo 'not json'`)
+
+	defer func(hooks []string) { enricherHooks = hooks }(enricherHooks)
+	enricherHooks = []string{ci, cal, broken, garbled}
+	refreshHooks([]byte(`{"timestamp": 1}`))
+
+	hooksMu.Lock()
+	set, errs := hooksSet, hooksErrs
+	hooksMu.Unlock()
+
+	api := set.forSpace(Space{Index: 2, Label: "api"})
+	if !reflect.DeepEqual(api, enrichment{
+		Badges: []hookBadge{{"CI ✓", "green"}, {"busy", ""}},
+		Lines:  []string{"1:1 at 15:00"},
+	}) {
+		t.Errorf("api = %+v", api)
+	}
+	if got := set.forSpace(Space{Index: 4}).Lines; !reflect.DeepEqual(got, []string{"standup 10:00"}) {
+		t.Errorf("space 4 lines = %v", got)
+	}
+	if got := set.forSession("web"); len(got.Badges) != 1 || got.Lines[0] != "eta 14:05" {
+		t.Errorf("web = %+v", got)
+	}
+	if enrichmentJSON(set.forSession("nope")) != nil {
+		t.Error("empty enrichment serialized")
+	}
+
+	if len(errs) != 2 || errs[0].Source != "hook broken" || !strings.Contains(errs[0].Err.Error(), "oops") || errs[1].Source != "hook garbled" {
+		t.Errorf("errors = %v", errs)
+	}
+}
@@ -0,0 +1,105 @@
+// hotplug: columns and the cursor survive displays coming and going.
+//
+// display indices are positional — unplug a monitor and every display
+// after it shifts down one, so "column 2" suddenly means a different
+// screen. columns are identified by their display's UUID instead
+// (columnKey), and the cursor is remembered as its space id and column
+// and put back after every refresh: on the same space wherever that
+// space went — spaces created or destroyed above it shift its row, not
+// the selection — else on the same row of the same display. per-column
+// scroll is keyed the same way. a space the window manager re-homed to
+// another display raises space_migrated (watch.go); its row flashes and
+// is flagged ↪ with the display it came from for migrationFlagDuration.
+
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// migrationFlagDuration is how long a migrated space stays flagged.
+const migrationFlagDuration = time.Minute
+
+// columnKey identifies a display column across hot-plugs: the display's
+// UUID, or its index when the backend doesn't report one.
+func columnKey(dg displayGroup) string {
+	if dg.display.UUID != "" {
+		return dg.display.UUID
+	}
+	return strconv.Itoa(dg.index)
+}
+
+// displayKey is columnKey for a bare display index, looked up in the
+// fetch's displays.
+func displayKey(index int, displays []Display) string {
+	for _, d := range displays {
+		if d.Index == index && d.UUID != "" {
+			return d.UUID
+		}
+	}
+	return strconv.Itoa(index)
+}
+
+// cursorAnchor is where the cursor is, in terms that outlive a refresh.
+type cursorAnchor struct {
+	spaceID int
+	column  string
+	row     int
+	ok      bool
+}
+
+func (m model) cursorAnchor() cursorAnchor {
+	row, ok := m.selectedSpaceRow()
+	if !ok {
+		return cursorAnchor{}
+	}
+	return cursorAnchor{spaceID: row.space.ID, column: columnKey(m.displayGroups[m.cursorCol]), row: m.cursorRow, ok: true}
+}
+
+// restoreAnchor puts the cursor back on its space, else on its row in
+// its column, wherever that column is now. with neither it stays put
+// for the caller's clamping.
+func (m *model) restoreAnchor(a cursorAnchor) {
+	if !a.ok || (a.spaceID != 0 && m.restoreCursor(a.spaceID)) {
+		return
+	}
+	for c, dg := range m.displayGroups {
+		if columnKey(dg) == a.column {
+			m.cursorCol, m.cursorRow = c, min(a.row, max(len(dg.spaces)-1, 0))
+			return
+		}
+	}
+}
+
+// spaceMigration flags a space that moved displays.
+type spaceMigration struct {
+	from  string // the display it was on
+	until time.Time
+}
+
+// flagMigrations records the spaces events moved between displays, by
+// absolute index, dropping expired flags.
+func flagMigrations(flags map[int]spaceMigration, events []watchEvent, now time.Time) map[int]spaceMigration {
+	next := map[int]spaceMigration{}
+	for idx, f := range flags {
+		if now.Before(f.until) {
+			next[idx] = f
+		}
+	}
+	for _, ev := range events {
+		if ev.Event == watchSpaceMigrated {
+			next[ev.Space] = spaceMigration{from: ev.Previous, until: now.Add(migrationFlagDuration)}
+		}
+	}
+	return next
+}
+
+// renderMigration is a migrated space's flag, or "".
+func renderMigration(flags map[int]spaceMigration, space Space, now time.Time) string {
+	f, ok := flags[space.Index]
+	if !ok || !now.Before(f.until) {
+		return ""
+	}
+	return warnStyle.Render("↪ ") + dimStyle.Render("from "+f.from+" ")
+}
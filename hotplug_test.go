@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorSurvivesUnplug(t *testing.T) {
+	displays := []Display{{Index: 1, UUID: "built-in"}, {Index: 2, UUID: "lg"}, {Index: 3, UUID: "dell"}}
+	spaces := []Space{
+		{ID: 1, Index: 1, Display: 1},
+		{ID: 2, Index: 2, Display: 2},
+		{ID: 3, Index: 3, Display: 3},
+		{ID: 4, Index: 4, Display: 3},
+	}
+	m := newModel()
+	next, _ := m.handleData(fetchResult{spaces: spaces, displays: displays})
+	m = next.(model)
+	m.cursorCol, m.cursorRow = 2, 1 // space 4 on the dell
+
+	// the LG is unplugged: the dell becomes display 2 and the LG's space
+	// moves to the built-in panel
+	displays = []Display{{Index: 1, UUID: "built-in"}, {Index: 2, UUID: "dell"}}
+	spaces = []Space{
+		{ID: 1, Index: 1, Display: 1},
+		{ID: 2, Index: 2, Display: 1},
+		{ID: 3, Index: 3, Display: 2},
+		{ID: 4, Index: 4, Display: 2},
+	}
+	next, _ = m.handleData(fetchResult{spaces: spaces, displays: displays})
+	m = next.(model)
+	if row, _ := m.selectedSpaceRow(); row.space.ID != 4 || m.cursorCol != 1 {
+		t.Errorf("cursor on space %d in column %d", row.space.ID, m.cursorCol)
+	}
+	if f, ok := m.migrations[2]; !ok || f.from != "display 2" {
+		t.Errorf("migrations = %+v", m.migrations)
+	}
+
+	// space 4 goes away: the cursor stays on the dell, clamped
+	spaces = spaces[:3]
+	next, _ = m.handleData(fetchResult{spaces: spaces, displays: displays})
+	m = next.(model)
+	if row, _ := m.selectedSpaceRow(); row.space.ID != 3 || columnKey(m.displayGroups[m.cursorCol]) != "dell" {
+		t.Errorf("cursor on space %d in column %d", row.space.ID, m.cursorCol)
+	}
+}
+
+func TestMigrationFlags(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	flags := flagMigrations(nil, []watchEvent{{Event: watchSpaceMigrated, Space: 3, Previous: "LG", Display: "built-in"}}, now)
+	if renderMigration(flags, Space{Index: 3}, now) == "" {
+		t.Error("migrated space not flagged")
+	}
+	if renderMigration(flags, Space{Index: 3}, now.Add(migrationFlagDuration)) != "" {
+		t.Error("flag outlived migrationFlagDuration")
+	}
+	if flags = flagMigrations(flags, nil, now.Add(migrationFlagDuration)); len(flags) != 0 {
+		t.Errorf("expired flag kept: %+v", flags)
+	}
+}
+
+func TestCursorFollowsSpaceID(t *testing.T) {
+	m := newModel()
+	spaces := []Space{{ID: 10, Index: 1}, {ID: 11, Index: 2}, {ID: 12, Index: 3}}
+	next, _ := m.handleData(fetchResult{spaces: spaces})
+	m = next.(model)
+	m.cursorRow = 1 // space 11
+
+	// a space is created at the front: 11 is now the third row
+	spaces = []Space{{ID: 20, Index: 1}, {ID: 10, Index: 2}, {ID: 11, Index: 3}, {ID: 12, Index: 4}}
+	next, _ = m.handleData(fetchResult{spaces: spaces})
+	m = next.(model)
+	if row, _ := m.selectedSpaceRow(); row.space.ID != 11 || m.cursorRow != 2 {
+		t.Errorf("after create: cursor on %d at row %d", row.space.ID, m.cursorRow)
+	}
+
+	// two spaces above it are destroyed
+	spaces = []Space{{ID: 11, Index: 1}, {ID: 12, Index: 2}}
+	next, _ = m.handleData(fetchResult{spaces: spaces})
+	m = next.(model)
+	if row, _ := m.selectedSpaceRow(); row.space.ID != 11 || m.cursorRow != 0 {
+		t.Errorf("after destroy: cursor on %d at row %d", row.space.ID, m.cursorRow)
+	}
+}
@@ -0,0 +1,68 @@
+// icons: nerd font glyphs in front of app names.
+//
+// appIcons (config.go) maps an app to a glyph, falling back to a generic
+// terminal glyph for terminals it doesn't name. nerd font glyphs live in
+// the private use area, which the width tables count as one cell, while
+// terminals draw them one cell wide (mono variants) or spilling into the
+// next cell (the rest). every icon is therefore padded to iconCells
+// measured cells — the glyph and the cell it may spill into — so the
+// column layout and what's drawn agree either way. --no-icons turns them
+// off for terminals without a nerd font.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/fadedlamp42/stop/engine"
+	"github.com/mattn/go-runewidth"
+)
+
+// iconsEnabled is cleared by --no-icons.
+var iconsEnabled = true
+
+// iconCells is how many cells an icon and its gap take.
+const iconCells = 2
+
+// terminalIcon is nf-oct-terminal, for terminals appIcons doesn't name.
+const terminalIcon = "\uf489"
+
+// appIcon returns the app's glyph padded to iconCells, or "" when icons
+// are off or the app has none. lookup is exact, then case-insensitive.
+func appIcon(app string) string {
+	if !iconsEnabled {
+		return ""
+	}
+	glyph, ok := appIcons[app]
+	if !ok {
+		for name, g := range appIcons {
+			if strings.EqualFold(name, app) {
+				glyph, ok = g, true
+				break
+			}
+		}
+	}
+	if !ok && engine.IsTerminal(app) {
+		glyph, ok = terminalIcon, true
+	}
+	if !ok || glyph == "" {
+		return ""
+	}
+	if pad := iconCells - runewidth.StringWidth(glyph); pad > 0 {
+		glyph += strings.Repeat(" ", pad)
+	}
+	return glyph
+}
+
+// withIcon prefixes an app name with its icon.
+func withIcon(app string) string {
+	return appIcon(app) + app
+}
+
+// iconWidth is the room an icon takes in a row, for width budgets.
+func iconWidth() int {
+	if !iconsEnabled {
+		return 0
+	}
+	return iconCells
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestAppIcon(t *testing.T) {
+	defer func(icons map[string]string, enabled bool) { appIcons, iconsEnabled = icons, enabled }(appIcons, iconsEnabled)
+	appIcons = map[string]string{"Firefox": "\uf269", "Slack": "\U000f04b1", "Music": "🎵"}
+	iconsEnabled = true
+
+	for _, app := range []string{"Firefox", "firefox", "Slack", "Music", "kitty"} {
+		icon := appIcon(app)
+		if icon == "" || runewidth.StringWidth(icon) != iconCells {
+			t.Errorf("%s: icon %q measures %d cells, want %d", app, icon, runewidth.StringWidth(icon), iconCells)
+		}
+	}
+	if appIcon("kitty") != terminalIcon+" " {
+		t.Errorf("terminal fallback = %q", appIcon("kitty"))
+	}
+	if appIcon("Preview") != "" || withIcon("Preview") != "Preview" {
+		t.Error("unmapped app got an icon")
+	}
+
+	iconsEnabled = false
+	if withIcon("Firefox") != "Firefox" || iconWidth() != 0 {
+		t.Error("--no-icons still draws icons")
+	}
+}
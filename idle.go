@@ -0,0 +1,97 @@
+// idle: how long since the keyboard or mouse was last touched.
+//
+// stale sessions are alarming when you're at the desk and meaningless
+// when you're at lunch. every fetch reads the system's input idle time
+// (IOHIDSystem's HIDIdleTime on macOS, xprintidle on X11) and, once it
+// passes awayThreshold, the dashboard says "away for 43m" and freezes
+// staleness at the moment you left: sessions that went quiet while you
+// were gone keep the color they had, and nothing turns red or grows a
+// "!" until you're back. `stop serve` reports the idle time too, so a
+// phone can tell the desk is empty.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// awayThreshold is how long without input counts as away.
+const awayThreshold = 5 * time.Minute
+
+// queryIdle reads the input idle time. ok is false where it can't be
+// known (no HID service, no X server, xprintidle missing).
+func queryIdle() (idle time.Duration, ok bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if runtime.GOOS == "darwin" {
+		out, err := exec.CommandContext(ctx, "ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+		if err != nil {
+			return 0, false
+		}
+		return parseHIDIdle(string(out))
+	}
+	out, err := exec.CommandContext(ctx, "xprintidle").Output()
+	if err != nil {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// parseHIDIdle finds `"HIDIdleTime" = <nanoseconds>` in ioreg output.
+func parseHIDIdle(out string) (time.Duration, bool) {
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		_, value, ok := strings.Cut(sc.Text(), `"HIDIdleTime" = `)
+		if !ok {
+			continue
+		}
+		ns, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(ns), true
+	}
+	return 0, false
+}
+
+// awaySince is when the user left, zero while they're here. the TUI sets
+// it from every fetch; staleness is judged as of this moment while set.
+var awaySince time.Time
+
+// markAway records the latest idle reading taken at now.
+func markAway(idle time.Duration, ok bool, now time.Time) {
+	if ok && idle >= awayThreshold {
+		awaySince = now.Add(-idle)
+		return
+	}
+	awaySince = time.Time{}
+}
+
+// stalenessAge is how old activity is for coloring and alarms: its real
+// age, or its age when the user left.
+func stalenessAge(lastActivity time.Time) time.Duration {
+	if !awaySince.IsZero() {
+		return awaySince.Sub(lastActivity)
+	}
+	return time.Since(lastActivity)
+}
+
+// renderAwayLine is the banner while the user is away, or "".
+func renderAwayLine() string {
+	if awaySince.IsZero() {
+		return ""
+	}
+	return warnStyle.Render("away for "+formatRelativeTime(awaySince)) +
+		dimStyle.Render(fmt.Sprintf(" — staleness frozen at %s", awaySince.Format("15:04")))
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHIDIdle(t *testing.T) {
+	out := `+-o IOHIDSystem  <class IOHIDSystem, id 0x100000215>
+    {
+      "HIDIdleTime" = 2580000000000
+      "HIDParameters" = {"HIDClickTime"=500000000}
+    }`
+	if d, ok := parseHIDIdle(out); !ok || d != 43*time.Minute {
+		t.Errorf("parseHIDIdle = %v, %v", d, ok)
+	}
+	if _, ok := parseHIDIdle("no service here"); ok {
+		t.Error("found an idle time in empty output")
+	}
+}
+
+func TestAwayFreezesStaleness(t *testing.T) {
+	defer func() { awaySince = time.Time{} }()
+	now := time.Now()
+	left := now.Add(-2 * time.Hour)
+
+	markAway(time.Hour, true, now)
+	if stalenessAge(left) > 61*time.Minute || stalenessAge(left) < 59*time.Minute {
+		t.Errorf("away: age = %v, want the hour before leaving", stalenessAge(left))
+	}
+	if priorityHigh.needsAttention(now.Add(-30 * time.Minute)) {
+		t.Error("alarm raised for activity that went quiet after leaving")
+	}
+	if renderAwayLine() == "" {
+		t.Error("no away banner")
+	}
+
+	markAway(time.Minute, true, now)
+	if !awaySince.IsZero() || stalenessAge(left) < 2*time.Hour {
+		t.Error("still away after input")
+	}
+	markAway(time.Hour, false, now)
+	if !awaySince.IsZero() {
+		t.Error("away without an idle reading")
+	}
+}
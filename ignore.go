@@ -0,0 +1,54 @@
+// ignore: apps and spaces the dashboard leaves out.
+//
+// some windows are always there and never interesting — Finder, a
+// Stickies note — and some spaces are scratch areas not worth a row.
+// windows of apps matching ignoreApps and spaces whose label matches
+// ignoreSpaceLabels (config.go) are dropped before spaces are grouped,
+// so they're gone from the grid and the hidden section, and the free and
+// terminal counts act as if they weren't there: a space holding only
+// Finder counts as free. serve applies the same rules to /spaces when
+// asked with ?filtered=1, and otherwise reports everything.
+
+package main
+
+// ignoredApp reports whether an app's windows are left out.
+func ignoredApp(app string) bool {
+	return ignoreApps != nil && ignoreApps.MatchString(app)
+}
+
+// ignoredSpace reports whether a space is left out.
+func ignoredSpace(s Space) bool {
+	return ignoreSpaceLabels != nil && s.Label != "" && ignoreSpaceLabels.MatchString(s.Label)
+}
+
+// filterIgnored drops ignored spaces, their windows, and ignored apps'
+// windows. the inputs are left alone.
+func filterIgnored(spaces []Space, windows []Window) ([]Space, []Window) {
+	if ignoreApps == nil && ignoreSpaceLabels == nil {
+		return spaces, windows
+	}
+	dropped := map[int]bool{}
+	keptSpaces := make([]Space, 0, len(spaces))
+	for _, s := range spaces {
+		if ignoredSpace(s) {
+			dropped[s.Index] = true
+			continue
+		}
+		keptSpaces = append(keptSpaces, s)
+	}
+	keptWindows := make([]Window, 0, len(windows))
+	for _, w := range windows {
+		if ignoredApp(w.App) || dropped[w.Space] {
+			continue
+		}
+		keptWindows = append(keptWindows, w)
+	}
+	return keptSpaces, keptWindows
+}
+
+// buildVisibleGroups is buildDisplayGroups with the ignore rules applied,
+// what the dashboard shows.
+func buildVisibleGroups(spaces []Space, windows []Window, displays []Display) []displayGroup {
+	spaces, windows = filterIgnored(spaces, windows)
+	return buildDisplayGroups(spaces, windows, displays)
+}
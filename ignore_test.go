@@ -0,0 +1,41 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFilterIgnored(t *testing.T) {
+	defer func(apps, labels *regexp.Regexp) { ignoreApps, ignoreSpaceLabels = apps, labels }(ignoreApps, ignoreSpaceLabels)
+	spaces := []Space{{Index: 1}, {Index: 2, Label: "scratch"}, {Index: 3}}
+	windows := []Window{
+		{ID: 1, App: "Finder", Space: 1},
+		{ID: 2, App: "kitty", Space: 2},
+		{ID: 3, App: "kitty", Space: 3},
+		{ID: 4, App: "Stickies", Space: 3, IsMinimized: true},
+	}
+
+	ignoreApps, ignoreSpaceLabels = nil, nil
+	if s, w := filterIgnored(spaces, windows); len(s) != 3 || len(w) != 4 {
+		t.Errorf("no rules dropped something: %d spaces, %d windows", len(s), len(w))
+	}
+
+	ignoreApps = regexp.MustCompile(`^(Finder|Stickies)$`)
+	ignoreSpaceLabels = regexp.MustCompile(`^scratch$`)
+	s, w := filterIgnored(spaces, windows)
+	if len(s) != 2 || s[0].Index != 1 || s[1].Index != 3 {
+		t.Errorf("spaces = %+v", s)
+	}
+	if len(w) != 1 || w[0].ID != 3 {
+		t.Errorf("windows = %+v", w)
+	}
+	if len(spaces) != 3 || len(windows) != 4 {
+		t.Error("inputs modified")
+	}
+
+	// space 1 only holds Finder, so it counts as free
+	groups := buildVisibleGroups(spaces, windows, nil)
+	if len(groups) != 1 || groups[0].freeCount != 1 || groups[0].termCount != 1 || len(groups[0].hidden) != 0 {
+		t.Errorf("group = %+v", groups)
+	}
+}
@@ -0,0 +1,225 @@
+// incremental: a refresh only redoes what it changed.
+//
+// every refresh used to regroup every space and walk every tmux client
+// up the process tree to its display, though most ticks change nothing
+// but a title or an activity time. applyRefresh compares the new answers
+// with the previous ones and patches the derived state instead: when the
+// spaces and displays keep their shape, only the rows of spaces whose
+// windows changed are rebuilt (and their column's counts), and the tmux
+// client walk is only redone when clients, terminal windows, the spaces
+// or the process tree changed — panes are re-bucketed against the kept
+// answer. a space created, destroyed, relabeled or moved, a display
+// plugged in, or a window hidden or shown still regroups from scratch,
+// as does the first refresh.
+
+package main
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// refreshInputs is what the derived state is computed from.
+type refreshInputs struct {
+	spaces      []Space
+	windows     []Window
+	displays    []Display
+	panes       []TmuxPane
+	clients     []TmuxClient
+	processTree map[int]int
+}
+
+// refreshInputs captures the model's current inputs, before a refresh
+// replaces them.
+func (m model) refreshInputs() refreshInputs {
+	return refreshInputs{
+		spaces:      m.spaces,
+		windows:     m.windows,
+		displays:    m.displays,
+		panes:       m.tmuxPanes,
+		clients:     m.tmuxClients,
+		processTree: m.processTree,
+	}
+}
+
+// applyRefresh brings displayGroups and the tmux placement up to date
+// with the model's inputs, given the ones they were derived from.
+func (m *model) applyRefresh(prev refreshInputs) {
+	cur := m.refreshInputs()
+	regrouped := false
+	if m.displayGroups == nil || !patchDisplayGroups(m.displayGroups, prev, cur) {
+		m.displayGroups = buildVisibleGroups(m.spaces, m.windows, m.displays)
+		regrouped = true
+	}
+	m.orderSpaces()
+
+	if regrouped || m.sessionDisplays == nil || !sameTmuxPlacement(prev, cur) {
+		m.sessionDisplays, m.detachedWhy = engine.MapTmuxClients(
+			m.tmuxClients, m.processTree, m.windows, groupedSpaces(m.displayGroups))
+	} else if slices.Equal(prev.panes, cur.panes) && m.tmuxByDisplay != nil {
+		return
+	}
+	m.tmuxByDisplay, m.detachedTmux = engine.BucketTmux(m.tmuxPanes, m.sessionDisplays, m.detachedWhy)
+}
+
+// spaceShape is what places a space in the grid.
+type spaceShape struct {
+	id, index, display int
+	label              string
+}
+
+func shapeOf(s Space) spaceShape {
+	return spaceShape{s.ID, s.Index, s.Display, s.Label}
+}
+
+// sameShapes reports whether two space lists place the same spaces in the
+// same places; focus and window lists may differ.
+func sameShapes(a, b []Space) bool {
+	return slices.EqualFunc(a, b, func(x, y Space) bool { return shapeOf(x) == shapeOf(y) })
+}
+
+// placedOnSpace reports whether a window sits in a space's row rather
+// than in a hidden list.
+func placedOnSpace(w Window) bool {
+	return !w.IsHidden && !w.IsMinimized
+}
+
+// changedSpaces lists the spaces whose rows differ between two answers:
+// a window added, removed or changed there, or its windows reordered.
+// ok is false when a hidden or minimized window changed, which moves the
+// hidden lists too.
+func changedSpaces(prev, cur []Window) (dirty map[int]bool, ok bool) {
+	dirty = map[int]bool{}
+	if slices.Equal(prev, cur) {
+		return dirty, true
+	}
+	before := make(map[int]Window, len(prev))
+	for _, w := range prev {
+		before[w.ID] = w
+	}
+	after := make(map[int]bool, len(cur))
+	for _, w := range cur {
+		after[w.ID] = true
+	}
+	// a window's rank among the windows in both answers; a space whose
+	// windows all keep their rank keeps its row order
+	prevRank := map[int]int{}
+	for _, w := range prev {
+		if after[w.ID] {
+			prevRank[w.ID] = len(prevRank)
+		}
+	}
+
+	rank := 0
+	for _, w := range cur {
+		old, seen := before[w.ID]
+		delete(before, w.ID)
+		if seen && old == w && prevRank[w.ID] == rank {
+			rank++
+			continue
+		}
+		if seen {
+			rank++
+		}
+		if !placedOnSpace(w) || (seen && !placedOnSpace(old)) {
+			return nil, false
+		}
+		dirty[w.Space] = true
+		if seen {
+			dirty[old.Space] = true
+		}
+	}
+	for _, old := range before {
+		if !placedOnSpace(old) {
+			return nil, false
+		}
+		dirty[old.Space] = true
+	}
+	return dirty, true
+}
+
+// patchDisplayGroups updates groups in place from prev to cur, rebuilding
+// only the rows whose windows changed. false means the change is beyond
+// a patch and the groups must be rebuilt; they're left untouched then.
+func patchDisplayGroups(groups []displayGroup, prev, cur refreshInputs) bool {
+	if !slices.Equal(prev.displays, cur.displays) || !sameShapes(prev.spaces, cur.spaces) {
+		return false
+	}
+	dirty, ok := changedSpaces(prev.windows, cur.windows)
+	if !ok {
+		return false
+	}
+
+	byIndex := make(map[int]Space, len(cur.spaces))
+	for _, s := range cur.spaces {
+		byIndex[s.Index] = s
+	}
+	var onDirty map[int][]Window
+	if len(dirty) > 0 {
+		onDirty = map[int][]Window{}
+		for _, w := range cur.windows {
+			if dirty[w.Space] && placedOnSpace(w) && !ignoredApp(w.App) {
+				onDirty[w.Space] = append(onDirty[w.Space], w)
+			}
+		}
+	}
+
+	for gi := range groups {
+		dg := &groups[gi]
+		touched := false
+		for ri := range dg.spaces {
+			row := &dg.spaces[ri]
+			row.space = byIndex[row.space.Index]
+			if dirty[row.space.Index] {
+				row.windows = onDirty[row.space.Index]
+				touched = true
+			}
+		}
+		if touched {
+			dg.freeCount, dg.termCount = rowCounts(dg.spaces)
+		}
+	}
+	return true
+}
+
+// rowCounts is a column's free and terminal space counts, as
+// engine.GroupDisplays keeps them.
+func rowCounts(rows []spaceRow) (free, term int) {
+	for _, row := range rows {
+		if len(row.windows) == 0 {
+			free++
+		}
+		if slices.ContainsFunc(row.windows, func(w Window) bool { return engine.IsTerminal(w.App) }) {
+			term++
+		}
+	}
+	return free, term
+}
+
+// terminalPlacement is what the tmux client walk reads from a window.
+type terminalPlacement struct {
+	id, pid, space int
+	app, title     string
+	tty            string
+}
+
+func terminalPlacements(windows []Window) []terminalPlacement {
+	var out []terminalPlacement
+	for _, w := range windows {
+		if engine.IsTerminal(w.App) {
+			out = append(out, terminalPlacement{w.ID, w.PID, w.Space, w.App, w.Title, w.TTY})
+		}
+	}
+	return out
+}
+
+// sameTmuxPlacement reports whether the last client walk still holds:
+// the same clients, terminal windows, spaces and process tree.
+func sameTmuxPlacement(prev, cur refreshInputs) bool {
+	return slices.Equal(prev.clients, cur.clients) &&
+		sameShapes(prev.spaces, cur.spaces) &&
+		slices.Equal(terminalPlacements(prev.windows), terminalPlacements(cur.windows)) &&
+		maps.Equal(prev.processTree, cur.processTree)
+}
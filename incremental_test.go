@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// desk is a refresh's worth of spaces and windows: n spaces over two
+// displays, perSpace windows on each, every third a terminal.
+func desk(n, perSpace int) ([]Space, []Window, []Display) {
+	displays := []Display{{Index: 1, UUID: "a"}, {Index: 2, UUID: "b", Frame: Frame{X: 100, W: 100}}}
+	var spaces []Space
+	var windows []Window
+	for i := 1; i <= n; i++ {
+		spaces = append(spaces, Space{ID: 100 + i, Index: i, Display: 1 + i%2})
+		for j := 0; j < perSpace; j++ {
+			app := "Safari"
+			if (i+j)%3 == 0 {
+				app = "kitty"
+			}
+			windows = append(windows, Window{ID: i*100 + j, PID: 1000 + i, App: app, Title: fmt.Sprint("w", j), Space: i})
+		}
+	}
+	return spaces, windows, displays
+}
+
+func TestPatchMatchesRebuild(t *testing.T) {
+	spaces, windows, displays := desk(12, 3)
+	m := newModel()
+	next, _ := m.handleData(fetchResult{spaces: spaces, windows: windows, displays: displays})
+	m = next.(model)
+
+	rng := rand.New(rand.NewSource(1))
+	for step := 0; step < 200; step++ {
+		windows = append([]Window(nil), windows...)
+		spaces = append([]Space(nil), spaces...)
+		switch i := rng.Intn(len(windows)); rng.Intn(6) {
+		case 0: // retitled
+			windows[i].Title += "!"
+		case 1: // moved to another space
+			windows[i].Space = 1 + rng.Intn(len(spaces))
+		case 2: // closed, keeping a few open
+			if len(windows) > 4 {
+				windows = append(windows[:i], windows[i+1:]...)
+			}
+		case 3: // opened
+			windows = append(windows, Window{ID: 10000 + step, App: "kitty", Space: 1 + rng.Intn(len(spaces))})
+		case 4: // raised: the window manager lists it first
+			w := windows[i]
+			windows = append([]Window{w}, append(windows[:i], windows[i+1:]...)...)
+		case 5: // focus moved
+			for j := range spaces {
+				spaces[j].HasFocus = j == i%len(spaces)
+			}
+		}
+		next, _ = m.handleData(fetchResult{spaces: spaces, windows: windows, displays: displays})
+		m = next.(model)
+		if want := buildVisibleGroups(spaces, windows, displays); !reflect.DeepEqual(m.displayGroups, want) {
+			t.Fatalf("step %d: patched groups differ from a rebuild\n got %+v\nwant %+v", step, m.displayGroups, want)
+		}
+	}
+}
+
+func TestChangedSpaces(t *testing.T) {
+	prev := []Window{{ID: 1, Space: 1}, {ID: 2, Space: 2}, {ID: 3, Space: 3}}
+	cases := []struct {
+		name string
+		cur  []Window
+		want map[int]bool
+		ok   bool
+	}{
+		{"unchanged", prev, map[int]bool{}, true},
+		{"moved", []Window{{ID: 1, Space: 1}, {ID: 2, Space: 3}, {ID: 3, Space: 3}}, map[int]bool{2: true, 3: true}, true},
+		{"closed", []Window{{ID: 1, Space: 1}, {ID: 3, Space: 3}}, map[int]bool{2: true}, true},
+		{"opened first", []Window{{ID: 4, Space: 2}, {ID: 1, Space: 1}, {ID: 2, Space: 2}, {ID: 3, Space: 3}}, map[int]bool{2: true}, true},
+		{"reordered", []Window{{ID: 2, Space: 2}, {ID: 1, Space: 1}, {ID: 3, Space: 3}}, map[int]bool{1: true, 2: true}, true},
+		{"minimized", []Window{{ID: 1, Space: 1, IsMinimized: true}, {ID: 2, Space: 2}, {ID: 3, Space: 3}}, nil, false},
+	}
+	for _, c := range cases {
+		got, ok := changedSpaces(prev, c.cur)
+		if ok != c.ok || (ok && !reflect.DeepEqual(got, c.want)) {
+			t.Errorf("%s: got %v, %v; want %v, %v", c.name, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestTmuxWalkKept(t *testing.T) {
+	spaces, windows, displays := desk(2, 1)
+	panes := []TmuxPane{{SessionName: "api", PanePID: 1}}
+	m := newModel()
+	next, _ := m.handleData(fetchResult{spaces: spaces, windows: windows, displays: displays, tmuxPanes: panes})
+	m = next.(model)
+
+	// stand in for a walk that placed api on display 2: a refresh that
+	// only changes a pane re-buckets against it rather than walking again
+	m.sessionDisplays = map[string]int{"api": 2}
+	panes = []TmuxPane{{SessionName: "api", PanePID: 1, HistorySize: 10}}
+	next, _ = m.handleData(fetchResult{spaces: spaces, windows: windows, displays: displays, tmuxPanes: panes})
+	m = next.(model)
+	if got := m.tmuxByDisplay[2]; len(got) != 1 || got[0].HistorySize != 10 {
+		t.Fatalf("tmuxByDisplay = %+v, want the new pane on display 2", m.tmuxByDisplay)
+	}
+
+	// a new client walks again
+	next, _ = m.handleData(fetchResult{spaces: spaces, windows: windows, displays: displays, tmuxPanes: panes,
+		tmuxClients: []TmuxClient{{PID: 5, SessionName: "api"}}})
+	m = next.(model)
+	if len(m.detachedTmux) != 1 {
+		t.Errorf("detached = %+v, want api unplaced after the walk", m.detachedTmux)
+	}
+}
+
+func BenchmarkRefresh(b *testing.B) {
+	spaces, windows, displays := desk(40, 10)
+	retitled := append([]Window(nil), windows...)
+	retitled[0].Title = "changed"
+	answers := [][]Window{windows, retitled}
+
+	b.Run("rebuild", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			buildVisibleGroups(spaces, answers[i%2], displays)
+		}
+	})
+	b.Run("patch", func(b *testing.B) {
+		m := newModel()
+		next, _ := m.handleData(fetchResult{spaces: spaces, windows: windows, displays: displays})
+		m = next.(model)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			prev := m.refreshInputs()
+			m.windows = answers[(i+1)%2]
+			m.applyRefresh(prev)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+// keys: the dashboard's keybindings, remappable from config.go.
+//
+// every dashboard key names an action (down, swap, agents, ...) and the
+// keymap turns keypresses into actions. keyBindings in config.go replaces
+// the keys of any action, and a binding can be a sequence of keys written
+// space-separated ("g g", "ctrl+w l"): the first keys of a sequence wait
+// for the rest, and anything that doesn't continue it starts over; the
+// space bar is written "space". help text is built from the active
+// keymap, so it always shows what's bound.
+//
+// a few keys stay fixed: ctrl+c quits, esc backs out of whatever is open,
+// digits jump to spaces or count (bookmarks.go), and y / n answer
+// confirmations.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dashboard actions
+const (
+	actQuit       = "quit"
+	actDown       = "down"
+	actUp         = "up"
+	actLeft       = "left"
+	actRight      = "right"
+	actTop        = "top"
+	actBottom     = "bottom"
+	actFocus      = "focus"
+	actDetail     = "detail"
+	actSwap       = "swap"
+	actDestroy    = "destroy"
+	actKill       = "kill"
+	actRename     = "rename"
+	actMirror     = "mirror"
+	actFollow     = "follow"
+	actAgents     = "agents"
+	actDensity    = "density"
+	actCompare    = "compare"
+	actAck        = "ack"
+	actHidden     = "hidden"
+	actRestore    = "restore"
+	actFold       = "fold"
+	actScratchpad = "scratchpad"
+	actBalance    = "balance"
+	actTimer      = "timer"
+	actSort       = "sort"
+	actPin        = "pin"
+	actLabel      = "label"
+	actNew        = "new"
+	actCleanup    = "cleanup"
+	actMark       = "mark"
+	actMoveMarked = "move-marked"
+	actSetMark    = "set-mark"
+	actJumpMark   = "jump-mark"
+	actZoom       = "zoom"
+)
+
+// defaultKeyBindings are the vim-flavored defaults; the first key of each
+// action is the one help shows.
+var defaultKeyBindings = map[string][]string{
+	actQuit:       {"q"},
+	actDown:       {"j", "down"},
+	actUp:         {"k", "up"},
+	actLeft:       {"h", "left"},
+	actRight:      {"l", "right"},
+	actTop:        {"g"},
+	actBottom:     {"G"},
+	actFocus:      {"enter"},
+	actDetail:     {"i"},
+	actSwap:       {"S"},
+	actDestroy:    {"D"},
+	actKill:       {"X"},
+	actRename:     {"R"},
+	actMirror:     {"v"},
+	actFollow:     {"f"},
+	actAgents:     {"a"},
+	actDensity:    {"z"},
+	actCompare:    {"C"},
+	actAck:        {"A"},
+	actHidden:     {"H"},
+	actRestore:    {"U"},
+	actFold:       {"O"},
+	actScratchpad: {"P"},
+	actBalance:    {"B"},
+	actTimer:      {"t"},
+	actSort:       {"s"},
+	actPin:        {"p"},
+	actLabel:      {"L"},
+	actNew:        {"N"},
+	actCleanup:    {"c"},
+	actMark:       {"space"},
+	actMoveMarked: {"M"},
+	actSetMark:    {"m"},
+	actJumpMark:   {"'"},
+	actZoom:       {"o"},
+}
+
+// reservedKeys can't be rebound; see the file comment.
+var reservedKeys = []string{"ctrl+c", "esc", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+
+// keymap resolves key sequences to actions.
+type keymap struct {
+	keys     map[string][]string // action → sequences, in configured order
+	actions  map[string]string   // sequence → action
+	prefixes map[string]bool     // proper prefixes of multi-key sequences
+}
+
+// normalizeKeySeq collapses a binding's whitespace: "g  g" → "g g".
+func normalizeKeySeq(seq string) string {
+	return strings.Join(strings.Fields(seq), " ")
+}
+
+// buildKeymap layers overrides (action → keys, replacing that action's
+// defaults) on the defaults and rejects ambiguous maps: one sequence on
+// two actions, or a sequence that is a prefix of another, which could
+// never fire.
+func buildKeymap(overrides map[string][]string) (*keymap, error) {
+	km := &keymap{keys: map[string][]string{}, actions: map[string]string{}, prefixes: map[string]bool{}}
+	for action, keys := range defaultKeyBindings {
+		km.keys[action] = keys
+	}
+	for action, keys := range overrides {
+		if _, ok := defaultKeyBindings[action]; !ok {
+			return nil, fmt.Errorf("unknown key action %q", action)
+		}
+		km.keys[action] = keys
+	}
+
+	actions := make([]string, 0, len(km.keys))
+	for action := range km.keys {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions) // deterministic error messages
+	for _, action := range actions {
+		normalized := make([]string, 0, len(km.keys[action]))
+		for _, k := range km.keys[action] {
+			seq := normalizeKeySeq(k)
+			if seq == "" {
+				return nil, fmt.Errorf("key action %s: empty binding", action)
+			}
+			if slices.Contains(reservedKeys, strings.Fields(seq)[0]) {
+				return nil, fmt.Errorf("key action %s: %q is reserved", action, strings.Fields(seq)[0])
+			}
+			if other, ok := km.actions[seq]; ok && other != action {
+				return nil, fmt.Errorf("key %q bound to both %s and %s", seq, other, action)
+			}
+			km.actions[seq] = action
+			normalized = append(normalized, seq)
+			parts := strings.Fields(seq)
+			for i := 1; i < len(parts); i++ {
+				km.prefixes[strings.Join(parts[:i], " ")] = true
+			}
+		}
+		km.keys[action] = normalized
+	}
+	for seq := range km.prefixes {
+		if action, ok := km.actions[seq]; ok {
+			return nil, fmt.Errorf("key %q (%s) is also the start of a longer binding", seq, action)
+		}
+	}
+	return km, nil
+}
+
+// activeKeymap is replaced in main once keyBindings is validated.
+var activeKeymap, _ = buildKeymap(nil)
+
+// resolve feeds one key after the pending ones. it returns the action a
+// complete sequence names, or the keys still pending when key continues a
+// sequence. a key that breaks a sequence is retried on its own.
+func (km *keymap) resolve(pending []string, key string) (string, []string) {
+	seq := strings.Join(append(slices.Clone(pending), key), " ")
+	if action, ok := km.actions[seq]; ok {
+		return action, nil
+	}
+	if km.prefixes[seq] {
+		return "", append(slices.Clone(pending), key)
+	}
+	if len(pending) > 0 {
+		return km.resolve(nil, key)
+	}
+	return "", nil
+}
+
+// keyName is a key as bindings write it: the space bar, which bubbletea
+// calls " ", is "space", since bindings are split on spaces.
+func keyName(msg tea.KeyMsg) string {
+	if msg.Type == tea.KeySpace {
+		return "space"
+	}
+	return msg.String()
+}
+
+// action is the action a single key is bound to, for the modal views
+// that don't take sequences; "" when none.
+func (km *keymap) action(key string) string {
+	return km.actions[key]
+}
+
+// label shows the primary key of each action, slash-separated ("j/k").
+func (km *keymap) label(actions ...string) string {
+	parts := make([]string, 0, len(actions))
+	for _, a := range actions {
+		if keys := km.keys[a]; len(keys) > 0 {
+			parts = append(parts, keys[0])
+		}
+	}
+	return strings.Join(parts, "/")
+}
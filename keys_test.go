@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestKeymapSequences(t *testing.T) {
+	km, err := buildKeymap(map[string][]string{
+		"down": {"n", "down"},
+		"up":   {"e"},
+		"top":  {"g  g"},
+		"kill": {"ctrl+w x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if action, pending := km.resolve(nil, "n"); action != actDown || pending != nil {
+		t.Errorf("n = %q %v, want down", action, pending)
+	}
+	if action, _ := km.resolve(nil, "j"); action != "" {
+		t.Errorf("j still bound to %q after remapping down", action)
+	}
+
+	action, pending := km.resolve(nil, "g")
+	if action != "" || len(pending) != 1 {
+		t.Fatalf("g = %q %v, want pending", action, pending)
+	}
+	if action, pending := km.resolve(pending, "g"); action != actTop || pending != nil {
+		t.Errorf("g g = %q %v, want top", action, pending)
+	}
+	// a key that breaks the sequence is taken on its own
+	if action, pending := km.resolve([]string{"g"}, "e"); action != actUp || pending != nil {
+		t.Errorf("g e = %q %v, want up", action, pending)
+	}
+
+	if got := km.label(actDown, actUp); got != "n/e" {
+		t.Errorf("label = %q, want n/e", got)
+	}
+	if got := km.label(actKill); got != "ctrl+w x" {
+		t.Errorf("label = %q", got)
+	}
+}
+
+func TestBuildKeymapRejects(t *testing.T) {
+	bad := []map[string][]string{
+		{"fly": {"x"}},
+		{"down": {"a"}},                   // a is agents
+		{"top": {"g"}, "bottom": {"g g"}}, // g could never wait for the second g
+		{"down": {"esc"}},                 // reserved
+		{"down": {"  "}},
+	}
+	for _, b := range bad {
+		if _, err := buildKeymap(b); err == nil {
+			t.Errorf("buildKeymap(%v) accepted", b)
+		}
+	}
+}
+
+func TestRemappedKeysDriveTheGrid(t *testing.T) {
+	saved := activeKeymap
+	defer func() { activeKeymap = saved }()
+	km, err := buildKeymap(map[string][]string{"down": {"n"}, "bottom": {"ctrl+w G"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	activeKeymap = km
+
+	m := newModel()
+	m.displayGroups = []displayGroup{{spaces: []spaceRow{{}, {}, {}}}}
+	press := func(m model, k tea.KeyMsg) model {
+		next, _ := m.handleKey(k)
+		return next.(model)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if m.cursorRow != 1 {
+		t.Fatalf("n moved to row %d, want 1", m.cursorRow)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyCtrlW})
+	if len(m.pendingKeys) != 1 || !strings.Contains(m.renderPrompt(), "ctrl+w") {
+		t.Fatalf("ctrl+w should be pending, prompt %q", m.renderPrompt())
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}})
+	if m.cursorRow != 2 || m.pendingKeys != nil {
+		t.Errorf("ctrl+w G: row %d pending %v", m.cursorRow, m.pendingKeys)
+	}
+	if help := renderHelp(1); !strings.Contains(help, "n/k") {
+		t.Errorf("help doesn't show the remapped keys: %q", help)
+	}
+	for displays, want := range map[int]bool{1: false, 2: true, 3: false} {
+		if got := strings.Contains(renderHelp(displays), "\u21e7"); got != want {
+			t.Errorf("%d displays: shift-jump shown = %v, want %v", displays, got, want)
+		}
+	}
+}
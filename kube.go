@@ -0,0 +1,170 @@
+// kube: which cluster a pane's kubectl / k9s / helm is pointed at.
+//
+// a k9s left open against prod is easy to forget about. for every pane
+// whose process tree runs one of kubeTools, stop works out the context
+// the tool is using — a --context flag on its command line, else the
+// current-context of the kubeconfig it was started with (its KUBECONFIG,
+// or ~/.kube/config) — and shows a ⎈ badge colored by kubeContextColors
+// in config.go.
+//
+// the kubeconfig is read now, not when the tool started: a
+// `kubectl config use-context` since then shows the new context even
+// though a running k9s stays on the old one. passing --context avoids the
+// ambiguity.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// kubeTools are the commands whose cluster is worth a badge.
+var kubeTools = map[string]bool{"kubectl": true, "k9s": true, "helm": true}
+
+// kubeContextColor colors contexts whose name contains match.
+type kubeContextColor struct {
+	match string
+	color string
+}
+
+// kubeContextFromArgs finds --context / --kube-context (helm) in a
+// command line.
+func kubeContextFromArgs(args []string) string {
+	for i, a := range args {
+		for _, flag := range []string{"--context", "--kube-context"} {
+			if a == flag && i+1 < len(args) {
+				return args[i+1]
+			}
+			if v, ok := strings.CutPrefix(a, flag+"="); ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// parseCurrentContext pulls the top-level current-context out of a
+// kubeconfig without a yaml parser; it's always a plain top-level key.
+func parseCurrentContext(data string) string {
+	sc := bufio.NewScanner(strings.NewReader(data))
+	for sc.Scan() {
+		v, ok := strings.CutPrefix(sc.Text(), "current-context:")
+		if !ok {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(v), `"'`)
+	}
+	return ""
+}
+
+// kubeconfigContext reads the current context for a KUBECONFIG value
+// (possibly a path list; the first file that sets one wins, as kubectl
+// does) or ~/.kube/config when it's empty.
+func kubeconfigContext(kubeconfig string) string {
+	paths := filepath.SplitList(kubeconfig)
+	if len(paths) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		paths = []string{filepath.Join(home, ".kube", "config")}
+	}
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		if ctx := parseCurrentContext(string(data)); ctx != "" {
+			return ctx
+		}
+	}
+	return ""
+}
+
+// readProcessArgs returns a process's command line.
+func readProcessArgs(pid int) []string {
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid)); err == nil {
+		return strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ps", "-o", "args=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// queryPaneKube resolves the kube context for every pane running a kube
+// tool, keyed by pane pid. the shallowest tool process in the pane wins.
+func queryPaneKube(panes []TmuxPane, processTree map[int]int, processComm map[int]string) map[int]string {
+	if len(panes) == 0 || processTree == nil {
+		return nil
+	}
+	children := map[int][]int{}
+	for pid, ppid := range processTree {
+		children[ppid] = append(children[ppid], pid)
+	}
+
+	result := map[int]string{}
+	for _, p := range panes {
+		for _, pid := range paneProcesses(p.PanePID, children) {
+			if !kubeTools[filepath.Base(processComm[pid])] {
+				continue
+			}
+			ctx := kubeContextFromArgs(readProcessArgs(pid))
+			if ctx == "" {
+				kubeconfig := readProcessEnv([]int{pid}, []string{"KUBECONFIG"})[pid]["KUBECONFIG"]
+				ctx = kubeconfigContext(kubeconfig)
+			}
+			if ctx != "" {
+				result[p.PanePID] = ctx
+			}
+			break
+		}
+	}
+	return result
+}
+
+// kubeContextStyle picks the configured color for a context, or the env
+// badge style when none matches.
+func kubeContextStyle(ctx string) lipgloss.Style {
+	lower := strings.ToLower(ctx)
+	for _, c := range kubeContextColors {
+		if !strings.Contains(lower, strings.ToLower(c.match)) {
+			continue
+		}
+		color, err := resolveColor(c.color)
+		if err != nil || color == "" {
+			break
+		}
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Bold(true)
+	}
+	return envBadgeStyle
+}
+
+// renderPaneBadges renders a pane's agent state, environment badges and
+// kube context; "" when it has none of them.
+func renderPaneBadges(rc renderContext, panePID int) string {
+	var parts []string
+	if state := renderPaneState(rc.paneStates[panePID]); state != "" {
+		parts = append(parts, state)
+	}
+	if badges := renderEnvBadges(rc.paneEnv[panePID]); badges != "" {
+		parts = append(parts, badges)
+	}
+	if ctx := rc.paneKube[panePID]; ctx != "" {
+		parts = append(parts, kubeContextStyle(ctx).Render("⎈ "+ctx))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKubeContextFromArgs(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"k9s", "--context", "prod-eu"}, "prod-eu"},
+		{[]string{"kubectl", "get", "pods", "--context=staging"}, "staging"},
+		{[]string{"helm", "upgrade", "--kube-context", "dev", "app"}, "dev"},
+		{[]string{"k9s"}, ""},
+		{[]string{"kubectl", "--context"}, ""},
+	}
+	for _, c := range cases {
+		if got := kubeContextFromArgs(c.args); got != c.want {
+			t.Errorf("kubeContextFromArgs(%v) = %q, want %q", c.args, got, c.want)
+		}
+	}
+}
+
+func TestKubeconfigContext(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.yaml")
+	prod := filepath.Join(dir, "prod.yaml")
+	os.WriteFile(empty, []byte("apiVersion: v1\nclusters: []\n"), 0o644)
+	os.WriteFile(prod, []byte("apiVersion: v1\ncontexts:\n- name: a\n  context:\n    cluster: a\ncurrent-context: \"prod-us\"\nkind: Config\n"), 0o644)
+
+	// the first file in the list that sets a context wins
+	if got := kubeconfigContext(empty + string(filepath.ListSeparator) + prod); got != "prod-us" {
+		t.Errorf("kubeconfigContext = %q, want prod-us", got)
+	}
+	if got := kubeconfigContext(filepath.Join(dir, "missing")); got != "" {
+		t.Errorf("missing kubeconfig = %q", got)
+	}
+}
+
+func TestRenderPaneBadges(t *testing.T) {
+	rc := renderContext{
+		paneEnv:  map[int]map[string]string{1: {"AWS_PROFILE": "dev"}},
+		paneKube: map[int]string{1: "prod-eu", 2: "kind-local"},
+	}
+	got := renderPaneBadges(rc, 1)
+	if !strings.Contains(got, "aws:dev") || !strings.Contains(got, "⎈ prod-eu") {
+		t.Errorf("badges = %q", got)
+	}
+	if strings.Index(got, "aws:") > strings.Index(got, "⎈") {
+		t.Errorf("kube badge should follow env badges: %q", got)
+	}
+	if got := renderPaneBadges(rc, 3); got != "" {
+		t.Errorf("pane without badges = %q", got)
+	}
+	if kubeContextStyle("kind-local").GetBold() {
+		t.Error("unmatched context got a configured color")
+	}
+	if !kubeContextStyle("PROD-eu").GetBold() {
+		t.Error("prod context should match case-insensitively")
+	}
+}
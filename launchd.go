@@ -0,0 +1,172 @@
+// launchd: run `stop serve` as a login service on macOS.
+//
+// the companion app polls `stop serve`, and a server started by hand in
+// a terminal is gone after the next reboot. `stop serve install` writes
+// a LaunchAgent plist that runs this binary's serve mode at login and
+// keeps it alive, with whatever serve flags followed install (-port,
+// -no-yabai, -read-only, ...), and loads it; `stop serve uninstall`
+// unloads and removes it and `stop serve status` says whether it's
+// running. the job's output goes to ~/Library/Logs/stop/serve.log. the
+// PATH the job gets is the one install ran with, since launchd's own
+// doesn't reach homebrew's yabai and tmux.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// serviceCommands are the words after `stop serve` that manage the
+// service instead of serving.
+var serviceCommands = map[string]bool{"install": true, "uninstall": true, "status": true}
+
+// servicePaths are where the plist and the log go.
+func servicePaths() (plist, logFile string, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("finding home directory: %w", err)
+	}
+	plist = filepath.Join(home, "Library", "LaunchAgents", serviceLabel+".plist")
+	logFile = filepath.Join(home, "Library", "Logs", "stop", "serve.log")
+	return plist, logFile, nil
+}
+
+// serviceCommand runs `stop serve install|uninstall|status`. serveArgs
+// are the serve flags install bakes into the job.
+func serviceCommand(command string, serveArgs []string) error {
+	if runtime.GOOS != "darwin" {
+		return fmt.Errorf("stop serve %s: launchd services are macOS only", command)
+	}
+	plist, logFile, err := servicePaths()
+	if err != nil {
+		return err
+	}
+	domain := fmt.Sprintf("gui/%d", os.Getuid())
+	switch command {
+	case "install":
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("finding the stop binary: %w", err)
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		if err := os.MkdirAll(filepath.Dir(plist), 0o755); err != nil {
+			return fmt.Errorf("creating LaunchAgents: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(logFile), 0o755); err != nil {
+			return fmt.Errorf("creating log directory: %w", err)
+		}
+		args := append([]string{exe, "serve"}, serveArgs...)
+		if err := os.WriteFile(plist, []byte(renderLaunchdPlist(args, os.Getenv("PATH"), logFile)), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", plist, err)
+		}
+		// reinstalling replaces a loaded job; bootout fails when there's none
+		launchctl("bootout", domain+"/"+serviceLabel)
+		if out, err := launchctl("bootstrap", domain, plist); err != nil {
+			return fmt.Errorf("loading %s: %w: %s", plist, err, strings.TrimSpace(out))
+		}
+		fmt.Printf("installed %s\n  runs: %s\n  logs: %s\n", plist, strings.Join(args, " "), logFile)
+		return nil
+	case "uninstall":
+		launchctl("bootout", domain+"/"+serviceLabel)
+		if err := os.Remove(plist); err != nil {
+			if os.IsNotExist(err) {
+				fmt.Println("not installed.")
+				return nil
+			}
+			return fmt.Errorf("removing %s: %w", plist, err)
+		}
+		fmt.Printf("uninstalled %s\n", plist)
+		return nil
+	case "status":
+		if _, err := os.Stat(plist); err != nil {
+			fmt.Println("not installed.")
+			return nil
+		}
+		out, err := launchctl("print", domain+"/"+serviceLabel)
+		state, pid := "not loaded", ""
+		if err == nil {
+			state, pid = parseLaunchctlPrint(out)
+		}
+		fmt.Printf("%s: %s", serviceLabel, state)
+		if pid != "" {
+			fmt.Printf(" (pid %s)", pid)
+		}
+		fmt.Printf("\n  plist: %s\n  logs:  %s\n", plist, logFile)
+		return nil
+	}
+	return fmt.Errorf("unknown serve command %q", command)
+}
+
+// launchctl runs one launchctl command, returning its combined output.
+func launchctl(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, "launchctl", args...).CombinedOutput()
+	engine.LogCommand(start, "launchctl", args, err)
+	return string(out), err
+}
+
+// renderLaunchdPlist is the LaunchAgent running args at login, restarted
+// whenever it exits, with output appended to logFile.
+func renderLaunchdPlist(args []string, path, logFile string) string {
+	esc := func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", esc(serviceLabel))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, a := range args {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", esc(a))
+	}
+	b.WriteString("\t</array>\n")
+	if path != "" {
+		fmt.Fprintf(&b, "\t<key>EnvironmentVariables</key>\n\t<dict>\n\t\t<key>PATH</key>\n\t\t<string>%s</string>\n\t</dict>\n", esc(path))
+	}
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n\t<key>KeepAlive</key>\n\t<true/>\n")
+	fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", esc(logFile))
+	fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", esc(logFile))
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// parseLaunchctlPrint finds the job's state and pid in `launchctl print`.
+func parseLaunchctlPrint(out string) (state, pid string) {
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(sc.Text()), " = ")
+		if !ok {
+			continue
+		}
+		switch {
+		case key == "state" && state == "":
+			state = value
+		case key == "pid" && pid == "":
+			pid = value
+		}
+	}
+	if state == "" {
+		state = "loaded"
+	}
+	return state, pid
+}
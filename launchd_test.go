@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLaunchdPlist(t *testing.T) {
+	plist := renderLaunchdPlist([]string{"/opt/stop & co/stop", "serve", "-port", "9000"}, "/opt/homebrew/bin:/usr/bin", "/Users/me/Library/Logs/stop/serve.log")
+	for _, want := range []string{
+		"<string>" + serviceLabel + "</string>",
+		"<string>/opt/stop &amp; co/stop</string>\n\t\t<string>serve</string>\n\t\t<string>-port</string>\n\t\t<string>9000</string>",
+		"<string>/opt/homebrew/bin:/usr/bin</string>",
+		"<key>KeepAlive</key>\n\t<true/>",
+		"<key>StandardErrorPath</key>\n\t<string>/Users/me/Library/Logs/stop/serve.log</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist is missing %q:\n%s", want, plist)
+		}
+	}
+	// it's well-formed xml
+	d := xml.NewDecoder(strings.NewReader(plist))
+	for {
+		if _, err := d.Token(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("plist doesn't parse: %v", err)
+			}
+			break
+		}
+	}
+}
+
+func TestParseLaunchctlPrint(t *testing.T) {
+	out := "gui/501/com.fadedlamp42.stop.serve = {\n\tactive count = 1\n\tpath = /Users/me/Library/LaunchAgents/x.plist\n\tstate = running\n\n\tprogram = /usr/local/bin/stop\n\tpid = 4312\n\tendpoints = {\n\t\tstate = idle\n\t}\n}\n"
+	if state, pid := parseLaunchctlPrint(out); state != "running" || pid != "4312" {
+		t.Errorf("state %q, pid %q", state, pid)
+	}
+	if state, pid := parseLaunchctlPrint("gui/501/x = {\n}\n"); state != "loaded" || pid != "" {
+		t.Errorf("bare job: state %q, pid %q", state, pid)
+	}
+}
@@ -0,0 +1,282 @@
+// layout: put windows back on the spaces they were on.
+//
+// unplugging a display makes the window manager pile its spaces' windows
+// somewhere else, and plugging it back in doesn't undo that. `stop layout
+// save <name>` records which space every window is on — by the space's
+// label when it has one, its index otherwise — and `stop layout restore
+// <name>` moves the windows that are still open back there. windows are
+// matched by id first (stable until the app restarts), then by app and
+// title, then by app alone, each window claimed once. windows whose
+// recorded space no longer exists, and saved windows that are gone, are
+// reported and left alone. -dry-run prints the moves without making them.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const layoutSchema = `
+CREATE TABLE IF NOT EXISTS layouts (
+	name TEXT NOT NULL,
+	saved_at TEXT NOT NULL,
+	window_id INTEGER NOT NULL,
+	app TEXT NOT NULL,
+	title TEXT NOT NULL,
+	space_index INTEGER NOT NULL,
+	space_label TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_layouts_name
+	ON layouts (name);
+`
+
+// layoutEntry is one saved window.
+type layoutEntry struct {
+	WindowID   int
+	App        string
+	Title      string
+	SpaceIndex int
+	SpaceLabel string
+}
+
+// layoutMove is one window going back to its space.
+type layoutMove struct {
+	window Window
+	to     int // space index
+	entry  layoutEntry
+}
+
+// captureLayout lists where every placed window is. hidden, minimized
+// and sticky windows belong to no one space and are skipped.
+func captureLayout(spaces []Space, windows []Window) []layoutEntry {
+	labels := map[int]string{}
+	for _, s := range spaces {
+		labels[s.Index] = s.Label
+	}
+	var entries []layoutEntry
+	for _, w := range windows {
+		if w.Space <= 0 || w.IsHidden || w.IsMinimized || w.IsSticky {
+			continue
+		}
+		entries = append(entries, layoutEntry{
+			WindowID: w.ID, App: w.App, Title: w.Title,
+			SpaceIndex: w.Space, SpaceLabel: labels[w.Space],
+		})
+	}
+	return entries
+}
+
+// layoutTarget resolves a saved space: by label, else by index.
+func layoutTarget(e layoutEntry, spaces []Space) (int, bool) {
+	if e.SpaceLabel != "" {
+		for _, s := range spaces {
+			if s.Label == e.SpaceLabel {
+				return s.Index, true
+			}
+		}
+	}
+	for _, s := range spaces {
+		if s.Index == e.SpaceIndex {
+			return s.Index, true
+		}
+	}
+	return 0, false
+}
+
+// planLayoutRestore matches saved windows to open ones and lists the
+// moves that put them back, plus the entries that couldn't be placed.
+func planLayoutRestore(entries []layoutEntry, spaces []Space, windows []Window) (moves []layoutMove, missing []layoutEntry) {
+	claimed := map[int]bool{}
+	matched := make([]*Window, len(entries))
+	passes := []func(e layoutEntry, w Window) bool{
+		func(e layoutEntry, w Window) bool { return w.ID == e.WindowID && w.App == e.App },
+		func(e layoutEntry, w Window) bool { return w.App == e.App && w.Title == e.Title },
+		func(e layoutEntry, w Window) bool { return w.App == e.App },
+	}
+	for _, match := range passes {
+		for i, e := range entries {
+			if matched[i] != nil {
+				continue
+			}
+			for j, w := range windows {
+				if !claimed[w.ID] && w.Space > 0 && !w.IsSticky && match(e, w) {
+					claimed[w.ID] = true
+					matched[i] = &windows[j]
+					break
+				}
+			}
+		}
+	}
+
+	for i, e := range entries {
+		to, ok := layoutTarget(e, spaces)
+		if matched[i] == nil || !ok {
+			missing = append(missing, e)
+			continue
+		}
+		if matched[i].Space != to {
+			moves = append(moves, layoutMove{window: *matched[i], to: to, entry: e})
+		}
+	}
+	return moves, missing
+}
+
+// saveLayout replaces a named layout.
+func saveLayout(db *sql.DB, name string, entries []layoutEntry, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM layouts WHERE name = ?", name); err != nil {
+		return fmt.Errorf("clearing layout: %w", err)
+	}
+	at := now.UTC().Format(time.RFC3339)
+	for _, e := range entries {
+		if _, err := tx.Exec(
+			"INSERT INTO layouts (name, saved_at, window_id, app, title, space_index, space_label) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			name, at, e.WindowID, e.App, e.Title, e.SpaceIndex, e.SpaceLabel,
+		); err != nil {
+			return fmt.Errorf("saving window %d: %w", e.WindowID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// loadLayout reads a named layout in save order; an unknown name is an
+// error.
+func loadLayout(db *sql.DB, name string) ([]layoutEntry, error) {
+	rows, err := db.Query(
+		"SELECT window_id, app, title, space_index, space_label FROM layouts WHERE name = ? ORDER BY rowid", name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []layoutEntry
+	for rows.Next() {
+		var e layoutEntry
+		if err := rows.Scan(&e.WindowID, &e.App, &e.Title, &e.SpaceIndex, &e.SpaceLabel); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no layout named %q", name)
+	}
+	return entries, nil
+}
+
+// layoutNames lists saved layouts with their window counts and save
+// times, newest first.
+func layoutNames(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT name, COUNT(*), MAX(saved_at) FROM layouts GROUP BY name ORDER BY MAX(saved_at) DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var lines []string
+	for rows.Next() {
+		var name, at string
+		var n int
+		if err := rows.Scan(&name, &n, &at); err != nil {
+			return nil, err
+		}
+		saved, _ := time.Parse(time.RFC3339, at)
+		lines = append(lines, fmt.Sprintf("%-16s  %3d windows  saved %s", name, n, saved.Local().Format("2006-01-02 15:04")))
+	}
+	return lines, rows.Err()
+}
+
+// layoutCommand is the entry point for `stop layout`.
+func layoutCommand(args []string, dryRun bool) error {
+	if len(args) == 0 || (args[0] != "list" && len(args) != 2) {
+		return fmt.Errorf("usage: stop layout list | stop layout save <name> | stop layout [-dry-run] restore <name>")
+	}
+	db, err := openSnapshotDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "list":
+		lines, err := layoutNames(db)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			fmt.Println("no layouts saved.")
+		}
+		for _, l := range lines {
+			fmt.Println(l)
+		}
+		return nil
+	case "save", "restore":
+	default:
+		return fmt.Errorf("unknown layout command %q", args[0])
+	}
+
+	wm := currentWM()
+	spaces, err := wm.QuerySpaces()
+	if err != nil {
+		return fmt.Errorf("querying spaces: %w", err)
+	}
+	windows, err := wm.QueryWindows()
+	if err != nil {
+		return fmt.Errorf("querying windows: %w", err)
+	}
+
+	name := args[1]
+	if args[0] == "save" {
+		entries := captureLayout(spaces, windows)
+		if err := saveLayout(db, name, entries, time.Now()); err != nil {
+			return err
+		}
+		fmt.Printf("saved %d windows as %q\n", len(entries), name)
+		return nil
+	}
+
+	entries, err := loadLayout(db, name)
+	if err != nil {
+		return err
+	}
+	moves, missing := planLayoutRestore(entries, spaces, windows)
+	failed := 0
+	for _, mv := range moves {
+		line := fmt.Sprintf("%s %q: space %d -> %d", mv.window.App, truncateStr(mv.window.Title, 40), mv.window.Space, mv.to)
+		if dryRun {
+			fmt.Println("would move " + line)
+			continue
+		}
+		if err := wm.MoveWindow(mv.window.ID, mv.to); err != nil {
+			fmt.Printf("failed %s: %v\n", line, err)
+			failed++
+			continue
+		}
+		fmt.Println("moved " + line)
+	}
+	for _, e := range missing {
+		fmt.Printf("skipped %s %q: window or space %s gone\n", e.App, truncateStr(e.Title, 40), layoutSpaceName(e))
+	}
+	if len(moves) == 0 {
+		fmt.Println("every window is already in place.")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d moves failed", failed, len(moves))
+	}
+	return nil
+}
+
+// layoutSpaceName is how a saved space is referred to.
+func layoutSpaceName(e layoutEntry) string {
+	if e.SpaceLabel != "" {
+		return e.SpaceLabel
+	}
+	return fmt.Sprintf("%d", e.SpaceIndex)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanLayoutRestore(t *testing.T) {
+	saved := captureLayout(
+		[]Space{{Index: 1, Label: "code"}, {Index: 2}, {Index: 3, Label: "chat"}},
+		[]Window{
+			{ID: 10, App: "kitty", Title: "api", Space: 1},
+			{ID: 11, App: "Safari", Title: "docs", Space: 2},
+			{ID: 12, App: "Slack", Title: "general", Space: 3},
+			{ID: 13, App: "Notes", Title: "todo", Space: 2},
+			{ID: 14, App: "Music", Space: 1, IsMinimized: true},
+		},
+	)
+	if len(saved) != 4 || saved[0].SpaceLabel != "code" || saved[1].SpaceLabel != "" {
+		t.Fatalf("captured = %+v", saved)
+	}
+
+	// after a reshuffle: "chat" is now space 2, everything piled on 1,
+	// Safari restarted (new id, same title), Slack's title changed and
+	// Notes quit
+	spaces := []Space{{Index: 1, Label: "code"}, {Index: 2, Label: "chat"}, {Index: 3}}
+	windows := []Window{
+		{ID: 10, App: "kitty", Title: "api", Space: 1},
+		{ID: 21, App: "Safari", Title: "docs", Space: 1},
+		{ID: 12, App: "Slack", Title: "random", Space: 1},
+	}
+	moves, missing := planLayoutRestore(saved, spaces, windows)
+	var got [][2]int
+	for _, mv := range moves {
+		got = append(got, [2]int{mv.window.ID, mv.to})
+	}
+	if !reflect.DeepEqual(got, [][2]int{{21, 2}, {12, 2}}) {
+		t.Errorf("moves = %v", got)
+	}
+	if len(missing) != 1 || missing[0].App != "Notes" {
+		t.Errorf("missing = %+v", missing)
+	}
+}
+
+func TestLayoutPersistence(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(layoutSchema); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := []layoutEntry{{WindowID: 1, App: "kitty", Title: "api", SpaceIndex: 1, SpaceLabel: "code"}}
+	second := []layoutEntry{
+		{WindowID: 2, App: "Slack", SpaceIndex: 3},
+		{WindowID: 3, App: "Safari", Title: "docs", SpaceIndex: 2},
+	}
+	if err := saveLayout(db, "desk", first, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveLayout(db, "desk", second, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadLayout(db, "desk")
+	if err != nil || !reflect.DeepEqual(got, second) {
+		t.Errorf("loaded = %+v, %v", got, err)
+	}
+	if _, err := loadLayout(db, "nope"); err == nil {
+		t.Error("unknown layout loaded")
+	}
+	if names, err := layoutNames(db); err != nil || len(names) != 1 {
+		t.Errorf("names = %v, %v", names, err)
+	}
+}
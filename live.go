@@ -0,0 +1,64 @@
+// live: spaces holding a call or playing media.
+//
+// switching away from a space with a meeting on it is how you miss being
+// asked something. window titles give calls and media away (zoom's
+// "Zoom Meeting" window, a "Meet - abc-defg-hij" tab, a "... - YouTube"
+// page), so liveWindowRules (config.go) match app and title, and a space
+// with a match gets a marker before its windows: a meeting one, or a
+// media one when there's no meeting. titles don't say whether a video is
+// paused, so media is only ever a hint.
+
+package main
+
+import "regexp"
+
+// liveKind is what a matched window is doing.
+type liveKind string
+
+const (
+	liveMeeting liveKind = "meeting"
+	liveMedia   liveKind = "media"
+)
+
+// liveWindowRule marks windows of app (any app when empty) whose title
+// matches.
+type liveWindowRule struct {
+	kind  liveKind
+	app   string
+	title *regexp.Regexp
+}
+
+// windowLive is the first rule a window matches, or "".
+func windowLive(w Window, rules []liveWindowRule) liveKind {
+	for _, r := range rules {
+		if (r.app == "" || r.app == w.App) && r.title.MatchString(w.Title) {
+			return r.kind
+		}
+	}
+	return ""
+}
+
+// spaceLive is what's live on a space: a meeting outranks media.
+func spaceLive(windows []Window, rules []liveWindowRule) liveKind {
+	var live liveKind
+	for _, w := range windows {
+		switch windowLive(w, rules) {
+		case liveMeeting:
+			return liveMeeting
+		case liveMedia:
+			live = liveMedia
+		}
+	}
+	return live
+}
+
+// renderLiveMarker is the marker before a live space's windows.
+func renderLiveMarker(kind liveKind) string {
+	switch kind {
+	case liveMeeting:
+		return warnStyle.Render("◉ ")
+	case liveMedia:
+		return keyStyle.Render("♪ ")
+	}
+	return ""
+}
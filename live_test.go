@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestSpaceLive(t *testing.T) {
+	cases := []struct {
+		name    string
+		windows []Window
+		want    liveKind
+	}{
+		{"zoom", []Window{{App: "zoom.us", Title: "Zoom Meeting"}}, liveMeeting},
+		{"zoom idle", []Window{{App: "zoom.us", Title: "Zoom Workplace"}}, ""},
+		{"meet tab", []Window{{App: "Google Chrome", Title: "Meet - abc-defg-hij - Google Chrome"}}, liveMeeting},
+		{"meet landing", []Window{{App: "Google Chrome", Title: "Google Meet - Google Chrome"}}, ""},
+		{"youtube", []Window{{App: "Firefox", Title: "lofi beats - YouTube — Mozilla Firefox"}}, liveMedia},
+		{"spotify paused", []Window{{App: "Spotify", Title: "Spotify Premium"}}, ""},
+		{"meeting beats media", []Window{
+			{App: "Firefox", Title: "lofi beats - YouTube — Mozilla Firefox"},
+			{App: "Slack", Title: "Huddle: #eng - Slack"},
+		}, liveMeeting},
+		{"nothing", []Window{{App: "kitty", Title: "api"}}, ""},
+	}
+	for _, c := range cases {
+		if got := spaceLive(c.windows, liveWindowRules); got != c.want {
+			t.Errorf("%s: spaceLive = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
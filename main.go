@@ -4,69 +4,88 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
 )
 
 func main() {
-	// `stop serve` subcommand — HTTP JSON server for Rose companion app
-	if len(os.Args) > 1 && os.Args[1] == "serve" {
-		fs := flag.NewFlagSet("serve", flag.ExitOnError)
-		port := fs.Int("port", 8385, "port to listen on")
-		fs.IntVar(port, "p", 8385, "port to listen on")
-		_ = fs.Parse(os.Args[2:])
-		serveCommand(*port)
-		return
+	if err := checkStalenessTiers(stalenessTiers); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
 	}
-
-	// `stop history` subcommand — find restarts/crashes/sleeps in the
-	// snapshot timeline and print the last known state before each.
-	if len(os.Args) > 1 && os.Args[1] == "history" {
-		fs := flag.NewFlagSet("history", flag.ExitOnError)
-		since := fs.Duration("since", 7*24*time.Hour, "lookback window (e.g. 24h, 168h)")
-		gap := fs.Duration("gap", 5*time.Minute, "minimum wall-clock gap to flag as a discontinuity")
-		collapse := fs.Int("collapse", 5, "minimum drop in tmux pane count to flag (0 disables)")
-		limit := fs.Int("limit", 1, "max number of discontinuities to print (0 = no limit)")
-		_ = fs.Parse(os.Args[2:])
-		err := historyCommand(historyOptions{
-			since:             time.Now().Add(-*since),
-			gapThreshold:      *gap,
-			collapseThreshold: *collapse,
-			limit:             *limit,
-		})
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
-		}
-		return
+	km, err := buildKeymap(keyBindings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	activeKeymap = km
+	engine.SetTitlePattern(terminalTitlePattern)
+	engine.SetQueryLimits(engine.QueryLimits{Timeouts: queryTimeouts, Retries: queryRetries, Backoff: queryRetryBackoff})
+	if err := engine.SetFocusFallback(focusFallback); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if focusMode != focusModeSwitch && focusMode != focusModeRestore {
+		fmt.Fprintf(os.Stderr, "error: unknown focusMode %q (want %s or %s)\n", focusMode, focusModeSwitch, focusModeRestore)
+		os.Exit(1)
 	}
 
-	// `stop show <snapshot_id>` — render a single snapshot by id (debug aid).
-	if len(os.Args) > 2 && os.Args[1] == "show" {
-		var id int64
-		fmt.Sscanf(os.Args[2], "%d", &id)
-		if id <= 0 {
-			fmt.Fprintln(os.Stderr, "usage: stop show <snapshot_id>")
-			os.Exit(1)
-		}
-		db, err := openSnapshotDB()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
-		}
-		defer db.Close()
-		if err := printSnapshotState(os.Stdout, db, id); err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
+	cmds := commands()
+	if len(os.Args) > 1 {
+		switch name := os.Args[1]; name {
+		case "help", "-h", "-help", "--help":
+			os.Exit(helpCommand(cmds, os.Args[2:]))
+		default:
+			if cmd, ok := findCommand(cmds, name); ok {
+				os.Exit(runCommand(cmd, os.Args[2:]))
+			}
 		}
-		return
 	}
+	os.Exit(runCommand(dashboardCommand(), os.Args[1:]))
+}
 
-	// default: launch TUI
-	p := tea.NewProgram(newModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+// dashboardCommand is plain `stop`: the TUI.
+func dashboardCommand() command {
+	return command{
+		usage:   "[flags]",
+		summary: "the dashboard: every display's spaces, their windows and tmux sessions",
+		setup: func(fs *flag.FlagSet, g *globalFlags) func([]string) error {
+			themeFlag := fs.String("theme", themeName, "color theme: default, solarized, gruvbox, high-contrast, no-color")
+			background := fs.String("background", themeBackground, "terminal background: auto, dark, or light")
+			fs.BoolVar(&tmuxOnly, "no-yabai", false, "skip the window manager: a tmux-only dashboard")
+			noIcons := fs.Bool("no-icons", false, "plain app names, for terminals without a nerd font")
+			readOnly := fs.Bool("read-only", false, "observe only: never focus, move, kill, rename or notify")
+			replay := fs.String("replay", "", "run from a `stop record` recording instead of this machine (read-only)")
+			demo := fs.Bool("demo", false, "run on a generated desk, no yabai or tmux needed")
+			return func(args []string) error {
+				if len(args) > 0 {
+					return fmt.Errorf("unknown command %q (stop help lists them)", args[0])
+				}
+				iconsEnabled = !*noIcons
+				engine.SetReadOnly(*readOnly)
+				if *demo {
+					startDemo()
+				}
+				if *replay != "" {
+					if err := startReplay(*replay); err != nil {
+						return err
+					}
+				}
+				dark, err := isDarkBackground(*background)
+				if err != nil {
+					return err
+				}
+				t, err := resolveTheme(*themeFlag, dark, themeOverrides)
+				if err != nil {
+					return err
+				}
+				applyTheme(t)
+
+				p := tea.NewProgram(newModel(), tea.WithAltScreen(), tea.WithMouseCellMotion())
+				_, err = p.Run()
+				return err
+			}
+		},
 	}
 }
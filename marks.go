@@ -0,0 +1,164 @@
+// marks: pick several spaces, then act on all of them at once.
+//
+// space marks the space under the cursor (again unmarks it), and a + in
+// the cursor column shows which are marked. while any are, the keys that
+// act on one space act on the marked ones instead: enter focuses the
+// next marked space after the focused one, cycling through them; X kills
+// every tmux session on them; and M moves them onto free spaces of the
+// cursor's display, a space at a time, planned and rolled back like a
+// balance (balance.go). esc drops the marks, as does a kill or move
+// going through. marks follow the space's id, so they survive sorting
+// and spaces moving between displays; a destroyed space drops out.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// toggleMark marks or unmarks the selected space. the set is copied, not
+// changed in place, since frames still hold the old one.
+func (m model) toggleMark() model {
+	row, ok := m.selectedSpaceRow()
+	if !ok {
+		return m
+	}
+	marks := make(map[int]bool, len(m.marks)+1)
+	for id := range m.marks {
+		marks[id] = true
+	}
+	if marks[row.space.ID] {
+		delete(marks, row.space.ID)
+	} else {
+		marks[row.space.ID] = true
+	}
+	if len(marks) == 0 {
+		marks = nil
+	}
+	m.marks = marks
+	return m
+}
+
+// markedRows are the marked spaces still on the desk, in grid order.
+func markedRows(groups []displayGroup, marks map[int]bool) []spaceRow {
+	if len(marks) == 0 {
+		return nil
+	}
+	var rows []spaceRow
+	for _, dg := range groups {
+		for _, row := range dg.spaces {
+			if marks[row.space.ID] {
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows
+}
+
+// nextMarked is the marked space after the focused one in grid order,
+// wrapping around; the first when none of them has focus.
+func nextMarked(rows []spaceRow) spaceRow {
+	for i, row := range rows {
+		if row.space.HasFocus {
+			return rows[(i+1)%len(rows)]
+		}
+	}
+	return rows[0]
+}
+
+// focusNextMarked is enter while spaces are marked.
+func (m model) focusNextMarked(rows []spaceRow) (tea.Model, tea.Cmd) {
+	next := nextMarked(rows)
+	m.restoreCursor(next.space.ID)
+	return m, focusSpaceCmd(next.space.Index)
+}
+
+// killMarked asks before killing every session on the marked spaces.
+func (m model) killMarked(rows []spaceRow) model {
+	var sessions []string
+	seen := map[string]bool{}
+	for _, row := range rows {
+		for _, s := range sessionsOnSpace(row, m.tmuxPanes) {
+			if !seen[s] {
+				seen[s] = true
+				sessions = append(sessions, s)
+			}
+		}
+	}
+	if len(sessions) == 0 {
+		m.status = "no tmux sessions on the marked spaces"
+		return m
+	}
+	m.confirm = &pendingAction{
+		prompt:     fmt.Sprintf("kill tmux %s on %d marked spaces?", strings.Join(sessions, ", "), len(rows)),
+		run:        killSessionsCmd(sessions, m.tmuxPanes),
+		clearMarks: true,
+	}
+	return m
+}
+
+// planMarkedMove pairs each marked space with windows, off the target
+// display, with a free space of it that isn't on screen. nil, with
+// the reason, when there's nothing to move or too few free spaces.
+func planMarkedMove(rows []spaceRow, target displayGroup) ([]balanceMove, string) {
+	var movable []spaceRow
+	for _, row := range rows {
+		if row.space.Display != target.index && len(row.windows) > 0 {
+			movable = append(movable, row)
+		}
+	}
+	if len(movable) == 0 {
+		return nil, "no marked spaces with windows off " + displayName(target)
+	}
+	var free []spaceRow
+	for _, row := range target.spaces {
+		if len(row.windows) == 0 && !row.space.IsVisible {
+			free = append(free, row)
+		}
+	}
+	if len(free) < len(movable) {
+		return nil, fmt.Sprintf("%s has %d free spaces for %d marked", displayName(target), len(free), len(movable))
+	}
+	moves := make([]balanceMove, len(movable))
+	for i, row := range movable {
+		moves[i] = balanceMove{from: row, to: free[i].space}
+	}
+	return moves, ""
+}
+
+// moveMarked asks before moving the marked spaces to the cursor's
+// display.
+func (m model) moveMarked(rows []spaceRow) model {
+	if m.cursorCol >= len(m.displayGroups) {
+		return m
+	}
+	target := m.displayGroups[m.cursorCol]
+	moves, why := planMarkedMove(rows, target)
+	if moves == nil {
+		m.status = why
+		return m
+	}
+	names := make([]string, len(moves))
+	for i, mv := range moves {
+		names[i] = spaceSummaryName(mv.from)
+	}
+	m.confirm = &pendingAction{
+		prompt:     fmt.Sprintf("move %s to %s?", strings.Join(names, ", "), displayName(target)),
+		run:        balanceCmd(balancePlan{roomy: target, moves: moves}),
+		clearMarks: true,
+	}
+	return m
+}
+
+// renderMarkedPrompt counts the marks and names the bulk keys.
+func renderMarkedPrompt(n int) string {
+	km := activeKeymap
+	return dimStyle.Render(fmt.Sprintf("%d marked: ", n)) +
+		keyStyle.Render(km.label(actFocus)) + dimStyle.Render(" cycle, ") +
+		keyStyle.Render(km.label(actKill)) + dimStyle.Render(" kill, ") +
+		keyStyle.Render(km.label(actMoveMarked)) + dimStyle.Render(" move here, ") +
+		keyStyle.Render("esc") + dimStyle.Render(" to clear")
+}
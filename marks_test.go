@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMarkedSpaces(t *testing.T) {
+	displays := []Display{{Index: 1}, {Index: 2}}
+	spaces := []Space{
+		{ID: 11, Index: 1, Display: 1, HasFocus: true, IsVisible: true},
+		{ID: 12, Index: 2, Display: 1},
+		{ID: 13, Index: 3, Display: 1},
+		{ID: 21, Index: 4, Display: 2, IsVisible: true},
+		{ID: 22, Index: 5, Display: 2},
+		{ID: 23, Index: 6, Display: 2},
+	}
+	windows := []Window{
+		{ID: 1, App: "kitty", Title: "api", Space: 2},
+		{ID: 2, App: "kitty", Title: "web", Space: 3},
+	}
+	m := newModel()
+	next, _ := m.handleData(fetchResult{spaces: spaces, displays: displays, windows: windows})
+	m = next.(model)
+	press := func(msg tea.KeyMsg) {
+		t.Helper()
+		next, _ := m.handleKey(msg)
+		m = next.(model)
+	}
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+
+	// mark spaces 2 and 3, and 1 then unmark it
+	for _, row := range []int{1, 2, 0, 0} {
+		m.cursorCol, m.cursorRow = 0, row
+		press(space)
+	}
+	if rows := markedRows(m.displayGroups, m.marks); len(rows) != 2 || rows[0].space.ID != 12 || rows[1].space.ID != 13 {
+		t.Fatalf("marked = %+v", m.marks)
+	}
+	if !strings.Contains(m.renderPrompt(), "2 marked") {
+		t.Errorf("prompt = %q", m.renderPrompt())
+	}
+
+	// enter cycles from the focused space to the first marked one after it
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if row, _ := m.selectedSpaceRow(); row.space.ID != 12 {
+		t.Errorf("enter moved the cursor to space %d", row.space.ID)
+	}
+	rows := markedRows(m.displayGroups, m.marks)
+	rows[0].space.HasFocus = true
+	if next := nextMarked(rows); next.space.ID != 13 {
+		t.Errorf("after space 2 comes space %d", next.space.ID)
+	}
+	rows[0].space.HasFocus, rows[1].space.HasFocus = false, true
+	if next := nextMarked(rows); next.space.ID != 12 {
+		t.Errorf("cycle didn't wrap: got space %d", next.space.ID)
+	}
+
+	// M on display 2 moves both onto its free spaces that aren't on screen
+	m.cursorCol, m.cursorRow = 1, 0
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")})
+	if m.confirm == nil || m.confirm.prompt != "move api, web to display 2?" {
+		t.Fatalf("confirm = %+v", m.confirm)
+	}
+	moves, _ := planMarkedMove(markedRows(m.displayGroups, m.marks), m.displayGroups[1])
+	if len(moves) != 2 || moves[0].to.Index != 5 || moves[1].to.Index != 6 {
+		t.Errorf("moves = %+v", moves)
+	}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if m.marks == nil {
+		t.Error("declining the move dropped the marks")
+	}
+
+	// on display 1 they are where they would go
+	if _, why := planMarkedMove(markedRows(m.displayGroups, m.marks), m.displayGroups[0]); !strings.Contains(why, "no marked spaces") {
+		t.Errorf("why = %q", why)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.marks != nil {
+		t.Errorf("esc left marks %v", m.marks)
+	}
+}
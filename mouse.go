@@ -0,0 +1,200 @@
+// mouse: click and scroll handling for the spaces grid.
+//
+// clicking a space row selects it, a second click on the same row within
+// doubleClickWindow focuses it, and the wheel moves the cursor like j/k.
+// clicking one of the inline tmux window lines opens a preview of what
+// that window is showing, and clicking a hidden window restores it. View
+// can't know where things landed on screen without laying them out, so
+// it records a clickable-region map as it renders; the mouse handler
+// hit-tests against the most recent one.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// doubleClickWindow is how close two clicks on the same row must be to
+// count as a double-click.
+const doubleClickWindow = 400 * time.Millisecond
+
+// previewLines is how much of a tmux window the preview shows.
+const previewLines = 12
+
+// hit target kinds
+const (
+	hitNone = iota
+	hitSpace
+	hitTmuxWindow
+	hitHiddenWindow
+)
+
+// hitTarget is what a click on one rendered line means.
+type hitTarget struct {
+	kind     int
+	col      int // display group
+	row      int // space within the group
+	session  string
+	window   int
+	windowID int // hidden window (hidden.go)
+}
+
+// hitRegion is a rectangle of screen cells, half-open on the far edges.
+type hitRegion struct {
+	x0, y0, x1, y1 int
+	target         hitTarget
+}
+
+func (r hitRegion) offset(x, y int) hitRegion {
+	r.x0, r.x1 = r.x0+x, r.x1+x
+	r.y0, r.y1 = r.y0+y, r.y1+y
+	return r
+}
+
+// hitMap is the clickable-region map of the last render. the model holds
+// it by pointer so View, which gets a copy of the model, can fill it in.
+type hitMap struct {
+	regions []hitRegion
+}
+
+func (h *hitMap) reset() {
+	if h != nil {
+		h.regions = h.regions[:0]
+	}
+}
+
+func (h *hitMap) add(r hitRegion) {
+	if h != nil {
+		h.regions = append(h.regions, r)
+	}
+}
+
+// at returns the target under a cell.
+func (h *hitMap) at(x, y int) (hitTarget, bool) {
+	if h == nil {
+		return hitTarget{}, false
+	}
+	for _, r := range h.regions {
+		if x >= r.x0 && x < r.x1 && y >= r.y0 && y < r.y1 {
+			return r.target, true
+		}
+	}
+	return hitTarget{}, false
+}
+
+// columnRegions maps a column's logical lines (one target each) to
+// column-relative regions, accounting for lines the column style wraps.
+func columnRegions(col string, targets []hitTarget, colStyle lipgloss.Style) []hitRegion {
+	var regions []hitRegion
+	y := 0
+	for i, line := range strings.Split(col, "\n") {
+		h := lipgloss.Height(colStyle.Render(line))
+		if i < len(targets) && targets[i].kind != hitNone {
+			regions = append(regions, hitRegion{x0: 0, y0: y, x1: colStyle.GetWidth(), y1: y + h, target: targets[i]})
+		}
+		y += h
+	}
+	return regions
+}
+
+// panePreview is a snapshot of a tmux window's visible output.
+type panePreview struct {
+	title string
+	lines []string
+}
+
+type previewMsg struct {
+	preview panePreview
+	err     error
+}
+
+// previewWindowCmd captures the window's most interesting pane: the
+// productive one when there is one, its first pane otherwise.
+func previewWindowCmd(session string, window int, panes []TmuxPane, productive map[int]bool) tea.Cmd {
+	var pick *TmuxPane
+	for i, p := range panes {
+		if p.SessionName != session || p.WindowIndex != window {
+			continue
+		}
+		if pick == nil || (productive[p.PanePID] && !productive[pick.PanePID]) {
+			pick = &panes[i]
+		}
+	}
+	if pick == nil {
+		return nil
+	}
+	p := *pick
+	return func() tea.Msg {
+		title := fmt.Sprintf("%s:%d.%d %s", p.SessionName, p.WindowIndex, p.PaneIndex, p.CurrentCommand)
+		lines := capturePaneTail(p, previewLines)
+		if lines == nil {
+			return previewMsg{err: fmt.Errorf("capturing %s failed", title)}
+		}
+		return previewMsg{preview: panePreview{title: title, lines: lines}}
+	}
+}
+
+// handleMouse selects, focuses, scrolls, and previews.
+func (m model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.confirm != nil || m.showAgents || len(m.displayGroups) == 0 {
+		return m, nil
+	}
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		return m.handleAction(actUp)
+	case msg.Button == tea.MouseButtonWheelDown:
+		return m.handleAction(actDown)
+	case msg.Button != tea.MouseButtonLeft || msg.Action != tea.MouseActionPress:
+		return m, nil
+	}
+
+	t, ok := m.hits.at(msg.X, msg.Y)
+	if ok && t.kind == hitHiddenWindow && t.col < len(m.displayGroups) {
+		for _, w := range m.displayGroups[t.col].hidden {
+			if w.ID == t.windowID {
+				return m, restoreWindowsCmd([]Window{w})
+			}
+		}
+		return m, nil
+	}
+	if !ok || t.col >= len(m.displayGroups) || t.row >= len(m.displayGroups[t.col].spaces) {
+		return m, nil
+	}
+	m.cursorCol, m.cursorRow = t.col, t.row
+
+	now := time.Now()
+	double := t == m.lastClick && now.Sub(m.lastClickAt) < doubleClickWindow
+	m.lastClick, m.lastClickAt = t, now
+
+	switch t.kind {
+	case hitSpace:
+		if double {
+			m.lastClickAt = time.Time{} // a third click starts over
+			idx, _ := m.selectedSpaceIndex()
+			return m, focusSpaceCmd(idx)
+		}
+	case hitTmuxWindow:
+		return m, previewWindowCmd(t.session, t.window, m.tmuxPanes, m.productivePanePIDs)
+	}
+	return m, nil
+}
+
+// renderPreview draws the open preview above the prompt line.
+func (m model) renderPreview(width int, pad string) string {
+	if m.preview == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(pad + displayStyle.Render(m.preview.title) + "  " +
+		keyStyle.Render("esc") + " " + helpStyle.Render("close") + "\n")
+	for _, line := range m.preview.lines {
+		b.WriteString(pad + dimStyle.Render("│ ") + truncateForWidth(line, maxInt(width-2, 10)) + "\n")
+	}
+	return b.String()
+}
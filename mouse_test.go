@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestColumnRegionsWrap(t *testing.T) {
+	style := lipgloss.NewStyle().Width(10)
+	col := "header\nspace one is long enough to wrap\n  1:main"
+	targets := []hitTarget{{}, {kind: hitSpace}, {kind: hitTmuxWindow, session: "api", window: 1}}
+
+	regions := columnRegions(col, targets, style)
+	if len(regions) != 2 {
+		t.Fatalf("got %d regions, want 2 (header isn't clickable)", len(regions))
+	}
+	if regions[0].y0 != 1 || regions[0].y1 <= 2 {
+		t.Errorf("wrapped space line spans rows %d-%d, want 1 to past 2", regions[0].y0, regions[0].y1)
+	}
+	if regions[1].y0 != regions[0].y1 {
+		t.Errorf("window line starts at %d, want %d", regions[1].y0, regions[0].y1)
+	}
+}
+
+func TestHandleMouseSelectsAndDoubleClickFocuses(t *testing.T) {
+	m := newModel()
+	m.displayGroups = []displayGroup{
+		{index: 1, spaces: []spaceRow{{space: Space{Index: 1}}}},
+		{index: 2, spaces: []spaceRow{{space: Space{Index: 2}}, {space: Space{Index: 3}}}},
+	}
+	m.hits.add(hitRegion{x0: 40, y0: 3, x1: 70, y1: 4, target: hitTarget{kind: hitSpace, col: 1, row: 1}})
+
+	click := tea.MouseMsg{X: 45, Y: 3, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress}
+	next, cmd := m.handleMouse(click)
+	m = next.(model)
+	if m.cursorCol != 1 || m.cursorRow != 1 {
+		t.Fatalf("cursor = (%d,%d), want (1,1)", m.cursorCol, m.cursorRow)
+	}
+	if cmd != nil {
+		t.Error("single click should only select")
+	}
+
+	if _, cmd = m.handleMouse(click); cmd == nil {
+		t.Error("double click should focus the space")
+	}
+
+	miss := tea.MouseMsg{X: 5, Y: 3, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress}
+	if next, _ := m.handleMouse(miss); next.(model).cursorRow != 1 {
+		t.Error("click outside any region moved the cursor")
+	}
+}
@@ -0,0 +1,183 @@
+// mqtt: the desk's state, published for home automation.
+//
+// with mqttBroker set (config.go), `stop serve` publishes a few numbers
+// every mqttInterval to the topics in mqttTopics: the focused space, how
+// many minutes the stalest agent has gone without output, and how many
+// spaces have no windows. Home Assistant (or anything else on the
+// broker) can then turn a desk light red when an agent has been waiting
+// too long. messages are retained, so a subscriber gets the current
+// value the moment it connects, and only values that changed are sent.
+// publishing drives whatever listens, so read-only mode skips it.
+//
+// the client is the few packets of MQTT 3.1.1 this needs — CONNECT,
+// PUBLISH at QoS 0, DISCONNECT — over a fresh connection each round, so
+// a broker restart costs one missed round and nothing else.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// mqtt summary keys, as used in mqttTopics
+const (
+	mqttFocusedSpace        = "focused_space"
+	mqttStalestAgentMinutes = "stalest_agent_minutes"
+	mqttFreeSpaces          = "free_spaces"
+)
+
+// mqttSummary is what gets published, key → payload.
+func mqttSummary(result fetchResult, now time.Time) map[string]string {
+	summary := map[string]string{mqttFocusedSpace: "", mqttFreeSpaces: "0", mqttStalestAgentMinutes: "0"}
+	free := 0
+	for _, s := range result.spaces {
+		if s.HasFocus {
+			summary[mqttFocusedSpace] = s.Label
+			if s.Label == "" {
+				summary[mqttFocusedSpace] = strconv.Itoa(s.Index)
+			}
+		}
+		if len(s.Windows) == 0 {
+			free++
+		}
+	}
+	summary[mqttFreeSpaces] = strconv.Itoa(free)
+
+	// each session's agents are as fresh as the freshest of them
+	var stalest time.Duration
+	for _, activity := range bestProductiveActivity(result.tmuxPanes, result.productivePanePIDs) {
+		stalest = max(stalest, now.Sub(activity))
+	}
+	summary[mqttStalestAgentMinutes] = strconv.Itoa(int(stalest.Minutes()))
+	return summary
+}
+
+// mqttPublisher sends the summaries that changed since the last round.
+type mqttPublisher struct {
+	addr string
+	sent map[string]string // topic → payload last published
+}
+
+// publish sends every configured topic whose payload changed.
+func (p *mqttPublisher) publish(summary map[string]string) error {
+	changed := map[string]string{}
+	for key, topic := range mqttTopics {
+		if payload, ok := summary[key]; ok && topic != "" && p.sent[topic] != payload {
+			changed[topic] = payload
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("tcp", p.addr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", p.addr, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err := mqttConnect(conn, mqttClientID, mqttUsername, mqttPassword); err != nil {
+		return err
+	}
+	for topic, payload := range changed {
+		if _, err := conn.Write(mqttPacket(0x31, mqttString(topic), []byte(payload))); err != nil { // PUBLISH, retained
+			return fmt.Errorf("publishing %s: %w", topic, err)
+		}
+		p.sent[topic] = payload
+	}
+	_, err = conn.Write([]byte{0xe0, 0}) // DISCONNECT
+	return err
+}
+
+// startMQTTLoop publishes the summary until ctx is done.
+func startMQTTLoop(ctx context.Context, interval time.Duration) {
+	if err := engine.Guard("mqtt", mqttBroker); err != nil {
+		log.Printf("mqtt: not publishing: %v", err)
+		return
+	}
+	p := &mqttPublisher{addr: mqttBroker, sent: map[string]string{}}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if result := fetchAll(); result.err != nil {
+			log.Printf("mqtt: %v", result.err)
+		} else if err := p.publish(mqttSummary(result, time.Now())); err != nil {
+			log.Printf("mqtt: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// mqttConnect opens an MQTT 3.1.1 session and waits for the broker's
+// CONNACK.
+func mqttConnect(rw io.ReadWriter, clientID, username, password string) error {
+	flags := byte(0x02) // clean session
+	payload := mqttString(clientID)
+	if username != "" {
+		flags |= 0x80
+		payload = append(payload, mqttString(username)...)
+		if password != "" {
+			flags |= 0x40
+			payload = append(payload, mqttString(password)...)
+		}
+	}
+	header := append(mqttString("MQTT"), 4, flags, 0, 60) // level 4, 60s keep-alive
+	if _, err := rw.Write(mqttPacket(0x10, header, payload)); err != nil {
+		return fmt.Errorf("sending connect: %w", err)
+	}
+
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(rw, ack); err != nil {
+		return fmt.Errorf("reading connack: %w", err)
+	}
+	switch {
+	case ack[0] != 0x20 || ack[1] != 2:
+		return errors.New("broker didn't answer with a connack")
+	case ack[3] != 0:
+		return fmt.Errorf("broker refused the connection (code %d)", ack[3])
+	}
+	return nil
+}
+
+// mqttPacket frames a control packet: its type byte, the remaining length
+// as a variable-length integer, then the parts.
+func mqttPacket(kind byte, parts ...[]byte) []byte {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	packet := []byte{kind}
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	for _, p := range parts {
+		packet = append(packet, p...)
+	}
+	return packet
+}
+
+// mqttString is a length-prefixed UTF-8 string.
+func mqttString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestMQTTSummary(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	result := fetchResult{
+		spaces: []Space{{Index: 1, Label: "code", Windows: []int{1}}, {Index: 2, HasFocus: true, Windows: []int{2}}, {Index: 3}, {Index: 4}},
+		tmuxPanes: []TmuxPane{
+			{SessionName: "api", PanePID: 10, LastActivity: now.Add(-40 * time.Minute)},
+			{SessionName: "api", PanePID: 11, LastActivity: now.Add(-5 * time.Minute)},
+			{SessionName: "web", PanePID: 12, LastActivity: now.Add(-12 * time.Minute)},
+			{SessionName: "notes", PanePID: 13, LastActivity: now.Add(-3 * time.Hour)},
+		},
+		productivePanePIDs: map[int]bool{10: true, 11: true, 12: true},
+	}
+	got := mqttSummary(result, now)
+	want := map[string]string{mqttFocusedSpace: "2", mqttFreeSpaces: "2", mqttStalestAgentMinutes: "12"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestMQTTPacket(t *testing.T) {
+	if got := mqttPacket(0xe0); len(got) != 2 || got[1] != 0 {
+		t.Errorf("empty packet = %x", got)
+	}
+	// 200 bytes of remaining length take two length bytes
+	got := mqttPacket(0x30, make([]byte, 200))
+	if got[1] != 0xc8 || got[2] != 0x01 || len(got) != 203 {
+		t.Errorf("length bytes = %x %x, packet %d bytes", got[1], got[2], len(got))
+	}
+}
+
+// readMQTTPacket reads one packet off a fake broker's connection.
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, n)
+	_, err = io.ReadFull(r, body)
+	return kind, body, err
+}
+
+func TestMQTTPublish(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback: %v", err)
+	}
+	defer ln.Close()
+
+	published := make(chan map[string]string, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			if kind, body, err := readMQTTPacket(r); err != nil || kind != 0x10 || string(body[2:6]) != "MQTT" {
+				t.Errorf("first packet %x %q (%v), want CONNECT", kind, body, err)
+			}
+			conn.Write([]byte{0x20, 2, 0, 0})
+			got := map[string]string{}
+			for {
+				kind, body, err := readMQTTPacket(r)
+				if err != nil || kind == 0xe0 {
+					break
+				}
+				if kind != 0x31 {
+					t.Errorf("packet %x, want a retained PUBLISH", kind)
+				}
+				n := int(body[0])<<8 | int(body[1])
+				got[string(body[2:2+n])] = string(body[2+n:])
+			}
+			conn.Close()
+			published <- got
+		}
+	}()
+
+	p := &mqttPublisher{addr: ln.Addr().String(), sent: map[string]string{}}
+	summary := map[string]string{mqttFocusedSpace: "code", mqttFreeSpaces: "2", mqttStalestAgentMinutes: "12"}
+	if err := p.publish(summary); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-published; got["stop/focused_space"] != "code" || got["stop/free_spaces"] != "2" || len(got) != 3 {
+		t.Errorf("first round = %v", got)
+	}
+
+	// only what changed goes out, and nothing at all when nothing did
+	summary[mqttFreeSpaces] = "1"
+	if err := p.publish(summary); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-published; len(got) != 1 || got["stop/free_spaces"] != "1" {
+		t.Errorf("second round = %v", got)
+	}
+	if err := p.publish(summary); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-published:
+		t.Errorf("unchanged round published %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMQTTLoopSkippedReadOnly(t *testing.T) {
+	engine.SetReadOnly(true)
+	defer engine.SetReadOnly(false)
+	done := make(chan struct{})
+	go func() {
+		startMQTTLoop(context.Background(), time.Hour)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the loop ran in read-only mode")
+	}
+}
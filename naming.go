@@ -0,0 +1,139 @@
+// naming: enforce the tmux session naming convention.
+//
+// sessionNamePattern (config.go) says what a session name should look
+// like. the view flags sessions that don't match, and R on a space offers
+// to rename its first offender to a name derived from the project it's
+// working in. the rename goes through tmux, so a terminal with tmux's
+// set-titles on picks up the new title and the title → session match keeps
+// working; annotations and priorities keyed by the old name move with it.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// conformsSessionName reports whether a session name follows the policy.
+func conformsSessionName(name string) bool {
+	return sessionNamePattern == nil || sessionNamePattern.MatchString(name)
+}
+
+// slugify lowercases s and collapses every run of characters outside
+// [a-z0-9] into a single dash.
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// suggestSessionName derives a conforming name for a session from the
+// directory its agent (or else its first pane) is working in, made unique
+// against the names in taken. empty when no conforming name comes out of
+// it, e.g. under a custom pattern the slug can't satisfy.
+func suggestSessionName(session string, panes []TmuxPane, productive map[int]bool, taken map[string]bool) string {
+	dir := ""
+	for _, p := range panes {
+		if p.SessionName != session {
+			continue
+		}
+		if dir == "" || productive[p.PanePID] {
+			dir = p.CurrentPath
+		}
+		if productive[p.PanePID] {
+			break
+		}
+	}
+	base := slugify(filepathBase(dir))
+	if base == "" {
+		base = slugify(session)
+	}
+	if base == "" {
+		base = "session"
+	}
+
+	name := base
+	for n := 2; taken[name] && name != session; n++ {
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	if !conformsSessionName(name) {
+		return ""
+	}
+	return name
+}
+
+// nonconformingSessions filters session names down to the offenders.
+func nonconformingSessions(sessions []string) []string {
+	var bad []string
+	for _, s := range sessions {
+		if !conformsSessionName(s) {
+			bad = append(bad, s)
+		}
+	}
+	return bad
+}
+
+// renameTmuxSession renames a session in place; its windows, panes and
+// attached clients are untouched.
+func renameTmuxSession(from, to string) error {
+	if err := engine.Guard("tmux", "rename-session", "-t", "="+from, to); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "tmux", "rename-session", "-t", "="+from, to).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("tmux rename-session: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// moveSessionState re-keys session-targeted annotations and priorities
+// from one name to another.
+func moveSessionState(db *sql.DB, from, to string) error {
+	for _, table := range []string{"annotations", "priorities"} {
+		_, err := db.Exec(
+			"UPDATE OR REPLACE "+table+" SET target = ? WHERE target_kind = ? AND target = ?",
+			to, targetSession, from,
+		)
+		if err != nil {
+			return fmt.Errorf("moving %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// renameSessionCmd renames a session and carries its state over. the
+// state move is best-effort: the rename already happened.
+func renameSessionCmd(from, to string) tea.Cmd {
+	return func() tea.Msg {
+		status := fmt.Sprintf("rename %s", from)
+		if err := renameTmuxSession(from, to); err != nil {
+			return actionDoneMsg{status: status + " failed", err: err}
+		}
+		if db := sharedStateDB(); db != nil {
+			if err := moveSessionState(db, from, to); err != nil {
+				return actionDoneMsg{status: fmt.Sprintf("renamed %s → %s", from, to), err: err}
+			}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("renamed %s → %s", from, to)}
+	}
+}
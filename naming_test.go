@@ -0,0 +1,61 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSuggestSessionName(t *testing.T) {
+	panes := []TmuxPane{
+		{SessionName: "My Session", PanePID: 1, CurrentPath: "/home/me"},
+		{SessionName: "My Session", PanePID: 2, CurrentPath: "/src/Web_App.v2"},
+		{SessionName: "tmp", PanePID: 3, CurrentPath: ""},
+	}
+	productive := map[int]bool{2: true}
+
+	if got := suggestSessionName("My Session", panes, productive, map[string]bool{}); got != "web-app-v2" {
+		t.Errorf("suggestion = %q, want web-app-v2 from the agent's cwd", got)
+	}
+	taken := map[string]bool{"web-app-v2": true, "web-app-v2-2": true}
+	if got := suggestSessionName("My Session", panes, productive, taken); got != "web-app-v2-3" {
+		t.Errorf("suggestion = %q, want web-app-v2-3 past the taken names", got)
+	}
+	if got := suggestSessionName("tmp", panes, productive, map[string]bool{"tmp": true}); got != "tmp" {
+		t.Errorf("suggestion = %q, want the session's own slug when it has no cwd", got)
+	}
+
+	for name, want := range map[string]bool{"api": true, "web-2": true, "My Session": false, "a--b": false, "x.y": false} {
+		if got := conformsSessionName(name); got != want {
+			t.Errorf("conformsSessionName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestMoveSessionState(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, schema := range []string{annotationSchema, prioritySchema} {
+		if _, err := db.Exec(schema); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := setAnnotation(db, targetSession, "Old Name", "reviewing"); err != nil {
+		t.Fatal(err)
+	}
+	if err := moveSessionState(db, "Old Name", "old-name"); err != nil {
+		t.Fatal(err)
+	}
+	set, err := loadAnnotations(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := set.forSession("Old Name"); ok {
+		t.Error("annotation still under the old name")
+	}
+	if a, ok := set.forSession("old-name"); !ok || a.Text != "reviewing" {
+		t.Errorf("annotation under new name = %+v, %v", a, ok)
+	}
+}
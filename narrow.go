@@ -0,0 +1,49 @@
+// narrow: stack the display columns when they don't fit side by side.
+//
+// columns sit side by side the way the monitors do, each a share of the
+// terminal's width. below minColumnWidth a column can't hold a row, and
+// clamping it there made the columns overrun the terminal and wrap into
+// each other. when the displays of one layout row can't each get
+// minColumnWidth, every column gets the full width instead, one under
+// the other in display order, each scrolling within its share of the
+// height. j and k run off the end of one column into the next, and h /
+// l still step between displays.
+
+package main
+
+// minColumnWidth is the narrowest a display column renders at.
+const minColumnWidth = 30
+
+// gridGeometry is how the grid lays out at the model's size: the width
+// of each column, the detail panel's width (0 when it's shut), and
+// whether the columns stack. monitors stacked on the desk render as
+// extra rows of columns, so the widest row sets the width.
+func (m model) gridGeometry() (colWidth, panelWidth int, stacked bool) {
+	const margin, gap = 2, 6
+	perRow := map[int]int{}
+	widest := 0
+	for _, dg := range m.displayGroups {
+		perRow[dg.layoutRow]++
+		widest = maxInt(widest, perRow[dg.layoutRow])
+	}
+	if m.zoom {
+		widest = 1 // the cursor's column alone (zoom.go)
+	}
+	availWidth := m.width - 2*margin
+	// the detail panel takes the right side of the grid's width
+	gridWidth := availWidth
+	if m.detail && !m.showAgents && !m.zoom {
+		panelWidth = maxInt(availWidth*2/5, detailPanelMinWidth)
+		gridWidth = availWidth - panelWidth - gap
+	}
+	colWidth = gridWidth
+	if widest > 1 {
+		colWidth = (gridWidth - gap*(widest-1)) / widest
+		// m.width is 0 until the terminal reports its size
+		stacked = m.width > 0 && colWidth < minColumnWidth
+	}
+	if stacked {
+		colWidth = gridWidth
+	}
+	return maxInt(colWidth, minColumnWidth), panelWidth, stacked
+}
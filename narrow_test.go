@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNarrowTerminalStacksColumns(t *testing.T) {
+	next, _ := newModel().handleData(fixtureResult(t, "desk.json"))
+	m := next.(model)
+
+	m.width, m.height = 160, 50
+	if w, _, stacked := m.gridGeometry(); stacked || w != 75 {
+		t.Errorf("160 wide: column %d, stacked %v", w, stacked)
+	}
+
+	m.width = 60
+	w, _, stacked := m.gridGeometry()
+	if !stacked || w != 56 {
+		t.Fatalf("60 wide: column %d, stacked %v", w, stacked)
+	}
+	// the second display's header sits below the first's rows
+	view := m.renderView()
+	first, second := strings.Index(view, "api"), strings.Index(view, "display 2")
+	if first < 0 || second < first {
+		t.Errorf("columns aren't stacked:\n%s", view)
+	}
+
+	// j off the end of the first column lands in the second
+	m.cursorCol, m.cursorRow = 0, len(m.displayGroups[0].spaces)-1
+	next, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	if m = next.(model); m.cursorCol != 1 || m.cursorRow != 0 {
+		t.Errorf("j moved to column %d row %d", m.cursorCol, m.cursorRow)
+	}
+	next, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	if m = next.(model); m.cursorCol != 0 {
+		t.Errorf("k stayed in column %d", m.cursorCol)
+	}
+}
@@ -0,0 +1,118 @@
+// power: ease off while on battery or running hot.
+//
+// a refresh every two seconds costs little on the desk and more on a
+// laptop unplugged in a café, or one the system is already throttling
+// for heat. every so often the fetch asks whether the machine is on
+// battery (pmset -g batt on macOS, /sys/class/power_supply on linux) and
+// whether it's under thermal pressure (pmset -g therm), and while either
+// holds — as far as throttleOnBattery and throttleOnThermal allow — the
+// poll interval stretches to throttledPollInterval (budget.go) and the
+// agent screens agentstate.go reads with capture-pane are skipped, their
+// last states held. the budget line says why refreshes slowed.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// powerCheckInterval is how long a power reading is reused; pmset isn't
+// worth running every refresh.
+const powerCheckInterval = 30 * time.Second
+
+var (
+	powerMu     sync.Mutex
+	powerAt     time.Time
+	powerReason string
+)
+
+// throttleReason says why refreshes should slow down at now — "on
+// battery", "thermal pressure" — or "" when they shouldn't.
+func throttleReason(now time.Time) string {
+	if !throttleOnBattery && !throttleOnThermal {
+		return ""
+	}
+	powerMu.Lock()
+	defer powerMu.Unlock()
+	if powerAt.IsZero() || now.Sub(powerAt) >= powerCheckInterval {
+		battery, thermal := queryPower()
+		powerReason, powerAt = throttleFor(battery, thermal), now
+	}
+	return powerReason
+}
+
+// throttleFor applies the policy in config.go to a power reading.
+func throttleFor(battery, thermal bool) string {
+	switch {
+	case thermal && throttleOnThermal:
+		return "thermal pressure"
+	case battery && throttleOnBattery:
+		return "on battery"
+	}
+	return ""
+}
+
+// queryPower reads whether the machine runs on battery and whether it's
+// thermally throttled. anything that can't be read counts as no.
+func queryPower() (battery, thermal bool) {
+	if runtime.GOOS != "darwin" {
+		return sysOnBattery("/sys/class/power_supply"), false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, "pmset", "-g", "batt").Output(); err == nil {
+		battery = parsePmsetBattery(string(out))
+	}
+	if out, err := exec.CommandContext(ctx, "pmset", "-g", "therm").Output(); err == nil {
+		thermal = parsePmsetThermal(string(out))
+	}
+	return battery, thermal
+}
+
+// parsePmsetBattery reads `pmset -g batt`'s "Now drawing from 'Battery
+// Power'".
+func parsePmsetBattery(out string) bool {
+	return strings.Contains(out, "'Battery Power'")
+}
+
+// parsePmsetThermal reads `pmset -g therm`: a recorded thermal warning
+// level, or a CPU speed limit under 100%, is pressure.
+func parsePmsetThermal(out string) bool {
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		line := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if strings.Contains(line, "thermal warning level") && !strings.Contains(line, "no thermal warning") {
+			return true
+		}
+		if _, value, ok := strings.Cut(line, "cpu_speed_limit"); ok {
+			limit, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "=")))
+			if err == nil && limit < 100 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sysOnBattery is whether any battery under dir (linux's power_supply
+// class) is discharging.
+func sysOnBattery(dir string) bool {
+	supplies, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, s := range supplies {
+		kind, _ := os.ReadFile(filepath.Join(s, "type"))
+		status, _ := os.ReadFile(filepath.Join(s, "status"))
+		if strings.TrimSpace(string(kind)) == "Battery" && strings.TrimSpace(string(status)) == "Discharging" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePmset(t *testing.T) {
+	if !parsePmsetBattery("Now drawing from 'Battery Power'\n -InternalBattery-0 (id=1)\t64%; discharging") {
+		t.Error("battery not recognised")
+	}
+	if parsePmsetBattery("Now drawing from 'AC Power'\n") {
+		t.Error("AC read as battery")
+	}
+
+	cool := "Note: No thermal warning level has been recorded\nNote: No performance warning level has been recorded\n" +
+		"2026-10-16 12:00:00 +0000 CPU Power notify\n\tCPU_Scheduler_Limit \t= 100\n\tCPU_Speed_Limit \t= 100\n"
+	if parsePmsetThermal(cool) {
+		t.Error("cool machine read as throttled")
+	}
+	if !parsePmsetThermal(strings.Replace(cool, "CPU_Speed_Limit \t= 100", "CPU_Speed_Limit \t= 72", 1)) {
+		t.Error("speed limit not read")
+	}
+	if !parsePmsetThermal("Thermal warning level set to 1.\n") {
+		t.Error("warning level not read")
+	}
+}
+
+func TestSysOnBattery(t *testing.T) {
+	dir := t.TempDir()
+	supply := func(name, kind, status string) {
+		os.MkdirAll(filepath.Join(dir, name), 0o755)
+		os.WriteFile(filepath.Join(dir, name, "type"), []byte(kind+"\n"), 0o644)
+		os.WriteFile(filepath.Join(dir, name, "status"), []byte(status+"\n"), 0o644)
+	}
+	supply("AC", "Mains", "")
+	supply("BAT0", "Battery", "Charging")
+	if sysOnBattery(dir) {
+		t.Error("charging read as on battery")
+	}
+	supply("BAT0", "Battery", "Discharging")
+	if !sysOnBattery(dir) {
+		t.Error("discharging not read")
+	}
+}
+
+func TestThrottleSlowsPolling(t *testing.T) {
+	defer func(b, th bool) { throttleOnBattery, throttleOnThermal = b, th }(throttleOnBattery, throttleOnThermal)
+	throttleOnBattery, throttleOnThermal = true, true
+	if throttleFor(true, true) != "thermal pressure" || throttleFor(true, false) != "on battery" || throttleFor(false, false) != "" {
+		t.Error("policy")
+	}
+	throttleOnBattery = false
+	if throttleFor(true, false) != "" {
+		t.Error("battery throttled with throttleOnBattery off")
+	}
+
+	b := newRefreshBudget()
+	b.setThrottle("on battery")
+	if b.pollInterval() != throttledPollInterval || !strings.Contains(renderBudgetLine(b), "on battery") {
+		t.Errorf("throttled interval = %s, line %q", b.pollInterval(), renderBudgetLine(b))
+	}
+	// a load-stretched interval past the throttle stays
+	b.interval = maxPollInterval
+	if b.pollInterval() != maxPollInterval {
+		t.Errorf("interval = %s", b.pollInterval())
+	}
+	b.setThrottle("")
+	b.interval = basePollInterval
+	if b.pollInterval() != basePollInterval || renderBudgetLine(b) != "" {
+		t.Error("unthrottled budget should be back at the base")
+	}
+
+	// throttled refreshes keep the last agent states
+	m := newModel()
+	m.paneStates = map[int]paneState{100: paneWorking}
+	result := fixtureResult(t, "desk.json")
+	result.throttle = "on battery"
+	next, _ := m.handleData(result)
+	if m = next.(model); m.paneStates[100] != paneWorking || m.budget.pollInterval() != throttledPollInterval {
+		t.Errorf("states = %v, interval %s", m.paneStates, m.budget.pollInterval())
+	}
+}
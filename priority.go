@@ -0,0 +1,250 @@
+// priority: high / normal / low triage levels for sessions and spaces.
+//
+// flat staleness coloring treats every agent the same. priority adds
+// triage on top: high-priority items escalate through the staleness
+// colors twice as fast, render bold, sort first, and need attention
+// sooner; low-priority items render faint and never ask for attention.
+// levels live in the snapshot db next to annotations and use the same
+// session / space targets.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const prioritySchema = `
+CREATE TABLE IF NOT EXISTS priorities (
+	target_kind TEXT NOT NULL,
+	target TEXT NOT NULL,
+	level TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (target_kind, target)
+);
+`
+
+// priority is a triage level. the zero value is normal so anything
+// without an explicit level behaves exactly as before.
+type priority int
+
+const (
+	priorityLow    priority = -1
+	priorityNormal priority = 0
+	priorityHigh   priority = 1
+)
+
+func (p priority) String() string {
+	switch p {
+	case priorityHigh:
+		return "high"
+	case priorityLow:
+		return "low"
+	}
+	return "normal"
+}
+
+// parsePriority accepts the level names used by the CLI and API.
+func parsePriority(s string) (priority, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "high", "hi":
+		return priorityHigh, nil
+	case "normal", "", "default":
+		return priorityNormal, nil
+	case "low", "lo":
+		return priorityLow, nil
+	}
+	return priorityNormal, fmt.Errorf("unknown priority %q (want high, normal, or low)", s)
+}
+
+// attentionThreshold is how stale a productive item must get before it's
+// flagged as needing attention. ok=false means the level never alerts.
+func (p priority) attentionThreshold() (time.Duration, bool) {
+	switch p {
+	case priorityHigh:
+		return 5 * time.Minute, true
+	case priorityLow:
+		return 0, false
+	}
+	return 15 * time.Minute, true
+}
+
+// needsAttention reports whether an item last active at lastActivity has
+// gone stale past its priority's threshold.
+func (p priority) needsAttention(lastActivity time.Time) bool {
+	threshold, ok := p.attentionThreshold()
+	return ok && stalenessAge(lastActivity) >= threshold
+}
+
+// stalenessStyle wraps the global staleness tiers with priority: high
+// items are judged at double their real age and rendered bold; low items
+// render faint.
+func (p priority) stalenessStyle(lastActivity time.Time) lipgloss.Style {
+	switch p {
+	case priorityHigh:
+		return stalenessStyle(lastActivity.Add(-stalenessAge(lastActivity))).Bold(true)
+	case priorityLow:
+		return stalenessStyle(lastActivity).Faint(true)
+	}
+	return stalenessStyle(lastActivity)
+}
+
+// prioritySet indexes levels by kind then target. a nil set is valid and
+// reports normal for everything.
+type prioritySet map[string]map[string]priority
+
+// forSession returns the level for a tmux session name.
+func (s prioritySet) forSession(name string) priority {
+	return s[targetSession][name]
+}
+
+// forSpace returns the level for a space, matching label or absolute index.
+func (s prioritySet) forSpace(space Space) priority {
+	if space.Label != "" {
+		if p, ok := s[targetSpace][space.Label]; ok {
+			return p
+		}
+	}
+	return s[targetSpace][strconv.Itoa(space.Index)]
+}
+
+// setPriority upserts a level; normal deletes the row since it's the default.
+func setPriority(db *sql.DB, kind, target string, p priority) error {
+	if kind != targetSession && kind != targetSpace {
+		return fmt.Errorf("unknown priority target kind %q", kind)
+	}
+	if target == "" {
+		return fmt.Errorf("priority target is required")
+	}
+	if p == priorityNormal {
+		_, err := db.Exec("DELETE FROM priorities WHERE target_kind = ? AND target = ?", kind, target)
+		return err
+	}
+	_, err := db.Exec(
+		"INSERT INTO priorities (target_kind, target, level, updated_at) VALUES (?, ?, ?, ?) ON CONFLICT (target_kind, target) DO UPDATE SET level = excluded.level, updated_at = excluded.updated_at",
+		kind, target, p.String(), time.Now().UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// loadPriorities reads every stored level into a prioritySet.
+func loadPriorities(db *sql.DB) (prioritySet, error) {
+	rows, err := db.Query("SELECT target_kind, target, level FROM priorities")
+	if err != nil {
+		return nil, fmt.Errorf("querying priorities: %w", err)
+	}
+	defer rows.Close()
+
+	set := prioritySet{}
+	for rows.Next() {
+		var kind, target, level string
+		if err := rows.Scan(&kind, &target, &level); err != nil {
+			continue
+		}
+		p, err := parsePriority(level)
+		if err != nil {
+			continue
+		}
+		if set[kind] == nil {
+			set[kind] = map[string]priority{}
+		}
+		set[kind][target] = p
+	}
+	return set, rows.Err()
+}
+
+// queryPriorities returns current levels, or nil when the db is unavailable.
+func queryPriorities() prioritySet {
+	db := sharedStateDB()
+	if db == nil {
+		return nil
+	}
+	set, err := loadPriorities(db)
+	if err != nil {
+		return nil
+	}
+	return set
+}
+
+// priorityRequest is the POST /priorities body, mirroring annotations.
+type priorityRequest struct {
+	Session string `json:"session"`
+	Space   string `json:"space"`
+	Level   string `json:"level"`
+}
+
+// handlePriorities serves GET (list all) and POST (set one).
+func handlePriorities(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			set, err := loadPriorities(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			var list []map[string]any
+			for kind, byTarget := range set {
+				for target, p := range byTarget {
+					list = append(list, map[string]any{"kind": kind, "target": target, "level": p.String()})
+				}
+			}
+			writeJSON(w, list)
+		case http.MethodPost:
+			var req priorityRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if req.Session != "" && req.Space != "" {
+				http.Error(w, "set either session or space, not both", http.StatusBadRequest)
+				return
+			}
+			kind, target := targetSession, req.Session
+			if req.Space != "" {
+				kind, target = targetSpace, req.Space
+			}
+			p, err := parsePriority(req.Level)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := setPriority(db, kind, target, p); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// priorityCommand is the entry point for `stop priority`.
+func priorityCommand(session, space, level string) error {
+	if (session == "") == (space == "") {
+		return fmt.Errorf("exactly one of --session or --space is required")
+	}
+	p, err := parsePriority(level)
+	if err != nil {
+		return err
+	}
+	kind, target := targetSession, session
+	if space != "" {
+		kind, target = targetSpace, space
+	}
+	db, err := openSnapshotDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return setPriority(db, kind, target, p)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriorityAttention(t *testing.T) {
+	tenMinutesAgo := time.Now().Add(-10 * time.Minute)
+	if !priorityHigh.needsAttention(tenMinutesAgo) {
+		t.Error("high priority should need attention after 10m")
+	}
+	if priorityNormal.needsAttention(tenMinutesAgo) {
+		t.Error("normal priority shouldn't need attention until 15m")
+	}
+	if priorityLow.needsAttention(time.Now().Add(-24 * time.Hour)) {
+		t.Error("low priority should never need attention")
+	}
+}
+
+func TestPrioritySetLookup(t *testing.T) {
+	set := prioritySet{
+		targetSession: {"api": priorityHigh},
+		targetSpace:   {"web": priorityLow, "3": priorityHigh},
+	}
+	if set.forSession("api") != priorityHigh || set.forSession("other") != priorityNormal {
+		t.Error("session lookup wrong")
+	}
+	if set.forSpace(Space{Index: 3, Label: "web"}) != priorityLow {
+		t.Error("label should win over index")
+	}
+	if set.forSpace(Space{Index: 3}) != priorityHigh {
+		t.Error("index lookup wrong")
+	}
+	var empty prioritySet
+	if empty.forSpace(Space{Index: 1}) != priorityNormal {
+		t.Error("nil set should report normal")
+	}
+	if _, err := parsePriority("urgent"); err == nil {
+		t.Error("expected parse error")
+	}
+}
@@ -0,0 +1,96 @@
+// prompts: notice the moment an agent stops to ask something.
+//
+// an agent asking for permission sits idle until someone answers, and
+// staleness alerts (alerts.go) only fire after it's been waiting a while.
+// agentstate.go already reads each agent pane's screen; here the states
+// of consecutive refreshes are compared, and a pane that has just turned
+// "awaiting input" gets a desktop notification (with promptNotify on)
+// and a status line. while any pane on a space is awaiting input the
+// space is marked ◆ in the grid. the first refresh only sets the
+// baseline, so starting the dashboard doesn't announce every prompt that
+// was already open.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// newPrompts lists the panes that are awaiting input now and weren't at
+// the previous refresh; none on the first refresh.
+func newPrompts(prev, next map[int]paneState, panes []TmuxPane, first bool) []TmuxPane {
+	if first {
+		return nil
+	}
+	var prompted []TmuxPane
+	for _, p := range panes {
+		if next[p.PanePID] == paneAwaiting && prev[p.PanePID] != paneAwaiting {
+			prompted = append(prompted, p)
+		}
+	}
+	sort.Slice(prompted, func(i, j int) bool {
+		if prompted[i].SessionName != prompted[j].SessionName {
+			return prompted[i].SessionName < prompted[j].SessionName
+		}
+		return prompted[i].PaneIndex < prompted[j].PaneIndex
+	})
+	return prompted
+}
+
+// promptMessage is how a prompting pane is announced, by its agent's
+// name when the pane's command is the agent itself.
+func promptMessage(p TmuxPane) string {
+	who := p.CurrentCommand
+	if !isProductive(who) {
+		who = "an agent"
+	}
+	return fmt.Sprintf("%s in %s is waiting for input", who, p.SessionName)
+}
+
+// promptStatus is the status line for a refresh's new prompts.
+func promptStatus(prompted []TmuxPane) string {
+	if len(prompted) == 1 {
+		return promptMessage(prompted[0])
+	}
+	sessions := make([]string, len(prompted))
+	for i, p := range prompted {
+		sessions[i] = p.SessionName
+	}
+	return fmt.Sprintf("%d agents waiting for input: %s", len(prompted), strings.Join(sessions, ", "))
+}
+
+// notifyPromptsCmd sends one desktop notification per prompting pane.
+// only a failure comes back as a message.
+func notifyPromptsCmd(prompted []TmuxPane) tea.Cmd {
+	return func() tea.Msg {
+		for _, p := range prompted {
+			err := notifyDesktop("stop: "+p.SessionName, promptMessage(p))
+			if err != nil && !errors.Is(err, engine.ErrReadOnly) {
+				return actionDoneMsg{status: "prompt notification failed", err: err}
+			}
+		}
+		return nil
+	}
+}
+
+// awaitingOnSpace reports whether any pane of the space's terminal
+// sessions is awaiting input.
+func awaitingOnSpace(row spaceRow, tmuxBySession map[string][]TmuxPane, states map[int]paneState) bool {
+	for _, w := range row.windows {
+		if !engine.IsTerminal(w.App) {
+			continue
+		}
+		for _, p := range tmuxBySession[strings.TrimSpace(w.Title)] {
+			if states[p.PanePID] == paneAwaiting {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestNewPrompts(t *testing.T) {
+	panes := []TmuxPane{
+		{SessionName: "web", PanePID: 1, CurrentCommand: "claude"},
+		{SessionName: "api", PanePID: 2, CurrentCommand: "node"},
+		{SessionName: "api", PanePID: 3, CurrentCommand: "codex"},
+	}
+	prev := map[int]paneState{1: paneWorking, 3: paneAwaiting}
+	next := map[int]paneState{1: paneAwaiting, 2: paneAwaiting, 3: paneAwaiting}
+
+	if got := newPrompts(nil, next, panes, true); got != nil {
+		t.Errorf("first refresh announced %v", got)
+	}
+	got := newPrompts(prev, next, panes, false)
+	if len(got) != 2 || got[0].PanePID != 2 || got[1].PanePID != 1 {
+		t.Fatalf("prompted = %+v", got)
+	}
+	if s := promptStatus(got); s != "2 agents waiting for input: api, web" {
+		t.Errorf("status = %q", s)
+	}
+	if s := promptStatus(got[1:]); s != "claude in web is waiting for input" {
+		t.Errorf("status = %q", s)
+	}
+	if s := promptMessage(got[0]); s != "an agent in api is waiting for input" {
+		t.Errorf("message = %q", s)
+	}
+
+	row := spaceRow{windows: []Window{{App: "kitty", Title: "api"}}}
+	bySession := map[string][]TmuxPane{"api": panes[1:]}
+	if !awaitingOnSpace(row, bySession, next) || awaitingOnSpace(row, bySession, map[int]paneState{3: paneWorking}) {
+		t.Error("awaitingOnSpace")
+	}
+}
@@ -0,0 +1,130 @@
+// scratchpad: windows that are toggled in and out of view by label.
+//
+// yabai 6 scratchpad windows (and i3 scratchpad windows with a mark) are
+// part of the working set but belong to no space: hidden they sit in
+// none of the grid's rows, shown they float over whichever space is
+// current. a line under the grid names them; P opens a panel listing
+// each with its app, title and whether it's showing, where j/k pick one
+// and enter toggles it.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// scratchPanel is the open P panel; sel indexes scratchpadWindows.
+type scratchPanel struct {
+	sel int
+}
+
+// scratchpadWindows lists the labeled scratchpad windows by label.
+func scratchpadWindows(windows []Window) []Window {
+	var pad []Window
+	for _, w := range windows {
+		if w.Scratchpad != "" {
+			pad = append(pad, w)
+		}
+	}
+	sort.Slice(pad, func(i, j int) bool { return pad[i].Scratchpad < pad[j].Scratchpad })
+	return pad
+}
+
+// scratchpadShowing reports whether a scratchpad window is on screen.
+func scratchpadShowing(w Window) bool {
+	return w.IsVisible && !w.IsHidden && !w.IsMinimized
+}
+
+func toggleScratchpadCmd(label string) tea.Cmd {
+	return func() tea.Msg {
+		if err := currentWM().ToggleScratchpad(label); err != nil {
+			return actionDoneMsg{status: "toggling " + label + " failed", err: err}
+		}
+		return actionDoneMsg{status: "toggled " + label}
+	}
+}
+
+// openScratchpad opens the panel, or says there's nothing to show.
+func (m model) openScratchpad() model {
+	if len(scratchpadWindows(m.windows)) == 0 {
+		m.status = "no scratchpad windows"
+		return m
+	}
+	m.scratch = &scratchPanel{}
+	return m
+}
+
+func (m model) handleScratchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	pad := scratchpadWindows(m.windows)
+	p := *m.scratch
+	p.sel = min(p.sel, len(pad)-1)
+	switch key := msg.String(); {
+	case key == "esc" || activeKeymap.action(key) == actScratchpad:
+		m.scratch = nil
+		return m, nil
+	case activeKeymap.action(key) == actDown:
+		if p.sel < len(pad)-1 {
+			p.sel++
+		}
+	case activeKeymap.action(key) == actUp:
+		if p.sel > 0 {
+			p.sel--
+		}
+	case key == "enter":
+		if p.sel < len(pad) {
+			return m, toggleScratchpadCmd(pad[p.sel].Scratchpad)
+		}
+	}
+	m.scratch = &p
+	return m, nil
+}
+
+// renderScratchpad is the panel when open, a one-line summary of the
+// labels (bright when showing) otherwise, and nothing without any
+// scratchpad windows.
+func (m model) renderScratchpad(width int, pad string) string {
+	windows := scratchpadWindows(m.windows)
+	if len(windows) == 0 {
+		return ""
+	}
+	if m.scratch == nil {
+		labels := make([]string, len(windows))
+		for i, w := range windows {
+			labels[i] = dimStyle.Render(w.Scratchpad)
+			if scratchpadShowing(w) {
+				labels[i] = w.Scratchpad
+			}
+		}
+		return "\n" + pad + dimStyle.Render("scratchpad ") + strings.Join(labels, dimStyle.Render(", ")) + "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString("\n" + pad + displayStyle.Render("scratchpad") + "  " +
+		keyStyle.Render("enter") + " " + helpStyle.Render("toggle") + "  " +
+		keyStyle.Render("esc") + " " + helpStyle.Render("close") + "\n")
+	labelWidth := 0
+	for _, w := range windows {
+		labelWidth = max(labelWidth, len(w.Scratchpad))
+	}
+	for i, w := range windows {
+		cursor := "  "
+		if i == m.scratch.sel {
+			cursor = cursorStyle.Render("> ")
+		}
+		state := dimStyle.Render("hidden ")
+		if scratchpadShowing(w) {
+			state = freeStyle.Render("showing")
+		}
+		text := w.App
+		if w.Title != "" {
+			text += ": " + w.Title
+		}
+		line := fmt.Sprintf("%s%-*s  %s  %s", cursor, labelWidth, w.Scratchpad, state, text)
+		b.WriteString(pad + truncateForWidth(line, maxInt(width, 10)) + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestScratchpadPanel(t *testing.T) {
+	m := newModel()
+	m.displayGroups = []displayGroup{{spaces: []spaceRow{{space: Space{Index: 1}}}}}
+	if m = m.openScratchpad(); m.scratch != nil || m.status != "no scratchpad windows" {
+		t.Fatalf("opened without scratchpad windows: %+v", m.scratch)
+	}
+
+	m.windows = []Window{
+		{ID: 1, App: "kitty", Title: "scratch", Scratchpad: "term", IsMinimized: true},
+		{ID: 2, App: "Safari", Space: 1, IsVisible: true},
+		{ID: 3, App: "Notes", Title: "todo", Scratchpad: "notes", IsVisible: true},
+	}
+	pad := scratchpadWindows(m.windows)
+	if len(pad) != 2 || pad[0].Scratchpad != "notes" || pad[1].Scratchpad != "term" {
+		t.Fatalf("scratchpad windows = %+v", pad)
+	}
+	if line := m.renderScratchpad(80, ""); !strings.Contains(line, "scratchpad") || !strings.Contains(line, "notes") {
+		t.Errorf("summary = %q", line)
+	}
+
+	next, _ := m.handleAction(actScratchpad)
+	m = next.(model)
+	if m.scratch == nil {
+		t.Fatal("P didn't open the panel")
+	}
+	next, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = next.(model)
+	if m.scratch.sel != 1 {
+		t.Errorf("sel = %d after j", m.scratch.sel)
+	}
+	panel := m.renderScratchpad(80, "")
+	if lines := strings.Split(strings.TrimSpace(panel), "\n"); len(lines) != 3 || !strings.Contains(lines[1], "showing") || !strings.Contains(lines[2], "hidden") {
+		t.Errorf("panel = %q", panel)
+	}
+	if _, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil {
+		t.Error("enter didn't toggle")
+	}
+	next, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+	if next.(model).scratch != nil {
+		t.Error("esc didn't close the panel")
+	}
+}
@@ -0,0 +1,120 @@
+// scroll: per-column viewports for displays with more spaces than fit.
+//
+// a column is a fixed header, the space rows, and a fixed summary. when
+// the rows don't fit the height the layout allots them, only a window of
+// them renders, with "↑ n more" / "↓ n more" lines marking what's cut off.
+// each column remembers its offset (model.scroll) and moves it only as far
+// as needed to keep the cursor's row in view, so scrolling feels like a
+// pager rather than re-centering on every keypress. on very short
+// terminals the summary goes first, then the indicators.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// viewport lays the column out in at most height physical lines (0 means
+// unlimited) and returns the lines, a click target per line, and the
+// scroll offset to remember. cursorRow is -1 for columns without the
+// cursor; their offset is only clamped.
+func (c renderedColumn) viewport(colStyle lipgloss.Style, height, cursorRow, offset int) ([]string, []hitTarget, int) {
+	// wrap up front so every entry below is exactly one screen line
+	wrap := func(s string) []string { return strings.Split(colStyle.Render(s), "\n") }
+
+	header := wrap(c.header)
+	footer := append([]string{""}, wrap(c.footer)...)
+	var body []string
+	var bodyTargets []hitTarget
+	rowStart := make([]int, len(c.rows))
+	rowEnd := make([]int, len(c.rows))
+	for i, row := range c.rows {
+		rowStart[i] = len(body)
+		if row == "" { // folded away (spacegroups.go): no lines at all
+			rowEnd[i] = len(body)
+			continue
+		}
+		for j, line := range strings.Split(row, "\n") {
+			var t hitTarget
+			if j < len(c.targets[i]) {
+				t = c.targets[i][j]
+			}
+			for _, phys := range wrap(line) {
+				body = append(body, phys)
+				bodyTargets = append(bodyTargets, t)
+			}
+		}
+		rowEnd[i] = len(body)
+	}
+
+	assemble := func(top, mid, bottom []string, midTargets []hitTarget, midAt int) ([]string, []hitTarget) {
+		lines := append(append(append([]string{}, top...), mid...), bottom...)
+		targets := make([]hitTarget, len(lines))
+		copy(targets[midAt:], midTargets)
+		return lines, targets
+	}
+
+	if height <= 0 || len(header)+len(body)+len(footer) <= height {
+		lines, targets := assemble(header, body, footer, bodyTargets, len(header))
+		return lines, targets, 0
+	}
+
+	avail := height - len(header) - len(footer)
+	if avail < 3 {
+		footer = nil
+		avail = height - len(header)
+	}
+	indicators := avail >= 3
+	visible := max(avail, 1)
+	if indicators {
+		visible -= 2
+	}
+	visible = min(visible, len(body))
+
+	if cursorRow >= 0 && cursorRow < len(c.rows) {
+		c0, c1 := rowStart[cursorRow], rowEnd[cursorRow]
+		if c1-c0 > visible {
+			c1 = c0 + visible // a row taller than the window shows its top
+		}
+		if c0 < offset {
+			offset = c0
+		}
+		if c1 > offset+visible {
+			offset = c1 - visible
+		}
+	}
+	offset = max(0, min(offset, len(body)-visible))
+
+	var above, below int
+	for i := range c.rows {
+		if rowStart[i] == rowEnd[i] {
+			continue
+		}
+		if rowStart[i] < offset {
+			above++
+		}
+		if rowEnd[i] > offset+visible {
+			below++
+		}
+	}
+
+	mid := body[offset : offset+visible]
+	midTargets := bodyTargets[offset : offset+visible]
+	if !indicators {
+		lines, targets := assemble(header, mid, footer, midTargets, len(header))
+		return lines, targets, offset
+	}
+	indicator := func(arrow string, n int) string {
+		if n == 0 {
+			return ""
+		}
+		return dimStyle.Render(fmt.Sprintf("  %s %d more", arrow, n))
+	}
+	mid = append(append([]string{indicator("↑", above)}, mid...), indicator("↓", below))
+	midTargets = append(append([]hitTarget{{}}, midTargets...), hitTarget{})
+	lines, targets := assemble(header, mid, footer, midTargets, len(header))
+	return lines, targets, offset
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func testColumn(n int) renderedColumn {
+	c := renderedColumn{header: "display 1", footer: "3 free"}
+	for i := 0; i < n; i++ {
+		c.rows = append(c.rows, fmt.Sprintf("space %d", i))
+		c.targets = append(c.targets, []hitTarget{{kind: hitSpace, row: i}})
+	}
+	return c
+}
+
+func TestViewportFollowsCursor(t *testing.T) {
+	style := lipgloss.NewStyle().Width(30)
+	c := testColumn(10)
+
+	lines, targets, offset := c.viewport(style, 8, 5, 0)
+	if len(lines) != 8 {
+		t.Fatalf("got %d lines, want 8:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	if offset != 3 {
+		t.Errorf("offset = %d, want 3 (cursor row at the bottom edge)", offset)
+	}
+	if !strings.Contains(lines[1], "↑ 3 more") || !strings.Contains(lines[5], "↓ 4 more") {
+		t.Errorf("indicators missing:\n%s", strings.Join(lines, "\n"))
+	}
+	if targets[4].kind != hitSpace || targets[4].row != 5 {
+		t.Errorf("line 4 target = %+v, want space row 5", targets[4])
+	}
+
+	// moving back up inside the window doesn't scroll
+	if _, _, again := c.viewport(style, 8, 4, offset); again != offset {
+		t.Errorf("offset moved to %d for a cursor already in view", again)
+	}
+
+	// everything fits: no scrolling, no indicators
+	if lines, _, _ := c.viewport(style, 0, 9, 7); len(lines) != 13 {
+		t.Errorf("unlimited height rendered %d lines, want 13", len(lines))
+	}
+}
+
+func TestViewportTinyTerminal(t *testing.T) {
+	style := lipgloss.NewStyle().Width(30)
+	lines, targets, _ := testColumn(10).viewport(style, 2, 7, 0)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	if targets[1].row != 7 {
+		t.Errorf("visible row = %d, want the cursor's row 7", targets[1].row)
+	}
+}
@@ -0,0 +1,133 @@
+// scrollback: the end of a pane's output, over HTTP.
+//
+// /tmux/{session}/{window}/{pane}/tail?lines=50 answers with the last
+// lines a pane printed, scrollback included, so the Rose app can peek at
+// what an agent said without a terminal. the target must name a pane
+// tmux reports right now — nothing from the URL reaches tmux unchecked —
+// and the answer is capped at scrollbackMaxLines lines and
+// scrollbackMaxBytes bytes, keeping the newest. JSON by default;
+// ?format=text gives the bare lines. only with paneTails on (config.go,
+// or `stop serve --tails`): a pane's output can hold secrets, so it's
+// opt-in, and unlike the rest of serve it sends no CORS header, so a web
+// page open in a local browser can't read it.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// scrollback limits: a default, and caps no request can raise
+const (
+	scrollbackDefaultLines = 50
+	scrollbackMaxLines     = 1000
+	scrollbackMaxBytes     = 64 << 10
+)
+
+// findPane is the pane tmux reports at session:window.pane, if any.
+func findPane(panes []TmuxPane, session string, window, pane int) (TmuxPane, bool) {
+	for _, p := range panes {
+		if p.SessionName == session && p.WindowIndex == window && p.PaneIndex == pane {
+			return p, true
+		}
+	}
+	return TmuxPane{}, false
+}
+
+// captureScrollback returns a pane's last n lines, reaching back into
+// its history, without the blank lines below the prompt.
+func captureScrollback(p TmuxPane, n int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	target := fmt.Sprintf("%s:%d.%d", p.SessionName, p.WindowIndex, p.PaneIndex)
+	args := []string{"capture-pane", "-p", "-J", "-S", strconv.Itoa(-n), "-t", target}
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, "tmux", args...).Output()
+	engine.LogCommand(start, "tmux", args, err)
+	if err != nil {
+		return nil, fmt.Errorf("capturing %s: %w", target, err)
+	}
+	return limitScrollback(strings.Split(string(out), "\n"), n, scrollbackMaxBytes), nil
+}
+
+// limitScrollback trims trailing blank lines and right-hand padding, then
+// keeps the newest lines that fit in n lines and maxBytes bytes.
+func limitScrollback(lines []string, n, maxBytes int) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	size := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		lines[i] = strings.TrimRight(lines[i], " ")
+		size += len(lines[i]) + 1
+		if size > maxBytes {
+			return lines[i+1:]
+		}
+	}
+	return lines
+}
+
+// handlePaneTail serves /tmux/{session}/{window}/{pane}/tail.
+func handlePaneTail(w http.ResponseWriter, r *http.Request) {
+	window, werr := strconv.Atoi(r.PathValue("window"))
+	pane, perr := strconv.Atoi(r.PathValue("pane"))
+	if werr != nil || perr != nil {
+		http.Error(w, "window and pane must be numbers", http.StatusBadRequest)
+		return
+	}
+	n := scrollbackDefaultLines
+	if s := r.URL.Query().Get("lines"); s != "" {
+		parsed, err := strconv.Atoi(s)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "lines must be a positive number", http.StatusBadRequest)
+			return
+		}
+		n = min(parsed, scrollbackMaxLines)
+	}
+
+	panes, err := currentQuerier().QueryTmuxPanes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	session := r.PathValue("session")
+	p, ok := findPane(panes, session, window, pane)
+	if !ok {
+		http.Error(w, fmt.Sprintf("no pane %s:%d.%d", session, window, pane), http.StatusNotFound)
+		return
+	}
+	lines, err := captureScrollback(p, n)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session":          p.SessionName,
+		"window":           p.WindowIndex,
+		"pane":             p.PaneIndex,
+		"command":          p.CurrentCommand,
+		"last_activity_ms": p.LastActivity.UnixMilli(),
+		"lines":            lines,
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimitScrollback(t *testing.T) {
+	out := "one\ntwo  \nthree\n\n  \n"
+	if got := limitScrollback(strings.Split(out, "\n"), 50, 1<<10); strings.Join(got, "|") != "one|two|three" {
+		t.Errorf("trimmed = %q", got)
+	}
+	if got := limitScrollback(strings.Split(out, "\n"), 2, 1<<10); strings.Join(got, "|") != "two|three" {
+		t.Errorf("last 2 = %q", got)
+	}
+	// the byte cap keeps the newest whole lines
+	if got := limitScrollback(strings.Split(out, "\n"), 50, 10); strings.Join(got, "|") != "two|three" {
+		t.Errorf("10 bytes = %q", got)
+	}
+	if got := limitScrollback([]string{"", ""}, 50, 1<<10); len(got) != 0 {
+		t.Errorf("blank = %q", got)
+	}
+}
+
+func TestFindPane(t *testing.T) {
+	panes := []TmuxPane{
+		{SessionName: "api", WindowIndex: 0, PaneIndex: 0, PanePID: 1},
+		{SessionName: "api", WindowIndex: 0, PaneIndex: 1, PanePID: 2},
+		{SessionName: "web", WindowIndex: 2, PaneIndex: 0, PanePID: 3},
+	}
+	if p, ok := findPane(panes, "api", 0, 1); !ok || p.PanePID != 2 {
+		t.Errorf("api:0.1 = %+v, %v", p, ok)
+	}
+	for _, target := range []struct {
+		session      string
+		window, pane int
+	}{{"api", 1, 0}, {"web", 2, 1}, {"api;kill-server", 0, 0}} {
+		if _, ok := findPane(panes, target.session, target.window, target.pane); ok {
+			t.Errorf("found %+v", target)
+		}
+	}
+}
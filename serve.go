@@ -11,7 +11,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/fadedlamp42/stop/engine"
 )
 
 // serveCommand starts an HTTP server that exposes space/tmux data as JSON,
@@ -21,6 +24,8 @@ func serveCommand(port int) {
 	snapshotDB, err := openSnapshotDB()
 	if err != nil {
 		log.Printf("snapshot db unavailable, running without persistence: %v", err)
+	} else {
+		log.Printf("snapshot db ready at %s", snapshotDBPath)
 	}
 
 	// start snapshot capture loop in background
@@ -31,16 +36,37 @@ func serveCommand(port int) {
 		go startSnapshotLoop(ctx, snapshotDB, 30*time.Second)
 	}
 
+	if mqttBroker != "" {
+		go startMQTTLoop(context.Background(), mqttInterval)
+	}
+
 	http.HandleFunc("/spaces", handleSpaces)
+
+	// change stream, refreshed only while a client is connected
+	hub := newEventHub()
+	go hub.run(context.Background(), 2*time.Second)
+	http.HandleFunc("/events", handleEvents(hub))
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
+	if paneTails {
+		http.HandleFunc("/tmux/{session}/{window}/{pane}/tail", handlePaneTail)
+	}
+	if spaceThumbnails {
+		http.HandleFunc("/preview/{space}", handlePreview(newThumbnailCache()))
+	}
 
 	// query endpoint for historical snapshots
 	if snapshotDB != nil {
 		http.HandleFunc("/snapshots", handleSnapshots(snapshotDB))
 		http.HandleFunc("/snapshots/latest", handleLatestSnapshot(snapshotDB))
+		http.HandleFunc("/annotations", handleAnnotations(snapshotDB))
+		http.HandleFunc("/agents", handleAgents(snapshotDB))
+		http.HandleFunc("/priorities", handlePriorities(snapshotDB))
+		http.HandleFunc("/slo", handleSLO(snapshotDB))
+		http.HandleFunc("/alerts", handleAlerts(snapshotDB))
+		http.HandleFunc("/alerts/ack", handleAlertAck(snapshotDB))
 	}
 
 	addr := fmt.Sprintf(":%d", port)
@@ -50,17 +76,29 @@ func serveCommand(port int) {
 	}
 }
 
-// handleSpaces returns the full yabai + tmux state as JSON.
+// handleSpaces returns the full yabai + tmux state as JSON; with
+// ?filtered=1, minus what the ignore rules leave out (ignore.go).
 func handleSpaces(w http.ResponseWriter, r *http.Request) {
 	result := fetchAll()
 	if result.err != nil {
 		http.Error(w, result.err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if filtered, _ := strconv.ParseBool(r.URL.Query().Get("filtered")); filtered {
+		result.spaces, result.windows = filterIgnored(result.spaces, result.windows)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(buildSpacesResponse(result))
+}
 
+// buildSpacesResponse serializes one fetch into the /spaces shape, which
+// `stop watch --json` streams too.
+func buildSpacesResponse(result fetchResult) map[string]any {
 	nowMS := time.Now().UnixMilli()
-	productiveActivity := bestProductiveActivity(result.tmuxPanes, result.productivePanePIDs)
-	groups := buildDisplayGroups(result.spaces, result.windows)
+	productiveActivity := withBareAgents(bestProductiveActivity(result.tmuxPanes, result.productivePanePIDs), result.windows, result.bareAgents)
+	groups := buildDisplayGroups(result.spaces, result.windows, result.displays)
 
 	// serialize displays
 	var displays []map[string]any
@@ -78,7 +116,7 @@ func handleSpaces(w http.ResponseWriter, r *http.Request) {
 			// compute freshness for this space from productive sessions
 			var freshestActivityMS int64
 			for _, w := range row.windows {
-				if !isTerminal(w.App) {
+				if !engine.IsTerminal(w.App) {
 					continue
 				}
 				if activity, ok := productiveActivity[w.Title]; ok {
@@ -89,20 +127,35 @@ func handleSpaces(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
-			spaces = append(spaces, map[string]any{
+			space := map[string]any{
 				"index":                i + 1,
 				"yabai_index":          row.space.Index,
 				"label":                row.space.Label,
+				"group":                spaceGroupOf(row.space.Label),
 				"has_focus":            row.space.HasFocus,
 				"is_visible":           row.space.IsVisible,
 				"windows":              windows,
 				"freshest_activity_ms": freshestActivityMS,
-			})
+				"priority":             result.priorities.forSpace(row.space).String(),
+				"live":                 string(spaceLive(row.windows, liveWindowRules)),
+			}
+			if a, ok := result.annotations.forSpace(row.space); ok {
+				space["annotation"] = a.Text
+			}
+			if e := enrichmentJSON(result.enrichments.forSpace(row.space)); e != nil {
+				space["enrichments"] = e
+			}
+			spaces = append(spaces, space)
 		}
 
 		displays = append(displays, map[string]any{
 			"index":      dg.index,
+			"uuid":       dg.display.UUID,
+			"name":       dg.display.Name,
+			"resolution": dg.display.Resolution(),
+			"layout_row": dg.layoutRow,
 			"spaces":     spaces,
+			"groups":     spaceGroupsJSON(dg),
 			"free_count": dg.freeCount,
 			"term_count": dg.termCount,
 		})
@@ -116,12 +169,28 @@ func handleSpaces(w http.ResponseWriter, r *http.Request) {
 		for _, wg := range sg.windows {
 			var panes []map[string]any
 			for _, p := range wg.panes {
-				panes = append(panes, map[string]any{
+				pane := map[string]any{
 					"command":          p.CurrentCommand,
 					"last_activity_ms": p.LastActivity.UnixMilli(),
 					"history_size":     p.HistorySize,
 					"productive":       result.productivePanePIDs[p.PanePID],
-				})
+					"tier":             stalenessTiers[stalenessTier(p.LastActivity)].name,
+				}
+				// progress only appears when the pane published one, so
+				// clients can treat a missing key as "no progress reported"
+				if p.Progress >= 0 {
+					pane["progress"] = p.Progress
+				}
+				if env := result.paneEnv[p.PanePID]; len(env) > 0 {
+					pane["env"] = env
+				}
+				if ctx := result.paneKube[p.PanePID]; ctx != "" {
+					pane["kube_context"] = ctx
+				}
+				if state := result.paneStates[p.PanePID]; state != "" {
+					pane["state"] = string(state)
+				}
+				panes = append(panes, pane)
 			}
 			windows = append(windows, map[string]any{
 				"index": wg.index,
@@ -129,19 +198,46 @@ func handleSpaces(w http.ResponseWriter, r *http.Request) {
 				"panes": panes,
 			})
 		}
-		tmuxSessions = append(tmuxSessions, map[string]any{
-			"name":    sg.name,
-			"windows": windows,
-		})
+		session := map[string]any{
+			"name":     sg.name,
+			"windows":  windows,
+			"priority": result.priorities.forSession(sg.name).String(),
+		}
+		if a, ok := result.annotations.forSession(sg.name); ok {
+			session["annotation"] = a.Text
+		}
+		if e := enrichmentJSON(result.enrichments.forSession(sg.name)); e != nil {
+			session["enrichments"] = e
+		}
+		tmuxSessions = append(tmuxSessions, session)
 	}
 
+	// yabai_available is false whenever the window manager (yabai or
+	// otherwise) couldn't be queried; displays is empty then and only the
+	// tmux half is filled in
 	response := map[string]any{
-		"timestamp":     nowMS,
-		"displays":      displays,
-		"tmux_sessions": tmuxSessions,
+		"timestamp":       nowMS,
+		"displays":        displays,
+		"tmux_sessions":   tmuxSessions,
+		"yabai_available": result.wmErr == nil,
+		"errors":          sourceErrorsJSON(result.sourceErrs),
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	json.NewEncoder(w).Encode(response)
+	if result.wmErr != nil {
+		response["window_manager_error"] = result.wmErr.Error()
+	}
+	if s := result.focusSession; s != nil {
+		response["focus_session"] = map[string]any{
+			"space":        s.name(),
+			"space_index":  s.SpaceIndex,
+			"started_ms":   s.StartedAt.UnixMilli(),
+			"length_ms":    s.Length.Milliseconds(),
+			"remaining_ms": s.remaining(time.Now()).Milliseconds(),
+		}
+	}
+	// idle_ms is left out where idle time can't be read
+	if result.idleKnown {
+		response["idle_ms"] = result.idle.Milliseconds()
+		response["away"] = result.idle >= awayThreshold
+	}
+	return response
 }
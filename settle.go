@@ -0,0 +1,44 @@
+// settle: ride out the window manager's in-between states.
+//
+// while spaces animate or Mission Control is open, yabai can answer with
+// no spaces at all, or with every window gone, for a tick. drawing that
+// makes the whole grid flash empty and snap back. a refresh that would
+// take the dashboard from something to nothing is held instead: the
+// previous frame stays up with a "settling…" note and a quick re-fetch
+// is scheduled. only if the emptiness is still there settleTicks fetches
+// later is it believed.
+
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	settleTicks   = 2                      // held fetches before an empty state is believed
+	settleRetryIn = 300 * time.Millisecond // re-fetch delay while held
+)
+
+// implausibleTransition reports whether next empties a dashboard that had
+// spaces: all spaces vanished, or every window did. a window manager that
+// is down outright is the tmux-only fallback's business, not a
+// transition.
+func implausibleTransition(prevSpaces []Space, prevWindows []Window, next fetchResult) bool {
+	if len(prevSpaces) == 0 || next.wmErr != nil {
+		return false
+	}
+	if len(next.spaces) == 0 {
+		return true
+	}
+	return len(prevWindows) > 0 && len(next.windows) == 0
+}
+
+// settleRetryCmd re-fetches shortly, so a held frame clears as soon as
+// the window manager has settled rather than on the next tick.
+func settleRetryCmd() tea.Cmd {
+	return tea.Tick(settleRetryIn, func(time.Time) tea.Msg {
+		return dataMsg(fetchAll())
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestImplausibleTransition(t *testing.T) {
+	spaces := []Space{{ID: 1, Index: 1, Display: 1}}
+	windows := []Window{{ID: 5, Space: 1}}
+	cases := []struct {
+		name        string
+		prevSpaces  []Space
+		prevWindows []Window
+		next        fetchResult
+		want        bool
+	}{
+		{"spaces vanished", spaces, windows, fetchResult{}, true},
+		{"windows vanished", spaces, windows, fetchResult{spaces: spaces}, true},
+		{"steady", spaces, windows, fetchResult{spaces: spaces, windows: windows}, false},
+		{"first fetch", nil, nil, fetchResult{}, false},
+		{"window manager down", spaces, windows, fetchResult{wmErr: errors.New("timeout")}, false},
+		{"no windows before either", spaces, nil, fetchResult{spaces: spaces}, false},
+	}
+	for _, c := range cases {
+		if got := implausibleTransition(c.prevSpaces, c.prevWindows, c.next); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSettlingHoldsFrame(t *testing.T) {
+	m := newModel()
+	full := fetchResult{spaces: []Space{{ID: 1, Index: 1, Display: 1}}, windows: []Window{{ID: 5, Space: 1}}}
+	next, _ := m.handleData(full)
+	m = next.(model)
+
+	// an empty answer is held for settleTicks fetches, then believed
+	for i := 1; i <= settleTicks; i++ {
+		next, cmd := m.handleData(fetchResult{})
+		m = next.(model)
+		if m.settling != i || len(m.spaces) != 1 || cmd == nil {
+			t.Fatalf("fetch %d: settling %d, %d spaces", i, m.settling, len(m.spaces))
+		}
+	}
+	next, _ = m.handleData(fetchResult{})
+	m = next.(model)
+	if m.settling != 0 || len(m.spaces) != 0 {
+		t.Errorf("persistent empty state not accepted: settling %d, %d spaces", m.settling, len(m.spaces))
+	}
+}
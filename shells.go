@@ -0,0 +1,61 @@
+// shells: the plain shells in a terminal window's tabs.
+//
+// tmux sessions show under the terminal window they're attached in; a
+// shell that isn't in tmux had no line at all, so a build left running
+// in a second iTerm2 tab was invisible. terminals that list their tabs
+// and split panes (engine/iterm.go) now get a line per session that
+// isn't a tmux client, with its foreground command, its directory, and
+// its tab when the window has several.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// tmuxClientTTYs are the ttys tmux clients run on.
+func tmuxClientTTYs(clients []TmuxClient) map[string]bool {
+	ttys := make(map[string]bool, len(clients))
+	for _, c := range clients {
+		if c.TTY != "" {
+			ttys[c.TTY] = true
+		}
+	}
+	return ttys
+}
+
+// renderShellLines lists a window's sessions that aren't tmux clients.
+func renderShellLines(sessions []engine.TerminalSession, tmuxTTYs map[string]bool, indent string, maxLen int) []string {
+	tabs := map[int]bool{}
+	for _, s := range sessions {
+		tabs[s.Tab] = true
+	}
+	var lines []string
+	for _, s := range sessions {
+		// agents get their own line (bareagents.go)
+		if tmuxTTYs[s.TTY] || s.Job == "tmux" || isProductive(filepath.Base(s.Job)) {
+			continue
+		}
+		job := s.Job
+		if job == "" {
+			job = s.Name
+		}
+		var b strings.Builder
+		b.WriteString(indent)
+		b.WriteString(dimStyle.Render("▎ "))
+		b.WriteString(truncateStr(job, maxLen))
+		if p := shortPath(s.Path); p != "" {
+			b.WriteString("  ")
+			b.WriteString(dimStyle.Render(truncateStr(p, maxLen)))
+		}
+		if len(tabs) > 1 {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  tab %d", s.Tab)))
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
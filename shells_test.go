@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestShellLines(t *testing.T) {
+	sessions := []engine.TerminalSession{
+		{TTY: "/dev/ttys003", Job: "zsh", Tab: 1, Current: true},
+		{TTY: "/dev/ttys004", Job: "npm", Path: "/srv/api", Tab: 2},
+		{TTY: "/dev/ttys005", Job: "tmux", Tab: 2},
+		{TTY: "/dev/ttys006", Name: "server", Tab: 3},
+	}
+	tmuxTTYs := tmuxClientTTYs([]TmuxClient{{SessionName: "api", TTY: "/dev/ttys003"}})
+	lines := renderShellLines(sessions, tmuxTTYs, "  ", 40)
+	if len(lines) != 2 {
+		t.Fatalf("lines = %q", lines)
+	}
+	if !strings.Contains(lines[0], "npm") || !strings.Contains(lines[0], "/srv/api") || !strings.Contains(lines[0], "tab 2") {
+		t.Errorf("npm line = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "server") {
+		t.Errorf("a session without a job should show its title: %q", lines[1])
+	}
+
+	// a window of one tab doesn't number it
+	if lines := renderShellLines(sessions[1:2], nil, "", 40); strings.Contains(lines[0], "tab") {
+		t.Errorf("single tab line = %q", lines[0])
+	}
+}
@@ -0,0 +1,199 @@
+// skhd: the hotkeys that really focus each space.
+//
+// the row numbers in a column are stop's own jump keys, and only line up
+// with a global hotkey when the hotkeys happen to be laid out that way.
+// when skhd is what switches spaces, its config says exactly which key
+// does it: every `<mods> - <key> : yabai -m space --focus <space>` line
+// binds a key to a space, by index or by label. stop reads skhdConfigPath
+// (config.go), re-reading it when it changes, and shows each space's
+// hotkey beside its row, e.g. ⌥3. bindings that focus something relative
+// (next, recent) or computed by a script don't name a space and are
+// skipped, as are ones only active in a mode other than the default.
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// skhdBinding is one hotkey that focuses a space.
+type skhdBinding struct {
+	hotkey string // as shown, e.g. "⌥3"
+	space  string // an index or a label
+}
+
+// skhdFocus finds the space a command focuses.
+var skhdFocus = regexp.MustCompile(`yabai\s+-m\s+space\s+--focus\s+([A-Za-z0-9_./-]+)\s*(?:$|[;&|])`)
+
+// skhdModifiers are the symbols of skhd's modifier names, in the order
+// macOS writes them.
+var skhdModifiers = []struct{ names, symbol string }{
+	{"fn", "fn"},
+	{"ctrl lctrl rctrl", "⌃"},
+	{"alt lalt ralt", "⌥"},
+	{"shift lshift rshift", "⇧"},
+	{"cmd lcmd rcmd", "⌘"},
+}
+
+// skhdHotkey renders skhd's "<mods> - <key>" as macOS does, e.g.
+// "cmd + shift - 1" as ⇧⌘1. false when it doesn't read as a hotkey.
+func skhdHotkey(spec string) (string, bool) {
+	mods, key, ok := strings.Cut(spec, " - ")
+	if !ok {
+		mods, key = "", spec
+	}
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return "", false
+	}
+	have := map[string]bool{}
+	for _, m := range strings.Split(mods, "+") {
+		switch m = strings.TrimSpace(m); m {
+		case "":
+		case "hyper":
+			have["cmd"], have["alt"], have["shift"], have["ctrl"] = true, true, true, true
+		case "meh":
+			have["alt"], have["shift"], have["ctrl"] = true, true, true
+		default:
+			have[m] = true
+		}
+	}
+	var b strings.Builder
+	for _, mod := range skhdModifiers {
+		for _, name := range strings.Fields(mod.names) {
+			if have[name] {
+				b.WriteString(mod.symbol)
+				break
+			}
+		}
+	}
+	if len(key) == 1 {
+		key = strings.ToUpper(key)
+	}
+	b.WriteString(key)
+	return b.String(), true
+}
+
+// parseSkhdrc reads the space-focusing bindings from an skhdrc.
+func parseSkhdrc(text string) []skhdBinding {
+	var bindings []skhdBinding
+	var line string
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for sc.Scan() {
+		// commands can continue onto the next line with a backslash
+		l := sc.Text()
+		if strings.HasSuffix(l, "\\") {
+			line += strings.TrimSuffix(l, "\\") + " "
+			continue
+		}
+		line += l
+		full := strings.TrimSpace(line)
+		line = ""
+		if full == "" || strings.HasPrefix(full, "#") || strings.HasPrefix(full, "::") || strings.HasPrefix(full, ".") {
+			continue
+		}
+		spec, command, ok := strings.Cut(full, ":")
+		if !ok {
+			continue
+		}
+		if mode, key, ok := strings.Cut(spec, "<"); ok {
+			if strings.TrimSpace(mode) != "default" {
+				continue
+			}
+			spec = key
+		}
+		hotkey, ok := skhdHotkey(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(spec), "->")))
+		if !ok {
+			continue
+		}
+		if m := skhdFocus.FindStringSubmatch(command); m != nil && !yabaiSelectors[m[1]] {
+			bindings = append(bindings, skhdBinding{hotkey: hotkey, space: m[1]})
+		}
+	}
+	return bindings
+}
+
+var (
+	skhdMu       sync.Mutex
+	skhdModTime  time.Time
+	skhdBindings []skhdBinding
+)
+
+// loadSkhdBindings is skhdConfigPath's bindings, read again only when
+// the file changes. nil when there's no file.
+func loadSkhdBindings() []skhdBinding {
+	if skhdConfigPath == "" {
+		return nil
+	}
+	path := expandHome(skhdConfigPath)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	skhdMu.Lock()
+	defer skhdMu.Unlock()
+	if !info.ModTime().Equal(skhdModTime) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		skhdModTime, skhdBindings = info.ModTime(), parseSkhdrc(string(data))
+	}
+	return skhdBindings
+}
+
+// spaceHotkeys maps each space's index to its hotkey. a label binding
+// wins over an index one, since it follows the space when spaces move;
+// the first binding of each kind wins.
+func spaceHotkeys(bindings []skhdBinding, spaces []Space) map[int]string {
+	if len(bindings) == 0 {
+		return nil
+	}
+	byLabel := map[string]string{}
+	byIndex := map[int]string{}
+	for _, b := range bindings {
+		if n, err := strconv.Atoi(b.space); err == nil {
+			if _, ok := byIndex[n]; !ok {
+				byIndex[n] = b.hotkey
+			}
+		} else if _, ok := byLabel[b.space]; !ok {
+			byLabel[b.space] = b.hotkey
+		}
+	}
+	hotkeys := map[int]string{}
+	for _, s := range spaces {
+		if k, ok := byLabel[s.Label]; ok && s.Label != "" {
+			hotkeys[s.Index] = k
+		} else if k, ok := byIndex[s.Index]; ok {
+			hotkeys[s.Index] = k
+		}
+	}
+	return hotkeys
+}
+
+// hotkeyWidth is the widest hotkey, which the rows pad to.
+func hotkeyWidth(hotkeys map[int]string) int {
+	w := 0
+	for _, k := range hotkeys {
+		w = max(w, lipgloss.Width(k))
+	}
+	return w
+}
+
+// renderHotkey is a row's hotkey column: the key padded to width, or
+// blank when the space has none.
+func renderHotkey(hotkeys map[int]string, index, width int) string {
+	if width == 0 {
+		return ""
+	}
+	k := hotkeys[index]
+	return " " + dimStyle.Render(k) + strings.Repeat(" ", width-lipgloss.Width(k))
+}
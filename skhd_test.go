@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSkhdrc(t *testing.T) {
+	rc := `# spaces
+alt - 1 : yabai -m space --focus 1
+alt - 2 : yabai -m space --focus 2 || true
+cmd + shift - w : yabai -m space --focus web
+hyper - c : \
+    yabai -m space --focus code
+meh - n : yabai -m space --focus next
+alt - r : yabai -m space --focus recent
+alt - 0 : yabai -m space --focus $(pick-space)
+resize < alt - 3 : yabai -m space --focus 3
+default < lalt - 4 -> : yabai -m space --focus 4
+alt - return : open -a kitty
+:: resize @ : echo resize
+`
+	var got []string
+	for _, b := range parseSkhdrc(rc) {
+		got = append(got, b.hotkey+"="+b.space)
+	}
+	want := []string{"⌥1=1", "⌥2=2", "⇧⌘W=web", "⌃⌥⇧⌘C=code", "⌥4=4"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("bindings = %v, want %v", got, want)
+	}
+}
+
+func TestSpaceHotkeys(t *testing.T) {
+	bindings := []skhdBinding{{"⌥1", "1"}, {"⌥2", "2"}, {"⇧⌘W", "web"}, {"⌥9", "1"}}
+	spaces := []Space{{Index: 1}, {Index: 2, Label: "web"}, {Index: 3}}
+	hotkeys := spaceHotkeys(bindings, spaces)
+	if len(hotkeys) != 2 || hotkeys[1] != "⌥1" || hotkeys[2] != "⇧⌘W" {
+		t.Errorf("hotkeys = %v", hotkeys)
+	}
+	if w := hotkeyWidth(hotkeys); w != 3 {
+		t.Errorf("width = %d", w)
+	}
+	if col := renderHotkey(hotkeys, 3, 3); col != "    " {
+		t.Errorf("blank column = %q", col)
+	}
+	if spaceHotkeys(nil, spaces) != nil || renderHotkey(nil, 1, 0) != "" {
+		t.Error("no skhdrc still rendered hotkeys")
+	}
+}
@@ -0,0 +1,252 @@
+// slo: response-time objective for agents waiting on a human.
+//
+// the target reads "respond to a waiting agent within N minutes". an
+// episode starts when the agent registry records an agent going idle
+// after producing output (it's done or blocked on input) and ends when
+// its terminal window is focused (the registry marks it seen) or, failing
+// that, when it starts working again. episodes that ended inside the
+// target meet the objective; ones that ended late, or are still open
+// past the target, breach it. open episodes still inside the target
+// are pending and don't count either way yet.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// sloWindow is the lookback the TUI and /slo use for the running figure.
+const sloWindow = 24 * time.Hour
+
+// sloEpisode is one idle → seen (or working) wait.
+type sloEpisode struct {
+	agentID     string
+	idleAt      time.Time
+	respondedAt time.Time // zero while the agent is still waiting
+}
+
+// sloReport summarizes compliance over a set of episodes.
+type sloReport struct {
+	Target   time.Duration
+	Met      int
+	Breached int
+	Pending  int
+	Worst    time.Duration // longest wait among met/breached episodes
+}
+
+// total counts the episodes that have been decided either way.
+func (r sloReport) total() int {
+	return r.Met + r.Breached
+}
+
+// compliance is the met fraction of decided episodes; -1 when none.
+func (r sloReport) compliance() float64 {
+	if r.total() == 0 {
+		return -1
+	}
+	return float64(r.Met) / float64(r.total())
+}
+
+// sloEpisodes pairs each idle transition that follows output with the
+// agent's next seen or working transition. an agent that registers idle,
+// or goes idle again without working in between, isn't waiting on
+// anyone. going straight from working to seen is a wait of zero: the
+// window was already in front of you. events must be ordered by agent
+// then time, as loadAgentStatusEvents returns them. gone ends an episode
+// without a response — the pane closed, so nobody is waiting anymore.
+func sloEpisodes(events []agentStatusEvent) []sloEpisode {
+	var episodes []sloEpisode
+	open := map[string]int{}    // agent id → index of its open episode
+	worked := map[string]bool{} // agent id → produced output since its last wait
+	for _, ev := range events {
+		idx, waiting := open[ev.AgentID]
+		switch ev.Status {
+		case agentIdle:
+			if !waiting && worked[ev.AgentID] {
+				open[ev.AgentID] = len(episodes)
+				episodes = append(episodes, sloEpisode{agentID: ev.AgentID, idleAt: ev.At})
+				worked[ev.AgentID] = false
+			}
+		case agentSeen:
+			if waiting {
+				episodes[idx].respondedAt = ev.At
+				delete(open, ev.AgentID)
+			} else if worked[ev.AgentID] {
+				episodes = append(episodes, sloEpisode{agentID: ev.AgentID, idleAt: ev.At, respondedAt: ev.At})
+				worked[ev.AgentID] = false
+			}
+		case agentWorking:
+			worked[ev.AgentID] = true
+			if waiting {
+				episodes[idx].respondedAt = ev.At
+				delete(open, ev.AgentID)
+			}
+		case agentGone:
+			if waiting {
+				episodes = append(episodes[:idx], episodes[idx+1:]...)
+				delete(open, ev.AgentID)
+				// later open indices shifted down by one
+				for id, i := range open {
+					if i > idx {
+						open[id] = i - 1
+					}
+				}
+			}
+		}
+	}
+	return episodes
+}
+
+// computeSLO grades episodes against target as of now.
+func computeSLO(episodes []sloEpisode, target time.Duration, now time.Time) sloReport {
+	r := sloReport{Target: target}
+	for _, ep := range episodes {
+		end := ep.respondedAt
+		if end.IsZero() {
+			if now.Sub(ep.idleAt) <= target {
+				r.Pending++
+				continue
+			}
+			end = now
+		}
+		wait := end.Sub(ep.idleAt)
+		if wait > r.Worst {
+			r.Worst = wait
+		}
+		if !ep.respondedAt.IsZero() && wait <= target {
+			r.Met++
+		} else {
+			r.Breached++
+		}
+	}
+	return r
+}
+
+// loadAgentStatusEvents returns registry transitions since a time, ordered
+// by agent then time.
+func loadAgentStatusEvents(db *sql.DB, since time.Time) ([]agentStatusEvent, error) {
+	rows, err := db.Query(
+		"SELECT agent_id, status, at FROM agent_status_events WHERE at >= ? ORDER BY agent_id, at, id",
+		since.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying agent status events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []agentStatusEvent
+	for rows.Next() {
+		var ev agentStatusEvent
+		var at string
+		if err := rows.Scan(&ev.AgentID, &ev.Status, &at); err != nil {
+			continue
+		}
+		ev.At, _ = time.Parse(time.RFC3339, at)
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// loadSLOReport computes the report over [since, now].
+func loadSLOReport(db *sql.DB, since time.Time, target time.Duration) (sloReport, []sloEpisode, error) {
+	events, err := loadAgentStatusEvents(db, since)
+	if err != nil {
+		return sloReport{}, nil, err
+	}
+	episodes := sloEpisodes(events)
+	return computeSLO(episodes, target, time.Now()), episodes, nil
+}
+
+// querySLO returns the running report for the TUI, or nil when the db is
+// unavailable.
+func querySLO() *sloReport {
+	db := sharedStateDB()
+	if db == nil {
+		return nil
+	}
+	r, _, err := loadSLOReport(db, time.Now().Add(-sloWindow), responseSLOTarget)
+	if err != nil {
+		return nil
+	}
+	return &r
+}
+
+// handleSLO serves the running report. ?since=168h widens the window.
+func handleSLO(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		window := sloWindow
+		if s := r.URL.Query().Get("since"); s != "" {
+			if d, err := time.ParseDuration(s); err == nil && d > 0 {
+				window = d
+			}
+		}
+		report, _, err := loadSLOReport(db, time.Now().Add(-window), responseSLOTarget)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{
+			"target_ms":  report.Target.Milliseconds(),
+			"window_ms":  window.Milliseconds(),
+			"met":        report.Met,
+			"breached":   report.Breached,
+			"pending":    report.Pending,
+			"compliance": report.compliance(),
+			"worst_ms":   report.Worst.Milliseconds(),
+		})
+	}
+}
+
+// sloCommand is the entry point for `stop slo` — a compliance report over
+// a lookback window (a week by default), with the slowest responses listed.
+func sloCommand(since time.Duration, target time.Duration) error {
+	db, err := openSnapshotDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, episodes, err := loadSLOReport(db, time.Now().Add(-since), target)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("response slo: respond within %s, last %s\n", humanDuration(target), humanDuration(since))
+	if report.total() == 0 {
+		fmt.Printf("  no decided waits yet (%d pending)\n", report.Pending)
+		return nil
+	}
+	fmt.Printf("  compliance %.0f%%  (%d met, %d breached, %d pending)\n",
+		report.compliance()*100, report.Met, report.Breached, report.Pending)
+	fmt.Printf("  worst wait %s\n", humanDuration(report.Worst))
+
+	// slowest decided waits, so the breaches are easy to audit
+	now := time.Now()
+	sort.Slice(episodes, func(i, j int) bool {
+		return episodeWait(episodes[i], now) > episodeWait(episodes[j], now)
+	})
+	fmt.Println("  slowest:")
+	for i, ep := range episodes {
+		if i >= 5 {
+			break
+		}
+		state := "responded"
+		if ep.respondedAt.IsZero() {
+			state = "still waiting"
+		}
+		fmt.Printf("    %-8s  %s  %s  %s\n", humanDuration(episodeWait(ep, now)), ep.idleAt.Local().Format("01-02 15:04"), state, ep.agentID)
+	}
+	return nil
+}
+
+// episodeWait is how long an episode waited, up to now when still open.
+func episodeWait(ep sloEpisode, now time.Time) time.Duration {
+	if ep.respondedAt.IsZero() {
+		return now.Sub(ep.idleAt)
+	}
+	return ep.respondedAt.Sub(ep.idleAt)
+}
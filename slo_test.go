@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSLOEpisodesAndCompliance(t *testing.T) {
+	base := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+	events := []agentStatusEvent{
+		// a: answered in 5m (met), then in 20m (breached)
+		{AgentID: "a", Status: agentWorking, At: at(0)},
+		{AgentID: "a", Status: agentIdle, At: at(10)},
+		{AgentID: "a", Status: agentWorking, At: at(15)},
+		{AgentID: "a", Status: agentIdle, At: at(30)},
+		{AgentID: "a", Status: agentWorking, At: at(50)},
+		// b: went idle then its pane closed — not a wait anymore
+		{AgentID: "b", Status: agentWorking, At: at(0)},
+		{AgentID: "b", Status: agentIdle, At: at(5)},
+		{AgentID: "b", Status: agentGone, At: at(40)},
+		// c: waiting 3m as of now (pending); d: waiting 30m (breached)
+		{AgentID: "c", Status: agentWorking, At: at(50)},
+		{AgentID: "c", Status: agentIdle, At: at(57)},
+		{AgentID: "d", Status: agentWorking, At: at(20)},
+		{AgentID: "d", Status: agentIdle, At: at(30)},
+		// e: registered already idle, never produced output — not waiting
+		{AgentID: "e", Status: agentIdle, At: at(0)},
+	}
+	episodes := sloEpisodes(events)
+	if len(episodes) != 4 {
+		t.Fatalf("expected 4 episodes, got %d: %+v", len(episodes), episodes)
+	}
+	r := computeSLO(episodes, 10*time.Minute, at(60))
+	if r.Met != 1 || r.Breached != 2 || r.Pending != 1 {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+	if r.Worst != 30*time.Minute {
+		t.Fatalf("worst = %s", r.Worst)
+	}
+	if got := r.compliance(); got < 0.33 || got > 0.34 {
+		t.Fatalf("compliance = %f", got)
+	}
+}
+
+func TestSLOFocusStopsTheClock(t *testing.T) {
+	base := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+	events := []agentStatusEvent{
+		// a: looked at 4m after going idle, answered much later
+		{AgentID: "a", Status: agentWorking, At: at(0)},
+		{AgentID: "a", Status: agentIdle, At: at(10)},
+		{AgentID: "a", Status: agentSeen, At: at(14)},
+		{AgentID: "a", Status: agentWorking, At: at(45)},
+		// b: finished while its window was focused
+		{AgentID: "b", Status: agentWorking, At: at(0)},
+		{AgentID: "b", Status: agentSeen, At: at(20)},
+	}
+	episodes := sloEpisodes(events)
+	if len(episodes) != 2 {
+		t.Fatalf("expected 2 episodes, got %d: %+v", len(episodes), episodes)
+	}
+	if w := episodeWait(episodes[0], at(60)); w != 4*time.Minute {
+		t.Errorf("focused wait = %s, want 4m", w)
+	}
+	if w := episodeWait(episodes[1], at(60)); w != 0 {
+		t.Errorf("already-focused wait = %s, want 0", w)
+	}
+	r := computeSLO(episodes, 10*time.Minute, at(60))
+	if r.Met != 2 || r.Breached != 0 {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+}
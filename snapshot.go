@@ -211,7 +211,53 @@ func openSnapshotDB() (*sql.DB, error) {
 		return nil, fmt.Errorf("migrating snapshot db: %w", err)
 	}
 
-	log.Printf("snapshot db ready at %s", snapshotDBPath)
+	// annotations, the agent registry, priorities, alerts, and the cleanup
+	// journal share the file but aren't snapshot history
+	if _, err := db.Exec(annotationSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying annotation schema: %w", err)
+	}
+	if _, err := db.Exec(agentSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying agent schema: %w", err)
+	}
+	if _, err := db.Exec(prioritySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying priority schema: %w", err)
+	}
+	if _, err := db.Exec(alertSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying alert schema: %w", err)
+	}
+	if _, err := db.Exec(journalSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying journal schema: %w", err)
+	}
+	if _, err := db.Exec(agentViewSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying agent view schema: %w", err)
+	}
+	if _, err := db.Exec(uiStateSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying ui state schema: %w", err)
+	}
+	if _, err := db.Exec(focusSessionSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying focus session schema: %w", err)
+	}
+	if _, err := db.Exec(spaceTimeSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying space time schema: %w", err)
+	}
+	if _, err := db.Exec(layoutSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying layout schema: %w", err)
+	}
+	if _, err := db.Exec(agentUsageSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("applying agent usage schema: %w", err)
+	}
+
 	return db, nil
 }
 
@@ -341,7 +387,26 @@ func recordSnapshot(db *sql.DB) error {
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// fold the same fetch into the agent registry so its active-time
+	// accounting advances at the snapshot cadence
+	if err := syncAgentRegistry(db, result, time.Now()); err != nil {
+		return fmt.Errorf("syncing agent registry: %w", err)
+	}
+
+	// and escalate waiting agents off the freshly synced registry
+	if err := runAlerts(db, time.Now()); err != nil {
+		return fmt.Errorf("running alerts: %w", err)
+	}
+
+	// today's agent usage so far, per session (nil unless enabled)
+	if err := recordAgentUsage(db, time.Now(), result.agentUsage); err != nil {
+		return fmt.Errorf("recording agent usage: %w", err)
+	}
+	return nil
 }
 
 // resolveSessionsByAncestry maps tmux pane PIDs to opencode session IDs
@@ -441,6 +506,22 @@ func startSnapshotLoop(ctx context.Context, db *sql.DB, interval time.Duration)
 		log.Printf("snapshot error: %v", err)
 	}
 
+	// thin old history once an hour (and once now)
+	var lastPrune time.Time
+	prune := func() {
+		if time.Since(lastPrune) < time.Hour {
+			return
+		}
+		lastPrune = time.Now()
+		n, err := pruneSnapshots(db, time.Now(), snapshotFullRetention, snapshotHourlyRetention)
+		if err != nil {
+			log.Printf("snapshot prune error: %v", err)
+		} else if n > 0 {
+			log.Printf("pruned %d old snapshots", n)
+		}
+	}
+	prune()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -449,6 +530,7 @@ func startSnapshotLoop(ctx context.Context, db *sql.DB, interval time.Duration)
 			if err := recordSnapshot(db); err != nil {
 				log.Printf("snapshot error: %v", err)
 			}
+			prune()
 		}
 	}
 }
@@ -0,0 +1,39 @@
+// sources: which of the queries behind a refresh failed, and why.
+//
+// a refresh fans out to the window manager, tmux and ps, and each is
+// best-effort: when one fails the rest still render. that used to make a
+// failure look like an empty result — a tmux timeout just meant fewer
+// sessions on screen. engine.Fetch records a short reason per failed
+// source, which the TUI shows on one status line ("tmux: timeout") and
+// serve returns as an errors array.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// renderSourceErrors is the status line for failed sources; empty when
+// everything answered.
+func renderSourceErrors(errs []engine.SourceError) string {
+	if len(errs) == 0 {
+		return ""
+	}
+	parts := make([]string, len(errs))
+	for i, e := range errs {
+		parts[i] = e.String()
+	}
+	return warnStyle.Render(strings.Join(parts, "  "))
+}
+
+// sourceErrorsJSON is the serve shape: always an array, empty when
+// nothing failed.
+func sourceErrorsJSON(errs []engine.SourceError) []map[string]any {
+	out := []map[string]any{}
+	for _, e := range errs {
+		out = append(out, map[string]any{"source": e.Source, "error": e.Err.Error()})
+	}
+	return out
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestRenderSourceErrors(t *testing.T) {
+	if renderSourceErrors(nil) != "" {
+		t.Error("no errors should render nothing")
+	}
+	errs := []engine.SourceError{{Source: "ps", Err: exec.ErrNotFound}, {Source: "tmux", Err: context.DeadlineExceeded}}
+	line := renderSourceErrors(errs)
+	if !strings.Contains(line, "ps: executable file not found") || strings.Index(line, "ps:") > strings.Index(line, "tmux:") {
+		t.Errorf("status line = %q", line)
+	}
+	if got := sourceErrorsJSON(nil); got == nil || len(got) != 0 {
+		t.Errorf("serve errors should be an empty array, got %v", got)
+	}
+	if got := sourceErrorsJSON(errs); got[1]["source"] != "tmux" {
+		t.Errorf("serve errors = %v", got)
+	}
+}
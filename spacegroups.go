@@ -0,0 +1,134 @@
+// spacegroups: sub-headers for spaces labeled by convention.
+//
+// labels like "work/api", "work/frontend", "personal/music" already say
+// which spaces belong together. within a display column, consecutive
+// spaces whose labels share the part before spaceGroupSeparator get a
+// header line, and a group can be folded (O) down to that one line. a
+// folded group's other spaces render nothing and the cursor steps over
+// them; the header row stands for the whole group. spaces stay in index
+// order (unless sorted, spacesort.go) so relative numbers and 1-9 keep
+// meaning the same thing, which means a group split by an unlabeled
+// space shows up as two runs.
+// folded groups persist with the rest of the UI state (uistate.go).
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// spaceGroupOf is the group part of a space label: "work/api" → "work".
+// labels without the separator belong to no group.
+func spaceGroupOf(label string) string {
+	group, _, ok := strings.Cut(label, spaceGroupSeparator)
+	if !ok {
+		return ""
+	}
+	return group
+}
+
+// groupRun reports the group that starts at row i and how many
+// consecutive spaces it spans; ok is false when row i continues the run
+// above it or has no group.
+func groupRun(dg displayGroup, i int) (name string, n int, ok bool) {
+	name = spaceGroupOf(dg.spaces[i].space.Label)
+	if name == "" || (i > 0 && spaceGroupOf(dg.spaces[i-1].space.Label) == name) {
+		return "", 0, false
+	}
+	n = 1
+	for i+n < len(dg.spaces) && spaceGroupOf(dg.spaces[i+n].space.Label) == name {
+		n++
+	}
+	return name, n, true
+}
+
+// foldedRow reports whether row i is hidden inside a folded group: it
+// continues a folded group's run.
+func foldedRow(dg displayGroup, i int, folded map[string]bool) bool {
+	if i <= 0 || i >= len(dg.spaces) {
+		return false
+	}
+	name := spaceGroupOf(dg.spaces[i].space.Label)
+	return name != "" && folded[name] && spaceGroupOf(dg.spaces[i-1].space.Label) == name
+}
+
+// skipFolded moves the cursor off a folded row: past the run when moving
+// down, to the run's header row otherwise (and when the run ends the
+// column).
+func (m *model) skipFolded(down bool) {
+	if m.cursorCol >= len(m.displayGroups) {
+		return
+	}
+	dg := m.displayGroups[m.cursorCol]
+	if down {
+		for m.cursorRow < len(dg.spaces)-1 && foldedRow(dg, m.cursorRow, m.foldedGroups) {
+			m.cursorRow++
+		}
+	}
+	for foldedRow(dg, m.cursorRow, m.foldedGroups) {
+		m.cursorRow--
+	}
+}
+
+// toggleFold folds or unfolds the selected space's group.
+func (m model) toggleFold() model {
+	row, ok := m.selectedSpaceRow()
+	if !ok {
+		return m
+	}
+	name := spaceGroupOf(row.space.Label)
+	if name == "" {
+		m.status = fmt.Sprintf("space has no %q group in its label", spaceGroupSeparator)
+		return m
+	}
+	folded := make(map[string]bool, len(m.foldedGroups)+1)
+	for g := range m.foldedGroups {
+		folded[g] = true
+	}
+	if folded[name] {
+		delete(folded, name)
+		m.status = "unfolded " + name
+	} else {
+		folded[name] = true
+		m.status = "folded " + name
+	}
+	m.foldedGroups = folded
+	m.skipFolded(false)
+	return m
+}
+
+// renderGroupHeader is the line above a group's first space: ▾ and the
+// name when open; ▸, the name and its space count when folded, in which
+// case it's the whole row and carries the cursor.
+func renderGroupHeader(name string, n int, folded, isSelected bool) string {
+	if !folded {
+		return dimStyle.Render("  ▾ " + name)
+	}
+	cursor := "  "
+	if isSelected {
+		cursor = cursorStyle.Render("> ")
+	}
+	return cursor + displayStyle.Render("▸ "+name) + "  " + dimStyle.Render(fmt.Sprintf("%d spaces", n))
+}
+
+// spaceGroupsJSON lists a display's groups for the API in order of first
+// appearance, each with the relative indices of its spaces.
+func spaceGroupsJSON(dg displayGroup) []map[string]any {
+	var groups []map[string]any
+	at := map[string]int{}
+	for i, row := range dg.spaces {
+		name := spaceGroupOf(row.space.Label)
+		if name == "" {
+			continue
+		}
+		j, ok := at[name]
+		if !ok {
+			j = len(groups)
+			at[name] = j
+			groups = append(groups, map[string]any{"name": name, "spaces": []int{}})
+		}
+		groups[j]["spaces"] = append(groups[j]["spaces"].([]int), i+1)
+	}
+	return groups
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func labeledGroup(labels ...string) displayGroup {
+	var dg displayGroup
+	for i, l := range labels {
+		dg.spaces = append(dg.spaces, spaceRow{space: Space{Index: i + 1, Label: l}})
+	}
+	return dg
+}
+
+func TestGroupRuns(t *testing.T) {
+	if spaceGroupOf("work/api") != "work" || spaceGroupOf("music") != "" {
+		t.Fatal("spaceGroupOf")
+	}
+	dg := labeledGroup("work/api", "work/frontend", "", "personal/music", "work/ops")
+	if name, n, ok := groupRun(dg, 0); !ok || name != "work" || n != 2 {
+		t.Errorf("run at 0 = %q %d %v", name, n, ok)
+	}
+	if _, _, ok := groupRun(dg, 1); ok {
+		t.Error("row 1 continues the run above")
+	}
+	if _, _, ok := groupRun(dg, 2); ok {
+		t.Error("unlabeled row starts a run")
+	}
+	if name, n, ok := groupRun(dg, 4); !ok || name != "work" || n != 1 {
+		t.Errorf("split run at 4 = %q %d %v", name, n, ok)
+	}
+
+	folded := map[string]bool{"work": true}
+	for i, want := range []bool{false, true, false, false, false} {
+		if got := foldedRow(dg, i, folded); got != want {
+			t.Errorf("row %d folded = %v", i, got)
+		}
+	}
+
+	groups := spaceGroupsJSON(dg)
+	if len(groups) != 2 || groups[0]["name"] != "work" || len(groups[0]["spaces"].([]int)) != 3 || groups[1]["name"] != "personal" {
+		t.Errorf("groups = %+v", groups)
+	}
+}
+
+func TestFoldGroup(t *testing.T) {
+	m := newModel()
+	m.displayGroups = []displayGroup{labeledGroup("work/api", "work/frontend", "work/ops", "notes")}
+	m.cursorRow = 1
+
+	next, _ := m.handleAction(actFold)
+	m = next.(model)
+	if !m.foldedGroups["work"] || m.cursorRow != 0 {
+		t.Fatalf("folded = %v, cursor %d", m.foldedGroups, m.cursorRow)
+	}
+
+	// j steps over the folded rows
+	next, _ = m.handleAction(actDown)
+	if m = next.(model); m.cursorRow != 3 {
+		t.Errorf("down from the folded header landed on %d", m.cursorRow)
+	}
+	next, _ = m.handleAction(actUp)
+	if m = next.(model); m.cursorRow != 0 {
+		t.Errorf("up onto the folded group landed on %d", m.cursorRow)
+	}
+
+	col := renderDisplayColumn(m.displayGroups[0], 0, 60, nil, renderContext{foldedGroups: m.foldedGroups})
+	if len(col.rows) != 4 || !strings.Contains(col.rows[0], "▸ work") || !strings.Contains(col.rows[0], "3 spaces") || col.rows[1] != "" {
+		t.Errorf("rows = %q", col.rows)
+	}
+
+	next, _ = m.handleAction(actFold)
+	if m = next.(model); m.foldedGroups["work"] {
+		t.Error("second o didn't unfold")
+	}
+	col = renderDisplayColumn(m.displayGroups[0], 0, 60, nil, renderContext{})
+	if lines := strings.Split(col.rows[0], "\n"); !strings.Contains(lines[0], "▾ work") || len(col.targets[0]) != len(lines) {
+		t.Errorf("open header row = %q, targets %+v", col.rows[0], col.targets[0])
+	}
+}
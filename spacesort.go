@@ -0,0 +1,230 @@
+// spacesort: the order spaces are listed in within their display column.
+//
+// s cycles the order: index (the window manager's own), staleness (the
+// most stale agent space first), windows (busiest first), free first and
+// free last. p pins the selected space to the top of its column, above
+// whatever the order puts there; pinned spaces keep the order they were
+// pinned in, so the important agent spaces always sit in the same place.
+// a labeled space is pinned by label, an unlabeled one showing a tmux
+// session by that session (it survives the space moving), anything else
+// by index. row numbers and 1-9 follow what's on screen, with the real
+// index after them when it differs. the order and the pins persist in
+// the ui_state table with the rest of the UI state (uistate.go).
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// ui_state keys, next to uiStateDensity
+const (
+	uiStateSpaceSort = "space_sort"
+	uiStatePins      = "pins"
+)
+
+type spaceSort int
+
+const (
+	sortIndex spaceSort = iota
+	sortStaleness
+	sortWindows
+	sortFreeFirst
+	sortFreeLast
+)
+
+var spaceSortNames = []string{"index", "staleness", "windows", "free first", "free last"}
+
+func (s spaceSort) String() string {
+	if s < 0 || int(s) >= len(spaceSortNames) {
+		return spaceSortNames[0]
+	}
+	return spaceSortNames[s]
+}
+
+func (s spaceSort) next() spaceSort {
+	return (s + 1) % spaceSort(len(spaceSortNames))
+}
+
+// parseSpaceSort maps a stored name back; unknown names read as index.
+func parseSpaceSort(name string) spaceSort {
+	for i, n := range spaceSortNames {
+		if n == name {
+			return spaceSort(i)
+		}
+	}
+	return sortIndex
+}
+
+// pinKey is how a space is pinned: "space:<label>", "session:<name>" or
+// "space:<index>".
+func pinKey(row spaceRow, sessions map[string][]TmuxPane) string {
+	if row.space.Label != "" {
+		return targetSpace + ":" + row.space.Label
+	}
+	for _, w := range row.windows {
+		name := strings.TrimSpace(w.Title)
+		if _, ok := sessions[name]; ok && engine.IsTerminal(w.App) {
+			return targetSession + ":" + name
+		}
+	}
+	return targetSpace + ":" + strconv.Itoa(row.space.Index)
+}
+
+// pinRank is a row's position among the pins, -1 when it isn't pinned.
+func pinRank(row spaceRow, pins []string) int {
+	best := -1
+	for _, w := range row.windows {
+		if i := slices.Index(pins, targetSession+":"+strings.TrimSpace(w.Title)); i >= 0 && engine.IsTerminal(w.App) && (best < 0 || i < best) {
+			best = i
+		}
+	}
+	for _, key := range []string{targetSpace + ":" + row.space.Label, targetSpace + ":" + strconv.Itoa(row.space.Index)} {
+		if i := slices.Index(pins, key); i >= 0 && key != targetSpace+":" && (best < 0 || i < best) {
+			best = i
+		}
+	}
+	return best
+}
+
+// oldestActivity is the least recent productive session on a space.
+func oldestActivity(row spaceRow, activity map[string]time.Time) (time.Time, bool) {
+	var oldest time.Time
+	found := false
+	for _, w := range row.windows {
+		if !engine.IsTerminal(w.App) {
+			continue
+		}
+		if at, ok := activity[w.Title]; ok && (!found || at.Before(oldest)) {
+			oldest, found = at, true
+		}
+	}
+	return oldest, found
+}
+
+// sortSpaceRows orders one column: pinned rows first in pin order, then
+// by mode, with the space index breaking ties. activity is each
+// productive session's latest activity (bestProductiveActivity).
+func sortSpaceRows(rows []spaceRow, mode spaceSort, pins []string, activity map[string]time.Time) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		pa, pb := pinRank(a, pins), pinRank(b, pins)
+		if (pa >= 0) != (pb >= 0) {
+			return pa >= 0
+		}
+		if pa != pb {
+			return pa < pb
+		}
+		switch mode {
+		case sortStaleness:
+			ta, oka := oldestActivity(a, activity)
+			tb, okb := oldestActivity(b, activity)
+			if oka != okb {
+				return oka
+			}
+			if !ta.Equal(tb) {
+				return ta.Before(tb)
+			}
+		case sortWindows:
+			if len(a.windows) != len(b.windows) {
+				return len(a.windows) > len(b.windows)
+			}
+		case sortFreeFirst, sortFreeLast:
+			fa, fb := len(a.windows) == 0, len(b.windows) == 0
+			if fa != fb {
+				return fa == (mode == sortFreeFirst)
+			}
+		}
+		return a.space.Index < b.space.Index
+	})
+}
+
+// orderSpaces sorts every column in place; false when the window
+// manager's order stands.
+func (m *model) orderSpaces() bool {
+	if m.spaceSort == sortIndex && len(m.pins) == 0 {
+		return false
+	}
+	activity := bestProductiveActivity(m.tmuxPanes, m.productivePanePIDs)
+	for _, dg := range m.displayGroups {
+		sortSpaceRows(dg.spaces, m.spaceSort, m.pins, activity)
+	}
+	return true
+}
+
+// arrangeSpaces regroups the last refresh and orders it again, keeping
+// the cursor on its space (hotplug.go).
+func (m *model) arrangeSpaces() {
+	anchor := m.cursorAnchor()
+	m.displayGroups = buildVisibleGroups(m.spaces, m.windows, m.displays)
+	m.orderSpaces()
+	m.restoreAnchor(anchor)
+}
+
+// cycleSpaceSort moves to the next order.
+func (m model) cycleSpaceSort() (model, tea.Cmd) {
+	m.spaceSort = m.spaceSort.next()
+	m.status = "sorted by " + m.spaceSort.String()
+	m.arrangeSpaces()
+	return m, saveSpaceOrderCmd(m.spaceSort, m.pins)
+}
+
+// togglePin pins or unpins the selected space.
+func (m model) togglePin() (model, tea.Cmd) {
+	row, ok := m.selectedSpaceRow()
+	if !ok {
+		return m, nil
+	}
+	pins := slices.Clone(m.pins)
+	if i := pinRank(row, pins); i >= 0 {
+		m.status = "unpinned " + pins[i]
+		pins = slices.Delete(pins, i, i+1)
+	} else {
+		key := pinKey(row, m.tmuxSessions())
+		pins = append(pins, key)
+		m.status = "pinned " + key
+	}
+	m.pins = pins
+	m.arrangeSpaces()
+	return m, saveSpaceOrderCmd(m.spaceSort, m.pins)
+}
+
+// tmuxSessions indexes the last refresh's panes by session.
+func (m model) tmuxSessions() map[string][]TmuxPane {
+	sessions := make(map[string][]TmuxPane)
+	for _, p := range m.tmuxPanes {
+		sessions[p.SessionName] = append(sessions[p.SessionName], p)
+	}
+	return sessions
+}
+
+// isPinned reports whether a row is pinned, for its marker.
+func isPinned(row spaceRow, pins []string) bool {
+	return len(pins) > 0 && pinRank(row, pins) >= 0
+}
+
+func saveSpaceOrderCmd(s spaceSort, pins []string) tea.Cmd {
+	return func() tea.Msg {
+		db := sharedStateDB()
+		if db == nil {
+			return stateSavedMsg{err: fmt.Errorf("state db unavailable")}
+		}
+		encoded, err := json.Marshal(pins)
+		if err != nil {
+			return stateSavedMsg{err: fmt.Errorf("encoding pins: %w", err)}
+		}
+		if err := setUIState(db, uiStateSpaceSort, s.String()); err != nil {
+			return stateSavedMsg{err: err}
+		}
+		return stateSavedMsg{err: setUIState(db, uiStatePins, string(encoded))}
+	}
+}
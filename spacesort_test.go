@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func spaceOrder(rows []spaceRow) []int {
+	var order []int
+	for _, r := range rows {
+		order = append(order, r.space.Index)
+	}
+	return order
+}
+
+func TestSortSpaceRows(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	rows := func() []spaceRow {
+		return []spaceRow{
+			{space: Space{Index: 1, Label: "notes"}},
+			{space: Space{Index: 2}, windows: []Window{{App: "kitty", Title: "api"}}},
+			{space: Space{Index: 3}, windows: []Window{{App: "kitty", Title: "web"}, {App: "Safari"}}},
+			{space: Space{Index: 4}},
+		}
+	}
+	activity := map[string]time.Time{"api": now.Add(-time.Minute), "web": now.Add(-time.Hour)}
+
+	cases := []struct {
+		mode spaceSort
+		pins []string
+		want []int
+	}{
+		{sortIndex, nil, []int{1, 2, 3, 4}},
+		{sortStaleness, nil, []int{3, 2, 1, 4}},
+		{sortWindows, nil, []int{3, 2, 1, 4}},
+		{sortFreeFirst, nil, []int{1, 4, 2, 3}},
+		{sortFreeLast, nil, []int{2, 3, 1, 4}},
+		{sortIndex, []string{"space:4", "session:api"}, []int{4, 2, 1, 3}},
+		{sortFreeLast, []string{"space:notes"}, []int{1, 2, 3, 4}},
+	}
+	for _, c := range cases {
+		r := rows()
+		sortSpaceRows(r, c.mode, c.pins, activity)
+		if got := spaceOrder(r); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s pins %v: order = %v, want %v", c.mode, c.pins, got, c.want)
+		}
+	}
+}
+
+func TestTogglePin(t *testing.T) {
+	m := newModel()
+	m.spaces = []Space{{ID: 11, Index: 1, Label: "notes"}, {ID: 12, Index: 2}, {ID: 13, Index: 3}}
+	m.windows = []Window{{ID: 1, App: "kitty", Title: "api", Space: 3}}
+	m.tmuxPanes = []TmuxPane{{SessionName: "api"}}
+	m.displayGroups = buildDisplayGroups(m.spaces, m.windows, m.displays)
+	m.cursorRow = 2
+
+	m, _ = m.togglePin()
+	if !reflect.DeepEqual(m.pins, []string{"session:api"}) {
+		t.Fatalf("pins = %v", m.pins)
+	}
+	// the pinned space moved to the top and the cursor went with it
+	if got := spaceOrder(m.displayGroups[0].spaces); !reflect.DeepEqual(got, []int{3, 1, 2}) || m.cursorRow != 0 {
+		t.Errorf("order = %v, cursor %d", got, m.cursorRow)
+	}
+
+	m, _ = m.togglePin()
+	if len(m.pins) != 0 || !reflect.DeepEqual(spaceOrder(m.displayGroups[0].spaces), []int{1, 2, 3}) || m.cursorRow != 2 {
+		t.Errorf("after unpin: pins %v, order %v, cursor %d", m.pins, spaceOrder(m.displayGroups[0].spaces), m.cursorRow)
+	}
+
+	m, _ = m.cycleSpaceSort()
+	if m.spaceSort != sortStaleness || m.status != "sorted by staleness" {
+		t.Errorf("sort = %s, status %q", m.spaceSort, m.status)
+	}
+}
@@ -0,0 +1,232 @@
+// spacetime: how long each space and app had focus, day by day.
+//
+// every refresh the dashboard notes which space has focus and which app
+// owns the focused window. the time since the previous refresh goes to
+// what had focus then, capped at spaceTimeMaxGap so a sleeping laptop or
+// a stalled fetch doesn't credit an hour to whatever was on screen, and
+// not counted at all while the user is away (idle.go). totals pile up
+// per local day in space_time; `stop report` prints a day's breakdown
+// and the detail panel shows the selected space's time today. spaces
+// are keyed by label, else index, so a labeled space keeps its history
+// when it moves.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const spaceTimeSchema = `
+CREATE TABLE IF NOT EXISTS space_time (
+	day TEXT NOT NULL,
+	space TEXT NOT NULL,
+	app TEXT NOT NULL,
+	ms INTEGER NOT NULL,
+	PRIMARY KEY (day, space, app)
+);
+`
+
+// spaceTimeMaxGap is the most one refresh interval can credit.
+const spaceTimeMaxGap = 2 * time.Minute
+
+// focusSample is what had focus at one refresh.
+type focusSample struct {
+	at    time.Time
+	space string // spaceTitle of the focused space, "" when none
+	app   string // app of the focused window, "" when none
+}
+
+// takeFocusSample reads the focused space and window out of a fetch.
+func takeFocusSample(spaces []Space, windows []Window, now time.Time) focusSample {
+	s := focusSample{at: now}
+	for _, sp := range spaces {
+		if sp.HasFocus {
+			s.space = spaceTitle(sp)
+		}
+	}
+	for _, w := range windows {
+		if w.HasFocus {
+			s.app = w.App
+		}
+	}
+	return s
+}
+
+// creditFor is how much of the gap up to next the previous sample earns.
+func (s focusSample) creditFor(next focusSample, away bool) time.Duration {
+	if s.at.IsZero() || s.space == "" || away {
+		return 0
+	}
+	return min(next.at.Sub(s.at), spaceTimeMaxGap)
+}
+
+// addSpaceTime credits d to a space and app on the day at falls in.
+func addSpaceTime(db *sql.DB, at time.Time, space, app string, d time.Duration) error {
+	_, err := db.Exec(
+		"INSERT INTO space_time (day, space, app, ms) VALUES (?, ?, ?, ?) ON CONFLICT (day, space, app) DO UPDATE SET ms = ms + excluded.ms",
+		at.Format("2006-01-02"), space, app, d.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("recording space time: %w", err)
+	}
+	return nil
+}
+
+// trackSpaceTime takes this refresh's sample and returns the write that
+// credits the previous one, or nil when there's nothing to credit.
+func (m *model) trackSpaceTime(spaces []Space, windows []Window, now time.Time) tea.Cmd {
+	prev, next := m.focusSample, takeFocusSample(spaces, windows, now)
+	m.focusSample = next
+	d := prev.creditFor(next, !awaySince.IsZero())
+	if d <= 0 || simulated != nil {
+		// a replayed or demo desk's focus isn't time spent here
+		return nil
+	}
+	return func() tea.Msg {
+		db := sharedStateDB()
+		if db == nil {
+			return nil
+		}
+		if err := addSpaceTime(db, prev.at, prev.space, prev.app, d); err != nil {
+			return actionDoneMsg{status: "tracking space time failed", err: err}
+		}
+		return nil
+	}
+}
+
+// spaceDay is one space's time on a day, with its apps, most used first.
+type spaceDay struct {
+	space string
+	total time.Duration
+	apps  []appTime
+}
+
+type appTime struct {
+	app string
+	d   time.Duration
+}
+
+// loadSpaceDay reads a day's totals, busiest space first.
+func loadSpaceDay(db *sql.DB, day time.Time) ([]spaceDay, error) {
+	rows, err := db.Query("SELECT space, app, ms FROM space_time WHERE day = ?", day.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("querying space time: %w", err)
+	}
+	defer rows.Close()
+
+	bySpace := map[string]*spaceDay{}
+	for rows.Next() {
+		var space, app string
+		var ms int64
+		if err := rows.Scan(&space, &app, &ms); err != nil {
+			return nil, fmt.Errorf("scanning space time: %w", err)
+		}
+		sd := bySpace[space]
+		if sd == nil {
+			sd = &spaceDay{space: space}
+			bySpace[space] = sd
+		}
+		d := time.Duration(ms) * time.Millisecond
+		sd.total += d
+		sd.apps = append(sd.apps, appTime{app, d})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	days := make([]spaceDay, 0, len(bySpace))
+	for _, sd := range bySpace {
+		sort.Slice(sd.apps, func(i, j int) bool {
+			if sd.apps[i].d != sd.apps[j].d {
+				return sd.apps[i].d > sd.apps[j].d
+			}
+			return sd.apps[i].app < sd.apps[j].app
+		})
+		days = append(days, *sd)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		if days[i].total != days[j].total {
+			return days[i].total > days[j].total
+		}
+		return days[i].space < days[j].space
+	})
+	return days, nil
+}
+
+// querySpaceTimeToday is the fetch-side read: space → time today.
+func querySpaceTimeToday() map[string]time.Duration {
+	db := sharedStateDB()
+	if db == nil {
+		return nil
+	}
+	days, err := loadSpaceDay(db, time.Now())
+	if err != nil {
+		return nil
+	}
+	totals := make(map[string]time.Duration, len(days))
+	for _, sd := range days {
+		totals[sd.space] = sd.total
+	}
+	return totals
+}
+
+// writeSpaceReport prints a day's breakdown.
+func writeSpaceReport(w io.Writer, day time.Time, days []spaceDay) {
+	var total time.Duration
+	for _, sd := range days {
+		total += sd.total
+	}
+	fmt.Fprintf(w, "focus by space, %s: %s tracked\n", day.Format("Mon Jan 2"), humanDuration(total))
+	if len(days) == 0 {
+		fmt.Fprintln(w, "  nothing tracked (the dashboard records time while it runs)")
+		return
+	}
+	for _, sd := range days {
+		fmt.Fprintf(w, "  %-24s %8s  %3.0f%%\n", sd.space, humanDuration(sd.total), 100*sd.total.Seconds()/total.Seconds())
+		for _, a := range sd.apps {
+			app := a.app
+			if app == "" {
+				app = "(no window)"
+			}
+			fmt.Fprintf(w, "    %-22s %8s\n", app, humanDuration(a.d))
+		}
+	}
+}
+
+// reportCommand is the entry point for `stop report` — day is
+// YYYY-MM-DD in local time, today when empty.
+func reportCommand(day string) error {
+	when := time.Now()
+	if day != "" {
+		t, err := time.ParseInLocation("2006-01-02", day, time.Local)
+		if err != nil {
+			return fmt.Errorf("parsing day: %w", err)
+		}
+		when = t
+	}
+	db, err := openSnapshotDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	days, err := loadSpaceDay(db, when)
+	if err != nil {
+		return err
+	}
+	writeSpaceReport(os.Stdout, when, days)
+
+	usage, err := loadAgentUsage(db, when)
+	if err != nil {
+		return err
+	}
+	writeUsageReport(os.Stdout, when, usage)
+	return nil
+}
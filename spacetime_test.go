@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpaceTime(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(spaceTimeSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	spaces := []Space{{ID: 1, Index: 1, Label: "api", HasFocus: true}, {ID: 2, Index: 2}}
+	windows := []Window{{App: "kitty", Space: 1, HasFocus: true}, {App: "Firefox", Space: 2}}
+
+	prev := takeFocusSample(spaces, windows, at)
+	if prev.space != "api" || prev.app != "kitty" {
+		t.Fatalf("sample = %+v", prev)
+	}
+	next := focusSample{at: at.Add(30 * time.Second)}
+	if d := prev.creditFor(next, false); d != 30*time.Second {
+		t.Errorf("credit = %v", d)
+	}
+	if d := prev.creditFor(focusSample{at: at.Add(time.Hour)}, false); d != spaceTimeMaxGap {
+		t.Errorf("long gap credit = %v, want the cap", d)
+	}
+	if d := prev.creditFor(next, true); d != 0 {
+		t.Errorf("credited time away: %v", d)
+	}
+
+	for _, add := range []struct {
+		space, app string
+		d          time.Duration
+	}{
+		{"api", "kitty", 40 * time.Minute},
+		{"api", "kitty", 20 * time.Minute},
+		{"api", "Firefox", 10 * time.Minute},
+		{"space 2", "Slack", 5 * time.Minute},
+	} {
+		if err := addSpaceTime(db, at, add.space, add.app, add.d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	addSpaceTime(db, at.AddDate(0, 0, -1), "api", "kitty", time.Hour)
+
+	days, err := loadSpaceDay(db, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 2 || days[0].space != "api" || days[0].total != 70*time.Minute || days[0].apps[0] != (appTime{"kitty", time.Hour}) {
+		t.Fatalf("days = %+v", days)
+	}
+
+	var buf bytes.Buffer
+	writeSpaceReport(&buf, at, days)
+	for _, want := range []string{"1h15m tracked", "api", "93%", "kitty", "space 2"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// staledata: say so when the page is showing old data.
+//
+// a refresh that fails once the page is up leaves the last good data in
+// place — the grid doesn't give way to an error screen over one bad
+// tick — and this line says how old what's showing is and why. a refresh
+// that never comes back (a hung yabai query, say) gets the same line
+// once the last one is summaryStaleAfter old. it goes away with the next
+// refresh that lands.
+
+package main
+
+import (
+	"time"
+)
+
+// renderStaleDataLine is the warning while the page shows old data, or
+// "" while it's current.
+func renderStaleDataLine(err error, refreshedAt, now time.Time) string {
+	if refreshedAt.IsZero() {
+		return ""
+	}
+	age := now.Sub(refreshedAt)
+	badge := warnStyle.Render("⚠ data from " + humanDuration(age) + " ago")
+	switch {
+	case err != nil:
+		return badge + dimStyle.Render(" — refresh failed: "+err.Error())
+	case age > summaryStaleAfter:
+		return badge + dimStyle.Render(" — waiting on a refresh")
+	}
+	return ""
+}
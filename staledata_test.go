@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStaleDataLine(t *testing.T) {
+	now := time.Now()
+	if line := renderStaleDataLine(nil, now.Add(-time.Second), now); line != "" {
+		t.Errorf("fresh data got a line: %q", line)
+	}
+	if line := renderStaleDataLine(errors.New("boom"), time.Time{}, now); line != "" {
+		t.Errorf("no data yet got a line: %q", line)
+	}
+	line := renderStaleDataLine(errors.New("yabai: timed out"), now.Add(-37*time.Second), now)
+	if !strings.Contains(line, "data from 37s ago") || !strings.Contains(line, "yabai: timed out") {
+		t.Errorf("failed refresh line = %q", line)
+	}
+	if line := renderStaleDataLine(nil, now.Add(-time.Minute), now); !strings.Contains(line, "data from 1m0s ago") || !strings.Contains(line, "waiting") {
+		t.Errorf("hung refresh line = %q", line)
+	}
+}
+
+func TestFailedRefreshKeepsData(t *testing.T) {
+	next, _ := newModel().handleData(fixtureResult(t, "desk.json"))
+	next, _ = next.(model).handleData(fetchResult{err: errors.New("yabai: timed out")})
+	m := next.(model)
+	m.width, m.height = 160, 50
+	view := m.View()
+	for _, want := range []string{"api", "data from", "yabai: timed out"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view is missing %q:\n%s", want, view)
+		}
+	}
+}
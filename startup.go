@@ -0,0 +1,119 @@
+// startup: the screen shown until the first refresh succeeds.
+//
+// a refresh only fails outright when the window manager is unreachable
+// and there's no tmux to fall back to. instead of a bare "is yabai
+// running?", the dashboard shows what's wrong: the doctor's dependency
+// checks (doctor.go), rerun every time a refresh fails, with keys to
+// retry now, open the docs, or give up on the window manager and carry on
+// tmux only. the poll tick keeps refreshing underneath, so the dashboard
+// takes over by itself as soon as the requirements come up.
+
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// docsURL is what o opens from the startup screen.
+const docsURL = "https://github.com/fadedlamp42/stop#readme"
+
+// startupChecksMsg carries a fresh run of the startup checks.
+type startupChecksMsg []doctorCheck
+
+// startupChecksCmd runs the doctor's checks off the update loop.
+func startupChecksCmd() tea.Msg {
+	return startupChecksMsg(runDoctor())
+}
+
+// openDocsCmd opens docsURL in the browser. failures land in the status
+// line.
+func openDocsCmd() tea.Msg {
+	opener := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		opener = "open"
+	}
+	if err := engine.Guard(opener, docsURL); err != nil {
+		return actionDoneMsg{status: "opening docs failed", err: err}
+	}
+	if err := exec.Command(opener, docsURL).Start(); err != nil {
+		return actionDoneMsg{status: "opening docs failed", err: err}
+	}
+	return actionDoneMsg{status: "opened " + docsURL}
+}
+
+// starting reports whether the startup screen is up: nothing has loaded
+// yet and the last refresh failed.
+func (m model) starting() bool {
+	return !m.ready && m.err != nil
+}
+
+// handleStartupKey handles the startup screen's keys. quit is handled
+// before this, like everywhere else.
+func (m model) handleStartupKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "r":
+		m.status = "retrying..."
+		cmds := []tea.Cmd{fetchCmd}
+		if !m.checking {
+			m.checking = true
+			cmds = append(cmds, startupChecksCmd)
+		}
+		return m, tea.Batch(cmds...)
+	case "t":
+		// the same switch as --no-yabai; the next refresh degrades to
+		// tmux only instead of failing
+		tmuxOnly = true
+		m.status = "continuing without the window manager"
+		return m, fetchCmd
+	case "o":
+		return m, openDocsCmd
+	}
+	return m, nil
+}
+
+// renderStartup draws the startup screen.
+func (m model) renderStartup() string {
+	pad := "  "
+	var b strings.Builder
+	b.WriteString("\n" + pad + warnStyle.Render("waiting for "+currentWM().Name()) +
+		dimStyle.Render(" — "+m.err.Error()) + "\n\n")
+
+	if len(m.startupChecks) == 0 {
+		b.WriteString(pad + dimStyle.Render("checking...") + "\n")
+	} else {
+		width := 0
+		for _, c := range m.startupChecks {
+			width = max(width, len(c.name))
+		}
+		for _, c := range m.startupChecks {
+			mark := freeStyle.Render("ok  ")
+			if !c.ok {
+				mark = warnStyle.Render("FAIL")
+			}
+			b.WriteString(pad + mark + "  " + c.name + strings.Repeat(" ", width-len(c.name)) +
+				"  " + dimStyle.Render(c.detail) + "\n")
+		}
+	}
+
+	b.WriteString("\n" + pad + dimStyle.Render("the dashboard opens as soon as "+currentWM().Name()+" answers") + "\n")
+	if m.status != "" {
+		b.WriteString(pad + m.status + "\n")
+	}
+	binds := []struct{ key, desc string }{
+		{"r", "retry"},
+		{"t", "tmux only"},
+		{"o", "docs"},
+		{activeKeymap.label(actQuit), "quit"},
+	}
+	var parts []string
+	for _, bd := range binds {
+		parts = append(parts, keyStyle.Render(bd.key)+" "+helpStyle.Render(bd.desc))
+	}
+	b.WriteString(pad + strings.Join(parts, "  ") + "\n")
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStartupScreen(t *testing.T) {
+	m := newModel()
+	next, cmd := m.handleData(fetchResult{err: errors.New("yabai: connection refused")})
+	m = next.(model)
+	if !m.starting() || !m.checking || cmd == nil {
+		t.Fatalf("failed first refresh should open the startup screen and run checks: starting=%v checking=%v", m.starting(), m.checking)
+	}
+
+	next, _ = m.Update(startupChecksMsg{
+		{"tmux", true, "/usr/bin/tmux"},
+		{"yabai spaces", false, "connection refused"},
+	})
+	m = next.(model)
+	view := m.View()
+	for _, want := range []string{"connection refused", "yabai spaces", "FAIL", "tmux only"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("startup view missing %q:\n%s", want, view)
+		}
+	}
+
+	defer func(was bool) { tmuxOnly = was }(tmuxOnly)
+	next, cmd = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	if !tmuxOnly || cmd == nil {
+		t.Errorf("t should switch to tmux only and refetch")
+	}
+
+	// the first good refresh replaces the screen with the dashboard
+	next, _ = next.(model).handleData(fetchResult{wmErr: errors.New("disabled")})
+	if m = next.(model); m.starting() || m.startupChecks != nil {
+		t.Errorf("startup screen still up after a good refresh")
+	}
+}
@@ -0,0 +1,49 @@
+// statusbar: what the last action did, for a few seconds.
+//
+// every action reports back on the status line — "focused space 4",
+// "killed session foo", or what went wrong — and a failure reads as one,
+// in the warning color, rather than looking like nothing happened. a
+// message is bright for statusFresh, dims, and is gone after
+// statusLinger (config.go), so the line only ever says something recent.
+// Update stamps a message when it changes, or when an action reports
+// again with the same words.
+
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stampStatus notes when the status changed, and whether it's a failure.
+func (m model) stampStatus(before string, msg tea.Msg) model {
+	done, isDone := msg.(actionDoneMsg)
+	if m.status == before && !isDone {
+		return m
+	}
+	m.statusAt = time.Now()
+	m.statusErr = isDone && done.err != nil
+	return m
+}
+
+// renderStatus is the status line at now: fresh, faded, or gone. a
+// status never stamped (set outside Update) shows as fresh.
+func renderStatus(status string, isErr bool, at, now time.Time) string {
+	age := now.Sub(at)
+	switch {
+	case status == "":
+		return ""
+	case at.IsZero():
+		age = 0
+	case age >= statusLinger:
+		return ""
+	}
+	if isErr {
+		return warnStyle.Render(status)
+	}
+	if age < statusFresh {
+		return freeStyle.Render(status)
+	}
+	return dimStyle.Render(status)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStatusStamp(t *testing.T) {
+	next, _ := newModel().Update(actionDoneMsg{status: "killing foo failed", err: errors.New("no such session")})
+	m := next.(model)
+	if m.statusAt.IsZero() || !m.statusErr || m.status != "killing foo failed: no such session" {
+		t.Fatalf("status = %q at %v, err %v", m.status, m.statusAt, m.statusErr)
+	}
+
+	// the same words again still count as news
+	m.statusAt = time.Time{}
+	next, _ = m.Update(actionDoneMsg{status: "killing foo failed", err: errors.New("no such session")})
+	if next.(model).statusAt.IsZero() {
+		t.Error("a repeated result wasn't stamped")
+	}
+
+	next, _ = m.Update(actionDoneMsg{status: "killed session foo"})
+	if m := next.(model); m.statusErr {
+		t.Error("a success kept the failure color")
+	}
+}
+
+func TestRenderStatus(t *testing.T) {
+	now := time.Now()
+	if renderStatus("focused space 4", false, now.Add(-time.Second), now) == "" {
+		t.Error("a fresh status was hidden")
+	}
+	if renderStatus("focused space 4", false, now.Add(-statusLinger), now) != "" {
+		t.Error("an old status outstayed statusLinger")
+	}
+	if renderStatus("set by hand", false, time.Time{}, now) == "" {
+		t.Error("an unstamped status was hidden")
+	}
+	if renderStatus("", true, now, now) != "" {
+		t.Error("an empty status rendered")
+	}
+}
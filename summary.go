@@ -0,0 +1,73 @@
+// summary: the whole desk in one line.
+//
+// each column's footer counts its own display; the page's top line adds
+// them up, so the desk's health reads at a glance without scanning every
+// column: how many spaces there are and how many are free, the
+// productive panes counted by staleness tier in the tiers' own colors,
+// and when the last refresh landed — which turns into a warning once
+// refreshes stop arriving, so a frozen page doesn't pass for a quiet one.
+// summaryLine (config.go) turns it off. a running focus session keeps
+// the front of the line.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// summaryStaleAfter is how old the last refresh can get before the
+// summary says so.
+const summaryStaleAfter = 5 * basePollInterval
+
+// deskSummary is what the summary line counts.
+type deskSummary struct {
+	spaces, free int
+	tiers        []int // productive panes per staleness tier
+}
+
+// summarizeDesk counts every display's spaces and every productive pane.
+func summarizeDesk(groups []displayGroup, panes []TmuxPane, productive map[int]bool) deskSummary {
+	s := deskSummary{tiers: make([]int, len(stalenessTiers))}
+	for _, dg := range groups {
+		s.spaces += len(dg.spaces)
+		s.free += dg.freeCount
+	}
+	for _, p := range panes {
+		if productive[p.PanePID] {
+			s.tiers[stalenessTier(p.LastActivity)]++
+		}
+	}
+	return s
+}
+
+// renderSummaryLine is the summary as the top line shows it.
+func renderSummaryLine(s deskSummary, refreshedAt, now time.Time) string {
+	parts := []string{fmt.Sprintf("%d spaces", s.spaces)}
+	if s.free > 0 {
+		parts = append(parts, freeStyle.Render(fmt.Sprintf("%d free", s.free)))
+	} else {
+		parts = append(parts, warnStyle.Render("0 free"))
+	}
+	var tiers []string
+	for i, n := range s.tiers {
+		if n > 0 {
+			tiers = append(tiers, stalenessTiers[i].style().Render(fmt.Sprintf("%d %s", n, stalenessTiers[i].name)))
+		}
+	}
+	if len(tiers) > 0 {
+		parts = append(parts, strings.Join(tiers, dimStyle.Render(" / ")))
+	} else {
+		parts = append(parts, dimStyle.Render("no agents"))
+	}
+	switch {
+	case refreshedAt.IsZero():
+	case now.Sub(refreshedAt) > summaryStaleAfter:
+		// the stale data line says how old (staledata.go)
+		parts = append(parts, warnStyle.Render("refreshed "+refreshedAt.Format("15:04:05")))
+	default:
+		parts = append(parts, dimStyle.Render("refreshed "+refreshedAt.Format("15:04:05")))
+	}
+	return strings.Join(parts, dimStyle.Render("  ·  "))
+}
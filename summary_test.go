@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeskSummary(t *testing.T) {
+	now := time.Now()
+	groups := []displayGroup{
+		{index: 1, spaces: make([]spaceRow, 3), freeCount: 1},
+		{index: 2, spaces: make([]spaceRow, 2), freeCount: 1},
+	}
+	panes := []TmuxPane{
+		{PanePID: 1, LastActivity: now},
+		{PanePID: 2, LastActivity: now.Add(-10 * time.Second)},
+		{PanePID: 3, LastActivity: now.Add(-2 * time.Hour)},
+		{PanePID: 4, LastActivity: now}, // a shell
+	}
+	s := summarizeDesk(groups, panes, map[int]bool{1: true, 2: true, 3: true})
+	if s.spaces != 5 || s.free != 2 || s.tiers[0] != 2 || s.tiers[len(s.tiers)-1] != 1 {
+		t.Fatalf("summary = %+v", s)
+	}
+
+	line := renderSummaryLine(s, now.Add(-time.Second), now)
+	for _, want := range []string{"5 spaces", "2 free", "2 active", "1 cold", "refreshed " + now.Add(-time.Second).Format("15:04:05")} {
+		if !strings.Contains(line, want) {
+			t.Errorf("line missing %q: %s", want, line)
+		}
+	}
+	if strings.Contains(line, "recent") {
+		t.Errorf("line lists an empty tier: %s", line)
+	}
+
+	if line := renderSummaryLine(deskSummary{tiers: make([]int, len(stalenessTiers))}, now.Add(-time.Minute), now); !strings.Contains(line, "0 free") ||
+		!strings.Contains(line, "no agents") || !strings.Contains(line, "refreshed "+now.Add(-time.Minute).Format("15:04:05")) {
+		t.Errorf("stale line = %s", line)
+	}
+}
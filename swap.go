@@ -0,0 +1,78 @@
+// swap: exchange the windows of two spaces.
+//
+// the window manager can only move one window at a time, so a swap is a
+// batch of single moves: everything on A goes to B, everything on B goes
+// to A. the batch is planned up front from one window listing, and if a
+// move fails partway the moves already made are undone in reverse so the
+// two spaces don't end up half-swapped.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// windowMove sends one window from a space to another.
+type windowMove struct {
+	windowID int
+	from, to int
+}
+
+// planSpaceSwap lists the moves that exchange spaces a and b. only
+// windows the dashboard shows (not hidden or minimized) are moved — those
+// are the ones the user picked the swap by.
+func planSpaceSwap(a, b int, windows []Window) []windowMove {
+	var moves []windowMove
+	for _, w := range windows {
+		if w.IsHidden || w.IsMinimized {
+			continue
+		}
+		switch w.Space {
+		case a:
+			moves = append(moves, windowMove{windowID: w.ID, from: a, to: b})
+		case b:
+			moves = append(moves, windowMove{windowID: w.ID, from: b, to: a})
+		}
+	}
+	return moves
+}
+
+// applyWindowMoves runs moves in order. on the first failure it moves the
+// already-moved windows back, newest first, and reports the failure along
+// with any window that couldn't be returned.
+func applyWindowMoves(moves []windowMove, move func(windowID, space int) error) error {
+	for i, mv := range moves {
+		err := move(mv.windowID, mv.to)
+		if err == nil {
+			continue
+		}
+		var stranded []string
+		for j := i - 1; j >= 0; j-- {
+			done := moves[j]
+			if rerr := move(done.windowID, done.from); rerr != nil {
+				stranded = append(stranded, fmt.Sprintf("%d", done.windowID))
+			}
+		}
+		if len(stranded) > 0 {
+			return fmt.Errorf("moving window %d: %w (rollback left windows %s on the wrong space)",
+				mv.windowID, err, strings.Join(stranded, ", "))
+		}
+		return fmt.Errorf("moving window %d: %w (rolled back)", mv.windowID, err)
+	}
+	return nil
+}
+
+// swapSpaces exchanges the windows of spaces a and b via the active
+// window manager. returns how many windows moved.
+func swapSpaces(a, b int) (int, error) {
+	windows, err := currentWM().QueryWindows()
+	if err != nil {
+		return 0, fmt.Errorf("listing windows: %w", err)
+	}
+	moves := planSpaceSwap(a, b, windows)
+	if err := applyWindowMoves(moves, currentWM().MoveWindow); err != nil {
+		return 0, err
+	}
+	return len(moves), nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSpaceSwapRollsBack(t *testing.T) {
+	windows := []Window{
+		{ID: 1, Space: 2},
+		{ID: 2, Space: 2},
+		{ID: 3, Space: 5},
+		{ID: 4, Space: 5, IsMinimized: true},
+		{ID: 5, Space: 7},
+	}
+	moves := planSpaceSwap(2, 5, windows)
+	if len(moves) != 3 {
+		t.Fatalf("expected 3 moves, got %+v", moves)
+	}
+
+	// window 3 refuses to move: 1 and 2 must go back to space 2
+	location := map[int]int{1: 2, 2: 2, 3: 5}
+	err := applyWindowMoves(moves, func(id, space int) error {
+		if id == 3 {
+			return errors.New("window is sticky")
+		}
+		location[id] = space
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if location[1] != 2 || location[2] != 2 || location[3] != 5 {
+		t.Fatalf("rollback incomplete: %v", location)
+	}
+}
@@ -0,0 +1,233 @@
+// tabs: how many tabs each browser window holds.
+//
+// a browser window is one line in the grid however many tabs it hides.
+// with browserTabsEnabled on (config.go) stop asks Safari and the Chromium
+// browsers over AppleScript for every window's tabs and shows the count
+// next to the window, and the detail panel lists the titles when
+// browserTabTitles is on too. AppleScript doesn't know window server
+// ids, so windows are matched by title: a browser's window title is its
+// active tab's, give or take the app name it appends. titles shared by
+// two windows are ambiguous and left out.
+//
+// on Linux, or anywhere the browser was started with
+// --remote-debugging-port, browserCDPURL points at the DevTools endpoint
+// instead. it lists pages without saying which window they're in, so its
+// tabs are only attributed when the browser has a single window.
+//
+// asking is slow (hundreds of milliseconds per browser), so results are
+// cached for browserTabsRefresh and refreshed in the background: a fetch
+// never waits on a browser, it uses the last answer.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// browserTabsRefresh is how old the cached tabs may get.
+const browserTabsRefresh = 30 * time.Second
+
+// tabScripts list every tab of every window as "window<tab>tab" lines.
+// the Chromium browsers share Chrome's dictionary.
+var tabScripts = func() map[string]string {
+	safari := `tell application "Safari"
+	set out to ""
+	repeat with w in windows
+		repeat with t in tabs of w
+			set out to out & (name of w) & tab & (name of t) & linefeed
+		end repeat
+	end repeat
+	return out
+end tell`
+	chromium := `tell application "%s"
+	set out to ""
+	repeat with w in windows
+		repeat with t in tabs of w
+			set out to out & (title of active tab of w) & tab & (title of t) & linefeed
+		end repeat
+	end repeat
+	return out
+end tell`
+	scripts := map[string]string{"Safari": safari}
+	for _, app := range []string{"Google Chrome", "Brave Browser", "Microsoft Edge", "Chromium"} {
+		scripts[app] = fmt.Sprintf(chromium, app)
+	}
+	return scripts
+}()
+
+// appTabs is one app's answer: window title → its tab titles.
+type appTabs map[string][]string
+
+// parseTabLines groups "window<tab>tab" lines by window, in order.
+func parseTabLines(out string) appTabs {
+	tabs := appTabs{}
+	for _, line := range strings.Split(out, "\n") {
+		window, tab, ok := strings.Cut(strings.TrimRight(line, "\r"), "\t")
+		if !ok || window == "" {
+			continue
+		}
+		tabs[window] = append(tabs[window], tab)
+	}
+	return tabs
+}
+
+// browserTitleMatches reports whether a window manager title is the
+// window AppleScript named: equal, or that plus the app name suffix.
+func browserTitleMatches(windowTitle, name string) bool {
+	return windowTitle == name ||
+		strings.HasPrefix(windowTitle, name+" - ") ||
+		strings.HasPrefix(windowTitle, name+" — ")
+}
+
+// attachTabs maps each browser window id to its tabs. a window matching
+// no entry, or one whose entry matches several of the app's windows, gets
+// none. cdp tabs go to an app's only window when nothing else claimed it.
+func attachTabs(windows []Window, byApp map[string]appTabs, cdp map[string][]string) map[int][]string {
+	perApp := map[string][]Window{}
+	for _, w := range windows {
+		if isBrowser(w.App) {
+			perApp[w.App] = append(perApp[w.App], w)
+		}
+	}
+
+	result := map[int][]string{}
+	for app, ws := range perApp {
+		for name, tabs := range byApp[app] {
+			var match []int
+			for _, w := range ws {
+				if browserTitleMatches(w.Title, name) {
+					match = append(match, w.ID)
+				}
+			}
+			if len(match) == 1 {
+				result[match[0]] = tabs
+			}
+		}
+		if tabs, ok := cdp[app]; ok && len(ws) == 1 && result[ws[0].ID] == nil {
+			result[ws[0].ID] = tabs
+		}
+	}
+	return result
+}
+
+// queryCDPTabs lists page titles from a DevTools endpoint.
+func queryCDPTabs(base string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+"/json/list", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("querying devtools: %w", err)
+	}
+	defer resp.Body.Close()
+	var targets []struct {
+		Type  string `json:"type"`
+		Title string `json:"title"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&targets); err != nil {
+		return nil, fmt.Errorf("decoding devtools targets: %w", err)
+	}
+	var titles []string
+	for _, t := range targets {
+		if t.Type == "page" {
+			titles = append(titles, t.Title)
+		}
+	}
+	return titles, nil
+}
+
+// runTabScript asks one browser for its tabs.
+func runTabScript(script string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, "osascript", "-e", script).Output()
+	engine.LogCommand(start, "osascript", []string{"-e", "…"}, err)
+	return string(out), err
+}
+
+var (
+	tabsMu      sync.Mutex
+	tabsAt      time.Time
+	tabsLoading bool
+	tabsByApp   map[string]appTabs
+	tabsCDP     map[string][]string
+)
+
+// refreshTabs asks every running browser with a script, and the
+// DevTools endpoint when set. apps without windows aren't asked: telling
+// a browser that isn't running to list its windows would launch it.
+func refreshTabs(windows []Window) {
+	var apps []string
+	for _, w := range windows {
+		if _, ok := tabScripts[w.App]; ok && !slices.Contains(apps, w.App) {
+			apps = append(apps, w.App)
+		}
+	}
+	sort.Strings(apps)
+
+	byApp := map[string]appTabs{}
+	for _, app := range apps {
+		if out, err := runTabScript(tabScripts[app]); err == nil {
+			byApp[app] = parseTabLines(out)
+		}
+	}
+	var cdp map[string][]string
+	if browserCDPURL != "" {
+		if titles, err := queryCDPTabs(browserCDPURL); err == nil {
+			cdp = map[string][]string{}
+			for _, w := range windows {
+				if isBrowser(w.App) && w.App != "Safari" && w.App != "Firefox" {
+					cdp[w.App] = titles
+				}
+			}
+		}
+	}
+
+	tabsMu.Lock()
+	tabsByApp, tabsCDP, tabsAt, tabsLoading = byApp, cdp, time.Now(), false
+	tabsMu.Unlock()
+}
+
+// queryBrowserTabs returns the cached tabs attached to this fetch's
+// windows, starting a background refresh when they're stale. nil when
+// browserTabsEnabled is off.
+func queryBrowserTabs(windows []Window) map[int][]string {
+	if !browserTabsEnabled {
+		return nil
+	}
+	tabsMu.Lock()
+	if !tabsLoading && time.Since(tabsAt) >= browserTabsRefresh {
+		tabsLoading = true
+		go refreshTabs(append([]Window(nil), windows...))
+	}
+	byApp, cdp := tabsByApp, tabsCDP
+	tabsMu.Unlock()
+	return attachTabs(windows, byApp, cdp)
+}
+
+// renderTabCount is the dim "12 tabs" after a browser window, or "".
+func renderTabCount(rc renderContext, w Window) string {
+	n := len(rc.browserTabs[w.ID])
+	if n == 0 {
+		return ""
+	}
+	if n == 1 {
+		return dimStyle.Render(" 1 tab")
+	}
+	return dimStyle.Render(fmt.Sprintf(" %d tabs", n))
+}
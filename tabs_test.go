@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAttachTabs(t *testing.T) {
+	byApp := map[string]appTabs{
+		"Google Chrome": parseTabLines("Inbox\tInbox\nInbox\tDocs\nNews\tNews\nDup\ta\n\nnot a line\n"),
+		"Safari":        parseTabLines("Apple\tApple\r\n"),
+	}
+	if got := byApp["Google Chrome"]["Inbox"]; !reflect.DeepEqual(got, []string{"Inbox", "Docs"}) {
+		t.Fatalf("parsed = %v", got)
+	}
+
+	windows := []Window{
+		{ID: 1, App: "Google Chrome", Title: "Inbox - Google Chrome"},
+		{ID: 2, App: "Google Chrome", Title: "News - Google Chrome"},
+		{ID: 3, App: "Google Chrome", Title: "Dup - Google Chrome"},
+		{ID: 4, App: "Google Chrome", Title: "Dup - Google Chrome"},
+		{ID: 5, App: "Safari", Title: "Apple"},
+		{ID: 6, App: "Brave Browser", Title: "Local - Brave"},
+		{ID: 7, App: "kitty", Title: "Inbox"},
+	}
+	cdp := map[string][]string{"Brave Browser": {"Local", "Docs"}, "Google Chrome": {"x"}}
+	got := attachTabs(windows, byApp, cdp)
+
+	want := map[int][]string{
+		1: {"Inbox", "Docs"},
+		2: {"News"},
+		5: {"Apple"},
+		6: {"Local", "Docs"},
+	}
+	// 3 and 4 share a title; chrome has several windows so cdp can't say
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("attachTabs = %v, want %v", got, want)
+	}
+}
+
+func TestQueryCDPTabs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json/list" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"type":"page","title":"Docs"},{"type":"service_worker","title":"sw"},{"type":"page","title":"Mail"}]`))
+	}))
+	defer srv.Close()
+
+	titles, err := queryCDPTabs(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(titles, []string{"Docs", "Mail"}) {
+		t.Errorf("titles = %v", titles)
+	}
+}
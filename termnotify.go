@@ -0,0 +1,61 @@
+// termnotify: notifications through the terminal instead of the desktop.
+//
+// osascript and notify-send pop a notification on the machine stop runs
+// on, which is no use when the TUI runs on a remote box over SSH. with
+// terminalNotify set (config.go) notifications are written to the
+// controlling terminal as an escape sequence the terminal emulator turns
+// into a notification on whichever machine it's on: OSC 9 (iTerm2,
+// kitty, WezTerm, Windows Terminal) or OSC 777 (foot, Ghostty, urxvt).
+// inside tmux the sequence is wrapped for passthrough, which needs
+// `set -g allow-passthrough on`. a process without a terminal, like
+// `stop serve` run from launchd, falls back to the desktop.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// terminal notification styles for terminalNotify
+const (
+	terminalNotifyOSC9   = "osc9"
+	terminalNotifyOSC777 = "osc777"
+)
+
+// terminalNotifySequence is the escape sequence for a notification.
+func terminalNotifySequence(style, title, msg string, inTmux bool) (string, error) {
+	// a stray BEL or ESC would end the sequence early
+	clean := strings.NewReplacer("\x1b", "", "\a", "", "\n", " ", "\r", "")
+	title, msg = clean.Replace(title), clean.Replace(msg)
+
+	var seq string
+	switch style {
+	case terminalNotifyOSC9:
+		seq = "\x1b]9;" + title + ": " + msg + "\a"
+	case terminalNotifyOSC777:
+		seq = "\x1b]777;notify;" + strings.ReplaceAll(title, ";", ",") + ";" + msg + "\a"
+	default:
+		return "", fmt.Errorf("unknown terminal notification style %q", style)
+	}
+	if inTmux {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq, nil
+}
+
+// notifyTerminal writes a notification to the controlling terminal.
+func notifyTerminal(style, title, msg string) error {
+	seq, err := terminalNotifySequence(style, title, msg, os.Getenv("TMUX") != "")
+	if err != nil {
+		return err
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("opening the terminal: %w", err)
+	}
+	defer tty.Close()
+	_, err = tty.WriteString(seq)
+	return err
+}
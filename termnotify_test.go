@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestTerminalNotifySequence(t *testing.T) {
+	for _, tc := range []struct {
+		style, title, msg string
+		tmux              bool
+		want              string
+	}{
+		{terminalNotifyOSC9, "stop: api", "waiting 12m", false, "\x1b]9;stop: api: waiting 12m\a"},
+		{terminalNotifyOSC777, "stop: a;b", "waiting; 12m", false, "\x1b]777;notify;stop: a,b;waiting; 12m\a"},
+		// control characters can't end the sequence early
+		{terminalNotifyOSC9, "stop", "done\a\x1b]0;pwned\a", false, "\x1b]9;stop: done]0;pwned\a"},
+		{terminalNotifyOSC9, "stop", "hi", true, "\x1bPtmux;\x1b\x1b]9;stop: hi\a\x1b\\"},
+	} {
+		got, err := terminalNotifySequence(tc.style, tc.title, tc.msg, tc.tmux)
+		if err != nil || got != tc.want {
+			t.Errorf("terminalNotifySequence(%q, %q, %q, %v) = %q, %v; want %q", tc.style, tc.title, tc.msg, tc.tmux, got, err, tc.want)
+		}
+	}
+	if _, err := terminalNotifySequence("growl", "stop", "hi", false); err == nil {
+		t.Error("an unknown style was accepted")
+	}
+}
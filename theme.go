@@ -0,0 +1,198 @@
+// theme: every color the TUI draws with, by role.
+//
+// a theme maps roles (display headers, dim text, the cursor, staleness
+// tiers, ...) to colors. colors are ANSI numbers ("6", "208"), hex
+// ("#268bd2"), or names ("cyan", "bright-red"); an empty color means the
+// terminal's default. the theme is picked with --theme or themeName in
+// config.go, and themeOverrides there recolors individual roles on top of
+// it. applyTheme rebuilds the package-level styles, so the rest of the
+// view code keeps using displayStyle and friends unchanged.
+//
+// the built-in palettes are tuned for dark terminals: gray "8" dim text
+// and white "15" keys all but vanish on a light background. each theme
+// can carry a light variant (lightThemes) that's layered over it when the
+// background is light — detected by querying the terminal, or forced with
+// --background / themeBackground.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// theme is a role → color map. the staleness roles color the tiers in
+// config.go's stalenessTiers.
+type theme map[string]string
+
+// themeRoles lists every role a theme colors, for validation and docs.
+var themeRoles = []string{
+	"display", "dim", "cursor", "free", "warn", "key", "help",
+	"lyric-active", "lyric-near", "lyric-far", "annotation", "env-badge",
+	"active", "recent", "cooling", "stale", "cold",
+}
+
+var builtinThemes = map[string]theme{
+	"default": {
+		"display": "6", "dim": "8", "cursor": "6", "free": "2", "warn": "3",
+		"key": "15", "help": "8", "lyric-active": "15", "lyric-near": "7", "lyric-far": "8",
+		"annotation": "5", "env-badge": "4",
+		"active": "2", "recent": "3", "cooling": "208", "stale": "202", "cold": "1",
+	},
+	"solarized": {
+		"display": "#268bd2", "dim": "#586e75", "cursor": "#2aa198", "free": "#859900", "warn": "#b58900",
+		"key": "#93a1a1", "help": "#586e75", "lyric-active": "#eee8d5", "lyric-near": "#839496", "lyric-far": "#586e75",
+		"annotation": "#d33682", "env-badge": "#6c71c4",
+		"active": "#859900", "recent": "#b58900", "cooling": "#cb4b16", "stale": "#dc322f", "cold": "#d33682",
+	},
+	"gruvbox": {
+		"display": "#83a598", "dim": "#928374", "cursor": "#8ec07c", "free": "#b8bb26", "warn": "#fabd2f",
+		"key": "#fbf1c7", "help": "#928374", "lyric-active": "#fbf1c7", "lyric-near": "#ebdbb2", "lyric-far": "#928374",
+		"annotation": "#d3869b", "env-badge": "#83a598",
+		"active": "#b8bb26", "recent": "#fabd2f", "cooling": "#fe8019", "stale": "#d65d0e", "cold": "#fb4934",
+	},
+	"high-contrast": {
+		"display": "bright-cyan", "dim": "white", "cursor": "bright-cyan", "free": "bright-green", "warn": "bright-yellow",
+		"key": "bright-white", "help": "white", "lyric-active": "bright-white", "lyric-near": "white", "lyric-far": "white",
+		"annotation": "bright-magenta", "env-badge": "bright-blue",
+		"active": "bright-green", "recent": "bright-yellow", "cooling": "214", "stale": "202", "cold": "bright-red",
+	},
+	// no-color leaves every role empty; bold / italic / faint still apply
+	"no-color": {},
+}
+
+// lightThemes recolor the roles that don't survive a light background.
+var lightThemes = map[string]theme{
+	"default": {
+		"display": "30", "dim": "243", "cursor": "30", "free": "28", "warn": "136",
+		"key": "0", "help": "243", "lyric-active": "0", "lyric-near": "238", "lyric-far": "246",
+		"annotation": "90", "env-badge": "25",
+		"active": "28", "recent": "136", "cooling": "166", "stale": "160", "cold": "124",
+	},
+	"solarized": {
+		"dim": "#93a1a1", "key": "#073642", "help": "#93a1a1",
+		"lyric-active": "#073642", "lyric-near": "#586e75", "lyric-far": "#93a1a1",
+	},
+	"gruvbox": {
+		"display": "#076678", "dim": "#7c6f64", "cursor": "#427b58", "free": "#79740e", "warn": "#b57614",
+		"key": "#3c3836", "help": "#7c6f64", "lyric-active": "#282828", "lyric-near": "#504945", "lyric-far": "#928374",
+		"annotation": "#8f3f71", "env-badge": "#076678",
+		"active": "#79740e", "recent": "#b57614", "cooling": "#af3a03", "stale": "#9d0006", "cold": "#9d0006",
+	},
+	"high-contrast": {
+		"dim": "black", "key": "black", "help": "black",
+		"lyric-active": "black", "lyric-near": "black", "lyric-far": "black",
+		"recent": "3", "cooling": "166", "stale": "160", "cold": "1",
+	},
+}
+
+// isDarkBackground resolves a background setting: "dark", "light", or
+// "auto" to ask the terminal.
+func isDarkBackground(setting string) (bool, error) {
+	switch setting {
+	case "dark":
+		return true, nil
+	case "light":
+		return false, nil
+	case "auto", "":
+		return lipgloss.HasDarkBackground(), nil
+	}
+	return true, fmt.Errorf("unknown background %q (want auto, dark, or light)", setting)
+}
+
+// namedColors maps color names to ANSI numbers.
+var namedColors = map[string]string{
+	"black": "0", "red": "1", "green": "2", "yellow": "3",
+	"blue": "4", "magenta": "5", "cyan": "6", "white": "7",
+	"gray": "8", "grey": "8", "bright-black": "8",
+	"bright-red": "9", "bright-green": "10", "bright-yellow": "11",
+	"bright-blue": "12", "bright-magenta": "13", "bright-cyan": "14", "bright-white": "15",
+}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// resolveColor normalizes a color to something lipgloss.Color accepts.
+func resolveColor(c string) (string, error) {
+	c = strings.TrimSpace(c)
+	if c == "" || hexColorPattern.MatchString(c) {
+		return c, nil
+	}
+	if n, ok := namedColors[strings.ToLower(c)]; ok {
+		return n, nil
+	}
+	if n, err := strconv.Atoi(c); err == nil && n >= 0 && n <= 255 {
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown color %q (want 0-255, #hex, or a name like cyan)", c)
+}
+
+// resolveTheme looks up a built-in theme, layers its light variant when
+// the background isn't dark, applies overrides, and checks every role and
+// color.
+func resolveTheme(name string, dark bool, overrides map[string]string) (theme, error) {
+	base, ok := builtinThemes[name]
+	if !ok {
+		names := make([]string, 0, len(builtinThemes))
+		for n := range builtinThemes {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown theme %q (have %s)", name, strings.Join(names, ", "))
+	}
+	known := map[string]bool{}
+	for _, r := range themeRoles {
+		known[r] = true
+	}
+
+	layers := []map[string]string{base}
+	if !dark {
+		layers = append(layers, lightThemes[name])
+	}
+	t := theme{}
+	for _, layer := range append(layers, overrides) {
+		for role, c := range layer {
+			if !known[role] {
+				return nil, fmt.Errorf("unknown theme role %q", role)
+			}
+			color, err := resolveColor(c)
+			if err != nil {
+				return nil, fmt.Errorf("theme role %s: %w", role, err)
+			}
+			t[role] = color
+		}
+	}
+	return t, nil
+}
+
+// fg is a style with the role's foreground, or none when it's unset.
+func (t theme) fg(role string) lipgloss.Style {
+	s := lipgloss.NewStyle()
+	if c := t[role]; c != "" {
+		s = s.Foreground(lipgloss.Color(c))
+	}
+	return s
+}
+
+// applyTheme rebuilds the package styles and staleness tier colors.
+func applyTheme(t theme) {
+	displayStyle = t.fg("display").Bold(true)
+	dimStyle = t.fg("dim")
+	cursorStyle = t.fg("cursor")
+	freeStyle = t.fg("free")
+	warnStyle = t.fg("warn")
+	keyStyle = t.fg("key")
+	helpStyle = t.fg("help")
+	lyricActiveStyle = t.fg("lyric-active").Bold(true)
+	lyricNearStyle = t.fg("lyric-near")
+	lyricFarStyle = t.fg("lyric-far")
+	annotationStyle = t.fg("annotation").Italic(true)
+	envBadgeStyle = t.fg("env-badge")
+	for i := range stalenessTiers {
+		stalenessTiers[i].color = t[stalenessTiers[i].colorRole()]
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveTheme(t *testing.T) {
+	for name, th := range builtinThemes {
+		if name == "no-color" {
+			continue
+		}
+		for _, role := range themeRoles {
+			if th[role] == "" {
+				t.Errorf("theme %s leaves role %s unset", name, role)
+			}
+		}
+	}
+
+	th, err := resolveTheme("gruvbox", true, map[string]string{"cursor": "bright-red", "cold": "#fff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th["cursor"] != "9" || th["cold"] != "#fff" || th["display"] != "#83a598" {
+		t.Errorf("resolved = cursor %q cold %q display %q", th["cursor"], th["cold"], th["display"])
+	}
+
+	bad := []struct {
+		name      string
+		overrides map[string]string
+	}{
+		{"nope", nil},
+		{"default", map[string]string{"cursor": "chartreuse"}},
+		{"default", map[string]string{"cursor": "256"}},
+		{"default", map[string]string{"borders": "1"}},
+	}
+	for _, b := range bad {
+		if _, err := resolveTheme(b.name, true, b.overrides); err == nil {
+			t.Errorf("resolveTheme(%q, %v) accepted", b.name, b.overrides)
+		}
+	}
+}
+
+func TestApplyThemeStaleness(t *testing.T) {
+	defer func() {
+		th, _ := resolveTheme("default", true, nil)
+		applyTheme(th)
+	}()
+
+	th, _ := resolveTheme("no-color", true, nil)
+	applyTheme(th)
+	for _, tier := range stalenessTiers {
+		if tier.color != "" {
+			t.Errorf("no-color left tier %s colored %q", tier.name, tier.color)
+		}
+	}
+
+	th, _ = resolveTheme("solarized", true, nil)
+	applyTheme(th)
+	if stalenessTiers[len(stalenessTiers)-1].color != "#d33682" {
+		t.Errorf("cold tier = %q, want solarized magenta", stalenessTiers[len(stalenessTiers)-1].color)
+	}
+}
+
+func TestResolveLightTheme(t *testing.T) {
+	th, err := resolveTheme("default", false, map[string]string{"key": "blue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th["dim"] == "8" || th["lyric-active"] == "15" {
+		t.Errorf("light default kept dark-only colors: dim %q lyric-active %q", th["dim"], th["lyric-active"])
+	}
+	if th["key"] != "4" {
+		t.Errorf("override should win over the light variant, key = %q", th["key"])
+	}
+
+	// themes without a light variant fall back to the dark palette
+	if th, err := resolveTheme("no-color", false, nil); err != nil || len(th) != 0 {
+		t.Errorf("light no-color = %v, %v", th, err)
+	}
+	if _, err := isDarkBackground("dim"); err == nil {
+		t.Error("accepted an unknown background")
+	}
+}
+
+func TestCustomStalenessTiers(t *testing.T) {
+	saved := stalenessTiers
+	defer func() {
+		stalenessTiers = saved
+		th, _ := resolveTheme("default", true, nil)
+		applyTheme(th)
+	}()
+
+	stalenessTiers = []stalenessBand{
+		{name: "working", under: 20 * time.Minute, role: "active"},
+		{name: "idle", role: "cold"},
+	}
+	if err := checkStalenessTiers(stalenessTiers); err != nil {
+		t.Fatal(err)
+	}
+	th, _ := resolveTheme("default", true, nil)
+	applyTheme(th)
+	if stalenessTiers[0].color != "2" || stalenessTiers[1].color != "1" {
+		t.Errorf("tier colors = %q, %q; want the active and cold roles", stalenessTiers[0].color, stalenessTiers[1].color)
+	}
+	if got := stalenessTiers[stalenessTier(time.Now().Add(-15*time.Minute))].name; got != "working" {
+		t.Errorf("15m-old pane in tier %q, want working", got)
+	}
+
+	bad := [][]stalenessBand{
+		{{name: "only"}},
+		{{name: "a", under: time.Minute}, {name: "a"}},
+		{{name: "a", under: 5 * time.Minute}, {name: "recent", under: time.Minute}, {name: "cold"}},
+		{{name: "a", under: time.Minute}, {name: "cold", under: time.Hour}},
+		{{name: "thinking", under: time.Minute}, {name: "cold"}},
+	}
+	for _, tiers := range bad {
+		if err := checkStalenessTiers(tiers); err == nil {
+			t.Errorf("checkStalenessTiers(%v) accepted", tiers)
+		}
+	}
+	if err := checkStalenessTiers(saved); err != nil {
+		t.Errorf("default tiers rejected: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+// thumbnail: small screenshots of spaces for the Rose app.
+//
+// with spaceThumbnails on (config.go, or `stop serve --previews`), serve
+// answers /preview/{space} — a label or a yabai index — with a JPEG of
+// the space, shrunk so its longest edge is spaceThumbnailSize. macOS only
+// draws the visible space of each display, so a thumbnail is a capture of
+// the space's display (`screencapture -x -D`, whose display numbers are
+// yabai's indexes) taken while the space is the one showing there. a
+// hidden space answers with its last thumbnail, X-Captured-At saying how
+// old it is, or 404 when it hasn't been seen yet.
+//
+// thumbnails are cached per space and retaken at most every
+// spaceThumbnailInterval, and only one capture runs at a time, so a
+// client polling every space can't keep screencapture busy.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// errThumbnailHidden is a hidden space never seen on screen.
+var errThumbnailHidden = errors.New("space not on screen yet")
+
+// thumbnail is one capture of a space.
+type thumbnail struct {
+	jpeg []byte
+	at   time.Time
+}
+
+// thumbnailCache keeps the last capture of each space, by yabai space id
+// (indexes shift as spaces come and go).
+type thumbnailCache struct {
+	mu      sync.Mutex // held across a capture: one at a time
+	shots   map[int]thumbnail
+	capture func(display int) ([]byte, error)
+	now     func() time.Time
+}
+
+func newThumbnailCache() *thumbnailCache {
+	return &thumbnailCache{shots: map[int]thumbnail{}, capture: captureDisplay, now: time.Now}
+}
+
+// get returns space's thumbnail: the cached one while it's fresh or the
+// space is hidden, a new capture otherwise.
+func (c *thumbnailCache) get(space Space) (thumbnail, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	shot, ok := c.shots[space.ID]
+	if ok && (c.now().Sub(shot.at) < spaceThumbnailInterval || !space.IsVisible) {
+		return shot, nil
+	}
+	if !space.IsVisible {
+		return thumbnail{}, errThumbnailHidden
+	}
+	jpeg, err := c.capture(space.Display)
+	if err != nil {
+		return thumbnail{}, err
+	}
+	shot = thumbnail{jpeg: jpeg, at: c.now()}
+	c.shots[space.ID] = shot
+	return shot, nil
+}
+
+// findSpace resolves a label or yabai index, the label first.
+func findSpace(spaces []Space, target string) (Space, bool) {
+	for _, s := range spaces {
+		if s.Label != "" && s.Label == target {
+			return s, true
+		}
+	}
+	if index, err := strconv.Atoi(target); err == nil {
+		for _, s := range spaces {
+			if s.Index == index {
+				return s, true
+			}
+		}
+	}
+	return Space{}, false
+}
+
+// captureDisplay screenshots a display, silently, and shrinks it.
+func captureDisplay(display int) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "stop-thumbnail")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "space.jpg")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, cmd := range [][]string{
+		{"screencapture", "-x", "-D", strconv.Itoa(display), "-t", "jpg", path},
+		{"sips", "-Z", strconv.Itoa(spaceThumbnailSize), path},
+	} {
+		start := time.Now()
+		out, err := exec.CommandContext(ctx, cmd[0], cmd[1:]...).CombinedOutput()
+		engine.LogCommand(start, cmd[0], cmd[1:], err)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w: %s", cmd[0], err, out)
+		}
+	}
+	return os.ReadFile(path)
+}
+
+// handlePreview serves /preview/{space}. it asks the window manager for
+// spaces alone rather than running a full fetch: a client polling every
+// thumbnail shouldn't cost a tmux and process scan per image.
+func handlePreview(cache *thumbnailCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		spaces, err := currentWM().QuerySpaces()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		space, ok := findSpace(spaces, r.PathValue("space"))
+		if !ok {
+			http.Error(w, "no such space", http.StatusNotFound)
+			return
+		}
+		shot, err := cache.get(space)
+		switch {
+		case errors.Is(err, errThumbnailHidden):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(spaceThumbnailInterval.Seconds())))
+		w.Header().Set("X-Captured-At", shot.at.UTC().Format(time.RFC3339))
+		w.Write(shot.jpeg)
+	}
+}
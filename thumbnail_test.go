@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestThumbnailCache(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var captured []int
+	c := newThumbnailCache()
+	c.now = func() time.Time { return now }
+	c.capture = func(display int) ([]byte, error) {
+		captured = append(captured, display)
+		return []byte{byte(len(captured))}, nil
+	}
+	code := Space{ID: 7, Index: 3, Display: 2, IsVisible: true}
+
+	first, err := c.get(code)
+	if err != nil || len(captured) != 1 || captured[0] != 2 {
+		t.Fatalf("first get: %v, captured %v", err, captured)
+	}
+	// inside the interval the cached one comes back
+	now = now.Add(spaceThumbnailInterval / 2)
+	if shot, _ := c.get(code); string(shot.jpeg) != string(first.jpeg) || len(captured) != 1 {
+		t.Errorf("retook a fresh thumbnail: captured %v", captured)
+	}
+	// a hidden space keeps its last one however old
+	now = now.Add(time.Hour)
+	code.IsVisible = false
+	if shot, err := c.get(code); err != nil || !shot.at.Equal(first.at) || len(captured) != 1 {
+		t.Errorf("hidden space: %v, captured %v", err, captured)
+	}
+	// visible again and stale: retaken
+	code.IsVisible = true
+	if shot, _ := c.get(code); !shot.at.Equal(now) || len(captured) != 2 {
+		t.Errorf("stale thumbnail not retaken: captured %v", captured)
+	}
+
+	if _, err := c.get(Space{ID: 8, Index: 4, Display: 1}); !errors.Is(err, errThumbnailHidden) {
+		t.Errorf("never-seen hidden space: err = %v", err)
+	}
+	c.capture = func(int) ([]byte, error) { return nil, errors.New("no screen recording access") }
+	if _, err := c.get(Space{ID: 9, Index: 5, Display: 1, IsVisible: true}); err == nil {
+		t.Error("a failed capture was swallowed")
+	}
+}
+
+func TestFindSpace(t *testing.T) {
+	spaces := []Space{{ID: 1, Index: 1, Label: "code"}, {ID: 2, Index: 2, Label: "3"}, {ID: 3, Index: 3}}
+	for target, want := range map[string]int{"code": 1, "2": 2, "3": 2, "1": 1} {
+		if s, ok := findSpace(spaces, target); !ok || s.ID != want {
+			t.Errorf("findSpace(%q) = %d, %v; want %d", target, s.ID, ok, want)
+		}
+	}
+	if _, ok := findSpace(spaces, "web"); ok {
+		t.Error("found a space that isn't there")
+	}
+}
+
+func TestHandlePreview(t *testing.T) {
+	// only spaces are asked for: windows and tmux failing doesn't matter
+	fake := &engine.Fake{
+		Spaces: []Space{{ID: 1, Index: 1, Label: "code", Display: 1, IsVisible: true}},
+		Errors: map[string]string{"windows": "down", "tmux": "down", "ps": "down"},
+	}
+	simulated = fake
+	defer func() { simulated = nil }()
+	wmOnce = sync.Once{}
+	defer func() { wmOnce = sync.Once{} }()
+
+	cache := newThumbnailCache()
+	cache.capture = func(int) ([]byte, error) { return []byte("jpeg"), nil }
+	mux := http.NewServeMux()
+	mux.HandleFunc("/preview/{space}", handlePreview(cache))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/preview/code", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "jpeg" {
+		t.Errorf("/preview/code = %d %q", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/preview/web", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("/preview/web = %d", rec.Code)
+	}
+
+	fake.Errors = map[string]string{"spaces": "yabai isn't running"}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/preview/code", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("spaces failing: %d", rec.Code)
+	}
+}
@@ -0,0 +1,95 @@
+// titlehistory: what each space's windows were titled a while ago.
+//
+// a space's windows say what it's for right now, which isn't always what
+// it was for: a browser tab navigates away from the docs it was opened
+// for, an editor switches files. every refresh notes each window's title
+// against its space, keeping the last titleHistoryLength (config.go) per
+// space, and the detail panel lists the ones no longer showing under
+// "earlier", with how long ago each was last seen. the history lives in
+// memory only and starts empty with each run.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// titleSeen is one title a space's window had.
+type titleSeen struct {
+	app, title string
+	last       time.Time // when it was last on screen
+}
+
+// titleHistory is each space's recent titles, oldest first, by space ID
+// (which, unlike the index, survives spaces being reordered). a refresh
+// makes a new one rather than changing it, so models can share it.
+type titleHistory map[int][]titleSeen
+
+// observe notes the titles on screen at now. spaces that are gone drop
+// their history.
+func (h titleHistory) observe(spaces []Space, windows []Window, now time.Time) titleHistory {
+	idByIndex := map[int]int{}
+	next := titleHistory{}
+	for _, s := range spaces {
+		idByIndex[s.Index] = s.ID
+		if seen, ok := h[s.ID]; ok {
+			next[s.ID] = seen
+		}
+	}
+	for _, w := range windows {
+		id, ok := idByIndex[w.Space]
+		title := strings.TrimSpace(w.Title)
+		if !ok || title == "" {
+			continue
+		}
+		seen := make([]titleSeen, 0, titleHistoryLength+1)
+		for _, t := range next[id] {
+			if t.app != w.App || t.title != title {
+				seen = append(seen, t)
+			}
+		}
+		seen = append(seen, titleSeen{app: w.App, title: title, last: now})
+		if len(seen) > titleHistoryLength {
+			seen = seen[len(seen)-titleHistoryLength:]
+		}
+		next[id] = seen
+	}
+	return next
+}
+
+// earlier is the titles a space had that none of its windows show now,
+// most recent first.
+func (h titleHistory) earlier(row spaceRow) []titleSeen {
+	showing := map[string]bool{}
+	for _, w := range row.windows {
+		showing[w.App+"\x00"+strings.TrimSpace(w.Title)] = true
+	}
+	var gone []titleSeen
+	seen := h[row.space.ID]
+	for i := len(seen) - 1; i >= 0; i-- {
+		if !showing[seen[i].app+"\x00"+seen[i].title] {
+			gone = append(gone, seen[i])
+		}
+	}
+	return gone
+}
+
+// renderEarlierTitles is the detail panel's "earlier" section, empty when
+// the space has no past titles.
+func renderEarlierTitles(row spaceRow, h titleHistory) []string {
+	gone := h.earlier(row)
+	if len(gone) == 0 {
+		return nil
+	}
+	lines := []string{dimStyle.Render(fmt.Sprintf("earlier (%d)", len(gone)))}
+	for _, t := range gone {
+		ago := formatRelativeTime(t.last) + " ago"
+		if ago == "now ago" {
+			ago = "just now"
+		}
+		lines = append(lines, "  "+keyStyle.Render(withIcon(t.app))+"  "+t.title+dimStyle.Render("  "+ago))
+	}
+	return lines
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTitleHistory(t *testing.T) {
+	at := time.Now().Add(-10 * time.Minute)
+	spaces := []Space{{ID: 11, Index: 1}, {ID: 12, Index: 2}}
+	docs := Window{App: "Firefox", Title: "net/http - Go Packages", Space: 2}
+	var h titleHistory
+	h = h.observe(spaces, []Window{docs, {App: "kitty", Title: "api", Space: 1}}, at)
+
+	// the tab navigates away, and the spaces swap places
+	spaces = []Space{{ID: 12, Index: 1}, {ID: 11, Index: 2}}
+	news := Window{App: "Firefox", Title: "Hacker News", Space: 1}
+	h = h.observe(spaces, []Window{news}, at.Add(5*time.Minute))
+
+	row := spaceRow{space: spaces[0], windows: []Window{news}}
+	gone := h.earlier(row)
+	if len(gone) != 1 || gone[0].title != docs.Title || !gone[0].last.Equal(at) {
+		t.Fatalf("earlier = %+v, want the docs tab by space ID", gone)
+	}
+	lines := strings.Join(renderEarlierTitles(row, h), "\n")
+	if !strings.Contains(lines, "net/http - Go Packages") || !strings.Contains(lines, "10m ago") {
+		t.Errorf("earlier section:\n%s", lines)
+	}
+
+	// a title coming back moves to the front instead of repeating
+	h = h.observe(spaces, []Window{{App: "Firefox", Title: docs.Title, Space: 1}}, at.Add(6*time.Minute))
+	if seen := h[12]; len(seen) != 2 || seen[1].title != docs.Title {
+		t.Errorf("history = %+v", seen)
+	}
+
+	// the ring keeps the newest few, and a space that's gone drops its own
+	for i := range titleHistoryLength + 3 {
+		h = h.observe(spaces[:1], []Window{{App: "kitty", Title: strings.Repeat("x", i+1), Space: 1}}, at)
+	}
+	if seen := h[12]; len(seen) != titleHistoryLength || seen[len(seen)-1].title != strings.Repeat("x", titleHistoryLength+3) {
+		t.Errorf("ring = %d entries, last %+v", len(seen), seen[len(seen)-1])
+	}
+	if _, ok := h[11]; ok {
+		t.Error("a destroyed space kept its history")
+	}
+}
@@ -0,0 +1,158 @@
+// titles: keep terminal window titles equal to their tmux session names.
+//
+// single-process terminals (kitty) put every OS window under one PID, so
+// partitionTmuxByDisplay can only tell which window hosts which session
+// by matching the window title against the session name. a title that
+// drifted (a shell's own title escape, a tmux.conf without set-titles)
+// silently drops the session into "detached". `stop sync-titles` turns on
+// tmux's set-titles with a "#S" format — tmux then writes the title escape
+// sequence itself on every attach and session switch — and forces each
+// client to redraw so the titles are right immediately. --watch keeps
+// doing it in the background, for configs that get re-sourced.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// tmuxTitleFormat is the set-titles-string that makes a terminal's title
+// exactly its session name.
+const tmuxTitleFormat = "#S"
+
+// tmuxClientTTY is an attached client by its terminal device.
+type tmuxClientTTY struct {
+	tty     string
+	session string
+}
+
+// tmuxQueries are the tmux commands that only read, and so still run in
+// read-only mode.
+var tmuxQueries = map[string]bool{"list-clients": true, "list-panes": true, "list-sessions": true, "show-options": true}
+
+// runTmux runs a tmux command, folding its stderr into the error.
+func runTmux(args ...string) (string, error) {
+	if !tmuxQueries[args[0]] {
+		if err := engine.Guard("tmux", args...); err != nil {
+			return "", err
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, "tmux", args...).CombinedOutput()
+	engine.LogCommand(start, "tmux", args, err)
+	if err != nil {
+		return "", fmt.Errorf("tmux %s: %s", args[0], strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// enableTmuxTitles makes tmux own terminal titles, globally.
+func enableTmuxTitles() error {
+	if _, err := runTmux("set-option", "-g", "set-titles", "on"); err != nil {
+		return err
+	}
+	_, err := runTmux("set-option", "-g", "set-titles-string", tmuxTitleFormat)
+	return err
+}
+
+// queryTmuxClientTTYs lists attached clients.
+func queryTmuxClientTTYs() ([]tmuxClientTTY, error) {
+	out, err := runTmux("list-clients", "-F", "#{client_tty}\t#{session_name}")
+	if err != nil {
+		return nil, err
+	}
+	var clients []tmuxClientTTY
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		tty, session, ok := strings.Cut(line, "\t")
+		if ok && tty != "" {
+			clients = append(clients, tmuxClientTTY{tty: tty, session: session})
+		}
+	}
+	return clients, nil
+}
+
+// syncTitles enables tmux titles and redraws the clients whose session
+// differs from seen (all of them when seen is empty), recording what it
+// synced. returns how many clients were redrawn.
+func syncTitles(seen map[string]string) (int, error) {
+	if err := enableTmuxTitles(); err != nil {
+		return 0, err
+	}
+	clients, err := queryTmuxClientTTYs()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, c := range clients {
+		if seen[c.tty] == c.session {
+			continue
+		}
+		if _, err := runTmux("refresh-client", "-t", c.tty); err != nil {
+			return n, err
+		}
+		seen[c.tty] = c.session
+		n++
+	}
+	return n, nil
+}
+
+// unmappedSessions lists attached sessions that still can't be placed on
+// a display after a fresh fetch.
+func unmappedSessions(result fetchResult) []string {
+	groups := buildDisplayGroups(result.spaces, result.windows, result.displays)
+	byDisplay, _ := partitionTmuxByDisplay(result.tmuxPanes, result.tmuxClients, result.processTree, result.windows, groups)
+	mapped := map[string]bool{}
+	for _, panes := range byDisplay {
+		for _, p := range panes {
+			mapped[p.SessionName] = true
+		}
+	}
+	unmapped := map[string]bool{}
+	for _, c := range result.tmuxClients {
+		if !mapped[c.SessionName] {
+			unmapped[c.SessionName] = true
+		}
+	}
+	return sortedKeys(unmapped)
+}
+
+// syncTitlesCommand is the entry point for `stop sync-titles`.
+func syncTitlesCommand(watch bool, interval time.Duration) error {
+	seen := map[string]string{}
+	n, err := syncTitles(seen)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("synced %d terminal title(s) to tmux session names\n", n)
+
+	// give terminals a moment to apply the new titles before checking
+	time.Sleep(300 * time.Millisecond)
+	if result := fetchAll(); result.err == nil {
+		if unmapped := unmappedSessions(result); len(unmapped) > 0 {
+			fmt.Printf("still unmapped: %s\n", strings.Join(unmapped, ", "))
+		}
+	}
+	if !watch {
+		return nil
+	}
+
+	for range time.Tick(interval) {
+		n, err := syncTitles(seen)
+		if err != nil {
+			fmt.Printf("%s  sync failed: %v\n", time.Now().Format("15:04:05"), err)
+			continue
+		}
+		if n > 0 {
+			fmt.Printf("%s  synced %d title(s)\n", time.Now().Format("15:04:05"), n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestUnmappedSessions(t *testing.T) {
+	// one kitty process, two windows: "api" is titled after its session,
+	// the other window's title drifted to the shell's
+	result := fetchResult{
+		spaces: []Space{{Index: 1, Display: 1}, {Index: 2, Display: 1}},
+		windows: []Window{
+			{ID: 10, PID: 500, App: "kitty", Title: "api", Space: 1},
+			{ID: 11, PID: 500, App: "kitty", Title: "zsh", Space: 2},
+		},
+		tmuxPanes: []TmuxPane{
+			{SessionName: "api", PanePID: 1},
+			{SessionName: "web", PanePID: 2},
+		},
+		tmuxClients: []TmuxClient{{PID: 600, SessionName: "api"}, {PID: 601, SessionName: "web"}},
+		processTree: map[int]int{600: 500, 601: 500},
+	}
+
+	got := unmappedSessions(result)
+	if len(got) != 1 || got[0] != "web" {
+		t.Errorf("unmapped = %v, want [web]", got)
+	}
+}
@@ -6,18 +6,21 @@
 //
 // cursor uses (col, row) addressing where col selects the display and
 // row selects the space within that display. h/l moves between displays,
-// j/k moves within. this mirrors the physical monitor layout.
+// j/k moves within (and across into a monitor stacked above or below).
+// this mirrors the physical monitor layout.
 
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
-	"sort"
+	"strings"
 	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
 )
 
 // -- messages --
@@ -38,6 +41,20 @@ type metaMsg PlayingMeta
 // scroll smoothly without paying the cost of an extra script call.
 type renderTickMsg struct{}
 
+// actionDoneMsg reports the outcome of a confirmed action (swap,
+// destroy, kill) for the status line.
+type actionDoneMsg struct {
+	status string
+	err    error
+}
+
+// pendingAction is a destructive action waiting on y/n.
+type pendingAction struct {
+	prompt     string
+	run        tea.Cmd
+	clearMarks bool // a bulk action over the marked spaces (marks.go)
+}
+
 // spaceChangedMsg is sent when yabai reports a space/display focus change via SIGUSR1
 type spaceChangedMsg struct{}
 
@@ -54,9 +71,12 @@ func init() {
 
 type displayGroup struct {
 	index     int
+	display   Display // zero when the backend couldn't describe this display
+	layoutRow int     // 0 for the top row of monitors, 1 for ones stacked below, ...
 	spaces    []spaceRow
 	freeCount int
 	termCount int
+	hidden    []Window // minimized and hidden windows (hidden.go)
 }
 
 type spaceRow struct {
@@ -69,6 +89,7 @@ type spaceRow struct {
 type model struct {
 	// raw data from queries
 	spaces              []Space
+	displays            []Display
 	windows             []Window
 	tmuxPanes           []TmuxPane
 	tmuxClients         []TmuxClient
@@ -76,16 +97,148 @@ type model struct {
 	productivePanePIDs  map[int]bool
 	nvimBuffers         map[int][]NvimBuffer // pane_pid → open buffers
 	playingMeta         PlayingMeta          // latest player sample; renderer interpolates from this
-
-	// derived (rebuilt on each data refresh)
-	displayGroups []displayGroup
-	tmuxByDisplay map[int][]TmuxPane // display index → panes on that display
-	detachedTmux  []TmuxPane         // sessions not attached to any terminal
+	annotations         annotationSet        // external status strings for sessions / spaces
+	priorities          prioritySet          // triage levels for sessions / spaces
+	slo                 *sloReport           // running response-time compliance
+	alerts              alertSet             // fired, unacknowledged alerts by session
+	baselines           commandBaselines     // usual run per command
+	paneEnv             map[int]map[string]string // pane_pid → environment badge keys
+	paneKube            map[int]string            // pane_pid → kube context
+	paneStates          map[int]paneState         // pane_pid → agent state
+	browserTabs         map[int][]string          // window id → tab titles
+	terminalSessions    map[int][]engine.TerminalSession // window id → its tabs and split panes
+	bareAgents          map[int][]bareAgent       // window id → agents running outside tmux
+	focusSession        *focusSession             // the running focus timer
+	focusSample         focusSample               // what had focus at the last refresh
+	spaceTime           map[string]time.Duration  // spaceTitle → focus time today
+	agentUsage          map[string]agentUsage     // session → agents' usage today
+	titleHistory        titleHistory              // space ID → recent window titles
+	refreshedAt         time.Time                 // when the last refresh landed
+	spaceHotkeys        map[int]string            // space index → skhd hotkey
+	statusAt            time.Time                 // when status last changed (statusbar.go)
+	statusErr           bool                      // status reports a failed action
+	enrichments         enrichSet                 // badges and lines from enricher hooks
+	lastWatch           *watchState               // previous refresh, for events
+	flashes             map[int]time.Time         // space index → highlighted until
+	migrations          map[int]spaceMigration    // space index → moved-display flag
+	wmErr               error                     // window manager unreachable: tmux-only view
+	sourceErrs          []engine.SourceError      // queries that failed on the last refresh
+
+	// derived (patched on each data refresh, incremental.go)
+	displayGroups   []displayGroup
+	tmuxByDisplay   map[int][]TmuxPane // display index → panes on that display
+	detachedTmux    []TmuxPane         // sessions not attached to any terminal
+	sessionDisplays map[string]int     // tmux session → display, from the last client walk
+	detachedWhy     map[string]string  // tmux session → why it didn't place
 
 	// cursor: (col, row) where col = display index, row = space within display
 	cursorCol int
 	cursorRow int
 
+	// pendingKeys are the first keys of a multi-key binding, waiting for
+	// the rest (keys.go)
+	pendingKeys []string
+
+	// restoreSpaceID is the persisted cursor (uistate.go), held until the
+	// first refresh brings spaces to place it on
+	restoreSpaceID int
+
+	// compare is the open C overlay (compare.go), nil when closed
+	compare *compareView
+
+	// settling counts refreshes held back as mid-transition noise
+	// (settle.go); the previous frame stays up meanwhile
+	settling int
+
+	// swapFrom is the source space picked with S (0 = none); confirm is
+	// an action waiting on y/n and swallows all other keys while set
+	swapFrom int
+	confirm  *pendingAction
+	status   string // outcome of the last action, shown above the help line
+
+	// marks are the spaces picked for a bulk action, by space id
+	// (marks.go); nil when none are
+	marks map[int]bool
+
+	// count is a pending count prefix and countSeq numbers its digits, so
+	// only the latest one's timeout acts; bookmarkPending is the m or '
+	// waiting for its key, and bookmarks map each key to a space id
+	// (bookmarks.go)
+	count           int
+	countSeq        int
+	bookmarkPending string
+	bookmarks       map[string]int
+
+	// zoom shows the cursor's display alone, across the terminal (zoom.go)
+	zoom bool
+
+	// mirrorCursor flashes the selected space's windows on the real
+	// display whenever the cursor settles; followCursor focuses the space
+	// itself, turning the dashboard into a live switcher. cursorSeq
+	// numbers cursor moves so only the latest one's settle ticks act.
+	mirrorCursor bool
+	followCursor bool
+	cursorSeq    int
+
+	// agents view (a): a filtered flat list of live agents instead of the
+	// spaces grid. builder and picker are the open filter editor and
+	// saved-views dropdown, nil when closed.
+	showAgents  bool
+	agentFilter agentFilter
+	agentCursor int
+	builder     *filterBuilder
+	picker      *viewPicker
+
+	// hits is the clickable-region map View leaves behind for the mouse
+	// handler; lastClick/lastClickAt detect double-clicks. preview is an
+	// open tmux window preview, nil when closed.
+	hits        *hitMap
+	lastClick   hitTarget
+	lastClickAt time.Time
+	preview     *panePreview
+
+	// scroll is each column's first visible body line, keyed by
+	// columnKey. View moves it to keep the cursor in sight, so it's a map the
+	// value-receiver View can still write through.
+	scroll map[string]int
+
+	density    density // row detail level, cycled with z and persisted
+	detail     bool    // the selected space's detail panel is open (i)
+	showHidden bool    // each display lists its hidden windows (H, hidden.go)
+
+	// scratch is the open scratchpad panel (P, scratchpad.go), nil when
+	// closed
+	scratch *scratchPanel
+
+	// cleanup is the open cleanup checklist (c, cleanup.go), nil when
+	// closed
+	cleanup *cleanupPanel
+
+	// foldedGroups are the label groups folded to their header
+	// (spacegroups.go), persisted with the UI state
+	foldedGroups map[string]bool
+
+	// spaceSort orders each column (s) and pins lead it (p), both
+	// persisted with the UI state (spacesort.go)
+	spaceSort spaceSort
+	pins      []string
+
+	// budget times each refresh and owns the (adaptive) poll interval
+	budget *refreshBudget
+
+	// frame is the last page View drew, reused while nothing it shows
+	// changes (frame.go)
+	frame *frameCache
+
+	// labeler tracks the space labels L and autoLabelSpaces set
+	// (autolabel.go)
+	labeler *spaceLabeler
+
+	// startupChecks are the doctor's checks behind the startup screen
+	// (startup.go); checking is set while a run is in flight
+	startupChecks []doctorCheck
+	checking      bool
+
 	width  int
 	height int
 	err    error
@@ -93,17 +246,41 @@ type model struct {
 }
 
 func newModel() model {
-	return model{}
+	return model{hits: &hitMap{}, scroll: map[string]int{}, budget: newRefreshBudget(), frame: &frameCache{},
+		labeler: &spaceLabeler{ours: map[string]bool{}}}
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(fetchCmd, tickCmd(), metaSampleCmd(), renderTickCmd(), waitForSignalCmd)
+	return tea.Batch(fetchCmd, tickCmd(m.budget.pollInterval()), metaSampleCmd(), renderTickCmd(), waitForSignalCmd, loadDensityCmd, loadUIStateCmd, updateCheckCmd())
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	before := m.status
+	next, cmd := m.update(msg)
+	if nm, ok := next.(model); ok {
+		next = nm.stampStatus(before, msg)
+	}
+	return next, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m.frame.observe(msg)
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		return m.handleKey(msg)
+		before, _ := m.selectedSpaceIndex()
+		next, cmd := m.handleKey(msg)
+		return next.(model).afterCursorMove(before, cmd)
+	case tea.MouseMsg:
+		before, _ := m.selectedSpaceIndex()
+		next, cmd := m.handleMouse(msg)
+		return next.(model).afterCursorMove(before, cmd)
+	case previewMsg:
+		if msg.err != nil {
+			m.status = msg.err.Error()
+			return m, nil
+		}
+		m.preview = &msg.preview
+		return m, nil
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -122,32 +299,327 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// position recomputes against the latest wall-clock.
 		return m, renderTickCmd()
 	case tickMsg:
-		return m, tea.Batch(fetchCmd, tickCmd())
+		return m, tea.Batch(fetchCmd, tickCmd(m.budget.pollInterval()))
 	case spaceChangedMsg:
 		return m, tea.Batch(fetchCmd, waitForSignalCmd)
+	case updateAvailableMsg:
+		if m.status == "" {
+			m.status = fmt.Sprintf("stop %s is available: run stop update", string(msg))
+		}
+		return m, nil
+	case countTimeoutMsg:
+		if msg.seq != m.countSeq || m.count == 0 {
+			return m, nil
+		}
+		return m.flushCount()
+	case cursorSettledMsg:
+		if msg.seq != m.cursorSeq {
+			return m, nil
+		}
+		if msg.focus && m.followCursor {
+			if idx, ok := m.selectedSpaceIndex(); ok {
+				return m, focusSpaceCmd(idx)
+			}
+		}
+		if !msg.focus && m.mirrorCursor {
+			return m, flashWindowsCmd(m.selectedWindowIDs())
+		}
+		return m, nil
+	case flashDoneMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("highlight failed: %v", msg.err)
+		}
+		return m, nil
+	case densityLoadedMsg:
+		m.density = density(msg)
+		return m, nil
+	case comparePointsMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("loading snapshots: %v", msg.err)
+			return m, nil
+		}
+		m.compare = &compareView{points: msg.points}
+		return m, nil
+	case compareStateMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("loading snapshot: %v", msg.err)
+			return m, nil
+		}
+		if m.compare != nil {
+			c := *m.compare
+			c.then = &msg.state
+			m.compare = &c
+		}
+		return m, nil
+	case uiStateLoadedMsg:
+		m.showAgents, m.agentFilter, m.detail = msg.showAgents, msg.agentFilter, msg.detail
+		m.foldedGroups = map[string]bool{}
+		for _, g := range msg.folded {
+			m.foldedGroups[g] = true
+		}
+		m.spaceSort, m.pins = msg.spaceSort, msg.pins
+		if m.ready {
+			m.arrangeSpaces()
+		}
+		// the cursor needs spaces to land on; hold it for the first
+		// refresh if that hasn't arrived yet
+		if !m.ready || !m.restoreCursor(msg.cursorSpaceID) {
+			m.restoreSpaceID = msg.cursorSpaceID
+		}
+		return m, nil
+	case stateSavedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("saving view state: %v", msg.err)
+		}
+		return m, nil
+	case agentViewsMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("loading views: %v", msg.err)
+			return m, nil
+		}
+		m.picker = &viewPicker{views: msg.views}
+		return m, nil
+	case startupChecksMsg:
+		m.startupChecks, m.checking = msg, false
+		return m, nil
+	case actionDoneMsg:
+		m.status = msg.status
+		if msg.err != nil {
+			m.status = fmt.Sprintf("%s: %v", msg.status, msg.err)
+		}
+		return m, fetchCmd
 	}
 	return m, nil
 }
 
 func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	if msg.String() == "q" || msg.String() == "ctrl+c" {
-		return m, tea.Quit
+	if m.builder != nil && m.builder.naming && msg.String() != "ctrl+c" {
+		return m.handleNamingKey(msg)
+	}
+	if msg.String() == "ctrl+c" || (len(m.pendingKeys) == 0 && m.bookmarkPending == "" && activeKeymap.action(msg.String()) == actQuit) {
+		return m.quit()
+	}
+	if m.starting() {
+		return m.handleStartupKey(msg)
+	}
+	if m.showAgents {
+		return m.handleAgentsKey(msg)
+	}
+	if m.compare != nil {
+		return m.handleCompareKey(msg)
+	}
+	if m.scratch != nil {
+		if len(scratchpadWindows(m.windows)) > 0 {
+			return m.handleScratchKey(msg)
+		}
+		m.scratch = nil // its windows went away
+	}
+	if m.cleanup != nil {
+		return m.handleCleanupKey(msg)
 	}
 	if len(m.displayGroups) == 0 {
 		return m, nil
 	}
 
-	switch msg.String() {
-	case "j", "down":
+	// a pending confirmation swallows every key but its answer
+	if m.confirm != nil {
+		switch msg.String() {
+		case "y", "enter":
+			run := m.confirm.run
+			if m.confirm.clearMarks {
+				m.marks = nil
+			}
+			m.confirm, m.swapFrom = nil, 0
+			m.status = "working..."
+			return m, run
+		case "n", "esc":
+			m.confirm, m.swapFrom = nil, 0
+		}
+		return m, nil
+	}
+
+	if m.preview != nil && msg.String() == "esc" {
+		m.preview = nil
+		return m, nil
+	}
+	if m.zoom && msg.String() == "esc" && m.swapFrom == 0 {
+		m.zoom = false
+		return m, nil
+	}
+	if m.detail && msg.String() == "esc" && m.swapFrom == 0 {
+		m.detail = false
+		return m, nil
+	}
+
+	if m.bookmarkPending != "" {
+		return m.handleBookmarkKey(msg.String())
+	}
+	if n, other, ok := parseQuickFocusKey(msg.String()); ok {
+		if other {
+			return m.quickFocus(n, true)
+		}
+		return m.countDigit(n)
+	}
+	if msg.String() == "0" && m.count > 0 {
+		return m.countDigit(0)
+	}
+
+	if msg.String() == "esc" {
+		m.swapFrom, m.pendingKeys, m.marks, m.count = 0, nil, nil, 0
+		return m, nil
+	}
+	var action string
+	action, m.pendingKeys = activeKeymap.resolve(m.pendingKeys, keyName(msg))
+	if m.count > 0 && len(m.pendingKeys) == 0 {
+		return m.withCount(action)
+	}
+	return m.handleAction(action)
+}
+
+// quickFocus jumps to and focuses the nth space of the cursor's column,
+// or of the other one of two.
+func (m model) quickFocus(n int, other bool) (tea.Model, tea.Cmd) {
+	col := m.cursorCol
+	if other {
+		if len(m.displayGroups) != 2 {
+			return m, nil
+		}
+		col = 1 - m.cursorCol
+	}
+	if n > len(m.displayGroups[col].spaces) {
+		return m, nil
+	}
+	m.cursorCol, m.cursorRow = col, n-1
+	idx, _ := m.selectedSpaceIndex()
+	m.skipFolded(false)
+	return m, focusSpaceCmd(idx)
+}
+
+// handleAction runs one dashboard action (keys.go) against the grid.
+func (m model) handleAction(action string) (tea.Model, tea.Cmd) {
+	switch action {
+	case actQuit:
+		return m.quit()
+	case actSwap:
+		idx, ok := m.selectedSpaceIndex()
+		switch {
+		case !ok:
+		case m.swapFrom == 0:
+			m.swapFrom = idx
+			m.status = ""
+		case m.swapFrom == idx:
+			m.swapFrom = 0
+		default:
+			m.confirm = &pendingAction{
+				prompt: fmt.Sprintf("swap space %d \u2194 %d?", m.swapFrom, idx),
+				run:    swapSpacesCmd(m.swapFrom, idx),
+			}
+		}
+	case actDestroy:
+		if row, ok := m.selectedSpaceRow(); ok {
+			m.confirm = &pendingAction{
+				prompt: fmt.Sprintf("destroy space %d?", row.space.Index),
+				run:    destroySpaceCmd(row, m.tmuxPanes),
+			}
+		}
+	case actKill:
+		if rows := markedRows(m.displayGroups, m.marks); len(rows) > 0 {
+			return m.killMarked(rows), nil
+		}
+		if row, ok := m.selectedSpaceRow(); ok {
+			if sessions := sessionsOnSpace(row, m.tmuxPanes); len(sessions) > 0 {
+				m.confirm = &pendingAction{
+					prompt: fmt.Sprintf("kill tmux %s?", strings.Join(sessions, ", ")),
+					run:    killSessionsCmd(sessions, m.tmuxPanes),
+				}
+			}
+		}
+	case actAck:
+		if row, ok := m.selectedSpaceRow(); ok {
+			var alerted []string
+			for _, s := range sessionsOnSpace(row, m.tmuxPanes) {
+				if len(m.alerts[s]) > 0 {
+					alerted = append(alerted, s)
+				}
+			}
+			if len(alerted) == 0 {
+				m.status = "no alerts on this space"
+				break
+			}
+			return m, ackAlertsCmd(alerted)
+		}
+	case actRename:
+		row, ok := m.selectedSpaceRow()
+		if !ok {
+			break
+		}
+		bad := nonconformingSessions(sessionsOnSpace(row, m.tmuxPanes))
+		if len(bad) == 0 {
+			m.status = "sessions on this space follow the naming policy"
+			break
+		}
+		taken := map[string]bool{}
+		for _, p := range m.tmuxPanes {
+			taken[p.SessionName] = true
+		}
+		to := suggestSessionName(bad[0], m.tmuxPanes, m.productivePanePIDs, taken)
+		if to == "" {
+			m.status = fmt.Sprintf("no conforming name to suggest for %s", bad[0])
+			break
+		}
+		m.confirm = &pendingAction{
+			prompt: fmt.Sprintf("rename tmux %s \u2192 %s?", bad[0], to),
+			run:    renameSessionCmd(bad[0], to),
+		}
+	case actAgents:
+		m.showAgents, m.agentCursor = true, 0
+	case actCompare:
+		return m, loadComparePointsCmd
+	case actHidden:
+		m.showHidden = !m.showHidden
+		m.status = ""
+	case actRestore:
+		if row, ok := m.selectedSpaceRow(); ok {
+			ws := hiddenOnSpace(m.displayGroups[m.cursorCol], row.space.Index)
+			if len(ws) == 0 {
+				m.status = "no hidden windows on this space"
+				break
+			}
+			return m, restoreWindowsCmd(ws)
+		}
+	case actDensity:
+		m.density = m.density.next()
+		m.status = m.density.String() + " view"
+		return m, saveDensityCmd(m.density)
+	case actMirror:
+		m.mirrorCursor = !m.mirrorCursor
+		if m.mirrorCursor {
+			m.status = "mirroring cursor to the display"
+			return m, flashWindowsCmd(m.selectedWindowIDs())
+		}
+		m.status = ""
+	case actFollow:
+		m.followCursor = !m.followCursor
+		m.status = ""
+		if m.followCursor {
+			m.status = "focus follows cursor"
+		}
+	case actDown:
 		dg := m.displayGroups[m.cursorCol]
 		if m.cursorRow < len(dg.spaces)-1 {
 			m.cursorRow++
+		} else if below, ok := m.stackedNeighbor(1); ok {
+			// off the bottom of a column: drop into the monitor below
+			m.cursorCol, m.cursorRow = below, 0
 		}
-	case "k", "up":
+	case actUp:
 		if m.cursorRow > 0 {
 			m.cursorRow--
+		} else if above, ok := m.stackedNeighbor(-1); ok {
+			m.cursorCol = above
+			m.cursorRow = maxInt(0, len(m.displayGroups[above].spaces)-1)
 		}
-	case "l", "right":
+	case actRight:
 		if m.cursorCol < len(m.displayGroups)-1 {
 			m.cursorCol++
 			// clamp row to new display's row count
@@ -156,7 +628,7 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.cursorRow = len(dg.spaces) - 1
 			}
 		}
-	case "h", "left":
+	case actLeft:
 		if m.cursorCol > 0 {
 			m.cursorCol--
 			dg := m.displayGroups[m.cursorCol]
@@ -164,27 +636,127 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.cursorRow = len(dg.spaces) - 1
 			}
 		}
-	case "g":
+	case actTop:
 		m.cursorRow = 0
-	case "G":
+	case actBottom:
 		dg := m.displayGroups[m.cursorCol]
 		if len(dg.spaces) > 0 {
 			m.cursorRow = len(dg.spaces) - 1
 		}
-	case "enter":
-		if idx, ok := m.selectedSpaceIndex(); ok {
-			return m, focusSpaceCmd(idx)
+	case actFocus:
+		if rows := markedRows(m.displayGroups, m.marks); len(rows) > 0 {
+			return m.focusNextMarked(rows)
+		}
+		// enter on the space that already has focus drills into it
+		if row, ok := m.selectedSpaceRow(); ok {
+			if row.space.HasFocus {
+				m.detail = true
+				return m, nil
+			}
+			return m, focusSpaceCmd(row.space.Index)
+		}
+	case actDetail:
+		m.detail = !m.detail
+	case actMark:
+		return m.toggleMark(), nil
+	case actSetMark, actJumpMark:
+		m.bookmarkPending = action
+	case actMoveMarked:
+		if rows := markedRows(m.displayGroups, m.marks); len(rows) > 0 {
+			return m.moveMarked(rows), nil
+		}
+		m.status = "nothing marked: " + activeKeymap.label(actMark) + " marks a space"
+	case actFold:
+		return m.toggleFold(), nil
+	case actZoom:
+		return m.toggleZoom(), nil
+	case actScratchpad:
+		return m.openScratchpad(), nil
+	case actCleanup:
+		return m.openCleanup(), nil
+	case actSort:
+		return m.cycleSpaceSort()
+	case actPin:
+		return m.togglePin()
+	case actTimer:
+		if row, ok := m.selectedSpaceRow(); ok {
+			return m, toggleFocusSessionCmd(m.focusSession, row.space)
+		}
+	case actLabel:
+		plan := planSpaceLabels(m.displayGroups, m.tmuxPanes, m.labeler.ours)
+		if len(plan) == 0 {
+			m.status = "space labels match their sessions"
+			break
+		}
+		m.confirm = &pendingAction{
+			prompt: fmt.Sprintf("label spaces %s?", summarizeLabels(plan)),
+			run:    labelSpacesCmd(plan, m.labeler),
+		}
+	case actNew:
+		template := defaultTemplate()
+		if template == "" {
+			m.status = "no workspace templates"
+			break
+		}
+		m.confirm = &pendingAction{
+			prompt: fmt.Sprintf("new %s workspace?", template),
+			run:    newWorkspaceCmd(template),
+		}
+	case actBalance:
+		plan, ok := planBalance(m.displayGroups, balanceMinGap)
+		if !ok {
+			m.status = "displays are balanced"
+			break
+		}
+		m.confirm = &pendingAction{
+			prompt: fmt.Sprintf("move %d spaces to %s?", len(plan.moves), displayName(plan.roomy)),
+			run:    balanceCmd(plan),
 		}
 	}
+	m.skipFolded(action == actDown)
 	return m, nil
 }
 
+// afterCursorMove schedules the debounced cursor side effects when a key
+// moved the cursor to a different space.
+func (m model) afterCursorMove(before int, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	after, ok := m.selectedSpaceIndex()
+	if !ok || after == before || (!m.mirrorCursor && !m.followCursor) {
+		return m, cmd
+	}
+	m.cursorSeq++
+	cmds := []tea.Cmd{cmd}
+	if m.mirrorCursor {
+		cmds = append(cmds, cursorSettleCmd(m.cursorSeq, cursorSettleDelay, false))
+	}
+	if m.followCursor {
+		cmds = append(cmds, cursorSettleCmd(m.cursorSeq, focusFollowDelay, true))
+	}
+	return m, tea.Batch(cmds...)
+}
+
 func (m model) handleData(result fetchResult) (tea.Model, tea.Cmd) {
 	if result.err != nil {
 		m.err = result.err
+		m.frame.stamp(m)
+		if !m.ready && !m.checking {
+			m.checking = true
+			return m, startupChecksCmd
+		}
 		return m, nil
 	}
+	// hold the last frame through a mid-transition empty answer
+	if m.settling < settleTicks && implausibleTransition(m.spaces, m.windows, result) {
+		m.settling++
+		return m, settleRetryCmd()
+	}
+	m.settling = 0
+	m.refreshedAt = time.Now()
+	start := time.Now()
+	defer func() { m.budget.observe(result.fetchTook, time.Since(start)) }()
+	prev := m.refreshInputs()
 	m.spaces = result.spaces
+	m.displays = result.displays
 	m.windows = result.windows
 	m.tmuxPanes = result.tmuxPanes
 	m.tmuxClients = result.tmuxClients
@@ -192,6 +764,45 @@ func (m model) handleData(result fetchResult) (tea.Model, tea.Cmd) {
 	m.productivePanePIDs = result.productivePanePIDs
 	m.nvimBuffers = result.nvimBuffers
 	m.playingMeta = result.playingMeta
+	m.annotations = result.annotations
+	m.priorities = result.priorities
+	m.slo = result.slo
+	m.alerts = result.alerts
+	m.baselines = result.baselines
+	m.paneEnv = result.paneEnv
+	m.paneKube = result.paneKube
+	// throttled refreshes don't read agent screens (power.go): hold the
+	// last states
+	m.budget.setThrottle(result.throttle)
+	if result.throttle != "" {
+		result.paneStates = m.paneStates
+	}
+	prompted := newPrompts(m.paneStates, result.paneStates, result.tmuxPanes, !m.ready)
+	m.paneStates = result.paneStates
+	var promptCmd tea.Cmd
+	if len(prompted) > 0 {
+		m.status = promptStatus(prompted)
+		if promptNotify {
+			promptCmd = notifyPromptsCmd(prompted)
+		}
+	}
+	m.browserTabs = result.browserTabs
+	m.terminalSessions = result.terminalSessions
+	m.bareAgents = result.bareAgents
+	markAway(result.idle, result.idleKnown, time.Now())
+	if status, ok := focusSessionDone(m.focusSession, result.focusSession, time.Now()); ok {
+		m.status = status
+	}
+	m.focusSession = result.focusSession
+	m.spaceTime = result.spaceTime
+	m.agentUsage = result.agentUsage
+	m.spaceHotkeys = result.spaceHotkeys
+	m.titleHistory = m.titleHistory.observe(result.spaces, result.windows, time.Now())
+	m.enrichments = result.enrichments
+	trackCmd := m.trackSpaceTime(result.spaces, result.windows, time.Now())
+	hookCmd := m.trackEvents(result, time.Now())
+	m.wmErr = result.wmErr
+	m.sourceErrs = result.sourceErrs
 
 	// kick off lyrics fetch + title translation when a song is known.
 	// both are cached per artist|title, so re-issuing on every tick is
@@ -203,13 +814,19 @@ func (m model) handleData(result fetchResult) (tea.Model, tea.Cmd) {
 		ensureTitleTranslation(artist, title)
 	}
 
+	if !m.ready {
+		m.status, m.startupChecks = "", nil
+	}
 	m.err = nil
 	m.ready = true
-	m.displayGroups = buildDisplayGroups(m.spaces, m.windows)
+	anchor := m.cursorAnchor()
+	m.applyRefresh(prev)
+	m.restoreAnchor(anchor)
 
-	// map tmux sessions to displays via process tree walk
-	m.tmuxByDisplay, m.detachedTmux = partitionTmuxByDisplay(
-		m.tmuxPanes, m.tmuxClients, m.processTree, m.windows, m.displayGroups)
+	if m.restoreSpaceID != 0 {
+		m.restoreCursor(m.restoreSpaceID)
+		m.restoreSpaceID = 0
+	}
 
 	// clamp cursor after data change (spaces may have been added/removed)
 	if len(m.displayGroups) == 0 {
@@ -223,81 +840,41 @@ func (m model) handleData(result fetchResult) (tea.Model, tea.Cmd) {
 		if m.cursorRow >= len(dg.spaces) && len(dg.spaces) > 0 {
 			m.cursorRow = len(dg.spaces) - 1
 		}
+		m.skipFolded(false)
 	}
-	return m, nil
+	m.frame.stamp(m)
+	return m, tea.Batch(trackCmd, promptCmd, hookCmd, m.autoLabelCmd())
 }
 
 // -- derived data computation --
 
-// buildDisplayGroups organizes spaces by display and attaches their
-// visible (non-hidden, non-minimized) windows. each group gets its
-// own free/terminal counts for the per-display summary.
-func buildDisplayGroups(spaces []Space, windows []Window) []displayGroup {
-	// index windows by space, filtering hidden and minimized
-	windowsBySpace := make(map[int][]Window)
-	for _, w := range windows {
-		if w.Space <= 0 || w.IsHidden || w.IsMinimized {
-			continue
-		}
-		windowsBySpace[w.Space] = append(windowsBySpace[w.Space], w)
-	}
-
-	// group spaces by display
-	displayMap := make(map[int][]spaceRow)
-	for _, s := range spaces {
-		displayMap[s.Display] = append(displayMap[s.Display], spaceRow{
-			space:   s,
-			windows: windowsBySpace[s.Index],
-		})
-	}
-
-	// sort displays, then spaces within each display
-	var displayIndices []int
-	for d := range displayMap {
-		displayIndices = append(displayIndices, d)
-	}
-	sort.Ints(displayIndices)
-
+// buildDisplayGroups lays spaces out by display (engine.GroupDisplays)
+// and attaches their visible windows, keeping the per-display free and
+// terminal counts for the summary line.
+func buildDisplayGroups(spaces []Space, windows []Window, displays []Display) []displayGroup {
 	var groups []displayGroup
-	for _, d := range displayIndices {
-		rows := displayMap[d]
-		sort.Slice(rows, func(i, j int) bool {
-			return rows[i].space.Index < rows[j].space.Index
-		})
-
-		freeCount := 0
-		termCount := 0
-		for _, row := range rows {
-			if len(row.windows) == 0 {
-				freeCount++
-			}
-			for _, w := range row.windows {
-				if isTerminal(w.App) {
-					termCount++
-					break
-				}
-			}
+	for _, g := range engine.GroupDisplays(spaces, windows, displays) {
+		rows := make([]spaceRow, len(g.Spaces))
+		for i, r := range g.Spaces {
+			rows[i] = spaceRow{space: r.Space, windows: r.Windows}
 		}
-
 		groups = append(groups, displayGroup{
-			index:     d,
+			index:     g.Index,
+			display:   g.Display,
+			layoutRow: g.LayoutRow,
 			spaces:    rows,
-			freeCount: freeCount,
-			termCount: termCount,
+			freeCount: g.FreeCount,
+			termCount: g.TermCount,
+			hidden:    g.Hidden,
 		})
 	}
-
 	return groups
 }
 
 // -- tmux-to-display mapping --
 
-// partitionTmuxByDisplay correlates tmux sessions to yabai displays.
-// walks from each tmux client PID up the process tree to find the terminal
-// emulator's PID, which matches a yabai window PID → space → display.
-// when multiple windows share a PID (e.g. kitty is single-process),
-// disambiguates by matching window title to tmux session name.
-// sessions without an attached client (or unmappable) go into detached.
+// partitionTmuxByDisplay buckets tmux panes by the display their session
+// is showing on (engine.PartitionTmux); the rest are detached.
 func partitionTmuxByDisplay(
 	panes []TmuxPane,
 	clients []TmuxClient,
@@ -305,100 +882,78 @@ func partitionTmuxByDisplay(
 	windows []Window,
 	groups []displayGroup,
 ) (byDisplay map[int][]TmuxPane, detached []TmuxPane) {
-	byDisplay = make(map[int][]TmuxPane)
-
-	if len(panes) == 0 {
-		return byDisplay, nil
-	}
+	return engine.PartitionTmux(panes, clients, processTree, windows, groupedSpaces(groups))
+}
 
-	// build space → display lookup
-	spaceToDisplay := make(map[int]int)
+// groupedSpaces flattens display groups back to their spaces.
+func groupedSpaces(groups []displayGroup) []Space {
+	var spaces []Space
 	for _, g := range groups {
 		for _, row := range g.spaces {
-			spaceToDisplay[row.space.Index] = g.index
+			spaces = append(spaces, row.space)
 		}
 	}
+	return spaces
+}
 
-	// group terminal windows by PID with their display info.
-	// kitty is single-process so all its OS windows share one PID.
-	type windowInfo struct {
-		title   string
-		display int
+// -- navigation --
+
+// shiftedDigits maps the US-layout shift+1..9 characters back to digits.
+const shiftedDigits = "!@#$%^&*("
+
+// parseQuickFocusKey resolves 1-9 to the Nth space on the cursor's display
+// and shift+1-9 to the Nth space on the other display (other=true).
+func parseQuickFocusKey(key string) (n int, other bool, ok bool) {
+	if len(key) != 1 {
+		return 0, false, false
 	}
-	windowsByPID := make(map[int][]windowInfo)
-	for _, w := range windows {
-		if !isTerminal(w.App) {
-			continue
-		}
-		if display, ok := spaceToDisplay[w.Space]; ok {
-			windowsByPID[w.PID] = append(windowsByPID[w.PID], windowInfo{
-				title:   w.Title,
-				display: display,
-			})
-		}
+	if key[0] >= '1' && key[0] <= '9' {
+		return int(key[0] - '0'), false, true
 	}
+	if i := strings.IndexByte(shiftedDigits, key[0]); i >= 0 {
+		return i + 1, true, true
+	}
+	return 0, false, false
+}
 
-	// for each tmux client, walk up process tree to find terminal PID,
-	// then resolve to a specific window/display
-	sessionToDisplay := make(map[string]int)
-	for _, client := range clients {
-		termPID := -1
-		pid := client.PID
-		for depth := 0; depth < 20; depth++ {
-			if _, ok := windowsByPID[pid]; ok {
-				termPID = pid
-				break
-			}
-			ppid, ok := processTree[pid]
-			if !ok || ppid <= 1 {
-				break
-			}
-			pid = ppid
-		}
-
-		if termPID < 0 {
+// stackedNeighbor finds the display physically above (dir -1) or below
+// (dir 1) the cursor's display: the one in the adjacent layout row whose
+// frame overlaps it horizontally the most. on a terminal too narrow for
+// the columns side by side (narrow.go), it's the next column down.
+func (m model) stackedNeighbor(dir int) (int, bool) {
+	if _, _, stacked := m.gridGeometry(); stacked {
+		next := m.cursorCol + dir
+		return next, next >= 0 && next < len(m.displayGroups)
+	}
+	cur := m.displayGroups[m.cursorCol]
+	best, bestOverlap := -1, 0.0
+	for i, dg := range m.displayGroups {
+		if dg.layoutRow != cur.layoutRow+dir {
 			continue
 		}
-
-		wins := windowsByPID[termPID]
-		if len(wins) == 1 {
-			// single window for this PID — unambiguous
-			sessionToDisplay[client.SessionName] = wins[0].display
-		} else {
-			// multiple windows share this PID (e.g. kitty)
-			// match window title to session name
-			for _, wi := range wins {
-				if wi.title == client.SessionName {
-					sessionToDisplay[client.SessionName] = wi.display
-					break
-				}
-			}
+		a, b := cur.display.Frame, dg.display.Frame
+		overlap := min(a.X+a.W, b.X+b.W) - max(a.X, b.X)
+		if overlap > bestOverlap {
+			best, bestOverlap = i, overlap
 		}
 	}
-
-	// partition panes into per-display buckets or detached
-	for _, p := range panes {
-		if display, ok := sessionToDisplay[p.SessionName]; ok {
-			byDisplay[display] = append(byDisplay[display], p)
-		} else {
-			detached = append(detached, p)
-		}
-	}
-
-	return byDisplay, detached
+	return best, best >= 0
 }
 
-// -- navigation --
-
 func (m model) selectedSpaceIndex() (int, bool) {
+	row, ok := m.selectedSpaceRow()
+	return row.space.Index, ok
+}
+
+func (m model) selectedSpaceRow() (spaceRow, bool) {
 	if m.cursorCol >= len(m.displayGroups) {
-		return 0, false
+		return spaceRow{}, false
 	}
 	dg := m.displayGroups[m.cursorCol]
 	if m.cursorRow >= len(dg.spaces) {
-		return 0, false
+		return spaceRow{}, false
 	}
-	return dg.spaces[m.cursorRow].space.Index, true
+	return dg.spaces[m.cursorRow], true
 }
 
 // -- commands --
@@ -407,8 +962,8 @@ func fetchCmd() tea.Msg {
 	return dataMsg(fetchAll())
 }
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
+func tickCmd(interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
@@ -441,10 +996,24 @@ func waitForSignalCmd() tea.Msg {
 	return spaceChangedMsg{}
 }
 
+// swapSpacesCmd exchanges two spaces' windows off the update loop; the
+// result comes back as an actionDoneMsg.
+func swapSpacesCmd(a, b int) tea.Cmd {
+	return func() tea.Msg {
+		moved, err := swapSpaces(a, b)
+		if err != nil {
+			return actionDoneMsg{status: fmt.Sprintf("swap %d \u2194 %d failed", a, b), err: err}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("swapped %d \u2194 %d (%d windows)", a, b, moved)}
+	}
+}
+
 func focusSpaceCmd(index int) tea.Cmd {
 	return func() tea.Msg {
-		focusSpace(index)
-		// refresh immediately after switching so the view updates
-		return dataMsg(fetchAll())
+		// the result asks for a refresh, so the view follows at once
+		if err := focusSpace(index); err != nil {
+			return actionDoneMsg{status: fmt.Sprintf("focusing space %d failed", index), err: err}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("focused space %d", index)}
 	}
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildDisplayGroupsOrder(t *testing.T) {
+	displays := map[int]Display{
+		// two externals side by side, laptop centered under the left one
+		1: {Index: 1, Frame: Frame{X: 0, Y: 0, W: 1512, H: 982}},
+		2: {Index: 2, Frame: Frame{X: -200, Y: -1080, W: 1920, H: 1080}},
+		3: {Index: 3, Frame: Frame{X: 1720, Y: -1080, W: 1920, H: 1080}},
+	}
+	groups := buildDisplayGroups(
+		[]Space{{Index: 1, Display: 1}, {Index: 2, Display: 2}, {Index: 3, Display: 3}},
+		nil,
+		[]Display{displays[1], displays[2], displays[3]},
+	)
+	var order []int
+	for _, g := range groups {
+		order = append(order, g.index)
+	}
+	if len(order) != 3 || order[0] != 2 || order[1] != 3 || order[2] != 1 {
+		t.Fatalf("group order = %v, want [2 3 1]", order)
+	}
+	if groups[2].layoutRow != 1 {
+		t.Errorf("laptop in row %d, want 1", groups[2].layoutRow)
+	}
+}
+
+func TestParseQuickFocusKey(t *testing.T) {
+	cases := []struct {
+		key   string
+		n     int
+		other bool
+		ok    bool
+	}{
+		{"1", 1, false, true},
+		{"9", 9, false, true},
+		{"0", 0, false, false},
+		{"!", 1, true, true},
+		{"(", 9, true, true},
+		{"ctrl+c", 0, false, false},
+	}
+	for _, c := range cases {
+		n, other, ok := parseQuickFocusKey(c.key)
+		if n != c.n || other != c.other || ok != c.ok {
+			t.Errorf("parseQuickFocusKey(%q) = %d, %v, %v", c.key, n, other, ok)
+		}
+	}
+}
+
+func TestTmuxOnlyFallback(t *testing.T) {
+	panes := []TmuxPane{{SessionName: "api", WindowName: "zsh", PanePID: 10, CurrentCommand: "zsh", LastActivity: time.Now()}}
+	result := fetchResult{tmuxPanes: panes, wmErr: errors.New("yabai: executable file not found")}
+
+	m, _ := newModel().handleData(result)
+	view := m.View()
+	if !strings.Contains(view, "tmux only") || !strings.Contains(view, "api") {
+		t.Errorf("tmux-only view missing its banner or sessions:\n%s", view)
+	}
+
+	resp := buildSpacesResponse(result)
+	if resp["yabai_available"] != false || resp["window_manager_error"] == nil {
+		t.Errorf("serve response = %v", resp)
+	}
+	if resp := buildSpacesResponse(fetchResult{}); resp["yabai_available"] != true {
+		t.Errorf("yabai_available = %v with no window manager error", resp["yabai_available"])
+	}
+}
+
+func TestRenderWindowFlags(t *testing.T) {
+	windows := []Window{
+		{App: "kitty", Title: "api", IsFloating: true},
+		{App: "Safari", Title: "docs", IsNativeFullscreen: true, HasFullscreenZoom: true},
+		{App: "Notes", IsSticky: true},
+		{App: "Notes"},
+		{App: "Music"},
+	}
+	got := renderWindows(windows, 20, renderContext{})
+	for _, want := range []string{"kitty: api ~", "Safari: docs F", "Notes (2) +"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderWindows = %q, missing %q", got, want)
+		}
+	}
+	if f := renderWindowFlags(Window{App: "Music"}); f != "" {
+		t.Errorf("plain window got flags %q", f)
+	}
+}
@@ -0,0 +1,155 @@
+// uistate: the dashboard comes back the way it was left.
+//
+// on quit stop writes where the cursor was (by space id, which survives
+// spaces being reordered or added), whether the agents view was up and
+// with which filter, whether the detail panel was open, which label
+// groups were folded, and the space order and pins (spacesort.go) into
+// the ui_state table next to density. at startup they're read back; the
+// cursor is placed once the first refresh has the spaces to find it in.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ui_state keys, next to uiStateDensity
+const (
+	uiStateCursor      = "cursor_space"
+	uiStateAgentsView  = "agents_view"
+	uiStateAgentFilter = "agent_filter"
+	uiStateDetail      = "detail"
+	uiStateFolded      = "folded_groups"
+)
+
+// savedUIState is what persists across restarts.
+type savedUIState struct {
+	cursorSpaceID int
+	showAgents    bool
+	agentFilter   agentFilter
+	detail        bool
+	folded        []string // folded label groups, sorted
+	spaceSort     spaceSort
+	pins          []string // in pin order
+}
+
+// uiStateLoadedMsg carries the persisted state at startup.
+type uiStateLoadedMsg savedUIState
+
+// uiSnapshot captures the state worth persisting.
+func (m model) uiSnapshot() savedUIState {
+	s := savedUIState{showAgents: m.showAgents, agentFilter: m.agentFilter, detail: m.detail, spaceSort: m.spaceSort, pins: m.pins}
+	for g := range m.foldedGroups {
+		s.folded = append(s.folded, g)
+	}
+	sort.Strings(s.folded)
+	if row, ok := m.selectedSpaceRow(); ok {
+		s.cursorSpaceID = row.space.ID
+	}
+	return s
+}
+
+// writeUIState stores a snapshot.
+func writeUIState(db *sql.DB, s savedUIState) error {
+	filter, err := json.Marshal(s.agentFilter)
+	if err != nil {
+		return fmt.Errorf("encoding agent filter: %w", err)
+	}
+	folded, err := json.Marshal(s.folded)
+	if err != nil {
+		return fmt.Errorf("encoding folded groups: %w", err)
+	}
+	pins, err := json.Marshal(s.pins)
+	if err != nil {
+		return fmt.Errorf("encoding pins: %w", err)
+	}
+	values := map[string]string{
+		uiStateAgentsView:  strconv.FormatBool(s.showAgents),
+		uiStateAgentFilter: string(filter),
+		uiStateDetail:      strconv.FormatBool(s.detail),
+		uiStateFolded:      string(folded),
+		uiStateSpaceSort:   s.spaceSort.String(),
+		uiStatePins:        string(pins),
+	}
+	// keep the last known cursor when quitting with nothing selected
+	if s.cursorSpaceID != 0 {
+		values[uiStateCursor] = strconv.Itoa(s.cursorSpaceID)
+	}
+	for key, value := range values {
+		if err := setUIState(db, key, value); err != nil {
+			return fmt.Errorf("saving %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// readUIState loads a snapshot; unset or unreadable keys keep their
+// zero values.
+func readUIState(db *sql.DB) savedUIState {
+	var s savedUIState
+	if v, _ := getUIState(db, uiStateCursor); v != "" {
+		s.cursorSpaceID, _ = strconv.Atoi(v)
+	}
+	if v, _ := getUIState(db, uiStateAgentsView); v != "" {
+		s.showAgents, _ = strconv.ParseBool(v)
+	}
+	if v, _ := getUIState(db, uiStateAgentFilter); v != "" {
+		_ = json.Unmarshal([]byte(v), &s.agentFilter)
+	}
+	if v, _ := getUIState(db, uiStateDetail); v != "" {
+		s.detail, _ = strconv.ParseBool(v)
+	}
+	if v, _ := getUIState(db, uiStateFolded); v != "" {
+		_ = json.Unmarshal([]byte(v), &s.folded)
+	}
+	if v, _ := getUIState(db, uiStateSpaceSort); v != "" {
+		s.spaceSort = parseSpaceSort(v)
+	}
+	if v, _ := getUIState(db, uiStatePins); v != "" {
+		_ = json.Unmarshal([]byte(v), &s.pins)
+	}
+	return s
+}
+
+func loadUIStateCmd() tea.Msg {
+	db := sharedStateDB()
+	if db == nil {
+		return uiStateLoadedMsg{}
+	}
+	return uiStateLoadedMsg(readUIState(db))
+}
+
+func saveUIStateCmd(s savedUIState) tea.Cmd {
+	return func() tea.Msg {
+		db := sharedStateDB()
+		if db == nil {
+			return stateSavedMsg{err: fmt.Errorf("state db unavailable")}
+		}
+		return stateSavedMsg{err: writeUIState(db, s)}
+	}
+}
+
+// quit persists the UI state, then exits.
+func (m model) quit() (tea.Model, tea.Cmd) {
+	return m, tea.Sequence(saveUIStateCmd(m.uiSnapshot()), tea.Quit)
+}
+
+// restoreCursor moves the cursor onto the space with the given id; false
+// when it's gone.
+func (m *model) restoreCursor(spaceID int) bool {
+	for c, dg := range m.displayGroups {
+		for r, row := range dg.spaces {
+			if row.space.ID == spaceID {
+				m.cursorCol, m.cursorRow = c, r
+				return true
+			}
+		}
+	}
+	return false
+}
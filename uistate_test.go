@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestUIStateRoundTrip(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(uiStateSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readUIState(db); got.cursorSpaceID != 0 || got.showAgents || len(got.agentFilter.Preds) != 0 {
+		t.Errorf("empty state = %+v", got)
+	}
+
+	want := savedUIState{
+		cursorSpaceID: 42,
+		showAgents:    true,
+		agentFilter:   agentFilter{Op: "or", Preds: []agentPredicate{{Field: "tier", Value: "stale"}}},
+		detail:        true,
+		folded:        []string{"personal", "work"},
+		spaceSort:     sortFreeLast,
+		pins:          []string{"session:api", "space:notes"},
+	}
+	if err := writeUIState(db, want); err != nil {
+		t.Fatal(err)
+	}
+	// quitting with nothing selected keeps the last cursor
+	next := want
+	next.cursorSpaceID = 0
+	if err := writeUIState(db, next); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readUIState(db)
+	if got.cursorSpaceID != 42 || !got.showAgents || !got.detail || len(got.folded) != 2 || got.folded[1] != "work" {
+		t.Errorf("state = %+v", got)
+	}
+	if got.spaceSort != sortFreeLast || len(got.pins) != 2 || got.pins[0] != "session:api" {
+		t.Errorf("order = %s, pins %v", got.spaceSort, got.pins)
+	}
+	if got.agentFilter.Op != "or" || len(got.agentFilter.Preds) != 1 || got.agentFilter.Preds[0].Value != "stale" {
+		t.Errorf("filter = %+v", got.agentFilter)
+	}
+}
+
+func TestRestoreCursorBySpaceID(t *testing.T) {
+	m := newModel()
+	m.displayGroups = []displayGroup{
+		{spaces: []spaceRow{{space: Space{ID: 10, Index: 1}}}},
+		{spaces: []spaceRow{{space: Space{ID: 20, Index: 2}}, {space: Space{ID: 30, Index: 3}}}},
+	}
+	if !m.restoreCursor(30) || m.cursorCol != 1 || m.cursorRow != 1 {
+		t.Errorf("cursor at %d,%d, want 1,1", m.cursorCol, m.cursorRow)
+	}
+	if m.restoreCursor(99) {
+		t.Error("restored onto a space that no longer exists")
+	}
+
+	// state that arrives before the first refresh waits for it
+	fresh := newModel()
+	next, _ := fresh.Update(uiStateLoadedMsg{cursorSpaceID: 30, detail: true})
+	fresh = next.(model)
+	if fresh.restoreSpaceID != 30 || !fresh.detail {
+		t.Errorf("pending restore = %d, detail %v", fresh.restoreSpaceID, fresh.detail)
+	}
+}
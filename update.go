@@ -0,0 +1,327 @@
+// update: replace this binary with the latest GitHub release.
+//
+// `stop update` asks GitHub for the latest release of fadedlamp42/stop,
+// and when it's newer than this build downloads the asset for this
+// platform (stop_<os>_<arch>), checks it against the release's
+// checksums.txt (sha256, one "<hash>  <file>" line per asset), then swaps
+// it in for the running binary. the swap is a rename in the binary's own
+// directory, so a failed download never leaves half a binary behind.
+//
+// the checksum comes from the same release as the binary, so it catches
+// a corrupt or truncated download and nothing more: releases aren't
+// signed, and whoever can publish a release can publish its checksums.
+// stop update trusts GitHub and the repository's release access, and
+// says so.
+//
+// the dashboard also checks at startup, at most once per
+// updateCheckInterval (the last answer is kept in ui_state), and says so
+// in the status line when there's something newer. updateCheck turns it
+// off. dev builds — anything not stamped with a release tag — never
+// nag, and `stop update` only replaces one with -force.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// version is this build's release tag, stamped at build time with
+// -ldflags "-X main.version=v1.4.0"; "dev" otherwise.
+var version = "dev"
+
+const (
+	releasesURL         = "https://api.github.com/repos/fadedlamp42/stop/releases/latest"
+	updateCheckInterval = 24 * time.Hour
+	maxReleaseAsset     = 256 << 20
+
+	uiStateUpdateCheck = "update_check" // "<RFC3339 time> <latest tag>"
+)
+
+// release is the part of GitHub's release JSON an update needs.
+type release struct {
+	Tag    string `json:"tag_name"`
+	Assets []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// asset is the download URL of the release's asset called name.
+func (r release) asset(name string) (string, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL, true
+		}
+	}
+	return "", false
+}
+
+// releaseAssetName is the binary built for this platform.
+func releaseAssetName() string {
+	return fmt.Sprintf("stop_%s_%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// parseVersion reads "v1.2.3" (the v and patch optional).
+func parseVersion(s string) ([3]int, bool) {
+	var v [3]int
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// newerVersion reports whether latest is a later release than current.
+// a version that doesn't parse (a dev build) is never behind.
+func newerVersion(current, latest string) bool {
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := range c {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// httpGet fetches url, refusing bodies over maxReleaseAsset.
+func httpGet(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "stop/"+version)
+	resp, err := (&http.Client{Timeout: 2 * time.Minute}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxReleaseAsset+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxReleaseAsset {
+		return nil, fmt.Errorf("%s: larger than %d bytes", url, maxReleaseAsset)
+	}
+	return body, nil
+}
+
+// latestRelease asks GitHub for the newest release.
+func latestRelease(ctx context.Context) (release, error) {
+	var r release
+	body, err := httpGet(ctx, releasesURL)
+	if err != nil {
+		return r, fmt.Errorf("checking releases: %w", err)
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return r, fmt.Errorf("reading release: %w", err)
+	}
+	if r.Tag == "" {
+		return r, fmt.Errorf("reading release: no tag")
+	}
+	return r, nil
+}
+
+// verifyChecksum checks binary against name's line in checksums.
+func verifyChecksum(binary, checksums []byte, name string) error {
+	sum := sha256.Sum256(binary)
+	for _, line := range strings.Split(string(checksums), "\n") {
+		f := strings.Fields(line)
+		if len(f) != 2 || strings.TrimPrefix(f[1], "*") != name {
+			continue
+		}
+		want, err := hex.DecodeString(f[0])
+		if err != nil {
+			return fmt.Errorf("checksum for %s: %w", name, err)
+		}
+		if !bytes.Equal(want, sum[:]) {
+			return fmt.Errorf("checksum mismatch for %s", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum for %s", name)
+}
+
+// replaceExecutable swaps binary in for the running executable, keeping
+// its mode, and returns the path it replaced.
+func replaceExecutable(binary []byte) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("finding the stop binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", exe, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".stop-update-*")
+	if err != nil {
+		return "", fmt.Errorf("writing next to %s: %w", exe, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("writing update: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("writing update: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("writing update: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return "", fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	return exe, nil
+}
+
+// updateCommand is `stop update`: report the latest release with
+// checkOnly, otherwise install it. force installs over a dev build or
+// the same version.
+func updateCommand(checkOnly, force bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	r, err := latestRelease(ctx)
+	if err != nil {
+		return err
+	}
+	newer := newerVersion(version, r.Tag)
+	if db := sharedStateDB(); db != nil {
+		setUIState(db, uiStateUpdateCheck, formatUpdateCheck(time.Now(), r.Tag))
+	}
+	if checkOnly {
+		if newer {
+			fmt.Printf("stop %s is available (this is %s). run stop update to install it.\n", r.Tag, version)
+		} else {
+			fmt.Printf("stop %s is the latest release (this is %s).\n", r.Tag, version)
+		}
+		return nil
+	}
+	if !newer && !force {
+		if _, ok := parseVersion(version); !ok {
+			return fmt.Errorf("this is a %s build; stop update -force installs %s over it", version, r.Tag)
+		}
+		fmt.Printf("already up to date (%s).\n", version)
+		return nil
+	}
+
+	name := releaseAssetName()
+	binURL, ok := r.asset(name)
+	if !ok {
+		return fmt.Errorf("release %s has no %s", r.Tag, name)
+	}
+	sumsURL, ok := r.asset("checksums.txt")
+	if !ok {
+		return fmt.Errorf("release %s has no checksums.txt", r.Tag)
+	}
+	checksums, err := httpGet(ctx, sumsURL)
+	if err != nil {
+		return fmt.Errorf("downloading checksums: %w", err)
+	}
+	fmt.Printf("downloading %s %s...\n", name, r.Tag)
+	binary, err := httpGet(ctx, binURL)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", name, err)
+	}
+	if err := verifyChecksum(binary, checksums, name); err != nil {
+		return err
+	}
+	exe, err := replaceExecutable(binary)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("updated %s: %s → %s (sha256 checksum verified; releases aren't signed)\n", exe, version, r.Tag)
+	return nil
+}
+
+// formatUpdateCheck and parseUpdateCheck are the ui_state value of the
+// last check.
+func formatUpdateCheck(at time.Time, tag string) string {
+	return at.UTC().Format(time.RFC3339) + " " + tag
+}
+
+func parseUpdateCheck(value string) (time.Time, string, bool) {
+	at, tag, ok := strings.Cut(value, " ")
+	if !ok {
+		return time.Time{}, "", false
+	}
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return t, tag, true
+}
+
+// updateAvailableMsg carries a release newer than this build.
+type updateAvailableMsg string
+
+// updateCheckCmd looks for a newer release at startup, reusing the last
+// answer while it's younger than updateCheckInterval. nil when checks
+// are off or this is a dev build.
+func updateCheckCmd() tea.Cmd {
+	if !updateCheck {
+		return nil
+	}
+	if _, ok := parseVersion(version); !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		db := sharedStateDB()
+		tag := ""
+		if db != nil {
+			value, _ := getUIState(db, uiStateUpdateCheck)
+			if at, last, ok := parseUpdateCheck(value); ok && time.Since(at) < updateCheckInterval {
+				tag = last
+			}
+		}
+		if tag == "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			r, err := latestRelease(ctx)
+			if err != nil {
+				return nil
+			}
+			tag = r.Tag
+			if db != nil {
+				setUIState(db, uiStateUpdateCheck, formatUpdateCheck(time.Now(), tag))
+			}
+		}
+		if !newerVersion(version, tag) {
+			return nil
+		}
+		return updateAvailableMsg(tag)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestNewerVersion(t *testing.T) {
+	cases := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"v1.2.3", "v1.2.4", true},
+		{"v1.2.3", "v1.10.0", true},
+		{"v1.2", "v1.2.0", false},
+		{"v2.0.0", "v1.9.9", false},
+		{"dev", "v9.0.0", false},
+		{"v1.0.0", "nightly", false},
+	}
+	for _, c := range cases {
+		if got := newerVersion(c.current, c.latest); got != c.want {
+			t.Errorf("newerVersion(%q, %q) = %v", c.current, c.latest, got)
+		}
+	}
+}
+
+func TestVerifyRelease(t *testing.T) {
+	binary := []byte("#!/bin/stop")
+	sum := sha256.Sum256(binary)
+	name := releaseAssetName()
+	checksums := []byte("0000  stop_plan9_mips\n" + hex.EncodeToString(sum[:]) + "  " + name + "\n")
+	if err := verifyChecksum(binary, checksums, name); err != nil {
+		t.Errorf("good binary: %v", err)
+	}
+	if err := verifyChecksum([]byte("tampered"), checksums, name); err == nil {
+		t.Error("tampered binary verified")
+	}
+	if err := verifyChecksum(binary, []byte("abc  other\n"), name); err == nil {
+		t.Error("missing checksum verified")
+	}
+}
+
+func TestUpdateCheckRoundTrip(t *testing.T) {
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	got, tag, ok := parseUpdateCheck(formatUpdateCheck(at, "v1.4.0"))
+	if !ok || !got.Equal(at) || tag != "v1.4.0" {
+		t.Errorf("round trip = %v %q %v", got, tag, ok)
+	}
+	if _, _, ok := parseUpdateCheck("garbage"); ok {
+		t.Error("garbage parsed")
+	}
+
+	r := release{Tag: "v1.4.0"}
+	r.Assets = append(r.Assets, struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	}{"checksums.txt", "https://example.com/checksums.txt"})
+	if u, ok := r.asset("checksums.txt"); !ok || u != "https://example.com/checksums.txt" {
+		t.Errorf("asset = %q, %v", u, ok)
+	}
+	if _, ok := r.asset(releaseAssetName()); ok {
+		t.Error("found a missing asset")
+	}
+}
+
+func TestUpdateCheckOffForDevBuilds(t *testing.T) {
+	defer func(v string, on bool) { version, updateCheck = v, on }(version, updateCheck)
+	version, updateCheck = "dev", true
+	if updateCheckCmd() != nil {
+		t.Error("dev build checks for updates")
+	}
+	version, updateCheck = "v1.0.0", false
+	if updateCheckCmd() != nil {
+		t.Error("check runs with updateCheck off")
+	}
+}
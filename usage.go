@@ -0,0 +1,384 @@
+// usage: what the agents spent today, read from their own logs.
+//
+// with agentUsageEnabled on (config.go) each fetch tallies the tokens the
+// agents in each tmux session used today, and what they cost at
+// modelPrices, so the detail panel can show which sessions are worth
+// keeping. claude writes every reply's usage to
+// ~/.claude/projects/<its working directory, dashed>/<conversation>.jsonl;
+// codex writes running totals to ~/.codex/sessions/YYYY/MM/DD/*.jsonl
+// under a session_meta line naming its directory. a session's agents are
+// found by the working directories of its productive panes, so two
+// sessions working in one directory both show its agents' usage.
+//
+// logs only grow, so each file is read from where the last fetch left
+// off. `stop serve` keeps each day's totals per session in the snapshot
+// db (agent_usage), and `stop report` lists them under the day's focus.
+
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const agentUsageSchema = `
+CREATE TABLE IF NOT EXISTS agent_usage (
+	day TEXT NOT NULL,
+	session_name TEXT NOT NULL,
+	input INTEGER NOT NULL,
+	output INTEGER NOT NULL,
+	cache_read INTEGER NOT NULL,
+	cache_write INTEGER NOT NULL,
+	cost REAL NOT NULL,
+	PRIMARY KEY (day, session_name)
+);
+`
+
+// the agents' log directories; vars so tests can point them elsewhere
+var (
+	claudeProjectsDir = filepath.Join(homeDir(), ".claude", "projects")
+	codexSessionsDir  = filepath.Join(homeDir(), ".codex", "sessions")
+)
+
+func homeDir() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// agentUsage is tokens and what they cost.
+type agentUsage struct {
+	Input      int64
+	Output     int64
+	CacheRead  int64
+	CacheWrite int64
+	Cost       float64
+}
+
+func (u *agentUsage) add(o agentUsage) {
+	u.Input += o.Input
+	u.Output += o.Output
+	u.CacheRead += o.CacheRead
+	u.CacheWrite += o.CacheWrite
+	u.Cost += o.Cost
+}
+
+func (u agentUsage) tokens() int64 {
+	return u.Input + u.Output + u.CacheRead + u.CacheWrite
+}
+
+// String is the usage as the detail panel shows it.
+func (u agentUsage) String() string {
+	return fmt.Sprintf("%s tokens · $%.2f", formatTokens(u.tokens()), u.Cost)
+}
+
+// formatTokens abbreviates a count: 950, 12.3k, 4.1M.
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// modelPrice is dollars per million tokens of each kind.
+type modelPrice struct {
+	input, output, cacheRead, cacheWrite float64
+}
+
+// priced fills in the cost of usage by a model: the longest key of
+// modelPrices the model's name contains. unknown models cost nothing.
+func priced(u agentUsage, model string) agentUsage {
+	best := ""
+	for key := range modelPrices {
+		if strings.Contains(model, key) && len(key) > len(best) {
+			best = key
+		}
+	}
+	if best != "" {
+		p := modelPrices[best]
+		u.Cost = (float64(u.Input)*p.input + float64(u.Output)*p.output +
+			float64(u.CacheRead)*p.cacheRead + float64(u.CacheWrite)*p.cacheWrite) / 1e6
+	}
+	return u
+}
+
+// claudeProjectDir is where claude logs a working directory's
+// conversations: the path with everything but letters and digits dashed.
+var claudeDirChars = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+func claudeProjectDir(cwd string) string {
+	return filepath.Join(claudeProjectsDir, claudeDirChars.ReplaceAllString(cwd, "-"))
+}
+
+// usageLog is how far one log has been read and what it added up to.
+type usageLog struct {
+	offset int64
+	days   map[string]agentUsage // local day → usage
+	seen   map[string]bool       // claude: replies already counted
+	cwd    string                // codex: the session's directory
+	model  string                // codex: the model of the latest turn
+	total  agentUsage            // codex: the running total last read
+}
+
+var (
+	usageMu   sync.Mutex
+	usageLogs = map[string]*usageLog{} // path → progress
+)
+
+// readUsageLog reads what's new in a log. parse handles one line; a
+// partial last line waits for the next read.
+func readUsageLog(path string, parse func(l *usageLog, line []byte)) (*usageLog, error) {
+	l := usageLogs[path]
+	if l == nil {
+		l = &usageLog{days: map[string]agentUsage{}, seen: map[string]bool{}}
+		usageLogs[path] = l
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return l, err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() < l.offset {
+		*l = usageLog{days: map[string]agentUsage{}, seen: map[string]bool{}} // rewritten
+	}
+	if _, err := f.Seek(l.offset, io.SeekStart); err != nil {
+		return l, err
+	}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			break // EOF, or a line still being written
+		}
+		l.offset += int64(len(line))
+		parse(l, line)
+	}
+	return l, nil
+}
+
+// dayOf is the local day of a log timestamp.
+func dayOf(stamp string) string {
+	t, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		return ""
+	}
+	return t.Local().Format("2006-01-02")
+}
+
+// parseClaudeLine counts one assistant reply. a reply is logged once per
+// content block, each line repeating its usage, so replies are counted
+// by id once.
+func parseClaudeLine(l *usageLog, line []byte) {
+	var entry struct {
+		Type      string `json:"type"`
+		Timestamp string `json:"timestamp"`
+		RequestID string `json:"requestId"`
+		Message   struct {
+			ID    string `json:"id"`
+			Model string `json:"model"`
+			Usage struct {
+				Input      int64 `json:"input_tokens"`
+				Output     int64 `json:"output_tokens"`
+				CacheRead  int64 `json:"cache_read_input_tokens"`
+				CacheWrite int64 `json:"cache_creation_input_tokens"`
+			} `json:"usage"`
+		} `json:"message"`
+	}
+	if json.Unmarshal(line, &entry) != nil || entry.Type != "assistant" {
+		return
+	}
+	key := entry.Message.ID + ":" + entry.RequestID
+	if l.seen[key] {
+		return
+	}
+	l.seen[key] = true
+	u := entry.Message.Usage
+	day := l.days[dayOf(entry.Timestamp)]
+	day.add(priced(agentUsage{Input: u.Input, Output: u.Output, CacheRead: u.CacheRead, CacheWrite: u.CacheWrite}, entry.Message.Model))
+	l.days[dayOf(entry.Timestamp)] = day
+}
+
+// parseCodexLine follows a codex session: its directory, its model, and
+// its running token total, whose growth is credited to the day it grew.
+func parseCodexLine(l *usageLog, line []byte) {
+	var entry struct {
+		Timestamp string `json:"timestamp"`
+		Type      string `json:"type"`
+		Payload   struct {
+			Type  string `json:"type"`
+			Cwd   string `json:"cwd"`
+			Model string `json:"model"`
+			Info  *struct {
+				Total struct {
+					Input  int64 `json:"input_tokens"`
+					Cached int64 `json:"cached_input_tokens"`
+					Output int64 `json:"output_tokens"`
+				} `json:"total_token_usage"`
+			} `json:"info"`
+		} `json:"payload"`
+	}
+	if json.Unmarshal(line, &entry) != nil {
+		return
+	}
+	switch {
+	case entry.Type == "session_meta":
+		l.cwd = entry.Payload.Cwd
+	case entry.Type == "turn_context":
+		l.model = entry.Payload.Model
+	case entry.Payload.Type == "token_count" && entry.Payload.Info != nil:
+		t := entry.Payload.Info.Total
+		// codex counts cached input inside input
+		total := agentUsage{Input: t.Input - t.Cached, Output: t.Output, CacheRead: t.Cached}
+		grew := agentUsage{Input: total.Input - l.total.Input, Output: total.Output - l.total.Output, CacheRead: total.CacheRead - l.total.CacheRead}
+		l.total = total
+		day := l.days[dayOf(entry.Timestamp)]
+		day.add(priced(grew, l.model))
+		l.days[dayOf(entry.Timestamp)] = day
+	}
+}
+
+// usageByDir is every agent's usage on day, by working directory.
+func usageByDir(dirs map[string]bool, day time.Time) map[string]agentUsage {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	key := day.Format("2006-01-02")
+	byDir := map[string]agentUsage{}
+
+	for dir := range dirs {
+		logs, _ := filepath.Glob(filepath.Join(claudeProjectDir(dir), "*.jsonl"))
+		for _, path := range logs {
+			if info, err := os.Stat(path); err != nil || info.ModTime().Before(startOfDay(day)) {
+				continue // nothing written today
+			}
+			if l, err := readUsageLog(path, parseClaudeLine); err == nil {
+				u := byDir[dir]
+				u.add(l.days[key])
+				byDir[dir] = u
+			}
+		}
+	}
+
+	// a codex session started yesterday may still be running
+	for _, d := range []time.Time{day.AddDate(0, 0, -1), day} {
+		logs, _ := filepath.Glob(filepath.Join(codexSessionsDir, d.Format("2006/01/02"), "*.jsonl"))
+		for _, path := range logs {
+			if l, err := readUsageLog(path, parseCodexLine); err == nil && dirs[l.cwd] {
+				u := byDir[l.cwd]
+				u.add(l.days[key])
+				byDir[l.cwd] = u
+			}
+		}
+	}
+	return byDir
+}
+
+// startOfDay is local midnight of t's day.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// queryAgentUsage is the fetch-side read: tmux session → today's usage of
+// the agents working in its panes' directories.
+func queryAgentUsage(panes []TmuxPane, productive map[int]bool, now time.Time) map[string]agentUsage {
+	if !agentUsageEnabled {
+		return nil
+	}
+	sessionDirs := map[string]map[string]bool{}
+	dirs := map[string]bool{}
+	for _, p := range panes {
+		if !productive[p.PanePID] || p.CurrentPath == "" {
+			continue
+		}
+		if sessionDirs[p.SessionName] == nil {
+			sessionDirs[p.SessionName] = map[string]bool{}
+		}
+		sessionDirs[p.SessionName][p.CurrentPath] = true
+		dirs[p.CurrentPath] = true
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+	byDir := usageByDir(dirs, now)
+	usage := map[string]agentUsage{}
+	for session, ds := range sessionDirs {
+		var u agentUsage
+		for d := range ds {
+			u.add(byDir[d])
+		}
+		if u.tokens() > 0 {
+			usage[session] = u
+		}
+	}
+	return usage
+}
+
+// recordAgentUsage stores each session's total for the day, replacing
+// the last: the logs are re-tallied from the start of the day each time.
+func recordAgentUsage(db *sql.DB, day time.Time, usage map[string]agentUsage) error {
+	key := day.Format("2006-01-02")
+	for session, u := range usage {
+		_, err := db.Exec(`INSERT INTO agent_usage (day, session_name, input, output, cache_read, cache_write, cost)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (day, session_name) DO UPDATE SET input = excluded.input, output = excluded.output,
+				cache_read = excluded.cache_read, cache_write = excluded.cache_write, cost = excluded.cost`,
+			key, session, u.Input, u.Output, u.CacheRead, u.CacheWrite, u.Cost)
+		if err != nil {
+			return fmt.Errorf("recording usage of %s: %w", session, err)
+		}
+	}
+	return nil
+}
+
+// sessionUsage is one session's stored total for a day.
+type sessionUsage struct {
+	session string
+	usage   agentUsage
+}
+
+// loadAgentUsage reads a day's totals, costliest first.
+func loadAgentUsage(db *sql.DB, day time.Time) ([]sessionUsage, error) {
+	rows, err := db.Query(`SELECT session_name, input, output, cache_read, cache_write, cost
+		FROM agent_usage WHERE day = ?`, day.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("querying agent usage: %w", err)
+	}
+	defer rows.Close()
+	var out []sessionUsage
+	for rows.Next() {
+		var s sessionUsage
+		if err := rows.Scan(&s.session, &s.usage.Input, &s.usage.Output, &s.usage.CacheRead, &s.usage.CacheWrite, &s.usage.Cost); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].usage.Cost > out[j].usage.Cost })
+	return out, rows.Err()
+}
+
+// writeUsageReport prints a day's usage by session.
+func writeUsageReport(w io.Writer, day time.Time, sessions []sessionUsage) {
+	if len(sessions) == 0 {
+		return
+	}
+	var total agentUsage
+	for _, s := range sessions {
+		total.add(s.usage)
+	}
+	fmt.Fprintf(w, "\nagent usage, %s: %s\n", day.Format("Mon Jan 2"), total)
+	for _, s := range sessions {
+		fmt.Fprintf(w, "  %-24s %10s tokens  $%7.2f\n", s.session, formatTokens(s.usage.tokens()), s.usage.Cost)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useUsageDirs points the log readers at fresh temp dirs.
+func useUsageDirs(t *testing.T) (claude, codex string) {
+	t.Helper()
+	oldClaude, oldCodex, oldEnabled := claudeProjectsDir, codexSessionsDir, agentUsageEnabled
+	claudeProjectsDir, codexSessionsDir, agentUsageEnabled = t.TempDir(), t.TempDir(), true
+	usageLogs = map[string]*usageLog{}
+	t.Cleanup(func() {
+		claudeProjectsDir, codexSessionsDir, agentUsageEnabled = oldClaude, oldCodex, oldEnabled
+		usageLogs = map[string]*usageLog{}
+	})
+	return claudeProjectsDir, codexSessionsDir
+}
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, l := range lines {
+		fmt.Fprintln(f, l)
+	}
+}
+
+func claudeReply(id string, at time.Time, input, output int) string {
+	return fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-%s","message":{"id":%q,"model":"claude-sonnet-4-5","usage":{"input_tokens":%d,"output_tokens":%d,"cache_read_input_tokens":0,"cache_creation_input_tokens":0}}}`,
+		at.UTC().Format(time.RFC3339Nano), id, id, input, output)
+}
+
+func TestAgentUsageClaude(t *testing.T) {
+	useUsageDirs(t)
+	now := time.Now()
+	log := filepath.Join(claudeProjectDir("/work/api.v2"), "conv.jsonl")
+	if !strings.HasSuffix(filepath.Dir(log), "-work-api-v2") {
+		t.Fatalf("project dir = %s", filepath.Dir(log))
+	}
+	appendLines(t, log,
+		`{"type":"user","message":{"role":"user","content":"hi"}}`,
+		claudeReply("a", now, 1000, 500),
+		claudeReply("a", now, 1000, 500), // the same reply's second content block
+		claudeReply("old", now.AddDate(0, 0, -1), 9000, 9000),
+	)
+	panes := []TmuxPane{
+		{SessionName: "api", PanePID: 1, CurrentPath: "/work/api.v2"},
+		{SessionName: "api", PanePID: 2, CurrentPath: "/elsewhere"},
+		{SessionName: "idle", PanePID: 3, CurrentPath: "/work/api.v2"},
+	}
+	productive := map[int]bool{1: true, 2: true}
+
+	usage := queryAgentUsage(panes, productive, now)
+	u := usage["api"]
+	if u.Input != 1000 || u.Output != 500 {
+		t.Fatalf("usage = %+v, want one reply of today", u)
+	}
+	// sonnet: 1000 × $3 + 500 × $15 per million
+	if math.Abs(u.Cost-0.0105) > 1e-9 {
+		t.Errorf("cost = %v", u.Cost)
+	}
+	if _, ok := usage["idle"]; ok {
+		t.Error("a session with no agent running got usage")
+	}
+
+	// later replies are read from where the last fetch stopped
+	appendLines(t, log, claudeReply("b", now, 10, 20))
+	if u := queryAgentUsage(panes, productive, now)["api"]; u.Input != 1010 || u.Output != 520 {
+		t.Errorf("after another reply = %+v", u)
+	}
+}
+
+func TestAgentUsageCodex(t *testing.T) {
+	_, codex := useUsageDirs(t)
+	now := time.Now()
+	stamp := now.UTC().Format(time.RFC3339Nano)
+	count := func(input, cached, output int) string {
+		return fmt.Sprintf(`{"timestamp":%q,"type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":%d,"cached_input_tokens":%d,"output_tokens":%d}}}}`,
+			stamp, input, cached, output)
+	}
+	log := filepath.Join(codex, now.Format("2006/01/02"), "rollout.jsonl")
+	appendLines(t, log,
+		fmt.Sprintf(`{"timestamp":%q,"type":"session_meta","payload":{"cwd":"/work/web"}}`, stamp),
+		fmt.Sprintf(`{"timestamp":%q,"type":"turn_context","payload":{"model":"gpt-5-codex"}}`, stamp),
+		count(1000, 400, 100),
+		`{"type":"event_msg","payload":{"type":"token_count","info":null}}`,
+		count(3000, 1400, 300),
+	)
+	panes := []TmuxPane{{SessionName: "web", PanePID: 7, CurrentPath: "/work/web"}}
+
+	u := queryAgentUsage(panes, map[int]bool{7: true}, now)["web"]
+	if u.Input != 1600 || u.CacheRead != 1400 || u.Output != 300 {
+		t.Fatalf("usage = %+v, want the running total split into uncached and cached", u)
+	}
+	// gpt-5: 1600 × $1.25 + 1400 × $0.125 + 300 × $10 per million
+	if math.Abs(u.Cost-0.005175) > 1e-9 {
+		t.Errorf("cost = %v", u.Cost)
+	}
+}
+
+func TestAgentUsageDisabled(t *testing.T) {
+	useUsageDirs(t)
+	agentUsageEnabled = false
+	panes := []TmuxPane{{SessionName: "api", PanePID: 1, CurrentPath: "/work"}}
+	if usage := queryAgentUsage(panes, map[int]bool{1: true}, time.Now()); usage != nil {
+		t.Errorf("usage = %v with tracking off", usage)
+	}
+}
+
+func TestPriced(t *testing.T) {
+	u := agentUsage{Input: 1_000_000, Output: 1_000_000}
+	if c := priced(u, "claude-opus-4-5-20251101").Cost; c != 30 {
+		t.Errorf("opus 4.5 cost = %v, want the longer key's price", c)
+	}
+	if c := priced(u, "claude-opus-4-1").Cost; c != 90 {
+		t.Errorf("opus cost = %v", c)
+	}
+	if c := priced(u, "mystery").Cost; c != 0 {
+		t.Errorf("unknown model cost = %v", c)
+	}
+}
+
+func TestAgentUsageStore(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(agentUsageSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	if err := recordAgentUsage(db, day, map[string]agentUsage{"api": {Input: 10, Cost: 1}, "web": {Output: 5, Cost: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	// a later snapshot's totals replace the earlier ones
+	if err := recordAgentUsage(db, day.Add(time.Hour), map[string]agentUsage{"api": {Input: 2_000_000, Cost: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadAgentUsage(db, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].session != "api" || got[0].usage.Input != 2_000_000 || got[1].session != "web" {
+		t.Fatalf("loaded = %+v", got)
+	}
+	if other, _ := loadAgentUsage(db, day.AddDate(0, 0, 1)); len(other) != 0 {
+		t.Errorf("next day = %+v", other)
+	}
+
+	var buf bytes.Buffer
+	writeUsageReport(&buf, day, got)
+	if out := buf.String(); !strings.Contains(out, "2.0M tokens · $5.00") || !strings.Contains(out, "web") {
+		t.Errorf("report:\n%s", out)
+	}
+}
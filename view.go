@@ -1,8 +1,9 @@
 // rendering: the View() method and all display formatting.
 //
 // displays are rendered as separate columns and joined horizontally
-// with lipgloss, mirroring the physical monitor layout. each column
-// has its own header, space rows, and summary stats.
+// with lipgloss, mirroring the physical monitor layout; monitors stacked
+// vertically get their own row of columns below. each column has its
+// own header, space rows, and summary stats.
 //
 // spaces are numbered from 1 within each display (relative index)
 // since that's how they map to keyboard shortcuts. the absolute yabai
@@ -11,17 +12,22 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/fadedlamp42/stop/engine"
 	"github.com/mattn/go-runewidth"
 )
 
 // -- styles --
 
+// the default palette; applyTheme (theme.go) replaces these at startup.
 var (
 	displayStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)
 	dimStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
@@ -33,65 +39,76 @@ var (
 	lyricActiveStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Bold(true)
 	lyricNearStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
 	lyricFarStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	annotationStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("5")).Italic(true)
+	envBadgeStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("4"))
 )
 
 // -- view --
 
-func (m model) View() string {
+// renderView builds the page; View (frame.go) skips it when nothing
+// shown has changed.
+func (m model) renderView() string {
+	start := time.Now()
+	defer func() { m.budget.recordRender(time.Since(start)) }()
+
 	if !m.ready {
 		if m.err != nil {
-			return fmt.Sprintf("\n  error: %v\n\n  is yabai running?\n", m.err)
+			return m.renderStartup()
 		}
 		return "\n  loading...\n"
 	}
 
 	numDisplays := len(m.displayGroups)
+	if numDisplays == 0 && m.wmErr != nil {
+		return m.renderTmuxOnly()
+	}
 	if numDisplays == 0 {
 		return "\n  no displays found\n"
 	}
 
-	// compute column width from terminal width
+	// compute column width from terminal width (narrow.go)
 	margin := 2
 	gap := 6
 	availWidth := m.width - 2*margin
-	colWidth := availWidth
-	if numDisplays > 1 {
-		colWidth = (availWidth - gap*(numDisplays-1)) / numDisplays
-	}
-	if colWidth < 30 {
-		colWidth = 30
-	}
+	colWidth, panelWidth, stacked := m.gridGeometry()
 
 	// compute per-session staleness for bubbling up to space rows.
 	// uses most recent pane activity per session (freshest pane wins).
-	productiveActivity := bestProductiveActivity(m.tmuxPanes, m.productivePanePIDs)
-
-	// render each display as a separate column
-	colStyle := lipgloss.NewStyle().Width(colWidth)
-	var styledColumns []string
-	for i, dg := range m.displayGroups {
-		activeRow := -1
-		if i == m.cursorCol {
-			activeRow = m.cursorRow
-		}
-		col := renderDisplayColumn(dg, activeRow, colWidth, m.tmuxByDisplay[dg.index], productiveActivity, m.productivePanePIDs, m.nvimBuffers)
-		styledColumns = append(styledColumns, colStyle.Render(col))
-	}
-
-	// join columns horizontally with gap (mirrors physical monitor layout)
-	var body string
-	if numDisplays == 1 {
-		body = styledColumns[0]
-	} else {
-		args := make([]string, 0, numDisplays*2-1)
-		gapStr := strings.Repeat(" ", gap)
-		for i, col := range styledColumns {
-			if i > 0 {
-				args = append(args, gapStr)
-			}
-			args = append(args, col)
-		}
-		body = lipgloss.JoinHorizontal(lipgloss.Top, args...)
+	rc := renderContext{
+		productiveActivity: withBareAgents(bestProductiveActivity(m.tmuxPanes, m.productivePanePIDs), m.windows, m.bareAgents),
+		productivePanePIDs: m.productivePanePIDs,
+		nvimBuffers:        m.nvimBuffers,
+		annotations:        m.annotations,
+		priorities:         m.priorities,
+		alerts:             m.alerts,
+		baselines:          m.baselines,
+		tmuxTTYs:           tmuxClientTTYs(m.tmuxClients),
+		terminalSessions:   m.terminalSessions,
+		bareAgents:         m.bareAgents,
+		swapFrom:           m.swapFrom,
+		marks:              m.marks,
+		showHidden:         m.showHidden,
+		foldedGroups:       m.foldedGroups,
+		density:            m.density,
+		paneEnv:            m.paneEnv,
+		paneKube:           m.paneKube,
+		paneStates:         m.paneStates,
+		browserTabs:        m.browserTabs,
+		focusSession:       m.focusSession,
+		spaceTime:          m.spaceTime,
+		agentUsage:         m.agentUsage,
+		titleHistory:       m.titleHistory,
+		spaceHotkeys:       m.spaceHotkeys,
+		enrichments:        m.enrichments,
+		flashes:            m.flashes,
+		pins:               m.pins,
+		migrations:         m.migrations,
+	}
+	if panelWidth > 0 {
+		rc.density = densityCompact
+	}
+	if m.zoom {
+		rc = rc.zoomed()
 	}
 
 	pad := strings.Repeat(" ", margin)
@@ -101,19 +118,16 @@ func (m model) View() string {
 	//   middle = lyrics viewport (claims all remaining terminal height)
 	//   bottom = keybinds help
 	// computing lengths up front lets the lyrics block expand or shrink
-	// to fill exactly the leftover vertical space.
-
-	var top strings.Builder
-	top.WriteString("\n")
-	for _, line := range strings.Split(body, "\n") {
-		top.WriteString(pad)
-		top.WriteString(line)
-		top.WriteString("\n")
-	}
+	// to fill exactly the leftover vertical space. the grid is the one
+	// part that can scroll, so everything around it is rendered first
+	// and it gets what's left.
 
+	var below strings.Builder
 	if len(m.detachedTmux) > 0 {
-		top.WriteString(renderTmuxSessions(m.detachedTmux, "detached", m.nvimBuffers, m.productivePanePIDs))
+		below.WriteString(renderTmuxSessions(m.detachedTmux, "detached", rc))
 	}
+	below.WriteString(m.renderScratchpad(m.width-2*margin, pad))
+	below.WriteString(m.renderCleanup(m.width-2*margin, pad))
 
 	nowPlayingDisplay := m.playingMeta.DisplayString()
 	if nowPlayingDisplay != "" {
@@ -131,19 +145,19 @@ func (m model) View() string {
 		writeWrapped := func(prefix, body string) {
 			lines := wrapForWidth(body, npInner)
 			for i, line := range lines {
-				top.WriteString(pad)
+				below.WriteString(pad)
 				if i == 0 {
-					top.WriteString(dimStyle.Render(prefix))
+					below.WriteString(dimStyle.Render(prefix))
 				} else {
-					top.WriteString(dimStyle.Render("  "))
+					below.WriteString(dimStyle.Render("  "))
 				}
-				top.WriteString(dimStyle.Render(line))
-				top.WriteString("\n")
+				below.WriteString(dimStyle.Render(line))
+				below.WriteString("\n")
 			}
 		}
 
-		top.WriteString("\n")
-		writeWrapped("\u266a ", nowPlayingDisplay)
+		below.WriteString("\n")
+		writeWrapped("♪ ", nowPlayingDisplay)
 		// english translation of the artist + title when the source is
 		// non-latin. populated asynchronously after a song change so the
 		// row only appears once translateBatch returns. wrapped to the
@@ -153,7 +167,158 @@ func (m model) View() string {
 		}
 	}
 
-	bottom := "\n" + pad + renderHelp(numDisplays > 1) + "\n"
+	bottom := "\n"
+	bottom += m.renderPreview(m.width-2*margin, pad)
+	if line := m.renderPrompt(); line != "" {
+		bottom += pad + line + "\n"
+	}
+	if line := renderSLOLine(m.slo); line != "" {
+		bottom += pad + line + "\n"
+	}
+	if plan, ok := planBalance(m.displayGroups, balanceMinGap); ok && m.confirm == nil && !engine.ReadOnly() {
+		bottom += pad + renderBalanceLine(plan) + "\n"
+	}
+	if m.settling > 0 {
+		bottom += pad + dimStyle.Render("settling…") + "\n"
+	}
+	if line := renderAwayLine(); line != "" {
+		bottom += pad + line + "\n"
+	}
+	if line := renderBudgetLine(m.budget); line != "" {
+		bottom += pad + line + "\n"
+	}
+	if line := renderStaleDataLine(m.err, m.refreshedAt, time.Now()); line != "" {
+		bottom += pad + line + "\n"
+	}
+	if line := renderSourceErrors(m.sourceErrs); line != "" {
+		bottom += pad + line + "\n"
+	}
+	if line := renderSimulatedLine(); line != "" {
+		bottom += pad + line + "\n"
+	} else if engine.ReadOnly() {
+		bottom += pad + warnStyle.Render("read-only") + dimStyle.Render(" — focus, moves, kills and renames are off") + "\n"
+	}
+	if m.showAgents {
+		bottom += pad + m.renderAgentsHelp() + "\n"
+	} else {
+		bottom += pad + renderHelp(numDisplays) + "\n"
+	}
+
+	// vertical budget per layout row of columns: whatever the rest of the
+	// page leaves, minus the leading newline and the blank lines between
+	// rows. 0 while the terminal size is unknown, which disables scrolling.
+	numLayoutRows := 0
+	for i, dg := range m.displayGroups {
+		if i == 0 || dg.layoutRow != m.displayGroups[i-1].layoutRow {
+			numLayoutRows++
+		}
+	}
+	if m.zoom {
+		numLayoutRows = 1
+	} else if stacked {
+		numLayoutRows = len(m.displayGroups)
+	}
+	rowHeight := 0
+	if m.height > 0 {
+		gridHeight := m.height - 1 - countLines(below.String()) - countLines(bottom)
+		rowHeight = maxInt((gridHeight-(numLayoutRows-1))/numLayoutRows, 1)
+	}
+
+	// render each display as a separate column, bucketed by layout row.
+	// groups arrive sorted row by row, so a row change starts a new bucket.
+	colStyle := lipgloss.NewStyle().Width(colWidth)
+	var layoutRows [][]string
+	var layoutTargets [][][]hitRegion // per layout row, per column, column-relative
+	for i, dg := range m.displayGroups {
+		if m.zoom && i != m.cursorCol {
+			continue
+		}
+		activeRow := -1
+		if i == m.cursorCol {
+			activeRow = m.cursorRow
+		}
+		rendered := renderDisplayColumn(dg, activeRow, colWidth, m.tmuxByDisplay[dg.index], rc)
+		lines, targets, offset := rendered.viewport(colStyle, rowHeight, activeRow, m.scroll[columnKey(dg)])
+		if m.scroll != nil {
+			m.scroll[columnKey(dg)] = offset
+		}
+		if len(layoutRows) == 0 || stacked || dg.layoutRow != m.displayGroups[i-1].layoutRow {
+			layoutRows = append(layoutRows, nil)
+			layoutTargets = append(layoutTargets, nil)
+		}
+		for j := range targets {
+			targets[j].col = i
+		}
+		col := strings.Join(lines, "\n")
+		last := len(layoutRows) - 1
+		layoutRows[last] = append(layoutRows[last], colStyle.Render(col))
+		layoutTargets[last] = append(layoutTargets[last], columnRegions(col, targets, colStyle))
+	}
+
+	// join columns horizontally with gap (mirrors physical monitor layout),
+	// then stack the rows with a blank line between them
+	var rowBodies []string
+	gapStr := strings.Repeat(" ", gap)
+	for _, cols := range layoutRows {
+		args := make([]string, 0, len(cols)*2-1)
+		for i, col := range cols {
+			if i > 0 {
+				args = append(args, gapStr)
+			}
+			args = append(args, col)
+		}
+		rowBodies = append(rowBodies, lipgloss.JoinHorizontal(lipgloss.Top, args...))
+	}
+	body := strings.Join(rowBodies, "\n\n")
+	if panelWidth > 0 {
+		if row, ok := m.selectedSpaceRow(); ok {
+			panel := renderDetailPanel(row, m.tmuxPanes, panelWidth, rc)
+			body = lipgloss.JoinHorizontal(lipgloss.Top, body, gapStr, lipgloss.NewStyle().Width(panelWidth).Render(panel))
+		}
+	}
+
+	// place the column-relative click regions on the screen: body starts
+	// one line down (the leading newline) and margin cells in
+	m.hits.reset()
+	y := 1
+	for r, cols := range layoutTargets {
+		x := margin
+		for _, regions := range cols {
+			for _, reg := range regions {
+				m.hits.add(reg.offset(x, y))
+			}
+			x += colWidth + gap
+		}
+		y += lipgloss.Height(rowBodies[r]) + 1
+	}
+	if m.showAgents {
+		body = m.renderAgentsView(availWidth)
+		m.hits.reset()
+	} else if m.compare != nil {
+		body = m.renderCompareView(availWidth)
+		m.hits.reset()
+	}
+
+	// the top line: a running focus session, then the desk summary
+	var top strings.Builder
+	var topLine []string
+	if line := renderFocusSessionLine(m.focusSession, m.focusedSpace(), time.Now()); line != "" {
+		topLine = append(topLine, line)
+	}
+	if summaryLine {
+		s := summarizeDesk(m.displayGroups, m.tmuxPanes, m.productivePanePIDs)
+		topLine = append(topLine, renderSummaryLine(s, m.refreshedAt, time.Now()))
+	}
+	if len(topLine) > 0 {
+		top.WriteString(pad + truncateForWidth(strings.Join(topLine, "    "), maxInt(m.width-2*margin, 10)))
+	}
+	top.WriteString("\n")
+	for _, line := range strings.Split(body, "\n") {
+		top.WriteString(pad)
+		top.WriteString(line)
+		top.WriteString("\n")
+	}
+	top.WriteString(below.String())
 
 	topStr := top.String()
 
@@ -181,23 +346,135 @@ func countLines(s string) int {
 	return strings.Count(s, "\n")
 }
 
+// renderContext bundles the per-refresh lookups the column, row, and tmux
+// renderers share, so adding one doesn't ripple through every signature.
+type renderContext struct {
+	productiveActivity map[string]time.Time             // session → freshest productive pane activity
+	productivePanePIDs map[int]bool                     // pane pids with a productive descendant
+	nvimBuffers        map[int][]NvimBuffer             // pane_pid → open buffers
+	annotations        annotationSet                    // external status strings
+	priorities         prioritySet                      // triage levels
+	alerts             alertSet                         // fired, unacknowledged alerts by session
+	baselines          commandBaselines                 // usual run per command, for silence anomalies
+	tmuxTTYs           map[string]bool                  // ttys tmux clients run on, to tell shells apart (shells.go)
+	terminalSessions   map[int][]engine.TerminalSession // window id → its tabs and split panes
+	bareAgents         map[int][]bareAgent              // window id → agents running outside tmux (bareagents.go)
+	swapFrom           int                              // space picked as a swap source; 0 when none
+	zoom               bool                             // one column across the terminal, in full (zoom.go)
+	marks              map[int]bool                     // space ID → marked for a bulk action
+	showHidden         bool                             // list each display's hidden windows
+	foldedGroups       map[string]bool                  // label groups folded to their header
+	density            density                          // how much detail each space row carries
+	paneEnv            map[int]map[string]string        // pane_pid → environment badge keys
+	paneKube           map[int]string                   // pane_pid → kube context of its kubectl / k9s / helm
+	paneStates         map[int]paneState                // pane_pid → agent state read off its screen
+	browserTabs        map[int][]string                 // window id → tab titles, when browserTabsEnabled is on
+	focusSession       *focusSession                    // the running focus timer, marked on its space
+	spaceTime          map[string]time.Duration         // spaceTitle → focus time today
+	agentUsage         map[string]agentUsage            // session → agents' usage today
+	titleHistory       titleHistory                     // space ID → recent window titles
+	spaceHotkeys       map[int]string                   // space index → skhd hotkey
+	enrichments        enrichSet                        // badges and lines from enricher hooks
+	flashes            map[int]time.Time                // space index → highlighted until
+	pins               []string                         // pinned spaces and sessions, marked ⇡
+	migrations         map[int]spaceMigration           // spaces that moved displays, flagged ↪
+}
+
+// renderTmuxOnly is the whole page when there's no window manager to ask
+// about spaces: every tmux session with its staleness, no grid.
+func (m model) renderTmuxOnly() string {
+	rc := renderContext{
+		productiveActivity: bestProductiveActivity(m.tmuxPanes, m.productivePanePIDs),
+		productivePanePIDs: m.productivePanePIDs,
+		nvimBuffers:        m.nvimBuffers,
+		annotations:        m.annotations,
+		priorities:         m.priorities,
+		alerts:             m.alerts,
+		baselines:          m.baselines,
+		density:            m.density,
+		paneEnv:            m.paneEnv,
+		paneKube:           m.paneKube,
+		paneStates:         m.paneStates,
+		browserTabs:        m.browserTabs,
+	}
+	pad := "  "
+	var b strings.Builder
+	b.WriteString("\n")
+	reason := fmt.Sprintf("%s unavailable (%v)", currentWM().Name(), m.wmErr)
+	if errors.Is(m.wmErr, engine.ErrNoWindowManager) {
+		reason = "window manager disabled"
+	}
+	b.WriteString(pad + warnStyle.Render("tmux only") + dimStyle.Render(" — "+reason) + "\n")
+	if len(m.tmuxPanes) == 0 {
+		b.WriteString("\n" + pad + dimStyle.Render("no tmux sessions") + "\n")
+	} else {
+		b.WriteString(renderTmuxSessions(m.tmuxPanes, "sessions", rc))
+	}
+	b.WriteString("\n")
+	if line := renderSLOLine(m.slo); line != "" {
+		b.WriteString(pad + line + "\n")
+	}
+	if line := renderBudgetLine(m.budget); line != "" {
+		b.WriteString(pad + line + "\n")
+	}
+	if line := renderStaleDataLine(m.err, m.refreshedAt, time.Now()); line != "" {
+		b.WriteString(pad + line + "\n")
+	}
+	var others []engine.SourceError // the banner already covers the window manager
+	for _, e := range m.sourceErrs {
+		if e.Source != currentWM().Name() {
+			others = append(others, e)
+		}
+	}
+	if line := renderSourceErrors(others); line != "" {
+		b.WriteString(pad + line + "\n")
+	}
+	if line := renderSimulatedLine(); line != "" {
+		b.WriteString(pad + line + "\n")
+	}
+	b.WriteString(pad + keyStyle.Render("q") + " " + helpStyle.Render("quit") + "\n")
+	return b.String()
+}
+
 // -- column rendering --
 
-func renderDisplayColumn(dg displayGroup, cursorRow int, colWidth int, tmuxPanes []TmuxPane, productiveActivity map[string]time.Time, productivePanePIDs map[int]bool, nvimBuffers map[int][]NvimBuffer) string {
+// renderedColumn is one display's column in pieces, so the space rows
+// can be scrolled between a fixed header and summary.
+type renderedColumn struct {
+	header  string
+	rows    []string      // one per space, multi-line when tmux detail is inlined; the hidden section last when shown
+	targets [][]hitTarget // per row, one per line of it
+	footer  string
+}
+
+func renderDisplayColumn(dg displayGroup, cursorRow int, colWidth int, tmuxPanes []TmuxPane, rc renderContext) renderedColumn {
+	var col renderedColumn
 	var b strings.Builder
 
-	// header
-	b.WriteString(displayStyle.Render(fmt.Sprintf("display %d", dg.index)))
+	// header: real display name + resolution when the backend knows them,
+	// bare index otherwise
+	b.WriteString(displayStyle.Render(displayName(dg)))
+	if res := dg.display.Resolution(); res != "" {
+		b.WriteString(" ")
+		b.WriteString(dimStyle.Render(res))
+	}
 	b.WriteString("  ")
 	b.WriteString(dimStyle.Render(fmt.Sprintf("%d spaces", len(dg.spaces))))
-	b.WriteString("\n")
+	col.header = b.String()
 
 	// how much room for window titles after the fixed-width prefix
-	// rough overhead: "  > " (4) + "1(10)" (5) + " * " (3) + "kitty: " (7) ≈ 19
-	maxTitleLen := colWidth - 22
+	// rough overhead: "  > " (4) + "1(10)" (5) + " * " (3) + "kitty: " (7) ≈ 19,
+	// plus the app icon
+	maxTitleLen := colWidth - 22 - iconWidth()
+	if w := hotkeyWidth(rc.spaceHotkeys); w > 0 {
+		maxTitleLen -= w + 1
+	}
 	if maxTitleLen < 10 {
 		maxTitleLen = 10
 	}
+	if rc.zoom {
+		maxTitleLen = zoomTitleLen
+	}
 
 	// build session name → panes lookup so space rows can inline tmux detail
 	tmuxBySession := make(map[string][]TmuxPane)
@@ -205,17 +482,42 @@ func renderDisplayColumn(dg displayGroup, cursorRow int, colWidth int, tmuxPanes
 		tmuxBySession[p.SessionName] = append(tmuxBySession[p.SessionName], p)
 	}
 
-	// space rows
+	// space rows, under their label group's header (spacegroups.go); a
+	// folded group's header is its first row and the rest render nothing
 	for i, row := range dg.spaces {
 		relIdx := i + 1
 		absIdx := row.space.Index
 		isSelected := i == cursorRow
-		b.WriteString(renderSpaceRow(row, relIdx, absIdx, isSelected, maxTitleLen, productiveActivity, tmuxBySession, nvimBuffers, productivePanePIDs))
-		b.WriteString("\n")
+		if foldedRow(dg, i, rc.foldedGroups) {
+			col.rows = append(col.rows, "")
+			col.targets = append(col.targets, nil)
+			continue
+		}
+		name, n, head := groupRun(dg, i)
+		if head && rc.foldedGroups[name] {
+			col.rows = append(col.rows, renderGroupHeader(name, n, true, isSelected))
+			col.targets = append(col.targets, []hitTarget{{kind: hitSpace, row: i}})
+			continue
+		}
+		text, rowTargets := renderSpaceRow(row, relIdx, absIdx, isSelected, maxTitleLen, tmuxBySession, rc)
+		if head {
+			text = renderGroupHeader(name, n, false, false) + "\n" + text
+			rowTargets = append([]hitTarget{{kind: hitSpace}}, rowTargets...)
+		}
+		for j := range rowTargets {
+			rowTargets[j].row = i
+		}
+		col.rows = append(col.rows, text)
+		col.targets = append(col.targets, rowTargets)
+	}
+	if rc.showHidden && len(dg.hidden) > 0 {
+		text, targets := renderHiddenSection(dg, maxTitleLen)
+		col.rows = append(col.rows, text)
+		col.targets = append(col.targets, targets)
 	}
 
 	// per-display summary
-	b.WriteString("\n")
+	b.Reset()
 	if dg.freeCount > 0 {
 		b.WriteString(freeStyle.Render(fmt.Sprintf("%d free", dg.freeCount)))
 	} else {
@@ -223,16 +525,31 @@ func renderDisplayColumn(dg displayGroup, cursorRow int, colWidth int, tmuxPanes
 	}
 	b.WriteString("  ")
 	b.WriteString(fmt.Sprintf("%d terminals", dg.termCount))
+	if len(dg.hidden) > 0 && !rc.showHidden {
+		b.WriteString("  ")
+		b.WriteString(dimStyle.Render(fmt.Sprintf("%d hidden", len(dg.hidden))))
+	}
+	col.footer = b.String()
 
-	return b.String()
+	return col
 }
 
 // -- row rendering --
 
-func renderSpaceRow(row spaceRow, relIdx, absIdx int, isSelected bool, maxTitleLen int, productiveActivity map[string]time.Time, tmuxBySession map[string][]TmuxPane, nvimBuffers map[int][]NvimBuffer, productivePanePIDs map[int]bool) string {
+// renderSpaceRow returns the row's lines and what a click on each of
+// them means: the space itself, or one of its inline tmux windows.
+func renderSpaceRow(row spaceRow, relIdx, absIdx int, isSelected bool, maxTitleLen int, tmuxBySession map[string][]TmuxPane, rc renderContext) (string, []hitTarget) {
 	cursor := "  "
 	if isSelected {
 		cursor = cursorStyle.Render("> ")
+	} else if absIdx == rc.swapFrom {
+		cursor = warnStyle.Render("S ")
+	}
+	if rc.marks[row.space.ID] {
+		cursor = keyStyle.Render("+ ")
+		if isSelected {
+			cursor = cursorStyle.Render(">") + keyStyle.Render("+")
+		}
 	}
 
 	// focus indicator: * = focused, · = visible on other display
@@ -246,28 +563,50 @@ func renderSpaceRow(row spaceRow, relIdx, absIdx int, isSelected bool, maxTitleL
 
 	// compute worst (most stale) productive session on this space.
 	// only productive panes contribute — bash/btop sitting idle isn't meaningful.
+	// the space's priority is the highest of its own level and its sessions'.
 	var worstProductiveActivity time.Time
 	hasProductiveSession := false
+	prio := rc.priorities.forSpace(row.space)
 	for _, w := range row.windows {
-		if !isTerminal(w.App) {
+		if !engine.IsTerminal(w.App) {
 			continue
 		}
-		if activity, ok := productiveActivity[w.Title]; ok {
+		if activity, ok := rc.productiveActivity[w.Title]; ok {
 			if !hasProductiveSession || activity.Before(worstProductiveActivity) {
 				worstProductiveActivity = activity
 				hasProductiveSession = true
 			}
+			if sp := rc.priorities.forSession(w.Title); sp > prio {
+				prio = sp
+			}
 		}
 	}
 
 	// relative index colored only when productive work is happening on this space
 	indexStr := fmt.Sprintf("%2d", relIdx)
+	attention := ""
 	if hasProductiveSession {
-		indexStr = stalenessStyle(worstProductiveActivity).Render(fmt.Sprintf("%2d", relIdx))
+		indexStr = prio.stalenessStyle(worstProductiveActivity).Render(fmt.Sprintf("%2d", relIdx))
+		if prio.needsAttention(worstProductiveActivity) {
+			attention = warnStyle.Render("! ")
+		}
+	}
+	if awaitingOnSpace(row, tmuxBySession, rc.paneStates) {
+		attention = keyStyle.Render("◆ ")
+	}
+	for _, w := range row.windows {
+		if engine.IsTerminal(w.App) && len(rc.alerts[w.Title]) > 0 {
+			attention = warnStyle.Render("\u2691 ")
+			break
+		}
+	}
+	if flashing(rc.flashes, row.space, time.Now()) {
+		indexStr = flashStyle.Render(fmt.Sprintf("%2d", relIdx))
 	}
 	if relIdx != absIdx {
 		indexStr += dimStyle.Render(fmt.Sprintf("(%d)", absIdx))
 	}
+	indexStr += renderHotkey(rc.spaceHotkeys, absIdx, hotkeyWidth(rc.spaceHotkeys))
 
 	// optional space label from yabai config
 	label := ""
@@ -275,9 +614,30 @@ func renderSpaceRow(row spaceRow, relIdx, absIdx int, isSelected bool, maxTitleL
 		label = dimStyle.Render(fmt.Sprintf("[%s] ", row.space.Label))
 	}
 
-	windowText := renderWindows(row.windows, maxTitleLen, productiveActivity)
+	windowText := renderWindows(row.windows, maxTitleLen, rc)
+	if rc.density == densityCompact {
+		windowText = renderSpaceCounts(row, tmuxBySession, rc)
+	}
 
-	mainLine := fmt.Sprintf("%s%s %s  %s%s", cursor, indexStr, indicator, label, windowText)
+	live := renderLiveMarker(spaceLive(row.windows, liveWindowRules))
+	if rc.focusSession != nil && rc.focusSession.SpaceID == row.space.ID {
+		live = keyStyle.Render("⏱ ") + live
+	}
+	if isPinned(row, rc.pins) {
+		live = dimStyle.Render("⇡ ") + live
+	}
+	live = renderMigration(rc.migrations, row.space, time.Now()) + live
+	mainLine := fmt.Sprintf("%s%s %s  %s%s%s%s", cursor, indexStr, indicator, attention, live, label, windowText)
+	if a, ok := rc.annotations.forSpace(row.space); ok {
+		mainLine += "  " + renderAnnotation(a, maxTitleLen)
+	}
+	spaceEnrich := rc.enrichments.forSpace(row.space)
+	if badges := renderHookBadges(spaceEnrich); badges != "" {
+		mainLine += "  " + badges
+	}
+	if rc.density == densityCompact {
+		return mainLine, []hitTarget{{kind: hitSpace}}
+	}
 
 	// inline tmux pane detail under terminals on this space.
 	// matches terminal window titles to tmux session names.
@@ -285,14 +645,47 @@ func renderSpaceRow(row spaceRow, relIdx, absIdx int, isSelected bool, maxTitleL
 	// cursor(2) + index(2) + space(1) + indicator(1) + gap(2) = 8 chars
 	indent := "        "
 	var tmuxLines []string
+	targets := []hitTarget{{kind: hitSpace}}
+	for _, l := range spaceEnrich.Lines {
+		tmuxLines = append(tmuxLines, indent+dimStyle.Render(truncateStr(l, maxTitleLen)))
+		targets = append(targets, hitTarget{kind: hitSpace})
+	}
 	for _, w := range row.windows {
-		if !isTerminal(w.App) {
+		if !engine.IsTerminal(w.App) {
 			continue
 		}
-		sessionPanes, ok := tmuxBySession[strings.TrimSpace(w.Title)]
+		for _, l := range renderShellLines(rc.terminalSessions[w.ID], rc.tmuxTTYs, indent, maxTitleLen) {
+			tmuxLines = append(tmuxLines, l)
+			targets = append(targets, hitTarget{kind: hitSpace})
+		}
+		for _, l := range renderBareAgentLines(rc.bareAgents[w.ID], rc.priorities.forSession(w.Title), indent) {
+			tmuxLines = append(tmuxLines, l)
+			targets = append(targets, hitTarget{kind: hitSpace})
+		}
+		sessionName := strings.TrimSpace(w.Title)
+		sessionPanes, ok := tmuxBySession[sessionName]
 		if !ok {
 			continue
 		}
+		if a, ok := rc.annotations.forSession(sessionName); ok {
+			tmuxLines = append(tmuxLines, indent+renderAnnotation(a, maxTitleLen))
+			targets = append(targets, hitTarget{kind: hitSpace})
+		}
+		if e := rc.enrichments.forSession(sessionName); len(e.Badges) > 0 || len(e.Lines) > 0 {
+			if badges := renderHookBadges(e); badges != "" {
+				tmuxLines = append(tmuxLines, indent+dimStyle.Render(sessionName+" ")+badges)
+				targets = append(targets, hitTarget{kind: hitSpace})
+			}
+			for _, l := range e.Lines {
+				tmuxLines = append(tmuxLines, indent+dimStyle.Render(truncateStr(l, maxTitleLen)))
+				targets = append(targets, hitTarget{kind: hitSpace})
+			}
+		}
+		if !conformsSessionName(sessionName) {
+			tmuxLines = append(tmuxLines, indent+warnStyle.Render("~ nonstandard session name, "+activeKeymap.label(actRename)+" to rename"))
+			targets = append(targets, hitTarget{kind: hitSpace})
+		}
+		sessionPrio := rc.priorities.forSession(sessionName)
 		for _, win := range groupPanesByWindow(sessionPanes) {
 			windowLabel := fmt.Sprintf("%d:%s", win.index, win.name)
 
@@ -300,7 +693,7 @@ func renderSpaceRow(row spaceRow, relIdx, absIdx int, isSelected bool, maxTitleL
 			var bestProductive time.Time
 			windowHasProductive := false
 			for _, p := range win.panes {
-				if productivePanePIDs[p.PanePID] {
+				if rc.productivePanePIDs[p.PanePID] {
 					if !windowHasProductive || p.LastActivity.After(bestProductive) {
 						bestProductive = p.LastActivity
 						windowHasProductive = true
@@ -311,50 +704,80 @@ func renderSpaceRow(row spaceRow, relIdx, absIdx int, isSelected bool, maxTitleL
 			var line strings.Builder
 			line.WriteString(indent)
 			if windowHasProductive {
-				line.WriteString(stalenessStyle(bestProductive).Render(windowLabel))
+				line.WriteString(sessionPrio.stalenessStyle(bestProductive).Render(windowLabel))
 			} else {
 				line.WriteString(dimStyle.Render(windowLabel))
 			}
 
-			// panes inline after window label
+			// panes inline after window label; expanded mode lists them
+			// one per line with their cwd and scrollback size
+			target := hitTarget{kind: hitTmuxWindow, session: sessionName, window: win.index}
+			var paneLines []string
 			for _, p := range win.panes {
 				style := dimStyle
-				if productivePanePIDs[p.PanePID] {
-					style = stalenessStyle(p.LastActivity)
+				if rc.productivePanePIDs[p.PanePID] {
+					style = sessionPrio.stalenessStyle(p.LastActivity)
 				}
-				line.WriteString("  ")
-				line.WriteString(style.Render("\u258e"))
-				line.WriteString(" ")
-				line.WriteString(style.Render(p.CurrentCommand))
+				var pane strings.Builder
+				pane.WriteString("  ")
+				pane.WriteString(style.Render("\u258e"))
+				pane.WriteString(" ")
+				pane.WriteString(style.Render(p.CurrentCommand))
 				if p.CurrentCommand == "nvim" {
-					if extra := nvimBufferLabel(nvimBuffers[p.PanePID]); extra != "" {
-						line.WriteString(" ")
-						line.WriteString(dimStyle.Render(extra))
+					if extra := nvimBufferLabel(rc.nvimBuffers[p.PanePID]); extra != "" {
+						pane.WriteString(" ")
+						pane.WriteString(dimStyle.Render(extra))
 					}
 				}
-				line.WriteString(" ")
-				line.WriteString(dimStyle.Render(formatRelativeTime(p.LastActivity)))
+				if p.Progress >= 0 {
+					pane.WriteString(" ")
+					pane.WriteString(renderProgressBar(p.Progress))
+				}
+				pane.WriteString(" ")
+				pane.WriteString(dimStyle.Render(formatRelativeTime(p.LastActivity)))
+				if anomaly := renderSilenceAnomaly(rc, p); anomaly != "" {
+					pane.WriteString(" ")
+					pane.WriteString(anomaly)
+				}
+				if badges := renderPaneBadges(rc, p.PanePID); badges != "" {
+					pane.WriteString(" ")
+					pane.WriteString(badges)
+				}
+				if rc.density != densityExpanded {
+					line.WriteString(pane.String())
+					continue
+				}
+				pane.WriteString("  ")
+				pane.WriteString(dimStyle.Render(shortPath(p.CurrentPath)))
+				pane.WriteString(" ")
+				pane.WriteString(dimStyle.Render(formatHistorySize(p.HistorySize) + " lines"))
+				paneLines = append(paneLines, indent+pane.String())
 			}
 			tmuxLines = append(tmuxLines, line.String())
+			targets = append(targets, target)
+			for _, pl := range paneLines {
+				tmuxLines = append(tmuxLines, pl)
+				targets = append(targets, target)
+			}
 		}
 	}
 
 	if len(tmuxLines) > 0 {
-		return mainLine + "\n" + strings.Join(tmuxLines, "\n")
+		return mainLine + "\n" + strings.Join(tmuxLines, "\n"), targets
 	}
-	return mainLine
+	return mainLine, targets
 }
 
 // -- window rendering --
 
-func renderWindows(windows []Window, maxTitleLen int, productiveActivity map[string]time.Time) string {
+func renderWindows(windows []Window, maxTitleLen int, rc renderContext) string {
 	if len(windows) == 0 {
 		return dimStyle.Render("--")
 	}
 
 	var terminals, browsers, others []Window
 	for _, w := range windows {
-		if isTerminal(w.App) {
+		if engine.IsTerminal(w.App) {
 			terminals = append(terminals, w)
 		} else if isBrowser(w.App) {
 			browsers = append(browsers, w)
@@ -373,16 +796,15 @@ func renderWindows(windows []Window, maxTitleLen int, productiveActivity map[str
 
 		var entry string
 		if displayTitle != "" {
-			entry = fmt.Sprintf("%s: %s", w.App, displayTitle)
+			entry = fmt.Sprintf("%s: %s", withIcon(w.App), displayTitle)
 		} else {
-			entry = w.App
+			entry = withIcon(w.App)
 		}
 
-		if activity, ok := productiveActivity[rawTitle]; ok {
-			parts = append(parts, stalenessStyle(activity).Render(entry))
-		} else {
-			parts = append(parts, entry)
+		if activity, ok := rc.productiveActivity[rawTitle]; ok {
+			entry = rc.priorities.forSession(rawTitle).stalenessStyle(activity).Render(entry)
 		}
+		parts = append(parts, entry+renderWindowFlags(w))
 	}
 
 	// browsers: show individual page titles (cleaned of " — Firefox" etc.)
@@ -390,16 +812,24 @@ func renderWindows(windows []Window, maxTitleLen int, productiveActivity map[str
 		title := cleanBrowserTitle(strings.TrimSpace(w.Title))
 		title = truncateStr(title, maxTitleLen)
 		if title != "" {
-			parts = append(parts, fmt.Sprintf("%s: %s", w.App, title))
+			parts = append(parts, fmt.Sprintf("%s: %s", withIcon(w.App), title)+renderTabCount(rc, w)+renderWindowFlags(w))
 		} else {
-			parts = append(parts, w.App)
+			parts = append(parts, withIcon(w.App)+renderTabCount(rc, w)+renderWindowFlags(w))
 		}
 	}
 
-	// everything else: group by app name, show count when duplicated
+	// everything else: group by app name, show count when duplicated.
+	// a group carries the flags any of its windows has.
 	appCounts := make(map[string]int)
+	appFlags := make(map[string]Window)
 	for _, w := range others {
 		appCounts[w.App]++
+		f := appFlags[w.App]
+		f.IsFloating = f.IsFloating || w.IsFloating
+		f.IsSticky = f.IsSticky || w.IsSticky
+		f.IsNativeFullscreen = f.IsNativeFullscreen || w.IsNativeFullscreen
+		f.HasFullscreenZoom = f.HasFullscreenZoom || w.HasFullscreenZoom
+		appFlags[w.App] = f
 	}
 	var appNames []string
 	for app := range appCounts {
@@ -410,34 +840,166 @@ func renderWindows(windows []Window, maxTitleLen int, productiveActivity map[str
 	for _, app := range appNames {
 		count := appCounts[app]
 		if count > 1 {
-			parts = append(parts, fmt.Sprintf("%s (%d)", app, count))
+			parts = append(parts, fmt.Sprintf("%s (%d)", withIcon(app), count)+renderWindowFlags(appFlags[app]))
 		} else {
-			parts = append(parts, app)
+			parts = append(parts, withIcon(app)+renderWindowFlags(appFlags[app]))
 		}
 	}
 
 	return strings.Join(parts, "  ")
 }
 
+// windowFlagMarks are the indicators renderWindowFlags uses, for windows
+// that don't move with their space the way the rest do.
+const (
+	markFloating   = "~" // floating over the tiling layout
+	markSticky     = "+" // shown on every space
+	markFullscreen = "F" // a native fullscreen space of its own
+	markZoomed     = "z" // zoomed to fill its space
+)
+
+// renderWindowFlags returns a window's indicators, dimmed and prefixed
+// with a space, or "" when it's an ordinary tiled window.
+func renderWindowFlags(w Window) string {
+	var marks string
+	if w.IsFloating {
+		marks += markFloating
+	}
+	if w.IsSticky {
+		marks += markSticky
+	}
+	if w.IsNativeFullscreen {
+		marks += markFullscreen
+	} else if w.HasFullscreenZoom {
+		marks += markZoomed
+	}
+	if marks == "" {
+		return ""
+	}
+	return " " + dimStyle.Render(marks)
+}
+
 // -- helpers --
 
-func renderHelp(multiDisplay bool) string {
+// renderPrompt is the interactive line above the help: a pending
+// confirmation or swap instructions, else the outcome of the last action,
+// else the marked spaces' keys.
+func (m model) renderPrompt() string {
+	switch {
+	case m.confirm != nil:
+		return warnStyle.Render(m.confirm.prompt+" ") +
+			keyStyle.Render("y") + helpStyle.Render("/") + keyStyle.Render("n")
+	case m.swapFrom != 0:
+		return dimStyle.Render(fmt.Sprintf("swapping space %d: move to the target, ", m.swapFrom)) +
+			keyStyle.Render(activeKeymap.label(actSwap)) + dimStyle.Render(" to pick, ") +
+			keyStyle.Render("esc") + dimStyle.Render(" to cancel")
+	case m.bookmarkPending != "":
+		return renderBookmarkPrompt(m.bookmarkPending, m.bookmarks)
+	case m.count > 0 || len(m.pendingKeys) > 0:
+		keys := m.pendingKeys
+		if m.count > 0 {
+			keys = append([]string{strconv.Itoa(m.count)}, keys...)
+		}
+		return keyStyle.Render(strings.Join(keys, " ")) + dimStyle.Render(" …")
+	}
+	if line := renderStatus(m.status, m.statusErr, m.statusAt, time.Now()); line != "" {
+		return line
+	}
+	if len(m.marks) > 0 {
+		return renderMarkedPrompt(len(markedRows(m.displayGroups, m.marks)))
+	}
+	return ""
+}
+
+// renderSLOLine summarizes response-time compliance, e.g.
+// "response slo 92% ≤10m · 24h (23/25)". empty until any wait has been
+// decided. green at or above 90%, yellow below.
+func renderSLOLine(r *sloReport) string {
+	if r == nil || r.total() == 0 {
+		return ""
+	}
+	pct := r.compliance() * 100
+	style := freeStyle
+	if pct < 90 {
+		style = warnStyle
+	}
+	return dimStyle.Render("response slo ") +
+		style.Render(fmt.Sprintf("%.0f%%", pct)) +
+		dimStyle.Render(fmt.Sprintf(" \u2264%s \u00b7 %s (%d/%d)",
+			humanDuration(r.Target), humanDuration(sloWindow), r.Met, r.total()))
+}
+
+// renderHelp lists the bound keys. display keys only show with more than
+// one display, and shift+digit only with exactly two, the one layout
+// where "the other display" means something.
+func renderHelp(displays int) string {
+	km := activeKeymap
+	bind := func(key, desc string) struct{ key, desc string } {
+		return struct{ key, desc string }{key, desc}
+	}
 	binds := []struct{ key, desc string }{
-		{"q", "quit"},
-		{"j/k", "navigate"},
+		bind(km.label(actQuit), "quit"),
+		bind(km.label(actDown, actUp), "navigate"),
 	}
-	if multiDisplay {
-		binds = append(binds, struct{ key, desc string }{"h/l", "display"})
+	if displays > 1 {
+		binds = append(binds, bind(km.label(actLeft, actRight), "display"))
+	}
+	binds = append(binds, bind(km.label(actFocus), "focus"))
+	binds = append(binds, bind(km.label(actDetail), "detail"))
+	if displays == 2 {
+		binds = append(binds, bind("1-9/\u21e71-9", "jump"))
+	} else {
+		binds = append(binds, bind("1-9", "jump"))
+	}
+	binds = append(binds, bind(km.label(actSwap), "swap"))
+	binds = append(binds, bind(km.label(actDestroy, actKill), "destroy/kill"))
+	binds = append(binds, bind(km.label(actMark, actMoveMarked), "mark/move marked"))
+	binds = append(binds, bind(km.label(actSetMark, actJumpMark), "bookmark/jump"))
+	binds = append(binds, bind(km.label(actRename), "rename"))
+	binds = append(binds, bind(km.label(actMirror), "mirror"))
+	binds = append(binds, bind(km.label(actFollow), "follow"))
+	binds = append(binds, bind(km.label(actAgents), "agents"))
+	binds = append(binds, bind(km.label(actDensity), "density"))
+	binds = append(binds, bind(km.label(actCompare), "compare"))
+	binds = append(binds, bind(km.label(actAck), "ack"))
+	binds = append(binds, bind(km.label(actHidden, actRestore), "hidden/restore"))
+	binds = append(binds, bind(km.label(actFold), "fold"))
+	binds = append(binds, bind(km.label(actZoom), "zoom"))
+	binds = append(binds, bind(km.label(actScratchpad), "scratchpad"))
+	binds = append(binds, bind(km.label(actTimer), "timer"))
+	binds = append(binds, bind(km.label(actSort, actPin), "sort/pin"))
+	binds = append(binds, bind(km.label(actLabel), "label"))
+	binds = append(binds, bind(km.label(actNew), "new"))
+	binds = append(binds, bind(km.label(actCleanup), "cleanup"))
+	if displays > 1 {
+		binds = append(binds, bind(km.label(actBalance), "balance"))
 	}
-	binds = append(binds, struct{ key, desc string }{"enter", "focus"})
 
 	var parts []string
 	for _, b := range binds {
+		if b.key == "" {
+			continue // action unbound in keyBindings
+		}
 		parts = append(parts, keyStyle.Render(b.key)+" "+helpStyle.Render(b.desc))
 	}
+	if line := renderScriptingAdditionNote(); line != "" {
+		parts = append(parts, line)
+	}
 	return strings.Join(parts, "  ")
 }
 
+// renderScriptingAdditionNote labels focusing's limits once yabai has
+// refused it for want of the scripting addition (engine/yabaisa.go).
+func renderScriptingAdditionNote() string {
+	if !engine.ScriptingAdditionMissing() {
+		return ""
+	}
+	if fallback := engine.FocusFallback(); fallback != "" {
+		return warnStyle.Render("no scripting addition") + dimStyle.Render(": focusing by desktop shortcut via "+fallback+", spaces 1-9 per display; destroy and create fail")
+	}
+	return warnStyle.Render("no scripting addition") + dimStyle.Render(": focus, destroy and create fail (sudo yabai --load-sa)")
+}
+
 func truncateStr(s string, maxLen int) string {
 	if maxLen < 4 {
 		maxLen = 4
@@ -525,23 +1087,75 @@ func groupPanesByWindow(panes []TmuxPane) []tmuxWindowGroup {
 	return groups
 }
 
-// stalenessStyle returns a color style reflecting how recently a pane had output.
-// five tiers: green (<1m) → yellow (<5m) → orange (<15m) → dark orange (<1h) → red (1h+)
-func stalenessStyle(lastActivity time.Time) lipgloss.Style {
-	age := time.Since(lastActivity)
-	if age < time.Minute {
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+// stalenessBand is one activity tier (the list lives in config.go).
+// color is filled in from the theme by applyTheme.
+type stalenessBand struct {
+	name  string
+	under time.Duration // upper bound on activity age; 0 on the last tier
+	role  string        // theme role coloring the tier; the name when empty
+	color string
+}
+
+// stalenessRoles are the theme roles a tier can be colored with.
+var stalenessRoles = []string{"active", "recent", "cooling", "stale", "cold"}
+
+func (t stalenessBand) colorRole() string {
+	if t.role != "" {
+		return t.role
 	}
-	if age < 5*time.Minute {
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	return t.name
+}
+
+// tier is the band as the engine classifies it.
+func (t stalenessBand) tier() engine.StalenessTier {
+	return engine.StalenessTier{Name: t.name, Under: t.under}
+}
+
+// checkStalenessTiers validates a tier list: the engine's structural
+// checks, plus colors drawn from the staleness roles.
+func checkStalenessTiers(bands []stalenessBand) error {
+	tiers := make([]engine.StalenessTier, len(bands))
+	for i, b := range bands {
+		tiers[i] = b.tier()
 	}
-	if age < 15*time.Minute {
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
+	if err := engine.CheckStalenessTiers(tiers); err != nil {
+		return err
 	}
-	if age < time.Hour {
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("202"))
+	for _, b := range bands {
+		if !slices.Contains(stalenessRoles, b.colorRole()) {
+			return fmt.Errorf("staleness tier %q: color role %q is not one of %s", b.name, b.colorRole(), strings.Join(stalenessRoles, ", "))
+		}
+	}
+	return nil
+}
+
+// stalenessTier returns the index into stalenessTiers for an activity time.
+func stalenessTier(lastActivity time.Time) int {
+	return stalenessTierFor(stalenessAge(lastActivity))
+}
+
+// stalenessTierFor is the tier for activity age old.
+func stalenessTierFor(age time.Duration) int {
+	tiers := make([]engine.StalenessTier, len(stalenessTiers))
+	for i, b := range stalenessTiers {
+		tiers[i] = b.tier()
+	}
+	return engine.ClassifyStaleness(tiers, age)
+}
+
+// stalenessStyle returns a color style reflecting how recently a pane had output.
+// with the default tiers and theme: green (<1m) → yellow (<5m) → orange
+// (<15m) → dark orange (<1h) → red (1h+)
+func stalenessStyle(lastActivity time.Time) lipgloss.Style {
+	return stalenessTiers[stalenessTier(lastActivity)].style()
+}
+
+// style is the tier's color.
+func (t stalenessBand) style() lipgloss.Style {
+	if t.color == "" {
+		return lipgloss.NewStyle() // themed without color
 	}
-	return lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(t.color))
 }
 
 // formatRelativeTime renders a duration since last activity as a compact string
@@ -603,6 +1217,38 @@ func filepathBase(p string) string {
 	return p
 }
 
+// renderAnnotation formats an external status string with its age, e.g.
+// "» awaiting review 3m". text is truncated so a chatty agent can't blow
+// out the column width.
+func renderAnnotation(a Annotation, maxLen int) string {
+	out := annotationStyle.Render("\u00bb " + truncateStr(a.Text, maxLen))
+	if !a.UpdatedAt.IsZero() {
+		out += " " + dimStyle.Render(formatRelativeTime(a.UpdatedAt))
+	}
+	return out
+}
+
+// progressBarWidth is the cell count of the inline progress bar. kept
+// small so it fits alongside the pane's command and activity age.
+const progressBarWidth = 8
+
+// renderProgressBar draws a compact bar for a pane-published percentage,
+// e.g. "▕████░░░░▏42%". the filled part uses the free color so finished
+// work reads as good news; the remainder stays dim.
+func renderProgressBar(pct int) string {
+	if pct < 0 {
+		pct = 0
+	}
+	if pct > 100 {
+		pct = 100
+	}
+	filled := pct * progressBarWidth / 100
+	return dimStyle.Render("\u2595") +
+		freeStyle.Render(strings.Repeat("\u2588", filled)) +
+		dimStyle.Render(strings.Repeat("\u2591", progressBarWidth-filled)+"\u258f") +
+		dimStyle.Render(fmt.Sprintf("%d%%", pct))
+}
+
 // formatHistorySize renders scroll buffer line count compactly
 func formatHistorySize(lines int) string {
 	if lines < 1000 {
@@ -617,12 +1263,18 @@ func formatHistorySize(lines int) string {
 // renderTmuxSessions renders tmux panes grouped by session with staleness
 // coloring, scroll buffer sizes, and time since last activity.
 // header is the section label (e.g. "tmux" or "detached").
-func renderTmuxSessions(panes []TmuxPane, header string, nvimBuffers map[int][]NvimBuffer, productivePanePIDs map[int]bool) string {
+func renderTmuxSessions(panes []TmuxPane, header string, rc renderContext) string {
 	if len(panes) == 0 {
 		return ""
 	}
 	sessions := groupPanesBySession(panes)
 
+	// high-priority sessions float to the top; stable so tmux's own order
+	// holds within a level
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return rc.priorities.forSession(sessions[i].name) > rc.priorities.forSession(sessions[j].name)
+	})
+
 	var b strings.Builder
 	b.WriteString("\n")
 	b.WriteString(dimStyle.Render(header))
@@ -631,7 +1283,16 @@ func renderTmuxSessions(panes []TmuxPane, header string, nvimBuffers map[int][]N
 	for _, session := range sessions {
 		b.WriteString("  ")
 		b.WriteString(session.name)
+		if !conformsSessionName(session.name) {
+			b.WriteString(" ")
+			b.WriteString(warnStyle.Render("~"))
+		}
+		if a, ok := rc.annotations.forSession(session.name); ok {
+			b.WriteString("  ")
+			b.WriteString(renderAnnotation(a, 60))
+		}
 		b.WriteString("\n")
+		sessionPrio := rc.priorities.forSession(session.name)
 
 		for _, window := range session.windows {
 			// window label — colored by best productive pane if any
@@ -639,7 +1300,7 @@ func renderTmuxSessions(panes []TmuxPane, header string, nvimBuffers map[int][]N
 			var bestProductive time.Time
 			windowHasProductive := false
 			for _, p := range window.panes {
-				if productivePanePIDs[p.PanePID] {
+				if rc.productivePanePIDs[p.PanePID] {
 					if !windowHasProductive || p.LastActivity.After(bestProductive) {
 						bestProductive = p.LastActivity
 						windowHasProductive = true
@@ -649,7 +1310,7 @@ func renderTmuxSessions(panes []TmuxPane, header string, nvimBuffers map[int][]N
 
 			b.WriteString("    ")
 			if windowHasProductive {
-				b.WriteString(stalenessStyle(bestProductive).Render(windowLabel))
+				b.WriteString(sessionPrio.stalenessStyle(bestProductive).Render(windowLabel))
 			} else {
 				b.WriteString(dimStyle.Render(windowLabel))
 			}
@@ -657,8 +1318,8 @@ func renderTmuxSessions(panes []TmuxPane, header string, nvimBuffers map[int][]N
 			// panes inline on the same line as the window header
 			for _, p := range window.panes {
 				style := dimStyle
-				if productivePanePIDs[p.PanePID] {
-					style = stalenessStyle(p.LastActivity)
+				if rc.productivePanePIDs[p.PanePID] {
+					style = sessionPrio.stalenessStyle(p.LastActivity)
 				}
 				timeStr := formatRelativeTime(p.LastActivity)
 				b.WriteString("  ")
@@ -666,13 +1327,25 @@ func renderTmuxSessions(panes []TmuxPane, header string, nvimBuffers map[int][]N
 				b.WriteString(" ")
 				b.WriteString(style.Render(p.CurrentCommand))
 				if p.CurrentCommand == "nvim" {
-					if extra := nvimBufferLabel(nvimBuffers[p.PanePID]); extra != "" {
+					if extra := nvimBufferLabel(rc.nvimBuffers[p.PanePID]); extra != "" {
 						b.WriteString(" ")
 						b.WriteString(dimStyle.Render(extra))
 					}
 				}
+				if p.Progress >= 0 {
+					b.WriteString(" ")
+					b.WriteString(renderProgressBar(p.Progress))
+				}
 				b.WriteString(" ")
 				b.WriteString(dimStyle.Render(timeStr))
+				if anomaly := renderSilenceAnomaly(rc, p); anomaly != "" {
+					b.WriteString(" ")
+					b.WriteString(anomaly)
+				}
+				if badges := renderPaneBadges(rc, p.PanePID); badges != "" {
+					b.WriteString(" ")
+					b.WriteString(badges)
+				}
 			}
 			b.WriteString("\n")
 		}
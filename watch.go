@@ -0,0 +1,450 @@
+// watch: headless stream of state for piping into other tools.
+//
+// `stop watch --json` refreshes on the TUI's cadence (and on the yabai
+// SIGUSR1 signal) and writes one JSON object per line to stdout: a
+// "snapshot" line carrying the same shape as /spaces, followed by an
+// "event" line for each change derived from the previous refresh — focus
+// moving, spaces appearing, disappearing or emptying, windows moving
+// between spaces, sessions opening, closing, attaching or detaching,
+// panes going stale, agents starting or stopping work. the same events
+// drive eventHooks (eventhooks.go), the TUI's flashes and serve's
+// /events stream (events.go).
+// NDJSON keeps it trivially consumable by jq or a read loop, no HTTP
+// server required. without --json only the events print, as text.
+// --events-only drops the snapshot lines for log collectors that only
+// want what changed; on a quiet desk that could mean writing nothing for
+// hours, and a reader that went away (`| head -1`) is only noticed by a
+// failed write, so a {"type":"heartbeat"} line goes out whenever the
+// stream has been silent for watchHeartbeat. --once prints one snapshot and exits, for an fzf
+// picker or a status bar that polls on its own.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// watch event kinds
+const (
+	watchFocusChanged  = "focus_changed"
+	watchSessionOpened = "session_opened"
+	watchSessionClosed = "session_closed"
+	watchAgentStatus   = "agent_status"
+	watchSpaceFreed    = "space_freed"    // a space's last window went away
+	watchAgentFinished = "agent_finished" // working → idle
+	watchAgentStale    = "agent_stale"    // reached the last staleness tier
+
+	watchSpaceCreated    = "space_created"
+	watchSpaceDestroyed  = "space_destroyed"
+	watchWindowMoved     = "window_moved"
+	watchWindowOpened    = "window_opened"
+	watchWindowClosed    = "window_closed"
+	watchSessionAttached = "session_attached" // a terminal client attached
+	watchSessionDetached = "session_detached" // its last client detached
+	watchPaneStale       = "pane_stale"       // any pane, into the last tier
+	watchSpaceMigrated   = "space_migrated"   // now on another display
+)
+
+// watchEvent is one derived change between two refreshes.
+type watchEvent struct {
+	Type     string `json:"type"` // always "event"
+	Event    string `json:"event"`
+	At       int64  `json:"at_ms"`
+	Space    int    `json:"space,omitempty"`
+	Display  string `json:"display,omitempty"`
+	Window   int    `json:"window,omitempty"`
+	App      string `json:"app,omitempty"`
+	Pane     string `json:"pane,omitempty"`
+	Previous string `json:"previous,omitempty"`
+	Session  string `json:"session,omitempty"`
+	Agent    string `json:"agent,omitempty"`
+	Command  string `json:"command,omitempty"`
+	Status   string `json:"status,omitempty"`
+}
+
+// String renders an event for the text output mode.
+func (e watchEvent) String() string {
+	ts := time.UnixMilli(e.At).Format("15:04:05")
+	switch e.Event {
+	case watchFocusChanged:
+		return fmt.Sprintf("%s  focus %s -> %d", ts, e.Previous, e.Space)
+	case watchAgentStatus:
+		return fmt.Sprintf("%s  %s %s -> %s (%s)", ts, e.Agent, e.Previous, e.Status, e.Command)
+	case watchSpaceFreed:
+		return fmt.Sprintf("%s  space %d freed", ts, e.Space)
+	case watchAgentFinished, watchAgentStale:
+		return fmt.Sprintf("%s  %s %s (%s)", ts, e.Event, e.Agent, e.Command)
+	case watchSpaceCreated, watchSpaceDestroyed:
+		return fmt.Sprintf("%s  %s %d", ts, e.Event, e.Space)
+	case watchSpaceMigrated:
+		return fmt.Sprintf("%s  space %d %s -> %s", ts, e.Space, e.Previous, e.Display)
+	case watchWindowMoved:
+		return fmt.Sprintf("%s  %s %d -> %d (%s)", ts, e.App, e.Window, e.Space, e.Previous)
+	case watchWindowOpened, watchWindowClosed:
+		return fmt.Sprintf("%s  %s %s %d (space %d)", ts, e.Event, e.App, e.Window, e.Space)
+	case watchPaneStale:
+		return fmt.Sprintf("%s  %s %s (%s)", ts, e.Event, e.Pane, e.Command)
+	}
+	return fmt.Sprintf("%s  %s %s", ts, e.Event, e.Session)
+}
+
+// watchState is the slice of a fetch that events are derived from.
+type watchState struct {
+	focusedSpace int
+	sessions     map[string]bool
+	agents       map[string]TmuxPane // agent fingerprint → pane
+	agentStatus  map[string]string   // agent fingerprint → working / idle
+	agentTier    map[string]int      // agent fingerprint → staleness tier
+	spaceWindows map[int]int         // space index → windows on it
+	spaceIndex   map[int]int         // space id → index
+	spaceDisplay map[int]string      // space id → displayKey
+	displayNames map[string]string   // displayKey → name
+	windows      map[int]Window      // window id → window
+	attached     map[string]bool     // sessions with a client
+	panes        map[string]TmuxPane // "session:window.pane" → pane
+	paneTier     map[string]int      // "session:window.pane" → staleness tier
+}
+
+// newWatchState extracts the comparable state from one fetch.
+func newWatchState(result fetchResult, now time.Time) watchState {
+	st := watchState{
+		sessions:     map[string]bool{},
+		agents:       map[string]TmuxPane{},
+		agentStatus:  map[string]string{},
+		agentTier:    map[string]int{},
+		spaceWindows: map[int]int{},
+		spaceIndex:   map[int]int{},
+		spaceDisplay: map[int]string{},
+		displayNames: map[string]string{},
+		windows:      map[int]Window{},
+		attached:     map[string]bool{},
+		panes:        map[string]TmuxPane{},
+		paneTier:     map[string]int{},
+	}
+	for _, s := range result.spaces {
+		if s.HasFocus {
+			st.focusedSpace = s.Index
+		}
+		st.spaceIndex[s.ID] = s.Index
+		st.spaceDisplay[s.ID] = displayKey(s.Display, result.displays)
+	}
+	for _, d := range result.displays {
+		g := displayGroup{index: d.Index, display: d}
+		st.displayNames[columnKey(g)] = displayName(g)
+	}
+	for _, w := range result.windows {
+		if w.Space > 0 {
+			st.windows[w.ID] = w
+		}
+	}
+	for _, c := range result.tmuxClients {
+		st.attached[c.SessionName] = true
+	}
+	for _, g := range buildDisplayGroups(result.spaces, result.windows, result.displays) {
+		for _, row := range g.spaces {
+			st.spaceWindows[row.space.Index] = len(row.windows)
+		}
+	}
+	for _, p := range result.tmuxPanes {
+		st.sessions[p.SessionName] = true
+		target := fmt.Sprintf("%s:%d.%d", p.SessionName, p.WindowIndex, p.PaneIndex)
+		st.panes[target] = p
+		st.paneTier[target] = stalenessTierFor(now.Sub(p.LastActivity))
+		if result.productivePanePIDs[p.PanePID] {
+			id := agentFingerprint(p)
+			st.agents[id] = p
+			st.agentStatus[id] = agentStatusFor(p, now)
+			st.agentTier[id] = stalenessTierFor(now.Sub(p.LastActivity))
+		}
+	}
+	return st
+}
+
+// diffWatchState lists what changed from prev to cur, in a stable order.
+func diffWatchState(prev, cur watchState, now time.Time) []watchEvent {
+	at := now.UnixMilli()
+	var events []watchEvent
+	if cur.focusedSpace != prev.focusedSpace {
+		events = append(events, watchEvent{
+			Event: watchFocusChanged, Space: cur.focusedSpace,
+			Previous: fmt.Sprintf("%d", prev.focusedSpace),
+		})
+	}
+	var created, destroyed []int
+	for id, idx := range cur.spaceIndex {
+		if _, ok := prev.spaceIndex[id]; !ok {
+			created = append(created, idx)
+		}
+	}
+	for id, idx := range prev.spaceIndex {
+		if _, ok := cur.spaceIndex[id]; !ok {
+			destroyed = append(destroyed, idx)
+		}
+	}
+	sort.Ints(created)
+	sort.Ints(destroyed)
+	for _, idx := range created {
+		events = append(events, watchEvent{Event: watchSpaceCreated, Space: idx})
+	}
+	for _, idx := range destroyed {
+		events = append(events, watchEvent{Event: watchSpaceDestroyed, Space: idx})
+	}
+	var migrated []int
+	for id, key := range cur.spaceDisplay {
+		if was, ok := prev.spaceDisplay[id]; ok && was != key {
+			migrated = append(migrated, id)
+		}
+	}
+	sort.Slice(migrated, func(i, j int) bool { return cur.spaceIndex[migrated[i]] < cur.spaceIndex[migrated[j]] })
+	for _, id := range migrated {
+		events = append(events, watchEvent{
+			Event: watchSpaceMigrated, Space: cur.spaceIndex[id],
+			Previous: prev.displayName(prev.spaceDisplay[id]), Display: cur.displayName(cur.spaceDisplay[id]),
+		})
+	}
+	for _, name := range sortedKeys(cur.sessions) {
+		if !prev.sessions[name] {
+			events = append(events, watchEvent{Event: watchSessionOpened, Session: name})
+		}
+	}
+	for _, name := range sortedKeys(prev.sessions) {
+		if !cur.sessions[name] {
+			events = append(events, watchEvent{Event: watchSessionClosed, Session: name})
+		}
+	}
+	for _, name := range sortedKeys(cur.attached) {
+		if !prev.attached[name] {
+			events = append(events, watchEvent{Event: watchSessionAttached, Session: name})
+		}
+	}
+	for _, name := range sortedKeys(prev.attached) {
+		// a session that closed outright already has its event
+		if !cur.attached[name] && cur.sessions[name] {
+			events = append(events, watchEvent{Event: watchSessionDetached, Session: name})
+		}
+	}
+	var opened, closed, moved []int
+	for id, w := range cur.windows {
+		was, ok := prev.windows[id]
+		switch {
+		case !ok:
+			opened = append(opened, id)
+		case was.Space != w.Space:
+			moved = append(moved, id)
+		}
+	}
+	for id := range prev.windows {
+		if _, ok := cur.windows[id]; !ok {
+			closed = append(closed, id)
+		}
+	}
+	sort.Ints(opened)
+	sort.Ints(closed)
+	sort.Ints(moved)
+	for _, id := range opened {
+		w := cur.windows[id]
+		events = append(events, watchEvent{Event: watchWindowOpened, Window: id, App: w.App, Space: w.Space})
+	}
+	for _, id := range closed {
+		w := prev.windows[id]
+		events = append(events, watchEvent{Event: watchWindowClosed, Window: id, App: w.App, Space: w.Space})
+	}
+	for _, id := range moved {
+		w := cur.windows[id]
+		events = append(events, watchEvent{
+			Event: watchWindowMoved, Window: id, App: w.App, Space: w.Space,
+			Previous: fmt.Sprintf("%d", prev.windows[id].Space),
+		})
+	}
+	var spaces []int
+	for idx, n := range cur.spaceWindows {
+		if n == 0 && prev.spaceWindows[idx] > 0 {
+			spaces = append(spaces, idx)
+		}
+	}
+	sort.Ints(spaces)
+	for _, idx := range spaces {
+		events = append(events, watchEvent{Event: watchSpaceFreed, Space: idx})
+	}
+
+	var ids []string
+	for id := range cur.agentStatus {
+		ids = append(ids, id)
+	}
+	for id := range prev.agentStatus {
+		if _, ok := cur.agentStatus[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		was, is := prev.agentStatus[id], cur.agentStatus[id]
+		if was == is {
+			continue
+		}
+		p, ok := cur.agents[id]
+		if !ok {
+			p = prev.agents[id]
+			is = agentGone
+		}
+		events = append(events, watchEvent{
+			Event: watchAgentStatus, Agent: id, Session: p.SessionName,
+			Command: p.CurrentCommand, Status: is, Previous: was,
+		})
+		if was == agentWorking && (is == agentIdle || is == agentSeen) {
+			events = append(events, watchEvent{
+				Event: watchAgentFinished, Agent: id, Session: p.SessionName,
+				Command: p.CurrentCommand, Status: is, Previous: was,
+			})
+		}
+	}
+
+	// "went red": crossed into the last tier since the previous refresh
+	red := len(stalenessTiers) - 1
+	for _, id := range ids {
+		tier, ok := cur.agentTier[id]
+		if was, seen := prev.agentTier[id]; !ok || !seen || tier != red || was == red {
+			continue
+		}
+		p := cur.agents[id]
+		events = append(events, watchEvent{
+			Event: watchAgentStale, Agent: id, Session: p.SessionName,
+			Command: p.CurrentCommand, Status: stalenessTiers[tier].name,
+		})
+	}
+
+	var targets []string
+	for target, tier := range cur.paneTier {
+		if was, ok := prev.paneTier[target]; ok && tier == red && was != red {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		p := cur.panes[target]
+		events = append(events, watchEvent{
+			Event: watchPaneStale, Pane: target, Session: p.SessionName,
+			Command: p.CurrentCommand, Status: stalenessTiers[red].name,
+		})
+	}
+
+	for i := range events {
+		events[i].Type = "event"
+		events[i].At = at
+	}
+	return events
+}
+
+// displayName names a display by key, falling back to the key.
+func (st watchState) displayName(key string) string {
+	if name, ok := st.displayNames[key]; ok {
+		return name
+	}
+	return "display " + key
+}
+
+// sortedKeys returns a set's members in order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// watchHeartbeat is how long a JSON stream stays silent before a
+// heartbeat line, which is also what notices a closed stdout.
+const watchHeartbeat = 10 * time.Second
+
+// watchOptions picks what `stop watch` emits.
+type watchOptions struct {
+	interval   time.Duration
+	jsonOut    bool          // NDJSON snapshots and events instead of text events
+	hooks      bool          // run eventHooks on each refresh's events
+	once       bool          // one snapshot, then exit — for pickers and status bars
+	eventsOnly bool          // leave the snapshots out of the JSON stream
+	heartbeat  time.Duration // JSON silence before a heartbeat line; 0 for none
+}
+
+// watchCommand is the entry point for `stop watch`. runs until killed.
+func watchCommand(opts watchOptions) error {
+	opts.heartbeat = watchHeartbeat
+	return watchStream(os.Stdout, fetchAll, opts)
+}
+
+// watchStream refreshes through fetch and writes to w until w closes.
+// the first refresh only establishes a baseline, so it emits a snapshot
+// but no events. with once it stops right there (printing the snapshot
+// even without --json, since there are no events to print).
+// with hooks, each refresh's events also run eventHooks, in the
+// background so a slow hook never delays the stream. a JSON stream
+// silent for opts.heartbeat writes a heartbeat line.
+func watchStream(w io.Writer, fetch func() fetchResult, opts watchOptions) error {
+	enc := json.NewEncoder(w)
+	var prev *watchState
+	lastWrite := time.Now()
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	for {
+		result := fetch()
+		now := time.Now()
+		if result.err != nil {
+			if opts.once {
+				return result.err
+			}
+			fmt.Fprintf(os.Stderr, "watch: %v\n", result.err)
+		} else {
+			cur := newWatchState(result, now)
+			if opts.once || (opts.jsonOut && !opts.eventsOnly) {
+				snapshot := buildSpacesResponse(result)
+				snapshot["type"] = "snapshot"
+				if err := enc.Encode(snapshot); err != nil {
+					return err // stdout closed, e.g. the pipe reader exited
+				}
+				lastWrite = now
+			}
+			if opts.once {
+				return nil
+			}
+			if prev != nil {
+				events := diffWatchState(*prev, cur, now)
+				if opts.hooks && len(events) > 0 {
+					go func() {
+						for _, err := range runEventHooks(events) {
+							fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+						}
+					}()
+				}
+				for _, ev := range events {
+					if opts.jsonOut {
+						if err := enc.Encode(ev); err != nil {
+							return err
+						}
+					} else if _, err := fmt.Fprintln(w, ev); err != nil {
+						return err
+					}
+					lastWrite = now
+				}
+			}
+			prev = &cur
+		}
+		if opts.jsonOut && opts.heartbeat > 0 && now.Sub(lastWrite) >= opts.heartbeat {
+			if err := enc.Encode(map[string]any{"type": "heartbeat", "at_ms": now.UnixMilli()}); err != nil {
+				return err
+			}
+			lastWrite = now
+		}
+
+		select {
+		case <-ticker.C:
+		case <-signalCh:
+		}
+	}
+}
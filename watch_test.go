@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffWatchState(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	agent := TmuxPane{SessionName: "api", PanePID: 10, CurrentCommand: "claude", LastActivity: now}
+	first := newWatchState(fetchResult{
+		spaces:             []Space{{Index: 1, HasFocus: true}, {Index: 2}},
+		tmuxPanes:          []TmuxPane{agent, {SessionName: "scratch", PanePID: 11}},
+		productivePanePIDs: map[int]bool{10: true},
+	}, now)
+
+	// focus moved, scratch closed, web opened, the agent went quiet
+	later := now.Add(5 * time.Minute)
+	second := newWatchState(fetchResult{
+		spaces:             []Space{{Index: 1}, {Index: 2, HasFocus: true}},
+		tmuxPanes:          []TmuxPane{agent, {SessionName: "web", PanePID: 12}},
+		productivePanePIDs: map[int]bool{10: true},
+	}, later)
+
+	events := diffWatchState(first, second, later)
+	var kinds []string
+	for _, ev := range events {
+		kinds = append(kinds, ev.Event)
+		if ev.Type != "event" || ev.At != later.UnixMilli() {
+			t.Errorf("event not stamped: %+v", ev)
+		}
+	}
+	want := []string{watchFocusChanged, watchSessionOpened, watchSessionClosed, watchAgentStatus, watchAgentFinished}
+	if len(kinds) != len(want) {
+		t.Fatalf("events = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("events = %v, want %v", kinds, want)
+		}
+	}
+	if last := events[3]; last.Previous != agentWorking || last.Status != agentIdle {
+		t.Errorf("agent transition = %+v", last)
+	}
+	if len(diffWatchState(second, second, later)) != 0 {
+		t.Error("identical states should produce no events")
+	}
+}
+
+func TestDiffWatchStateFreedAndStale(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	agent := TmuxPane{SessionName: "api", PanePID: 10, CurrentCommand: "claude", LastActivity: now}
+	spaces := []Space{{Index: 1, HasFocus: true}, {Index: 2}}
+	first := newWatchState(fetchResult{
+		spaces:             spaces,
+		windows:            []Window{{ID: 1, App: "kitty", Space: 2}},
+		tmuxPanes:          []TmuxPane{agent},
+		productivePanePIDs: map[int]bool{10: true},
+	}, now.Add(10*time.Minute))
+
+	// space 2's window closed and the agent has been quiet past the last tier
+	later := now.Add(2 * time.Hour)
+	second := newWatchState(fetchResult{
+		spaces:             spaces,
+		tmuxPanes:          []TmuxPane{agent},
+		productivePanePIDs: map[int]bool{10: true},
+	}, later)
+
+	var freed, stale []watchEvent
+	for _, ev := range diffWatchState(first, second, later) {
+		switch ev.Event {
+		case watchSpaceFreed:
+			freed = append(freed, ev)
+		case watchAgentStale:
+			stale = append(stale, ev)
+		}
+	}
+	if len(freed) != 1 || freed[0].Space != 2 {
+		t.Errorf("freed = %+v", freed)
+	}
+	if len(stale) != 1 || stale[0].Session != "api" || stale[0].Command != "claude" {
+		t.Errorf("stale = %+v", stale)
+	}
+	if events := diffWatchState(second, second, later.Add(time.Hour)); len(events) != 0 {
+		t.Errorf("still stale should not fire again: %+v", events)
+	}
+}
+
+func TestDiffWatchStateSpacesWindowsClients(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	panes := []TmuxPane{{SessionName: "api", LastActivity: now}, {SessionName: "web", LastActivity: now}}
+	first := newWatchState(fetchResult{
+		spaces:      []Space{{ID: 10, Index: 1, HasFocus: true}, {ID: 11, Index: 2}},
+		windows:     []Window{{ID: 1, App: "kitty", Space: 1}, {ID: 2, App: "Slack", Space: 1}},
+		tmuxPanes:   panes,
+		tmuxClients: []TmuxClient{{PID: 5, SessionName: "api"}},
+	}, now)
+
+	// space 11 destroyed, 12 created, the kitty window moved onto it and
+	// the terminal switched from api to web; slack quit, safari opened
+	second := newWatchState(fetchResult{
+		spaces:      []Space{{ID: 10, Index: 1, HasFocus: true}, {ID: 12, Index: 2}},
+		windows:     []Window{{ID: 1, App: "kitty", Space: 2}, {ID: 3, App: "Safari", Space: 2}},
+		tmuxPanes:   panes,
+		tmuxClients: []TmuxClient{{PID: 5, SessionName: "web"}},
+	}, now)
+
+	var kinds []string
+	events := diffWatchState(first, second, now)
+	for _, ev := range events {
+		kinds = append(kinds, ev.Event)
+	}
+	want := []string{watchSpaceCreated, watchSpaceDestroyed, watchSessionAttached, watchSessionDetached, watchWindowOpened, watchWindowClosed, watchWindowMoved, watchSpaceFreed}
+	if strings.Join(kinds, " ") != strings.Join(want, " ") {
+		t.Fatalf("events = %v, want %v", kinds, want)
+	}
+	if opened, closed := events[4], events[5]; opened.Window != 3 || opened.Space != 2 || closed.Window != 2 || closed.Space != 1 {
+		t.Errorf("opened = %+v, closed = %+v", opened, closed)
+	}
+	if moved := events[6]; moved.Window != 1 || moved.Space != 2 || moved.Previous != "1" || moved.App != "kitty" {
+		t.Errorf("moved = %+v", moved)
+	}
+	if events[2].Session != "web" || events[3].Session != "api" {
+		t.Errorf("attach = %+v, detach = %+v", events[2], events[3])
+	}
+}
+
+// closingWriter takes n writes, then fails like a closed pipe.
+type closingWriter struct {
+	bytes.Buffer
+	n int
+}
+
+func (w *closingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("closed")
+	}
+	w.n--
+	return w.Buffer.Write(p)
+}
+
+func TestWatchStreamOnce(t *testing.T) {
+	var out bytes.Buffer
+	desk := newDemoDesk(time.Now(), demoSeed)
+	fetch := func() fetchResult { return fetchFrom(desk, desk) }
+	if err := watchStream(&out, fetch, watchOptions{interval: time.Hour, once: true}); err != nil {
+		t.Fatal(err)
+	}
+	var snapshot map[string]any
+	if err := json.Unmarshal(out.Bytes(), &snapshot); err != nil {
+		t.Fatalf("not one JSON line: %v\n%s", err, out.String())
+	}
+	if snapshot["type"] != "snapshot" {
+		t.Errorf("type = %v, want snapshot", snapshot["type"])
+	}
+
+	failing := func() fetchResult { return fetchResult{err: errors.New("no yabai")} }
+	if err := watchStream(&out, failing, watchOptions{interval: time.Hour, once: true}); err == nil {
+		t.Error("once hid a failed fetch")
+	}
+}
+
+func TestWatchStreamEventsOnly(t *testing.T) {
+	results := []fetchResult{
+		{spaces: []Space{{Index: 1, HasFocus: true}, {Index: 2}}},
+		{spaces: []Space{{Index: 1}, {Index: 2, HasFocus: true}}},
+	}
+	calls := 0
+	fetch := func() fetchResult {
+		r := results[min(calls, len(results)-1)]
+		calls++
+		return r
+	}
+	// the desk goes quiet after the focus change: the heartbeat is what
+	// finds the reader gone
+	w := &closingWriter{n: 1}
+	err := watchStream(w, fetch, watchOptions{interval: time.Millisecond, jsonOut: true, eventsOnly: true, heartbeat: 50 * time.Millisecond})
+	if err == nil {
+		t.Fatal("the stream outlived its reader")
+	}
+	var ev watchEvent
+	if err := json.Unmarshal(w.Bytes(), &ev); err != nil {
+		t.Fatalf("not one JSON line: %v\n%s", err, w.String())
+	}
+	if ev.Type != "event" || ev.Event != watchFocusChanged {
+		t.Errorf("first line = %+v, want the focus change", ev)
+	}
+}
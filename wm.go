@@ -0,0 +1,57 @@
+// window manager selection.
+//
+// the backends live in the engine package; stop picks one per process:
+// whatever engine.DetectWindowManager finds, or none at all with
+// --no-yabai, which leaves the tmux half of the dashboard. --replay and
+// --demo swap the machine out entirely: the window manager, tmux and ps
+// all answer from a recording (fixture.go) or a generated desk (demo.go).
+
+package main
+
+import (
+	"sync"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// tmuxOnly skips the window manager entirely (--no-yabai); set before the
+// first currentWM call.
+var tmuxOnly bool
+
+var (
+	wmOnce   sync.Once
+	activeWM engine.WindowManager
+)
+
+// simulated stands in for the whole machine — a recording played back
+// with --replay (fixture.go) or the --demo desk (demo.go) — nil when
+// live; set before the first currentWM call.
+var simulated interface {
+	engine.WindowManager
+	engine.Querier
+}
+
+// currentWM returns the backend for this process, detecting it on first use.
+func currentWM() engine.WindowManager {
+	wmOnce.Do(func() {
+		if simulated != nil {
+			activeWM = simulated
+			return
+		}
+		if tmuxOnly {
+			activeWM = engine.NoWindowManager{}
+			return
+		}
+		activeWM = engine.DetectWindowManager()
+	})
+	return activeWM
+}
+
+// currentQuerier is where tmux and ps are read from: the simulation
+// when there is one, this machine otherwise.
+func currentQuerier() engine.Querier {
+	if simulated != nil {
+		return simulated
+	}
+	return engine.Local{}
+}
@@ -0,0 +1,256 @@
+// workspace: start a new piece of work from a template.
+//
+// a workspace template (workspaceTemplates, config.go) describes a tmux
+// session — the directory it works in, its windows, each window's panes
+// and the command each pane starts with, like an agent in one and a shell
+// in the other. `stop new <template>`, or N in the TUI for
+// defaultWorkspaceTemplate, builds the session, makes a fresh space on
+// the focused display, labels it after the session, and opens a terminal
+// there attached to it (workspaceTerminal). so a new agent workspace is
+// one command rather than a space, a terminal, a tmux session and a few
+// splits by hand.
+//
+// commands go to a pane as keystrokes, so the pane keeps its shell when
+// the command exits. a session name that's taken gets a number added.
+// without a window manager the session and terminal are still made.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fadedlamp42/stop/engine"
+)
+
+// workspaceTemplate is one kind of workspace.
+type workspaceTemplate struct {
+	dir     string           // where the session starts; ~ is the home directory
+	windows []templateWindow // in order; none gives one window with a shell
+}
+
+// templateWindow is one tmux window of a template.
+type templateWindow struct {
+	name   string
+	panes  []string // each pane's starting command, "" for just a shell
+	layout string   // a tmux layout for the panes, e.g. "main-vertical"
+}
+
+// templateNames lists the configured templates in order.
+func templateNames() []string {
+	names := make([]string, 0, len(workspaceTemplates))
+	for name := range workspaceTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultTemplate is the template N uses: defaultWorkspaceTemplate, or
+// the first by name.
+func defaultTemplate() string {
+	if _, ok := workspaceTemplates[defaultWorkspaceTemplate]; ok {
+		return defaultWorkspaceTemplate
+	}
+	if names := templateNames(); len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+// expandHome resolves a leading ~.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// uniqueSessionName is name, or name-2, name-3, ... when taken.
+func uniqueSessionName(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+	for n := 2; ; n++ {
+		if candidate := fmt.Sprintf("%s-%d", name, n); !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// templateTmuxCommands are the tmux invocations that build a session from
+// a template. each one targets the session's current window and pane,
+// which is always the one just made.
+func templateTmuxCommands(t workspaceTemplate, session, dir string) [][]string {
+	windows := t.windows
+	if len(windows) == 0 {
+		windows = []templateWindow{{panes: []string{""}}}
+	}
+	target := "=" + session + ":"
+	var cmds [][]string
+	for i, w := range windows {
+		create := []string{"new-window", "-t", target, "-c", dir}
+		if i == 0 {
+			create = []string{"new-session", "-d", "-s", session, "-c", dir}
+		}
+		if w.name != "" {
+			create = append(create, "-n", w.name)
+		}
+		cmds = append(cmds, create)
+		for j, command := range w.panes {
+			if j > 0 {
+				cmds = append(cmds, []string{"split-window", "-t", target, "-c", dir})
+			}
+			if command != "" {
+				cmds = append(cmds, []string{"send-keys", "-t", target, command, "Enter"})
+			}
+		}
+		if w.layout != "" {
+			cmds = append(cmds, []string{"select-layout", "-t", target, w.layout})
+		}
+	}
+	if len(windows) > 1 {
+		cmds = append(cmds, []string{"select-window", "-t", target + "^"})
+	}
+	return cmds
+}
+
+// terminalCommand fills {session} and {dir} into workspaceTerminal.
+func terminalCommand(session, dir string) []string {
+	r := strings.NewReplacer("{session}", session, "{dir}", dir)
+	args := make([]string, len(workspaceTerminal))
+	for i, a := range workspaceTerminal {
+		args[i] = r.Replace(a)
+	}
+	return args
+}
+
+// runWorkspaceCommand runs one command of the build, guarded.
+func runWorkspaceCommand(name string, args ...string) error {
+	if err := engine.Guard(name, args...); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	engine.LogCommand(start, name, args, err)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// newWorkspace builds a workspace from a template and returns the session
+// it made. name and dir, when set, override the template's.
+func newWorkspace(template, name, dir string) (string, error) {
+	t, ok := workspaceTemplates[template]
+	if !ok {
+		return "", fmt.Errorf("no workspace template %q (have %s)", template, strings.Join(templateNames(), ", "))
+	}
+	if name == "" {
+		name = template
+	}
+	if dir == "" {
+		dir = t.dir
+	}
+	dir = expandHome(dir)
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	panes, err := currentQuerier().QueryTmuxPanes()
+	if err != nil {
+		panes = nil // no server yet: every name is free
+	}
+	taken := map[string]bool{}
+	for _, p := range panes {
+		taken[p.SessionName] = true
+	}
+	session := uniqueSessionName(name, taken)
+	for _, args := range templateTmuxCommands(t, session, dir) {
+		if err := runWorkspaceCommand("tmux", args...); err != nil {
+			return "", err
+		}
+	}
+
+	// a space of its own on the focused display, where the terminal opens
+	if err := newWorkspaceSpace(session); err != nil && !errors.Is(err, engine.ErrNoWindowManager) {
+		return session, fmt.Errorf("session %s is up, but: %w", session, err)
+	}
+	if args := terminalCommand(session, dir); len(args) > 0 {
+		if err := engine.Guard(args[0], args[1:]...); err != nil {
+			return session, err
+		}
+		if err := exec.Command(args[0], args[1:]...).Start(); err != nil {
+			return session, fmt.Errorf("session %s is up, but opening a terminal failed: %w", session, err)
+		}
+	}
+	return session, nil
+}
+
+// newWorkspaceSpace makes, focuses and labels the workspace's space.
+func newWorkspaceSpace(session string) error {
+	wm := currentWM()
+	spaces, err := wm.QuerySpaces()
+	if err != nil {
+		return err
+	}
+	display := 1
+	for _, s := range spaces {
+		if s.HasFocus {
+			display = s.Display
+		}
+	}
+	index, err := wm.CreateSpace(display)
+	if err != nil {
+		return fmt.Errorf("creating a space: %w", err)
+	}
+	if err := switchSpace(wm, index); err != nil {
+		return fmt.Errorf("focusing space %d: %w", index, err)
+	}
+	if labelable(session) {
+		// a label already in use elsewhere just stays there
+		wm.LabelSpace(index, session)
+	}
+	return nil
+}
+
+// newCommand is the entry point for `stop new`.
+func newCommand(template, name, dir string) error {
+	if template == "" {
+		template = defaultTemplate()
+	}
+	if template == "" {
+		return fmt.Errorf("no workspace templates; add some to workspaceTemplates")
+	}
+	session, err := newWorkspace(template, name, dir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("started %s from %s\n", session, template)
+	return nil
+}
+
+// newWorkspaceCmd is N's action.
+func newWorkspaceCmd(template string) tea.Cmd {
+	return func() tea.Msg {
+		session, err := newWorkspace(template, "", "")
+		if err != nil {
+			return actionDoneMsg{status: "new workspace failed", err: err}
+		}
+		return actionDoneMsg{status: fmt.Sprintf("started %s from %s", session, template)}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fadedlamp42/stop/engine"
+)
+
+func TestTemplateTmuxCommands(t *testing.T) {
+	tmpl := workspaceTemplate{windows: []templateWindow{
+		{name: "agent", panes: []string{"claude"}},
+		{name: "shell", panes: []string{"", "make watch"}, layout: "even-horizontal"},
+	}}
+	var got []string
+	for _, args := range templateTmuxCommands(tmpl, "api", "/src/api") {
+		got = append(got, strings.Join(args, " "))
+	}
+	want := []string{
+		"new-session -d -s api -c /src/api -n agent",
+		"send-keys -t =api: claude Enter",
+		"new-window -t =api: -c /src/api -n shell",
+		"split-window -t =api: -c /src/api",
+		"send-keys -t =api: make watch Enter",
+		"select-layout -t =api: even-horizontal",
+		"select-window -t =api:^",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// no windows: a single shell
+	if cmds := templateTmuxCommands(workspaceTemplate{}, "x", "/tmp"); len(cmds) != 1 || cmds[0][0] != "new-session" {
+		t.Errorf("empty template = %v", cmds)
+	}
+}
+
+func TestUniqueSessionName(t *testing.T) {
+	taken := map[string]bool{"agent": true, "agent-2": true}
+	if got := uniqueSessionName("agent", taken); got != "agent-3" {
+		t.Errorf("got %q, want agent-3", got)
+	}
+	if got := uniqueSessionName("web", taken); got != "web" {
+		t.Errorf("got %q, want web", got)
+	}
+}
+
+func TestTerminalCommand(t *testing.T) {
+	got := strings.Join(terminalCommand("api", "/src/api"), " ")
+	if !strings.Contains(got, "/src/api") || !strings.Contains(got, "=api") || strings.Contains(got, "{") {
+		t.Errorf("terminal command = %q", got)
+	}
+}
+
+func TestNewWorkspaceSpace(t *testing.T) {
+	fake := &engine.Fake{Spaces: []Space{{Index: 1, Display: 1}, {Index: 2, Display: 2, HasFocus: true}}}
+	simulated = fake
+	defer func() { simulated = nil }()
+	wmOnce = sync.Once{}
+	defer func() { wmOnce = sync.Once{} }()
+
+	if err := newWorkspaceSpace("api"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"create-space 2", "focus-space 3", "label-space 3 api"}
+	if got := fake.Calls(); !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+// zoom: one display's column across the whole terminal.
+//
+// o zooms into the cursor's display: its column takes the full width,
+// the other columns and the detail panel step aside, and every row
+// carries the most it can — window titles in full rather than cut to
+// the column, and a line per tmux pane with its directory and
+// scrollback, as expanded density shows them (density.go). h / l still
+// move between displays, taking the zoom along; o again or esc goes back
+// to the grid.
+
+package main
+
+// zoomTitleLen is the title length a zoomed column allows: long enough
+// that nothing is cut, since the column wraps what doesn't fit.
+const zoomTitleLen = 1 << 12
+
+// toggleZoom zooms into the cursor's display, or back out.
+func (m model) toggleZoom() model {
+	m.zoom = !m.zoom && len(m.displayGroups) > 0
+	return m
+}
+
+// zoomed is the context a zoomed column renders with.
+func (rc renderContext) zoomed() renderContext {
+	rc.zoom = true
+	rc.density = densityExpanded
+	return rc
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestZoomShowsOneColumnInFull(t *testing.T) {
+	result := fixtureResult(t, "desk.json")
+	long := "a release checklist with every step spelled out so it runs well past a column"
+	result.windows = append(result.windows, Window{ID: 106, App: "Firefox", Title: long, Space: 1})
+	next, _ := newModel().handleData(result)
+	m := next.(model)
+	m.width, m.height = 160, 50
+	if strings.Contains(m.renderView(), long) {
+		t.Fatal("title shown in full before zooming")
+	}
+
+	next, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	m = next.(model)
+	view := m.renderView()
+	if !m.zoom || !strings.Contains(view, long) || !strings.Contains(view, "api") {
+		t.Errorf("zoomed view is missing the built-in column in full:\n%s", view)
+	}
+	if strings.Contains(view, "Slack") {
+		t.Errorf("zoomed view shows the other display:\n%s", view)
+	}
+
+	next, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+	if m = next.(model); m.zoom || !strings.Contains(m.renderView(), "Slack") {
+		t.Error("esc didn't restore the grid")
+	}
+}